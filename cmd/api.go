@@ -11,17 +11,8 @@ import (
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/database/postgres"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
-	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/repository"
-	accounttransaction "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/account_transaction"
-	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/bank"
-	bankbranch "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/bank_branch"
-	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/currency"
-	financialaccount "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/financial_account"
-	financialcard "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/financial_card"
-	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/user"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/log"
-	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/utils"
 	"github.com/urfave/cli/v2"
 	"go.uber.org/automaxprocs/maxprocs"
 	"go.uber.org/zap"
@@ -94,30 +85,29 @@ func api(_ *cli.Context) (err error) {
 		}
 	}()
 
-	userRepo := repository.NewUser(postgresDB)
-	bankRepo := repository.NewBank(postgresDB)
-	bankBranchRepo := repository.NewBankBranch(postgresDB)
-	financialCardRepo := repository.NewFinancialCard(postgresDB)
-	currencyRepo := repository.NewCurrency(postgresDB)
-	financialAccountRepo := repository.NewFinancialAccount(postgresDB)
-	accountTransactionRepo := repository.NewAccountTransaction(postgresDB)
-
-	// Create instances of BcryptHasher and JWTTokenGenerator
-	hasher := utils.BcryptHasher{}
-	tokenGenerator := utils.JWTTokenGenerator{}
-	userService := user.New(cfg.JWT, logger, userRepo, hasher, tokenGenerator)
-	bankService := bank.New(cfg.JWT, logger, bankRepo, tokenGenerator)
-	currencyService := currency.New(cfg.JWT, logger, tokenGenerator, currencyRepo)
-	bankBranchService := bankbranch.New(cfg.JWT, logger, bankBranchRepo, tokenGenerator, bankService)
-	financialAccountService := financialaccount.New(cfg.JWT, logger,
-		tokenGenerator,
-		financialAccountRepo,
-		bankService,
-		bankBranchService,
-		userService,
-		currencyService)
-	accountTransactionService := accounttransaction.New(cfg.JWT, logger, tokenGenerator, financialAccountService, accountTransactionRepo)
-	financialCardService := financialcard.New(cfg.JWT, logger, financialCardRepo, tokenGenerator, financialAccountService)
+	svc, err := newServices(cfg, logger, postgresDB)
+	if err != nil {
+		return err
+	}
+
+	// The background jobs can run here or in their own scheduler process
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	if cfg.Scheduler.RunInAPI {
+		go func() {
+			defer close(schedulerDone)
+			_ = runBackgroundJobs(schedulerCtx, svc)
+		}()
+	} else {
+		close(schedulerDone)
+	}
+
+	defer func() {
+		fmt.Println("scheduler shutdown start")
+		defer fmt.Println("scheduler shutdown complete")
+		stopScheduler()
+		<-schedulerDone
+	}()
 
 	// Make a channel to listen for an interrupt or terminate signal from the OS.
 	// Use a buffered channel because the signal package requires it.
@@ -128,13 +118,22 @@ func api(_ *cli.Context) (err error) {
 	serverConfig := http.ServerConfig{
 		Logger:               logger,
 		Config:               cfg.HTTP,
-		UserService:          userService,
-		BankService:          bankService,
-		BankBranchService:    bankBranchService,
-		FinancialCardService: financialCardService,
-		CurrencyService:      currencyService,
-		FinancialAccount:     financialAccountService,
-		AccountTransaction:   accountTransactionService,
+		UserService:          svc.user,
+		BankService:          svc.bank,
+		BankBranchService:    svc.bankBranch,
+		FinancialCardService: svc.financialCard,
+		CurrencyService:      svc.currency,
+		FinancialAccount:     svc.financialAccount,
+		AccountTransaction:   svc.accountTransaction,
+		Idempotency:          svc.idempotency,
+		AccountRules:         svc.accountRules,
+		Review:               svc.review,
+		ScheduledTransfer:    svc.scheduledTransfer,
+		CardTransaction:      svc.cardTransaction,
+		GiftCard:             svc.giftCard,
+		CreditCard:           svc.creditCard,
+		TwoFactor:            svc.twoFactor,
+		Authorizer:           svc.authorization,
 	}
 	httpServer = http.New(serverConfig)
 
@@ -14,7 +14,7 @@ var (
 		Action: func(*cli.Context) error {
 			return nil
 		},
-		Commands: []*cli.Command{apiCommand},
+		Commands: []*cli.Command{apiCommand, schedulerCommand, vaultCardsCommand},
 	}
 	banner = `▌║█║▌│║▌│║▌║▌█║ Digital-Wallet ▌│║▌║▌│║║▌█║▌║█`
 )
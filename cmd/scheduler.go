@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/database/postgres"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/log"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+var schedulerCommand = &cli.Command{
+	Name:        "scheduler",
+	Description: "executing due scheduled transfers, expiring stale card holds and expired gift cards, running the credit card statement cycle and renewing expiring cards",
+	Action:      runScheduler,
+}
+
+func runScheduler(_ *cli.Context) (err error) {
+	fmt.Println("starting scheduler")
+	defer fmt.Println("scheduler shutdown complete")
+
+	cfg, err := config.New()
+	if err != nil {
+		return fmt.Errorf("loading application config : %w", err)
+	}
+
+	logger, err := log.New("digital-wallet-scheduler", log.Config{
+		OutputPaths:       cfg.Logger.OutputPaths,
+		ErrorOutputPaths:  cfg.Logger.ErrorOutputPaths,
+		DisableStacktrace: cfg.Logger.DisableStacktrace,
+		Level:             cfg.Logger.Level,
+	})
+	if err != nil {
+		return fmt.Errorf("initial log: %w", err)
+	}
+
+	defer func(logger *zap.SugaredLogger) {
+		_ = logger.Sync()
+	}(logger)
+
+	postgresDB, err := postgres.New(cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+
+	defer func() {
+		if derr := postgresDB.Close(); derr != nil && err == nil {
+			err = fmt.Errorf("closing postgres connections: %w", derr)
+		}
+	}()
+
+	svc, err := newServices(cfg, logger, postgresDB)
+	if err != nil {
+		return err
+	}
+
+	// Run until an interrupt or terminate signal; the batch in progress is finished first
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return runBackgroundJobs(ctx, svc)
+}
+
+// runBackgroundJobs runs the scheduled transfer executor, the card hold sweeper, the gift
+// card breakage sweeper, the credit card statement cycle, the card renewal job and the token
+// denylist purge until ctx is cancelled and each has finished what it was doing.
+func runBackgroundJobs(ctx context.Context, svc *services) error {
+	jobs := []func(context.Context) error{
+		svc.scheduledTransfer.Run,
+		svc.cardTransaction.RunHoldSweeper,
+		svc.giftCard.RunBreakageSweeper,
+		svc.creditCard.RunStatementCycle,
+		svc.financialCard.RunCardRenewal,
+		svc.user.RunDenylistPurge,
+	}
+
+	errs := make(chan error, len(jobs))
+	for _, job := range jobs {
+		go func(job func(context.Context) error) {
+			errs <- job(ctx)
+		}(job)
+	}
+
+	var err error
+	for range jobs {
+		if jerr := <-errs; jerr != nil && err == nil {
+			err = jerr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/repository"
+	accountrules "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/account_rules"
+	accounttransaction "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/account_transaction"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/authorization"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/bank"
+	bankbranch "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/bank_branch"
+	cardtransaction "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/card_transaction"
+	cardvault "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/card_vault"
+	creditcard "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/credit_card"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/currency"
+	financialaccount "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/financial_account"
+	financialcard "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/financial_card"
+	giftcard "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/gift_card"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/idempotency"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/review"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/risk"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/scheduler"
+	twofactor "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/two_factor"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/service/user"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// services is shared by the commands so they run the same wiring.
+type services struct {
+	user               *user.Service
+	bank               *bank.Service
+	bankBranch         *bankbranch.Service
+	currency           *currency.Service
+	financialAccount   *financialaccount.Service
+	financialCard      *financialcard.Service
+	accountRules       *accountrules.Service
+	idempotency        *idempotency.Service
+	review             *review.Service
+	accountTransaction *accounttransaction.Service
+	scheduledTransfer  *scheduler.Service
+	cardTransaction    *cardtransaction.Service
+	cardVault          *cardvault.Service
+	twoFactor          *twofactor.Service
+	giftCard           *giftcard.Service
+	creditCard         *creditcard.Service
+	authorization      *authorization.Service
+}
+
+func newServices(cfg *config.Config, logger *zap.SugaredLogger, database protocol.Database) (*services, error) {
+	userRepo := repository.NewUser(database)
+	bankRepo := repository.NewBank(database)
+	bankBranchRepo := repository.NewBankBranch(database)
+	financialCardRepo := repository.NewFinancialCard(database)
+	cardBINRepo := repository.NewCardBIN(database)
+	currencyRepo := repository.NewCurrency(database)
+	financialAccountRepo := repository.NewFinancialAccount(database)
+	accountTransactionRepo := repository.NewAccountTransaction(database)
+	ledgerRepo := repository.NewLedger(database)
+	idempotencyKeyRepo := repository.NewIdempotencyKey(database)
+	fxQuoteRepo := repository.NewFXQuote(database)
+	accountRulesRepo := repository.NewAccountRules(database)
+	riskAssessmentRepo := repository.NewRiskAssessment(database)
+	reviewCaseRepo := repository.NewReviewCase(database)
+	scheduledTransferRepo := repository.NewScheduledTransfer(database)
+	cardTransactionRepo := repository.NewCardTransaction(database)
+	cardHoldRepo := repository.NewCardHold(database)
+	cardVaultRepo := repository.NewCardVault(database)
+	cardControlsRepo := repository.NewCardControls(database)
+	giftCardRepo := repository.NewGiftCard(database)
+	creditLineRepo := repository.NewCreditLine(database)
+	roleRepo := repository.NewRole(database)
+	sessionRepo := repository.NewSession(database)
+	twoFactorRepo := repository.NewTwoFactor(database)
+	verificationCodeRepo := repository.NewVerificationCode(database)
+
+	// Create instances of BcryptHasher, JWTTokenGenerator and the notifier
+	hasher := utils.BcryptHasher{}
+	tokenGenerator := utils.JWTTokenGenerator{}
+	notifier := newNotifier(cfg.Notifier, logger)
+	cardVaultService, err := cardvault.New(cfg.CardVault, logger, cardVaultRepo)
+	if err != nil {
+		return nil, fmt.Errorf("opening the card vault: %w", err)
+	}
+	twoFactorService, err := twofactor.New(cfg.TwoFactor, logger, twoFactorRepo, userRepo)
+	if err != nil {
+		return nil, fmt.Errorf("setting up two-factor authentication: %w", err)
+	}
+	userService := user.New(cfg.JWT, cfg.Verification, logger,
+		userRepo,
+		roleRepo,
+		sessionRepo,
+		verificationCodeRepo,
+		twoFactorService,
+		hasher,
+		tokenGenerator,
+		notifier)
+	bankService := bank.New(cfg.JWT, logger, bankRepo, tokenGenerator)
+	currencyService := currency.New(cfg.JWT, logger, tokenGenerator, currencyRepo)
+	bankBranchService := bankbranch.New(cfg.JWT, logger, bankBranchRepo, tokenGenerator, bankService)
+	financialAccountService := financialaccount.New(cfg.JWT, logger,
+		tokenGenerator,
+		financialAccountRepo,
+		bankService,
+		bankBranchService,
+		userService,
+		currencyService)
+	accountRulesService := accountrules.New(cfg.AccountRules, logger, accountRulesRepo, accountTransactionRepo, financialAccountService, userService)
+	idempotencyService := idempotency.New(cfg.Idempotency, logger, idempotencyKeyRepo)
+	riskChecks, err := risk.NewChecks(cfg.Risk, accountTransactionRepo)
+	if err != nil {
+		return nil, fmt.Errorf("building risk checks: %w", err)
+	}
+	reviewService := review.New(cfg.Risk, logger, reviewCaseRepo, accountTransactionRepo, ledgerRepo, userService, cardTransactionRepo, financialCardRepo)
+	riskService := risk.New(cfg.Risk, logger, riskAssessmentRepo, reviewService, riskChecks)
+	accountTransactionService, err := accounttransaction.New(cfg.JWT, cfg.FX, cfg.Risk, logger,
+		tokenGenerator,
+		financialAccountService,
+		accountTransactionRepo,
+		ledgerRepo,
+		idempotencyService,
+		currencyService,
+		fxQuoteRepo,
+		accountRulesService,
+		riskService,
+		twoFactorService,
+		reviewCaseRepo)
+	if err != nil {
+		return nil, fmt.Errorf("setting up account transactions: %w", err)
+	}
+	financialCardService := financialcard.New(cfg.JWT, cfg.Card.Issuing, cfg.Card.Renewal, logger, financialCardRepo, tokenGenerator, financialAccountService, cardVaultService, cardBINRepo, cardControlsRepo, notifier)
+	scheduledTransferService := scheduler.New(cfg.Scheduler, logger,
+		scheduledTransferRepo,
+		financialAccountService,
+		accountTransactionService,
+		idempotencyService,
+		accountRulesService)
+	cardTransactionService := cardtransaction.New(cfg.Card, cfg.Risk, logger,
+		cardTransactionRepo,
+		cardHoldRepo,
+		cardControlsRepo,
+		creditLineRepo,
+		ledgerRepo,
+		financialCardService,
+		financialAccountService,
+		accountRulesService,
+		idempotencyService,
+		twoFactorService,
+		riskService)
+	giftCardService := giftcard.New(cfg.GiftCard, cfg.Card, logger,
+		giftCardRepo,
+		ledgerRepo,
+		financialAccountService,
+		accountRulesService,
+		idempotencyService,
+		twoFactorService)
+	creditCardService := creditcard.New(cfg.CreditCard, logger,
+		creditLineRepo,
+		cardTransactionRepo,
+		ledgerRepo,
+		financialCardService,
+		financialAccountService,
+		accountRulesService,
+		idempotencyService,
+		twoFactorService)
+	authorizationService := authorization.New(logger,
+		financialAccountService,
+		financialCardRepo,
+		accountTransactionRepo,
+		cardTransactionRepo)
+
+	return &services{
+		user:               userService,
+		bank:               bankService,
+		bankBranch:         bankBranchService,
+		currency:           currencyService,
+		financialAccount:   financialAccountService,
+		financialCard:      financialCardService,
+		accountRules:       accountRulesService,
+		idempotency:        idempotencyService,
+		review:             reviewService,
+		accountTransaction: accountTransactionService,
+		scheduledTransfer:  scheduledTransferService,
+		cardTransaction:    cardTransactionService,
+		cardVault:          cardVaultService,
+		twoFactor:          twoFactorService,
+		giftCard:           giftCardService,
+		creditCard:         creditCardService,
+		authorization:      authorizationService,
+	}, nil
+}
+
+// newNotifier picks the development sink for notifications; there is no gateway yet.
+func newNotifier(cfg config.Notifier, logger *zap.SugaredLogger) protocol.Notifier {
+	if cfg.Driver == "file" {
+		return utils.NewFileNotifier(cfg.Path)
+	}
+	return utils.LogNotifier{Logger: logger}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/database/postgres"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/repository"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/log"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+const vaultCardsBatchSize = 100
+
+var vaultCardsCommand = &cli.Command{
+	Name:        "vault-cards",
+	Description: "moving card numbers stored before the card vault into it",
+	Action:      runVaultCards,
+}
+
+func runVaultCards(_ *cli.Context) (err error) {
+	cfg, err := config.New()
+	if err != nil {
+		return fmt.Errorf("loading application config : %w", err)
+	}
+
+	logger, err := log.New("digital-wallet-vault-cards", log.Config{
+		OutputPaths:       cfg.Logger.OutputPaths,
+		ErrorOutputPaths:  cfg.Logger.ErrorOutputPaths,
+		DisableStacktrace: cfg.Logger.DisableStacktrace,
+		Level:             cfg.Logger.Level,
+	})
+	if err != nil {
+		return fmt.Errorf("initial log: %w", err)
+	}
+
+	defer func(logger *zap.SugaredLogger) {
+		_ = logger.Sync()
+	}(logger)
+
+	postgresDB, err := postgres.New(cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+
+	defer func() {
+		if derr := postgresDB.Close(); derr != nil && err == nil {
+			err = fmt.Errorf("closing postgres connections: %w", derr)
+		}
+	}()
+
+	svc, err := newServices(cfg, logger, postgresDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	cardRepo := repository.NewFinancialCard(postgresDB)
+
+	// Each replaced number drops out of the next batch, so the loop ends once none are left
+	vaulted := 0
+	for {
+		numbers, err := cardRepo.ListPlaintextNumbers(ctx, vaultCardsBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(numbers) == 0 {
+			break
+		}
+
+		for cardID, number := range numbers {
+			if len(number) < 4 {
+				return fmt.Errorf("card %d has a malformed number stored", cardID)
+			}
+
+			token, err := svc.cardVault.Tokenize(ctx, number)
+			if err != nil {
+				return fmt.Errorf("vaulting the number of card %d: %w", cardID, err)
+			}
+
+			if err := cardRepo.ReplaceNumber(ctx, cardID, token, number[len(number)-4:]); err != nil {
+				return err
+			}
+			vaulted++
+		}
+	}
+
+	logger.Info("Card numbers moved into the vault", zap.Int("cards", vaulted))
+
+	return nil
+}
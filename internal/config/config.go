@@ -9,10 +9,22 @@ import (
 )
 
 type Config struct {
-	HTTP     HTTP     `mapstructure:"http"`
-	Postgres Postgres `mapstructure:"postgres"`
-	JWT      JWT      `mapstructure:"jwt"`
-	Logger   Logger   `mapstructure:"logger"`
+	HTTP         HTTP         `mapstructure:"http"`
+	Postgres     Postgres     `mapstructure:"postgres"`
+	JWT          JWT          `mapstructure:"jwt"`
+	Logger       Logger       `mapstructure:"logger"`
+	Idempotency  Idempotency  `mapstructure:"idempotency"`
+	FX           FX           `mapstructure:"fx"`
+	AccountRules AccountRules `mapstructure:"account_rules"`
+	Risk         Risk         `mapstructure:"risk"`
+	Scheduler    Scheduler    `mapstructure:"scheduler"`
+	Card         Card         `mapstructure:"card"`
+	CardVault    CardVault    `mapstructure:"card_vault"`
+	GiftCard     GiftCard     `mapstructure:"gift_card"`
+	CreditCard   CreditCard   `mapstructure:"credit_card"`
+	TwoFactor    TwoFactor    `mapstructure:"two_factor"`
+	Notifier     Notifier     `mapstructure:"notifier"`
+	Verification Verification `mapstructure:"verification"`
 }
 
 type HTTP struct {
@@ -54,6 +66,264 @@ type JWT struct {
 	Secret          string        `mapstructure:"secret"`
 	AccessTokenExp  time.Duration `mapstructure:"access_token_exp"`
 	RefreshTokenExp time.Duration `mapstructure:"refresh_token_exp"`
+	// Revoked access tokens are deleted from the denylist every DenylistPurgeInterval once
+	// they have expired.
+	DenylistPurgeInterval time.Duration `mapstructure:"denylist_purge_interval" validate:"gte=0"`
+}
+
+type Idempotency struct {
+	TTL   time.Duration `mapstructure:"ttl"`
+	Lease time.Duration `mapstructure:"lease"` // How long an in-flight claim holds its key
+}
+
+type FX struct {
+	QuoteTTL  time.Duration `mapstructure:"quote_ttl"`
+	SpreadBps int64         `mapstructure:"spread_bps" validate:"gte=0,lt=10000"`
+	// HouseAccounts are the accounts that buy and sell each currency in cross-currency transfers.
+	HouseAccounts []FXHouseAccount `mapstructure:"house_accounts"`
+}
+
+type FXHouseAccount struct {
+	Currency  string `mapstructure:"currency"`
+	AccountID int    `mapstructure:"account_id"`
+}
+
+type AccountRules struct {
+	// NewAccountPeriod is how long an account stays under new_account_max_amount after it is opened.
+	NewAccountPeriod time.Duration `mapstructure:"new_account_period"`
+}
+
+type Risk struct {
+	// Transfers scoring at least ReviewScore wait for an analyst, at least HoldScore are put
+	// on hold. A threshold of zero is never reached.
+	ReviewScore int `mapstructure:"review_score" validate:"gte=0"`
+	HoldScore   int `mapstructure:"hold_score" validate:"gte=0"`
+	// SuspenseAccounts keep the funds of held transfers, one per currency, until they are released.
+	SuspenseAccounts []SuspenseAccount `mapstructure:"suspense_accounts"`
+	// Checks run in order on every transfer; their scores add up.
+	Checks []RiskCheck `mapstructure:"checks"`
+}
+
+type SuspenseAccount struct {
+	Currency  string `mapstructure:"currency"`
+	AccountID int    `mapstructure:"account_id"`
+}
+
+// SuspenseAccountFor returns the suspense account that holds funds in the given currency.
+func (cfg Risk) SuspenseAccountFor(currency string) (int, bool) {
+	for _, suspense := range cfg.SuspenseAccounts {
+		if suspense.Currency == currency {
+			return suspense.AccountID, true
+		}
+	}
+	return 0, false
+}
+
+// RiskCheck configures one built-in check. Each check reads only the parameters it needs.
+type RiskCheck struct {
+	Name  string `mapstructure:"name"`
+	Score int    `mapstructure:"score"`
+
+	Thresholds []RiskThreshold `mapstructure:"thresholds"` // large_amount
+	StartHour  int             `mapstructure:"start_hour"` // unusual_hours
+	EndHour    int             `mapstructure:"end_hour"`   // unusual_hours
+	Timezone   string          `mapstructure:"timezone"`   // unusual_hours
+	Count      int             `mapstructure:"count"`      // velocity
+	Window     time.Duration   `mapstructure:"window"`     // velocity, amount_spike
+	Multiplier int64           `mapstructure:"multiplier"` // amount_spike
+}
+
+// RiskThreshold is an amount in minor units of its currency.
+type RiskThreshold struct {
+	Currency string `mapstructure:"currency"`
+	Amount   int64  `mapstructure:"amount"`
+}
+
+type Scheduler struct {
+	// RunInAPI starts the executor, the card hold and gift card sweepers, the credit card
+	// statement cycle and the card renewal job inside the api command. Without it, run the
+	// scheduler command.
+	RunInAPI     bool          `mapstructure:"run_in_api"`
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"gte=0"`
+	BatchSize    int           `mapstructure:"batch_size" validate:"gte=0"`
+	// Lease is how long an executor owns the orders it claimed. Orders of an executor that
+	// died are picked up again once it runs out.
+	Lease time.Duration `mapstructure:"lease" validate:"gte=0"`
+	// A failed occurrence is attempted MaxAttempts times, RetryBackoff apart and doubling
+	// after each attempt, before it is skipped.
+	MaxAttempts  int           `mapstructure:"max_attempts" validate:"gte=0"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" validate:"gte=0"`
+}
+
+type Card struct {
+	// SettlementAccounts clear card purchases and refunds with the card network, one per currency.
+	SettlementAccounts []CardSettlementAccount `mapstructure:"settlement_accounts"`
+	// HoldTTL is how long an authorization reserves funds before it is released uncaptured.
+	HoldTTL time.Duration `mapstructure:"hold_ttl" validate:"gte=0"`
+	// The sweeper expires stale holds every SweepInterval, SweepBatchSize at a time. It runs
+	// next to the scheduled transfer executor.
+	SweepInterval  time.Duration `mapstructure:"sweep_interval" validate:"gte=0"`
+	SweepBatchSize int           `mapstructure:"sweep_batch_size" validate:"gte=0"`
+	// HomeCountry is the ISO 3166 alpha-2 code of the country where the cards are issued.
+	// Payments at merchants elsewhere are international for the card controls.
+	HomeCountry string `mapstructure:"home_country"`
+	// Issuing numbers the virtual cards the wallet issues itself.
+	Issuing CardIssuing `mapstructure:"issuing"`
+	// Renewal replaces cards before they expire.
+	Renewal CardRenewal `mapstructure:"renewal"`
+}
+
+type CardIssuing struct {
+	// Each card gets a BIN picked from BINStart to BINEnd. The range has to be known to the
+	// card_bin table so that the network can be detected. A zero BINStart turns issuing off.
+	BINStart int `mapstructure:"bin_start" validate:"omitempty,gte=100000,lte=999999"`
+	BINEnd   int `mapstructure:"bin_end" validate:"omitempty,gtefield=BINStart,lte=999999"`
+	// NumberLength counts the check digit; it defaults to 16.
+	NumberLength int `mapstructure:"number_length" validate:"omitempty,gte=13,lte=19"`
+	// ValidityMonths is both the default and the longest validity of an issued card.
+	ValidityMonths int `mapstructure:"validity_months" validate:"gte=0"`
+}
+
+type CardRenewal struct {
+	// Cards expiring within NoticeWindow are renewed and their owners told about it. The old
+	// card keeps working until its expiration date.
+	NoticeWindow time.Duration `mapstructure:"notice_window" validate:"gte=0"`
+	// Every Interval the job renews cards and expires the ones past their date, BatchSize of
+	// each at a time.
+	Interval  time.Duration `mapstructure:"interval" validate:"gte=0"`
+	BatchSize int           `mapstructure:"batch_size" validate:"gte=0"`
+}
+
+type CardSettlementAccount struct {
+	Currency  string `mapstructure:"currency"`
+	AccountID int    `mapstructure:"account_id"`
+}
+
+// SettlementAccountFor returns the account that settles card payments in the given currency.
+func (cfg Card) SettlementAccountFor(currency string) (int, bool) {
+	for _, settlement := range cfg.SettlementAccounts {
+		if settlement.Currency == currency {
+			return settlement.AccountID, true
+		}
+	}
+	return 0, false
+}
+
+type GiftCard struct {
+	// Accounts carry the gift card balances in the ledger, one pair per currency.
+	Accounts []GiftCardAccounts `mapstructure:"accounts"`
+	// Validity is how long a gift card can be used after it is bought.
+	Validity time.Duration `mapstructure:"validity" validate:"gte=0"`
+	// A user who enters MaxFailedAttempts wrong codes within FailureWindow cannot use any
+	// code until the oldest of them falls out of the window.
+	MaxFailedAttempts int           `mapstructure:"max_failed_attempts" validate:"gte=0"`
+	FailureWindow     time.Duration `mapstructure:"failure_window" validate:"gte=0"`
+	// The breakage sweep closes expired cards every SweepInterval, SweepBatchSize at a time.
+	SweepInterval  time.Duration `mapstructure:"sweep_interval" validate:"gte=0"`
+	SweepBatchSize int           `mapstructure:"sweep_batch_size" validate:"gte=0"`
+}
+
+// GiftCardAccounts are the liability account that owes the unused gift card balances to
+// their holders and the income account that takes the balances left on expired cards.
+type GiftCardAccounts struct {
+	Currency           string `mapstructure:"currency"`
+	LiabilityAccountID int    `mapstructure:"liability_account_id"`
+	BreakageAccountID  int    `mapstructure:"breakage_account_id"`
+}
+
+// AccountsFor returns the gift card accounts of the given currency.
+func (cfg GiftCard) AccountsFor(currency string) (GiftCardAccounts, bool) {
+	for _, accounts := range cfg.Accounts {
+		if accounts.Currency == currency {
+			return accounts, true
+		}
+	}
+	return GiftCardAccounts{}, false
+}
+
+type CreditCard struct {
+	// Accounts collect the interest and fees charged on credit cards, one pair per currency.
+	Accounts []CreditCardAccounts `mapstructure:"accounts"`
+	// GracePeriod runs from a statement's close to its due date. It is kept under four weeks
+	// so that a statement falls due before the next one closes.
+	GracePeriod time.Duration `mapstructure:"grace_period" validate:"gte=0,lt=672h"`
+	// MinimumPaymentRate is the share of a statement's principal, in basis points, due by the
+	// due date on top of the interest and fees owed.
+	MinimumPaymentRate int `mapstructure:"minimum_payment_rate" validate:"gte=0,lte=10000"`
+	// The statement cycle accrues interest, closes statements and charges late fees every
+	// CycleInterval, CycleBatchSize lines or statements at a time.
+	CycleInterval  time.Duration `mapstructure:"cycle_interval" validate:"gte=0"`
+	CycleBatchSize int           `mapstructure:"cycle_batch_size" validate:"gte=0"`
+}
+
+// CreditCardAccounts holds the accounts and amounts of credit cards in one currency.
+// Amounts are in minor units.
+type CreditCardAccounts struct {
+	Currency          string `mapstructure:"currency"`
+	InterestAccountID int    `mapstructure:"interest_account_id"`
+	FeeAccountID      int    `mapstructure:"fee_account_id"`
+	// MinimumPayment is the least a statement asks for, unless less is owed.
+	MinimumPayment int64 `mapstructure:"minimum_payment"`
+	LateFee        int64 `mapstructure:"late_fee"`
+}
+
+func (cfg CreditCard) AccountsFor(currency string) (CreditCardAccounts, bool) {
+	for _, accounts := range cfg.Accounts {
+		if accounts.Currency == currency {
+			return accounts, true
+		}
+	}
+	return CreditCardAccounts{}, false
+}
+
+type CardVault struct {
+	// Keys encrypt the per-card data keys. New cards are sealed with ActiveKeyID; the other
+	// keys are kept to open cards sealed before a rotation.
+	ActiveKeyID string     `mapstructure:"active_key_id" validate:"required"`
+	Keys        []VaultKey `mapstructure:"keys" validate:"required,dive"`
+	// FingerprintKey keys the HMAC that finds a card number in the vault without decrypting
+	// it. It must never change, or known numbers are no longer recognised.
+	FingerprintKey string `mapstructure:"fingerprint_key" validate:"required,base64"`
+}
+
+type VaultKey struct {
+	ID  string `mapstructure:"id" validate:"required"`
+	Key string `mapstructure:"key" validate:"required,base64"` // 32 bytes for AES-256
+}
+
+type TwoFactor struct {
+	// Issuer names the wallet in authenticator apps.
+	Issuer string `mapstructure:"issuer"`
+	// ChallengeKey signs the challenges a client holds between a request and the code that
+	// completes it. ChallengeTTL is how long a challenge can be answered.
+	ChallengeKey string        `mapstructure:"challenge_key" validate:"required,base64"`
+	ChallengeTTL time.Duration `mapstructure:"challenge_ttl" validate:"gte=0"`
+	// SecretKey seals the authenticator secrets on their rows, 32 bytes for AES-256.
+	SecretKey string `mapstructure:"secret_key" validate:"required,base64"`
+	// A user who enters MaxFailedAttempts wrong codes within FailureWindow is refused every
+	// code until the oldest of them falls out of the window.
+	MaxFailedAttempts int           `mapstructure:"max_failed_attempts" validate:"gte=0"`
+	FailureWindow     time.Duration `mapstructure:"failure_window" validate:"gte=0"`
+	// RecoveryCodes is how many single-use codes are issued for a lost authenticator.
+	RecoveryCodes int `mapstructure:"recovery_codes" validate:"gte=0"`
+}
+
+// Notifier picks where notifications go until an email and SMS gateway is wired in: "log"
+// writes them to the application log, "file" appends them to Path as JSON lines.
+type Notifier struct {
+	Driver string `mapstructure:"driver" validate:"omitempty,oneof=log file"`
+	Path   string `mapstructure:"path" validate:"required_if=Driver file"`
+}
+
+type Verification struct {
+	// CodeTTL is how long a code sent to an email address or cellphone can be entered, and
+	// MaxAttempts how many wrong guesses use it up.
+	CodeTTL     time.Duration `mapstructure:"code_ttl" validate:"gte=0"`
+	MaxAttempts int           `mapstructure:"max_attempts" validate:"gte=0"`
+	// A new code for the same channel is sent no sooner than ResendInterval after the last
+	// one, and no more than MaxCodesPerDay times a day.
+	ResendInterval time.Duration `mapstructure:"resend_interval" validate:"gte=0"`
+	MaxCodesPerDay int           `mapstructure:"max_codes_per_day" validate:"gte=0"`
 }
 
 type Logger struct {
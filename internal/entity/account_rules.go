@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// AccountRules are the limits of one financial account. A zero limit or amount disables that rule.
+type AccountRules struct {
+	RuleID             int
+	FinancialAccountID int
+
+	// Number of debits allowed in a rolling day, week and month
+	DailyLimit   int
+	WeeklyLimit  int
+	MonthlyLimit int
+
+	MinAmount                  Money
+	MaxAmount                  Money
+	MaxFailedTransactionsDaily int
+	NewAccountMaxAmount        Money // Cap per transaction while the account is new
+	MinBalanceRequired         Money // Balance that must remain after a debit
+
+	AllowedCountries       []string
+	DisallowedCountries    []string
+	NotifyOnNewLocation    bool
+	BlockOnUnusualLocation bool
+	AllowedRegions         []string
+	AllowedCities          []string
+
+	Require2FAForAmount Money // Debits above this need a second factor
+	VerificationLevel   enum.VerificationLevel
+
+	// Cooling periods in hours
+	PasswordChangeCoolingPeriod       int
+	NewTransactionMethodCoolingPeriod int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+// PolicyViolation describes one account rule a transaction breaks.
+type PolicyViolation struct {
+	Rule    enum.PolicyRule `json:"rule"`
+	Message string          `json:"message"`
+}
+
+// SecondFactor is what a debit brings to pass the two-factor rule of its account. The binding
+// names the debit, so that a challenge is only good for the one it was issued for.
+type SecondFactor struct {
+	UserID   int
+	Binding  string
+	Verified bool
+	// Exempt debits are not started by a user who could be asked for a code, such as merchant
+	// charges against a card and ledger registrations.
+	Exempt bool
+}
+
+// OnlyTwoFactor reports whether the two-factor rule is all that holds a debit back, so that a
+// code from the user would let it through.
+func OnlyTwoFactor(violations []PolicyViolation) bool {
+	return len(violations) == 1 && violations[0].Rule == enum.PolicyRequire2FA
+}
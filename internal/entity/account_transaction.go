@@ -7,14 +7,20 @@ import (
 )
 
 type AccountTransaction struct {
-	TransactionID      int
-	TransactionGroupID int
-	FinancialAccountID int
-	Amount             float64
-	Balance            float64
-	Description        *string
-	Status             enum.AccountTransactionStatus
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
-	DeletedAt          *time.Time
+	TransactionID         int
+	TransactionGroupID    int
+	FinancialAccountID    int
+	Amount                Money
+	Balance               Money
+	Description           *string
+	Status                enum.AccountTransactionStatus
+	ReversesTransactionID *int // Set on the legs of a reversal entry, pointing at the leg they compensate
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             *time.Time
+}
+
+// IsHeld reports whether the transaction waits in suspense for a risk review.
+func (t *AccountTransaction) IsHeld() bool {
+	return t.Status == enum.OnHold || t.Status == enum.PendingReview
 }
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// CardBIN maps a range of six-digit bank identification numbers to the card network and,
+// where the range belongs to a single issuer, the bank that issues it.
+type CardBIN struct {
+	CardBINID  int
+	RangeStart int
+	RangeEnd   int
+	Network    enum.CardNetwork
+	BankCode   *string // Nil for ranges shared by many issuers, such as most Visa BINs
+	CreatedAt  time.Time
+}
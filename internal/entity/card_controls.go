@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// CardControls are the owner's limits on one card, applied on top of the rules of its
+// account. A zero limit disables it.
+type CardControls struct {
+	FinancialCardID       int
+	OnlineDisabled        bool     // Declines card-not-present payments
+	InternationalDisabled bool     // Declines merchants outside the card's home country
+	BlockedMCCs           []string // Merchant category codes to decline
+	PerTransactionLimit   Money
+	DailyLimit            Money // Spent in a rolling day, open authorizations included
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// BlocksMCC reports whether payments at merchants of the category are declined.
+func (c *CardControls) BlocksMCC(mcc string) bool {
+	for _, blocked := range c.BlockedMCCs {
+		if blocked == mcc {
+			return true
+		}
+	}
+	return false
+}
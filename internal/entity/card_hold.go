@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// CardHold is an authorization: funds reserved on the card's linked account until the
+// payment is captured, released or expires. A hold posts nothing to the ledger; it only
+// lowers the available balance.
+type CardHold struct {
+	HoldID               int
+	FinancialCardID      int
+	FinancialAccountID   int
+	Amount               Money // Authorized, positive
+	CapturedAmount       Money
+	Merchant             string
+	Description          string
+	Status               enum.CardHoldStatus
+	CaptureTransactionID *int64 // The purchase posted by the capture
+	ExpiresAt            time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// IsExpired reports whether an active hold can no longer be captured.
+func (h *CardHold) IsExpired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
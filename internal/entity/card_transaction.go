@@ -6,15 +6,21 @@ import (
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 )
 
+// CardTransaction is the card's view of a journal entry posted against its linked account.
 type CardTransaction struct {
-	TransactionID      int64
-	TransactionGroupID int
-	FinancialCardID    int
-	Amount             float64
-	Balance            float64
-	Description        string
-	Status             enum.CardTransactionStatus
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
-	DeletedAt          *time.Time
+	TransactionID        int64
+	TransactionGroupID   int
+	FinancialCardID      int
+	Type                 enum.CardTransactionType
+	Amount               Money // Negative for purchases and outgoing transfers
+	Balance              Money // Balance of the card's linked account after the transaction
+	Merchant             *string
+	Description          string
+	Status               enum.CardTransactionStatus
+	RefundsTransactionID *int64 // Set on refunds, to the purchase they return money for
+	HoldID               *int   // Set on purchases captured from an authorization hold
+	StatementID          *int   // Set on credit card transactions once a statement closes over them
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            *time.Time
 }
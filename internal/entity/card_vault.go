@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// VaultEntry is a card number sealed with its own data key, which is in turn sealed with
+// the vault key named by KeyID.
+type VaultEntry struct {
+	Token       string
+	KeyID       string
+	WrappedKey  []byte
+	Ciphertext  []byte
+	Fingerprint []byte // HMAC of the number, to find it again without decrypting
+	CreatedAt   time.Time
+}
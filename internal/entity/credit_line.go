@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// CreditLine is the borrowing behind a credit card. The card's linked account carries what
+// is owed as a negative balance; the line sets how far below zero it may go and keeps the
+// interest and fees owed apart from the principal, so payments can be applied in order.
+type CreditLine struct {
+	CreditLineID      int
+	FinancialCardID   int
+	AccountID         int // The card's linked account
+	PaymentAccountID  int // The checking account payments are drawn from
+	Limit             Money
+	APR               int   // Annual percentage rate in basis points
+	StatementDay      int   // Day of the month statements close on, 1 to 28
+	FeesDue           Money // Fees charged and not yet paid
+	InterestDue       Money // Interest charged and not yet paid
+	AccruedInterest   Money // Interest accrued since the last statement, charged when the next one closes
+	InterestAccruedOn time.Time
+	Revolving         bool // Set while a statement balance is carried past its due date
+	NextStatementAt   time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// CreditStatement is one monthly cycle of a credit line. Balances are what was owed, so
+// they are positive while the card is in debt.
+type CreditStatement struct {
+	CreditStatementID int
+	CreditLineID      int
+	PeriodStart       time.Time
+	PeriodEnd         time.Time
+	OpeningBalance    Money
+	ClosingBalance    Money
+	Purchases         Money
+	Credits           Money // Refunds from merchants
+	Payments          Money
+	InterestCharged   Money
+	FeesCharged       Money
+	MinimumPayment    Money
+	DueDate           time.Time
+	Paid              Money // Paid since the statement closed
+	Status            enum.CreditStatementStatus
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -11,7 +11,7 @@ type Currency struct {
 	CurrencyCode enum.CurrencyCode
 	CurrencyName enum.CurrencyName
 	Symbol       enum.CurrencySymbol
-	ExchangeRate *float64
+	ExchangeRate *Rate
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time
@@ -9,4 +9,6 @@ const (
 	Reversed
 	OnHold
 	Cancelled
+	PendingReview     // Held by the risk checks until an analyst reviews it
+	PartiallyReversed // Part of the amount was refunded by a reversal entry
 )
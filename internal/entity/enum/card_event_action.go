@@ -0,0 +1,14 @@
+package enum
+
+// CardEventAction names an entry of a card's lifecycle history.
+type CardEventAction string
+
+const (
+	CardActionFrozen         CardEventAction = "frozen"
+	CardActionUnfrozen       CardEventAction = "unfrozen"
+	CardActionReportedLost   CardEventAction = "reported_lost"
+	CardActionReportedStolen CardEventAction = "reported_stolen"
+	CardActionReissued       CardEventAction = "reissued"
+	CardActionRenewed        CardEventAction = "renewed"
+	CardActionExpired        CardEventAction = "expired"
+)
@@ -0,0 +1,10 @@
+package enum
+
+type CardHoldStatus uint
+
+const (
+	HoldActive   CardHoldStatus = iota
+	HoldCaptured                // Settled, in full or in part; any remainder was released
+	HoldReleased                // Voided before capture
+	HoldExpired                 // Not captured before it ran out
+)
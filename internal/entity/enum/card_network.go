@@ -0,0 +1,10 @@
+package enum
+
+type CardNetwork uint
+
+const (
+	NetworkUnknown CardNetwork = iota // Registered before BIN detection
+	Shetab
+	Visa
+	Mastercard
+)
@@ -0,0 +1,13 @@
+package enum
+
+type CardTransactionType uint
+
+const (
+	CardPurchase CardTransactionType = iota
+	CardRefund
+	CardTransferOut
+	CardTransferIn
+	CardPayment  // Payment towards a credit card's balance
+	CardInterest // Interest charged on a credit card
+	CardFee      // Late fee charged on a credit card
+)
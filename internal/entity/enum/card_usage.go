@@ -0,0 +1,19 @@
+package enum
+
+// CardUsage restricts what a virtual card can pay for.
+type CardUsage uint
+
+const (
+	MultiUse       CardUsage = iota
+	SingleUse                // Closed to further payments after its first
+	MerchantLocked           // Pays a single merchant only
+)
+
+func (u CardUsage) IsValid() bool {
+	switch u {
+	case MultiUse, SingleUse, MerchantLocked:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,18 @@
+package enum
+
+// ContactChannel is a way of reaching a user: mail to their email address or a text message
+// to their cellphone.
+type ContactChannel string
+
+const (
+	ContactEmail     ContactChannel = "email"
+	ContactCellphone ContactChannel = "cellphone"
+)
+
+func (c ContactChannel) IsValid() bool {
+	switch c {
+	case ContactEmail, ContactCellphone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+package enum
+
+type CreditStatementStatus uint
+
+const (
+	StatementOpen     CreditStatementStatus = iota // Closed over its transactions and waiting for payment
+	StatementPaid                                  // Paid in full
+	StatementRevolved                              // Past due with the minimum paid; the rest carries interest
+	StatementLate                                  // Past due without the minimum; a late fee was charged
+)
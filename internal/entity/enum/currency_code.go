@@ -53,3 +53,28 @@ const (
 	KES CurrencyCode = "KES"
 	UGX CurrencyCode = "UGX"
 )
+
+var currencyCodes = map[CurrencyCode]struct{}{
+	USD: {}, EUR: {}, GBP: {}, JPY: {}, CAD: {}, AUD: {}, CHF: {}, CNY: {}, SEK: {}, NZD: {},
+	MXN: {}, SGD: {}, HKD: {}, NOK: {}, KRW: {}, TRY: {}, RUB: {}, INR: {}, BRL: {}, ZAR: {},
+	DKK: {}, PLN: {}, THB: {}, IDR: {}, TWD: {}, MYR: {}, CZK: {}, HUF: {}, PHP: {}, AED: {},
+	SAR: {}, BHD: {}, EGP: {}, CLP: {}, COP: {}, PKR: {}, RON: {}, PEN: {}, JOD: {}, QAR: {},
+	OMR: {}, UAH: {}, LBP: {}, VND: {}, NGN: {}, KZT: {}, BDT: {}, KES: {}, UGX: {},
+}
+
+func (c CurrencyCode) IsValid() bool {
+	_, ok := currencyCodes[c]
+	return ok
+}
+
+// Exponent is the number of decimal places of the currency's minor unit (ISO 4217).
+func (c CurrencyCode) Exponent() int {
+	switch c {
+	case JPY, KRW, CLP, VND, UGX:
+		return 0
+	case BHD, JOD, OMR:
+		return 3
+	default:
+		return 2
+	}
+}
@@ -5,16 +5,42 @@ type FinancialCardStatus uint
 const (
 	Active FinancialCardStatus = iota
 	Inactive
-	Lost
-	Stolen
+	Lost         // Reported by the owner; never usable again
+	Stolen       // Reported by the owner; never usable again
+	CardFrozen   // Blocked by the owner until they unfreeze it
+	CardReplaced // Superseded by a reissued card
+	CardExpired  // Past its expiration date
 )
 
+// cardStatusTransitions lists where each status may move. Lost, stolen, replaced and
+// expired cards are terminal.
+var cardStatusTransitions = map[FinancialCardStatus][]FinancialCardStatus{
+	Active:     {CardFrozen, Lost, Stolen, CardReplaced, CardExpired},
+	Inactive:   {Lost, Stolen, CardReplaced, CardExpired},
+	CardFrozen: {Active, Lost, Stolen, CardReplaced, CardExpired},
+}
+
 // IsValid checks if the given FinancialCardStatus is valid.
 func IsValidFinancialCardStatus(s FinancialCardStatus) bool {
 	switch s {
-	case Active, Inactive, Lost, Stolen:
+	case Active, Inactive, Lost, Stolen, CardFrozen, CardReplaced, CardExpired:
 		return true
 	default:
 		return false
 	}
 }
+
+// CanBecome reports whether a card may move from s to next.
+func (s FinancialCardStatus) CanBecome(next FinancialCardStatus) bool {
+	for _, allowed := range cardStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether the card can never be used again.
+func (s FinancialCardStatus) IsTerminal() bool {
+	return len(cardStatusTransitions[s]) == 0
+}
@@ -0,0 +1,8 @@
+package enum
+
+type FXQuoteStatus uint
+
+const (
+	FXQuoteOpen FXQuoteStatus = iota
+	FXQuoteExecuted
+)
@@ -0,0 +1,9 @@
+package enum
+
+type GiftCardStatus uint
+
+const (
+	GiftCardActive   GiftCardStatus = iota
+	GiftCardDepleted                // Spent or redeemed down to zero
+	GiftCardExpired                 // Any unused balance was written off as breakage
+)
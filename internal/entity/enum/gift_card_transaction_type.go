@@ -0,0 +1,10 @@
+package enum
+
+type GiftCardTransactionType uint
+
+const (
+	GiftCardLoad     GiftCardTransactionType = iota // The purchase that loaded the card
+	GiftCardRedeem                                  // The balance moved into a wallet account
+	GiftCardSpend                                   // A payment to a merchant
+	GiftCardBreakage                                // The balance left when the card expired
+)
@@ -0,0 +1,8 @@
+package enum
+
+type IdempotencyStatus uint
+
+const (
+	IdempotencyInFlight IdempotencyStatus = iota
+	IdempotencyCompleted
+)
@@ -0,0 +1,27 @@
+package enum
+
+// Permission names an action a route requires. Users get permissions through their roles.
+type Permission string
+
+const (
+	// Granted to customers. Staff roles get profile, banks.read and currencies.read as well.
+	PermissionProfile        Permission = "profile"
+	PermissionBanksRead      Permission = "banks.read"
+	PermissionCurrenciesRead Permission = "currencies.read"
+	PermissionAccountsUse    Permission = "accounts.use" // Open and use one's own accounts
+	PermissionCardsUse       Permission = "cards.use"    // Hold and pay with one's own cards
+	PermissionTransfersMake  Permission = "transfers.make"
+
+	// Granted to staff roles
+	PermissionAccountsReadAll     Permission = "accounts.read_all" // Look up any user's accounts and cards
+	PermissionAccountsVerify      Permission = "accounts.verify"
+	PermissionAccountRules        Permission = "accounts.rules"
+	PermissionReviewsManage       Permission = "reviews.manage"
+	PermissionTransactionsPost    Permission = "transactions.post" // Post raw entries to an account
+	PermissionTransactionsReverse Permission = "transactions.reverse"
+	PermissionBanksManage         Permission = "banks.manage"
+	PermissionCurrenciesManage    Permission = "currencies.manage"
+	PermissionCardsSettle         Permission = "cards.settle" // Refund purchases and settle holds for merchants
+	PermissionCreditManage        Permission = "credit.manage"
+	PermissionRolesManage         Permission = "roles.manage"
+)
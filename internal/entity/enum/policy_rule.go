@@ -0,0 +1,27 @@
+package enum
+
+// PolicyRule names the account rule, card control or virtual card restriction a transaction
+// violated.
+type PolicyRule string
+
+const (
+	PolicyDailyLimit          PolicyRule = "daily_limit"
+	PolicyWeeklyLimit         PolicyRule = "weekly_limit"
+	PolicyMonthlyLimit        PolicyRule = "monthly_limit"
+	PolicyMinAmount           PolicyRule = "min_amount"
+	PolicyMaxAmount           PolicyRule = "max_amount"
+	PolicyNewAccountMaxAmount PolicyRule = "new_account_max_amount"
+	PolicyMinBalanceRequired  PolicyRule = "min_balance_required"
+	PolicyRequire2FA          PolicyRule = "require_2fa_for_amount"
+	PolicyVerificationLevel   PolicyRule = "verification_level"
+
+	CardControlOnline              PolicyRule = "card_online_disabled"
+	CardControlInternational       PolicyRule = "card_international_disabled"
+	CardControlMCC                 PolicyRule = "card_blocked_mcc"
+	CardControlPerTransactionLimit PolicyRule = "card_per_transaction_limit"
+	CardControlDailyLimit          PolicyRule = "card_daily_limit"
+
+	CardSingleUse    PolicyRule = "card_single_use"
+	CardMerchantLock PolicyRule = "card_merchant_locked"
+	CardSpendCap     PolicyRule = "card_spend_cap"
+)
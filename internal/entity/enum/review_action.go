@@ -0,0 +1,12 @@
+package enum
+
+// ReviewAction names an entry of a review case's audit trail.
+type ReviewAction string
+
+const (
+	ReviewActionOpened   ReviewAction = "opened"
+	ReviewActionAssigned ReviewAction = "assigned"
+	ReviewActionClaimed  ReviewAction = "claimed"
+	ReviewActionApproved ReviewAction = "approved"
+	ReviewActionRejected ReviewAction = "rejected"
+)
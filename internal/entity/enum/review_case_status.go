@@ -0,0 +1,9 @@
+package enum
+
+type ReviewCaseStatus uint
+
+const (
+	ReviewOpen ReviewCaseStatus = iota
+	ReviewApproved
+	ReviewRejected
+)
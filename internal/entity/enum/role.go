@@ -0,0 +1,13 @@
+package enum
+
+// Role names a set of permissions granted to a user. Roles and their permissions live in the
+// database; these are the ones seeded with it.
+type Role string
+
+const (
+	RoleCustomer   Role = "customer"
+	RoleSupport    Role = "support"
+	RoleCompliance Role = "compliance"
+	RoleTreasury   Role = "treasury"
+	RoleSuperadmin Role = "superadmin"
+)
@@ -0,0 +1,18 @@
+package enum
+
+type ScheduledTransferStatus uint
+
+const (
+	ScheduledActive ScheduledTransferStatus = iota
+	ScheduledPaused
+	ScheduledCompleted // No occurrences are left
+	ScheduledCancelled
+	ScheduledFailed // A one-off transfer that failed on every attempt
+)
+
+type ScheduledRunStatus uint
+
+const (
+	RunSucceeded ScheduledRunStatus = iota
+	RunFailed
+)
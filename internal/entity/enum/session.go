@@ -0,0 +1,12 @@
+package enum
+
+// SessionRevocation records why a session was ended before it expired.
+type SessionRevocation string
+
+const (
+	// SessionSignedOut is a session the user ended, from that device or another one.
+	SessionSignedOut SessionRevocation = "signed_out"
+	// SessionTokenReused is a session whose spent refresh token was presented again. Either
+	// the client or an attacker holds a copy, so neither may keep using it.
+	SessionTokenReused SessionRevocation = "token_reused"
+)
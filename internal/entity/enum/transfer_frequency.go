@@ -0,0 +1,21 @@
+package enum
+
+// TransferFrequency says how often a scheduled transfer repeats.
+type TransferFrequency string
+
+const (
+	FrequencyOnce    TransferFrequency = "once"
+	FrequencyDaily   TransferFrequency = "daily"
+	FrequencyWeekly  TransferFrequency = "weekly"
+	FrequencyMonthly TransferFrequency = "monthly"
+	FrequencyCron    TransferFrequency = "cron" // Follows a five-field cron expression
+)
+
+func (f TransferFrequency) IsValid() bool {
+	switch f {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyCron:
+		return true
+	default:
+		return false
+	}
+}
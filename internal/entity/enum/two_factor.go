@@ -0,0 +1,10 @@
+package enum
+
+// TwoFactorPurpose is what a second-factor challenge was issued for. A challenge can only be
+// answered for its own purpose.
+type TwoFactorPurpose string
+
+const (
+	TwoFactorSignIn   TwoFactorPurpose = "sign_in"
+	TwoFactorTransfer TwoFactorPurpose = "transfer"
+)
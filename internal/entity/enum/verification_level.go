@@ -0,0 +1,29 @@
+package enum
+
+type VerificationLevel string
+
+const (
+	VerificationMinimal      VerificationLevel = "minimal"
+	VerificationIntermediate VerificationLevel = "intermediate"
+	VerificationFull         VerificationLevel = "full"
+)
+
+func (l VerificationLevel) IsValid() bool {
+	switch l {
+	case VerificationMinimal, VerificationIntermediate, VerificationFull:
+		return true
+	}
+	return false
+}
+
+var verificationRank = map[VerificationLevel]int{
+	VerificationMinimal:      0,
+	VerificationIntermediate: 1,
+	VerificationFull:         2,
+}
+
+// Covers reports whether a user at level l meets the required level. An unset requirement
+// is met by everyone.
+func (l VerificationLevel) Covers(required VerificationLevel) bool {
+	return verificationRank[l] >= verificationRank[required]
+}
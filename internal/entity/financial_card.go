@@ -9,14 +9,45 @@ import (
 type FinancialCard struct {
 	CardID         int
 	AccountID      int
-	CardNumber     string
+	CardToken      string // Vault token standing in for the card number
+	LastFour       string
 	CardType       enum.FinancialCardType
+	Network        enum.CardNetwork
+	BankID         *int64 // The issuing bank, when the card's BIN names one
 	ExpirationDate time.Time
 	CardHolderName string
-	CVV            string
 	Status         enum.FinancialCardStatus
 	IssuedDate     time.Time
+	ReplacesCardID *int // The card this one was reissued for
+	Virtual        bool // Issued by the wallet rather than registered from a physical card
+	Usage          enum.CardUsage
+	LockedMerchant *string // The only merchant a merchant-locked card pays
+	SpendCap       Money   // The most the card can spend over its life; zero for no cap
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	DeletedAt      *time.Time
 }
+
+// CardEvent is one entry of a card's lifecycle history.
+type CardEvent struct {
+	CardEventID     int
+	FinancialCardID int
+	ActorID         *int // Nil when the system acted
+	Action          enum.CardEventAction
+	FromStatus      enum.FinancialCardStatus
+	ToStatus        enum.FinancialCardStatus
+	Note            *string
+	CreatedAt       time.Time
+}
+
+// IsExpired reports whether the card is past its expiration date. The card can still be
+// used on the expiration date itself.
+func (c *FinancialCard) IsExpired(now time.Time) bool {
+	year, month, day := c.ExpirationDate.Date()
+	return !now.Before(time.Date(year, month, day+1, 0, 0, 0, 0, c.ExpirationDate.Location()))
+}
+
+// MaskedNumber is the only form of the card number shown outside the vault.
+func (c *FinancialCard) MaskedNumber() string {
+	return "**** **** **** " + c.LastFour
+}
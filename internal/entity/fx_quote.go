@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// FXQuote locks the rate of a cross-currency transfer for a short window.
+type FXQuote struct {
+	FXQuoteID         int
+	UserID            int
+	SenderAccountID   int
+	ReceiverAccountID int
+	SourceAmount      Money // Debited from the sender
+	TargetAmount      Money // Credited to the receiver
+	MidRate           Rate
+	Rate              Rate // MidRate less the spread
+	SpreadBps         int64
+	Spread            Money // MidRate conversion minus TargetAmount, in the target currency
+	Status            enum.FXQuoteStatus
+	JournalEntryID    *int
+	ExpiresAt         time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (q *FXQuote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// GiftCard is a prepaid balance that whoever holds its activation code can redeem or spend.
+// Only a hash of the code is kept. The balances of all cards in a currency add up to the
+// gift card liability account of that currency.
+type GiftCard struct {
+	GiftCardID    int
+	PurchaserID   int
+	CodeHash      []byte
+	CodeLastFour  string
+	InitialAmount Money
+	Balance       Money
+	Status        enum.GiftCardStatus
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// GiftCardTransaction is one movement of a gift card's balance.
+type GiftCardTransaction struct {
+	GiftCardTransactionID int
+	GiftCardID            int
+	Type                  enum.GiftCardTransactionType
+	Amount                Money // Negative when the balance goes down
+	BalanceAfter          Money
+	UserID                *int // Nil for breakage
+	FinancialAccountID    *int // The account that paid for the card or received its balance
+	Merchant              *string
+	TransactionGroupID    int
+	CreatedAt             time.Time
+}
+
+func (g *GiftCard) IsExpired(now time.Time) bool {
+	return !now.Before(g.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type IdempotencyKey struct {
+	UserID      int
+	Scope       string
+	Key         string
+	Fingerprint string
+	Status      enum.IdempotencyStatus
+	Response    []byte
+	LockedUntil *time.Time // Lease of an in-flight claim, after which a retry may take it over
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -4,15 +4,30 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTClaims struct {
-	UserID int  `json:"user_id"`
-	Admin  bool `json:"admin"`
+	UserID      int               `json:"user_id"`
+	Admin       bool              `json:"admin"` // Set for superadmins, who skip ownership checks
+	Roles       []enum.Role       `json:"roles,omitempty"`
+	Permissions []enum.Permission `json:"permissions,omitempty"`
+	SessionID   int               `json:"sid,omitempty"` // The session the token was issued to
 	jwt.RegisteredClaims
 }
 
+// Can reports whether the roles of the user grant the permission. Roles are read when the
+// token is issued, so a change to them shows once the token is refreshed.
+func (c *JWTClaims) Can(permission enum.Permission) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *JWTClaims) Valid() error {
 	now := time.Now()
 	if c.ExpiresAt == nil {
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// JournalEntry groups the postings of one business event. Its ID is used as the
+// TransactionGroupID of the account transactions it produces.
+type JournalEntry struct {
+	JournalEntryID  int
+	Description     *string
+	FX              *FXConversion // Set on cross-currency entries
+	ReversesEntryID *int          // Set on entries that reverse, fully or in part, an earlier entry
+	Postings        []*LedgerPosting
+	CreatedAt       time.Time
+}
+
+// FXConversion records the quote a cross-currency entry executed.
+type FXConversion struct {
+	QuoteID int
+	Rate    Rate
+	MidRate Rate
+	Spread  Money
+}
+
+type LedgerPosting struct {
+	PostingID          int
+	JournalEntryID     int
+	FinancialAccountID int
+	Amount             Money // Positive for credits, negative for debits
+	BalanceAfter       Money
+	CreatedAt          time.Time
+}
+
+// IsBalanced reports whether the entry has at least two postings and its postings
+// sum to zero in every currency.
+func (e *JournalEntry) IsBalanced() bool {
+	if len(e.Postings) < 2 {
+		return false
+	}
+
+	sums := make(map[enum.CurrencyCode]int64)
+	for _, p := range e.Postings {
+		sums[p.Amount.Currency] += p.Amount.Amount
+	}
+
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PostingFor returns the posting of the entry that touches accountID, or nil.
+func (e *JournalEntry) PostingFor(accountID int) *LedgerPosting {
+	for _, p := range e.Postings {
+		if p.FinancialAccountID == accountID {
+			return p
+		}
+	}
+	return nil
+}
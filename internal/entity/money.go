@@ -0,0 +1,360 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+	ErrAmountOverflow   = errors.New("money: amount overflow")
+)
+
+// Money is an exact amount expressed in the minor unit of its currency,
+// e.g. cents for USD, yen for JPY and fils for BHD.
+type Money struct {
+	Amount   int64
+	Currency enum.CurrencyCode
+}
+
+func NewMoney(amount int64, currency enum.CurrencyCode) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal string in major units ("12.34") into Money. It rejects
+// more fraction digits than the currency allows instead of rounding them away.
+func ParseMoney(s string, currency enum.CurrencyCode) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidAmount, currency)
+	}
+
+	minor, err := parseDecimal(s, currency.Exponent())
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// String formats the amount in major units without the currency, e.g. "-12.34".
+func (m Money) String() string {
+	return formatDecimal(m.Amount, m.Currency.Exponent())
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.SameCurrency(o) {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Convert converts m into the target currency at rate (units of to per unit of m.Currency),
+// rounding half away from zero to the target's minor unit.
+func (m Money) Convert(rate Rate, to enum.CurrencyCode) (Money, error) {
+	num := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(rate)))
+	num.Mul(num, pow10(to.Exponent()))
+	den := new(big.Int).Mul(big.NewInt(RateScale), pow10(m.Currency.Exponent()))
+
+	converted := divRound(num, den)
+	if !converted.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Amount: converted.Int64(), Currency: to}, nil
+}
+
+// Scale returns m * num / den, rounding half away from zero, so that an amount and its
+// negation always scale to exact opposites. den must be positive.
+func (m Money) Scale(num, den int64) (Money, error) {
+	if den <= 0 {
+		return Money{}, fmt.Errorf("scale: non-positive denominator %d", den)
+	}
+
+	scaled := divRound(new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num)), big.NewInt(den))
+	if !scaled.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Amount: scaled.Int64(), Currency: m.Currency}, nil
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage   `json:"amount"`
+	Currency enum.CurrencyCode `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as a decimal string so that clients never see a float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.String())
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the amount as a decimal string or a JSON number; either way it
+// is parsed from its text, never through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	text := strings.TrimSpace(string(raw.Amount))
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	parsed, err := ParseMoney(text, raw.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as integer minor units. The currency is stored in its own column.
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan reads integer minor units and leaves Currency untouched, so the currency column
+// must be scanned into m.Currency alongside.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		m.Amount = v
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	case nil:
+		m.Amount = 0
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+func (m *Money) scanText(s string) error {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: cannot scan %q: %w", s, err)
+	}
+	m.Amount = amount
+	return nil
+}
+
+// RateScale is the fixed-point scale of Rate, matching DECIMAL(15, 6) in the currency table.
+const RateScale = 1_000_000
+
+// Rate is an exchange rate in millionths.
+type Rate int64
+
+func ParseRate(s string) (Rate, error) {
+	value, err := parseDecimal(s, 6)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("%w: rate must be positive", ErrInvalidAmount)
+	}
+	return Rate(value), nil
+}
+
+func (r Rate) String() string {
+	return formatDecimal(int64(r), 6)
+}
+
+// Float64 is for analytics and display only; never use it to move money.
+func (r Rate) Float64() float64 {
+	return float64(r) / RateScale
+}
+
+// Div returns r/o rounded half away from zero, used to derive cross rates from base rates.
+func (r Rate) Div(o Rate) (Rate, error) {
+	if o == 0 {
+		return 0, fmt.Errorf("%w: division by zero rate", ErrInvalidAmount)
+	}
+	num := new(big.Int).Mul(big.NewInt(int64(r)), big.NewInt(RateScale))
+	q := divRound(num, big.NewInt(int64(o)))
+	if !q.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+	return Rate(q.Int64()), nil
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Value stores the rate as an exact decimal string.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func (r *Rate) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		*r = Rate(v * RateScale)
+		return nil
+	default:
+		return fmt.Errorf("rate: cannot scan %T", src)
+	}
+
+	value, err := parseDecimal(text, 6)
+	if err != nil {
+		return err
+	}
+	*r = Rate(value)
+	return nil
+}
+
+// parseDecimal parses a plain decimal string into an integer scaled by 10^exp.
+func parseDecimal(s string, exp int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidAmount)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasDot := strings.Cut(s, ".")
+	if whole == "" && frac == "" || hasDot && frac == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(frac) > exp {
+		// Trailing zeros beyond the exponent are harmless ("1.500" for USD).
+		if strings.TrimRight(frac[exp:], "0") != "" {
+			return 0, fmt.Errorf("%w: at most %d decimal places allowed", ErrInvalidAmount, exp)
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	digits := whole + frac
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+		}
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		digits = "0"
+	}
+
+	value, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func formatDecimal(value int64, exp int) string {
+	sign := ""
+	u := new(big.Int).SetInt64(value)
+	if value < 0 {
+		sign = "-"
+		u.Neg(u)
+	}
+
+	digits := u.String()
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// divRound divides num by a positive den, rounding half away from zero.
+func divRound(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
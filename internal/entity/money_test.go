@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency enum.CurrencyCode
+		want     int64
+		wantErr  bool
+	}{
+		{name: "two decimals", input: "12.34", currency: enum.USD, want: 1234},
+		{name: "whole amount", input: "12", currency: enum.USD, want: 1200},
+		{name: "one decimal", input: "0.5", currency: enum.EUR, want: 50},
+		{name: "negative", input: "-0.01", currency: enum.USD, want: -1},
+		{name: "trailing zeros beyond exponent", input: "1.500", currency: enum.USD, want: 150},
+		{name: "zero exponent", input: "1500", currency: enum.JPY, want: 1500},
+		{name: "three decimals", input: "1.234", currency: enum.BHD, want: 1234},
+		{name: "too many decimals", input: "12.345", currency: enum.USD, wantErr: true},
+		{name: "decimals for JPY", input: "1.5", currency: enum.KRW, wantErr: true},
+		{name: "not a number", input: "1e3", currency: enum.USD, wantErr: true},
+		{name: "empty", input: "", currency: enum.USD, wantErr: true},
+		{name: "dangling dot", input: "1.", currency: enum.USD, wantErr: true},
+		{name: "unknown currency", input: "1", currency: "XXX", wantErr: true},
+		{name: "overflow", input: "999999999999999999999", currency: enum.USD, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.input, tt.currency)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, NewMoney(tt.want, tt.currency), got)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "12.34", NewMoney(1234, enum.USD).String())
+	assert.Equal(t, "-0.05", NewMoney(-5, enum.USD).String())
+	assert.Equal(t, "1500", NewMoney(1500, enum.JPY).String())
+	assert.Equal(t, "0.007", NewMoney(7, enum.OMR).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1234, enum.USD))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12.34","currency":"USD"}`, string(data))
+
+	var fromString Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"0.1","currency":"USD"}`), &fromString))
+	assert.Equal(t, NewMoney(10, enum.USD), fromString)
+
+	// 0.1 + 0.2 style values must not pick up float error when sent as numbers.
+	var fromNumber Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":0.3,"currency":"USD"}`), &fromNumber))
+	assert.Equal(t, NewMoney(30, enum.USD), fromNumber)
+
+	var tooPrecise Money
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"0.001","currency":"USD"}`), &tooPrecise))
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	sum, err := NewMoney(10, enum.USD).Add(NewMoney(20, enum.USD))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(30, enum.USD), sum)
+
+	_, err = NewMoney(10, enum.USD).Add(NewMoney(20, enum.EUR))
+	assert.True(t, errors.Is(err, ErrCurrencyMismatch))
+
+	cmp, err := NewMoney(10, enum.USD).Cmp(NewMoney(20, enum.USD))
+	require.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+}
+
+func TestMoney_Convert(t *testing.T) {
+	rate, err := ParseRate("151.235")
+	require.NoError(t, err)
+
+	// 10.00 USD at 151.235 JPY/USD is 1512.35 JPY, rounded to 1512.
+	got, err := NewMoney(1000, enum.USD).Convert(rate, enum.JPY)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1512, enum.JPY), got)
+
+	rate, err = ParseRate("0.376")
+	require.NoError(t, err)
+
+	// 1.25 USD at 0.376 BHD/USD is exactly 0.470 BHD.
+	got, err = NewMoney(125, enum.USD).Convert(rate, enum.BHD)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(470, enum.BHD), got)
+}
+
+func TestMoney_Scale(t *testing.T) {
+	// A third of 10.00 USD rounds to 3.33, and its negation to exactly -3.33.
+	got, err := NewMoney(1000, enum.USD).Scale(1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(333, enum.USD), got)
+
+	got, err = NewMoney(-1000, enum.USD).Scale(1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(-333, enum.USD), got)
+
+	// Halves round away from zero.
+	got, err = NewMoney(-5, enum.JPY).Scale(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(-3, enum.JPY), got)
+
+	_, err = NewMoney(5, enum.JPY).Scale(1, 0)
+	assert.Error(t, err)
+}
+
+func TestMoney_Scan(t *testing.T) {
+	m := Money{Currency: enum.USD}
+	require.NoError(t, m.Scan(int64(1234)))
+	assert.Equal(t, NewMoney(1234, enum.USD), m)
+
+	require.NoError(t, m.Scan([]byte("-5")))
+	assert.Equal(t, NewMoney(-5, enum.USD), m)
+
+	assert.Error(t, m.Scan(1.5))
+}
+
+func TestRate(t *testing.T) {
+	var r Rate
+	require.NoError(t, r.Scan([]byte("1.234500")))
+	assert.Equal(t, Rate(1234500), r)
+	assert.Equal(t, "1.234500", r.String())
+
+	cross, err := Rate(2 * RateScale).Div(Rate(4 * RateScale))
+	require.NoError(t, err)
+	assert.Equal(t, Rate(RateScale/2), cross)
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+
+// Notification is a message to a user.
+type Notification struct {
+	UserID int
+	// Channel and Destination address the message to a particular email address or
+	// cellphone. Left empty, it goes wherever the user is normally reached.
+	Channel     enum.ContactChannel
+	Destination string
+	Subject     string
+	Body        string
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// ReviewCase is the manual review of a transfer the risk checks held.
+type ReviewCase struct {
+	ReviewCaseID       int
+	TransactionGroupID int
+	RiskAssessmentID   int
+	Status             enum.ReviewCaseStatus
+	AssigneeID         *int
+	ResolutionEntryID  *int            // The journal entry that released or reversed the held funds
+	Assessment         *RiskAssessment // Loaded with the case
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	ResolvedAt         *time.Time
+}
+
+// ReviewEvent is one entry of a case's audit trail.
+type ReviewEvent struct {
+	ReviewEventID int
+	ReviewCaseID  int
+	ActorID       *int // Nil when the system acted
+	Action        enum.ReviewAction
+	AssigneeID    *int // Set on assignments and claims
+	Note          *string
+	CreatedAt     time.Time
+}
+
+// IsAssignedTo reports whether the case is assigned to the given user.
+func (c *ReviewCase) IsAssignedTo(userID int) bool {
+	return c.AssigneeID != nil && *c.AssigneeID == userID
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// RiskAssessment is the outcome of the risk checks run on a transfer. Decision is the
+// status both legs of the transfer get.
+type RiskAssessment struct {
+	RiskAssessmentID   int
+	TransactionGroupID int
+	FinancialAccountID int // The sender's account
+	Score              int
+	Decision           enum.AccountTransactionStatus
+	Reasons            []RiskReason
+	CreatedAt          time.Time
+}
+
+// RiskReason is a check that scored a transfer and why it did.
+type RiskReason struct {
+	Check   string `json:"check"`
+	Score   int    `json:"score"`
+	Message string `json:"message"`
+}
+
+// IsHeld reports whether the transfer must wait before its funds reach the receiver.
+func (a *RiskAssessment) IsHeld() bool {
+	return a.Decision == enum.OnHold || a.Decision == enum.PendingReview
+}
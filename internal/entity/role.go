@@ -0,0 +1,9 @@
+package entity
+
+import "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+
+type Role struct {
+	Name        enum.Role
+	Description string
+	Permissions []enum.Permission
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// ScheduledTransfer is a one-off future transfer or a standing order. Occurrences are
+// computed from StartAt in Timezone, so monthly orders keep their day of the month.
+type ScheduledTransfer struct {
+	ScheduledTransferID int
+	UserID              int
+	SenderAccountID     int
+	ReceiverAccountID   int
+	Amount              Money
+	Description         string
+	Frequency           enum.TransferFrequency
+	CronExpression      *string // Set when Frequency is cron
+	Timezone            string
+	StartAt             time.Time
+	EndAt               *time.Time
+	NextRunAt           *time.Time // The pending occurrence; nil once none is left
+	RetryAt             *time.Time // Set while a failed occurrence waits for its next attempt
+	Attempts            int        // Failed attempts of the pending occurrence
+	Status              enum.ScheduledTransferStatus
+	LastRunAt           *time.Time
+	LockedUntil         *time.Time // Lease of the executor that claimed the order
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// DueAt returns when the executor should next run the order.
+func (t *ScheduledTransfer) DueAt() *time.Time {
+	if t.RetryAt != nil {
+		return t.RetryAt
+	}
+	return t.NextRunAt
+}
+
+// ScheduledTransferRun records one attempt at an occurrence of a scheduled transfer.
+type ScheduledTransferRun struct {
+	ScheduledTransferRunID int
+	ScheduledTransferID    int
+	OccurrenceAt           time.Time
+	Attempt                int
+	Status                 enum.ScheduledRunStatus
+	TransactionGroupID     *int // The transfer's journal entry when it succeeded
+	Error                  *string
+	StartedAt              time.Time
+	FinishedAt             time.Time
+}
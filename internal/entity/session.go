@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// Session is a sign-in on one device. Its refresh tokens form a family: each one is spent
+// by the refresh that rotates it, and the session ends when a spent one comes back.
+type Session struct {
+	SessionID int
+	UserID    int
+	Device    string
+	IPAddress string
+	UserAgent string
+	// AccessTokenID is the jti of the last access token issued to the session. It is denied
+	// when the token is rotated or the session revoked, so at most one is ever live.
+	AccessTokenID   string
+	AccessExpiresAt time.Time
+	CreatedAt       time.Time
+	LastUsedAt      time.Time
+	ExpiresAt       time.Time
+	RevokedAt       *time.Time
+	RevokeReason    *enum.SessionRevocation
+}
+
+// IsActive reports whether the session can still be refreshed at now.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken is a refresh token of a session, kept only as the hash of its value.
+type RefreshToken struct {
+	TokenHash []byte
+	SessionID int
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
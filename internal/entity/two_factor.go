@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// TwoFactor is the TOTP authenticator of a user. It is pending from enrollment until a
+// first code shows the user's app holds the secret, and only then is it asked for.
+type TwoFactor struct {
+	UserID int
+	// SealedSecret is the base32 secret encrypted under the two-factor secret key.
+	SealedSecret []byte
+	EnabledAt    *time.Time
+	// LastUsedStep is the time step of the last accepted code. A code is accepted once, so
+	// one seen over a shoulder cannot be replayed within its window.
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
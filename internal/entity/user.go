@@ -22,3 +22,15 @@ type User struct {
 	UpdatedAt          time.Time
 	DeletedAt          *time.Time
 }
+
+// VerificationLevel is how much of their contact details the user has proven: minimal with
+// neither, intermediate with their email address or cellphone, full with both.
+func (u User) VerificationLevel() enum.VerificationLevel {
+	switch {
+	case u.ValidatedEmail && u.ValidatedCellphone:
+		return enum.VerificationFull
+	case u.ValidatedEmail || u.ValidatedCellphone:
+		return enum.VerificationIntermediate
+	}
+	return enum.VerificationMinimal
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// VerificationCode is a one-time code sent to prove that the user receives what is sent to
+// an email address or cellphone. It vouches for the Destination it went to only, so a code
+// sent before the user changed that address verifies nothing.
+type VerificationCode struct {
+	VerificationCodeID int
+	UserID             int
+	Channel            enum.ContactChannel
+	Destination        string
+	CodeHash           []byte
+	// Attempts counts the wrong guesses at the code.
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// IsUsable reports whether the code can still be entered.
+func (c *VerificationCode) IsUsable(now time.Time, maxAttempts int) bool {
+	return c.UsedAt == nil && now.Before(c.ExpiresAt) && c.Attempts < maxAttempts
+}
@@ -0,0 +1,21 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+)
+
+type AccountRules interface {
+	GetRules(ctx context.Context, accountID int) (*entity.AccountRules, error)
+	UpdateRules(ctx context.Context, req request.UpdateAccountRules) (*entity.AccountRules, error)
+
+	// Evaluate checks a debit against the rules of its account and returns every rule it breaks.
+	Evaluate(ctx context.Context, req request.EvaluatePolicy) ([]entity.PolicyViolation, error)
+}
+
+type AccountRulesRepository interface {
+	GetByAccountID(ctx context.Context, accountID int) (*entity.AccountRules, error)
+	Upsert(ctx context.Context, rules *entity.AccountRules) error
+}
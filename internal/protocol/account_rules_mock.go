@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccountRulesService struct {
+	mock.Mock
+}
+
+func (m *MockAccountRulesService) GetRules(ctx context.Context, accountID int) (*entity.AccountRules, error) {
+	args := m.Called(ctx, accountID)
+	rules, _ := args.Get(0).(*entity.AccountRules)
+	return rules, args.Error(1)
+}
+
+func (m *MockAccountRulesService) UpdateRules(ctx context.Context, req request.UpdateAccountRules) (*entity.AccountRules, error) {
+	args := m.Called(ctx, req)
+	rules, _ := args.Get(0).(*entity.AccountRules)
+	return rules, args.Error(1)
+}
+
+func (m *MockAccountRulesService) Evaluate(ctx context.Context, req request.EvaluatePolicy) ([]entity.PolicyViolation, error) {
+	args := m.Called(ctx, req)
+	violations, _ := args.Get(0).([]entity.PolicyViolation)
+	return violations, args.Error(1)
+}
+
+type MockAccountRulesRepo struct {
+	mock.Mock
+}
+
+func (m *MockAccountRulesRepo) GetByAccountID(ctx context.Context, accountID int) (*entity.AccountRules, error) {
+	args := m.Called(ctx, accountID)
+	rules, _ := args.Get(0).(*entity.AccountRules)
+	return rules, args.Error(1)
+}
+
+func (m *MockAccountRulesRepo) Upsert(ctx context.Context, rules *entity.AccountRules) error {
+	args := m.Called(ctx, rules)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"context"
+	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
@@ -10,30 +11,34 @@ import (
 
 type AccountTransaction interface {
 	RegisterTransaction(ctx context.Context, req *request.RegisterTransactionRequest) (*response.RegisterTransactionResponse, error)
-	DeleteTransaction(ctx context.Context, transactionID int64) error
 	GetTransactionByID(ctx context.Context, transactionID int64) (*entity.AccountTransaction, error)
 	ListTransactionsByAccountID(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error)
 	ListTransactionsByGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error)
+	// CancelTransaction fully reverses the transaction group the transaction belongs to.
 	CancelTransaction(ctx context.Context, transactionID int64) error
+	// ReverseTransactionGroup posts compensating entries for every leg of a group, for the
+	// whole amount or, as a refund, part of it. Ledger rows are never changed or deleted.
+	ReverseTransactionGroup(ctx context.Context, req request.ReverseTransactionGroup) (response.ReversalResponse, error)
 	Transfer(ctx context.Context, req request.TransferRequest) (res response.TransferResponse, err error)
 	GetAccountTransactionHistory(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error)
+
+	// QuoteTransfer locks an exchange rate for a cross-currency transfer; ExecuteQuote runs it before the quote expires.
+	QuoteTransfer(ctx context.Context, req request.QuoteTransferRequest) (response.TransferQuote, error)
+	ExecuteQuote(ctx context.Context, req request.ExecuteQuoteRequest) (response.TransferResponse, error)
 }
 
 type AccountTransactionRepository interface {
 	Insert(ctx context.Context, transaction *entity.AccountTransaction) error
-	Delete(ctx context.Context, transactionID int64) error
 	GetByID(ctx context.Context, transactionID int64) (*entity.AccountTransaction, error)
 	ListByAccountID(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error)
 	ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error)
-	CreateNewTransactionGroup(ctx context.Context) (int, error)
 	Update(ctx context.Context, transaction *entity.AccountTransaction) error
-	GetCurrentBalance(ctx context.Context, accountID int) (float64, error)
-
-	BeginTx(ctx context.Context) (context.Context, error) // Begins a new transaction
-	CommitTx(ctx context.Context) error                   // Commits the transaction
-	RollbackTx(ctx context.Context) error                 // Rollbacks the transaction
+	CountDebitsSince(ctx context.Context, accountID int, since time.Time) (int, error)
+	// HasPaid reports whether the sender has a completed transfer to the receiver.
+	HasPaid(ctx context.Context, senderAccountID, receiverAccountID int) (bool, error)
+	// AverageDebitSince returns the average debit of an account since the given time in
+	// minor units, as a positive number, or 0 when there was none.
+	AverageDebitSince(ctx context.Context, accountID int, since time.Time) (int64, error)
 
-	// Transfer related methods
-	DebitAccount(ctx context.Context, accountID int, amount float64) error
-	CreditAccount(ctx context.Context, accountID int, amount float64) error
+	Transactor
 }
@@ -0,0 +1,129 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccountTransactionRepo struct {
+	mock.Mock
+}
+
+func (m *MockAccountTransactionRepo) Insert(ctx context.Context, transaction *entity.AccountTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockAccountTransactionRepo) GetByID(ctx context.Context, transactionID int64) (*entity.AccountTransaction, error) {
+	args := m.Called(ctx, transactionID)
+	return args.Get(0).(*entity.AccountTransaction), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) ListByAccountID(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]*entity.AccountTransaction), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).([]*entity.AccountTransaction), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) Update(ctx context.Context, transaction *entity.AccountTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockAccountTransactionRepo) CountDebitsSince(ctx context.Context, accountID int, since time.Time) (int, error) {
+	args := m.Called(ctx, accountID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) HasPaid(ctx context.Context, senderAccountID, receiverAccountID int) (bool, error) {
+	args := m.Called(ctx, senderAccountID, receiverAccountID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) AverageDebitSince(ctx context.Context, accountID int, since time.Time) (int64, error) {
+	args := m.Called(ctx, accountID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(context.Context), args.Error(1)
+}
+
+func (m *MockAccountTransactionRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockAccountTransactionRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type MockAccountTransactionService struct {
+	mock.Mock
+}
+
+func (m *MockAccountTransactionService) RegisterTransaction(ctx context.Context, req *request.RegisterTransactionRequest) (*response.RegisterTransactionResponse, error) {
+	args := m.Called(ctx, req)
+	res, _ := args.Get(0).(*response.RegisterTransactionResponse)
+	return res, args.Error(1)
+}
+
+func (m *MockAccountTransactionService) GetTransactionByID(ctx context.Context, transactionID int64) (*entity.AccountTransaction, error) {
+	args := m.Called(ctx, transactionID)
+	transaction, _ := args.Get(0).(*entity.AccountTransaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockAccountTransactionService) ListTransactionsByAccountID(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error) {
+	args := m.Called(ctx, accountID)
+	transactions, _ := args.Get(0).([]*entity.AccountTransaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockAccountTransactionService) ListTransactionsByGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error) {
+	args := m.Called(ctx, groupID)
+	transactions, _ := args.Get(0).([]*entity.AccountTransaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockAccountTransactionService) CancelTransaction(ctx context.Context, transactionID int64) error {
+	args := m.Called(ctx, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockAccountTransactionService) ReverseTransactionGroup(ctx context.Context, req request.ReverseTransactionGroup) (response.ReversalResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.ReversalResponse), args.Error(1)
+}
+
+func (m *MockAccountTransactionService) Transfer(ctx context.Context, req request.TransferRequest) (response.TransferResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.TransferResponse), args.Error(1)
+}
+
+func (m *MockAccountTransactionService) GetAccountTransactionHistory(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error) {
+	args := m.Called(ctx, accountID)
+	transactions, _ := args.Get(0).([]*entity.AccountTransaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockAccountTransactionService) QuoteTransfer(ctx context.Context, req request.QuoteTransferRequest) (response.TransferQuote, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.TransferQuote), args.Error(1)
+}
+
+func (m *MockAccountTransactionService) ExecuteQuote(ctx context.Context, req request.ExecuteQuoteRequest) (response.TransferResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.TransferResponse), args.Error(1)
+}
@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+// Authorizer decides whether the caller of a request may act on a resource. Users may only
+// reach resources on their own financial accounts; admins may reach any. A refusal is a 403.
+type Authorizer interface {
+	AuthorizeAccount(ctx context.Context, claims *entity.JWTClaims, accountID int) error
+	// AuthorizeCard checks ownership of the account the card draws on.
+	AuthorizeCard(ctx context.Context, claims *entity.JWTClaims, cardID int) error
+	AuthorizeAccountTransaction(ctx context.Context, claims *entity.JWTClaims, transactionID int) error
+	// AuthorizeAccountTransactionGroup lets in a user owning any leg, so both the sender and the
+	// receiver of a transfer can see it. Pass the legs through OwnAccountTransactions before
+	// showing them.
+	AuthorizeAccountTransactionGroup(ctx context.Context, claims *entity.JWTClaims, groupID int) error
+	AuthorizeCardTransaction(ctx context.Context, claims *entity.JWTClaims, transactionID int) error
+	AuthorizeCardTransactionGroup(ctx context.Context, claims *entity.JWTClaims, groupID int) error
+
+	// OwnAccountTransactions and OwnCardTransactions drop the legs on accounts the caller does
+	// not own.
+	OwnAccountTransactions(ctx context.Context, claims *entity.JWTClaims, transactions []*entity.AccountTransaction) ([]*entity.AccountTransaction, error)
+	OwnCardTransactions(ctx context.Context, claims *entity.JWTClaims, transactions []*entity.CardTransaction) ([]*entity.CardTransaction, error)
+}
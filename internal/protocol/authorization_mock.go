@@ -0,0 +1,54 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthorizer struct {
+	mock.Mock
+}
+
+func (m *MockAuthorizer) AuthorizeAccount(ctx context.Context, claims *entity.JWTClaims, accountID int) error {
+	args := m.Called(ctx, claims, accountID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) AuthorizeCard(ctx context.Context, claims *entity.JWTClaims, cardID int) error {
+	args := m.Called(ctx, claims, cardID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) AuthorizeAccountTransaction(ctx context.Context, claims *entity.JWTClaims, transactionID int) error {
+	args := m.Called(ctx, claims, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) AuthorizeAccountTransactionGroup(ctx context.Context, claims *entity.JWTClaims, groupID int) error {
+	args := m.Called(ctx, claims, groupID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) AuthorizeCardTransaction(ctx context.Context, claims *entity.JWTClaims, transactionID int) error {
+	args := m.Called(ctx, claims, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) AuthorizeCardTransactionGroup(ctx context.Context, claims *entity.JWTClaims, groupID int) error {
+	args := m.Called(ctx, claims, groupID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) OwnAccountTransactions(ctx context.Context, claims *entity.JWTClaims, transactions []*entity.AccountTransaction) ([]*entity.AccountTransaction, error) {
+	args := m.Called(ctx, claims, transactions)
+	visible, _ := args.Get(0).([]*entity.AccountTransaction)
+	return visible, args.Error(1)
+}
+
+func (m *MockAuthorizer) OwnCardTransactions(ctx context.Context, claims *entity.JWTClaims, transactions []*entity.CardTransaction) ([]*entity.CardTransaction, error) {
+	args := m.Called(ctx, claims, transactions)
+	visible, _ := args.Get(0).([]*entity.CardTransaction)
+	return visible, args.Error(1)
+}
@@ -0,0 +1,12 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type CardBINRepository interface {
+	// Lookup finds the narrowest range holding the six-digit BIN, or nil when none does.
+	Lookup(ctx context.Context, bin int) (*entity.CardBIN, error)
+}
@@ -0,0 +1,18 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCardBINRepo struct {
+	mock.Mock
+}
+
+func (m *MockCardBINRepo) Lookup(ctx context.Context, bin int) (*entity.CardBIN, error) {
+	args := m.Called(ctx, bin)
+	cardBIN, _ := args.Get(0).(*entity.CardBIN)
+	return cardBIN, args.Error(1)
+}
@@ -0,0 +1,14 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type CardControlsRepository interface {
+	// GetByCardID returns nil when the owner never set controls on the card.
+	GetByCardID(ctx context.Context, cardID int) (*entity.CardControls, error)
+	// Upsert creates the controls of a card or replaces the existing ones.
+	Upsert(ctx context.Context, controls *entity.CardControls) error
+}
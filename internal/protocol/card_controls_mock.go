@@ -0,0 +1,23 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCardControlsRepo struct {
+	mock.Mock
+}
+
+func (m *MockCardControlsRepo) GetByCardID(ctx context.Context, cardID int) (*entity.CardControls, error) {
+	args := m.Called(ctx, cardID)
+	controls, _ := args.Get(0).(*entity.CardControls)
+	return controls, args.Error(1)
+}
+
+func (m *MockCardControlsRepo) Upsert(ctx context.Context, controls *entity.CardControls) error {
+	args := m.Called(ctx, controls)
+	return args.Error(0)
+}
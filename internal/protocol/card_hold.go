@@ -0,0 +1,21 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type CardHoldRepository interface {
+	Insert(ctx context.Context, hold *entity.CardHold) error
+	// Update saves the status, captured amount and capture transaction of the hold.
+	Update(ctx context.Context, hold *entity.CardHold) error
+	Get(ctx context.Context, holdID int) (*entity.CardHold, error)
+	// GetForUpdate is Get with the row locked until the surrounding transaction ends.
+	GetForUpdate(ctx context.Context, holdID int) (*entity.CardHold, error)
+	ListByCardID(ctx context.Context, cardID int) ([]*entity.CardHold, error)
+	// ExpireDue marks up to limit active holds that ran out by now as expired and returns
+	// them. Holds locked by a capture in progress are left for the next sweep.
+	ExpireDue(ctx context.Context, now time.Time, limit int) ([]*entity.CardHold, error)
+}
@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCardHoldRepo struct {
+	mock.Mock
+}
+
+func (m *MockCardHoldRepo) Insert(ctx context.Context, hold *entity.CardHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockCardHoldRepo) Update(ctx context.Context, hold *entity.CardHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockCardHoldRepo) Get(ctx context.Context, holdID int) (*entity.CardHold, error) {
+	args := m.Called(ctx, holdID)
+	hold, _ := args.Get(0).(*entity.CardHold)
+	return hold, args.Error(1)
+}
+
+func (m *MockCardHoldRepo) GetForUpdate(ctx context.Context, holdID int) (*entity.CardHold, error) {
+	args := m.Called(ctx, holdID)
+	hold, _ := args.Get(0).(*entity.CardHold)
+	return hold, args.Error(1)
+}
+
+func (m *MockCardHoldRepo) ListByCardID(ctx context.Context, cardID int) ([]*entity.CardHold, error) {
+	args := m.Called(ctx, cardID)
+	holds, _ := args.Get(0).([]*entity.CardHold)
+	return holds, args.Error(1)
+}
+
+func (m *MockCardHoldRepo) ExpireDue(ctx context.Context, now time.Time, limit int) ([]*entity.CardHold, error) {
+	args := m.Called(ctx, now, limit)
+	holds, _ := args.Get(0).([]*entity.CardHold)
+	return holds, args.Error(1)
+}
@@ -2,32 +2,58 @@ package protocol
 
 import (
 	"context"
+	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
 )
 
 type FinancialCardTransactionService interface {
-	RegisterTransaction(ctx context.Context, request *request.RegisterCardTransaction) (*response.RegisterCardTransactionResponse, error)
+	// Purchase debits the card's linked account and credits the card settlement account.
+	Purchase(ctx context.Context, req request.CardPurchase) (*entity.CardTransaction, error)
+	// Refund returns all or part of a purchase to the card; it can be repeated until the
+	// whole purchase has been refunded.
+	Refund(ctx context.Context, req request.CardRefund) (response.CardRefund, error)
+	Transfer(ctx context.Context, req request.Transfer) (res response.Transfer, err error)
 	GetTransactionByID(ctx context.Context, transactionID int64) (*entity.CardTransaction, error)
 	ListTransactionsByCardID(ctx context.Context, cardID int) ([]*entity.CardTransaction, error)
 	ListTransactionsByGroupID(ctx context.Context, groupID int) ([]*entity.CardTransaction, error)
-	Transfer(ctx context.Context, req request.Transfer) (res response.Transfer, err error)
-	CancelTransaction(ctx context.Context, transactionID int64) error
+
+	// Authorize reserves the amount on the card's linked account without moving it.
+	Authorize(ctx context.Context, req request.CardAuthorization) (*entity.CardHold, error)
+	// Capture settles an authorization for up to the authorized amount and releases the rest.
+	Capture(ctx context.Context, req request.CaptureHold) (response.CardCapture, error)
+	ReleaseHold(ctx context.Context, req request.ReleaseHold) (*entity.CardHold, error)
+	ListHoldsByCardID(ctx context.Context, cardID int) ([]*entity.CardHold, error)
+	GetCardBalance(ctx context.Context, userID, cardID int) (response.CardBalance, error)
+	// ExpireHolds releases the active holds that ran out by now and returns how many it expired.
+	ExpireHolds(ctx context.Context, now time.Time) (int, error)
+	// RunHoldSweeper calls ExpireHolds every sweep interval until ctx is cancelled.
+	RunHoldSweeper(ctx context.Context) error
 }
 
 type CardTransactionRepository interface {
 	Insert(ctx context.Context, transaction *entity.CardTransaction) error
+	Update(ctx context.Context, transaction *entity.CardTransaction) error
 	GetByID(ctx context.Context, transactionID int64) (*entity.CardTransaction, error)
-	ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error)
+	// GetForUpdate is GetByID with the row locked until the surrounding transaction ends.
+	GetForUpdate(ctx context.Context, transactionID int64) (*entity.CardTransaction, error)
+	ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.CardTransaction, error)
 	ListByCardID(ctx context.Context, cardID int) ([]*entity.CardTransaction, error)
+	// RefundedAmount sums the refunds of a purchase in the given currency.
+	RefundedAmount(ctx context.Context, transactionID int64, currency enum.CurrencyCode) (entity.Money, error)
+	// SpentSince sums the card's purchases since the given time, less what has been refunded
+	// of them, and its open authorizations.
+	SpentSince(ctx context.Context, cardID int, currency enum.CurrencyCode, since time.Time) (entity.Money, error)
+	// HasPayments reports whether the card has made a purchase or holds an open or captured
+	// authorization. Released and expired authorizations do not count.
+	HasPayments(ctx context.Context, cardID int) (bool, error)
+	// AssignToStatement puts every transaction of the account's cards that is not yet on a
+	// statement on the given one.
+	AssignToStatement(ctx context.Context, accountID, statementID int) error
+	ListByStatementID(ctx context.Context, statementID int) ([]*entity.CardTransaction, error)
 
-	BeginTx(ctx context.Context) (context.Context, error) // Begins a new transaction
-	CommitTx(ctx context.Context) error                   // Commits the transaction
-	RollbackTx(ctx context.Context) error                 // Rollbacks the transaction
-
-	// Transfer related methods
-	DebitAccount(ctx context.Context, accountID int, amount float64) error
-	CreditAccount(ctx context.Context, accountID int, amount float64) error
+	Transactor
 }
@@ -0,0 +1,89 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCardTransactionRepo struct {
+	mock.Mock
+}
+
+func (m *MockCardTransactionRepo) Insert(ctx context.Context, transaction *entity.CardTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockCardTransactionRepo) Update(ctx context.Context, transaction *entity.CardTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockCardTransactionRepo) GetByID(ctx context.Context, transactionID int64) (*entity.CardTransaction, error) {
+	args := m.Called(ctx, transactionID)
+	transaction, _ := args.Get(0).(*entity.CardTransaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) GetForUpdate(ctx context.Context, transactionID int64) (*entity.CardTransaction, error) {
+	args := m.Called(ctx, transactionID)
+	transaction, _ := args.Get(0).(*entity.CardTransaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.CardTransaction, error) {
+	args := m.Called(ctx, groupID)
+	transactions, _ := args.Get(0).([]*entity.CardTransaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) ListByCardID(ctx context.Context, cardID int) ([]*entity.CardTransaction, error) {
+	args := m.Called(ctx, cardID)
+	transactions, _ := args.Get(0).([]*entity.CardTransaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) RefundedAmount(ctx context.Context, transactionID int64, currency enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, transactionID, currency)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) SpentSince(ctx context.Context, cardID int, currency enum.CurrencyCode, since time.Time) (entity.Money, error) {
+	args := m.Called(ctx, cardID, currency, since)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) HasPayments(ctx context.Context, cardID int) (bool, error) {
+	args := m.Called(ctx, cardID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) AssignToStatement(ctx context.Context, accountID, statementID int) error {
+	args := m.Called(ctx, accountID, statementID)
+	return args.Error(0)
+}
+
+func (m *MockCardTransactionRepo) ListByStatementID(ctx context.Context, statementID int) ([]*entity.CardTransaction, error) {
+	args := m.Called(ctx, statementID)
+	transactions, _ := args.Get(0).([]*entity.CardTransaction)
+	return transactions, args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(context.Context), args.Error(1)
+}
+
+func (m *MockCardTransactionRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockCardTransactionRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,22 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type CardVault interface {
+	// Tokenize seals the card number in the vault and returns the token that stands in for
+	// it everywhere else. A number already in the vault gets its existing token back.
+	Tokenize(ctx context.Context, number string) (token string, err error)
+	// Detokenize opens the vault for the card number behind a token. Only code that talks
+	// to the card network may call it.
+	Detokenize(ctx context.Context, token string) (number string, err error)
+}
+
+type CardVaultRepository interface {
+	Insert(ctx context.Context, entry *entity.VaultEntry) error
+	GetByToken(ctx context.Context, token string) (*entity.VaultEntry, error)
+	GetByFingerprint(ctx context.Context, fingerprint []byte) (*entity.VaultEntry, error)
+}
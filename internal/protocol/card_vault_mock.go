@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCardVaultService struct {
+	mock.Mock
+}
+
+func (m *MockCardVaultService) Tokenize(ctx context.Context, number string) (string, error) {
+	args := m.Called(ctx, number)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCardVaultService) Detokenize(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.Error(1)
+}
+
+type MockCardVaultRepo struct {
+	mock.Mock
+}
+
+func (m *MockCardVaultRepo) Insert(ctx context.Context, entry *entity.VaultEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockCardVaultRepo) GetByToken(ctx context.Context, token string) (*entity.VaultEntry, error) {
+	args := m.Called(ctx, token)
+	entry, _ := args.Get(0).(*entity.VaultEntry)
+	return entry, args.Error(1)
+}
+
+func (m *MockCardVaultRepo) GetByFingerprint(ctx context.Context, fingerprint []byte) (*entity.VaultEntry, error) {
+	args := m.Called(ctx, fingerprint)
+	entry, _ := args.Get(0).(*entity.VaultEntry)
+	return entry, args.Error(1)
+}
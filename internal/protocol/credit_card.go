@@ -0,0 +1,64 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+)
+
+type CreditCard interface {
+	// OpenCreditLine lets a credit card spend up to the limit on credit. The card's linked
+	// account must be empty; what it owes is paid back from the payment account.
+	OpenCreditLine(ctx context.Context, req request.OpenCreditLine) (*entity.CreditLine, error)
+	UpdateCreditLimit(ctx context.Context, req request.UpdateCreditLimit) (*entity.CreditLine, error)
+	GetCreditLine(ctx context.Context, req request.CardAction) (response.CreditLine, error)
+	// MakePayment moves money from the payment account to the card. A payment goes to the
+	// fees owed first, then to the interest charged, and only then to the principal.
+	MakePayment(ctx context.Context, req request.CreditCardPayment) (response.CreditCardPayment, error)
+	ListStatements(ctx context.Context, req request.CardAction) ([]*entity.CreditStatement, error)
+	GetStatement(ctx context.Context, req request.CreditStatement) (response.CreditStatement, error)
+
+	// AccrueInterest adds each day's interest on the principal of revolving lines, up to
+	// the day of now, and returns how many lines it accrued.
+	AccrueInterest(ctx context.Context, now time.Time) (int, error)
+	// CloseStatements charges the accrued interest and closes the statements due by now.
+	CloseStatements(ctx context.Context, now time.Time) (int, error)
+	// ChargeLateFees settles the statements that fell due by now, charging a late fee on
+	// those without the minimum paid.
+	ChargeLateFees(ctx context.Context, now time.Time) (int, error)
+	// RunStatementCycle runs the three steps above every cycle interval until ctx is cancelled.
+	RunStatementCycle(ctx context.Context) error
+}
+
+type CreditLineRepository interface {
+	Insert(ctx context.Context, line *entity.CreditLine) error
+	// Update saves the limit, the amounts due and the cycle dates of the line.
+	Update(ctx context.Context, line *entity.CreditLine) error
+	GetByID(ctx context.Context, creditLineID int) (*entity.CreditLine, error)
+	// GetByCardID returns the line the card draws on: the line of its account, which the
+	// card's renewals and reissues share.
+	GetByCardID(ctx context.Context, cardID int) (*entity.CreditLine, error)
+	// GetByCardIDForUpdate is GetByCardID with the row locked until the surrounding
+	// transaction ends.
+	GetByCardIDForUpdate(ctx context.Context, cardID int) (*entity.CreditLine, error)
+	GetForUpdate(ctx context.Context, creditLineID int) (*entity.CreditLine, error)
+	// ListAccrualDue returns up to limit lines whose interest was last accrued before day.
+	ListAccrualDue(ctx context.Context, day time.Time, limit int) ([]*entity.CreditLine, error)
+	// ListStatementDue returns up to limit lines whose next statement closes by now.
+	ListStatementDue(ctx context.Context, now time.Time, limit int) ([]*entity.CreditLine, error)
+
+	InsertStatement(ctx context.Context, statement *entity.CreditStatement) error
+	// UpdateStatement saves the totals, the payments and the status of the statement.
+	UpdateStatement(ctx context.Context, statement *entity.CreditStatement) error
+	GetStatement(ctx context.Context, statementID int) (*entity.CreditStatement, error)
+	// LatestStatement returns the last statement of the line, or nil before the first closes.
+	LatestStatement(ctx context.Context, creditLineID int) (*entity.CreditStatement, error)
+	ListStatements(ctx context.Context, creditLineID int) ([]*entity.CreditStatement, error)
+	// ListPastDue returns up to limit open statements whose due date passed by now.
+	ListPastDue(ctx context.Context, now time.Time, limit int) ([]*entity.CreditStatement, error)
+
+	Transactor
+}
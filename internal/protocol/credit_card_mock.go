@@ -0,0 +1,171 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCreditCardService struct {
+	mock.Mock
+}
+
+func (m *MockCreditCardService) OpenCreditLine(ctx context.Context, req request.OpenCreditLine) (*entity.CreditLine, error) {
+	args := m.Called(ctx, req)
+	line, _ := args.Get(0).(*entity.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditCardService) UpdateCreditLimit(ctx context.Context, req request.UpdateCreditLimit) (*entity.CreditLine, error) {
+	args := m.Called(ctx, req)
+	line, _ := args.Get(0).(*entity.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditCardService) GetCreditLine(ctx context.Context, req request.CardAction) (response.CreditLine, error) {
+	args := m.Called(ctx, req)
+	line, _ := args.Get(0).(response.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditCardService) MakePayment(ctx context.Context, req request.CreditCardPayment) (response.CreditCardPayment, error) {
+	args := m.Called(ctx, req)
+	payment, _ := args.Get(0).(response.CreditCardPayment)
+	return payment, args.Error(1)
+}
+
+func (m *MockCreditCardService) ListStatements(ctx context.Context, req request.CardAction) ([]*entity.CreditStatement, error) {
+	args := m.Called(ctx, req)
+	statements, _ := args.Get(0).([]*entity.CreditStatement)
+	return statements, args.Error(1)
+}
+
+func (m *MockCreditCardService) GetStatement(ctx context.Context, req request.CreditStatement) (response.CreditStatement, error) {
+	args := m.Called(ctx, req)
+	statement, _ := args.Get(0).(response.CreditStatement)
+	return statement, args.Error(1)
+}
+
+func (m *MockCreditCardService) AccrueInterest(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCreditCardService) CloseStatements(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCreditCardService) ChargeLateFees(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCreditCardService) RunStatementCycle(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type MockCreditLineRepo struct {
+	mock.Mock
+}
+
+func (m *MockCreditLineRepo) Insert(ctx context.Context, line *entity.CreditLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockCreditLineRepo) Update(ctx context.Context, line *entity.CreditLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+
+func (m *MockCreditLineRepo) GetByID(ctx context.Context, creditLineID int) (*entity.CreditLine, error) {
+	args := m.Called(ctx, creditLineID)
+	line, _ := args.Get(0).(*entity.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) GetByCardID(ctx context.Context, cardID int) (*entity.CreditLine, error) {
+	args := m.Called(ctx, cardID)
+	line, _ := args.Get(0).(*entity.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) GetByCardIDForUpdate(ctx context.Context, cardID int) (*entity.CreditLine, error) {
+	args := m.Called(ctx, cardID)
+	line, _ := args.Get(0).(*entity.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) GetForUpdate(ctx context.Context, creditLineID int) (*entity.CreditLine, error) {
+	args := m.Called(ctx, creditLineID)
+	line, _ := args.Get(0).(*entity.CreditLine)
+	return line, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) ListAccrualDue(ctx context.Context, day time.Time, limit int) ([]*entity.CreditLine, error) {
+	args := m.Called(ctx, day, limit)
+	lines, _ := args.Get(0).([]*entity.CreditLine)
+	return lines, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) ListStatementDue(ctx context.Context, now time.Time, limit int) ([]*entity.CreditLine, error) {
+	args := m.Called(ctx, now, limit)
+	lines, _ := args.Get(0).([]*entity.CreditLine)
+	return lines, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) InsertStatement(ctx context.Context, statement *entity.CreditStatement) error {
+	args := m.Called(ctx, statement)
+	return args.Error(0)
+}
+
+func (m *MockCreditLineRepo) UpdateStatement(ctx context.Context, statement *entity.CreditStatement) error {
+	args := m.Called(ctx, statement)
+	return args.Error(0)
+}
+
+func (m *MockCreditLineRepo) GetStatement(ctx context.Context, statementID int) (*entity.CreditStatement, error) {
+	args := m.Called(ctx, statementID)
+	statement, _ := args.Get(0).(*entity.CreditStatement)
+	return statement, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) LatestStatement(ctx context.Context, creditLineID int) (*entity.CreditStatement, error) {
+	args := m.Called(ctx, creditLineID)
+	statement, _ := args.Get(0).(*entity.CreditStatement)
+	return statement, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) ListStatements(ctx context.Context, creditLineID int) ([]*entity.CreditStatement, error) {
+	args := m.Called(ctx, creditLineID)
+	statements, _ := args.Get(0).([]*entity.CreditStatement)
+	return statements, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) ListPastDue(ctx context.Context, now time.Time, limit int) ([]*entity.CreditStatement, error) {
+	args := m.Called(ctx, now, limit)
+	statements, _ := args.Get(0).([]*entity.CreditStatement)
+	return statements, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	txCtx, _ := args.Get(0).(context.Context)
+	return txCtx, args.Error(1)
+}
+
+func (m *MockCreditLineRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockCreditLineRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -17,16 +17,17 @@ type Currency interface {
 	GetCurrency(ctx context.Context, currencyID int) (entity.Currency, error)
 	GetCurrencyByName(ctx context.Context, currencyName enum.CurrencyName) (entity.Currency, error)
 	ListCurrencies(ctx context.Context) ([]entity.Currency, error)
-	GetExchangeRate(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode) (float64, error)
+	GetExchangeRate(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode) (entity.Rate, error)
 	IsCurrrencyExist(ctx context.Context, currencyID int) (bool, error)
 
 	// Update exchange rates for multiple currencies at once.
-	BulkUpdateExchangeRates(ctx context.Context, rates map[enum.CurrencyCode]float64) error
+	BulkUpdateExchangeRates(ctx context.Context, rates map[enum.CurrencyCode]entity.Rate) error
 
 	// Search for currencies based on partial matches (e.g., name, code, or symbol).
 	SearchCurrencies(ctx context.Context, query string) ([]entity.Currency, error)
 
-	ConvertAmount(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode, amount float64) (float64, error)
+	// Convert an amount into another currency, rounded to the target currency's minor unit.
+	ConvertAmount(ctx context.Context, amount entity.Money, toCode enum.CurrencyCode) (entity.Money, error)
 
 	// Compare two currencies – could be useful for traders or for users trying to decide which currency to transact in.
 	CompareCurrencies(ctx context.Context, firstCode enum.CurrencyCode, secondCode enum.CurrencyCode) (response.CurrencyComparison, error)
@@ -67,7 +68,7 @@ type CurrencyRepository interface {
 	Search(ctx context.Context, query string) ([]entity.Currency, error)
 
 	// Retrieve the latest exchange rates for all currencies.
-	GetLatestExchangeRates(ctx context.Context) (map[enum.CurrencyCode]float64, error)
+	GetLatestExchangeRates(ctx context.Context) (map[enum.CurrencyCode]entity.Rate, error)
 
 	// Retrieve historical exchange rates of a currency between two dates.
 	GetBetweenDates(ctx context.Context, code enum.CurrencyCode, startDate, endDate time.Time) ([]entity.Currency, error)
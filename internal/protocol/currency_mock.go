@@ -0,0 +1,101 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCurrencyService struct {
+	mock.Mock
+}
+
+func (m *MockCurrencyService) AddCurrency(ctx context.Context, req request.AddCurrency) (response.AddCurrency, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.AddCurrency), args.Error(1)
+}
+
+func (m *MockCurrencyService) UpdateCurrency(ctx context.Context, req request.UpdateCurrency) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) DeleteCurrency(ctx context.Context, currencyID int) error {
+	args := m.Called(ctx, currencyID)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) GetCurrency(ctx context.Context, currencyID int) (entity.Currency, error) {
+	args := m.Called(ctx, currencyID)
+	return args.Get(0).(entity.Currency), args.Error(1)
+}
+
+func (m *MockCurrencyService) GetCurrencyByName(ctx context.Context, currencyName enum.CurrencyName) (entity.Currency, error) {
+	args := m.Called(ctx, currencyName)
+	return args.Get(0).(entity.Currency), args.Error(1)
+}
+
+func (m *MockCurrencyService) ListCurrencies(ctx context.Context) ([]entity.Currency, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.Currency), args.Error(1)
+}
+
+func (m *MockCurrencyService) GetExchangeRate(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode) (entity.Rate, error) {
+	args := m.Called(ctx, fromCode, toCode)
+	return args.Get(0).(entity.Rate), args.Error(1)
+}
+
+func (m *MockCurrencyService) IsCurrrencyExist(ctx context.Context, currencyID int) (bool, error) {
+	args := m.Called(ctx, currencyID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCurrencyService) BulkUpdateExchangeRates(ctx context.Context, rates map[enum.CurrencyCode]entity.Rate) error {
+	args := m.Called(ctx, rates)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) SearchCurrencies(ctx context.Context, query string) ([]entity.Currency, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]entity.Currency), args.Error(1)
+}
+
+func (m *MockCurrencyService) ConvertAmount(ctx context.Context, amount entity.Money, toCode enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, amount, toCode)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockCurrencyService) CompareCurrencies(ctx context.Context, firstCode enum.CurrencyCode, secondCode enum.CurrencyCode) (response.CurrencyComparison, error) {
+	args := m.Called(ctx, firstCode, secondCode)
+	return args.Get(0).(response.CurrencyComparison), args.Error(1)
+}
+
+func (m *MockCurrencyService) GetCurrencyTrends(ctx context.Context, code enum.CurrencyCode, duration time.Duration) (response.CurrencyTrends, error) {
+	args := m.Called(ctx, code, duration)
+	return args.Get(0).(response.CurrencyTrends), args.Error(1)
+}
+
+func (m *MockCurrencyService) GetStrongestCurrency(ctx context.Context) (entity.Currency, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(entity.Currency), args.Error(1)
+}
+
+func (m *MockCurrencyService) GetWeakestCurrency(ctx context.Context) (entity.Currency, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(entity.Currency), args.Error(1)
+}
+
+func (m *MockCurrencyService) NotifyUsersOnExchangeRateChange(ctx context.Context, threshold float64) error {
+	args := m.Called(ctx, threshold)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyService) GetCountriesUsingCurrency(ctx context.Context, code enum.CurrencyCode) ([]string, error) {
+	args := m.Called(ctx, code)
+	return args.Get(0).([]string), args.Error(1)
+}
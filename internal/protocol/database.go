@@ -1,8 +1,19 @@
 package protocol
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type Database interface {
 	Close() error
 	DB() *sql.DB
 }
+
+// Transactor is a unit of work carried in the context. Every repository method
+// called with the returned context joins the same database transaction.
+type Transactor interface {
+	BeginTx(ctx context.Context) (context.Context, error) // Begins a new transaction
+	CommitTx(ctx context.Context) error                   // Commits the transaction
+	RollbackTx(ctx context.Context) error                 // Rollbacks the transaction
+}
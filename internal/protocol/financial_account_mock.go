@@ -0,0 +1,85 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFinancialAccountService struct {
+	mock.Mock
+}
+
+func (m *MockFinancialAccountService) CreateAccount(ctx context.Context, req request.RegisterFinancialAccount) (response.RegisterFinancialAccount, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.RegisterFinancialAccount), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) GetAccountByID(ctx context.Context, accountID int) (response.GetFinancialAccount, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(response.GetFinancialAccount), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) IsAccountExist(ctx context.Context, accountID int) (bool, error) {
+	args := m.Called(ctx, accountID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) UpdateAccount(ctx context.Context, req request.UpdateFinancialAccount) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockFinancialAccountService) DeleteAccount(ctx context.Context, accountID int) error {
+	args := m.Called(ctx, accountID)
+	return args.Error(0)
+}
+
+func (m *MockFinancialAccountService) ListAccountsByUserID(ctx context.Context, userID int) ([]entity.FinancialAccount, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.FinancialAccount), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) ListAccountsByStatus(ctx context.Context, status enum.FinancialAccountStatus) ([]*entity.FinancialAccount, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*entity.FinancialAccount), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) GetAccountStatus(ctx context.Context, accountID int) (enum.FinancialAccountStatus, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(enum.FinancialAccountStatus), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) VerifyAccount(ctx context.Context, accountID int) error {
+	args := m.Called(ctx, accountID)
+	return args.Error(0)
+}
+
+func (m *MockFinancialAccountService) GetAccountByShaba(ctx context.Context, shabaNumber string) (response.GetFinancialAccount, error) {
+	args := m.Called(ctx, shabaNumber)
+	return args.Get(0).(response.GetFinancialAccount), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) ListAccountsByType(ctx context.Context, accountType string) ([]*entity.FinancialAccount, error) {
+	args := m.Called(ctx, accountType)
+	return args.Get(0).([]*entity.FinancialAccount), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) GetAccountCurrency(ctx context.Context, accountID int) (response.GetCurrency, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(response.GetCurrency), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) GetBranchForAccount(ctx context.Context, accountID int) (response.GetBankBranch, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(response.GetBankBranch), args.Error(1)
+}
+
+func (m *MockFinancialAccountService) GetBankForAccount(ctx context.Context, accountID int) (response.GetBank, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(response.GetBank), args.Error(1)
+}
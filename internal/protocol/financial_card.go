@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"context"
+	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
@@ -15,6 +16,31 @@ type FinancialCard interface {
 	GetCardByID(ctx context.Context, cardID int64) (*entity.FinancialCard, error)
 	ListCardsByAccountID(ctx context.Context, accountID int) ([]*entity.FinancialCard, error)
 	ListCardsByType(ctx context.Context, cardType enum.FinancialCardType) ([]*entity.FinancialCard, error)
+
+	FreezeCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error)
+	UnfreezeCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error)
+	// ReportCardLost and ReportCardStolen end the card for good; the owner can have it reissued.
+	ReportCardLost(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error)
+	ReportCardStolen(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error)
+	// ReissueCard issues a new number and expiry on the same BIN and returns the new card. A
+	// card still in use is marked replaced.
+	ReissueCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error)
+	ListCardEvents(ctx context.Context, req request.CardAction) ([]*entity.CardEvent, error)
+
+	// IssueVirtualCard returns the new card along with its full number, which is shown once.
+	IssueVirtualCard(ctx context.Context, req request.IssueVirtualCard) (*entity.FinancialCard, string, error)
+
+	// GetCardControls returns the card's controls; a card without any has everything allowed.
+	GetCardControls(ctx context.Context, req request.CardAction) (*entity.CardControls, error)
+	UpdateCardControls(ctx context.Context, req request.UpdateCardControls) (*entity.CardControls, error)
+
+	// RenewExpiringCards renews a batch of cards expiring within the notice window and tells
+	// their owners, returning how many were renewed.
+	RenewExpiringCards(ctx context.Context, now time.Time) (int, error)
+	// ExpireCards ends a batch of cards past their expiration date.
+	ExpireCards(ctx context.Context, now time.Time) (int, error)
+	// RunCardRenewal renews and expires cards every interval until ctx is cancelled.
+	RunCardRenewal(ctx context.Context) error
 }
 
 type FinancialCardRepository interface {
@@ -22,6 +48,20 @@ type FinancialCardRepository interface {
 	Update(ctx context.Context, card *entity.FinancialCard) error
 	Delete(ctx context.Context, cardID int64) error
 	GetByID(ctx context.Context, cardID int64) (*entity.FinancialCard, error)
+	// GetForUpdate is GetByID with the row locked until the surrounding transaction ends.
+	GetForUpdate(ctx context.Context, cardID int64) (*entity.FinancialCard, error)
+	// GetReplacement returns the card reissued for cardID, or nil when it was never reissued.
+	GetReplacement(ctx context.Context, cardID int64) (*entity.FinancialCard, error)
 	ListByAccountID(ctx context.Context, accountID int) ([]*entity.FinancialCard, error)
 	ListByCardType(ctx context.Context, cardType enum.FinancialCardType) ([]*entity.FinancialCard, error)
+	// ListExpiring returns up to limit physical cards in use that expire before the given time
+	// and have not been replaced, soonest first.
+	ListExpiring(ctx context.Context, before time.Time, limit int) ([]*entity.FinancialCard, error)
+	// ListExpired returns up to limit active, inactive or frozen cards whose expiration date
+	// is before today, oldest first.
+	ListExpired(ctx context.Context, today time.Time, limit int) ([]*entity.FinancialCard, error)
+	InsertEvent(ctx context.Context, event *entity.CardEvent) error
+	ListEvents(ctx context.Context, cardID int64) ([]*entity.CardEvent, error)
+
+	Transactor
 }
@@ -0,0 +1,204 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFinancialCardService struct {
+	mock.Mock
+}
+
+func (m *MockFinancialCardService) RegisterCard(ctx context.Context, req *request.RegisterFinancialCard) (int, error) {
+	args := m.Called(ctx, req)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockFinancialCardService) UpdateCard(ctx context.Context, req *request.UpdateFinancialCard) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardService) DeleteCard(ctx context.Context, cardID int64) error {
+	args := m.Called(ctx, cardID)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardService) GetCardByID(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, cardID)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardService) ListCardsByAccountID(ctx context.Context, accountID int) ([]*entity.FinancialCard, error) {
+	args := m.Called(ctx, accountID)
+	cards, _ := args.Get(0).([]*entity.FinancialCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockFinancialCardService) ListCardsByType(ctx context.Context, cardType enum.FinancialCardType) ([]*entity.FinancialCard, error) {
+	args := m.Called(ctx, cardType)
+	cards, _ := args.Get(0).([]*entity.FinancialCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockFinancialCardService) FreezeCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardService) UnfreezeCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardService) ReportCardLost(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardService) ReportCardStolen(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardService) ReissueCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardService) ListCardEvents(ctx context.Context, req request.CardAction) ([]*entity.CardEvent, error) {
+	args := m.Called(ctx, req)
+	events, _ := args.Get(0).([]*entity.CardEvent)
+	return events, args.Error(1)
+}
+
+func (m *MockFinancialCardService) IssueVirtualCard(ctx context.Context, req request.IssueVirtualCard) (*entity.FinancialCard, string, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.String(1), args.Error(2)
+}
+
+func (m *MockFinancialCardService) GetCardControls(ctx context.Context, req request.CardAction) (*entity.CardControls, error) {
+	args := m.Called(ctx, req)
+	controls, _ := args.Get(0).(*entity.CardControls)
+	return controls, args.Error(1)
+}
+
+func (m *MockFinancialCardService) UpdateCardControls(ctx context.Context, req request.UpdateCardControls) (*entity.CardControls, error) {
+	args := m.Called(ctx, req)
+	controls, _ := args.Get(0).(*entity.CardControls)
+	return controls, args.Error(1)
+}
+
+func (m *MockFinancialCardService) RenewExpiringCards(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockFinancialCardService) ExpireCards(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockFinancialCardService) RunCardRenewal(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type MockFinancialCardRepo struct {
+	mock.Mock
+}
+
+func (m *MockFinancialCardRepo) Insert(ctx context.Context, card *entity.FinancialCard) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardRepo) Update(ctx context.Context, card *entity.FinancialCard) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardRepo) Delete(ctx context.Context, cardID int64) error {
+	args := m.Called(ctx, cardID)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardRepo) GetByID(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, cardID)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) ListByAccountID(ctx context.Context, accountID int) ([]*entity.FinancialCard, error) {
+	args := m.Called(ctx, accountID)
+	cards, _ := args.Get(0).([]*entity.FinancialCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) ListByCardType(ctx context.Context, cardType enum.FinancialCardType) ([]*entity.FinancialCard, error) {
+	args := m.Called(ctx, cardType)
+	cards, _ := args.Get(0).([]*entity.FinancialCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) ListExpiring(ctx context.Context, before time.Time, limit int) ([]*entity.FinancialCard, error) {
+	args := m.Called(ctx, before, limit)
+	cards, _ := args.Get(0).([]*entity.FinancialCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) ListExpired(ctx context.Context, today time.Time, limit int) ([]*entity.FinancialCard, error) {
+	args := m.Called(ctx, today, limit)
+	cards, _ := args.Get(0).([]*entity.FinancialCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) GetForUpdate(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, cardID)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) GetReplacement(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	args := m.Called(ctx, cardID)
+	card, _ := args.Get(0).(*entity.FinancialCard)
+	return card, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) InsertEvent(ctx context.Context, event *entity.CardEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardRepo) ListEvents(ctx context.Context, cardID int64) ([]*entity.CardEvent, error) {
+	args := m.Called(ctx, cardID)
+	events, _ := args.Get(0).([]*entity.CardEvent)
+	return events, args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(context.Context), args.Error(1)
+}
+
+func (m *MockFinancialCardRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockFinancialCardRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,14 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type FXQuoteRepository interface {
+	Insert(ctx context.Context, quote *entity.FXQuote) error
+	// GetForUpdate locks the quote until the end of the caller's transaction.
+	GetForUpdate(ctx context.Context, quoteID int) (*entity.FXQuote, error)
+	MarkExecuted(ctx context.Context, quoteID int, journalEntryID int) error
+}
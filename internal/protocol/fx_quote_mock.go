@@ -0,0 +1,28 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFXQuoteRepo struct {
+	mock.Mock
+}
+
+func (m *MockFXQuoteRepo) Insert(ctx context.Context, quote *entity.FXQuote) error {
+	args := m.Called(ctx, quote)
+	return args.Error(0)
+}
+
+func (m *MockFXQuoteRepo) GetForUpdate(ctx context.Context, quoteID int) (*entity.FXQuote, error) {
+	args := m.Called(ctx, quoteID)
+	quote, _ := args.Get(0).(*entity.FXQuote)
+	return quote, args.Error(1)
+}
+
+func (m *MockFXQuoteRepo) MarkExecuted(ctx context.Context, quoteID int, journalEntryID int) error {
+	args := m.Called(ctx, quoteID, journalEntryID)
+	return args.Error(0)
+}
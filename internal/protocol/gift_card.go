@@ -0,0 +1,50 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+)
+
+type GiftCard interface {
+	// IssueGiftCard loads a new gift card from one of the user's accounts and returns it
+	// with its activation code. The code is shown this once and never stored.
+	IssueGiftCard(ctx context.Context, req request.IssueGiftCard) (*entity.GiftCard, string, error)
+	// RedeemGiftCard moves the card's whole balance into one of the user's accounts.
+	// Redeeming the same card into the same account again returns the first redemption.
+	RedeemGiftCard(ctx context.Context, req request.RedeemGiftCard) (*entity.GiftCardTransaction, error)
+	// SpendGiftCard pays a merchant from the card's balance.
+	SpendGiftCard(ctx context.Context, req request.SpendGiftCard) (*entity.GiftCardTransaction, error)
+	GetGiftCardBalance(ctx context.Context, req request.GiftCardCode) (*entity.GiftCard, error)
+	// ListGiftCards returns the gift cards the user has bought.
+	ListGiftCards(ctx context.Context, userID int) ([]*entity.GiftCard, error)
+}
+
+type GiftCardRepository interface {
+	Insert(ctx context.Context, card *entity.GiftCard) error
+	// Update saves the balance and status of the card.
+	Update(ctx context.Context, card *entity.GiftCard) error
+	GetByCodeHash(ctx context.Context, codeHash []byte) (*entity.GiftCard, error)
+	// GetByCodeHashForUpdate is GetByCodeHash with the row locked until the surrounding
+	// transaction ends.
+	GetByCodeHashForUpdate(ctx context.Context, codeHash []byte) (*entity.GiftCard, error)
+	GetForUpdate(ctx context.Context, giftCardID int) (*entity.GiftCard, error)
+	ListByPurchaserID(ctx context.Context, userID int) ([]*entity.GiftCard, error)
+	// ListExpired returns up to limit active cards that expired by now, oldest first.
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*entity.GiftCard, error)
+
+	InsertTransaction(ctx context.Context, transaction *entity.GiftCardTransaction) error
+	// GetRedemption returns the transaction that redeemed the card, or nil.
+	GetRedemption(ctx context.Context, giftCardID int) (*entity.GiftCardTransaction, error)
+
+	// ClaimAttempt records a code attempt for the user unless max attempts are already
+	// recorded since the given time, and returns its ID. The count and the insert run under
+	// a lock on the user's row, so parallel guesses cannot all pass the same count.
+	ClaimAttempt(ctx context.Context, userID int, since time.Time, max int) (int, bool, error)
+	// ForgetAttempt deletes an attempt whose code turned out to be right.
+	ForgetAttempt(ctx context.Context, attemptID int) error
+
+	Transactor
+}
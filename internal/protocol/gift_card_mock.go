@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGiftCardService struct {
+	mock.Mock
+}
+
+func (m *MockGiftCardService) IssueGiftCard(ctx context.Context, req request.IssueGiftCard) (*entity.GiftCard, string, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.GiftCard)
+	return card, args.String(1), args.Error(2)
+}
+
+func (m *MockGiftCardService) RedeemGiftCard(ctx context.Context, req request.RedeemGiftCard) (*entity.GiftCardTransaction, error) {
+	args := m.Called(ctx, req)
+	transaction, _ := args.Get(0).(*entity.GiftCardTransaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockGiftCardService) SpendGiftCard(ctx context.Context, req request.SpendGiftCard) (*entity.GiftCardTransaction, error) {
+	args := m.Called(ctx, req)
+	transaction, _ := args.Get(0).(*entity.GiftCardTransaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockGiftCardService) GetGiftCardBalance(ctx context.Context, req request.GiftCardCode) (*entity.GiftCard, error) {
+	args := m.Called(ctx, req)
+	card, _ := args.Get(0).(*entity.GiftCard)
+	return card, args.Error(1)
+}
+
+func (m *MockGiftCardService) ListGiftCards(ctx context.Context, userID int) ([]*entity.GiftCard, error) {
+	args := m.Called(ctx, userID)
+	cards, _ := args.Get(0).([]*entity.GiftCard)
+	return cards, args.Error(1)
+}
+
+type MockGiftCardRepo struct {
+	mock.Mock
+}
+
+func (m *MockGiftCardRepo) Insert(ctx context.Context, card *entity.GiftCard) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepo) Update(ctx context.Context, card *entity.GiftCard) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepo) GetByCodeHash(ctx context.Context, codeHash []byte) (*entity.GiftCard, error) {
+	args := m.Called(ctx, codeHash)
+	card, _ := args.Get(0).(*entity.GiftCard)
+	return card, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) GetByCodeHashForUpdate(ctx context.Context, codeHash []byte) (*entity.GiftCard, error) {
+	args := m.Called(ctx, codeHash)
+	card, _ := args.Get(0).(*entity.GiftCard)
+	return card, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) GetForUpdate(ctx context.Context, giftCardID int) (*entity.GiftCard, error) {
+	args := m.Called(ctx, giftCardID)
+	card, _ := args.Get(0).(*entity.GiftCard)
+	return card, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) ListByPurchaserID(ctx context.Context, userID int) ([]*entity.GiftCard, error) {
+	args := m.Called(ctx, userID)
+	cards, _ := args.Get(0).([]*entity.GiftCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]*entity.GiftCard, error) {
+	args := m.Called(ctx, now, limit)
+	cards, _ := args.Get(0).([]*entity.GiftCard)
+	return cards, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) InsertTransaction(ctx context.Context, transaction *entity.GiftCardTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepo) GetRedemption(ctx context.Context, giftCardID int) (*entity.GiftCardTransaction, error) {
+	args := m.Called(ctx, giftCardID)
+	transaction, _ := args.Get(0).(*entity.GiftCardTransaction)
+	return transaction, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) ClaimAttempt(ctx context.Context, userID int, since time.Time, max int) (int, bool, error) {
+	args := m.Called(ctx, userID, since, max)
+	return args.Int(0), args.Bool(1), args.Error(2)
+}
+
+func (m *MockGiftCardRepo) ForgetAttempt(ctx context.Context, attemptID int) error {
+	args := m.Called(ctx, attemptID)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	txCtx, _ := args.Get(0).(context.Context)
+	return txCtx, args.Error(1)
+}
+
+func (m *MockGiftCardRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
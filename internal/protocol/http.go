@@ -17,4 +17,5 @@ type Success struct {
 
 type Error struct {
 	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
 }
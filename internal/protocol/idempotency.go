@@ -0,0 +1,30 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type Idempotency interface {
+	// Begin claims key for the user and scope. A fresh claim is carried by the returned
+	// context; a completed key with the same request returns the stored response instead.
+	Begin(ctx context.Context, userID int, scope, key string, req any) (context.Context, []byte, error)
+	// Complete stores the response of the claim carried by ctx. It joins the caller's
+	// database transaction, so the response is stored only if the operation commits.
+	Complete(ctx context.Context, resp any) error
+	// Release drops the claim carried by ctx after a failed operation so the client can retry.
+	Release(ctx context.Context) error
+}
+
+type IdempotencyRepository interface {
+	// Claim inserts the key, or takes over an expired one or an in-flight one for the same
+	// request whose lease has lapsed. It reports false when a live key exists.
+	Claim(ctx context.Context, key *entity.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, userID int, scope, key string) (*entity.IdempotencyKey, error)
+	// Complete and Delete act only on the claim holding the lease lockedUntil, reporting
+	// false when it was taken over.
+	Complete(ctx context.Context, userID int, scope, key string, lockedUntil time.Time, response []byte) (bool, error)
+	Delete(ctx context.Context, userID int, scope, key string, lockedUntil time.Time) (bool, error)
+}
@@ -0,0 +1,54 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyService) Begin(ctx context.Context, userID int, scope, key string, req any) (context.Context, []byte, error) {
+	args := m.Called(ctx, userID, scope, key, req)
+	replay, _ := args.Get(1).([]byte)
+	return args.Get(0).(context.Context), replay, args.Error(2)
+}
+
+func (m *MockIdempotencyService) Complete(ctx context.Context, resp any) error {
+	args := m.Called(ctx, resp)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyService) Release(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type MockIdempotencyRepo struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepo) Claim(ctx context.Context, key *entity.IdempotencyKey) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Get(ctx context.Context, userID int, scope, key string) (*entity.IdempotencyKey, error) {
+	args := m.Called(ctx, userID, scope, key)
+	record, _ := args.Get(0).(*entity.IdempotencyKey)
+	return record, args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Complete(ctx context.Context, userID int, scope, key string, lockedUntil time.Time, response []byte) (bool, error) {
+	args := m.Called(ctx, userID, scope, key, lockedUntil, response)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Delete(ctx context.Context, userID int, scope, key string, lockedUntil time.Time) (bool, error) {
+	args := m.Called(ctx, userID, scope, key, lockedUntil)
+	return args.Bool(0), args.Error(1)
+}
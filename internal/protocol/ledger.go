@@ -0,0 +1,29 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type LedgerRepository interface {
+	// Post appends a balanced journal entry with its postings and updates the cached
+	// balances. JournalEntryID and the postings' BalanceAfter are filled in on success.
+	Post(ctx context.Context, entry *entity.JournalEntry) error
+	GetJournalEntry(ctx context.Context, journalEntryID int) (*entity.JournalEntry, error)
+	ListPostingsByAccountID(ctx context.Context, accountID int) ([]*entity.LedgerPosting, error)
+	// ReversedAmount sums what the entries reversing journalEntryID have posted so far to
+	// the account in the given currency.
+	ReversedAmount(ctx context.Context, journalEntryID, accountID int, currency enum.CurrencyCode) (entity.Money, error)
+
+	// GetBalance returns the cached balance of the account in the given currency.
+	GetBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error)
+	// LockBalance is GetBalance with the balance row locked until the surrounding transaction ends.
+	LockBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error)
+	// DerivedBalance recomputes the balance from the postings, ignoring the cache.
+	DerivedBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error)
+	// HeldAmount sums what the open card authorization holds reserve on the account. The
+	// available balance is the balance less this amount.
+	HeldAmount(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error)
+}
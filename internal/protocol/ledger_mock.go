@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLedgerRepo struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepo) Post(ctx context.Context, entry *entity.JournalEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepo) GetJournalEntry(ctx context.Context, journalEntryID int) (*entity.JournalEntry, error) {
+	args := m.Called(ctx, journalEntryID)
+	return args.Get(0).(*entity.JournalEntry), args.Error(1)
+}
+
+func (m *MockLedgerRepo) ListPostingsByAccountID(ctx context.Context, accountID int) ([]*entity.LedgerPosting, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]*entity.LedgerPosting), args.Error(1)
+}
+
+func (m *MockLedgerRepo) ReversedAmount(ctx context.Context, journalEntryID, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, journalEntryID, accountID, currency)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockLedgerRepo) GetBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, accountID, currency)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockLedgerRepo) LockBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, accountID, currency)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockLedgerRepo) DerivedBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, accountID, currency)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
+func (m *MockLedgerRepo) HeldAmount(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	args := m.Called(ctx, accountID, currency)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
@@ -0,0 +1,12 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+// Notifier delivers messages to users, abstracting the email or SMS gateway.
+type Notifier interface {
+	Notify(ctx context.Context, notification entity.Notification) error
+}
@@ -0,0 +1,75 @@
+package request
+
+import (
+	"errors"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type UpdateAccountRules struct {
+	FinancialAccountID int `param:"accountID"`
+
+	DailyLimit   int
+	WeeklyLimit  int
+	MonthlyLimit int
+
+	MinAmount                  entity.Money
+	MaxAmount                  entity.Money
+	MaxFailedTransactionsDaily int
+	NewAccountMaxAmount        entity.Money
+	MinBalanceRequired         entity.Money
+
+	AllowedCountries       []string
+	DisallowedCountries    []string
+	NotifyOnNewLocation    bool
+	BlockOnUnusualLocation bool
+	AllowedRegions         []string
+	AllowedCities          []string
+
+	Require2FAForAmount entity.Money
+	VerificationLevel   enum.VerificationLevel
+
+	PasswordChangeCoolingPeriod       int
+	NewTransactionMethodCoolingPeriod int
+}
+
+func (req *UpdateAccountRules) Validate() error {
+	if req.FinancialAccountID <= 0 {
+		return errors.New("invalid financial account ID")
+	}
+
+	if req.DailyLimit < 0 || req.WeeklyLimit < 0 || req.MonthlyLimit < 0 || req.MaxFailedTransactionsDaily < 0 {
+		return errors.New("limits cannot be negative")
+	}
+
+	amounts := []entity.Money{req.MinAmount, req.MaxAmount, req.NewAccountMaxAmount, req.MinBalanceRequired, req.Require2FAForAmount}
+	for _, amount := range amounts {
+		if amount.IsNegative() {
+			return errors.New("amounts cannot be negative")
+		}
+	}
+
+	if !req.MaxAmount.IsZero() && req.MinAmount.Amount > req.MaxAmount.Amount {
+		return errors.New("min amount cannot exceed max amount")
+	}
+
+	if req.VerificationLevel != "" && !req.VerificationLevel.IsValid() {
+		return errors.New("invalid verification level")
+	}
+
+	if req.PasswordChangeCoolingPeriod < 0 || req.NewTransactionMethodCoolingPeriod < 0 {
+		return errors.New("cooling periods cannot be negative")
+	}
+
+	return nil
+}
+
+// EvaluatePolicy describes a debit to be checked against the rules of its account.
+type EvaluatePolicy struct {
+	FinancialAccountID   int
+	Amount               entity.Money // Leaving the account, positive
+	Balance              entity.Money // Before the debit
+	SecondFactorVerified bool
+	TwoFactorExempt      bool // Nobody is there to answer a challenge, see entity.SecondFactor
+}
@@ -2,13 +2,14 @@ package request
 
 import (
 	"errors"
-	"math"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 )
 
 type RegisterTransactionRequest struct {
 	TransactionGroupID int
 	FinancialAccountID int
-	Amount             float64
+	Amount             entity.Money
 	Description        *string
 }
 
@@ -23,15 +24,13 @@ func (req *RegisterTransactionRequest) Validate() error {
 		return errors.New("Invalid Financial Account ID")
 	}
 
-	// Check if Amount is within range.
-	// In this case, we use 15 digits with 2 decimal places as in your DECIMAL(15, 2) field.
-	// Make sure to adapt as per your business logic.
-	if req.Amount < -9999999999999.99 || req.Amount > 9999999999999.99 {
-		return errors.New("Amount out of range")
+	// The amount is exact minor units; its currency must be known so the exponent is defined.
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("Invalid currency")
 	}
 
 	// Optionally, you can check for a zero amount if your business logic does not allow it.
-	if req.Amount == 0 {
+	if req.Amount.IsZero() {
 		return errors.New("Amount cannot be zero")
 	}
 
@@ -48,10 +47,14 @@ func (req *RegisterTransactionRequest) Validate() error {
 }
 
 type TransferRequest struct {
+	UserID            int `json:"-"`
 	SenderAccountID   int
 	ReceiverAccountID int
-	Amount            float64
+	Amount            entity.Money
 	Description       string
+	// TwoFactor answers the challenge a transfer above the account's two-factor threshold
+	// is first turned down with.
+	TwoFactor *TwoFactorAnswer
 }
 
 func (req *TransferRequest) Validate() error {
@@ -63,13 +66,16 @@ func (req *TransferRequest) Validate() error {
 		return errors.New("invalid receiver account ID")
 	}
 
-	if req.Amount <= 0 {
-		return errors.New("invalid transfer amount")
+	if req.SenderAccountID == req.ReceiverAccountID {
+		return errors.New("sender and receiver accounts must be different")
 	}
 
-	// Check if the amount has more than 2 decimal places
-	if math.Round(req.Amount*100) != req.Amount*100 {
-		return errors.New("amount can have up to 2 decimal places")
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid transfer currency")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("invalid transfer amount")
 	}
 
 	// Assuming a max description length of 255
@@ -79,3 +85,84 @@ func (req *TransferRequest) Validate() error {
 
 	return nil
 }
+
+type QuoteTransferRequest struct {
+	UserID            int `json:"-"`
+	SenderAccountID   int
+	ReceiverAccountID int
+	Amount            entity.Money // In the sender's currency
+}
+
+func (req *QuoteTransferRequest) Validate() error {
+	if req.SenderAccountID <= 0 {
+		return errors.New("invalid sender account ID")
+	}
+
+	if req.ReceiverAccountID <= 0 {
+		return errors.New("invalid receiver account ID")
+	}
+
+	if req.SenderAccountID == req.ReceiverAccountID {
+		return errors.New("sender and receiver accounts must be different")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid transfer currency")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("invalid transfer amount")
+	}
+
+	return nil
+}
+
+type ExecuteQuoteRequest struct {
+	UserID      int `json:"-"`
+	QuoteID     int `param:"quoteID"`
+	Description string
+	TwoFactor   *TwoFactorAnswer
+}
+
+func (req *ExecuteQuoteRequest) Validate() error {
+	if req.QuoteID <= 0 {
+		return errors.New("invalid quote ID")
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	return nil
+}
+
+// ReverseTransactionGroup undoes a transfer with a compensating entry. Amount is in the
+// currency of the debited leg; when it is nil, whatever has not been reversed yet is.
+type ReverseTransactionGroup struct {
+	UserID             int `json:"-"`
+	TransactionGroupID int `param:"groupID"`
+	Amount             *entity.Money
+	Description        string
+}
+
+func (req *ReverseTransactionGroup) Validate() error {
+	if req.TransactionGroupID <= 0 {
+		return errors.New("invalid transaction group ID")
+	}
+
+	if req.Amount != nil {
+		if !req.Amount.Currency.IsValid() {
+			return errors.New("invalid reversal currency")
+		}
+
+		if !req.Amount.IsPositive() {
+			return errors.New("invalid reversal amount")
+		}
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	return nil
+}
@@ -1,15 +1,227 @@
 package request
 
-type RegisterCardTransaction struct {
-	TransactionGroupID int
-	FinancialCardID    int
-	Amount             float64
-	Description        string
+import (
+	"errors"
+	"regexp"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+var (
+	mccPattern     = regexp.MustCompile(`^\d{4}$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+type CardPurchase struct {
+	UserID      int `json:"-"`
+	CardID      int
+	Amount      entity.Money
+	Merchant    string
+	MCC         string // Merchant category code, four digits
+	Country     string // ISO 3166 alpha-2 code of the merchant; empty for a domestic merchant
+	Online      bool   // Card not present
+	Description string
+}
+
+func (req *CardPurchase) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid purchase currency")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("invalid purchase amount")
+	}
+
+	if req.Merchant == "" || len(req.Merchant) > 255 {
+		return errors.New("merchant must be between 1 and 255 characters")
+	}
+
+	if err := validateMerchantDetails(req.MCC, req.Country); err != nil {
+		return err
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	return nil
+}
+
+func validateMerchantDetails(mcc, country string) error {
+	if mcc != "" && !mccPattern.MatchString(mcc) {
+		return errors.New("the merchant category code must be four digits")
+	}
+
+	if country != "" && !countryPattern.MatchString(country) {
+		return errors.New("the merchant country must be an ISO 3166 alpha-2 code")
+	}
+
+	return nil
+}
+
+// CardRefund returns money for a purchase. Without an amount, what is left of the
+// purchase is refunded.
+type CardRefund struct {
+	TransactionID int64 `param:"transactionID"`
+	Amount        *entity.Money
+	Description   string
+}
+
+func (req *CardRefund) Validate() error {
+	if req.TransactionID <= 0 {
+		return errors.New("invalid transaction ID")
+	}
+
+	if req.Amount != nil && (!req.Amount.Currency.IsValid() || !req.Amount.IsPositive()) {
+		return errors.New("invalid refund amount")
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	return nil
 }
 
 type Transfer struct {
+	UserID         int `json:"-"`
 	SenderCardID   int
 	ReceiverCardID int
-	Amount         float64
+	Amount         entity.Money
 	Description    string
+	// TwoFactor answers the challenge a transfer above the account's two-factor threshold
+	// is first turned down with.
+	TwoFactor *TwoFactorAnswer
+}
+
+func (req *Transfer) Validate() error {
+	if req.SenderCardID <= 0 {
+		return errors.New("invalid sender card ID")
+	}
+
+	if req.ReceiverCardID <= 0 {
+		return errors.New("invalid receiver card ID")
+	}
+
+	if req.SenderCardID == req.ReceiverCardID {
+		return errors.New("sender and receiver cards must be different")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid transfer currency")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("invalid transfer amount")
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	return nil
+}
+
+type CardAuthorization struct {
+	UserID      int `json:"-"`
+	CardID      int
+	Amount      entity.Money
+	Merchant    string
+	MCC         string // Merchant category code, four digits
+	Country     string // ISO 3166 alpha-2 code of the merchant; empty for a domestic merchant
+	Online      bool   // Card not present
+	Description string
+}
+
+func (req *CardAuthorization) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid authorization currency")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("invalid authorization amount")
+	}
+
+	if req.Merchant == "" || len(req.Merchant) > 255 {
+		return errors.New("merchant must be between 1 and 255 characters")
+	}
+
+	if err := validateMerchantDetails(req.MCC, req.Country); err != nil {
+		return err
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	return nil
+}
+
+// CaptureHold settles an authorization. Without an amount, the whole authorized amount
+// is captured.
+type CaptureHold struct {
+	HoldID int `param:"holdID"`
+	Amount *entity.Money
+}
+
+func (req *CaptureHold) Validate() error {
+	if req.HoldID <= 0 {
+		return errors.New("invalid hold ID")
+	}
+
+	if req.Amount != nil && (!req.Amount.Currency.IsValid() || !req.Amount.IsPositive()) {
+		return errors.New("invalid capture amount")
+	}
+
+	return nil
+}
+
+type ReleaseHold struct {
+	HoldID int `param:"holdID"`
+}
+
+func (req *ReleaseHold) Validate() error {
+	if req.HoldID <= 0 {
+		return errors.New("invalid hold ID")
+	}
+
+	return nil
+}
+
+// UpdateCardControls replaces the controls of a card. Limits are in the currency of the
+// card's account; a zero or omitted limit is no limit.
+type UpdateCardControls struct {
+	UserID                int `json:"-"`
+	CardID                int `param:"cardID"`
+	OnlineDisabled        bool
+	InternationalDisabled bool
+	BlockedMCCs           []string
+	PerTransactionLimit   entity.Money
+	DailyLimit            entity.Money
+}
+
+func (req *UpdateCardControls) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
+	}
+
+	for _, mcc := range req.BlockedMCCs {
+		if !mccPattern.MatchString(mcc) {
+			return errors.New("merchant category codes must be four digits")
+		}
+	}
+
+	if req.PerTransactionLimit.IsNegative() || req.DailyLimit.IsNegative() {
+		return errors.New("limits cannot be negative")
+	}
+
+	return nil
 }
@@ -0,0 +1,105 @@
+package request
+
+import (
+	"errors"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+// OpenCreditLine gives a credit card a line of credit. The limit is in the currency of
+// the card's linked account.
+type OpenCreditLine struct {
+	CardID           int `param:"cardID"`
+	PaymentAccountID int
+	Limit            entity.Money
+	APR              int // Annual percentage rate in basis points
+	StatementDay     int // Day of the month statements close on
+}
+
+func (req *OpenCreditLine) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
+	}
+
+	if req.PaymentAccountID <= 0 {
+		return errors.New("invalid payment account ID")
+	}
+
+	if err := validateCreditLimit(req.Limit); err != nil {
+		return err
+	}
+
+	if req.APR < 0 || req.APR > 10000 {
+		return errors.New("the APR must be between 0 and 10000 basis points")
+	}
+
+	// Every month has these days, so statements close on the same day each month
+	if req.StatementDay < 1 || req.StatementDay > 28 {
+		return errors.New("the statement day must be between 1 and 28")
+	}
+
+	return nil
+}
+
+type UpdateCreditLimit struct {
+	CardID int `param:"cardID"`
+	Limit  entity.Money
+}
+
+func (req *UpdateCreditLimit) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
+	}
+
+	return validateCreditLimit(req.Limit)
+}
+
+// CreditCardPayment pays Amount towards the card's balance from its payment account.
+type CreditCardPayment struct {
+	UserID int `json:"-"`
+	CardID int `param:"cardID"`
+	Amount entity.Money
+	// TwoFactor answers the challenge a payment above the account's two-factor threshold
+	// is first turned down with.
+	TwoFactor *TwoFactorAnswer
+}
+
+func (req *CreditCardPayment) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("the amount must be positive")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid currency")
+	}
+
+	return nil
+}
+
+type CreditStatement struct {
+	UserID      int `json:"-"`
+	StatementID int `param:"statementID"`
+}
+
+func (req *CreditStatement) Validate() error {
+	if req.StatementID <= 0 {
+		return errors.New("invalid statement ID")
+	}
+	return nil
+}
+
+func validateCreditLimit(limit entity.Money) error {
+	if limit.IsNegative() {
+		return errors.New("the credit limit must not be negative")
+	}
+
+	if !limit.Currency.IsValid() {
+		return errors.New("invalid currency")
+	}
+
+	return nil
+}
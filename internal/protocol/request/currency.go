@@ -3,6 +3,7 @@ package request
 import (
 	"time"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 )
 
@@ -10,7 +11,7 @@ type AddCurrency struct {
 	CurrencyCode enum.CurrencyCode
 	CurrencyName enum.CurrencyName
 	Symbol       enum.CurrencySymbol
-	ExchangeRate *float64
+	ExchangeRate *entity.Rate
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -20,12 +21,12 @@ type UpdateCurrency struct {
 	CurrencyCode enum.CurrencyCode
 	CurrencyName enum.CurrencyName
 	Symbol       enum.CurrencySymbol
-	ExchangeRate *float64
+	ExchangeRate *entity.Rate
 	UpdatedAt    time.Time
 }
 
 type BulkUpdateExchangeRates struct {
-	Rates map[enum.CurrencyCode]float64
+	Rates map[enum.CurrencyCode]entity.Rate
 }
 
 type SearchCurrencies struct {
@@ -33,9 +34,8 @@ type SearchCurrencies struct {
 }
 
 type ConvertAmount struct {
-	FromCode enum.CurrencyCode
-	ToCode   enum.CurrencyCode
-	Amount   float64
+	Amount entity.Money
+	ToCode enum.CurrencyCode
 }
 
 type CompareCurrencies struct {
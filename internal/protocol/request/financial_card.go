@@ -5,9 +5,13 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/luhn"
 )
 
+// RegisterFinancialCard carries the only copy of the card number and CVV the service ever
+// sees: the number goes to the vault and the CVV is checked and dropped.
 type RegisterFinancialCard struct {
 	AccountID      int
 	CardNumber     string
@@ -15,7 +19,6 @@ type RegisterFinancialCard struct {
 	ExpirationDate time.Time
 	CardHolderName string
 	CVV            string
-	Status         enum.FinancialCardStatus
 }
 
 func (r *RegisterFinancialCard) Validate() error {
@@ -23,7 +26,8 @@ func (r *RegisterFinancialCard) Validate() error {
 		return errors.New("invalid AccountID")
 	}
 
-	if len(r.CardNumber) < 16 || len(r.CardNumber) > 19 {
+	isCardNumberValid, _ := regexp.MatchString(`^\d{16,19}$`, r.CardNumber)
+	if !isCardNumberValid || !luhn.Valid(r.CardNumber) {
 		return errors.New("invalid CardNumber")
 	}
 
@@ -31,6 +35,10 @@ func (r *RegisterFinancialCard) Validate() error {
 		return errors.New("invalid CardType")
 	}
 
+	if r.CardType == enum.Gift {
+		return errors.New("gift cards are bought through the gift card endpoints, not registered")
+	}
+
 	if time.Now().After(r.ExpirationDate) {
 		return errors.New("invalid ExpirationDate")
 	}
@@ -44,21 +52,14 @@ func (r *RegisterFinancialCard) Validate() error {
 		return errors.New("invalid CVV")
 	}
 
-	if !enum.IsValidFinancialCardStatus(r.Status) { // Assuming you have a function to validate the enum
-		return errors.New("invalid Status")
-	}
-
 	return nil
 }
 
+// UpdateFinancialCard changes only what the holder can correct on a card. The status
+// moves through the lifecycle actions and a new number or expiry means a reissue.
 type UpdateFinancialCard struct {
 	CardID         int64
-	CardNumber     string
-	CardType       enum.FinancialCardType
-	ExpirationDate time.Time
 	CardHolderName string
-	CVV            string
-	Status         enum.FinancialCardStatus
 }
 
 func (u *UpdateFinancialCard) Validate() error {
@@ -67,34 +68,73 @@ func (u *UpdateFinancialCard) Validate() error {
 		return errors.New("invalid CardID")
 	}
 
-	// Validate CardNumber
-	if len(u.CardNumber) < 16 || len(u.CardNumber) > 19 {
-		return errors.New("invalid CardNumber length")
+	// Validate CardHolderName
+	if len(u.CardHolderName) == 0 || len(u.CardHolderName) > 100 {
+		return errors.New("invalid CardHolderName length")
 	}
 
-	// Validate CardType
-	if !enum.IsValidFinancialCardType(u.CardType) {
-		return errors.New("invalid CardType")
+	return nil
+}
+
+// CardAction is an owner's lifecycle action on a card.
+type CardAction struct {
+	UserID int    `json:"-"`
+	CardID int    `param:"cardID"`
+	Note   string // Why the owner acted, kept with the card's history
+}
+
+func (req *CardAction) Validate() error {
+	if req.CardID <= 0 {
+		return errors.New("invalid card ID")
 	}
 
-	// Validate ExpirationDate
-	if u.ExpirationDate.Before(time.Now()) {
-		return errors.New("invalid ExpirationDate")
+	if len(req.Note) > 500 {
+		return errors.New("the note must not exceed 500 characters")
 	}
 
-	// Validate CardHolderName
-	if len(u.CardHolderName) == 0 || len(u.CardHolderName) > 100 {
-		return errors.New("invalid CardHolderName length")
+	return nil
+}
+
+// IssueVirtualCard asks the wallet for a virtual debit card on one of the user's accounts.
+// Without an ExpirationDate the card gets the longest validity, and a zero SpendCap leaves
+// it uncapped.
+type IssueVirtualCard struct {
+	UserID         int `json:"-"`
+	AccountID      int
+	CardHolderName string
+	Usage          enum.CardUsage
+	Merchant       string // The merchant a merchant-locked card is locked to
+	ExpirationDate *time.Time
+	SpendCap       entity.Money
+}
+
+func (req *IssueVirtualCard) Validate() error {
+	if req.AccountID <= 0 {
+		return errors.New("invalid account ID")
 	}
 
-	// Validate CVV
-	if len(u.CVV) != 3 && len(u.CVV) != 4 {
-		return errors.New("invalid CVV")
+	if len(req.CardHolderName) == 0 || len(req.CardHolderName) > 100 {
+		return errors.New("invalid card holder name length")
+	}
+
+	if !req.Usage.IsValid() {
+		return errors.New("invalid usage")
+	}
+
+	if (req.Usage == enum.MerchantLocked) != (req.Merchant != "") {
+		return errors.New("a merchant is given for, and only for, a merchant-locked card")
+	}
+
+	if len(req.Merchant) > 255 {
+		return errors.New("the merchant must not exceed 255 characters")
+	}
+
+	if req.ExpirationDate != nil && !req.ExpirationDate.After(time.Now()) {
+		return errors.New("the expiration date must be in the future")
 	}
 
-	// Validate Status
-	if !enum.IsValidFinancialCardStatus(u.Status) {
-		return errors.New("invalid Status")
+	if req.SpendCap.IsNegative() {
+		return errors.New("the spend cap cannot be negative")
 	}
 
 	return nil
@@ -0,0 +1,98 @@
+package request
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+// IssueGiftCard buys a gift card loaded with Amount from one of the user's accounts.
+type IssueGiftCard struct {
+	UserID    int `json:"-"`
+	AccountID int
+	Amount    entity.Money
+	// TwoFactor answers the challenge a purchase above the account's two-factor threshold
+	// is first turned down with.
+	TwoFactor *TwoFactorAnswer
+}
+
+func (req *IssueGiftCard) Validate() error {
+	if req.AccountID <= 0 {
+		return errors.New("invalid account ID")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("the amount must be positive")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid currency")
+	}
+
+	return nil
+}
+
+// GiftCardCode identifies a gift card by its activation code. Letters may be in either case
+// and spaces and dashes are ignored.
+type GiftCardCode struct {
+	UserID int `json:"-"`
+	Code   string
+}
+
+func (req *GiftCardCode) Validate() error {
+	return validateGiftCardCode(req.Code)
+}
+
+type RedeemGiftCard struct {
+	UserID    int `json:"-"`
+	Code      string
+	AccountID int // The account the balance goes into
+}
+
+func (req *RedeemGiftCard) Validate() error {
+	if err := validateGiftCardCode(req.Code); err != nil {
+		return err
+	}
+
+	if req.AccountID <= 0 {
+		return errors.New("invalid account ID")
+	}
+
+	return nil
+}
+
+type SpendGiftCard struct {
+	UserID      int `json:"-"`
+	Code        string
+	Amount      entity.Money
+	Merchant    string
+	Description string
+}
+
+func (req *SpendGiftCard) Validate() error {
+	if err := validateGiftCardCode(req.Code); err != nil {
+		return err
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("the amount must be positive")
+	}
+
+	if strings.TrimSpace(req.Merchant) == "" || len(req.Merchant) > 255 {
+		return errors.New("invalid merchant")
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("the description must not exceed 255 characters")
+	}
+
+	return nil
+}
+
+func validateGiftCardCode(code string) error {
+	if code == "" || len(code) > 64 {
+		return errors.New("invalid gift card code")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package request
+
+import (
+	"errors"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+const (
+	defaultReviewListLimit = 50
+	maxReviewListLimit     = 200
+)
+
+type ListReviewCases struct {
+	Status     enum.ReviewCaseStatus `query:"status"`     // Open cases by default
+	AssigneeID int                   `query:"assigneeID"` // Any assignee when zero
+	Limit      int                   `query:"limit"`
+}
+
+func (req *ListReviewCases) Validate() error {
+	if req.Status > enum.ReviewRejected {
+		return errors.New("invalid review case status")
+	}
+
+	if req.AssigneeID < 0 {
+		return errors.New("invalid assignee ID")
+	}
+
+	if req.Limit < 0 || req.Limit > maxReviewListLimit {
+		return errors.New("limit must be between 1 and 200")
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultReviewListLimit
+	}
+
+	return nil
+}
+
+// ReviewCaseAction is a reviewer claiming, approving or rejecting a case.
+type ReviewCaseAction struct {
+	ReviewerID   int `json:"-"`
+	ReviewCaseID int `param:"caseID"`
+	Note         *string
+}
+
+func (req *ReviewCaseAction) Validate() error {
+	if req.ReviewCaseID <= 0 {
+		return errors.New("invalid review case ID")
+	}
+
+	if req.Note != nil && len(*req.Note) > 1000 {
+		return errors.New("note must be at most 1000 characters")
+	}
+
+	return nil
+}
+
+type AssignReviewCase struct {
+	ReviewerID   int `json:"-"`
+	ReviewCaseID int `param:"caseID"`
+	AssigneeID   int
+	Note         *string
+}
+
+func (req *AssignReviewCase) Validate() error {
+	if req.ReviewCaseID <= 0 {
+		return errors.New("invalid review case ID")
+	}
+
+	if req.AssigneeID <= 0 {
+		return errors.New("invalid assignee ID")
+	}
+
+	if req.Note != nil && len(*req.Note) > 1000 {
+		return errors.New("note must be at most 1000 characters")
+	}
+
+	return nil
+}
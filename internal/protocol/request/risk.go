@@ -0,0 +1,17 @@
+package request
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+// AssessRisk describes a transfer for the risk checks, before it is posted.
+type AssessRisk struct {
+	UserID            int
+	SenderAccountID   int
+	ReceiverAccountID int
+	Amount            entity.Money // Debited from the sender, in the sender's currency
+	Balance           entity.Money // The sender's balance before the transfer
+	At                time.Time
+}
@@ -0,0 +1,26 @@
+package request
+
+import (
+	"errors"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// UserRole grants or takes away a role of a user.
+type UserRole struct {
+	ActorID int       `json:"-"`
+	UserID  int       `param:"userID"`
+	Role    enum.Role `param:"role"`
+}
+
+func (req *UserRole) Validate() error {
+	if req.UserID <= 0 {
+		return errors.New("invalid user ID")
+	}
+
+	if req.Role == "" {
+		return errors.New("role is required")
+	}
+
+	return nil
+}
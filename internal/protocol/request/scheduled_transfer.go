@@ -0,0 +1,83 @@
+package request
+
+import (
+	"errors"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type CreateScheduledTransfer struct {
+	UserID            int `json:"-"`
+	SenderAccountID   int
+	ReceiverAccountID int
+	Amount            entity.Money
+	Description       string
+	Frequency         enum.TransferFrequency
+	CronExpression    string    // Required when Frequency is cron
+	Timezone          string    // Occurrences are computed in this zone, UTC by default
+	StartAt           time.Time // The first occurrence, or the earliest one for cron schedules
+	EndAt             *time.Time
+}
+
+func (req *CreateScheduledTransfer) Validate() error {
+	if req.SenderAccountID <= 0 {
+		return errors.New("invalid sender account ID")
+	}
+
+	if req.ReceiverAccountID <= 0 {
+		return errors.New("invalid receiver account ID")
+	}
+
+	if req.SenderAccountID == req.ReceiverAccountID {
+		return errors.New("sender and receiver accounts must be different")
+	}
+
+	if !req.Amount.Currency.IsValid() {
+		return errors.New("invalid transfer currency")
+	}
+
+	if !req.Amount.IsPositive() {
+		return errors.New("invalid transfer amount")
+	}
+
+	if len(req.Description) > 255 {
+		return errors.New("description too long")
+	}
+
+	if !req.Frequency.IsValid() {
+		return errors.New("invalid frequency")
+	}
+
+	if (req.Frequency == enum.FrequencyCron) != (req.CronExpression != "") {
+		return errors.New("a cron expression is required for cron schedules, and only for them")
+	}
+
+	if req.StartAt.IsZero() {
+		return errors.New("start time is required")
+	}
+
+	if req.EndAt != nil && !req.EndAt.After(req.StartAt) {
+		return errors.New("end time must be after the start time")
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	return nil
+}
+
+type ScheduledTransferAction struct {
+	UserID              int `json:"-"`
+	ScheduledTransferID int `param:"scheduledTransferID"`
+}
+
+func (req *ScheduledTransferAction) Validate() error {
+	if req.ScheduledTransferID <= 0 {
+		return errors.New("invalid scheduled transfer ID")
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package request
+
+import "errors"
+
+// TwoFactorCode is a code from the user's authenticator app, or one of their recovery codes
+// where the action accepts them.
+type TwoFactorCode struct {
+	UserID int    `json:"-"`
+	Code   string `json:"code"`
+}
+
+func (req TwoFactorCode) Validate() error {
+	if req.Code == "" {
+		return errors.New("code is required")
+	}
+
+	return nil
+}
+
+// TwoFactorAnswer answers a challenge with a code.
+type TwoFactorAnswer struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+func (req TwoFactorAnswer) Validate() error {
+	if req.Challenge == "" {
+		return errors.New("challenge is required")
+	}
+
+	if req.Code == "" {
+		return errors.New("code is required")
+	}
+
+	return nil
+}
+
+// SignInTwoFactor completes a sign-in that was answered with a challenge.
+type SignInTwoFactor struct {
+	TwoFactorAnswer
+	Client
+}
@@ -1,6 +1,7 @@
 package request
 
 import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror/message"
 )
@@ -12,6 +13,7 @@ type SignUp struct {
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
 	Cellphone string `json:"cellphone"`
+	Client
 }
 
 func (req SignUp) Validate() error {
@@ -72,6 +74,7 @@ func (req SignUp) Validate() error {
 type SignIn struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Client
 }
 
 func (req SignIn) Validate() error {
@@ -86,6 +89,42 @@ func (req SignIn) Validate() error {
 	return nil
 }
 
+// Client describes where a session is used from. The device is named by the client, the
+// rest is read from the request.
+type Client struct {
+	Device    string `json:"device"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token.
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+	Client
+}
+
+func (req RefreshToken) Validate() error {
+	if len(req.RefreshToken) == 0 {
+		return derror.NewBadRequestError(message.InvalidRequest)
+	}
+
+	return nil
+}
+
+// RevokeSession signs the user out of one of their sessions.
+type RevokeSession struct {
+	UserID    int `json:"-"`
+	SessionID int `param:"sessionID"`
+}
+
+func (req RevokeSession) Validate() error {
+	if req.SessionID <= 0 {
+		return derror.NewBadRequestError(message.InvalidRequest)
+	}
+
+	return nil
+}
+
 type EditProfile struct {
 	UserID    int    `json:"-"`
 	Password  string `json:"password"`
@@ -99,3 +138,33 @@ func (req EditProfile) Validate() error {
 	// todo
 	return nil
 }
+
+// SendVerificationCode asks for a code to prove the email address or cellphone on the
+// profile reaches the user.
+type SendVerificationCode struct {
+	UserID  int                 `json:"-"`
+	Channel enum.ContactChannel `param:"channel"`
+}
+
+func (req SendVerificationCode) Validate() error {
+	if !req.Channel.IsValid() {
+		return derror.NewBadRequestError(message.InvalidRequest)
+	}
+
+	return nil
+}
+
+// ConfirmContact returns the code sent by SendVerificationCode.
+type ConfirmContact struct {
+	UserID  int                 `json:"-"`
+	Channel enum.ContactChannel `param:"channel"`
+	Code    string              `json:"code"`
+}
+
+func (req ConfirmContact) Validate() error {
+	if !req.Channel.IsValid() || req.Code == "" {
+		return derror.NewBadRequestError(message.InvalidRequest)
+	}
+
+	return nil
+}
@@ -16,4 +16,22 @@ type RegisterTransactionResponse struct {
 type TransferResponse struct {
 	SenderTx   entity.AccountTransaction
 	ReceiverTx entity.AccountTransaction
+	Quote      *TransferQuote `json:",omitempty"` // Set when the accounts use different currencies
+}
+
+type TransferQuote struct {
+	QuoteID      int
+	SourceAmount entity.Money
+	TargetAmount entity.Money
+	MidRate      entity.Rate
+	Rate         entity.Rate
+	Spread       entity.Money
+	ExpiresAt    time.Time
+}
+
+type ReversalResponse struct {
+	JournalEntryID int
+	ReversalTxs    []*entity.AccountTransaction // The compensating legs, one per original leg
+	OriginalTxs    []*entity.AccountTransaction // The original legs with their updated status
+	Remaining      entity.Money                 // What can still be reversed, in the debited leg's currency
 }
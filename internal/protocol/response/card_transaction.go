@@ -1,19 +1,32 @@
 package response
 
 import (
-	"time"
-
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
-	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 )
 
-type RegisterCardTransactionResponse struct {
-	TransactionID int
-	Status        enum.CardTransactionStatus
-	CreatedAt     time.Time
-}
-
 type Transfer struct {
 	SenderTx   entity.CardTransaction
 	ReceiverTx entity.CardTransaction
 }
+
+type CardRefund struct {
+	RefundTx   entity.CardTransaction
+	PurchaseTx entity.CardTransaction
+	Refundable entity.Money // What is left of the purchase to refund
+}
+
+type CardCapture struct {
+	PurchaseTx entity.CardTransaction
+	Hold       entity.CardHold
+}
+
+// CardBalance tells the balance of the card's linked account apart from what can be spent.
+type CardBalance struct {
+	CardID    int
+	AccountID int
+	Ledger    entity.Money // Posted to the ledger
+	Held      entity.Money // Reserved by open authorizations
+	Available entity.Money // Ledger less Held, plus the credit limit of credit cards
+	// CreditLimit is set on credit cards, whose Ledger goes below zero as they borrow
+	CreditLimit *entity.Money `json:",omitempty"`
+}
@@ -0,0 +1,25 @@
+package response
+
+import "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+
+type CreditLine struct {
+	*entity.CreditLine
+	Owed      entity.Money // Posted to the card's account and not yet paid back
+	Principal entity.Money // Owed less the interest and fees due
+	Held      entity.Money // Reserved by open authorizations
+	Available entity.Money // Limit less Owed and Held
+}
+
+type CreditCardPayment struct {
+	Transaction *entity.CardTransaction
+	// How the payment was applied, in the order it was applied
+	Fees      entity.Money
+	Interest  entity.Money
+	Principal entity.Money
+	Owed      entity.Money // Left to pay after the payment
+}
+
+type CreditStatement struct {
+	*entity.CreditStatement
+	Transactions []*entity.CardTransaction
+}
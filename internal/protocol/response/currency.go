@@ -22,7 +22,7 @@ type CurrencyTrends struct {
 	Code   enum.CurrencyCode
 	Trends []struct {
 		Date         time.Time
-		ExchangeRate float64
+		ExchangeRate entity.Rate
 	}
 }
 
@@ -31,7 +31,7 @@ type SearchCurrenciesResult struct {
 }
 
 type ConvertAmountResult struct {
-	ConvertedAmount float64
+	ConvertedAmount entity.Money
 }
 
 // This could be an object showing the latest exchange rate of the two currencies and how they fare against a base currency, or any other information relevant to comparing two currencies.
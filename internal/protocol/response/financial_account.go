@@ -3,6 +3,7 @@ package response
 import (
 	"time"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 )
 
@@ -27,12 +28,22 @@ type GetFinancialAccount struct {
 	DeletedAt     *time.Time
 }
 
+// FinancialAccountPayee is what a user may learn about an account they pay into but do
+// not own: enough to address a transfer, nothing about the owner or the account's state.
+type FinancialAccountPayee struct {
+	AccountID    int
+	BankID       int
+	ShabaNumber  string
+	AccountName  string
+	CurrencyCode string
+}
+
 type GetCurrency struct {
 	CurrencyID   int
 	CurrencyCode enum.CurrencyCode
 	CurrencyName enum.CurrencyName
 	Symbol       enum.CurrencySymbol
-	ExchangeRate *float64
+	ExchangeRate *entity.Rate
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time
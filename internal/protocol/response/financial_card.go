@@ -1,2 +1,37 @@
 package response
 
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// FinancialCard is a card as shown to API clients: the number only ever appears masked and
+// the vault token is left out.
+type FinancialCard struct {
+	CardID         int
+	AccountID      int
+	MaskedNumber   string
+	CardType       enum.FinancialCardType
+	Network        enum.CardNetwork
+	BankID         *int64
+	ExpirationDate time.Time
+	CardHolderName string
+	Status         enum.FinancialCardStatus
+	IssuedDate     time.Time
+	ReplacesCardID *int
+	Virtual        bool
+	Usage          enum.CardUsage
+	LockedMerchant *string
+	SpendCap       entity.Money
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// IssuedCard is the only response that carries a full card number: the wallet shows a
+// virtual card's number once, when it is issued.
+type IssuedCard struct {
+	*FinancialCard
+	CardNumber string
+}
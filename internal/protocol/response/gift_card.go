@@ -0,0 +1,25 @@
+package response
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// GiftCard is a gift card as shown to API clients, with the code masked.
+type GiftCard struct {
+	GiftCardID    int
+	MaskedCode    string
+	InitialAmount entity.Money
+	Balance       entity.Money
+	Status        enum.GiftCardStatus
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// IssuedGiftCard carries the activation code of a new gift card, the only time it is shown.
+type IssuedGiftCard struct {
+	*GiftCard
+	Code string
+}
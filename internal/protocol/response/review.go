@@ -0,0 +1,13 @@
+package response
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type ReviewCaseDetail struct {
+	Case         entity.ReviewCase
+	Transactions []*entity.AccountTransaction // Both legs of the held transfer
+	// CardTransactions are the legs of a held card to card transfer, which has no Transactions
+	CardTransactions []*entity.CardTransaction
+	Events           []*entity.ReviewEvent // The audit trail, oldest first
+}
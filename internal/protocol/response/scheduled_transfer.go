@@ -0,0 +1,8 @@
+package response
+
+import "github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+
+type ScheduledTransferDetail struct {
+	Transfer *entity.ScheduledTransfer
+	Runs     []*entity.ScheduledTransferRun
+}
@@ -0,0 +1,27 @@
+package response
+
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// TwoFactorEnrollment carries the secret to add to an authenticator app, as text and as the
+// otpauth URI the app scans from QRCode, a PNG data URI.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"`
+}
+
+// RecoveryCodes are shown once; only their hashes are kept.
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+// TwoFactorChallenge is sent back with a code to complete the request that raised it.
+type TwoFactorChallenge struct {
+	Challenge string                `json:"challenge"`
+	Purpose   enum.TwoFactorPurpose `json:"purpose"`
+	ExpiresAt time.Time             `json:"expires_at"`
+}
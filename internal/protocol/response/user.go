@@ -1,17 +1,47 @@
 package response
 
+import (
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
 type SignUp struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
+// SignIn carries the tokens, or the challenge to answer first when the user has two-factor
+// authentication on.
 type SignIn struct {
+	AccessToken  string              `json:"access_token,omitempty"`
+	RefreshToken string              `json:"refresh_token,omitempty"`
+	TwoFactor    *TwoFactorChallenge `json:"two_factor,omitempty"`
+}
+
+type RefreshToken struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
-type RefreshToken struct {
-	AccessToken string `json:"access_token"`
+// Session is a sign-in of the user. Current marks the one the request was made with.
+type Session struct {
+	SessionID  int       `json:"session_id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// VerificationCodeSent tells where a verification code went, with the address partly
+// masked, and until when it can be entered.
+type VerificationCodeSent struct {
+	Channel     enum.ContactChannel `json:"channel"`
+	Destination string              `json:"destination"`
+	ExpiresAt   time.Time           `json:"expires_at"`
 }
 
 type GetProfile struct {
@@ -0,0 +1,36 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+)
+
+type Review interface {
+	// OpenCase queues a held transfer for review once its assessment is recorded.
+	OpenCase(ctx context.Context, assessment *entity.RiskAssessment) error
+	ListCases(ctx context.Context, req request.ListReviewCases) ([]*entity.ReviewCase, error)
+	GetCase(ctx context.Context, caseID int) (response.ReviewCaseDetail, error)
+	AssignCase(ctx context.Context, req request.AssignReviewCase) (*entity.ReviewCase, error)
+	ClaimCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error)
+	// ApproveCase releases the held funds to the receiver; RejectCase returns them to the sender.
+	ApproveCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error)
+	RejectCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error)
+}
+
+type ReviewCaseRepository interface {
+	Insert(ctx context.Context, reviewCase *entity.ReviewCase) error
+	Get(ctx context.Context, caseID int) (*entity.ReviewCase, error)
+	// GetForUpdate is Get with the case row locked until the surrounding transaction ends.
+	GetForUpdate(ctx context.Context, caseID int) (*entity.ReviewCase, error)
+	// GetByTransactionGroupID returns the case of a held transfer, or nil if it was never held.
+	GetByTransactionGroupID(ctx context.Context, groupID int) (*entity.ReviewCase, error)
+	List(ctx context.Context, req request.ListReviewCases) ([]*entity.ReviewCase, error)
+	Update(ctx context.Context, reviewCase *entity.ReviewCase) error
+	InsertEvent(ctx context.Context, event *entity.ReviewEvent) error
+	ListEvents(ctx context.Context, caseID int) ([]*entity.ReviewEvent, error)
+
+	Transactor
+}
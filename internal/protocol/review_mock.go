@@ -0,0 +1,118 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReviewService struct {
+	mock.Mock
+}
+
+func (m *MockReviewService) OpenCase(ctx context.Context, assessment *entity.RiskAssessment) error {
+	args := m.Called(ctx, assessment)
+	return args.Error(0)
+}
+
+func (m *MockReviewService) ListCases(ctx context.Context, req request.ListReviewCases) ([]*entity.ReviewCase, error) {
+	args := m.Called(ctx, req)
+	cases, _ := args.Get(0).([]*entity.ReviewCase)
+	return cases, args.Error(1)
+}
+
+func (m *MockReviewService) GetCase(ctx context.Context, caseID int) (response.ReviewCaseDetail, error) {
+	args := m.Called(ctx, caseID)
+	return args.Get(0).(response.ReviewCaseDetail), args.Error(1)
+}
+
+func (m *MockReviewService) AssignCase(ctx context.Context, req request.AssignReviewCase) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, req)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+func (m *MockReviewService) ClaimCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, req)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+func (m *MockReviewService) ApproveCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, req)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+func (m *MockReviewService) RejectCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, req)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+type MockReviewCaseRepo struct {
+	mock.Mock
+}
+
+func (m *MockReviewCaseRepo) Insert(ctx context.Context, reviewCase *entity.ReviewCase) error {
+	args := m.Called(ctx, reviewCase)
+	return args.Error(0)
+}
+
+func (m *MockReviewCaseRepo) Get(ctx context.Context, caseID int) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, caseID)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+func (m *MockReviewCaseRepo) GetForUpdate(ctx context.Context, caseID int) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, caseID)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+func (m *MockReviewCaseRepo) GetByTransactionGroupID(ctx context.Context, groupID int) (*entity.ReviewCase, error) {
+	args := m.Called(ctx, groupID)
+	reviewCase, _ := args.Get(0).(*entity.ReviewCase)
+	return reviewCase, args.Error(1)
+}
+
+func (m *MockReviewCaseRepo) List(ctx context.Context, req request.ListReviewCases) ([]*entity.ReviewCase, error) {
+	args := m.Called(ctx, req)
+	cases, _ := args.Get(0).([]*entity.ReviewCase)
+	return cases, args.Error(1)
+}
+
+func (m *MockReviewCaseRepo) Update(ctx context.Context, reviewCase *entity.ReviewCase) error {
+	args := m.Called(ctx, reviewCase)
+	return args.Error(0)
+}
+
+func (m *MockReviewCaseRepo) InsertEvent(ctx context.Context, event *entity.ReviewEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockReviewCaseRepo) ListEvents(ctx context.Context, caseID int) ([]*entity.ReviewEvent, error) {
+	args := m.Called(ctx, caseID)
+	events, _ := args.Get(0).([]*entity.ReviewEvent)
+	return events, args.Error(1)
+}
+
+func (m *MockReviewCaseRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(context.Context), args.Error(1)
+}
+
+func (m *MockReviewCaseRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockReviewCaseRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
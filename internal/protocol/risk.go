@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+)
+
+// RiskCheck is one link of the risk pipeline. It returns nil when the transfer raises
+// no concern, or the reason it scored the transfer.
+type RiskCheck interface {
+	Name() string
+	Check(ctx context.Context, req request.AssessRisk) (*entity.RiskReason, error)
+}
+
+type Risk interface {
+	// Assess runs every configured check on a transfer and decides its status from the total score.
+	Assess(ctx context.Context, req request.AssessRisk) (*entity.RiskAssessment, error)
+	// Record stores an assessment once its transfer is posted and TransactionGroupID is set.
+	Record(ctx context.Context, assessment *entity.RiskAssessment) error
+}
+
+type RiskAssessmentRepository interface {
+	Insert(ctx context.Context, assessment *entity.RiskAssessment) error
+	GetByTransactionGroupID(ctx context.Context, groupID int) (*entity.RiskAssessment, error)
+}
@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRiskService struct {
+	mock.Mock
+}
+
+func (m *MockRiskService) Assess(ctx context.Context, req request.AssessRisk) (*entity.RiskAssessment, error) {
+	args := m.Called(ctx, req)
+	assessment, _ := args.Get(0).(*entity.RiskAssessment)
+	return assessment, args.Error(1)
+}
+
+func (m *MockRiskService) Record(ctx context.Context, assessment *entity.RiskAssessment) error {
+	args := m.Called(ctx, assessment)
+	return args.Error(0)
+}
+
+type MockRiskAssessmentRepo struct {
+	mock.Mock
+}
+
+func (m *MockRiskAssessmentRepo) Insert(ctx context.Context, assessment *entity.RiskAssessment) error {
+	args := m.Called(ctx, assessment)
+	return args.Error(0)
+}
+
+func (m *MockRiskAssessmentRepo) GetByTransactionGroupID(ctx context.Context, groupID int) (*entity.RiskAssessment, error) {
+	args := m.Called(ctx, groupID)
+	assessment, _ := args.Get(0).(*entity.RiskAssessment)
+	return assessment, args.Error(1)
+}
@@ -0,0 +1,21 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type RoleRepository interface {
+	List(ctx context.Context) ([]*entity.Role, error)
+	Exists(ctx context.Context, role enum.Role) (bool, error)
+	ListByUserID(ctx context.Context, userID int) ([]enum.Role, error)
+	// ListPermissionsByUserID returns the permissions of all the roles of the user, each once.
+	ListPermissionsByUserID(ctx context.Context, userID int) ([]enum.Permission, error)
+	HasPermission(ctx context.Context, userID int, permission enum.Permission) (bool, error)
+	// Assign grants the role, doing nothing if the user already holds it.
+	Assign(ctx context.Context, userID int, role enum.Role) error
+	// Revoke takes the role away, reporting whether the user held it.
+	Revoke(ctx context.Context, userID int, role enum.Role) (bool, error)
+}
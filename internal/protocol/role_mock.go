@@ -0,0 +1,51 @@
+package protocol
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoleRepo struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepo) List(ctx context.Context) ([]*entity.Role, error) {
+	args := m.Called(ctx)
+	roles, _ := args.Get(0).([]*entity.Role)
+	return roles, args.Error(1)
+}
+
+func (m *MockRoleRepo) Exists(ctx context.Context, role enum.Role) (bool, error) {
+	args := m.Called(ctx, role)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRoleRepo) ListByUserID(ctx context.Context, userID int) ([]enum.Role, error) {
+	args := m.Called(ctx, userID)
+	roles, _ := args.Get(0).([]enum.Role)
+	return roles, args.Error(1)
+}
+
+func (m *MockRoleRepo) ListPermissionsByUserID(ctx context.Context, userID int) ([]enum.Permission, error) {
+	args := m.Called(ctx, userID)
+	permissions, _ := args.Get(0).([]enum.Permission)
+	return permissions, args.Error(1)
+}
+
+func (m *MockRoleRepo) HasPermission(ctx context.Context, userID int, permission enum.Permission) (bool, error) {
+	args := m.Called(ctx, userID, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRoleRepo) Assign(ctx context.Context, userID int, role enum.Role) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) Revoke(ctx context.Context, userID int, role enum.Role) (bool, error) {
+	args := m.Called(ctx, userID, role)
+	return args.Bool(0), args.Error(1)
+}
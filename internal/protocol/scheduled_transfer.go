@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+)
+
+type ScheduledTransfer interface {
+	// CreateScheduledTransfer rejects amounts that need a second factor, which no run can give.
+	CreateScheduledTransfer(ctx context.Context, req request.CreateScheduledTransfer) (*entity.ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, userID int) ([]*entity.ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (response.ScheduledTransferDetail, error)
+	PauseScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (*entity.ScheduledTransfer, error)
+	// ResumeScheduledTransfer skips the occurrences missed while the order was paused.
+	ResumeScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (*entity.ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (*entity.ScheduledTransfer, error)
+
+	// RunDue executes the orders due at now through Transfer and returns how many it ran.
+	RunDue(ctx context.Context, now time.Time) (int, error)
+	// Run calls RunDue every poll interval until ctx is cancelled, finishing the batch in progress.
+	Run(ctx context.Context) error
+}
+
+type ScheduledTransferRepository interface {
+	Insert(ctx context.Context, transfer *entity.ScheduledTransfer) error
+	Get(ctx context.Context, scheduledTransferID int) (*entity.ScheduledTransfer, error)
+	GetForUpdate(ctx context.Context, scheduledTransferID int) (*entity.ScheduledTransfer, error)
+	ListByUserID(ctx context.Context, userID int) ([]*entity.ScheduledTransfer, error)
+	Update(ctx context.Context, transfer *entity.ScheduledTransfer) error
+	// ClaimDue leases up to limit active orders that are due at now until leaseUntil. Orders
+	// leased by another executor are skipped until their lease runs out.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.ScheduledTransfer, error)
+	InsertRun(ctx context.Context, run *entity.ScheduledTransferRun) error
+	ListRuns(ctx context.Context, scheduledTransferID int) ([]*entity.ScheduledTransferRun, error)
+
+	Transactor
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScheduledTransferRepo struct {
+	mock.Mock
+}
+
+func (m *MockScheduledTransferRepo) Insert(ctx context.Context, transfer *entity.ScheduledTransfer) error {
+	args := m.Called(ctx, transfer)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTransferRepo) Get(ctx context.Context, scheduledTransferID int) (*entity.ScheduledTransfer, error) {
+	args := m.Called(ctx, scheduledTransferID)
+	transfer, _ := args.Get(0).(*entity.ScheduledTransfer)
+	return transfer, args.Error(1)
+}
+
+func (m *MockScheduledTransferRepo) GetForUpdate(ctx context.Context, scheduledTransferID int) (*entity.ScheduledTransfer, error) {
+	args := m.Called(ctx, scheduledTransferID)
+	transfer, _ := args.Get(0).(*entity.ScheduledTransfer)
+	return transfer, args.Error(1)
+}
+
+func (m *MockScheduledTransferRepo) ListByUserID(ctx context.Context, userID int) ([]*entity.ScheduledTransfer, error) {
+	args := m.Called(ctx, userID)
+	transfers, _ := args.Get(0).([]*entity.ScheduledTransfer)
+	return transfers, args.Error(1)
+}
+
+func (m *MockScheduledTransferRepo) Update(ctx context.Context, transfer *entity.ScheduledTransfer) error {
+	args := m.Called(ctx, transfer)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTransferRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.ScheduledTransfer, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	transfers, _ := args.Get(0).([]*entity.ScheduledTransfer)
+	return transfers, args.Error(1)
+}
+
+func (m *MockScheduledTransferRepo) InsertRun(ctx context.Context, run *entity.ScheduledTransferRun) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTransferRepo) ListRuns(ctx context.Context, scheduledTransferID int) ([]*entity.ScheduledTransferRun, error) {
+	args := m.Called(ctx, scheduledTransferID)
+	runs, _ := args.Get(0).([]*entity.ScheduledTransferRun)
+	return runs, args.Error(1)
+}
+
+func (m *MockScheduledTransferRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(context.Context), args.Error(1)
+}
+
+func (m *MockScheduledTransferRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockScheduledTransferRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
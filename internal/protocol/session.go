@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type SessionRepository interface {
+	// Insert saves the session and sets its ID.
+	Insert(ctx context.Context, session *entity.Session) error
+	// Update saves the access token, last use, expiry and revocation of the session.
+	Update(ctx context.Context, session *entity.Session) error
+	// GetForUpdate returns the session with its row locked until the surrounding transaction
+	// ends, or nil.
+	GetForUpdate(ctx context.Context, sessionID int) (*entity.Session, error)
+	// ListActiveByUserID returns the sessions of the user that are neither revoked nor
+	// expired at now, most recently used first.
+	ListActiveByUserID(ctx context.Context, userID int, now time.Time) ([]*entity.Session, error)
+
+	InsertRefreshToken(ctx context.Context, token *entity.RefreshToken) error
+	// GetRefreshTokenForUpdate returns the token with the given hash, locked like
+	// GetForUpdate, or nil.
+	GetRefreshTokenForUpdate(ctx context.Context, tokenHash []byte) (*entity.RefreshToken, error)
+	// SpendRefreshToken marks the token used so it cannot be exchanged again.
+	SpendRefreshToken(ctx context.Context, tokenHash []byte, usedAt time.Time) error
+
+	// DenyToken adds the jti of an access token to the denylist until the token expires,
+	// doing nothing if it is already there.
+	DenyToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenDenied(ctx context.Context, jti string) (bool, error)
+	// PurgeDeniedTokens deletes the denied tokens that expired before now and returns how many.
+	PurgeDeniedTokens(ctx context.Context, now time.Time) (int, error)
+
+	Transactor
+}
+
+// TokenDenylist tells the JWT middleware whether an otherwise valid access token was revoked.
+type TokenDenylist interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
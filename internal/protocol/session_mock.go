@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepo struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepo) Insert(ctx context.Context, session *entity.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) Update(ctx context.Context, session *entity.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) GetForUpdate(ctx context.Context, sessionID int) (*entity.Session, error) {
+	args := m.Called(ctx, sessionID)
+	session, _ := args.Get(0).(*entity.Session)
+	return session, args.Error(1)
+}
+
+func (m *MockSessionRepo) ListActiveByUserID(ctx context.Context, userID int, now time.Time) ([]*entity.Session, error) {
+	args := m.Called(ctx, userID, now)
+	sessions, _ := args.Get(0).([]*entity.Session)
+	return sessions, args.Error(1)
+}
+
+func (m *MockSessionRepo) InsertRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) GetRefreshTokenForUpdate(ctx context.Context, tokenHash []byte) (*entity.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	token, _ := args.Get(0).(*entity.RefreshToken)
+	return token, args.Error(1)
+}
+
+func (m *MockSessionRepo) SpendRefreshToken(ctx context.Context, tokenHash []byte, usedAt time.Time) error {
+	args := m.Called(ctx, tokenHash, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) DenyToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) IsTokenDenied(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) PurgeDeniedTokens(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	txCtx, _ := args.Get(0).(context.Context)
+	return txCtx, args.Error(1)
+}
+
+func (m *MockSessionRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type MockTokenDenylist struct {
+	mock.Mock
+}
+
+func (m *MockTokenDenylist) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+)
+
+type TwoFactor interface {
+	// Enroll starts setting up an authenticator, replacing one that was never confirmed.
+	Enroll(ctx context.Context, userID int) (response.TwoFactorEnrollment, error)
+	// Confirm turns the authenticator on once a code from it checks out, and returns the
+	// first recovery codes.
+	Confirm(ctx context.Context, req request.TwoFactorCode) (response.RecoveryCodes, error)
+	Disable(ctx context.Context, req request.TwoFactorCode) error
+	RegenerateRecoveryCodes(ctx context.Context, req request.TwoFactorCode) (response.RecoveryCodes, error)
+	IsEnabled(ctx context.Context, userID int) (bool, error)
+
+	// Challenge asks the user for a second factor before the action the binding describes.
+	Challenge(ctx context.Context, userID int, purpose enum.TwoFactorPurpose, binding string) (response.TwoFactorChallenge, error)
+	// Verify checks an answer to a challenge issued for the purpose and binding, and returns
+	// the user the challenge was issued to.
+	Verify(ctx context.Context, answer request.TwoFactorAnswer, purpose enum.TwoFactorPurpose, binding string) (userID int, err error)
+
+	// VerifyStepUp checks the answer to a step-up challenge for the debit the binding names. A
+	// nil answer gives an unverified factor, which the account rules may still ask for.
+	VerifyStepUp(ctx context.Context, userID int, binding string, answer *request.TwoFactorAnswer) (entity.SecondFactor, error)
+	// StepUp turns down a debit held back by the two-factor rule with a challenge to answer on
+	// the retry, or, for a user without an authenticator, with the violations themselves.
+	StepUp(ctx context.Context, factor entity.SecondFactor, violations []entity.PolicyViolation) error
+}
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID int) (*entity.TwoFactor, error)
+	// Upsert saves a pending authenticator, replacing the one the user had.
+	Upsert(ctx context.Context, twoFactor *entity.TwoFactor) error
+	Enable(ctx context.Context, userID int, enabledAt time.Time) error
+	Delete(ctx context.Context, userID int) error
+	// UseStep records the time step of an accepted code, reporting false if that step or a
+	// later one was already used.
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes [][]byte) error
+	// UseRecoveryCode spends an unused recovery code, reporting whether there was one.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash []byte, usedAt time.Time) (bool, error)
+
+	RecordFailedAttempt(ctx context.Context, userID int) error
+	CountFailedAttempts(ctx context.Context, userID int, since time.Time) (int, error)
+
+	Transactor
+}
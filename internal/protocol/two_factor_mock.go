@@ -0,0 +1,132 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Enroll(ctx context.Context, userID int) (response.TwoFactorEnrollment, error) {
+	args := m.Called(ctx, userID)
+	enrollment, _ := args.Get(0).(response.TwoFactorEnrollment)
+	return enrollment, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Confirm(ctx context.Context, req request.TwoFactorCode) (response.RecoveryCodes, error) {
+	args := m.Called(ctx, req)
+	codes, _ := args.Get(0).(response.RecoveryCodes)
+	return codes, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(ctx context.Context, req request.TwoFactorCode) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, req request.TwoFactorCode) (response.RecoveryCodes, error) {
+	args := m.Called(ctx, req)
+	codes, _ := args.Get(0).(response.RecoveryCodes)
+	return codes, args.Error(1)
+}
+
+func (m *MockTwoFactorService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Challenge(ctx context.Context, userID int, purpose enum.TwoFactorPurpose, binding string) (response.TwoFactorChallenge, error) {
+	args := m.Called(ctx, userID, purpose, binding)
+	challenge, _ := args.Get(0).(response.TwoFactorChallenge)
+	return challenge, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Verify(ctx context.Context, answer request.TwoFactorAnswer, purpose enum.TwoFactorPurpose, binding string) (int, error) {
+	args := m.Called(ctx, answer, purpose, binding)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTwoFactorService) VerifyStepUp(ctx context.Context, userID int, binding string, answer *request.TwoFactorAnswer) (entity.SecondFactor, error) {
+	args := m.Called(ctx, userID, binding, answer)
+	factor, _ := args.Get(0).(entity.SecondFactor)
+	return factor, args.Error(1)
+}
+
+func (m *MockTwoFactorService) StepUp(ctx context.Context, factor entity.SecondFactor, violations []entity.PolicyViolation) error {
+	args := m.Called(ctx, factor, violations)
+	return args.Error(0)
+}
+
+type MockTwoFactorRepo struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepo) Get(ctx context.Context, userID int) (*entity.TwoFactor, error) {
+	args := m.Called(ctx, userID)
+	twoFactor, _ := args.Get(0).(*entity.TwoFactor)
+	return twoFactor, args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) Upsert(ctx context.Context, twoFactor *entity.TwoFactor) error {
+	args := m.Called(ctx, twoFactor)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) Enable(ctx context.Context, userID int, enabledAt time.Time) error {
+	args := m.Called(ctx, userID, enabledAt)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) Delete(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes [][]byte) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash []byte, usedAt time.Time) (bool, error) {
+	args := m.Called(ctx, userID, codeHash, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) RecordFailedAttempt(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) CountFailedAttempts(ctx context.Context, userID int, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	txCtx, _ := args.Get(0).(context.Context)
+	return txCtx, args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
 )
@@ -11,11 +12,28 @@ import (
 type User interface {
 	SignUp(ctx context.Context, req request.SignUp) (response.SignUp, error)
 	SignIn(ctx context.Context, req request.SignIn) (response.SignIn, error)
-	RefreshToken(ctx context.Context, userID int) (response.RefreshToken, error)
+	// CompleteSignIn answers the two-factor challenge SignIn returned in place of tokens.
+	CompleteSignIn(ctx context.Context, req request.SignInTwoFactor) (response.SignIn, error)
+	RefreshToken(ctx context.Context, req request.RefreshToken) (response.RefreshToken, error)
 	GetProfile(ctx context.Context, userID int) (response.GetProfile, error)
 	EditProfile(ctx context.Context, req request.EditProfile) error
+	// SendVerificationCode sends a one-time code to the email address or cellphone on the
+	// user's profile, and ConfirmContact marks it verified when the code comes back.
+	SendVerificationCode(ctx context.Context, req request.SendVerificationCode) (response.VerificationCodeSent, error)
+	ConfirmContact(ctx context.Context, req request.ConfirmContact) error
 	Get(ctx context.Context, id int) (entity.User, error)
 	IsUserExist(ctx context.Context, userID int) (bool, error)
+
+	ListRoles(ctx context.Context) ([]*entity.Role, error)
+	ListUserRoles(ctx context.Context, userID int) ([]enum.Role, error)
+	AssignRole(ctx context.Context, req request.UserRole) error
+	RevokeRole(ctx context.Context, req request.UserRole) error
+	HasPermission(ctx context.Context, userID int, permission enum.Permission) (bool, error)
+
+	ListSessions(ctx context.Context, userID, currentSessionID int) ([]*response.Session, error)
+	RevokeSession(ctx context.Context, req request.RevokeSession) error
+	RevokeAllSessions(ctx context.Context, userID int) error
+	TokenDenylist
 }
 
 type UserRepository interface {
@@ -23,6 +41,9 @@ type UserRepository interface {
 	GetByUsername(ctx context.Context, username string) (entity.User, error)
 	Insert(ctx context.Context, u entity.User) error
 	Update(ctx context.Context, u entity.User) error
+	// MarkContactVerified flags the email address or cellphone of the user as verified if
+	// it is still destination, reporting whether it was.
+	MarkContactVerified(ctx context.Context, userID int, channel enum.ContactChannel, destination string) (bool, error)
 	IsUsernameExist(ctx context.Context, username string) (bool, error)
 	IsExist(ctx context.Context, id int) (bool, error)
 	IsUserExist(ctx context.Context, userID int) (bool, error)
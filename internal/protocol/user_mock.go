@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
 	"github.com/stretchr/testify/mock"
@@ -33,6 +34,11 @@ func (m *MockUserRepository) Update(ctx context.Context, u entity.User) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) MarkContactVerified(ctx context.Context, userID int, channel enum.ContactChannel, destination string) (bool, error) {
+	args := m.Called(ctx, userID, channel, destination)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepository) IsUsernameExist(ctx context.Context, username string) (bool, error) {
 	args := m.Called(ctx, username)
 	return args.Bool(0), args.Error(1)
@@ -68,8 +74,13 @@ func (m *MockUserRepository) SignIn(ctx context.Context, req request.SignIn) (re
 	return args.Get(0).(response.SignIn), args.Error(1)
 }
 
-func (m *MockUserRepository) RefreshToken(ctx context.Context, userID int) (response.RefreshToken, error) {
-	args := m.Called(ctx, userID)
+func (m *MockUserRepository) CompleteSignIn(ctx context.Context, req request.SignInTwoFactor) (response.SignIn, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.SignIn), args.Error(1)
+}
+
+func (m *MockUserRepository) RefreshToken(ctx context.Context, req request.RefreshToken) (response.RefreshToken, error) {
+	args := m.Called(ctx, req)
 	return args.Get(0).(response.RefreshToken), args.Error(1)
 }
 
@@ -83,7 +94,65 @@ func (m *MockUserRepository) EditProfile(ctx context.Context, req request.EditPr
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) SendVerificationCode(ctx context.Context, req request.SendVerificationCode) (response.VerificationCodeSent, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(response.VerificationCodeSent), args.Error(1)
+}
+
+func (m *MockUserRepository) ConfirmContact(ctx context.Context, req request.ConfirmContact) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) IsUserExist(ctx context.Context, userID int) (bool, error) {
 	args := m.Called(ctx, userID)
 	return args.Bool(0), args.Error(1)
 }
+
+func (m *MockUserRepository) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	args := m.Called(ctx)
+	roles, _ := args.Get(0).([]*entity.Role)
+	return roles, args.Error(1)
+}
+
+func (m *MockUserRepository) ListUserRoles(ctx context.Context, userID int) ([]enum.Role, error) {
+	args := m.Called(ctx, userID)
+	roles, _ := args.Get(0).([]enum.Role)
+	return roles, args.Error(1)
+}
+
+func (m *MockUserRepository) AssignRole(ctx context.Context, req request.UserRole) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RevokeRole(ctx context.Context, req request.UserRole) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) HasPermission(ctx context.Context, userID int, permission enum.Permission) (bool, error) {
+	args := m.Called(ctx, userID, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ListSessions(ctx context.Context, userID, currentSessionID int) ([]*response.Session, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	sessions, _ := args.Get(0).([]*response.Session)
+	return sessions, args.Error(1)
+}
+
+func (m *MockUserRepository) RevokeSession(ctx context.Context, req request.RevokeSession) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RevokeAllSessions(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
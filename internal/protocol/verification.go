@@ -0,0 +1,23 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type VerificationCodeRepository interface {
+	// Insert saves the code and sets its ID and creation time.
+	Insert(ctx context.Context, code *entity.VerificationCode) error
+	// Update saves the attempts and use of the code.
+	Update(ctx context.Context, code *entity.VerificationCode) error
+	// GetLatest returns the newest code sent to the user on the channel, or nil.
+	GetLatest(ctx context.Context, userID int, channel enum.ContactChannel) (*entity.VerificationCode, error)
+	// GetLatestForUpdate is GetLatest with the row locked until the surrounding transaction ends.
+	GetLatestForUpdate(ctx context.Context, userID int, channel enum.ContactChannel) (*entity.VerificationCode, error)
+	CountSince(ctx context.Context, userID int, channel enum.ContactChannel, since time.Time) (int, error)
+
+	Transactor
+}
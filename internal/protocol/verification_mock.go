@@ -0,0 +1,57 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVerificationCodeRepo struct {
+	mock.Mock
+}
+
+func (m *MockVerificationCodeRepo) Insert(ctx context.Context, code *entity.VerificationCode) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockVerificationCodeRepo) Update(ctx context.Context, code *entity.VerificationCode) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockVerificationCodeRepo) GetLatest(ctx context.Context, userID int, channel enum.ContactChannel) (*entity.VerificationCode, error) {
+	args := m.Called(ctx, userID, channel)
+	code, _ := args.Get(0).(*entity.VerificationCode)
+	return code, args.Error(1)
+}
+
+func (m *MockVerificationCodeRepo) GetLatestForUpdate(ctx context.Context, userID int, channel enum.ContactChannel) (*entity.VerificationCode, error) {
+	args := m.Called(ctx, userID, channel)
+	code, _ := args.Get(0).(*entity.VerificationCode)
+	return code, args.Error(1)
+}
+
+func (m *MockVerificationCodeRepo) CountSince(ctx context.Context, userID int, channel enum.ContactChannel, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, channel, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockVerificationCodeRepo) BeginTx(ctx context.Context) (context.Context, error) {
+	args := m.Called(ctx)
+	txCtx, _ := args.Get(0).(context.Context)
+	return txCtx, args.Error(1)
+}
+
+func (m *MockVerificationCodeRepo) CommitTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockVerificationCodeRepo) RollbackTx(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/lib/pq"
+)
+
+type AccountRules struct {
+	cli *sql.DB
+}
+
+func (repo *AccountRules) GetByAccountID(ctx context.Context, accountID int) (*entity.AccountRules, error) {
+	query := `
+		SELECT
+			rule_id, financial_account_id, currency_code,
+			COALESCE(daily_limit, 0), COALESCE(weekly_limit, 0), COALESCE(monthly_limit, 0),
+			COALESCE(min_amount, 0), COALESCE(max_amount, 0), COALESCE(max_failed_transactions_daily, 0),
+			COALESCE(new_account_max_amount, 0), COALESCE(min_balance_required, 0),
+			allowed_countries, disallowed_countries,
+			COALESCE(notify_on_new_location, FALSE), COALESCE(block_on_unusual_location, FALSE),
+			allowed_regions, allowed_cities,
+			COALESCE(require_2fa_for_amount, 0), COALESCE(verification_level::TEXT, 'minimal'),
+			COALESCE(password_change_cooling_period, 0), COALESCE(new_transaction_method_cooling_period, 0),
+			created_at, updated_at, deleted_at
+		FROM public.account_rules
+		WHERE financial_account_id = $1 AND deleted_at IS NULL
+	`
+
+	rules := &entity.AccountRules{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, accountID).Scan(
+		&rules.RuleID,
+		&rules.FinancialAccountID,
+		&rules.MinAmount.Currency,
+		&rules.DailyLimit,
+		&rules.WeeklyLimit,
+		&rules.MonthlyLimit,
+		&rules.MinAmount,
+		&rules.MaxAmount,
+		&rules.MaxFailedTransactionsDaily,
+		&rules.NewAccountMaxAmount,
+		&rules.MinBalanceRequired,
+		pq.Array(&rules.AllowedCountries),
+		pq.Array(&rules.DisallowedCountries),
+		&rules.NotifyOnNewLocation,
+		&rules.BlockOnUnusualLocation,
+		pq.Array(&rules.AllowedRegions),
+		pq.Array(&rules.AllowedCities),
+		&rules.Require2FAForAmount,
+		&rules.VerificationLevel,
+		&rules.PasswordChangeCoolingPeriod,
+		&rules.NewTransactionMethodCoolingPeriod,
+		&rules.CreatedAt,
+		&rules.UpdatedAt,
+		&rules.DeletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.AccountRules.GetByAccountID.QueryRowContext: %w", err)
+	}
+
+	currency := rules.MinAmount.Currency
+	rules.MaxAmount.Currency = currency
+	rules.NewAccountMaxAmount.Currency = currency
+	rules.MinBalanceRequired.Currency = currency
+	rules.Require2FAForAmount.Currency = currency
+
+	return rules, nil
+}
+
+// Upsert creates the rules of an account or replaces the existing ones.
+func (repo *AccountRules) Upsert(ctx context.Context, rules *entity.AccountRules) error {
+	query := `
+		INSERT INTO public.account_rules (
+			financial_account_id, currency_code,
+			daily_limit, weekly_limit, monthly_limit,
+			min_amount, max_amount, max_failed_transactions_daily,
+			new_account_max_amount, min_balance_required,
+			allowed_countries, disallowed_countries, notify_on_new_location, block_on_unusual_location,
+			allowed_regions, allowed_cities,
+			require_2fa_for_amount, verification_level,
+			password_change_cooling_period, new_transaction_method_cooling_period,
+			created_at, updated_at, deleted_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULL
+		)
+		ON CONFLICT (financial_account_id) DO UPDATE SET
+			currency_code = EXCLUDED.currency_code,
+			daily_limit = EXCLUDED.daily_limit,
+			weekly_limit = EXCLUDED.weekly_limit,
+			monthly_limit = EXCLUDED.monthly_limit,
+			min_amount = EXCLUDED.min_amount,
+			max_amount = EXCLUDED.max_amount,
+			max_failed_transactions_daily = EXCLUDED.max_failed_transactions_daily,
+			new_account_max_amount = EXCLUDED.new_account_max_amount,
+			min_balance_required = EXCLUDED.min_balance_required,
+			allowed_countries = EXCLUDED.allowed_countries,
+			disallowed_countries = EXCLUDED.disallowed_countries,
+			notify_on_new_location = EXCLUDED.notify_on_new_location,
+			block_on_unusual_location = EXCLUDED.block_on_unusual_location,
+			allowed_regions = EXCLUDED.allowed_regions,
+			allowed_cities = EXCLUDED.allowed_cities,
+			require_2fa_for_amount = EXCLUDED.require_2fa_for_amount,
+			verification_level = EXCLUDED.verification_level,
+			password_change_cooling_period = EXCLUDED.password_change_cooling_period,
+			new_transaction_method_cooling_period = EXCLUDED.new_transaction_method_cooling_period,
+			updated_at = CURRENT_TIMESTAMP,
+			deleted_at = NULL
+		RETURNING rule_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		rules.FinancialAccountID,
+		rules.MinAmount.Currency,
+		rules.DailyLimit,
+		rules.WeeklyLimit,
+		rules.MonthlyLimit,
+		rules.MinAmount,
+		rules.MaxAmount,
+		rules.MaxFailedTransactionsDaily,
+		rules.NewAccountMaxAmount,
+		rules.MinBalanceRequired,
+		pq.Array(rules.AllowedCountries),
+		pq.Array(rules.DisallowedCountries),
+		rules.NotifyOnNewLocation,
+		rules.BlockOnUnusualLocation,
+		pq.Array(rules.AllowedRegions),
+		pq.Array(rules.AllowedCities),
+		rules.Require2FAForAmount,
+		rules.VerificationLevel,
+		rules.PasswordChangeCoolingPeriod,
+		rules.NewTransactionMethodCoolingPeriod,
+	).Scan(&rules.RuleID, &rules.CreatedAt, &rules.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.AccountRules.Upsert.QueryRowContext: %w", err)
+	}
+	rules.DeletedAt = nil
+
+	return nil
+}
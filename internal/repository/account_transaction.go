@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 )
 
 type AccountTransaction struct {
@@ -13,38 +15,28 @@ type AccountTransaction struct {
 }
 
 func (repo *AccountTransaction) Insert(ctx context.Context, transaction *entity.AccountTransaction) error {
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("BeginTx: %w", err)
-	}
-
 	query := `
 		INSERT INTO public.account_transaction (
-			transaction_group_id, financial_account_id, amount, balance,
-			description, status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			transaction_group_id, financial_account_id, currency_code, amount, balance,
+			description, status, reverses_transaction_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING transaction_id;
 	`
 
-	_, err = tx.ExecContext(ctx, query,
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
 		transaction.TransactionGroupID,
 		transaction.FinancialAccountID,
+		transaction.Amount.Currency,
 		transaction.Amount,
 		transaction.Balance,
 		transaction.Description,
 		transaction.Status,
+		transaction.ReversesTransactionID,
 		transaction.CreatedAt,
 		transaction.UpdatedAt,
-	)
-
+	).Scan(&transaction.TransactionID)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ExecContext: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("Commit: %w", err)
+		return fmt.Errorf("QueryRowContext: %w", err)
 	}
 
 	return nil
@@ -53,15 +45,15 @@ func (repo *AccountTransaction) Insert(ctx context.Context, transaction *entity.
 func (repo *AccountTransaction) GetByID(ctx context.Context, transactionID int64) (*entity.AccountTransaction, error) {
 	query := `
 		SELECT 
-			transaction_id, transaction_group_id, financial_account_id, amount,
-			balance, description, status, created_at, updated_at, deleted_at
+			transaction_id, transaction_group_id, financial_account_id, currency_code,
+			amount, balance, description, status, reverses_transaction_id, created_at, updated_at, deleted_at
 		FROM 
 			public.account_transaction
 		WHERE 
 			transaction_id = $1
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("PrepareContext: %w", err)
 	}
@@ -73,10 +65,12 @@ func (repo *AccountTransaction) GetByID(ctx context.Context, transactionID int64
 		&transaction.TransactionID,
 		&transaction.TransactionGroupID,
 		&transaction.FinancialAccountID,
+		&transaction.Amount.Currency,
 		&transaction.Amount,
 		&transaction.Balance,
 		&transaction.Description,
 		&transaction.Status,
+		&transaction.ReversesTransactionID,
 		&transaction.CreatedAt,
 		&transaction.UpdatedAt,
 		&transaction.DeletedAt,
@@ -86,45 +80,22 @@ func (repo *AccountTransaction) GetByID(ctx context.Context, transactionID int64
 		}
 		return nil, fmt.Errorf("Scan: %w", err)
 	}
+	transaction.Balance.Currency = transaction.Amount.Currency
 	return transaction, nil
 }
 
-func (repo *AccountTransaction) Delete(ctx context.Context, transactionID int64) error {
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("BeginTx: %w", err)
-	}
-
-	query := `
-		DELETE FROM public.account_transaction WHERE transaction_id = $1;
-	`
-
-	_, err = tx.ExecContext(ctx, query, transactionID)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ExecContext: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("Commit: %w", err)
-	}
-
-	return nil
-}
-
 func (repo *AccountTransaction) ListByAccountID(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error) {
 	query := `
 		SELECT 
-			transaction_id, transaction_group_id, financial_account_id, amount,
-			balance, description, status, created_at, updated_at, deleted_at
+			transaction_id, transaction_group_id, financial_account_id, currency_code,
+			amount, balance, description, status, reverses_transaction_id, created_at, updated_at, deleted_at
 		FROM 
 			public.account_transaction
 		WHERE 
 			financial_account_id = $1
 	`
 
-	rows, err := repo.cli.QueryContext(ctx, query, accountID)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("QueryContext: %w", err)
 	}
@@ -137,16 +108,19 @@ func (repo *AccountTransaction) ListByAccountID(ctx context.Context, accountID i
 			&transaction.TransactionID,
 			&transaction.TransactionGroupID,
 			&transaction.FinancialAccountID,
+			&transaction.Amount.Currency,
 			&transaction.Amount,
 			&transaction.Balance,
 			&transaction.Description,
 			&transaction.Status,
+			&transaction.ReversesTransactionID,
 			&transaction.CreatedAt,
 			&transaction.UpdatedAt,
 			&transaction.DeletedAt,
 		); err != nil {
 			return nil, fmt.Errorf("Scan: %w", err)
 		}
+		transaction.Balance.Currency = transaction.Amount.Currency
 		transactions = append(transactions, transaction)
 	}
 
@@ -156,15 +130,15 @@ func (repo *AccountTransaction) ListByAccountID(ctx context.Context, accountID i
 func (repo *AccountTransaction) ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error) {
 	query := `
 		SELECT 
-			transaction_id, transaction_group_id, financial_account_id, amount,
-			balance, description, status, created_at, updated_at, deleted_at
+			transaction_id, transaction_group_id, financial_account_id, currency_code,
+			amount, balance, description, status, reverses_transaction_id, created_at, updated_at, deleted_at
 		FROM 
 			public.account_transaction
 		WHERE 
 			transaction_group_id = $1
 	`
 
-	rows, err := repo.cli.QueryContext(ctx, query, groupID)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, groupID)
 	if err != nil {
 		return nil, fmt.Errorf("QueryContext: %w", err)
 	}
@@ -177,16 +151,19 @@ func (repo *AccountTransaction) ListByTransactionGroupID(ctx context.Context, gr
 			&transaction.TransactionID,
 			&transaction.TransactionGroupID,
 			&transaction.FinancialAccountID,
+			&transaction.Amount.Currency,
 			&transaction.Amount,
 			&transaction.Balance,
 			&transaction.Description,
 			&transaction.Status,
+			&transaction.ReversesTransactionID,
 			&transaction.CreatedAt,
 			&transaction.UpdatedAt,
 			&transaction.DeletedAt,
 		); err != nil {
 			return nil, fmt.Errorf("Scan: %w", err)
 		}
+		transaction.Balance.Currency = transaction.Amount.Currency
 		transactions = append(transactions, transaction)
 	}
 
@@ -194,184 +171,114 @@ func (repo *AccountTransaction) ListByTransactionGroupID(ctx context.Context, gr
 }
 
 func (repo *AccountTransaction) BeginTx(ctx context.Context) (context.Context, error) {
-	// TODO: Implement
-	return nil, nil
+	return beginTx(ctx, repo.cli)
 }
 
 func (repo *AccountTransaction) CommitTx(ctx context.Context) error {
-	// TODO: Implement
-	return nil
+	return commitTx(ctx)
 }
 
 func (repo *AccountTransaction) RollbackTx(ctx context.Context) error {
-	// TODO: Implement
-	return nil
-}
-
-func (repo *AccountTransaction) DebitAccount(ctx context.Context, accountID int, amount float64) error {
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("BeginTx: %w", err)
-	}
-
-	// Retrieve current balance
-	var currentBalance float64
-	err = tx.QueryRowContext(ctx, "SELECT balance FROM financial_account WHERE id = $1 FOR UPDATE", accountID).Scan(&currentBalance)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("QueryRowContext: %w", err)
-	}
-
-	// Check if sufficient balance
-	if currentBalance < amount {
-		tx.Rollback()
-		return fmt.Errorf("Insufficient funds")
-	}
-
-	// Update balance
-	newBalance := currentBalance - amount
-	_, err = tx.ExecContext(ctx, "UPDATE financial_account SET balance = $1 WHERE id = $2", newBalance, accountID)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ExecContext: %w", err)
-	}
-
-	// Insert transaction
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO account_transaction (financial_account_id, amount, balance, description, status, created_at, updated_at)
-		VALUES ($1, $2, $3, 'Debit', 'Completed', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	`, accountID, -amount, newBalance)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ExecContext: %w", err)
-	}
-
-	return tx.Commit()
-}
-
-func (repo *AccountTransaction) CreditAccount(ctx context.Context, accountID int, amount float64) error {
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("BeginTx: %w", err)
-	}
-
-	// Retrieve current balance
-	var currentBalance float64
-	err = tx.QueryRowContext(ctx, "SELECT balance FROM financial_account WHERE id = $1 FOR UPDATE", accountID).Scan(&currentBalance)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("QueryRowContext: %w", err)
-	}
-
-	// Update balance
-	newBalance := currentBalance + amount
-	_, err = tx.ExecContext(ctx, "UPDATE financial_account SET balance = $1 WHERE id = $2", newBalance, accountID)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ExecContext: %w", err)
-	}
-
-	// Insert transaction
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO account_transaction (financial_account_id, amount, balance, description, status, created_at, updated_at)
-		VALUES ($1, $2, $3, 'Credit', 'Completed', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	`, accountID, amount, newBalance)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ExecContext: %w", err)
-	}
-
-	return tx.Commit()
-}
-
-func (repo *AccountTransaction) CreateNewTransactionGroup(ctx context.Context) (int, error) {
-	var newID int
-	query := "INSERT INTO transaction_groups DEFAULT VALUES RETURNING id;"
-
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return 0, fmt.Errorf("BeginTx: %w", err)
-	}
-
-	err = tx.QueryRowContext(ctx, query).Scan(&newID)
-	if err != nil {
-		tx.Rollback()
-		return 0, fmt.Errorf("QueryRowContext: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return 0, fmt.Errorf("Commit: %w", err)
-	}
-
-	return newID, nil
+	return rollbackTx(ctx)
 }
 
 func (repo *AccountTransaction) Update(ctx context.Context, transaction *entity.AccountTransaction) error {
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("BeginTx: %w", err)
-	}
-
 	query := `
 		UPDATE public.account_transaction
 		SET
 			transaction_group_id = $2,
 			financial_account_id = $3,
-			amount = $4,
-			balance = $5,
-			description = $6,
-			status = $7,
+			currency_code = $4,
+			amount = $5,
+			balance = $6,
+			description = $7,
+			status = $8,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE 
 			transaction_id = $1;
 	`
 
-	_, err = tx.ExecContext(ctx, query,
+	_, err := conn(ctx, repo.cli).ExecContext(ctx, query,
 		transaction.TransactionID,
 		transaction.TransactionGroupID,
 		transaction.FinancialAccountID,
+		transaction.Amount.Currency,
 		transaction.Amount,
 		transaction.Balance,
 		transaction.Description,
 		transaction.Status,
 	)
-
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("ExecContext: %w", err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("Commit: %w", err)
-	}
-
 	return nil
 }
 
-func (repo *AccountTransaction) GetCurrentBalance(ctx context.Context, accountID int) (float64, error) {
-	return 0.0 ,nil
+// CountDebitsSince counts the debits of an account created at or after since, leaving out
+// the ones that were cancelled or failed.
+func (repo *AccountTransaction) CountDebitsSince(ctx context.Context, accountID int, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM public.account_transaction
+		WHERE financial_account_id = $1
+			AND amount < 0
+			AND created_at >= $2
+			AND status NOT IN ($3, $4)
+			AND deleted_at IS NULL
+	`
+
+	var count int
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, accountID, since, enum.Cancelled, enum.Failed).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("repository.AccountTransaction.CountDebitsSince.QueryRowContext: %w", err)
+	}
+
+	return count, nil
 }
 
+func (repo *AccountTransaction) HasPaid(ctx context.Context, senderAccountID, receiverAccountID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM public.account_transaction s
+			JOIN public.account_transaction r ON r.transaction_group_id = s.transaction_group_id
+			WHERE s.financial_account_id = $1
+				AND s.amount < 0
+				AND s.status = $3
+				AND s.deleted_at IS NULL
+				AND r.financial_account_id = $2
+				AND r.amount > 0
+				AND r.status = $3
+				AND r.deleted_at IS NULL
+		)
+	`
 
+	var paid bool
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, senderAccountID, receiverAccountID, enum.Completed).Scan(&paid)
+	if err != nil {
+		return false, fmt.Errorf("repository.AccountTransaction.HasPaid.QueryRowContext: %w", err)
+	}
 
+	return paid, nil
+}
 
-// type AccountTransactionRepository interface {
-// 	Insert(ctx context.Context, transaction *entity.AccountTransaction) error
-// 	Delete(ctx context.Context, transactionID int64) error
-// 	GetByID(ctx context.Context, transactionID int64) (*entity.AccountTransaction, error)
-// 	ListByAccountID(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error)
-// 	ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.AccountTransaction, error)
-// 	CreateNewTransactionGroup(ctx context.Context) (int, error)
-// 	Update(ctx context.Context, transaction *entity.AccountTransaction) error
-// 	GetCurrentBalance(ctx context.Context, accountID int) (float64, error)
+func (repo *AccountTransaction) AverageDebitSince(ctx context.Context, accountID int, since time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(ROUND(AVG(-amount)), 0)::BIGINT
+		FROM public.account_transaction
+		WHERE financial_account_id = $1
+			AND amount < 0
+			AND created_at >= $2
+			AND status NOT IN ($3, $4)
+			AND deleted_at IS NULL
+	`
 
-// 	BeginTx(ctx context.Context) (context.Context, error) // Begins a new transaction
-// 	CommitTx(ctx context.Context) error                   // Commits the transaction
-// 	RollbackTx(ctx context.Context) error                 // Rollbacks the transaction
+	var average int64
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, accountID, since, enum.Cancelled, enum.Failed).Scan(&average)
+	if err != nil {
+		return 0, fmt.Errorf("repository.AccountTransaction.AverageDebitSince.QueryRowContext: %w", err)
+	}
 
-// 	// Transfer related methods
-// 	DebitAccount(ctx context.Context, accountID int, amount float64) error
-// 	CreditAccount(ctx context.Context, accountID int, amount float64) error
-// }
+	return average, nil
+}
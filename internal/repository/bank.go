@@ -24,7 +24,7 @@ func (repo *Bank) GetByID(ctx context.Context, bankID int) (*entity.Bank, error)
 		WHERE
 			bank_id = $1 AND deleted_at IS NULL
 	`
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Bank.GetByID.PrepareContext: %w", err)
 	}
@@ -53,7 +53,7 @@ func (repo *Bank) GetByCode(ctx context.Context, bankCode string) (*entity.Bank,
 			bank_code = $1 AND deleted_at IS NULL
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Bank.GetByCode.PrepareContext: %w", err)
 	}
@@ -82,7 +82,7 @@ func (repo *Bank) GetByName(ctx context.Context, bankName string) (*entity.Bank,
 			name = $1 AND deleted_at IS NULL
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Bank.GetByName.PrepareContext: %w", err)
 	}
@@ -107,7 +107,7 @@ func (repo *Bank) Insert(ctx context.Context, bank *entity.Bank) error {
 		RETURNING bank_id
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.Bank.Insert.PrepareContext: %w", err)
 	}
@@ -127,7 +127,7 @@ func (repo *Bank) Update(ctx context.Context, bank *entity.Bank) error {
 		WHERE bank_id = $4
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.Bank.Update.PrepareContext: %w", err)
 	}
@@ -147,7 +147,7 @@ func (repo *Bank) Delete(ctx context.Context, bankID int) error {
 		WHERE bank_id = $1
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.Bank.Delete.PrepareContext: %w", err)
 	}
@@ -167,7 +167,7 @@ func (repo *Bank) ListAll(ctx context.Context) ([]*entity.Bank, error) {
 		WHERE deleted_at IS NULL
 	`
 
-	rows, err := repo.cli.QueryContext(ctx, query)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Bank.ListAll.QueryContext: %w", err)
 	}
@@ -192,7 +192,7 @@ func (repo *Bank) ListByStatus(ctx context.Context, status enum.BankStatus) ([]*
 		WHERE status = $1 AND deleted_at IS NULL
 	`
 
-	rows, err := repo.cli.QueryContext(ctx, query, status)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, status)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Bank.ListByStatus.QueryContext: %w", err)
 	}
@@ -219,7 +219,7 @@ func (repo *Bank) IsBankCodeExist(ctx context.Context, bankCode string) (bool, e
 		)
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return false, fmt.Errorf("repository.Bank.IsBankCodeExist.PrepareContext: %w", err)
 	}
@@ -25,7 +25,7 @@ func (repo *BankBranch) GetByID(ctx context.Context, branchID int) (*entity.Bank
 			branch_id = $1 AND deleted_at IS NULL
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.BankBranch.GetByID.PrepareContext: %w", err)
 	}
@@ -69,7 +69,7 @@ func (repo *BankBranch) GetByName(ctx context.Context, branchName string) (*enti
 			branch_name = $1 AND deleted_at IS NULL
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.BankBranch.GetByName.PrepareContext: %w", err)
 	}
@@ -112,7 +112,7 @@ func (repo *BankBranch) GetByCode(ctx context.Context, branchCode string) (*enti
 		WHERE
 			branch_code = $1 AND deleted_at IS NULL
 	`
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.BankBranch.GetByCode.PrepareContext: %w", err)
 	}
@@ -151,7 +151,7 @@ func (repo *BankBranch) Insert(ctx context.Context, branch *entity.BankBranch) e
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		RETURNING branch_id
 	`
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.BankBranch.Insert.PrepareContext: %w", err)
 	}
@@ -183,7 +183,7 @@ func (repo *BankBranch) Update(ctx context.Context, branch *entity.BankBranch) e
 			updated_at = CURRENT_TIMESTAMP
 		WHERE branch_id = $10
 	`
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.BankBranch.Update.PrepareContext: %w", err)
 	}
@@ -210,7 +210,7 @@ func (repo *BankBranch) Update(ctx context.Context, branch *entity.BankBranch) e
 
 func (repo *BankBranch) Delete(ctx context.Context, branchID int) error {
 	query := "UPDATE bank_branch SET deleted_at = CURRENT_TIMESTAMP WHERE branch_id = $1"
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.BankBranch.Delete.PrepareContext: %w", err)
 	}
@@ -231,7 +231,7 @@ func (repo *BankBranch) ListAll(ctx context.Context) ([]*entity.BankBranch, erro
 		FROM bank_branch
 		WHERE deleted_at IS NULL
 	`
-	rows, err := repo.cli.QueryContext(ctx, query)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.BankBranch.ListAll.QueryContext: %w", err)
 	}
@@ -270,7 +270,7 @@ func (repo *BankBranch) ListByStatus(ctx context.Context, status enum.BankBranch
 		FROM bank_branch
 		WHERE status = $1 AND deleted_at IS NULL
 	`
-	rows, err := repo.cli.QueryContext(ctx, query, status)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, status)
 	if err != nil {
 		return nil, fmt.Errorf("repository.BankBranch.ListByStatus.QueryContext: %w", err)
 	}
@@ -308,7 +308,7 @@ func (repo *BankBranch) ListByBankID(ctx context.Context, bankID int) ([]*entity
 		FROM bank_branch
 		WHERE bank_id = $1 AND deleted_at IS NULL
 	`
-	rows, err := repo.cli.QueryContext(ctx, query, bankID)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, bankID)
 	if err != nil {
 		return nil, fmt.Errorf("repository.BankBranch.ListByBankID.QueryContext: %w", err)
 	}
@@ -343,7 +343,7 @@ func (repo *BankBranch) IsBranchCodeExist(ctx context.Context, branchCode string
 	query := "SELECT COUNT(*) FROM bank_branch WHERE branch_code = $1 AND deleted_at IS NULL"
 	var count int
 
-	err := repo.cli.QueryRowContext(ctx, query, branchCode).Scan(&count)
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, branchCode).Scan(&count)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type CardBIN struct {
+	cli *sql.DB
+}
+
+// Lookup prefers the narrowest range so that an issuer's own BINs win over a network-wide
+// range that happens to contain them.
+func (repo *CardBIN) Lookup(ctx context.Context, bin int) (*entity.CardBIN, error) {
+	query := `
+		SELECT card_bin_id, range_start, range_end, network, bank_code, created_at
+		FROM public.card_bin
+		WHERE $1 BETWEEN range_start AND range_end
+		ORDER BY range_end - range_start
+		LIMIT 1
+	`
+
+	cardBIN := &entity.CardBIN{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, bin).Scan(
+		&cardBIN.CardBINID,
+		&cardBIN.RangeStart,
+		&cardBIN.RangeEnd,
+		&cardBIN.Network,
+		&cardBIN.BankCode,
+		&cardBIN.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CardBIN.Lookup.Scan: %w", err)
+	}
+
+	return cardBIN, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/lib/pq"
+)
+
+type CardControls struct {
+	cli *sql.DB
+}
+
+func (repo *CardControls) GetByCardID(ctx context.Context, cardID int) (*entity.CardControls, error) {
+	query := `
+		SELECT
+			financial_card_id, online_disabled, international_disabled, blocked_mccs,
+			currency_code, per_transaction_limit, daily_limit, created_at, updated_at
+		FROM public.card_controls
+		WHERE financial_card_id = $1
+	`
+
+	controls := &entity.CardControls{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, cardID).Scan(
+		&controls.FinancialCardID,
+		&controls.OnlineDisabled,
+		&controls.InternationalDisabled,
+		pq.Array(&controls.BlockedMCCs),
+		&controls.PerTransactionLimit.Currency,
+		&controls.PerTransactionLimit,
+		&controls.DailyLimit,
+		&controls.CreatedAt,
+		&controls.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CardControls.GetByCardID.Scan: %w", err)
+	}
+	controls.DailyLimit.Currency = controls.PerTransactionLimit.Currency
+
+	return controls, nil
+}
+
+func (repo *CardControls) Upsert(ctx context.Context, controls *entity.CardControls) error {
+	query := `
+		INSERT INTO public.card_controls (
+			financial_card_id, online_disabled, international_disabled, blocked_mccs,
+			currency_code, per_transaction_limit, daily_limit, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (financial_card_id) DO UPDATE SET
+			online_disabled = EXCLUDED.online_disabled,
+			international_disabled = EXCLUDED.international_disabled,
+			blocked_mccs = EXCLUDED.blocked_mccs,
+			currency_code = EXCLUDED.currency_code,
+			per_transaction_limit = EXCLUDED.per_transaction_limit,
+			daily_limit = EXCLUDED.daily_limit,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		controls.FinancialCardID,
+		controls.OnlineDisabled,
+		controls.InternationalDisabled,
+		pq.Array(controls.BlockedMCCs),
+		controls.PerTransactionLimit.Currency,
+		controls.PerTransactionLimit,
+		controls.DailyLimit,
+	).Scan(&controls.CreatedAt, &controls.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CardControls.Upsert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type CardHold struct {
+	cli *sql.DB
+}
+
+const cardHoldColumns = `
+	hold_id, financial_card_id, financial_account_id, currency_code, amount, captured_amount,
+	merchant, description, status, capture_transaction_id, expires_at, created_at, updated_at
+`
+
+func (repo *CardHold) Insert(ctx context.Context, hold *entity.CardHold) error {
+	query := `
+		INSERT INTO public.card_hold (
+			financial_card_id, financial_account_id, currency_code, amount, captured_amount,
+			merchant, description, status, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING hold_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		hold.FinancialCardID,
+		hold.FinancialAccountID,
+		hold.Amount.Currency,
+		hold.Amount,
+		hold.CapturedAmount,
+		hold.Merchant,
+		hold.Description,
+		hold.Status,
+		hold.ExpiresAt,
+	).Scan(&hold.HoldID, &hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CardHold.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CardHold) Update(ctx context.Context, hold *entity.CardHold) error {
+	query := `
+		UPDATE public.card_hold
+		SET status = $2, captured_amount = $3, capture_transaction_id = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE hold_id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		hold.HoldID,
+		hold.Status,
+		hold.CapturedAmount,
+		hold.CaptureTransactionID,
+	).Scan(&hold.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CardHold.Update.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CardHold) Get(ctx context.Context, holdID int) (*entity.CardHold, error) {
+	return repo.get(ctx, holdID, "")
+}
+
+func (repo *CardHold) GetForUpdate(ctx context.Context, holdID int) (*entity.CardHold, error) {
+	return repo.get(ctx, holdID, "FOR UPDATE")
+}
+
+func (repo *CardHold) get(ctx context.Context, holdID int, lock string) (*entity.CardHold, error) {
+	query := `
+		SELECT ` + cardHoldColumns + `
+		FROM public.card_hold
+		WHERE hold_id = $1
+	` + lock
+
+	hold, err := scanCardHold(conn(ctx, repo.cli).QueryRowContext(ctx, query, holdID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CardHold.Get.Scan: %w", err)
+	}
+
+	return hold, nil
+}
+
+func (repo *CardHold) ListByCardID(ctx context.Context, cardID int) ([]*entity.CardHold, error) {
+	query := `
+		SELECT ` + cardHoldColumns + `
+		FROM public.card_hold
+		WHERE financial_card_id = $1
+		ORDER BY hold_id DESC
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardHold.ListByCardID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	holds, err := scanCardHolds(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardHold.ListByCardID.%w", err)
+	}
+
+	return holds, nil
+}
+
+// ExpireDue expires the holds in a single statement, skipping the rows a capture holds
+// locked so that the sweeper never waits on one.
+func (repo *CardHold) ExpireDue(ctx context.Context, now time.Time, limit int) ([]*entity.CardHold, error) {
+	query := `
+		UPDATE public.card_hold
+		SET status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE hold_id IN (
+			SELECT hold_id
+			FROM public.card_hold
+			WHERE status = $3 AND expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + cardHoldColumns
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, now, enum.HoldExpired, enum.HoldActive, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardHold.ExpireDue.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	holds, err := scanCardHolds(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardHold.ExpireDue.%w", err)
+	}
+
+	return holds, nil
+}
+
+func scanCardHolds(rows *sql.Rows) ([]*entity.CardHold, error) {
+	var holds []*entity.CardHold
+	for rows.Next() {
+		hold, err := scanCardHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		holds = append(holds, hold)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return holds, nil
+}
+
+func scanCardHold(row rowScanner) (*entity.CardHold, error) {
+	hold := &entity.CardHold{}
+	err := row.Scan(
+		&hold.HoldID,
+		&hold.FinancialCardID,
+		&hold.FinancialAccountID,
+		&hold.Amount.Currency,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&hold.Merchant,
+		&hold.Description,
+		&hold.Status,
+		&hold.CaptureTransactionID,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	hold.CapturedAmount.Currency = hold.Amount.Currency
+
+	return hold, nil
+}
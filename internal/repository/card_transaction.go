@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type CardTransaction struct {
+	cli *sql.DB
+}
+
+const cardTransactionColumns = `
+	transaction_id, transaction_group_id, financial_card_id, transaction_type, currency_code,
+	amount, balance, merchant, description, status, refunds_transaction_id, hold_id,
+	credit_statement_id, created_at, updated_at, deleted_at
+`
+
+func (repo *CardTransaction) Insert(ctx context.Context, transaction *entity.CardTransaction) error {
+	query := `
+		INSERT INTO public.card_transaction (
+			transaction_group_id, financial_card_id, transaction_type, currency_code, amount,
+			balance, merchant, description, status, refunds_transaction_id, hold_id, created_at,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING transaction_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		transaction.TransactionGroupID,
+		transaction.FinancialCardID,
+		transaction.Type,
+		transaction.Amount.Currency,
+		transaction.Amount,
+		transaction.Balance,
+		transaction.Merchant,
+		transaction.Description,
+		transaction.Status,
+		transaction.RefundsTransactionID,
+		transaction.HoldID,
+	).Scan(&transaction.TransactionID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CardTransaction.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CardTransaction) Update(ctx context.Context, transaction *entity.CardTransaction) error {
+	query := `
+		UPDATE public.card_transaction
+		SET status = $2, balance = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE transaction_id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, transaction.TransactionID, transaction.Status, transaction.Balance).Scan(&transaction.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CardTransaction.Update.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CardTransaction) GetByID(ctx context.Context, transactionID int64) (*entity.CardTransaction, error) {
+	return repo.get(ctx, transactionID, "")
+}
+
+func (repo *CardTransaction) GetForUpdate(ctx context.Context, transactionID int64) (*entity.CardTransaction, error) {
+	return repo.get(ctx, transactionID, "FOR UPDATE")
+}
+
+func (repo *CardTransaction) get(ctx context.Context, transactionID int64, lock string) (*entity.CardTransaction, error) {
+	query := `
+		SELECT ` + cardTransactionColumns + `
+		FROM public.card_transaction
+		WHERE transaction_id = $1 AND deleted_at IS NULL
+	` + lock
+
+	transaction, err := scanCardTransaction(conn(ctx, repo.cli).QueryRowContext(ctx, query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CardTransaction.GetByID.Scan: %w", err)
+	}
+
+	return transaction, nil
+}
+
+func (repo *CardTransaction) ListByTransactionGroupID(ctx context.Context, groupID int) ([]*entity.CardTransaction, error) {
+	query := `
+		SELECT ` + cardTransactionColumns + `
+		FROM public.card_transaction
+		WHERE transaction_group_id = $1 AND deleted_at IS NULL
+		ORDER BY transaction_id
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardTransaction.ListByTransactionGroupID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanCardTransactions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardTransaction.ListByTransactionGroupID.%w", err)
+	}
+
+	return transactions, nil
+}
+
+func (repo *CardTransaction) ListByCardID(ctx context.Context, cardID int) ([]*entity.CardTransaction, error) {
+	query := `
+		SELECT ` + cardTransactionColumns + `
+		FROM public.card_transaction
+		WHERE financial_card_id = $1 AND deleted_at IS NULL
+		ORDER BY transaction_id DESC
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardTransaction.ListByCardID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanCardTransactions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardTransaction.ListByCardID.%w", err)
+	}
+
+	return transactions, nil
+}
+
+func (repo *CardTransaction) RefundedAmount(ctx context.Context, transactionID int64, currency enum.CurrencyCode) (entity.Money, error) {
+	refunded := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM public.card_transaction
+		WHERE refunds_transaction_id = $1 AND currency_code = $2 AND deleted_at IS NULL
+	`, transactionID, currency).Scan(&refunded)
+	if err != nil {
+		return entity.Money{}, fmt.Errorf("repository.CardTransaction.RefundedAmount.QueryRowContext: %w", err)
+	}
+
+	return refunded, nil
+}
+
+func (repo *CardTransaction) SpentSince(ctx context.Context, cardID int, currency enum.CurrencyCode, since time.Time) (entity.Money, error) {
+	spent := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(spent), 0) FROM (
+			SELECT -purchase.amount - COALESCE((
+				SELECT SUM(refund.amount)
+				FROM public.card_transaction refund
+				WHERE refund.refunds_transaction_id = purchase.transaction_id AND refund.transaction_type = $6
+					AND refund.currency_code = $3 AND refund.deleted_at IS NULL
+			), 0) AS spent
+			FROM public.card_transaction purchase
+			WHERE purchase.financial_card_id = $1 AND purchase.transaction_type = $2 AND purchase.currency_code = $3
+				AND purchase.created_at >= $4 AND purchase.deleted_at IS NULL
+			UNION ALL
+			SELECT amount
+			FROM public.card_hold
+			WHERE financial_card_id = $1 AND status = $5 AND currency_code = $3
+		) card_spend
+	`, cardID, enum.CardPurchase, currency, since, enum.HoldActive, enum.CardRefund).Scan(&spent)
+	if err != nil {
+		return entity.Money{}, fmt.Errorf("repository.CardTransaction.SpentSince.QueryRowContext: %w", err)
+	}
+
+	return spent, nil
+}
+
+func (repo *CardTransaction) HasPayments(ctx context.Context, cardID int) (bool, error) {
+	var paid bool
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM public.card_transaction
+			WHERE financial_card_id = $1 AND transaction_type = $2 AND deleted_at IS NULL
+		) OR EXISTS (
+			SELECT 1 FROM public.card_hold
+			WHERE financial_card_id = $1 AND status IN ($3, $4)
+		)
+	`, cardID, enum.CardPurchase, enum.HoldActive, enum.HoldCaptured).Scan(&paid)
+	if err != nil {
+		return false, fmt.Errorf("repository.CardTransaction.HasPayments.QueryRowContext: %w", err)
+	}
+
+	return paid, nil
+}
+
+func (repo *CardTransaction) AssignToStatement(ctx context.Context, accountID, statementID int) error {
+	_, err := conn(ctx, repo.cli).ExecContext(ctx, `
+		UPDATE public.card_transaction
+		SET credit_statement_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE financial_card_id IN (SELECT card_id FROM financial_card WHERE account_id = $1)
+			AND credit_statement_id IS NULL AND deleted_at IS NULL
+	`, accountID, statementID)
+	if err != nil {
+		return fmt.Errorf("repository.CardTransaction.AssignToStatement.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CardTransaction) ListByStatementID(ctx context.Context, statementID int) ([]*entity.CardTransaction, error) {
+	query := `
+		SELECT ` + cardTransactionColumns + `
+		FROM public.card_transaction
+		WHERE credit_statement_id = $1 AND deleted_at IS NULL
+		ORDER BY transaction_id
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, statementID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardTransaction.ListByStatementID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanCardTransactions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CardTransaction.ListByStatementID.%w", err)
+	}
+
+	return transactions, nil
+}
+
+func (repo *CardTransaction) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *CardTransaction) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *CardTransaction) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+func scanCardTransactions(rows *sql.Rows) ([]*entity.CardTransaction, error) {
+	var transactions []*entity.CardTransaction
+	for rows.Next() {
+		transaction, err := scanCardTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return transactions, nil
+}
+
+func scanCardTransaction(row rowScanner) (*entity.CardTransaction, error) {
+	transaction := &entity.CardTransaction{}
+	err := row.Scan(
+		&transaction.TransactionID,
+		&transaction.TransactionGroupID,
+		&transaction.FinancialCardID,
+		&transaction.Type,
+		&transaction.Amount.Currency,
+		&transaction.Amount,
+		&transaction.Balance,
+		&transaction.Merchant,
+		&transaction.Description,
+		&transaction.Status,
+		&transaction.RefundsTransactionID,
+		&transaction.HoldID,
+		&transaction.StatementID,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+		&transaction.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Balance.Currency = transaction.Amount.Currency
+
+	return transaction, nil
+}
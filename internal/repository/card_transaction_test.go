@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardTransaction_SpentSince(t *testing.T) {
+	t.Run("nets the refunds of each purchase", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		since := time.Now().AddDate(0, 0, -1)
+		mock.ExpectQuery(`(?s)-purchase.amount - COALESCE\(\(\s+SELECT SUM\(refund.amount\).*refund.refunds_transaction_id = purchase.transaction_id`).
+			WithArgs(10, enum.CardPurchase, enum.USD, since, enum.HoldActive, enum.CardRefund).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(int64(2500)))
+
+		repo := &CardTransaction{cli: db}
+		spent, err := repo.SpentSince(context.Background(), 10, enum.USD, since)
+		require.NoError(t, err)
+		assert.Equal(t, entity.NewMoney(2500, enum.USD), spent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type CardVault struct {
+	cli *sql.DB
+}
+
+const cardVaultColumns = `token, key_id, wrapped_key, ciphertext, fingerprint, created_at`
+
+func (repo *CardVault) Insert(ctx context.Context, entry *entity.VaultEntry) error {
+	query := `
+		INSERT INTO public.card_vault (token, key_id, wrapped_key, ciphertext, fingerprint)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		entry.Token,
+		entry.KeyID,
+		entry.WrappedKey,
+		entry.Ciphertext,
+		entry.Fingerprint,
+	).Scan(&entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CardVault.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CardVault) GetByToken(ctx context.Context, token string) (*entity.VaultEntry, error) {
+	query := `SELECT ` + cardVaultColumns + ` FROM public.card_vault WHERE token = $1`
+
+	entry, err := scanVaultEntry(conn(ctx, repo.cli).QueryRowContext(ctx, query, token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CardVault.GetByToken.Scan: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (repo *CardVault) GetByFingerprint(ctx context.Context, fingerprint []byte) (*entity.VaultEntry, error) {
+	query := `SELECT ` + cardVaultColumns + ` FROM public.card_vault WHERE fingerprint = $1`
+
+	entry, err := scanVaultEntry(conn(ctx, repo.cli).QueryRowContext(ctx, query, fingerprint))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CardVault.GetByFingerprint.Scan: %w", err)
+	}
+
+	return entry, nil
+}
+
+func scanVaultEntry(row rowScanner) (*entity.VaultEntry, error) {
+	entry := &entity.VaultEntry{}
+	err := row.Scan(
+		&entry.Token,
+		&entry.KeyID,
+		&entry.WrappedKey,
+		&entry.Ciphertext,
+		&entry.Fingerprint,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,431 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type CreditLine struct {
+	cli *sql.DB
+}
+
+const creditLineColumns = `
+	credit_line_id, financial_card_id, account_id, payment_account_id, currency_code, credit_limit,
+	apr, statement_day, fees_due, interest_due, accrued_interest, interest_accrued_on, revolving,
+	next_statement_at, created_at, updated_at
+`
+
+const creditStatementColumns = `
+	credit_statement_id, credit_line_id, currency_code, period_start, period_end, opening_balance,
+	closing_balance, purchases, credits, payments, interest_charged, fees_charged, minimum_payment,
+	due_date, paid, status, created_at, updated_at
+`
+
+func (repo *CreditLine) Insert(ctx context.Context, line *entity.CreditLine) error {
+	query := `
+		INSERT INTO public.credit_line (
+			financial_card_id, account_id, payment_account_id, currency_code, credit_limit, apr,
+			statement_day, fees_due, interest_due, accrued_interest, interest_accrued_on, revolving,
+			next_statement_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING credit_line_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		line.FinancialCardID,
+		line.AccountID,
+		line.PaymentAccountID,
+		line.Limit.Currency,
+		line.Limit,
+		line.APR,
+		line.StatementDay,
+		line.FeesDue,
+		line.InterestDue,
+		line.AccruedInterest,
+		line.InterestAccruedOn,
+		line.Revolving,
+		line.NextStatementAt,
+	).Scan(&line.CreditLineID, &line.CreatedAt, &line.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CreditLine.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CreditLine) Update(ctx context.Context, line *entity.CreditLine) error {
+	query := `
+		UPDATE public.credit_line
+		SET credit_limit = $2, fees_due = $3, interest_due = $4, accrued_interest = $5,
+			interest_accrued_on = $6, revolving = $7, next_statement_at = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE credit_line_id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		line.CreditLineID,
+		line.Limit,
+		line.FeesDue,
+		line.InterestDue,
+		line.AccruedInterest,
+		line.InterestAccruedOn,
+		line.Revolving,
+		line.NextStatementAt,
+	).Scan(&line.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CreditLine.Update.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+// byCard finds the line through the card's account, so that every card drawing on the
+// account, renewals included, shares the line opened for the first one.
+const byCard = "account_id = (SELECT account_id FROM financial_card WHERE card_id = $1)"
+
+func (repo *CreditLine) GetByID(ctx context.Context, creditLineID int) (*entity.CreditLine, error) {
+	line, err := repo.get(ctx, "credit_line_id = $1", creditLineID, "")
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.GetByID.%w", err)
+	}
+
+	return line, nil
+}
+
+func (repo *CreditLine) GetByCardID(ctx context.Context, cardID int) (*entity.CreditLine, error) {
+	line, err := repo.get(ctx, byCard, cardID, "")
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.GetByCardID.%w", err)
+	}
+
+	return line, nil
+}
+
+func (repo *CreditLine) GetByCardIDForUpdate(ctx context.Context, cardID int) (*entity.CreditLine, error) {
+	line, err := repo.get(ctx, byCard, cardID, "FOR UPDATE")
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.GetByCardIDForUpdate.%w", err)
+	}
+
+	return line, nil
+}
+
+func (repo *CreditLine) GetForUpdate(ctx context.Context, creditLineID int) (*entity.CreditLine, error) {
+	line, err := repo.get(ctx, "credit_line_id = $1", creditLineID, "FOR UPDATE")
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.GetForUpdate.%w", err)
+	}
+
+	return line, nil
+}
+
+func (repo *CreditLine) get(ctx context.Context, where string, value any, lock string) (*entity.CreditLine, error) {
+	query := `
+		SELECT ` + creditLineColumns + `
+		FROM public.credit_line
+		WHERE ` + where + `
+	` + lock
+
+	line, err := scanCreditLine(conn(ctx, repo.cli).QueryRowContext(ctx, query, value))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Scan: %w", err)
+	}
+
+	return line, nil
+}
+
+func (repo *CreditLine) ListAccrualDue(ctx context.Context, day time.Time, limit int) ([]*entity.CreditLine, error) {
+	lines, err := repo.list(ctx, "interest_accrued_on < $1 ORDER BY interest_accrued_on", day, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.ListAccrualDue.%w", err)
+	}
+
+	return lines, nil
+}
+
+func (repo *CreditLine) ListStatementDue(ctx context.Context, now time.Time, limit int) ([]*entity.CreditLine, error) {
+	lines, err := repo.list(ctx, "next_statement_at <= $1 ORDER BY next_statement_at", now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.ListStatementDue.%w", err)
+	}
+
+	return lines, nil
+}
+
+func (repo *CreditLine) list(ctx context.Context, condition string, at time.Time, limit int) ([]*entity.CreditLine, error) {
+	query := `
+		SELECT ` + creditLineColumns + `
+		FROM public.credit_line
+		WHERE ` + condition + `
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, at, limit)
+	if err != nil {
+		return nil, fmt.Errorf("QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*entity.CreditLine
+	for rows.Next() {
+		line, err := scanCreditLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (repo *CreditLine) InsertStatement(ctx context.Context, statement *entity.CreditStatement) error {
+	query := `
+		INSERT INTO public.credit_statement (
+			credit_line_id, currency_code, period_start, period_end, opening_balance, closing_balance,
+			purchases, credits, payments, interest_charged, fees_charged, minimum_payment, due_date,
+			paid, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING credit_statement_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		statement.CreditLineID,
+		statement.ClosingBalance.Currency,
+		statement.PeriodStart,
+		statement.PeriodEnd,
+		statement.OpeningBalance,
+		statement.ClosingBalance,
+		statement.Purchases,
+		statement.Credits,
+		statement.Payments,
+		statement.InterestCharged,
+		statement.FeesCharged,
+		statement.MinimumPayment,
+		statement.DueDate,
+		statement.Paid,
+		statement.Status,
+	).Scan(&statement.CreditStatementID, &statement.CreatedAt, &statement.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CreditLine.InsertStatement.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CreditLine) UpdateStatement(ctx context.Context, statement *entity.CreditStatement) error {
+	query := `
+		UPDATE public.credit_statement
+		SET purchases = $2, credits = $3, payments = $4, interest_charged = $5, fees_charged = $6,
+			minimum_payment = $7, paid = $8, status = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE credit_statement_id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		statement.CreditStatementID,
+		statement.Purchases,
+		statement.Credits,
+		statement.Payments,
+		statement.InterestCharged,
+		statement.FeesCharged,
+		statement.MinimumPayment,
+		statement.Paid,
+		statement.Status,
+	).Scan(&statement.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.CreditLine.UpdateStatement.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *CreditLine) GetStatement(ctx context.Context, statementID int) (*entity.CreditStatement, error) {
+	query := `
+		SELECT ` + creditStatementColumns + `
+		FROM public.credit_statement
+		WHERE credit_statement_id = $1
+	`
+
+	statement, err := scanCreditStatement(conn(ctx, repo.cli).QueryRowContext(ctx, query, statementID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CreditLine.GetStatement.Scan: %w", err)
+	}
+
+	return statement, nil
+}
+
+func (repo *CreditLine) LatestStatement(ctx context.Context, creditLineID int) (*entity.CreditStatement, error) {
+	query := `
+		SELECT ` + creditStatementColumns + `
+		FROM public.credit_statement
+		WHERE credit_line_id = $1
+		ORDER BY credit_statement_id DESC
+		LIMIT 1
+	`
+
+	statement, err := scanCreditStatement(conn(ctx, repo.cli).QueryRowContext(ctx, query, creditLineID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.CreditLine.LatestStatement.Scan: %w", err)
+	}
+
+	return statement, nil
+}
+
+func (repo *CreditLine) ListStatements(ctx context.Context, creditLineID int) ([]*entity.CreditStatement, error) {
+	query := `
+		SELECT ` + creditStatementColumns + `
+		FROM public.credit_statement
+		WHERE credit_line_id = $1
+		ORDER BY credit_statement_id DESC
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, creditLineID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.ListStatements.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	statements, err := scanCreditStatements(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.ListStatements.%w", err)
+	}
+
+	return statements, nil
+}
+
+func (repo *CreditLine) ListPastDue(ctx context.Context, now time.Time, limit int) ([]*entity.CreditStatement, error) {
+	query := `
+		SELECT ` + creditStatementColumns + `
+		FROM public.credit_statement
+		WHERE status = $1 AND due_date <= $2
+		ORDER BY due_date
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, enum.StatementOpen, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.ListPastDue.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	statements, err := scanCreditStatements(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.CreditLine.ListPastDue.%w", err)
+	}
+
+	return statements, nil
+}
+
+func (repo *CreditLine) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *CreditLine) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *CreditLine) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+func scanCreditLine(row rowScanner) (*entity.CreditLine, error) {
+	line := &entity.CreditLine{}
+	err := row.Scan(
+		&line.CreditLineID,
+		&line.FinancialCardID,
+		&line.AccountID,
+		&line.PaymentAccountID,
+		&line.Limit.Currency,
+		&line.Limit,
+		&line.APR,
+		&line.StatementDay,
+		&line.FeesDue,
+		&line.InterestDue,
+		&line.AccruedInterest,
+		&line.InterestAccruedOn,
+		&line.Revolving,
+		&line.NextStatementAt,
+		&line.CreatedAt,
+		&line.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	line.FeesDue.Currency = line.Limit.Currency
+	line.InterestDue.Currency = line.Limit.Currency
+	line.AccruedInterest.Currency = line.Limit.Currency
+
+	return line, nil
+}
+
+func scanCreditStatements(rows *sql.Rows) ([]*entity.CreditStatement, error) {
+	var statements []*entity.CreditStatement
+	for rows.Next() {
+		statement, err := scanCreditStatement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		statements = append(statements, statement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return statements, nil
+}
+
+func scanCreditStatement(row rowScanner) (*entity.CreditStatement, error) {
+	statement := &entity.CreditStatement{}
+	err := row.Scan(
+		&statement.CreditStatementID,
+		&statement.CreditLineID,
+		&statement.ClosingBalance.Currency,
+		&statement.PeriodStart,
+		&statement.PeriodEnd,
+		&statement.OpeningBalance,
+		&statement.ClosingBalance,
+		&statement.Purchases,
+		&statement.Credits,
+		&statement.Payments,
+		&statement.InterestCharged,
+		&statement.FeesCharged,
+		&statement.MinimumPayment,
+		&statement.DueDate,
+		&statement.Paid,
+		&statement.Status,
+		&statement.CreatedAt,
+		&statement.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := statement.ClosingBalance.Currency
+	for _, m := range []*entity.Money{
+		&statement.OpeningBalance, &statement.Purchases, &statement.Credits, &statement.Payments,
+		&statement.InterestCharged, &statement.FeesCharged, &statement.MinimumPayment, &statement.Paid,
+	} {
+		m.Currency = currency
+	}
+
+	return statement, nil
+}
@@ -21,7 +21,7 @@ func (repo *Currency) Insert(ctx context.Context, currency entity.Currency) erro
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING currency_id
 	`
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.Currency.Insert.PrepareContext: %w", err)
 	}
@@ -48,7 +48,7 @@ func (repo *Currency) Update(ctx context.Context, currency entity.Currency) erro
 		SET currency_code = $1, currency_name = $2, symbol = $3, exchange_rate = $4, updated_at = $5
 		WHERE currency_id = $6
 	`
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.Currency.Update.PrepareContext: %w", err)
 	}
@@ -71,7 +71,7 @@ func (repo *Currency) Update(ctx context.Context, currency entity.Currency) erro
 
 func (repo *Currency) Delete(ctx context.Context, currencyID int) error {
 	query := "UPDATE currency SET deleted_at = CURRENT_TIMESTAMP WHERE currency_id = $1"
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.Currency.Delete.PrepareContext: %w", err)
 	}
@@ -87,7 +87,7 @@ func (repo *Currency) Delete(ctx context.Context, currencyID int) error {
 
 func (repo *Currency) Get(ctx context.Context, currencyID int) (entity.Currency, error) {
 	query := "SELECT * FROM currency WHERE currency_id = $1"
-	row := repo.cli.QueryRowContext(ctx, query, currencyID)
+	row := conn(ctx, repo.cli).QueryRowContext(ctx, query, currencyID)
 
 	var currency entity.Currency
 	err := row.Scan(&currency.CurrencyID, &currency.CurrencyCode, &currency.CurrencyName, &currency.Symbol, &currency.ExchangeRate, &currency.CreatedAt, &currency.UpdatedAt, &currency.DeletedAt)
@@ -100,7 +100,7 @@ func (repo *Currency) Get(ctx context.Context, currencyID int) (entity.Currency,
 
 func (repo *Currency) GetByCode(ctx context.Context, code enum.CurrencyCode) (entity.Currency, error) {
 	query := "SELECT * FROM currency WHERE currency_code = $1"
-	row := repo.cli.QueryRowContext(ctx, query, code)
+	row := conn(ctx, repo.cli).QueryRowContext(ctx, query, code)
 
 	var currency entity.Currency
 	err := row.Scan(&currency.CurrencyID, &currency.CurrencyCode, &currency.CurrencyName, &currency.Symbol, &currency.ExchangeRate, &currency.CreatedAt, &currency.UpdatedAt, &currency.DeletedAt)
@@ -113,7 +113,7 @@ func (repo *Currency) GetByCode(ctx context.Context, code enum.CurrencyCode) (en
 
 func (repo *Currency) GetByName(ctx context.Context, name enum.CurrencyName) (entity.Currency, error) {
 	query := "SELECT * FROM currency WHERE currency_name = $1"
-	row := repo.cli.QueryRowContext(ctx, query, name)
+	row := conn(ctx, repo.cli).QueryRowContext(ctx, query, name)
 
 	var currency entity.Currency
 	err := row.Scan(&currency.CurrencyID, &currency.CurrencyCode, &currency.CurrencyName, &currency.Symbol, &currency.ExchangeRate, &currency.CreatedAt, &currency.UpdatedAt, &currency.DeletedAt)
@@ -126,7 +126,7 @@ func (repo *Currency) GetByName(ctx context.Context, name enum.CurrencyName) (en
 
 func (repo *Currency) List(ctx context.Context) ([]entity.Currency, error) {
 	query := "SELECT * FROM currency"
-	rows, err := repo.cli.QueryContext(ctx, query)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.List.QueryContext: %w", err)
 	}
@@ -148,7 +148,7 @@ func (repo *Currency) List(ctx context.Context) ([]entity.Currency, error) {
 func (repo *Currency) IsCodeExist(ctx context.Context, code enum.CurrencyCode) (bool, error) {
 	query := "SELECT EXISTS (SELECT 1 FROM currency WHERE currency_code = $1)"
 	var exists bool
-	err := repo.cli.QueryRowContext(ctx, query, code).Scan(&exists)
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, code).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("repository.Currency.IsCodeExist.QueryRowContext: %w", err)
 	}
@@ -162,21 +162,17 @@ func (repo *Currency) BulkInsert(ctx context.Context, currencies []entity.Curren
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("repository.Currency.BulkInsert.BeginTx: %w", err)
-	}
-
-	for _, currency := range currencies {
-		_, err = tx.ExecContext(ctx, query, currency.CurrencyCode, currency.CurrencyName, currency.Symbol, currency.ExchangeRate, time.Now(), time.Now())
-		if err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("repository.Currency.BulkInsert.ExecContext: %w", err)
+	err := runInTx(ctx, repo.cli, func(tx *sql.Tx) error {
+		for _, currency := range currencies {
+			_, err := tx.ExecContext(ctx, query, currency.CurrencyCode, currency.CurrencyName, currency.Symbol, currency.ExchangeRate, time.Now(), time.Now())
+			if err != nil {
+				return fmt.Errorf("ExecContext: %w", err)
+			}
 		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("repository.Currency.BulkInsert.Commit: %w", err)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("repository.Currency.BulkInsert.%w", err)
 	}
 
 	return nil
@@ -189,21 +185,17 @@ func (repo *Currency) BulkUpdate(ctx context.Context, currencies []entity.Curren
 		WHERE currency_code = $5
 	`
 
-	tx, err := repo.cli.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("repository.Currency.BulkUpdate.BeginTx: %w", err)
-	}
-
-	for _, currency := range currencies {
-		_, err = tx.ExecContext(ctx, query, currency.CurrencyName, currency.Symbol, currency.ExchangeRate, time.Now(), currency.CurrencyCode)
-		if err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("repository.Currency.BulkUpdate.ExecContext: %w", err)
+	err := runInTx(ctx, repo.cli, func(tx *sql.Tx) error {
+		for _, currency := range currencies {
+			_, err := tx.ExecContext(ctx, query, currency.CurrencyName, currency.Symbol, currency.ExchangeRate, time.Now(), currency.CurrencyCode)
+			if err != nil {
+				return fmt.Errorf("ExecContext: %w", err)
+			}
 		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("repository.Currency.BulkUpdate.Commit: %w", err)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("repository.Currency.BulkUpdate.%w", err)
 	}
 
 	return nil
@@ -215,7 +207,7 @@ func (repo *Currency) Search(ctx context.Context, query string) ([]entity.Curren
 		WHERE LOWER(currency_name) LIKE LOWER($1)
 		OR LOWER(currency_code) LIKE LOWER($1)
 	`
-	rows, err := repo.cli.QueryContext(ctx, sqlQuery, "%"+query+"%")
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, sqlQuery, "%"+query+"%")
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.Search.QueryContext: %w", err)
 	}
@@ -234,18 +226,18 @@ func (repo *Currency) Search(ctx context.Context, query string) ([]entity.Curren
 	return currencies, nil
 }
 
-func (repo *Currency) GetLatestExchangeRates(ctx context.Context) (map[enum.CurrencyCode]float64, error) {
+func (repo *Currency) GetLatestExchangeRates(ctx context.Context) (map[enum.CurrencyCode]entity.Rate, error) {
 	query := "SELECT currency_code, exchange_rate FROM currency"
-	rows, err := repo.cli.QueryContext(ctx, query)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.GetLatestExchangeRates.QueryContext: %w", err)
 	}
 	defer rows.Close()
 
-	exchangeRates := make(map[enum.CurrencyCode]float64)
+	exchangeRates := make(map[enum.CurrencyCode]entity.Rate)
 	for rows.Next() {
 		var code enum.CurrencyCode
-		var rate float64
+		var rate entity.Rate
 		err = rows.Scan(&code, &rate)
 		if err != nil {
 			return nil, fmt.Errorf("repository.Currency.GetLatestExchangeRates.Scan: %w", err)
@@ -262,7 +254,7 @@ func (repo *Currency) GetBetweenDates(ctx context.Context, code enum.CurrencyCod
 		WHERE currency_code = $1
 		AND (updated_at BETWEEN $2 AND $3)
 	`
-	rows, err := repo.cli.QueryContext(ctx, query, code, startDate, endDate)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, code, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.GetBetweenDates: %w", err)
 	}
@@ -287,7 +279,7 @@ func (repo *Currency) GetTopNCurrencies(ctx context.Context, n int) ([]entity.Cu
 		ORDER BY exchange_rate DESC
 		LIMIT $1
 	`
-	rows, err := repo.cli.QueryContext(ctx, query, n)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, n)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.GetTopNCurrencies: %w", err)
 	}
@@ -312,7 +304,7 @@ func (repo *Currency) GetBottomNCurrencies(ctx context.Context, n int) ([]entity
 		ORDER BY exchange_rate ASC
 		LIMIT $1
 	`
-	rows, err := repo.cli.QueryContext(ctx, query, n)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, n)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.GetBottomNCurrencies: %w", err)
 	}
@@ -337,7 +329,7 @@ func (repo *Currency) GetAverageExchangeRate(ctx context.Context, code enum.Curr
 		WHERE currency_code = $1
 	`
 	var avg float64
-	err := repo.cli.QueryRowContext(ctx, query, code).Scan(&avg)
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, code).Scan(&avg)
 	if err != nil {
 		return 0, fmt.Errorf("repository.Currency.GetAverageExchangeRate: %w", err)
 	}
@@ -349,7 +341,7 @@ func (repo *Currency) ListCountriesByCurrencyCode(ctx context.Context, code enum
 		SELECT DISTINCT country FROM country_currency_mapping
 		WHERE currency_code = $1
 	`
-	rows, err := repo.cli.QueryContext(ctx, query, code)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, code)
 	if err != nil {
 		return nil, fmt.Errorf("repository.Currency.ListCountriesByCurrencyCode: %w", err)
 	}
@@ -25,7 +25,7 @@ func (f *FinancialAccount) GetByID(ctx context.Context, accountID int) (*entity.
 			account_id = $1
 	`
 
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetByID.PrepareContext: %w", err)
 	}
@@ -70,7 +70,7 @@ func (f *FinancialAccount) GetByUserID(ctx context.Context, userID int) ([]entit
 			user_id = $1
 	`
 
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetByUserID.PrepareContext: %w", err)
 	}
@@ -115,7 +115,7 @@ func (f *FinancialAccount) IsAccountExist(ctx context.Context, accountID int) (b
         FROM financial_account 
         WHERE account_id = $1
     `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return false, fmt.Errorf("repository.FinancialAccount.IsAccountExist.Prepare: %w", err)
 	}
@@ -137,7 +137,7 @@ func (f *FinancialAccount) Insert(ctx context.Context, account *entity.Financial
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialAccount.Insert.PrepareContext: %w", err)
 	}
@@ -166,7 +166,7 @@ func (f *FinancialAccount) Update(ctx context.Context, account *entity.Financial
 		WHERE 
 			account_id = $12
 	`
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialAccount.Update.PrepareContext: %w", err)
 	}
@@ -190,7 +190,7 @@ func (f *FinancialAccount) Delete(ctx context.Context, accountID int) error {
         DELETE FROM financial_account 
         WHERE account_id = $1
     `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialAccount.Delete.Prepare: %w", err)
 	}
@@ -209,13 +209,13 @@ func (f *FinancialAccount) ListByStatus(ctx context.Context, status enum.Financi
 	FROM financial_account 
 	WHERE status = $1
 `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.ListByStatus.Prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx, string(status))
+	rows, err := stmt.QueryContext(ctx, status)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.ListByStatus: %w", err)
 	}
@@ -243,7 +243,7 @@ func (f *FinancialAccount) GetByShabaNumber(ctx context.Context, shabaNumber str
         FROM financial_account 
         WHERE shaba_number = $1
     `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetByShabaNumber.Prepare: %w", err)
 	}
@@ -267,7 +267,7 @@ func (f *FinancialAccount) GetTransactions(ctx context.Context, accountID int) (
 	FROM financial_account_transaction 
 	WHERE account_id = $1
 `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetTransactions.Prepare: %w", err)
 	}
@@ -310,7 +310,7 @@ func (f *FinancialAccount) ListByType(ctx context.Context, accountType string) (
         FROM financial_account 
         WHERE account_type = $1
     `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.ListByType.Prepare: %w", err)
 	}
@@ -356,7 +356,7 @@ func (f *FinancialAccount) GetCurrencyByAccountID(ctx context.Context, accountID
 	JOIN financial_account f ON f.currency_id = c.currency_id 
 	WHERE f.account_id = $1
 `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetCurrencyByAccountID.Prepare: %w", err)
 	}
@@ -387,7 +387,7 @@ func (f *FinancialAccount) GetBranchByAccountID(ctx context.Context, accountID i
 	JOIN financial_account f ON f.branch_id = b.branch_id 
 	WHERE f.account_id = $1
 `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetBranchByAccountID.Prepare: %w", err)
 	}
@@ -423,7 +423,7 @@ func (f *FinancialAccount) GetBankByAccountID(ctx context.Context, accountID int
 	JOIN financial_account f ON f.bank_id = b.bank_id 
 	WHERE f.account_id = $1
 `
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialAccount.GetBankByAccountID.Prepare: %w", err)
 	}
@@ -452,7 +452,7 @@ func (f *FinancialAccount) UpdateStatus(ctx context.Context, accountID int, stat
 		SET status = $2, updated_at = CURRENT_TIMESTAMP
 		WHERE account_id = $1
 	`
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialAccount.UpdateStatus.Prepare: %w", err)
 	}
@@ -472,7 +472,7 @@ func (f *FinancialAccount) GetAccountStatus(ctx context.Context, accountID int)
 		FROM financial_account
 		WHERE account_id = $1
 	`
-	stmt, err := f.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, f.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return 0, fmt.Errorf("repository.FinancialAccount.GetAccountStatus.Prepare: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
@@ -16,11 +17,12 @@ type FinancialCard struct {
 func (repo *FinancialCard) Insert(ctx context.Context, card *entity.FinancialCard) error {
 	query := `
         INSERT INTO financial_card 
-        (account_id, card_type, card_number, expiration_date, card_holder_name, cvv, status, issued_date, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        (account_id, card_type, network, bank_id, card_token, last_four, expiration_date, card_holder_name, status, issued_date, replaces_card_id,
+         virtual, usage, locked_merchant, spend_cap, spend_cap_currency, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, ''), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
         RETURNING card_id
     `
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialCard.Insert.PrepareContext: %w", err)
 	}
@@ -29,12 +31,20 @@ func (repo *FinancialCard) Insert(ctx context.Context, card *entity.FinancialCar
 	err = stmt.QueryRowContext(ctx,
 		card.AccountID,
 		card.CardType,
-		card.CardNumber,
+		card.Network,
+		card.BankID,
+		card.CardToken,
+		card.LastFour,
 		card.ExpirationDate,
 		card.CardHolderName,
-		card.CVV,
 		card.Status,
 		card.IssuedDate,
+		card.ReplacesCardID,
+		card.Virtual,
+		card.Usage,
+		card.LockedMerchant,
+		card.SpendCap,
+		card.SpendCap.Currency,
 	).Scan(&card.CardID)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialCard.Insert.QueryRowContext: %w", err)
@@ -46,10 +56,10 @@ func (repo *FinancialCard) Insert(ctx context.Context, card *entity.FinancialCar
 func (repo *FinancialCard) Update(ctx context.Context, card *entity.FinancialCard) error {
 	query := `
         UPDATE financial_card
-        SET account_id = $1, card_type = $2, card_number = $3, expiration_date = $4, card_holder_name = $5, cvv = $6, status = $7, issued_date = $8, updated_at = CURRENT_TIMESTAMP
-        WHERE card_id = $9
+        SET account_id = $1, card_type = $2, network = $3, bank_id = $4, card_token = $5, last_four = $6, expiration_date = $7, card_holder_name = $8, status = $9, issued_date = $10, updated_at = CURRENT_TIMESTAMP
+        WHERE card_id = $11
     `
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialCard.Update.PrepareContext: %w", err)
 	}
@@ -58,10 +68,12 @@ func (repo *FinancialCard) Update(ctx context.Context, card *entity.FinancialCar
 	_, err = stmt.ExecContext(ctx,
 		card.AccountID,
 		card.CardType,
-		card.CardNumber,
+		card.Network,
+		card.BankID,
+		card.CardToken,
+		card.LastFour,
 		card.ExpirationDate,
 		card.CardHolderName,
-		card.CVV,
 		card.Status,
 		card.IssuedDate,
 		card.CardID,
@@ -75,7 +87,7 @@ func (repo *FinancialCard) Update(ctx context.Context, card *entity.FinancialCar
 
 func (repo *FinancialCard) Delete(ctx context.Context, cardID int64) error {
 	query := "DELETE FROM financial_card WHERE card_id = $1"
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.FinancialCard.Delete.PrepareContext: %w", err)
 	}
@@ -89,83 +101,83 @@ func (repo *FinancialCard) Delete(ctx context.Context, cardID int64) error {
 	return nil
 }
 
+// Cards stored before the vault have no token until the vault-cards command has run.
+const financialCardColumns = `
+	card_id, account_id, card_type, network, bank_id, COALESCE(card_token, ''), last_four, expiration_date,
+	card_holder_name, status, issued_date, replaces_card_id, virtual, usage, locked_merchant,
+	spend_cap, COALESCE(spend_cap_currency, ''), created_at, updated_at, deleted_at
+`
+
 func (repo *FinancialCard) GetByID(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	card, err := repo.get(ctx, cardID, "")
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.GetByID.%w", err)
+	}
+
+	return card, nil
+}
+
+func (repo *FinancialCard) GetForUpdate(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	card, err := repo.get(ctx, cardID, "FOR UPDATE")
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.GetForUpdate.%w", err)
+	}
+
+	return card, nil
+}
+
+func (repo *FinancialCard) get(ctx context.Context, cardID int64, lock string) (*entity.FinancialCard, error) {
 	query := `
-		SELECT card_id, account_id, card_type, card_number, expiration_date, card_holder_name, cvv, status, issued_date, created_at, updated_at, deleted_at
+		SELECT ` + financialCardColumns + `
 		FROM financial_card
 		WHERE card_id = $1
-	`
+	` + lock
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	card, err := scanFinancialCard(conn(ctx, repo.cli).QueryRowContext(ctx, query, cardID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare query for getting a FinancialCard by ID: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Scan: %w", err)
 	}
-	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, cardID)
+	return card, nil
+}
 
-	var card entity.FinancialCard
-	var deletedAt sql.NullTime // Handle NULL time
+func (repo *FinancialCard) GetReplacement(ctx context.Context, cardID int64) (*entity.FinancialCard, error) {
+	query := `
+		SELECT ` + financialCardColumns + `
+		FROM financial_card
+		WHERE replaces_card_id = $1
+	`
 
-	if err := row.Scan(
-		&card.CardID,
-		&card.AccountID,
-		&card.CardNumber,
-		&card.CardType,
-		&card.ExpirationDate,
-		&card.CardHolderName,
-		&card.CVV,
-		&card.Status,
-		&card.IssuedDate,
-		&card.CreatedAt,
-		&card.UpdatedAt,
-		&deletedAt,
-	); err != nil {
+	card, err := scanFinancialCard(conn(ctx, repo.cli).QueryRowContext(ctx, query, cardID))
+	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("FinancialCard not found")
+			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to scan FinancialCard data: %w", err)
-	}
-
-	if deletedAt.Valid {
-		card.DeletedAt = &deletedAt.Time
+		return nil, fmt.Errorf("repository.FinancialCard.GetReplacement.Scan: %w", err)
 	}
 
-	return &card, nil
+	return card, nil
 }
 
 func (repo *FinancialCard) ListByAccountID(ctx context.Context, accountID int) ([]*entity.FinancialCard, error) {
 	query := `
-	SELECT card_id, account_id, card_type, card_number, expiration_date, status, created_at, updated_at
-	FROM financial_card
-	WHERE account_id = $1
-`
+		SELECT ` + financialCardColumns + `
+		FROM financial_card
+		WHERE account_id = $1
+	`
 
-	rows, err := repo.cli.QueryContext(ctx, query, accountID)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialCard.ListByAccountID.QueryContext: %w", err)
 	}
 	defer rows.Close()
 
-	var cards []*entity.FinancialCard
-	for rows.Next() {
-		var card entity.FinancialCard
-		if err := rows.Scan(
-			&card.CardID,
-			&card.AccountID,
-			&card.CardNumber,
-			&card.CardType,
-			&card.ExpirationDate,
-			&card.CardHolderName,
-			&card.CVV,
-			&card.Status,
-			&card.IssuedDate,
-			&card.CreatedAt,
-			&card.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("repository.FinancialCard.ListByAccountID.Scan: %w", err)
-		}
-		cards = append(cards, &card)
+	cards, err := scanFinancialCards(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListByAccountID.%w", err)
 	}
 
 	return cards, nil
@@ -173,37 +185,245 @@ func (repo *FinancialCard) ListByAccountID(ctx context.Context, accountID int) (
 
 func (repo *FinancialCard) ListByCardType(ctx context.Context, cardType enum.FinancialCardType) ([]*entity.FinancialCard, error) {
 	query := `
-	SELECT card_id, account_id, card_type, card_number, expiration_date, status, created_at, updated_at
-	FROM financial_card
-	WHERE card_type = $1
-`
+		SELECT ` + financialCardColumns + `
+		FROM financial_card
+		WHERE card_type = $1
+	`
 
-	rows, err := repo.cli.QueryContext(ctx, query, cardType)
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, cardType)
 	if err != nil {
 		return nil, fmt.Errorf("repository.FinancialCard.ListByCardType.QueryContext: %w", err)
 	}
 	defer rows.Close()
 
-	var cards []*entity.FinancialCard
+	cards, err := scanFinancialCards(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListByCardType.%w", err)
+	}
+
+	return cards, nil
+}
+
+// ListExpiring returns up to limit physical cards in use that expire before the given time
+// and were never replaced, soonest first. Cards not yet in the vault are left out, as a new
+// number cannot be drawn from their BIN.
+func (repo *FinancialCard) ListExpiring(ctx context.Context, before time.Time, limit int) ([]*entity.FinancialCard, error) {
+	query := `
+		SELECT ` + financialCardColumns + `
+		FROM financial_card c
+		WHERE c.status IN ($1, $2) AND c.expiration_date < $3
+			AND NOT c.virtual AND c.card_token IS NOT NULL AND c.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM financial_card r WHERE r.replaces_card_id = c.card_id)
+		ORDER BY c.expiration_date
+		LIMIT $4
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, enum.Active, enum.CardFrozen, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListExpiring.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	cards, err := scanFinancialCards(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListExpiring.%w", err)
+	}
+
+	return cards, nil
+}
+
+// ListExpired returns up to limit cards not yet ended whose expiration date is before today,
+// oldest first.
+func (repo *FinancialCard) ListExpired(ctx context.Context, today time.Time, limit int) ([]*entity.FinancialCard, error) {
+	query := `
+		SELECT ` + financialCardColumns + `
+		FROM financial_card
+		WHERE status IN ($1, $2, $3) AND expiration_date < $4 AND deleted_at IS NULL
+		ORDER BY expiration_date
+		LIMIT $5
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, enum.Active, enum.Inactive, enum.CardFrozen, today, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListExpired.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	cards, err := scanFinancialCards(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListExpired.%w", err)
+	}
+
+	return cards, nil
+}
+
+// ListPlaintextNumbers returns up to limit card numbers, by card ID, that were stored
+// before the vault and still have to be moved into it.
+func (repo *FinancialCard) ListPlaintextNumbers(ctx context.Context, limit int) (map[int]string, error) {
+	query := `
+		SELECT card_id, card_number
+		FROM financial_card
+		WHERE card_number IS NOT NULL
+		ORDER BY card_id
+		LIMIT $1
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListPlaintextNumbers.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	numbers := make(map[int]string)
 	for rows.Next() {
-		var card entity.FinancialCard
+		var cardID int
+		var number string
+		if err := rows.Scan(&cardID, &number); err != nil {
+			return nil, fmt.Errorf("repository.FinancialCard.ListPlaintextNumbers.Scan: %w", err)
+		}
+		numbers[cardID] = number
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListPlaintextNumbers.Rows: %w", err)
+	}
+
+	return numbers, nil
+}
+
+// ReplaceNumber swaps the stored card number for its vault token.
+func (repo *FinancialCard) ReplaceNumber(ctx context.Context, cardID int, token, lastFour string) error {
+	query := `
+		UPDATE financial_card
+		SET card_token = $2, last_four = $3, card_number = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE card_id = $1
+	`
+
+	if _, err := conn(ctx, repo.cli).ExecContext(ctx, query, cardID, token, lastFour); err != nil {
+		return fmt.Errorf("repository.FinancialCard.ReplaceNumber.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *FinancialCard) InsertEvent(ctx context.Context, event *entity.CardEvent) error {
+	query := `
+		INSERT INTO public.card_event (
+			financial_card_id, actor_id, action, from_status, to_status, note, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		RETURNING card_event_id, created_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		event.FinancialCardID,
+		event.ActorID,
+		event.Action,
+		event.FromStatus,
+		event.ToStatus,
+		event.Note,
+	).Scan(&event.CardEventID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.FinancialCard.InsertEvent.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *FinancialCard) ListEvents(ctx context.Context, cardID int64) ([]*entity.CardEvent, error) {
+	query := `
+		SELECT card_event_id, financial_card_id, actor_id, action, from_status, to_status, note, created_at
+		FROM public.card_event
+		WHERE financial_card_id = $1
+		ORDER BY card_event_id
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListEvents.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entity.CardEvent
+	for rows.Next() {
+		event := &entity.CardEvent{}
 		if err := rows.Scan(
-			&card.CardID,
-			&card.AccountID,
-			&card.CardNumber,
-			&card.CardType,
-			&card.ExpirationDate,
-			&card.CardHolderName,
-			&card.CVV,
-			&card.Status,
-			&card.IssuedDate,
-			&card.CreatedAt,
-			&card.UpdatedAt,
+			&event.CardEventID,
+			&event.FinancialCardID,
+			&event.ActorID,
+			&event.Action,
+			&event.FromStatus,
+			&event.ToStatus,
+			&event.Note,
+			&event.CreatedAt,
 		); err != nil {
-			return nil, fmt.Errorf("repository.FinancialCard.ListByCardType.Scan: %w", err)
+			return nil, fmt.Errorf("repository.FinancialCard.ListEvents.Scan: %w", err)
 		}
-		cards = append(cards, &card)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.FinancialCard.ListEvents.Rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (repo *FinancialCard) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *FinancialCard) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *FinancialCard) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+func scanFinancialCards(rows *sql.Rows) ([]*entity.FinancialCard, error) {
+	var cards []*entity.FinancialCard
+	for rows.Next() {
+		card, err := scanFinancialCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		cards = append(cards, card)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
 	}
 
 	return cards, nil
 }
+
+func scanFinancialCard(row rowScanner) (*entity.FinancialCard, error) {
+	card := &entity.FinancialCard{}
+	err := row.Scan(
+		&card.CardID,
+		&card.AccountID,
+		&card.CardType,
+		&card.Network,
+		&card.BankID,
+		&card.CardToken,
+		&card.LastFour,
+		&card.ExpirationDate,
+		&card.CardHolderName,
+		&card.Status,
+		&card.IssuedDate,
+		&card.ReplacesCardID,
+		&card.Virtual,
+		&card.Usage,
+		&card.LockedMerchant,
+		&card.SpendCap,
+		&card.SpendCap.Currency,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+		&card.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
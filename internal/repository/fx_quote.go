@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type FXQuote struct {
+	cli *sql.DB
+}
+
+func (repo *FXQuote) Insert(ctx context.Context, quote *entity.FXQuote) error {
+	query := `
+		INSERT INTO public.fx_quote (
+			user_id, sender_account_id, receiver_account_id,
+			source_currency, source_amount, target_currency, target_amount,
+			mid_rate, rate, spread_bps, spread, status, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING fx_quote_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		quote.UserID,
+		quote.SenderAccountID,
+		quote.ReceiverAccountID,
+		quote.SourceAmount.Currency,
+		quote.SourceAmount,
+		quote.TargetAmount.Currency,
+		quote.TargetAmount,
+		quote.MidRate,
+		quote.Rate,
+		quote.SpreadBps,
+		quote.Spread,
+		quote.Status,
+		quote.ExpiresAt,
+	).Scan(&quote.FXQuoteID, &quote.CreatedAt, &quote.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.FXQuote.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *FXQuote) GetForUpdate(ctx context.Context, quoteID int) (*entity.FXQuote, error) {
+	query := `
+		SELECT fx_quote_id, user_id, sender_account_id, receiver_account_id,
+			source_currency, source_amount, target_currency, target_amount,
+			mid_rate, rate, spread_bps, spread, status, journal_entry_id, expires_at, created_at, updated_at
+		FROM public.fx_quote
+		WHERE fx_quote_id = $1
+		FOR UPDATE
+	`
+
+	quote := &entity.FXQuote{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, quoteID).Scan(
+		&quote.FXQuoteID,
+		&quote.UserID,
+		&quote.SenderAccountID,
+		&quote.ReceiverAccountID,
+		&quote.SourceAmount.Currency,
+		&quote.SourceAmount,
+		&quote.TargetAmount.Currency,
+		&quote.TargetAmount,
+		&quote.MidRate,
+		&quote.Rate,
+		&quote.SpreadBps,
+		&quote.Spread,
+		&quote.Status,
+		&quote.JournalEntryID,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.FXQuote.GetForUpdate.QueryRowContext: %w", err)
+	}
+	quote.Spread.Currency = quote.TargetAmount.Currency
+
+	return quote, nil
+}
+
+func (repo *FXQuote) MarkExecuted(ctx context.Context, quoteID int, journalEntryID int) error {
+	query := `
+		UPDATE public.fx_quote
+		SET status = $2, journal_entry_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE fx_quote_id = $1 AND status = $4
+	`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, quoteID, enum.FXQuoteExecuted, journalEntryID, enum.FXQuoteOpen)
+	if err != nil {
+		return fmt.Errorf("repository.FXQuote.MarkExecuted.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository.FXQuote.MarkExecuted.RowsAffected: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("repository.FXQuote.MarkExecuted: quote %d is not open", quoteID)
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type GiftCard struct {
+	cli *sql.DB
+}
+
+const giftCardColumns = `
+	gift_card_id, purchaser_id, code_hash, code_last_four, currency_code, initial_amount, balance,
+	status, expires_at, created_at, updated_at
+`
+
+func (repo *GiftCard) Insert(ctx context.Context, card *entity.GiftCard) error {
+	query := `
+		INSERT INTO public.gift_card (
+			purchaser_id, code_hash, code_last_four, currency_code, initial_amount, balance,
+			status, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING gift_card_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		card.PurchaserID,
+		card.CodeHash,
+		card.CodeLastFour,
+		card.InitialAmount.Currency,
+		card.InitialAmount,
+		card.Balance,
+		card.Status,
+		card.ExpiresAt,
+	).Scan(&card.GiftCardID, &card.CreatedAt, &card.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.GiftCard.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *GiftCard) Update(ctx context.Context, card *entity.GiftCard) error {
+	query := `
+		UPDATE public.gift_card
+		SET balance = $1, status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE gift_card_id = $3
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, card.Balance, card.Status, card.GiftCardID).Scan(&card.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.GiftCard.Update.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *GiftCard) GetByCodeHash(ctx context.Context, codeHash []byte) (*entity.GiftCard, error) {
+	card, err := repo.get(ctx, "code_hash", codeHash, "")
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.GetByCodeHash.%w", err)
+	}
+
+	return card, nil
+}
+
+func (repo *GiftCard) GetByCodeHashForUpdate(ctx context.Context, codeHash []byte) (*entity.GiftCard, error) {
+	card, err := repo.get(ctx, "code_hash", codeHash, "FOR UPDATE")
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.GetByCodeHashForUpdate.%w", err)
+	}
+
+	return card, nil
+}
+
+func (repo *GiftCard) GetForUpdate(ctx context.Context, giftCardID int) (*entity.GiftCard, error) {
+	card, err := repo.get(ctx, "gift_card_id", giftCardID, "FOR UPDATE")
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.GetForUpdate.%w", err)
+	}
+
+	return card, nil
+}
+
+func (repo *GiftCard) get(ctx context.Context, column string, value any, lock string) (*entity.GiftCard, error) {
+	query := `
+		SELECT ` + giftCardColumns + `
+		FROM public.gift_card
+		WHERE ` + column + ` = $1
+	` + lock
+
+	card, err := scanGiftCard(conn(ctx, repo.cli).QueryRowContext(ctx, query, value))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Scan: %w", err)
+	}
+
+	return card, nil
+}
+
+func (repo *GiftCard) ListByPurchaserID(ctx context.Context, userID int) ([]*entity.GiftCard, error) {
+	query := `
+		SELECT ` + giftCardColumns + `
+		FROM public.gift_card
+		WHERE purchaser_id = $1
+		ORDER BY gift_card_id DESC
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.ListByPurchaserID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	cards, err := scanGiftCards(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.ListByPurchaserID.%w", err)
+	}
+
+	return cards, nil
+}
+
+func (repo *GiftCard) ListExpired(ctx context.Context, now time.Time, limit int) ([]*entity.GiftCard, error) {
+	query := `
+		SELECT ` + giftCardColumns + `
+		FROM public.gift_card
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, enum.GiftCardActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.ListExpired.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	cards, err := scanGiftCards(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.GiftCard.ListExpired.%w", err)
+	}
+
+	return cards, nil
+}
+
+func (repo *GiftCard) InsertTransaction(ctx context.Context, transaction *entity.GiftCardTransaction) error {
+	query := `
+		INSERT INTO public.gift_card_transaction (
+			gift_card_id, transaction_type, currency_code, amount, balance_after, user_id,
+			financial_account_id, merchant, transaction_group_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP)
+		RETURNING gift_card_transaction_id, created_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		transaction.GiftCardID,
+		transaction.Type,
+		transaction.Amount.Currency,
+		transaction.Amount,
+		transaction.BalanceAfter,
+		transaction.UserID,
+		transaction.FinancialAccountID,
+		transaction.Merchant,
+		transaction.TransactionGroupID,
+	).Scan(&transaction.GiftCardTransactionID, &transaction.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.GiftCard.InsertTransaction.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *GiftCard) GetRedemption(ctx context.Context, giftCardID int) (*entity.GiftCardTransaction, error) {
+	query := `
+		SELECT
+			gift_card_transaction_id, gift_card_id, transaction_type, currency_code, amount, balance_after,
+			user_id, financial_account_id, merchant, transaction_group_id, created_at
+		FROM public.gift_card_transaction
+		WHERE gift_card_id = $1 AND transaction_type = $2
+	`
+
+	transaction := &entity.GiftCardTransaction{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, giftCardID, enum.GiftCardRedeem).Scan(
+		&transaction.GiftCardTransactionID,
+		&transaction.GiftCardID,
+		&transaction.Type,
+		&transaction.Amount.Currency,
+		&transaction.Amount,
+		&transaction.BalanceAfter,
+		&transaction.UserID,
+		&transaction.FinancialAccountID,
+		&transaction.Merchant,
+		&transaction.TransactionGroupID,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.GiftCard.GetRedemption.Scan: %w", err)
+	}
+	transaction.BalanceAfter.Currency = transaction.Amount.Currency
+
+	return transaction, nil
+}
+
+func (repo *GiftCard) ClaimAttempt(ctx context.Context, userID int, since time.Time, max int) (int, bool, error) {
+	var attemptID int
+	var claimed bool
+	err := runInTx(ctx, repo.cli, func(tx *sql.Tx) error {
+		// The insert below only sees attempts committed before it starts, so the user's row
+		// is locked first to make a parallel claim wait for this one.
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM public.user WHERE user_id = $1 FOR UPDATE`, userID); err != nil {
+			return fmt.Errorf("repository.GiftCard.ClaimAttempt.LockUser: %w", err)
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO public.gift_card_failed_attempt (user_id, created_at)
+			SELECT $1, CURRENT_TIMESTAMP
+			WHERE (SELECT COUNT(*) FROM public.gift_card_failed_attempt WHERE user_id = $1 AND created_at >= $2) < $3
+			RETURNING gift_card_failed_attempt_id
+		`, userID, since, max).Scan(&attemptID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return fmt.Errorf("repository.GiftCard.ClaimAttempt.Insert: %w", err)
+		}
+
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return attemptID, claimed, nil
+}
+
+func (repo *GiftCard) ForgetAttempt(ctx context.Context, attemptID int) error {
+	_, err := conn(ctx, repo.cli).ExecContext(ctx, `
+		DELETE FROM public.gift_card_failed_attempt WHERE gift_card_failed_attempt_id = $1
+	`, attemptID)
+	if err != nil {
+		return fmt.Errorf("repository.GiftCard.ForgetAttempt.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *GiftCard) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *GiftCard) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *GiftCard) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+func scanGiftCards(rows *sql.Rows) ([]*entity.GiftCard, error) {
+	var cards []*entity.GiftCard
+	for rows.Next() {
+		card, err := scanGiftCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		cards = append(cards, card)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return cards, nil
+}
+
+func scanGiftCard(row rowScanner) (*entity.GiftCard, error) {
+	card := &entity.GiftCard{}
+	err := row.Scan(
+		&card.GiftCardID,
+		&card.PurchaserID,
+		&card.CodeHash,
+		&card.CodeLastFour,
+		&card.InitialAmount.Currency,
+		&card.InitialAmount,
+		&card.Balance,
+		&card.Status,
+		&card.ExpiresAt,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	card.Balance.Currency = card.InitialAmount.Currency
+
+	return card, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGiftCard_ClaimAttempt(t *testing.T) {
+	since := time.Now().Add(-15 * time.Minute)
+
+	t.Run("records an attempt under the user's lock", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT 1 FROM public.user WHERE user_id = \$1 FOR UPDATE`).
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO public.gift_card_failed_attempt").
+			WithArgs(3, since, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"gift_card_failed_attempt_id"}).AddRow(8))
+		mock.ExpectCommit()
+
+		repo := &GiftCard{cli: db}
+		attemptID, claimed, err := repo.ClaimAttempt(context.Background(), 3, since, 5)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, 8, attemptID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses a user at the limit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("FOR UPDATE").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO public.gift_card_failed_attempt").
+			WithArgs(3, since, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"gift_card_failed_attempt_id"}))
+		mock.ExpectCommit()
+
+		repo := &GiftCard{cli: db}
+		_, claimed, err := repo.ClaimAttempt(context.Background(), 3, since, 5)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
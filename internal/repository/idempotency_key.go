@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type IdempotencyKey struct {
+	cli *sql.DB
+}
+
+func (repo *IdempotencyKey) Claim(ctx context.Context, key *entity.IdempotencyKey) (bool, error) {
+	query := `
+		INSERT INTO public.idempotency_key (
+			user_id, scope, key, fingerprint, status, locked_until, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, scope, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status = EXCLUDED.status,
+			response = NULL,
+			locked_until = EXCLUDED.locked_until,
+			expires_at = EXCLUDED.expires_at,
+			created_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE idempotency_key.expires_at < CURRENT_TIMESTAMP
+			OR (idempotency_key.status = $8
+				AND idempotency_key.locked_until < CURRENT_TIMESTAMP
+				AND idempotency_key.fingerprint = EXCLUDED.fingerprint)
+		RETURNING locked_until, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		key.UserID,
+		key.Scope,
+		key.Key,
+		key.Fingerprint,
+		key.Status,
+		key.LockedUntil,
+		key.ExpiresAt,
+		enum.IdempotencyInFlight,
+	).Scan(&key.LockedUntil, &key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("repository.IdempotencyKey.Claim.QueryRowContext: %w", err)
+	}
+
+	return true, nil
+}
+
+func (repo *IdempotencyKey) Get(ctx context.Context, userID int, scope, key string) (*entity.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, scope, key, fingerprint, status, response, locked_until, expires_at, created_at, updated_at
+		FROM public.idempotency_key
+		WHERE user_id = $1 AND scope = $2 AND key = $3
+	`
+
+	record := &entity.IdempotencyKey{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, userID, scope, key).Scan(
+		&record.UserID,
+		&record.Scope,
+		&record.Key,
+		&record.Fingerprint,
+		&record.Status,
+		&record.Response,
+		&record.LockedUntil,
+		&record.ExpiresAt,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.IdempotencyKey.Get.QueryRowContext: %w", err)
+	}
+
+	return record, nil
+}
+
+func (repo *IdempotencyKey) Complete(ctx context.Context, userID int, scope, key string, lockedUntil time.Time, response []byte) (bool, error) {
+	query := `
+		UPDATE public.idempotency_key
+		SET status = $5, response = $6, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND scope = $2 AND key = $3 AND locked_until = $4
+	`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, userID, scope, key, lockedUntil, enum.IdempotencyCompleted, response)
+	if err != nil {
+		return false, fmt.Errorf("repository.IdempotencyKey.Complete.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.IdempotencyKey.Complete.RowsAffected: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *IdempotencyKey) Delete(ctx context.Context, userID int, scope, key string, lockedUntil time.Time) (bool, error) {
+	query := `
+		DELETE FROM public.idempotency_key
+		WHERE user_id = $1 AND scope = $2 AND key = $3 AND locked_until = $4
+	`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, userID, scope, key, lockedUntil)
+	if err != nil {
+		return false, fmt.Errorf("repository.IdempotencyKey.Delete.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.IdempotencyKey.Delete.RowsAffected: %w", err)
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey_Claim(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	key := func() *entity.IdempotencyKey {
+		until := lockedUntil
+		return &entity.IdempotencyKey{
+			UserID:      1,
+			Scope:       "scheduler.transfer",
+			Key:         "7:1700000000",
+			Fingerprint: "fingerprint",
+			Status:      enum.IdempotencyInFlight,
+			LockedUntil: &until,
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		}
+	}
+	args := func(k *entity.IdempotencyKey) []driver.Value {
+		return []driver.Value{k.UserID, k.Scope, k.Key, k.Fingerprint, k.Status, k.LockedUntil, k.ExpiresAt, enum.IdempotencyInFlight}
+	}
+
+	t.Run("takes over an in-flight claim whose lease lapsed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		record := key()
+		now := time.Now()
+		mock.ExpectQuery(`(?s)ON CONFLICT .* WHERE idempotency_key.expires_at < CURRENT_TIMESTAMP\s+OR \(idempotency_key.status = \$8\s+AND idempotency_key.locked_until < CURRENT_TIMESTAMP`).
+			WithArgs(args(record)...).
+			WillReturnRows(sqlmock.NewRows([]string{"locked_until", "created_at", "updated_at"}).AddRow(lockedUntil, now, now))
+
+		repo := &IdempotencyKey{cli: db}
+		claimed, err := repo.Claim(context.Background(), record)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, now, record.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("leaves a live claim alone", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		record := key()
+		mock.ExpectQuery("INSERT INTO public.idempotency_key").
+			WithArgs(args(record)...).
+			WillReturnRows(sqlmock.NewRows([]string{"locked_until", "created_at", "updated_at"}))
+
+		repo := &IdempotencyKey{cli: db}
+		claimed, err := repo.Claim(context.Background(), record)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIdempotencyKey_Complete(t *testing.T) {
+	lockedUntil := time.Now()
+
+	t.Run("reports a claim that was taken over", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec("UPDATE public.idempotency_key").
+			WithArgs(1, "scope", "key", lockedUntil, enum.IdempotencyCompleted, []byte(`{}`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := &IdempotencyKey{cli: db}
+		completed, err := repo.Complete(context.Background(), 1, "scope", "key", lockedUntil, []byte(`{}`))
+		require.NoError(t, err)
+		assert.False(t, completed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -29,3 +29,79 @@ func NewFinancialAccount(database protocol.Database) *FinancialAccount {
 func NewAccountTransaction(database protocol.Database) *AccountTransaction {
 	return &AccountTransaction{cli: database.DB()}
 }
+
+func NewTransactor(database protocol.Database) *Transactor {
+	return &Transactor{cli: database.DB()}
+}
+
+func NewLedger(database protocol.Database) *Ledger {
+	return &Ledger{cli: database.DB()}
+}
+
+func NewIdempotencyKey(database protocol.Database) *IdempotencyKey {
+	return &IdempotencyKey{cli: database.DB()}
+}
+
+func NewFXQuote(database protocol.Database) *FXQuote {
+	return &FXQuote{cli: database.DB()}
+}
+
+func NewAccountRules(database protocol.Database) *AccountRules {
+	return &AccountRules{cli: database.DB()}
+}
+
+func NewRiskAssessment(database protocol.Database) *RiskAssessment {
+	return &RiskAssessment{cli: database.DB()}
+}
+
+func NewReviewCase(database protocol.Database) *ReviewCase {
+	return &ReviewCase{cli: database.DB()}
+}
+
+func NewScheduledTransfer(database protocol.Database) *ScheduledTransfer {
+	return &ScheduledTransfer{cli: database.DB()}
+}
+
+func NewCardTransaction(database protocol.Database) *CardTransaction {
+	return &CardTransaction{cli: database.DB()}
+}
+
+func NewCardHold(database protocol.Database) *CardHold {
+	return &CardHold{cli: database.DB()}
+}
+
+func NewCardVault(database protocol.Database) *CardVault {
+	return &CardVault{cli: database.DB()}
+}
+
+func NewCardBIN(database protocol.Database) *CardBIN {
+	return &CardBIN{cli: database.DB()}
+}
+
+func NewCardControls(database protocol.Database) *CardControls {
+	return &CardControls{cli: database.DB()}
+}
+
+func NewGiftCard(database protocol.Database) *GiftCard {
+	return &GiftCard{cli: database.DB()}
+}
+
+func NewCreditLine(database protocol.Database) *CreditLine {
+	return &CreditLine{cli: database.DB()}
+}
+
+func NewRole(database protocol.Database) *Role {
+	return &Role{cli: database.DB()}
+}
+
+func NewSession(database protocol.Database) *Session {
+	return &Session{cli: database.DB()}
+}
+
+func NewTwoFactor(database protocol.Database) *TwoFactor {
+	return &TwoFactor{cli: database.DB()}
+}
+
+func NewVerificationCode(database protocol.Database) *VerificationCode {
+	return &VerificationCode{cli: database.DB()}
+}
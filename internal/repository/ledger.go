@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// ErrUnbalancedEntry is returned by Post when the postings of an entry do not sum to zero per currency.
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+type Ledger struct {
+	cli *sql.DB
+}
+
+func (repo *Ledger) Post(ctx context.Context, entry *entity.JournalEntry) error {
+	if !entry.IsBalanced() {
+		return fmt.Errorf("repository.Ledger.Post: %w", ErrUnbalancedEntry)
+	}
+
+	return runInTx(ctx, repo.cli, func(tx *sql.Tx) error {
+		var quoteID, rate, midRate, spread, spreadCurrency any
+		if fx := entry.FX; fx != nil {
+			quoteID, rate, midRate, spread, spreadCurrency = fx.QuoteID, fx.Rate, fx.MidRate, fx.Spread, fx.Spread.Currency
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO public.journal_entry (
+				description, fx_quote_id, exchange_rate, mid_rate, fx_spread, fx_spread_currency, reverses_entry_id, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+			RETURNING journal_entry_id, created_at
+		`, entry.Description, quoteID, rate, midRate, spread, spreadCurrency, entry.ReversesEntryID).Scan(&entry.JournalEntryID, &entry.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("repository.Ledger.Post.InsertJournalEntry: %w", err)
+		}
+
+		// Balance rows are locked in account order so that entries touching the
+		// same accounts in opposite directions cannot deadlock.
+		postings := make([]*entity.LedgerPosting, len(entry.Postings))
+		copy(postings, entry.Postings)
+		sort.SliceStable(postings, func(i, j int) bool {
+			return postings[i].FinancialAccountID < postings[j].FinancialAccountID
+		})
+
+		for _, posting := range postings {
+			// The upsert takes a row lock on the balance, so concurrent entries on the
+			// same account are serialized and BalanceAfter is exact.
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO public.account_balance (financial_account_id, currency_code, balance, updated_at)
+				VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+				ON CONFLICT (financial_account_id, currency_code)
+				DO UPDATE SET balance = account_balance.balance + EXCLUDED.balance, updated_at = CURRENT_TIMESTAMP
+				RETURNING balance
+			`, posting.FinancialAccountID, posting.Amount.Currency, posting.Amount).Scan(&posting.BalanceAfter)
+			if err != nil {
+				return fmt.Errorf("repository.Ledger.Post.UpdateBalance: %w", err)
+			}
+			posting.BalanceAfter.Currency = posting.Amount.Currency
+
+			posting.JournalEntryID = entry.JournalEntryID
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO public.ledger_posting (
+					journal_entry_id, financial_account_id, currency_code, amount, balance_after, created_at
+				) VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+				RETURNING posting_id, created_at
+			`, posting.JournalEntryID, posting.FinancialAccountID, posting.Amount.Currency, posting.Amount, posting.BalanceAfter).
+				Scan(&posting.PostingID, &posting.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("repository.Ledger.Post.InsertPosting: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repo *Ledger) GetJournalEntry(ctx context.Context, journalEntryID int) (*entity.JournalEntry, error) {
+	entry := &entity.JournalEntry{}
+	var (
+		quoteID        sql.NullInt64
+		rate, midRate  *entity.Rate
+		spread         sql.NullInt64
+		spreadCurrency sql.NullString
+	)
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT journal_entry_id, description, fx_quote_id, exchange_rate, mid_rate, fx_spread, fx_spread_currency, reverses_entry_id, created_at
+		FROM public.journal_entry
+		WHERE journal_entry_id = $1
+	`, journalEntryID).Scan(
+		&entry.JournalEntryID,
+		&entry.Description,
+		&quoteID,
+		&rate,
+		&midRate,
+		&spread,
+		&spreadCurrency,
+		&entry.ReversesEntryID,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.Ledger.GetJournalEntry.QueryRowContext: %w", err)
+	}
+
+	if quoteID.Valid && rate != nil && midRate != nil {
+		entry.FX = &entity.FXConversion{
+			QuoteID: int(quoteID.Int64),
+			Rate:    *rate,
+			MidRate: *midRate,
+			Spread:  entity.NewMoney(spread.Int64, enum.CurrencyCode(spreadCurrency.String)),
+		}
+	}
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, `
+		SELECT posting_id, journal_entry_id, financial_account_id, currency_code, amount, balance_after, created_at
+		FROM public.ledger_posting
+		WHERE journal_entry_id = $1
+		ORDER BY posting_id
+	`, journalEntryID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Ledger.GetJournalEntry.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	entry.Postings, err = scanPostings(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Ledger.GetJournalEntry.%w", err)
+	}
+
+	return entry, nil
+}
+
+func (repo *Ledger) ListPostingsByAccountID(ctx context.Context, accountID int) ([]*entity.LedgerPosting, error) {
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, `
+		SELECT posting_id, journal_entry_id, financial_account_id, currency_code, amount, balance_after, created_at
+		FROM public.ledger_posting
+		WHERE financial_account_id = $1
+		ORDER BY posting_id
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Ledger.ListPostingsByAccountID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	postings, err := scanPostings(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Ledger.ListPostingsByAccountID.%w", err)
+	}
+
+	return postings, nil
+}
+
+func (repo *Ledger) ReversedAmount(ctx context.Context, journalEntryID, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	reversed := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM public.ledger_posting p
+		JOIN public.journal_entry e ON e.journal_entry_id = p.journal_entry_id
+		WHERE e.reverses_entry_id = $1 AND p.financial_account_id = $2 AND p.currency_code = $3
+	`, journalEntryID, accountID, currency).Scan(&reversed)
+	if err != nil {
+		return entity.Money{}, fmt.Errorf("repository.Ledger.ReversedAmount.QueryRowContext: %w", err)
+	}
+
+	return reversed, nil
+}
+
+func (repo *Ledger) GetBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	balance := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT balance FROM public.account_balance
+		WHERE financial_account_id = $1 AND currency_code = $2
+	`, accountID, currency).Scan(&balance)
+	if err != nil && err != sql.ErrNoRows {
+		return entity.Money{}, fmt.Errorf("repository.Ledger.GetBalance.QueryRowContext: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (repo *Ledger) LockBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	balance := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT balance FROM public.account_balance
+		WHERE financial_account_id = $1 AND currency_code = $2
+		FOR UPDATE
+	`, accountID, currency).Scan(&balance)
+	if err != nil && err != sql.ErrNoRows {
+		return entity.Money{}, fmt.Errorf("repository.Ledger.LockBalance.QueryRowContext: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (repo *Ledger) DerivedBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	balance := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM public.ledger_posting
+		WHERE financial_account_id = $1 AND currency_code = $2
+	`, accountID, currency).Scan(&balance)
+	if err != nil {
+		return entity.Money{}, fmt.Errorf("repository.Ledger.DerivedBalance.QueryRowContext: %w", err)
+	}
+
+	return balance, nil
+}
+
+func (repo *Ledger) HeldAmount(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	held := entity.Money{Currency: currency}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM public.card_hold
+		WHERE financial_account_id = $1 AND currency_code = $2 AND status = $3
+	`, accountID, currency, enum.HoldActive).Scan(&held)
+	if err != nil {
+		return entity.Money{}, fmt.Errorf("repository.Ledger.HeldAmount.QueryRowContext: %w", err)
+	}
+
+	return held, nil
+}
+
+func scanPostings(rows *sql.Rows) ([]*entity.LedgerPosting, error) {
+	var postings []*entity.LedgerPosting
+	for rows.Next() {
+		posting := &entity.LedgerPosting{}
+		if err := rows.Scan(
+			&posting.PostingID,
+			&posting.JournalEntryID,
+			&posting.FinancialAccountID,
+			&posting.Amount.Currency,
+			&posting.Amount,
+			&posting.BalanceAfter,
+			&posting.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		posting.BalanceAfter.Currency = posting.Amount.Currency
+		postings = append(postings, posting)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return postings, nil
+}
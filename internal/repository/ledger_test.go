@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger_Post(t *testing.T) {
+	t.Run("posts a balanced entry and returns running balances", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		now := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO public.journal_entry").
+			WillReturnRows(sqlmock.NewRows([]string{"journal_entry_id", "created_at"}).AddRow(7, now))
+		// Postings are applied in account order, regardless of their order in the entry.
+		mock.ExpectQuery("INSERT INTO public.account_balance").
+			WithArgs(1, enum.USD, int64(1000)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(int64(1000)))
+		mock.ExpectQuery("INSERT INTO public.ledger_posting").
+			WithArgs(7, 1, enum.USD, int64(1000), int64(1000)).
+			WillReturnRows(sqlmock.NewRows([]string{"posting_id", "created_at"}).AddRow(1, now))
+		mock.ExpectQuery("INSERT INTO public.account_balance").
+			WithArgs(2, enum.USD, int64(-1000)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(int64(9000)))
+		mock.ExpectQuery("INSERT INTO public.ledger_posting").
+			WithArgs(7, 2, enum.USD, int64(-1000), int64(9000)).
+			WillReturnRows(sqlmock.NewRows([]string{"posting_id", "created_at"}).AddRow(2, now))
+		mock.ExpectCommit()
+
+		entry := &entity.JournalEntry{
+			Postings: []*entity.LedgerPosting{
+				{FinancialAccountID: 2, Amount: entity.NewMoney(-1000, enum.USD)},
+				{FinancialAccountID: 1, Amount: entity.NewMoney(1000, enum.USD)},
+			},
+		}
+
+		repo := &Ledger{cli: db}
+		require.NoError(t, repo.Post(context.Background(), entry))
+		assert.Equal(t, 7, entry.JournalEntryID)
+		assert.Equal(t, entity.NewMoney(9000, enum.USD), entry.PostingFor(2).BalanceAfter)
+		assert.Equal(t, entity.NewMoney(1000, enum.USD), entry.PostingFor(1).BalanceAfter)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects an unbalanced entry", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		entry := &entity.JournalEntry{
+			Postings: []*entity.LedgerPosting{
+				{FinancialAccountID: 1, Amount: entity.NewMoney(-1000, enum.USD)},
+				{FinancialAccountID: 2, Amount: entity.NewMoney(1000, enum.EUR)},
+			},
+		}
+
+		repo := &Ledger{cli: db}
+		err = repo.Post(context.Background(), entry)
+		assert.True(t, errors.Is(err, ErrUnbalancedEntry))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+)
+
+type ReviewCase struct {
+	cli *sql.DB
+}
+
+// reviewCaseColumns loads a case together with the assessment that opened it.
+const reviewCaseColumns = `
+	c.review_case_id, c.transaction_group_id, c.risk_assessment_id, c.status, c.assignee_id,
+	c.resolution_entry_id, c.created_at, c.updated_at, c.resolved_at,
+	a.financial_account_id, a.score, a.decision, a.reasons, a.created_at
+`
+
+func (repo *ReviewCase) Insert(ctx context.Context, reviewCase *entity.ReviewCase) error {
+	query := `
+		INSERT INTO public.review_case (
+			transaction_group_id, risk_assessment_id, status, assignee_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING review_case_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		reviewCase.TransactionGroupID,
+		reviewCase.RiskAssessmentID,
+		reviewCase.Status,
+		reviewCase.AssigneeID,
+	).Scan(&reviewCase.ReviewCaseID, &reviewCase.CreatedAt, &reviewCase.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.ReviewCase.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *ReviewCase) Get(ctx context.Context, caseID int) (*entity.ReviewCase, error) {
+	return repo.get(ctx, caseID, "")
+}
+
+func (repo *ReviewCase) GetForUpdate(ctx context.Context, caseID int) (*entity.ReviewCase, error) {
+	return repo.get(ctx, caseID, "FOR UPDATE OF c")
+}
+
+// GetByTransactionGroupID returns the case opened for a held transfer, or nil when the
+// transfer was never held.
+func (repo *ReviewCase) GetByTransactionGroupID(ctx context.Context, groupID int) (*entity.ReviewCase, error) {
+	query := `
+		SELECT ` + reviewCaseColumns + `
+		FROM public.review_case c
+		JOIN public.risk_assessment a ON a.risk_assessment_id = c.risk_assessment_id
+		WHERE c.transaction_group_id = $1
+	`
+
+	reviewCase, err := scanReviewCase(conn(ctx, repo.cli).QueryRowContext(ctx, query, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.ReviewCase.GetByTransactionGroupID.Scan: %w", err)
+	}
+
+	return reviewCase, nil
+}
+
+func (repo *ReviewCase) get(ctx context.Context, caseID int, lock string) (*entity.ReviewCase, error) {
+	query := `
+		SELECT ` + reviewCaseColumns + `
+		FROM public.review_case c
+		JOIN public.risk_assessment a ON a.risk_assessment_id = c.risk_assessment_id
+		WHERE c.review_case_id = $1
+	` + lock
+
+	reviewCase, err := scanReviewCase(conn(ctx, repo.cli).QueryRowContext(ctx, query, caseID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.ReviewCase.Get.Scan: %w", err)
+	}
+
+	return reviewCase, nil
+}
+
+// List returns the cases in the given status, oldest first, so the queue is worked in order.
+func (repo *ReviewCase) List(ctx context.Context, req request.ListReviewCases) ([]*entity.ReviewCase, error) {
+	query := `
+		SELECT ` + reviewCaseColumns + `
+		FROM public.review_case c
+		JOIN public.risk_assessment a ON a.risk_assessment_id = c.risk_assessment_id
+		WHERE c.status = $1
+			AND ($2 = 0 OR c.assignee_id = $2)
+		ORDER BY c.created_at, c.review_case_id
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, req.Status, req.AssigneeID, req.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ReviewCase.List.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var cases []*entity.ReviewCase
+	for rows.Next() {
+		reviewCase, err := scanReviewCase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository.ReviewCase.List.Scan: %w", err)
+		}
+		cases = append(cases, reviewCase)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.ReviewCase.List.Rows: %w", err)
+	}
+
+	return cases, nil
+}
+
+func (repo *ReviewCase) Update(ctx context.Context, reviewCase *entity.ReviewCase) error {
+	query := `
+		UPDATE public.review_case
+		SET status = $2,
+			assignee_id = $3,
+			resolution_entry_id = $4,
+			resolved_at = $5,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE review_case_id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		reviewCase.ReviewCaseID,
+		reviewCase.Status,
+		reviewCase.AssigneeID,
+		reviewCase.ResolutionEntryID,
+		reviewCase.ResolvedAt,
+	).Scan(&reviewCase.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.ReviewCase.Update.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *ReviewCase) InsertEvent(ctx context.Context, event *entity.ReviewEvent) error {
+	query := `
+		INSERT INTO public.review_event (
+			review_case_id, actor_id, action, assignee_id, note, created_at
+		) VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		RETURNING review_event_id, created_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		event.ReviewCaseID,
+		event.ActorID,
+		event.Action,
+		event.AssigneeID,
+		event.Note,
+	).Scan(&event.ReviewEventID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.ReviewCase.InsertEvent.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *ReviewCase) ListEvents(ctx context.Context, caseID int) ([]*entity.ReviewEvent, error) {
+	query := `
+		SELECT review_event_id, review_case_id, actor_id, action, assignee_id, note, created_at
+		FROM public.review_event
+		WHERE review_case_id = $1
+		ORDER BY review_event_id
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ReviewCase.ListEvents.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entity.ReviewEvent
+	for rows.Next() {
+		event := &entity.ReviewEvent{}
+		if err := rows.Scan(
+			&event.ReviewEventID,
+			&event.ReviewCaseID,
+			&event.ActorID,
+			&event.Action,
+			&event.AssigneeID,
+			&event.Note,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("repository.ReviewCase.ListEvents.Scan: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.ReviewCase.ListEvents.Rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (repo *ReviewCase) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *ReviewCase) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *ReviewCase) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReviewCase(row rowScanner) (*entity.ReviewCase, error) {
+	reviewCase := &entity.ReviewCase{}
+	assessment := &entity.RiskAssessment{}
+	var reasons []byte
+
+	err := row.Scan(
+		&reviewCase.ReviewCaseID,
+		&reviewCase.TransactionGroupID,
+		&reviewCase.RiskAssessmentID,
+		&reviewCase.Status,
+		&reviewCase.AssigneeID,
+		&reviewCase.ResolutionEntryID,
+		&reviewCase.CreatedAt,
+		&reviewCase.UpdatedAt,
+		&reviewCase.ResolvedAt,
+		&assessment.FinancialAccountID,
+		&assessment.Score,
+		&assessment.Decision,
+		&reasons,
+		&assessment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(reasons, &assessment.Reasons); err != nil {
+		return nil, err
+	}
+
+	assessment.RiskAssessmentID = reviewCase.RiskAssessmentID
+	assessment.TransactionGroupID = reviewCase.TransactionGroupID
+	reviewCase.Assessment = assessment
+
+	return reviewCase, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type RiskAssessment struct {
+	cli *sql.DB
+}
+
+func (repo *RiskAssessment) Insert(ctx context.Context, assessment *entity.RiskAssessment) error {
+	reasons, err := json.Marshal(reasonsOrEmpty(assessment.Reasons))
+	if err != nil {
+		return fmt.Errorf("repository.RiskAssessment.Insert.Marshal: %w", err)
+	}
+
+	query := `
+		INSERT INTO public.risk_assessment (
+			transaction_group_id, financial_account_id, score, decision, reasons, created_at
+		) VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		RETURNING risk_assessment_id, created_at
+	`
+
+	err = conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		assessment.TransactionGroupID,
+		assessment.FinancialAccountID,
+		assessment.Score,
+		assessment.Decision,
+		reasons,
+	).Scan(&assessment.RiskAssessmentID, &assessment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.RiskAssessment.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *RiskAssessment) GetByTransactionGroupID(ctx context.Context, groupID int) (*entity.RiskAssessment, error) {
+	query := `
+		SELECT risk_assessment_id, transaction_group_id, financial_account_id, score, decision, reasons, created_at
+		FROM public.risk_assessment
+		WHERE transaction_group_id = $1
+	`
+
+	assessment := &entity.RiskAssessment{}
+	var reasons []byte
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, groupID).Scan(
+		&assessment.RiskAssessmentID,
+		&assessment.TransactionGroupID,
+		&assessment.FinancialAccountID,
+		&assessment.Score,
+		&assessment.Decision,
+		&reasons,
+		&assessment.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.RiskAssessment.GetByTransactionGroupID.QueryRowContext: %w", err)
+	}
+
+	if err := json.Unmarshal(reasons, &assessment.Reasons); err != nil {
+		return nil, fmt.Errorf("repository.RiskAssessment.GetByTransactionGroupID.Unmarshal: %w", err)
+	}
+
+	return assessment, nil
+}
+
+// reasonsOrEmpty keeps a clean assessment stored as [] rather than null.
+func reasonsOrEmpty(reasons []entity.RiskReason) []entity.RiskReason {
+	if reasons == nil {
+		return []entity.RiskReason{}
+	}
+	return reasons
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/lib/pq"
+)
+
+type Role struct {
+	cli *sql.DB
+}
+
+func (repo *Role) List(ctx context.Context) ([]*entity.Role, error) {
+	query := `
+		SELECT r.name, r.description, COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+		FROM public.role r
+		LEFT JOIN public.role_permission p ON p.role = r.name
+		GROUP BY r.name, r.description
+		ORDER BY r.name
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Role.List.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*entity.Role
+	for rows.Next() {
+		role := &entity.Role{}
+		var permissions []string
+		if err := rows.Scan(&role.Name, &role.Description, pq.Array(&permissions)); err != nil {
+			return nil, fmt.Errorf("repository.Role.List.Scan: %w", err)
+		}
+
+		for _, permission := range permissions {
+			role.Permissions = append(role.Permissions, enum.Permission(permission))
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.Role.List.Rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (repo *Role) Exists(ctx context.Context, role enum.Role) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM public.role WHERE name = $1)`
+
+	var exists bool
+	if err := conn(ctx, repo.cli).QueryRowContext(ctx, query, role).Scan(&exists); err != nil {
+		return false, fmt.Errorf("repository.Role.Exists.Scan: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (repo *Role) ListByUserID(ctx context.Context, userID int) ([]enum.Role, error) {
+	query := `SELECT role FROM public.user_role WHERE user_id = $1 ORDER BY role`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Role.ListByUserID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []enum.Role
+	for rows.Next() {
+		var role enum.Role
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("repository.Role.ListByUserID.Scan: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.Role.ListByUserID.Rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (repo *Role) ListPermissionsByUserID(ctx context.Context, userID int) ([]enum.Permission, error) {
+	query := `
+		SELECT DISTINCT p.permission
+		FROM public.user_role u
+		JOIN public.role_permission p ON p.role = u.role
+		WHERE u.user_id = $1
+		ORDER BY p.permission
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Role.ListPermissionsByUserID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []enum.Permission
+	for rows.Next() {
+		var permission enum.Permission
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("repository.Role.ListPermissionsByUserID.Scan: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.Role.ListPermissionsByUserID.Rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (repo *Role) HasPermission(ctx context.Context, userID int, permission enum.Permission) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM public.user_role u
+			JOIN public.role_permission p ON p.role = u.role
+			WHERE u.user_id = $1 AND p.permission = $2
+		)
+	`
+
+	var has bool
+	if err := conn(ctx, repo.cli).QueryRowContext(ctx, query, userID, permission).Scan(&has); err != nil {
+		return false, fmt.Errorf("repository.Role.HasPermission.Scan: %w", err)
+	}
+
+	return has, nil
+}
+
+func (repo *Role) Assign(ctx context.Context, userID int, role enum.Role) error {
+	query := `
+		INSERT INTO public.user_role (user_id, role)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, role) DO NOTHING
+	`
+
+	if _, err := conn(ctx, repo.cli).ExecContext(ctx, query, userID, role); err != nil {
+		return fmt.Errorf("repository.Role.Assign.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *Role) Revoke(ctx context.Context, userID int, role enum.Role) (bool, error) {
+	query := `DELETE FROM public.user_role WHERE user_id = $1 AND role = $2`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return false, fmt.Errorf("repository.Role.Revoke.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.Role.Revoke.RowsAffected: %w", err)
+	}
+
+	return affected > 0, nil
+}
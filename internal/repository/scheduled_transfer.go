@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type ScheduledTransfer struct {
+	cli *sql.DB
+}
+
+const scheduledTransferColumns = `
+	scheduled_transfer_id, user_id, sender_account_id, receiver_account_id, currency_code, amount,
+	description, frequency, cron_expression, timezone, start_at, end_at, next_run_at, retry_at,
+	attempts, status, last_run_at, locked_until, created_at, updated_at
+`
+
+func (repo *ScheduledTransfer) Insert(ctx context.Context, transfer *entity.ScheduledTransfer) error {
+	query := `
+		INSERT INTO public.scheduled_transfer (
+			user_id, sender_account_id, receiver_account_id, currency_code, amount, description,
+			frequency, cron_expression, timezone, start_at, end_at, next_run_at, status,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING scheduled_transfer_id, created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		transfer.UserID,
+		transfer.SenderAccountID,
+		transfer.ReceiverAccountID,
+		transfer.Amount.Currency,
+		transfer.Amount,
+		transfer.Description,
+		transfer.Frequency,
+		transfer.CronExpression,
+		transfer.Timezone,
+		transfer.StartAt,
+		transfer.EndAt,
+		transfer.NextRunAt,
+		transfer.Status,
+	).Scan(&transfer.ScheduledTransferID, &transfer.CreatedAt, &transfer.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.ScheduledTransfer.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *ScheduledTransfer) Get(ctx context.Context, scheduledTransferID int) (*entity.ScheduledTransfer, error) {
+	return repo.get(ctx, scheduledTransferID, "")
+}
+
+func (repo *ScheduledTransfer) GetForUpdate(ctx context.Context, scheduledTransferID int) (*entity.ScheduledTransfer, error) {
+	return repo.get(ctx, scheduledTransferID, "FOR UPDATE")
+}
+
+func (repo *ScheduledTransfer) get(ctx context.Context, scheduledTransferID int, lock string) (*entity.ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduledTransferColumns + `
+		FROM public.scheduled_transfer
+		WHERE scheduled_transfer_id = $1
+	` + lock
+
+	transfer, err := scanScheduledTransfer(conn(ctx, repo.cli).QueryRowContext(ctx, query, scheduledTransferID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.ScheduledTransfer.Get.Scan: %w", err)
+	}
+
+	return transfer, nil
+}
+
+func (repo *ScheduledTransfer) ListByUserID(ctx context.Context, userID int) ([]*entity.ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduledTransferColumns + `
+		FROM public.scheduled_transfer
+		WHERE user_id = $1
+		ORDER BY scheduled_transfer_id
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ScheduledTransfer.ListByUserID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	transfers, err := scanScheduledTransfers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ScheduledTransfer.ListByUserID.%w", err)
+	}
+
+	return transfers, nil
+}
+
+func (repo *ScheduledTransfer) Update(ctx context.Context, transfer *entity.ScheduledTransfer) error {
+	query := `
+		UPDATE public.scheduled_transfer
+		SET next_run_at = $2,
+			retry_at = $3,
+			attempts = $4,
+			status = $5,
+			last_run_at = $6,
+			locked_until = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE scheduled_transfer_id = $1
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		transfer.ScheduledTransferID,
+		transfer.NextRunAt,
+		transfer.RetryAt,
+		transfer.Attempts,
+		transfer.Status,
+		transfer.LastRunAt,
+		transfer.LockedUntil,
+	).Scan(&transfer.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.ScheduledTransfer.Update.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue takes the lease in a single statement; SKIP LOCKED keeps executors that poll at
+// the same time from waiting on each other's rows.
+func (repo *ScheduledTransfer) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.ScheduledTransfer, error) {
+	query := `
+		UPDATE public.scheduled_transfer
+		SET locked_until = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE scheduled_transfer_id IN (
+			SELECT scheduled_transfer_id
+			FROM public.scheduled_transfer
+			WHERE status = $3
+				AND COALESCE(retry_at, next_run_at) <= $1
+				AND (locked_until IS NULL OR locked_until < $1)
+			ORDER BY COALESCE(retry_at, next_run_at)
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledTransferColumns
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, now, leaseUntil, enum.ScheduledActive, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ScheduledTransfer.ClaimDue.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	transfers, err := scanScheduledTransfers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ScheduledTransfer.ClaimDue.%w", err)
+	}
+
+	return transfers, nil
+}
+
+func (repo *ScheduledTransfer) InsertRun(ctx context.Context, run *entity.ScheduledTransferRun) error {
+	query := `
+		INSERT INTO public.scheduled_transfer_run (
+			scheduled_transfer_id, occurrence_at, attempt, status, transaction_group_id, error,
+			started_at, finished_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING scheduled_transfer_run_id
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		run.ScheduledTransferID,
+		run.OccurrenceAt,
+		run.Attempt,
+		run.Status,
+		run.TransactionGroupID,
+		run.Error,
+		run.StartedAt,
+		run.FinishedAt,
+	).Scan(&run.ScheduledTransferRunID)
+	if err != nil {
+		return fmt.Errorf("repository.ScheduledTransfer.InsertRun.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *ScheduledTransfer) ListRuns(ctx context.Context, scheduledTransferID int) ([]*entity.ScheduledTransferRun, error) {
+	query := `
+		SELECT scheduled_transfer_run_id, scheduled_transfer_id, occurrence_at, attempt, status,
+			transaction_group_id, error, started_at, finished_at
+		FROM public.scheduled_transfer_run
+		WHERE scheduled_transfer_id = $1
+		ORDER BY scheduled_transfer_run_id
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, scheduledTransferID)
+	if err != nil {
+		return nil, fmt.Errorf("repository.ScheduledTransfer.ListRuns.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*entity.ScheduledTransferRun
+	for rows.Next() {
+		run := &entity.ScheduledTransferRun{}
+		if err := rows.Scan(
+			&run.ScheduledTransferRunID,
+			&run.ScheduledTransferID,
+			&run.OccurrenceAt,
+			&run.Attempt,
+			&run.Status,
+			&run.TransactionGroupID,
+			&run.Error,
+			&run.StartedAt,
+			&run.FinishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("repository.ScheduledTransfer.ListRuns.Scan: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.ScheduledTransfer.ListRuns.Rows: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (repo *ScheduledTransfer) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *ScheduledTransfer) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *ScheduledTransfer) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+func scanScheduledTransfers(rows *sql.Rows) ([]*entity.ScheduledTransfer, error) {
+	var transfers []*entity.ScheduledTransfer
+	for rows.Next() {
+		transfer, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Rows: %w", err)
+	}
+
+	return transfers, nil
+}
+
+func scanScheduledTransfer(row rowScanner) (*entity.ScheduledTransfer, error) {
+	transfer := &entity.ScheduledTransfer{}
+	err := row.Scan(
+		&transfer.ScheduledTransferID,
+		&transfer.UserID,
+		&transfer.SenderAccountID,
+		&transfer.ReceiverAccountID,
+		&transfer.Amount.Currency,
+		&transfer.Amount,
+		&transfer.Description,
+		&transfer.Frequency,
+		&transfer.CronExpression,
+		&transfer.Timezone,
+		&transfer.StartAt,
+		&transfer.EndAt,
+		&transfer.NextRunAt,
+		&transfer.RetryAt,
+		&transfer.Attempts,
+		&transfer.Status,
+		&transfer.LastRunAt,
+		&transfer.LockedUntil,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
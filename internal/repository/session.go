@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type Session struct {
+	cli *sql.DB
+}
+
+const sessionColumns = `
+	session_id, user_id, device, ip_address, user_agent, access_jti, access_expires_at,
+	created_at, last_used_at, expires_at, revoked_at, revoke_reason
+`
+
+func (repo *Session) Insert(ctx context.Context, session *entity.Session) error {
+	query := `
+		INSERT INTO public.user_session (
+			user_id, device, ip_address, user_agent, access_jti, access_expires_at,
+			created_at, last_used_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $7)
+		RETURNING session_id, created_at, last_used_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		session.UserID,
+		session.Device,
+		session.IPAddress,
+		session.UserAgent,
+		session.AccessTokenID,
+		session.AccessExpiresAt,
+		session.ExpiresAt,
+	).Scan(&session.SessionID, &session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("repository.Session.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *Session) Update(ctx context.Context, session *entity.Session) error {
+	query := `
+		UPDATE public.user_session
+		SET ip_address = $1, user_agent = $2, access_jti = $3, access_expires_at = $4,
+			last_used_at = $5, expires_at = $6, revoked_at = $7, revoke_reason = $8
+		WHERE session_id = $9
+	`
+
+	_, err := conn(ctx, repo.cli).ExecContext(ctx, query,
+		session.IPAddress,
+		session.UserAgent,
+		session.AccessTokenID,
+		session.AccessExpiresAt,
+		session.LastUsedAt,
+		session.ExpiresAt,
+		session.RevokedAt,
+		session.RevokeReason,
+		session.SessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("repository.Session.Update.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *Session) GetForUpdate(ctx context.Context, sessionID int) (*entity.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM public.user_session
+		WHERE session_id = $1
+		FOR UPDATE
+	`
+
+	session, err := scanSession(conn(ctx, repo.cli).QueryRowContext(ctx, query, sessionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.Session.GetForUpdate.Scan: %w", err)
+	}
+
+	return session, nil
+}
+
+func (repo *Session) ListActiveByUserID(ctx context.Context, userID int, now time.Time) ([]*entity.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM public.user_session
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := conn(ctx, repo.cli).QueryContext(ctx, query, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("repository.Session.ListActiveByUserID.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*entity.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository.Session.ListActiveByUserID.Scan: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository.Session.ListActiveByUserID.Rows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (repo *Session) InsertRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	query := `
+		INSERT INTO public.refresh_token (token_hash, session_id, created_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		RETURNING created_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, token.TokenHash, token.SessionID).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.Session.InsertRefreshToken.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *Session) GetRefreshTokenForUpdate(ctx context.Context, tokenHash []byte) (*entity.RefreshToken, error) {
+	query := `
+		SELECT token_hash, session_id, created_at, used_at
+		FROM public.refresh_token
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	token := &entity.RefreshToken{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.TokenHash,
+		&token.SessionID,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.Session.GetRefreshTokenForUpdate.Scan: %w", err)
+	}
+
+	return token, nil
+}
+
+func (repo *Session) SpendRefreshToken(ctx context.Context, tokenHash []byte, usedAt time.Time) error {
+	query := `UPDATE public.refresh_token SET used_at = $1 WHERE token_hash = $2`
+
+	if _, err := conn(ctx, repo.cli).ExecContext(ctx, query, usedAt, tokenHash); err != nil {
+		return fmt.Errorf("repository.Session.SpendRefreshToken.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *Session) DenyToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO public.revoked_token (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	if _, err := conn(ctx, repo.cli).ExecContext(ctx, query, jti, expiresAt); err != nil {
+		return fmt.Errorf("repository.Session.DenyToken.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *Session) IsTokenDenied(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM public.revoked_token WHERE jti = $1)`
+
+	var denied bool
+	if err := conn(ctx, repo.cli).QueryRowContext(ctx, query, jti).Scan(&denied); err != nil {
+		return false, fmt.Errorf("repository.Session.IsTokenDenied.Scan: %w", err)
+	}
+
+	return denied, nil
+}
+
+func (repo *Session) PurgeDeniedTokens(ctx context.Context, now time.Time) (int, error) {
+	query := `DELETE FROM public.revoked_token WHERE expires_at < $1`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("repository.Session.PurgeDeniedTokens.ExecContext: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("repository.Session.PurgeDeniedTokens.RowsAffected: %w", err)
+	}
+
+	return int(purged), nil
+}
+
+func (repo *Session) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *Session) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *Session) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
+
+func scanSession(row rowScanner) (*entity.Session, error) {
+	session := &entity.Session{}
+	err := row.Scan(
+		&session.SessionID,
+		&session.UserID,
+		&session.Device,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.AccessTokenID,
+		&session.AccessExpiresAt,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.RevokeReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
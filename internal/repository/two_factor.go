@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+)
+
+type TwoFactor struct {
+	cli *sql.DB
+}
+
+func (repo *TwoFactor) Get(ctx context.Context, userID int) (*entity.TwoFactor, error) {
+	query := `
+		SELECT user_id, sealed_secret, enabled_at, last_used_step, created_at, updated_at
+		FROM public.two_factor
+		WHERE user_id = $1
+	`
+
+	twoFactor := &entity.TwoFactor{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.SealedSecret,
+		&twoFactor.EnabledAt,
+		&twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt,
+		&twoFactor.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.TwoFactor.Get.Scan: %w", err)
+	}
+
+	return twoFactor, nil
+}
+
+func (repo *TwoFactor) Upsert(ctx context.Context, twoFactor *entity.TwoFactor) error {
+	query := `
+		INSERT INTO public.two_factor (user_id, sealed_secret, enabled_at, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, NULL, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			sealed_secret = EXCLUDED.sealed_secret,
+			enabled_at = NULL,
+			last_used_step = 0,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, twoFactor.UserID, twoFactor.SealedSecret).
+		Scan(&twoFactor.CreatedAt, &twoFactor.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.TwoFactor.Upsert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *TwoFactor) Enable(ctx context.Context, userID int, enabledAt time.Time) error {
+	query := `UPDATE public.two_factor SET enabled_at = $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2`
+
+	if _, err := conn(ctx, repo.cli).ExecContext(ctx, query, enabledAt, userID); err != nil {
+		return fmt.Errorf("repository.TwoFactor.Enable.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *TwoFactor) Delete(ctx context.Context, userID int) error {
+	query := `DELETE FROM public.two_factor WHERE user_id = $1`
+
+	if _, err := conn(ctx, repo.cli).ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("repository.TwoFactor.Delete.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *TwoFactor) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	// The condition makes the check and the write one statement, so two requests racing
+	// with the same code cannot both get it accepted.
+	query := `
+		UPDATE public.two_factor
+		SET last_used_step = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2 AND last_used_step < $1
+	`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("repository.TwoFactor.UseStep.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.TwoFactor.UseStep.RowsAffected: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *TwoFactor) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes [][]byte) error {
+	return runInTx(ctx, repo.cli, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM public.two_factor_recovery_code WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("repository.TwoFactor.ReplaceRecoveryCodes.Delete: %w", err)
+		}
+
+		for _, codeHash := range codeHashes {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO public.two_factor_recovery_code (user_id, code_hash) VALUES ($1, $2)
+			`, userID, codeHash)
+			if err != nil {
+				return fmt.Errorf("repository.TwoFactor.ReplaceRecoveryCodes.Insert: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repo *TwoFactor) UseRecoveryCode(ctx context.Context, userID int, codeHash []byte, usedAt time.Time) (bool, error) {
+	query := `
+		UPDATE public.two_factor_recovery_code
+		SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`
+
+	result, err := conn(ctx, repo.cli).ExecContext(ctx, query, usedAt, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("repository.TwoFactor.UseRecoveryCode.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.TwoFactor.UseRecoveryCode.RowsAffected: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *TwoFactor) RecordFailedAttempt(ctx context.Context, userID int) error {
+	_, err := conn(ctx, repo.cli).ExecContext(ctx, `
+		INSERT INTO public.two_factor_failed_attempt (user_id, created_at) VALUES ($1, CURRENT_TIMESTAMP)
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("repository.TwoFactor.RecordFailedAttempt.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *TwoFactor) CountFailedAttempts(ctx context.Context, userID int, since time.Time) (int, error) {
+	var count int
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM public.two_factor_failed_attempt WHERE user_id = $1 AND created_at >= $2
+	`, userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("repository.TwoFactor.CountFailedAttempts.QueryRowContext: %w", err)
+	}
+
+	return count, nil
+}
+
+func (repo *TwoFactor) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *TwoFactor) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *TwoFactor) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNoTx is returned by CommitTx and RollbackTx when the context does not carry a transaction.
+var ErrNoTx = errors.New("no transaction in context")
+
+type txKey struct{}
+
+// txScope is the value stored in the context by BeginTx. A nested scope shares the
+// outer *sql.Tx but does not own it, so only the outermost scope commits or rolls back.
+type txScope struct {
+	tx     *sql.Tx
+	nested bool
+}
+
+// executor is the subset of *sql.DB and *sql.Tx used by the repositories.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scopeFromContext(ctx context.Context) *txScope {
+	scope, _ := ctx.Value(txKey{}).(*txScope)
+	return scope
+}
+
+// conn returns the transaction carried by ctx, or db when there is none,
+// so that every repository joins the unit of work started by the service.
+func conn(ctx context.Context, db *sql.DB) executor {
+	if scope := scopeFromContext(ctx); scope != nil {
+		return scope.tx
+	}
+	return db
+}
+
+// runInTx runs fn inside the transaction carried by ctx. When ctx carries none,
+// a local transaction is opened around fn and committed or rolled back here.
+func runInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if scope := scopeFromContext(ctx); scope != nil {
+		return fn(scope.tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTx: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+
+	return nil
+}
+
+func beginTx(ctx context.Context, db *sql.DB) (context.Context, error) {
+	if scope := scopeFromContext(ctx); scope != nil {
+		return context.WithValue(ctx, txKey{}, &txScope{tx: scope.tx, nested: true}), nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctx, fmt.Errorf("BeginTx: %w", err)
+	}
+
+	return context.WithValue(ctx, txKey{}, &txScope{tx: tx}), nil
+}
+
+func commitTx(ctx context.Context) error {
+	scope := scopeFromContext(ctx)
+	if scope == nil {
+		return ErrNoTx
+	}
+	if scope.nested {
+		return nil
+	}
+
+	if err := scope.tx.Commit(); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+
+	return nil
+}
+
+func rollbackTx(ctx context.Context) error {
+	scope := scopeFromContext(ctx)
+	if scope == nil {
+		return ErrNoTx
+	}
+	if scope.nested {
+		return nil
+	}
+
+	if err := scope.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("Rollback: %w", err)
+	}
+
+	return nil
+}
+
+// Transactor is a standalone unit of work for services that need to span
+// several repositories without owning one that exposes BeginTx itself.
+type Transactor struct {
+	cli *sql.DB
+}
+
+func (t *Transactor) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, t.cli)
+}
+
+func (t *Transactor) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (t *Transactor) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
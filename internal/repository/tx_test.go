@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactor_JoinsContextTx(t *testing.T) {
+	tests := []struct {
+		name   string
+		commit bool
+	}{
+		{name: "commit", commit: true},
+		{name: "rollback", commit: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectPrepare("UPDATE bank").ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectPrepare("UPDATE bank").ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+			if tt.commit {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			transactor := &Transactor{cli: db}
+			bankRepo := &Bank{cli: db}
+
+			ctx, err := transactor.BeginTx(context.Background())
+			require.NoError(t, err)
+
+			require.NoError(t, bankRepo.Delete(ctx, 1))
+			require.NoError(t, bankRepo.Delete(ctx, 2))
+
+			if tt.commit {
+				assert.NoError(t, transactor.CommitTx(ctx))
+			} else {
+				assert.NoError(t, transactor.RollbackTx(ctx))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTransactor_NestedScopeDoesNotCommit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	transactor := &Transactor{cli: db}
+
+	outer, err := transactor.BeginTx(context.Background())
+	require.NoError(t, err)
+
+	inner, err := transactor.BeginTx(outer)
+	require.NoError(t, err)
+
+	assert.NoError(t, transactor.CommitTx(inner))
+	assert.NoError(t, transactor.RollbackTx(inner))
+	assert.NoError(t, transactor.CommitTx(outer))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactor_WithoutTx(t *testing.T) {
+	transactor := &Transactor{}
+
+	assert.True(t, errors.Is(transactor.CommitTx(context.Background()), ErrNoTx))
+	assert.True(t, errors.Is(transactor.RollbackTx(context.Background()), ErrNoTx))
+}
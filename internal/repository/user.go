@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 )
 
@@ -25,7 +26,7 @@ func (repo *User) Get(ctx context.Context, id int) (entity.User, error) {
 		id = $1 AND deleted_at IS NULL
    `
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return entity.User{}, fmt.Errorf("repository.User.Get.PrepareContext: %w", err)
 	}
@@ -70,7 +71,7 @@ func (repo *User) GetByUsername(ctx context.Context, username string) (entity.Us
 			username = $1 AND deleted_at IS NULL
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return entity.User{}, fmt.Errorf("repository.User.GetByUsername.PrepareContext: %w", err)
 	}
@@ -107,7 +108,7 @@ func (repo *User) Insert(ctx context.Context, user entity.User) error {
 			)
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.User.Insert.PrepareContext: %w", err)
 	}
@@ -141,13 +142,13 @@ func (repo *User) Update(ctx context.Context, user entity.User) error {
 		id = $9
 	`
 
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("repository.User.Update.PrepareContext: %w", err)
 	}
 
 	res, err := stmt.ExecContext(ctx, user.Password, user.FirstName, user.LastName,
-		user.Email, user.ValidatedEmail, user.Cellphone, user.ValidatedCellphone, user.ID)
+		user.Email, user.ValidatedEmail, user.Cellphone, user.ValidatedCellphone, user.Status, user.ID)
 	if err != nil {
 		return fmt.Errorf("repository.User.Update.PrepareContext: %w", err)
 	}
@@ -164,6 +165,32 @@ func (repo *User) Update(ctx context.Context, user entity.User) error {
 	return nil
 }
 
+// MarkContactVerified sets the verified flag of the channel, but only while destination is
+// still the user's address on it.
+func (repo *User) MarkContactVerified(ctx context.Context, userID int, channel enum.ContactChannel, destination string) (bool, error) {
+	var query string
+	switch channel {
+	case enum.ContactEmail:
+		query = `UPDATE users SET validated_email = TRUE, updated_at = NOW() WHERE id = $1 AND email = $2 AND deleted_at IS NULL`
+	case enum.ContactCellphone:
+		query = `UPDATE users SET validated_cellphone = TRUE, updated_at = NOW() WHERE id = $1 AND cellphone = $2 AND deleted_at IS NULL`
+	default:
+		return false, fmt.Errorf("repository.User.MarkContactVerified: unknown channel %q", channel)
+	}
+
+	res, err := conn(ctx, repo.cli).ExecContext(ctx, query, userID, destination)
+	if err != nil {
+		return false, fmt.Errorf("repository.User.MarkContactVerified.ExecContext: %w", err)
+	}
+
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository.User.MarkContactVerified.RowsAffected: %w", err)
+	}
+
+	return ra > 0, nil
+}
+
 func (repo *User) IsUsernameExist(ctx context.Context, username string) (bool, error) {
 	query := `
 	SELECT
@@ -173,7 +200,7 @@ func (repo *User) IsUsernameExist(ctx context.Context, username string) (bool, e
 	WHERE
 		username = $1 AND deleted_at IS NULL
 `
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return false, fmt.Errorf("repository.User.IsUsernameExist.PrepareContext: %w", err)
 	}
@@ -201,7 +228,7 @@ func (repo *User) IsExist(ctx context.Context, id int) (bool, error) {
 	WHERE
 		id = $1 AND deleted_at IS NULL
 `
-	stmt, err := repo.cli.PrepareContext(ctx, query)
+	stmt, err := conn(ctx, repo.cli).PrepareContext(ctx, query)
 	if err != nil {
 		return false, fmt.Errorf("repository.User.IsExist.PrepareContext: %w", err)
 	}
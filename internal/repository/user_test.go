@@ -431,7 +431,7 @@ func TestUser_Update(t *testing.T) {
 			name: "successful update",
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectPrepare("^UPDATE users SET").ExpectExec().WithArgs(
-					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1,
+					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1, 1,
 				).WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			wantErr: false,
@@ -452,7 +452,7 @@ func TestUser_Update(t *testing.T) {
 			name: "no rows affected",
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectPrepare("^UPDATE users SET").ExpectExec().WithArgs(
-					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1,
+					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1, 1,
 				).WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 			wantErr: true,
@@ -473,7 +473,7 @@ func TestUser_Update(t *testing.T) {
 			name: "rows affected error",
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectPrepare("^UPDATE users SET").ExpectExec().WithArgs(
-					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1,
+					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1, 1,
 				).WillReturnResult(sqlmock.NewErrorResult(errors.New("rows affected error")))
 			},
 			wantErr: true,
@@ -513,7 +513,7 @@ func TestUser_Update(t *testing.T) {
 			name: "exec context error",
 			mock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectPrepare("^UPDATE users SET").ExpectExec().WithArgs(
-					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1,
+					"testpass", "test", "user", "test@user.com", true, "1234567890", true, 1, 1,
 				).WillReturnError(errors.New("exec context error"))
 			},
 			wantErr: true,
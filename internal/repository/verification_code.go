@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+type VerificationCode struct {
+	cli *sql.DB
+}
+
+const verificationCodeColumns = `
+	verification_code_id, user_id, channel, destination, code_hash, attempts,
+	expires_at, used_at, created_at
+`
+
+func (repo *VerificationCode) Insert(ctx context.Context, code *entity.VerificationCode) error {
+	query := `
+		INSERT INTO public.verification_code (user_id, channel, destination, code_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		RETURNING verification_code_id, created_at
+	`
+
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query,
+		code.UserID,
+		code.Channel,
+		code.Destination,
+		code.CodeHash,
+		code.ExpiresAt,
+	).Scan(&code.VerificationCodeID, &code.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("repository.VerificationCode.Insert.QueryRowContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *VerificationCode) Update(ctx context.Context, code *entity.VerificationCode) error {
+	query := `
+		UPDATE public.verification_code
+		SET attempts = $1, used_at = $2
+		WHERE verification_code_id = $3
+	`
+
+	_, err := conn(ctx, repo.cli).ExecContext(ctx, query, code.Attempts, code.UsedAt, code.VerificationCodeID)
+	if err != nil {
+		return fmt.Errorf("repository.VerificationCode.Update.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *VerificationCode) GetLatest(ctx context.Context, userID int, channel enum.ContactChannel) (*entity.VerificationCode, error) {
+	return repo.getLatest(ctx, userID, channel, "")
+}
+
+func (repo *VerificationCode) GetLatestForUpdate(ctx context.Context, userID int, channel enum.ContactChannel) (*entity.VerificationCode, error) {
+	return repo.getLatest(ctx, userID, channel, "FOR UPDATE")
+}
+
+func (repo *VerificationCode) getLatest(ctx context.Context, userID int, channel enum.ContactChannel, lock string) (*entity.VerificationCode, error) {
+	query := `
+		SELECT ` + verificationCodeColumns + `
+		FROM public.verification_code
+		WHERE user_id = $1 AND channel = $2
+		ORDER BY created_at DESC, verification_code_id DESC
+		LIMIT 1
+		` + lock
+
+	code := &entity.VerificationCode{}
+	err := conn(ctx, repo.cli).QueryRowContext(ctx, query, userID, channel).Scan(
+		&code.VerificationCodeID,
+		&code.UserID,
+		&code.Channel,
+		&code.Destination,
+		&code.CodeHash,
+		&code.Attempts,
+		&code.ExpiresAt,
+		&code.UsedAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("repository.VerificationCode.GetLatest.Scan: %w", err)
+	}
+
+	return code, nil
+}
+
+func (repo *VerificationCode) CountSince(ctx context.Context, userID int, channel enum.ContactChannel, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM public.verification_code
+		WHERE user_id = $1 AND channel = $2 AND created_at >= $3
+	`
+
+	var count int
+	if err := conn(ctx, repo.cli).QueryRowContext(ctx, query, userID, channel, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("repository.VerificationCode.CountSince.Scan: %w", err)
+	}
+
+	return count, nil
+}
+
+func (repo *VerificationCode) BeginTx(ctx context.Context) (context.Context, error) {
+	return beginTx(ctx, repo.cli)
+}
+
+func (repo *VerificationCode) CommitTx(ctx context.Context) error {
+	return commitTx(ctx)
+}
+
+func (repo *VerificationCode) RollbackTx(ctx context.Context) error {
+	return rollbackTx(ctx)
+}
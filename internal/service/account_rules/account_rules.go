@@ -0,0 +1,91 @@
+package accountrules
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) GetRules(ctx context.Context, accountID int) (*entity.AccountRules, error) {
+	if accountID <= 0 {
+		return nil, derror.NewBadRequestError("Invalid account ID")
+	}
+
+	rules, err := s.accountRulesRepo.GetByAccountID(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get account rules", zap.Error(err), zap.Int("accountID", accountID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if rules == nil {
+		return nil, derror.NewNotFoundError("No rules are set for account %d", accountID)
+	}
+
+	return rules, nil
+}
+
+// UpdateRules replaces the rules of an account, creating them if the account has none.
+func (s *Service) UpdateRules(ctx context.Context, req request.UpdateAccountRules) (*entity.AccountRules, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid account rules", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	currency, err := s.financialAccountService.GetAccountCurrency(ctx, req.FinancialAccountID)
+	if err != nil {
+		s.logger.Error("Failed to get the account currency", zap.Error(err), zap.Int("accountID", req.FinancialAccountID))
+		return nil, err
+	}
+
+	code := currency.CurrencyCode
+	amounts := []*entity.Money{&req.MinAmount, &req.MaxAmount, &req.NewAccountMaxAmount, &req.MinBalanceRequired, &req.Require2FAForAmount}
+	for _, amount := range amounts {
+		// Omitted amounts decode without a currency and disable their rule.
+		if amount.IsZero() {
+			*amount = entity.NewMoney(0, code)
+		}
+		if amount.Currency != code {
+			return nil, derror.NewBadRequestError("rule amounts must be in the account currency %s", code)
+		}
+	}
+
+	level := req.VerificationLevel
+	if level == "" {
+		level = enum.VerificationMinimal
+	}
+
+	rules := &entity.AccountRules{
+		FinancialAccountID:                req.FinancialAccountID,
+		DailyLimit:                        req.DailyLimit,
+		WeeklyLimit:                       req.WeeklyLimit,
+		MonthlyLimit:                      req.MonthlyLimit,
+		MinAmount:                         req.MinAmount,
+		MaxAmount:                         req.MaxAmount,
+		MaxFailedTransactionsDaily:        req.MaxFailedTransactionsDaily,
+		NewAccountMaxAmount:               req.NewAccountMaxAmount,
+		MinBalanceRequired:                req.MinBalanceRequired,
+		AllowedCountries:                  req.AllowedCountries,
+		DisallowedCountries:               req.DisallowedCountries,
+		NotifyOnNewLocation:               req.NotifyOnNewLocation,
+		BlockOnUnusualLocation:            req.BlockOnUnusualLocation,
+		AllowedRegions:                    req.AllowedRegions,
+		AllowedCities:                     req.AllowedCities,
+		Require2FAForAmount:               req.Require2FAForAmount,
+		VerificationLevel:                 level,
+		PasswordChangeCoolingPeriod:       req.PasswordChangeCoolingPeriod,
+		NewTransactionMethodCoolingPeriod: req.NewTransactionMethodCoolingPeriod,
+	}
+
+	if err := s.accountRulesRepo.Upsert(ctx, rules); err != nil {
+		s.logger.Error("Failed to save account rules", zap.Error(err), zap.Int("accountID", req.FinancialAccountID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	s.logger.Info("Updated account rules", zap.Int("accountID", req.FinancialAccountID))
+
+	return rules, nil
+}
@@ -0,0 +1,143 @@
+package accountrules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const defaultNewAccountPeriod = 30 * 24 * time.Hour
+
+// Evaluate checks a debit against the rules of its account. An account without rules
+// has no limits. All broken rules are reported, not only the first one.
+func (s *Service) Evaluate(ctx context.Context, req request.EvaluatePolicy) ([]entity.PolicyViolation, error) {
+	rules, err := s.accountRulesRepo.GetByAccountID(ctx, req.FinancialAccountID)
+	if err != nil {
+		s.logger.Error("Failed to get account rules", zap.Error(err), zap.Int("accountID", req.FinancialAccountID))
+		return nil, err
+	}
+
+	if rules == nil {
+		return nil, nil
+	}
+
+	if req.Amount.Currency != rules.MinAmount.Currency {
+		return nil, derror.NewBadRequestError("the amount must be in the account currency %s", rules.MinAmount.Currency)
+	}
+
+	var violations []entity.PolicyViolation
+	violate := func(rule enum.PolicyRule, format string, args ...any) {
+		violations = append(violations, entity.PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	amount := req.Amount.Amount
+
+	if !rules.MinAmount.IsZero() && amount < rules.MinAmount.Amount {
+		violate(enum.PolicyMinAmount, "amount %s is below the minimum %s", display(req.Amount), display(rules.MinAmount))
+	}
+
+	if !rules.MaxAmount.IsZero() && amount > rules.MaxAmount.Amount {
+		violate(enum.PolicyMaxAmount, "amount %s exceeds the maximum %s", display(req.Amount), display(rules.MaxAmount))
+	}
+
+	if !rules.NewAccountMaxAmount.IsZero() && amount > rules.NewAccountMaxAmount.Amount {
+		isNew, err := s.isNewAccount(ctx, req.FinancialAccountID)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			violate(enum.PolicyNewAccountMaxAmount, "amount exceeds new-account cap %s", display(rules.NewAccountMaxAmount))
+		}
+	}
+
+	if req.Balance.Amount-amount < rules.MinBalanceRequired.Amount {
+		violate(enum.PolicyMinBalanceRequired, "balance would fall below the required minimum %s", display(rules.MinBalanceRequired))
+	}
+
+	if !rules.Require2FAForAmount.IsZero() && amount > rules.Require2FAForAmount.Amount && !req.SecondFactorVerified && !req.TwoFactorExempt {
+		violate(enum.PolicyRequire2FA, "two-factor authentication is required for amounts above %s", display(rules.Require2FAForAmount))
+	}
+
+	if !enum.VerificationMinimal.Covers(rules.VerificationLevel) {
+		level, err := s.ownerVerificationLevel(ctx, req.FinancialAccountID)
+		if err != nil {
+			return nil, err
+		}
+		if !level.Covers(rules.VerificationLevel) {
+			violate(enum.PolicyVerificationLevel, "the account needs %s verification of its owner, who has %s", rules.VerificationLevel, level)
+		}
+	}
+
+	now := time.Now()
+	windows := []struct {
+		rule  enum.PolicyRule
+		name  string
+		limit int
+		since time.Time
+	}{
+		{rule: enum.PolicyDailyLimit, name: "daily", limit: rules.DailyLimit, since: now.AddDate(0, 0, -1)},
+		{rule: enum.PolicyWeeklyLimit, name: "weekly", limit: rules.WeeklyLimit, since: now.AddDate(0, 0, -7)},
+		{rule: enum.PolicyMonthlyLimit, name: "monthly", limit: rules.MonthlyLimit, since: now.AddDate(0, -1, 0)},
+	}
+
+	for _, window := range windows {
+		if window.limit <= 0 {
+			continue
+		}
+
+		count, err := s.accountTransactionRepo.CountDebitsSince(ctx, req.FinancialAccountID, window.since)
+		if err != nil {
+			s.logger.Error("Failed to count debits", zap.Error(err), zap.Int("accountID", req.FinancialAccountID))
+			return nil, err
+		}
+
+		if count >= window.limit {
+			violate(window.rule, "%s limit %d reached", window.name, window.limit)
+		}
+	}
+
+	return violations, nil
+}
+
+func (s *Service) isNewAccount(ctx context.Context, accountID int) (bool, error) {
+	account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get the account", zap.Error(err), zap.Int("accountID", accountID))
+		return false, err
+	}
+
+	period := s.cfg.NewAccountPeriod
+	if period <= 0 {
+		period = defaultNewAccountPeriod
+	}
+
+	return time.Since(account.CreatedAt) < period, nil
+}
+
+// ownerVerificationLevel is how far the owner of the account has verified their email
+// address and cellphone.
+func (s *Service) ownerVerificationLevel(ctx context.Context, accountID int) (enum.VerificationLevel, error) {
+	account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to get the account", zap.Error(err), zap.Int("accountID", accountID))
+		return "", err
+	}
+
+	owner, err := s.userService.Get(ctx, account.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get the account owner", zap.Error(err), zap.Int("userID", account.UserID))
+		return "", err
+	}
+
+	return owner.VerificationLevel(), nil
+}
+
+func display(m entity.Money) string {
+	return m.String() + " " + string(m.Currency)
+}
@@ -0,0 +1,172 @@
+package accountrules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setup() (*Service, *protocol.MockAccountRulesRepo, *protocol.MockAccountTransactionRepo, *protocol.MockFinancialAccountService) {
+	mockRulesRepo := new(protocol.MockAccountRulesRepo)
+	mockTransactionRepo := new(protocol.MockAccountTransactionRepo)
+	mockAccountService := new(protocol.MockFinancialAccountService)
+	logger, _ := zap.NewProduction()
+
+	service := New(config.AccountRules{NewAccountPeriod: 30 * 24 * time.Hour}, logger.Sugar(), mockRulesRepo, mockTransactionRepo, mockAccountService, new(protocol.MockUserRepository))
+	return service, mockRulesRepo, mockTransactionRepo, mockAccountService
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+func rules() *entity.AccountRules {
+	return &entity.AccountRules{
+		FinancialAccountID:  1,
+		DailyLimit:          10,
+		WeeklyLimit:         50,
+		MonthlyLimit:        200,
+		MinAmount:           usd(100),
+		MaxAmount:           usd(100000),
+		NewAccountMaxAmount: usd(50000),
+		MinBalanceRequired:  usd(1000),
+		Require2FAForAmount: usd(50000),
+	}
+}
+
+func rulesOf(violations []entity.PolicyViolation) []enum.PolicyRule {
+	var out []enum.PolicyRule
+	for _, v := range violations {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("allows a debit within every rule", func(t *testing.T) {
+		service, mockRulesRepo, mockTransactionRepo, _ := setup()
+		mockRulesRepo.On("GetByAccountID", ctx, 1).Return(rules(), nil)
+		mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.AnythingOfType("time.Time")).Return(3, nil)
+
+		violations, err := service.Evaluate(ctx, request.EvaluatePolicy{FinancialAccountID: 1, Amount: usd(5000), Balance: usd(20000)})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("reports every broken rule", func(t *testing.T) {
+		service, mockRulesRepo, mockTransactionRepo, mockAccountService := setup()
+		mockRulesRepo.On("GetByAccountID", ctx, 1).Return(rules(), nil)
+		mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.AnythingOfType("time.Time")).Return(10, nil)
+		mockAccountService.On("GetAccountByID", ctx, 1).Return(response.GetFinancialAccount{AccountID: 1, CreatedAt: time.Now().Add(-time.Hour)}, nil)
+
+		violations, err := service.Evaluate(ctx, request.EvaluatePolicy{FinancialAccountID: 1, Amount: usd(150000), Balance: usd(150500)})
+		require.NoError(t, err)
+		assert.Equal(t, []enum.PolicyRule{
+			enum.PolicyMaxAmount,
+			enum.PolicyNewAccountMaxAmount,
+			enum.PolicyMinBalanceRequired,
+			enum.PolicyRequire2FA,
+			enum.PolicyDailyLimit,
+		}, rulesOf(violations))
+		assert.Equal(t, "daily limit 10 reached", violations[4].Message)
+		assert.Equal(t, "amount exceeds new-account cap 500.00 USD", violations[1].Message)
+	})
+
+	t.Run("lifts the new-account cap once the account ages", func(t *testing.T) {
+		service, mockRulesRepo, mockTransactionRepo, mockAccountService := setup()
+		mockRulesRepo.On("GetByAccountID", ctx, 1).Return(rules(), nil)
+		mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.AnythingOfType("time.Time")).Return(0, nil)
+		mockAccountService.On("GetAccountByID", ctx, 1).Return(response.GetFinancialAccount{AccountID: 1, CreatedAt: time.Now().AddDate(0, -2, 0)}, nil)
+
+		violations, err := service.Evaluate(ctx, request.EvaluatePolicy{
+			FinancialAccountID:   1,
+			Amount:               usd(60000),
+			Balance:              usd(100000),
+			SecondFactorVerified: true,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("skips the two-factor rule for exempt debits", func(t *testing.T) {
+		service, mockRulesRepo, mockTransactionRepo, mockAccountService := setup()
+		mockRulesRepo.On("GetByAccountID", ctx, 1).Return(rules(), nil)
+		mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.AnythingOfType("time.Time")).Return(0, nil)
+		mockAccountService.On("GetAccountByID", ctx, 1).Return(response.GetFinancialAccount{AccountID: 1, CreatedAt: time.Now().AddDate(0, -2, 0)}, nil)
+
+		violations, err := service.Evaluate(ctx, request.EvaluatePolicy{
+			FinancialAccountID: 1,
+			Amount:             usd(60000),
+			Balance:            usd(100000),
+			TwoFactorExempt:    true,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("requires the owner's verification level", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			owner entity.User
+			want  []enum.PolicyRule
+		}{
+			{name: "nothing verified", owner: entity.User{ID: 7}, want: []enum.PolicyRule{enum.PolicyVerificationLevel}},
+			{name: "email only", owner: entity.User{ID: 7, ValidatedEmail: true}},
+			{name: "cellphone only", owner: entity.User{ID: 7, ValidatedCellphone: true}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				service, mockRulesRepo, mockTransactionRepo, mockAccountService := setup()
+				required := rules()
+				required.VerificationLevel = enum.VerificationIntermediate
+				mockRulesRepo.On("GetByAccountID", ctx, 1).Return(required, nil)
+				mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.AnythingOfType("time.Time")).Return(0, nil)
+				mockAccountService.On("GetAccountByID", ctx, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 7}, nil)
+				mockUsers := new(protocol.MockUserRepository)
+				mockUsers.On("Get", ctx, 7).Return(tt.owner, nil)
+				service.userService = mockUsers
+
+				violations, err := service.Evaluate(ctx, request.EvaluatePolicy{FinancialAccountID: 1, Amount: usd(5000), Balance: usd(20000)})
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, rulesOf(violations))
+			})
+		}
+	})
+
+	t.Run("asks nothing of the owner at minimal verification", func(t *testing.T) {
+		service, mockRulesRepo, mockTransactionRepo, mockAccountService := setup()
+		minimal := rules()
+		minimal.VerificationLevel = enum.VerificationMinimal
+		mockRulesRepo.On("GetByAccountID", ctx, 1).Return(minimal, nil)
+		mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.AnythingOfType("time.Time")).Return(0, nil)
+
+		violations, err := service.Evaluate(ctx, request.EvaluatePolicy{FinancialAccountID: 1, Amount: usd(5000), Balance: usd(20000)})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+		mockAccountService.AssertNotCalled(t, "GetAccountByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("has no limits without rules", func(t *testing.T) {
+		service, mockRulesRepo, mockTransactionRepo, _ := setup()
+		mockRulesRepo.On("GetByAccountID", ctx, 1).Return(nil, nil)
+
+		violations, err := service.Evaluate(ctx, request.EvaluatePolicy{FinancialAccountID: 1, Amount: usd(1), Balance: usd(0)})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+		mockTransactionRepo.AssertNotCalled(t, "CountDebitsSince", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,34 @@
+package accountrules
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                     config.AccountRules
+	logger                  *zap.SugaredLogger
+	accountRulesRepo        protocol.AccountRulesRepository
+	accountTransactionRepo  protocol.AccountTransactionRepository
+	financialAccountService protocol.FinancialAccount
+	userService             protocol.User
+}
+
+func New(
+	cfg config.AccountRules,
+	logger *zap.SugaredLogger,
+	accountRulesRepo protocol.AccountRulesRepository,
+	accountTransactionRepo protocol.AccountTransactionRepository,
+	financialAccountService protocol.FinancialAccount,
+	userService protocol.User,
+) *Service {
+	return &Service{
+		cfg:                     cfg,
+		logger:                  logger,
+		accountRulesRepo:        accountRulesRepo,
+		accountTransactionRepo:  accountTransactionRepo,
+		financialAccountService: financialAccountService,
+		userService:             userService,
+	}
+}
@@ -35,10 +35,45 @@ func (s *Service) RegisterTransaction(ctx context.Context, req *request.Register
 		return nil, err
 	}
 
+	// The transaction group is a journal entry; the registered transaction must mirror one of its postings
+	entry, err := s.ledgerRepo.GetJournalEntry(ctx, req.TransactionGroupID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the journal entry", zap.Error(err))
+		s.accountTransactionRepo.RollbackTx(ctx)
+		return nil, err
+	}
+
+	if entry == nil {
+		s.accountTransactionRepo.RollbackTx(ctx)
+		return nil, derror.NewNotFoundError("transaction group not found")
+	}
+
+	posting := entry.PostingFor(req.FinancialAccountID)
+	if posting == nil || posting.Amount != req.Amount {
+		s.logger.Error("Transaction does not match any posting of the journal entry", zap.Int("TransactionGroupID", req.TransactionGroupID))
+		s.accountTransactionRepo.RollbackTx(ctx)
+		return nil, derror.NewBadRequestError("transaction does not match the ledger entry of its group")
+	}
+
+	// Debits are held to the rules of the account they leave
+	if posting.Amount.IsNegative() {
+		balance, err := posting.BalanceAfter.Sub(posting.Amount)
+		if err != nil {
+			s.accountTransactionRepo.RollbackTx(ctx)
+			return nil, err
+		}
+
+		if err := s.enforcePolicy(ctx, req.FinancialAccountID, posting.Amount.Neg(), balance, entity.SecondFactor{Exempt: true}); err != nil {
+			s.accountTransactionRepo.RollbackTx(ctx)
+			return nil, err
+		}
+	}
+
 	transaction := &entity.AccountTransaction{
 		TransactionGroupID: req.TransactionGroupID,
 		FinancialAccountID: req.FinancialAccountID,
 		Amount:             req.Amount,
+		Balance:            posting.BalanceAfter,
 		Description:        req.Description,
 		Status:             enum.Peniding, // Assuming enum.Pending exists
 	}
@@ -51,49 +86,30 @@ func (s *Service) RegisterTransaction(ctx context.Context, req *request.Register
 		return nil, err
 	}
 
-	// Commit the transaction
-	err = s.accountTransactionRepo.CommitTx(ctx)
-	if err != nil {
-		s.logger.Error("Failed to commit transaction")
-		return nil, err
-	}
-
-	s.logger.Info("Transaction successfully registered")
-
-	return &response.RegisterTransactionResponse{
+	res := &response.RegisterTransactionResponse{
 		TransactionID: transaction.TransactionID,
 		Status:        transaction.Status,
 		CreatedAt:     transaction.CreatedAt,
-	}, nil
-}
-
-// DeleteTransaction deletes an account transaction
-func (s *Service) DeleteTransaction(ctx context.Context, transactionID int64) error {
-	s.logger.Info("Starting the process to delete transaction", zap.Int64("TransactionID", transactionID))
-
-	// Start a new transaction
-	txContext, err := s.accountTransactionRepo.BeginTx(ctx)
-	if err != nil {
-		s.logger.Error("Failed to start a new transaction", zap.Error(err))
-		return err
 	}
 
-	// Delete the transaction from the database
-	if err := s.accountTransactionRepo.Delete(txContext, transactionID); err != nil {
-		s.logger.Error("Failed to delete transaction", zap.Error(err))
-		_ = s.accountTransactionRepo.RollbackTx(txContext)
-		return err
+	// Store the response for the request's Idempotency-Key in the same transaction
+	if err := s.idempotencyService.Complete(ctx, res); err != nil {
+		s.logger.Error("Failed to store the idempotent response", zap.Error(err))
+		s.accountTransactionRepo.RollbackTx(ctx)
+		return nil, err
 	}
 
 	// Commit the transaction
-	if err := s.accountTransactionRepo.CommitTx(txContext); err != nil {
-		s.logger.Error("Failed to commit transaction", zap.Error(err))
-		return err
+	err = s.accountTransactionRepo.CommitTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to commit transaction")
+		s.accountTransactionRepo.RollbackTx(ctx)
+		return nil, err
 	}
 
-	s.logger.Info("Transaction successfully deleted")
-	return nil
+	s.logger.Info("Transaction successfully registered")
 
+	return res, nil
 }
 
 // GetTransactionByID retrieves an account transaction by its ID
@@ -179,49 +195,49 @@ func (s *Service) ListTransactionsByGroupID(ctx context.Context, groupID int) ([
 
 }
 
-func (s *Service) CancelTransaction(ctx context.Context, transactionID int64) error {
+// CancelTransaction fully reverses the group of the transaction, so that every leg is
+// compensated in the ledger rather than a single row changing its status.
+func (s *Service) CancelTransaction(ctx context.Context, transactionID int64) (err error) {
 	s.logger.Info("Starting to cancel transaction", zap.Int64("TransactionID", transactionID))
 
 	// Validate the transaction ID
 	if transactionID <= 0 {
 		s.logger.Error("Invalid transaction ID specified", zap.Int64("TransactionID", transactionID))
-		return errors.New("invalid transaction ID")
+		return derror.NewBadRequestError("invalid transaction ID")
 	}
 
 	// Begin database transaction
-	ctx, err := s.accountTransactionRepo.BeginTx(ctx)
+	ctx, err = s.accountTransactionRepo.BeginTx(ctx)
 	if err != nil {
 		s.logger.Error("Failed to begin database transaction", zap.Error(err))
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 
+	defer func() {
+		if err != nil {
+			s.accountTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
 	// Fetch the existing transaction
 	existingTransaction, err := s.accountTransactionRepo.GetByID(ctx, transactionID)
 	if err != nil {
 		s.logger.Error("Failed to fetch existing transaction", zap.Int64("TransactionID", transactionID), zap.Error(err))
-		s.accountTransactionRepo.RollbackTx(ctx)
 		return fmt.Errorf("failed to fetch existing transaction: %w", err)
 	}
 
-	// Validate if the transaction is already cancelled or completed
-	if existingTransaction.Status == enum.Cancelled || existingTransaction.Status == enum.Completed {
-		s.logger.Error("Cannot cancel a transaction that is already cancelled or completed", zap.Int64("TransactionID", transactionID))
-		s.accountTransactionRepo.RollbackTx(ctx)
-		return errors.New("invalid transaction state for cancellation")
+	if existingTransaction == nil {
+		err = derror.NewNotFoundError("transaction %d not found", transactionID)
+		return err
 	}
 
-	// Your business logic to cancel the transaction, for example:
-	existingTransaction.Status = enum.Cancelled
-
-	// Update the transaction status
-	if err := s.accountTransactionRepo.Update(ctx, existingTransaction); err != nil {
-		s.logger.Error("Failed to update the transaction status to 'cancelled'", zap.Int64("TransactionID", transactionID), zap.Error(err))
-		s.accountTransactionRepo.RollbackTx(ctx)
-		return fmt.Errorf("failed to update transaction: %w", err)
+	description := fmt.Sprintf("Cancellation of transaction %d", transactionID)
+	if _, err = s.reverseGroup(ctx, existingTransaction.TransactionGroupID, nil, description); err != nil {
+		return err
 	}
 
 	// Commit database transaction
-	if err := s.accountTransactionRepo.CommitTx(ctx); err != nil {
+	if err = s.accountTransactionRepo.CommitTx(ctx); err != nil {
 		s.logger.Error("Failed to commit transaction", zap.Error(err))
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -235,7 +251,8 @@ func (s *Service) Transfer(ctx context.Context, req request.TransferRequest) (re
 	s.logger.Info("Starting the process to transfer funds",
 		zap.Int("SenderAccountID", req.SenderAccountID),
 		zap.Int("ReceiverAccountID", req.ReceiverAccountID),
-		zap.Float64("Amount", req.Amount))
+		zap.String("Amount", req.Amount.String()),
+		zap.String("Currency", string(req.Amount.Currency)))
 
 	// Validate request
 	if err := req.Validate(); err != nil {
@@ -243,6 +260,13 @@ func (s *Service) Transfer(ctx context.Context, req request.TransferRequest) (re
 		return res, err
 	}
 
+	// The code is checked outside the transaction so that a wrong one still counts towards
+	// the lockout when the transfer rolls back.
+	factor, err := s.twoFactorService.VerifyStepUp(ctx, req.UserID, transferBinding(req), req.TwoFactor)
+	if err != nil {
+		return res, err
+	}
+
 	// Start database transaction
 	ctx, err = s.accountTransactionRepo.BeginTx(ctx)
 	if err != nil {
@@ -256,100 +280,106 @@ func (s *Service) Transfer(ctx context.Context, req request.TransferRequest) (re
 		}
 	}()
 
-	senderCurrentBalance, err := s.accountTransactionRepo.GetCurrentBalance(ctx, req.SenderAccountID)
+	if err := s.checkTransferAccounts(ctx, req.SenderAccountID, req.ReceiverAccountID); err != nil {
+		return res, err
+	}
+
+	senderCurrency, err := s.financialAccountService.GetAccountCurrency(ctx, req.SenderAccountID)
 	if err != nil {
-		s.logger.Error("Failed to fetch sender's current balance", zap.Error(err))
+		s.logger.Error("Failed to fetch the sender's account currency", zap.Error(err))
 		return res, err
 	}
 
-	receiverCurrentBalance, err := s.accountTransactionRepo.GetCurrentBalance(ctx, req.ReceiverAccountID)
+	receiverCurrency, err := s.financialAccountService.GetAccountCurrency(ctx, req.ReceiverAccountID)
 	if err != nil {
-		s.logger.Error("Failed to fetch receiver's current balance", zap.Error(err))
+		s.logger.Error("Failed to fetch the receiver's account currency", zap.Error(err))
 		return res, err
 	}
 
-	// Validate that the sender has enough funds and the receiver can receive funds
-	if senderCurrentBalance < req.Amount {
-		s.logger.Error("Insufficient funds in the sender's account")
-		err = errors.New("insufficient funds in the sender's account")
+	if req.Amount.Currency != senderCurrency.CurrencyCode {
+		s.logger.Error("Transfer amount is not in the sender's currency",
+			zap.String("senderCurrency", string(senderCurrency.CurrencyCode)),
+			zap.String("amountCurrency", string(req.Amount.Currency)))
+		err = derror.NewBadRequestError("the amount must be in the sender account's currency")
 		return res, err
 	}
 
-	// Check if the sender's account is in a state that allows transactions (e.g., not frozen or closed)
-	senderStatus, err := s.financialAccountService.GetAccountStatus(ctx, req.SenderAccountID)
+	// A cross-currency transfer is quoted at the current rate and executed right away
+	if senderCurrency.CurrencyCode != receiverCurrency.CurrencyCode {
+		quote, err := s.newQuote(ctx, req.UserID, req.SenderAccountID, req.ReceiverAccountID, req.Amount, receiverCurrency.CurrencyCode)
+		if err != nil {
+			return res, err
+		}
+
+		transfer, err := s.executeQuote(ctx, quote, req.Description, factor)
+		if err != nil {
+			return res, err
+		}
+
+		return s.completeTransfer(ctx, transfer)
+	}
+	currency := senderCurrency.CurrencyCode
+
+	// Lock both balances in account order before reading the sender's funds, so that
+	// concurrent transfers cannot overdraw the account or deadlock each other.
+	senderCurrentBalance, receiverCurrentBalance, err := s.lockBalances(ctx, req.SenderAccountID, currency, req.ReceiverAccountID, currency)
 	if err != nil {
-		s.logger.Error("Failed to fetch the sender's account status", zap.Error(err))
+		s.logger.Error("Failed to lock account balances", zap.Error(err))
 		return res, err
 	}
 
-	if senderStatus != enum.Verified {
-		s.logger.Error("Sender's account is not in a state that allows transactions", zap.String("status", senderStatus.String())) // Assuming you implement a String() method on the enum
-		err = errors.New("sender's account is not in a state that allows transactions")
+	senderCurrentBalance, err = s.availableBalance(ctx, req.SenderAccountID, senderCurrentBalance)
+	if err != nil {
 		return res, err
 	}
 
-	// Check if the receiver's account is in a state that allows receiving transactions (e.g., not frozen or closed)
-	receiverStatus, err := s.financialAccountService.GetAccountStatus(ctx, req.ReceiverAccountID)
-	if err != nil {
-		s.logger.Error("Failed to fetch the receiver's account status", zap.Error(err))
+	// Validate that the sender has enough funds
+	if senderCurrentBalance.Amount < req.Amount.Amount {
+		s.logger.Error("Insufficient funds in the sender's account")
+		err = errors.New("insufficient funds in the sender's account")
 		return res, err
 	}
 
-	if receiverStatus != enum.Verified {
-		s.logger.Error("Receiver's account is not in a state that allows receiving transactions", zap.String("status", receiverStatus.String()))
-		err = errors.New("receiver's account is not in a state that allows receiving transactions")
+	if err := s.enforcePolicy(ctx, req.SenderAccountID, req.Amount, senderCurrentBalance, factor); err != nil {
 		return res, err
 	}
 
-	// Generate new transaction group ID
-	txGroupID, err := s.accountTransactionRepo.CreateNewTransactionGroup(ctx)
+	// The risk score decides whether the transfer completes now or waits on hold or for review
+	assessment, err := s.assessRisk(ctx, req.UserID, req.SenderAccountID, req.ReceiverAccountID, req.Amount, senderCurrentBalance)
 	if err != nil {
-		s.logger.Error("Failed to create new transaction group", zap.Error(err))
 		return res, err
 	}
 
-	// Log the generated txGroupID
-	s.logger.Info("Generated transaction group ID", zap.Int("TransactionGroupID", txGroupID))
-
-	// please check docs/doc.go for more scenraios in populating the status:
-	// /home/delaram/go/src/github.com/delaram-gholampoor-sagha/Digital-Wallet/docs/doc.go
-	// it starts from line 102 till 571
-	// // Perform a risk check on the transaction
-	// isRisky, err := s.riskManagementService.PerformRiskCheck(ctx, req)
-	// if err != nil {
-	// 	s.logger.Error("Failed to perform risk check", zap.Error(err))
-	// 	return res, err
-	// }
-
-	// // Decide the transaction status based on risk check
-	// var transactionStatus enum.StatusType // Replace with your actual enum type
-	// if isRisky {
-	// 	transactionStatus = enum.Pending // Pending because the transaction is risky and might need manual review
-	// } else {
-	// 	transactionStatus = enum.Completed // Not risky, so mark it as completed
-	// }
-
-	// Debit the sender's account
-	if err := s.accountTransactionRepo.DebitAccount(ctx, req.SenderAccountID, req.Amount); err != nil {
-		s.logger.Error("Failed to debit the sender's account", zap.Error(err))
-
+	payee, err := s.payee(assessment, req.ReceiverAccountID, currency)
+	if err != nil {
 		return res, err
 	}
 
-	// Credit the receiver's account
-	if err := s.accountTransactionRepo.CreditAccount(ctx, req.ReceiverAccountID, req.Amount); err != nil {
-		s.logger.Error("Failed to credit the receiver's account", zap.Error(err))
+	// Post the journal entry: the sender's debit and the receiver's credit sum to zero
+	entry := &entity.JournalEntry{
+		Description: &req.Description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: req.SenderAccountID, Amount: req.Amount.Neg()},
+			{FinancialAccountID: payee, Amount: req.Amount},
+		},
+	}
+
+	if err := s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
 		return res, err
 	}
 
+	// Log the journal entry that groups both legs
+	s.logger.Info("Posted journal entry", zap.Int("TransactionGroupID", entry.JournalEntryID))
+
 	// Create sender's transaction record
 	senderTx := &entity.AccountTransaction{
-		TransactionGroupID: txGroupID,
+		TransactionGroupID: entry.JournalEntryID,
 		FinancialAccountID: req.SenderAccountID,
-		Amount:             -req.Amount,                       // Negative because it's a debit
-		Balance:            senderCurrentBalance - req.Amount, // Update the balance after the transaction
+		Amount:             req.Amount.Neg(), // Negative because it's a debit
+		Balance:            entry.PostingFor(req.SenderAccountID).BalanceAfter,
 		Description:        &req.Description,
-		Status:             enum.Peniding, // Or enum.Completed, depending on your logic
+		Status:             assessment.Decision,
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 		DeletedAt:          nil, // Not deleted, so it's nil
@@ -360,14 +390,20 @@ func (s *Service) Transfer(ctx context.Context, req request.TransferRequest) (re
 		return res, err
 	}
 
+	// The receiver's balance only moves once a held transfer is released
+	receiverBalance := receiverCurrentBalance
+	if !assessment.IsHeld() {
+		receiverBalance = entry.PostingFor(req.ReceiverAccountID).BalanceAfter
+	}
+
 	// Create receiver's transaction record
 	receiverTx := &entity.AccountTransaction{
-		TransactionGroupID: txGroupID,
+		TransactionGroupID: entry.JournalEntryID,
 		FinancialAccountID: req.ReceiverAccountID,
 		Amount:             req.Amount,
-		Balance:            receiverCurrentBalance + req.Amount, // Update the balance after the transaction
-		Description:        &req.Description,                    // If the receiver has a different description, update this
-		Status:             enum.Peniding,                       // Or enum.Completed, depending my your logic
+		Balance:            receiverBalance,
+		Description:        &req.Description, // If the receiver has a different description, update this
+		Status:             assessment.Decision,
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 		DeletedAt:          nil, // Not deleted, so it's nil
@@ -378,20 +414,15 @@ func (s *Service) Transfer(ctx context.Context, req request.TransferRequest) (re
 		return res, err
 	}
 
-	// Commit transaction
-	if err := s.accountTransactionRepo.CommitTx(ctx); err != nil {
-		s.logger.Error("Failed to commit transaction", zap.Error(err))
+	assessment.TransactionGroupID = entry.JournalEntryID
+	if err := s.riskService.Record(ctx, assessment); err != nil {
 		return res, err
 	}
 
-	res = response.TransferResponse{
+	return s.completeTransfer(ctx, response.TransferResponse{
 		SenderTx:   *senderTx,
 		ReceiverTx: *receiverTx,
-	}
-
-	s.logger.Info("Successfully transferred funds")
-
-	return res, nil
+	})
 }
 
 func (s *Service) GetAccountTransactionHistory(ctx context.Context, accountID int) ([]*entity.AccountTransaction, error) {
@@ -401,9 +432,6 @@ func (s *Service) GetAccountTransactionHistory(ctx context.Context, accountID in
 		return nil, derror.NewValidationError("Invalid account ID")
 	}
 
-	// TODO: Optional: I need to also add a check here to make sure the accountID belongs to the authenticated user.
-	// ... (My authorization logic here)
-
 	transactions, err := s.accountTransactionRepo.ListByAccountID(ctx, accountID)
 	if err != nil {
 		s.logger.Error("Failed to get transactions by account ID", zap.Error(err), zap.String("method", "GetAccountTransactionHistory"))
@@ -416,3 +444,155 @@ func (s *Service) GetAccountTransactionHistory(ctx context.Context, accountID in
 
 	return transactions, nil
 }
+
+// completeTransfer stores the response for the request's Idempotency-Key and commits
+// the transfer's database transaction, so both take effect together.
+func (s *Service) completeTransfer(ctx context.Context, transfer response.TransferResponse) (response.TransferResponse, error) {
+	if err := s.idempotencyService.Complete(ctx, transfer); err != nil {
+		s.logger.Error("Failed to store the idempotent response", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	if err := s.accountTransactionRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	s.logger.Info("Successfully transferred funds", zap.Int("TransactionGroupID", transfer.SenderTx.TransactionGroupID))
+
+	return transfer, nil
+}
+
+// checkTransferAccounts verifies that both accounts are in a state that allows transfers (e.g., not frozen or closed).
+func (s *Service) checkTransferAccounts(ctx context.Context, senderAccountID, receiverAccountID int) error {
+	// Check if the sender's account is in a state that allows transactions (e.g., not frozen or closed)
+	senderStatus, err := s.financialAccountService.GetAccountStatus(ctx, senderAccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the sender's account status", zap.Error(err))
+		return err
+	}
+
+	if senderStatus != enum.Verified {
+		s.logger.Error("Sender's account is not in a state that allows transactions", zap.String("status", senderStatus.String())) // Assuming you implement a String() method on the enum
+		return errors.New("sender's account is not in a state that allows transactions")
+	}
+
+	// Check if the receiver's account is in a state that allows receiving transactions (e.g., not frozen or closed)
+	receiverStatus, err := s.financialAccountService.GetAccountStatus(ctx, receiverAccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the receiver's account status", zap.Error(err))
+		return err
+	}
+
+	if receiverStatus != enum.Verified {
+		s.logger.Error("Receiver's account is not in a state that allows receiving transactions", zap.String("status", receiverStatus.String()))
+		return errors.New("receiver's account is not in a state that allows receiving transactions")
+	}
+
+	return nil
+}
+
+// enforcePolicy rejects a debit that breaks the rules of its account, listing every violated rule.
+// A debit held back by the two-factor rule alone is answered with a challenge instead.
+func (s *Service) enforcePolicy(ctx context.Context, accountID int, amount, balance entity.Money, factor entity.SecondFactor) error {
+	violations, err := s.accountRulesService.Evaluate(ctx, request.EvaluatePolicy{
+		FinancialAccountID:   accountID,
+		Amount:               amount,
+		Balance:              balance,
+		SecondFactorVerified: factor.Verified,
+		TwoFactorExempt:      factor.Exempt,
+	})
+	if err != nil {
+		s.logger.Error("Failed to evaluate the account rules", zap.Error(err))
+		return err
+	}
+
+	if factor.UserID != 0 && entity.OnlyTwoFactor(violations) {
+		return s.twoFactorService.StepUp(ctx, factor, violations)
+	}
+
+	if len(violations) > 0 {
+		s.logger.Warn("Transaction violates the account rules", zap.Int("accountID", accountID), zap.Any("violations", violations))
+		return derror.WithDetails(derror.NewValidationError("the transaction violates the rules of account %d", accountID), violations)
+	}
+
+	return nil
+}
+
+func transferBinding(req request.TransferRequest) string {
+	return fmt.Sprintf("transfer:%d:%d:%d:%d:%s", req.UserID, req.SenderAccountID, req.ReceiverAccountID, req.Amount.Amount, req.Amount.Currency)
+}
+
+func quoteBinding(req request.ExecuteQuoteRequest) string {
+	return fmt.Sprintf("quote:%d:%d", req.UserID, req.QuoteID)
+}
+
+// availableBalance takes what card authorizations hold off the sender's locked balance, so
+// that a transfer cannot spend funds reserved for a capture.
+func (s *Service) availableBalance(ctx context.Context, accountID int, balance entity.Money) (entity.Money, error) {
+	held, err := s.ledgerRepo.HeldAmount(ctx, accountID, balance.Currency)
+	if err != nil {
+		s.logger.Error("Failed to sum the authorization holds", zap.Error(err), zap.Int("accountID", accountID))
+		return entity.Money{}, err
+	}
+
+	return entity.NewMoney(balance.Amount-held.Amount, balance.Currency), nil
+}
+
+// lockBalances locks the balances of both accounts, each in its own currency, in
+// ascending account order and returns the sender's and the receiver's balance.
+func (s *Service) lockBalances(ctx context.Context, senderAccountID int, senderCurrency enum.CurrencyCode, receiverAccountID int, receiverCurrency enum.CurrencyCode) (sender, receiver entity.Money, err error) {
+	first, firstCurrency := senderAccountID, senderCurrency
+	second, secondCurrency := receiverAccountID, receiverCurrency
+	if second < first {
+		first, firstCurrency, second, secondCurrency = second, secondCurrency, first, firstCurrency
+	}
+
+	firstBalance, err := s.ledgerRepo.LockBalance(ctx, first, firstCurrency)
+	if err != nil {
+		return entity.Money{}, entity.Money{}, err
+	}
+
+	secondBalance, err := s.ledgerRepo.LockBalance(ctx, second, secondCurrency)
+	if err != nil {
+		return entity.Money{}, entity.Money{}, err
+	}
+
+	if first == senderAccountID {
+		return firstBalance, secondBalance, nil
+	}
+	return secondBalance, firstBalance, nil
+}
+
+// assessRisk runs the risk checks on a transfer before it is posted.
+func (s *Service) assessRisk(ctx context.Context, userID, senderAccountID, receiverAccountID int, amount, balance entity.Money) (*entity.RiskAssessment, error) {
+	assessment, err := s.riskService.Assess(ctx, request.AssessRisk{
+		UserID:            userID,
+		SenderAccountID:   senderAccountID,
+		ReceiverAccountID: receiverAccountID,
+		Amount:            amount,
+		Balance:           balance,
+		At:                time.Now(),
+	})
+	if err != nil {
+		s.logger.Error("Failed to assess the transfer risk", zap.Error(err))
+		return nil, err
+	}
+
+	return assessment, nil
+}
+
+// payee returns the account a transfer credits: the receiver's, or the suspense account
+// of the currency while the transfer is held.
+func (s *Service) payee(assessment *entity.RiskAssessment, receiverAccountID int, currency enum.CurrencyCode) (int, error) {
+	if !assessment.IsHeld() {
+		return receiverAccountID, nil
+	}
+
+	if suspense, ok := s.riskCfg.SuspenseAccountFor(string(currency)); ok {
+		return suspense, nil
+	}
+
+	s.logger.Error("No suspense account is configured for the currency", zap.String("currency", string(currency)))
+	return 0, derror.NewInternalSystemError()
+}
@@ -0,0 +1,462 @@
+package accounttransaction
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type txCtxKey struct{}
+
+var errInjected = errors.New("injected failure")
+
+func setup() (*Service, *protocol.MockAccountTransactionRepo, *protocol.MockLedgerRepo, *protocol.MockFinancialAccountService) {
+	mockRepo := new(protocol.MockAccountTransactionRepo)
+	mockLedger := new(protocol.MockLedgerRepo)
+	mockLedger.On("HeldAmount", mock.Anything, mock.Anything, mock.Anything).Return(entity.Money{}, nil).Maybe()
+	mockAccountService := new(protocol.MockFinancialAccountService)
+	mockIdempotency := new(protocol.MockIdempotencyService)
+	mockIdempotency.On("Complete", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockRules := new(protocol.MockAccountRulesService)
+	mockRules.On("Evaluate", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	mockRisk := new(protocol.MockRiskService)
+	mockRisk.On("Assess", mock.Anything, mock.Anything).Return(&entity.RiskAssessment{Decision: enum.Completed}, nil).Maybe()
+	mockRisk.On("Record", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockReviewCases := new(protocol.MockReviewCaseRepo)
+	mockReviewCases.On("GetByTransactionGroupID", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	mockTwoFactor := new(protocol.MockTwoFactorService)
+	mockTwoFactor.On("VerifyStepUp", mock.Anything, mock.Anything, mock.Anything, (*request.TwoFactorAnswer)(nil)).Return(entity.SecondFactor{}, nil).Maybe()
+
+	cfg := config.JWT{
+		AccessTokenExp:  time.Minute * 15,
+		RefreshTokenExp: time.Hour * 24,
+		Secret:          "testSecret",
+	}
+
+	logger, _ := zap.NewProduction()
+
+	service := &Service{
+		cfg:                     cfg,
+		logger:                  logger.Sugar(),
+		financialAccountService: mockAccountService,
+		accountTransactionRepo:  mockRepo,
+		ledgerRepo:              mockLedger,
+		idempotencyService:      mockIdempotency,
+		accountRulesService:     mockRules,
+		riskService:             mockRisk,
+		reviewCaseRepo:          mockReviewCases,
+		twoFactorService:        mockTwoFactor,
+	}
+	return service, mockRepo, mockLedger, mockAccountService
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+// errAt returns the injected error when step is the one under test.
+func errAt(step, failAt string) error {
+	if step == failAt {
+		return errInjected
+	}
+	return nil
+}
+
+// postEntry simulates the ledger assigning an ID and running balances to a posted entry.
+func postEntry(journalEntryID int) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		entry := args.Get(1).(*entity.JournalEntry)
+		entry.JournalEntryID = journalEntryID
+		for _, p := range entry.Postings {
+			p.JournalEntryID = journalEntryID
+			if p.FinancialAccountID == 1 {
+				p.BalanceAfter, _ = usd(10000).Add(p.Amount)
+			} else {
+				p.BalanceAfter = p.Amount
+			}
+		}
+	}
+}
+
+func journalEntry(journalEntryID int) *entity.JournalEntry {
+	return &entity.JournalEntry{
+		JournalEntryID: journalEntryID,
+		Postings: []*entity.LedgerPosting{
+			{JournalEntryID: journalEntryID, FinancialAccountID: 1, Amount: usd(-1000), BalanceAfter: usd(9000)},
+			{JournalEntryID: journalEntryID, FinancialAccountID: 2, Amount: usd(1000), BalanceAfter: usd(1000)},
+		},
+	}
+}
+
+func TestTransferRollsBackOnFailure(t *testing.T) {
+	steps := []string{
+		"GetAccountStatus.sender",
+		"GetAccountStatus.receiver",
+		"GetAccountCurrency.sender",
+		"GetAccountCurrency.receiver",
+		"LockBalance.sender",
+		"LockBalance.receiver",
+		"Evaluate",
+		"Assess",
+		"Post",
+		"Insert.sender",
+		"Insert.receiver",
+		"Record",
+		"Complete",
+		"CommitTx",
+	}
+
+	req := request.TransferRequest{
+		SenderAccountID:   1,
+		ReceiverAccountID: 2,
+		Amount:            usd(1000),
+		Description:       "rent",
+	}
+
+	for _, failAt := range steps {
+		t.Run(failAt, func(t *testing.T) {
+			service, mockRepo, mockLedger, mockAccountService := setup()
+			ctx := context.Background()
+			txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+			mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+			mockAccountService.On("GetAccountStatus", txCtx, 1).Return(enum.Verified, errAt("GetAccountStatus.sender", failAt))
+			mockAccountService.On("GetAccountStatus", txCtx, 2).Return(enum.Verified, errAt("GetAccountStatus.receiver", failAt))
+			mockAccountService.On("GetAccountCurrency", txCtx, 1).Return(response.GetCurrency{CurrencyCode: enum.USD}, errAt("GetAccountCurrency.sender", failAt))
+			mockAccountService.On("GetAccountCurrency", txCtx, 2).Return(response.GetCurrency{CurrencyCode: enum.USD}, errAt("GetAccountCurrency.receiver", failAt))
+			mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(10000), errAt("LockBalance.sender", failAt))
+			mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(0), errAt("LockBalance.receiver", failAt))
+			mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(postEntry(7)).Return(errAt("Post", failAt))
+			mockRepo.On("Insert", txCtx, mock.MatchedBy(func(tx *entity.AccountTransaction) bool {
+				return tx.FinancialAccountID == 1
+			})).Return(errAt("Insert.sender", failAt))
+			mockRepo.On("Insert", txCtx, mock.MatchedBy(func(tx *entity.AccountTransaction) bool {
+				return tx.FinancialAccountID == 2
+			})).Return(errAt("Insert.receiver", failAt))
+			mockRepo.On("CommitTx", txCtx).Return(errAt("CommitTx", failAt))
+			mockRepo.On("RollbackTx", txCtx).Return(nil)
+			mockIdempotency := new(protocol.MockIdempotencyService)
+			mockIdempotency.On("Complete", txCtx, mock.AnythingOfType("response.TransferResponse")).Return(errAt("Complete", failAt))
+			service.idempotencyService = mockIdempotency
+			mockRules := new(protocol.MockAccountRulesService)
+			mockRules.On("Evaluate", txCtx, request.EvaluatePolicy{FinancialAccountID: 1, Amount: usd(1000), Balance: usd(10000)}).Return(nil, errAt("Evaluate", failAt))
+			service.accountRulesService = mockRules
+			mockRisk := new(protocol.MockRiskService)
+			mockRisk.On("Assess", txCtx, mock.AnythingOfType("request.AssessRisk")).Return(&entity.RiskAssessment{Decision: enum.Completed}, errAt("Assess", failAt))
+			mockRisk.On("Record", txCtx, mock.AnythingOfType("*entity.RiskAssessment")).Return(errAt("Record", failAt))
+			service.riskService = mockRisk
+
+			_, err := service.Transfer(ctx, req)
+			assert.ErrorIs(t, err, errInjected)
+			mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+			if failAt != "CommitTx" {
+				mockRepo.AssertNotCalled(t, "CommitTx", mock.Anything)
+			}
+		})
+	}
+
+	t.Run("Successfully transfer", func(t *testing.T) {
+		service, mockRepo, mockLedger, mockAccountService := setup()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+		var posted *entity.JournalEntry
+		mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+		mockAccountService.On("GetAccountStatus", txCtx, mock.Anything).Return(enum.Verified, nil)
+		mockAccountService.On("GetAccountCurrency", txCtx, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+		mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(10000), nil)
+		mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(0), nil)
+		mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			posted = args.Get(1).(*entity.JournalEntry)
+			postEntry(7)(args)
+		}).Return(nil)
+		mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+		mockRepo.On("CommitTx", txCtx).Return(nil)
+
+		res, err := service.Transfer(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, res.SenderTx.TransactionGroupID)
+		assert.Equal(t, 7, res.ReceiverTx.TransactionGroupID)
+		assert.True(t, posted.IsBalanced())
+		assert.Equal(t, usd(9000), res.SenderTx.Balance)
+		assert.Equal(t, usd(1000), res.ReceiverTx.Balance)
+		mockRepo.AssertNotCalled(t, "RollbackTx", mock.Anything)
+	})
+}
+
+func TestCancelTransactionRollsBackOnFailure(t *testing.T) {
+	steps := []string{"GetByID", "ListByTransactionGroupID", "GetJournalEntry", "LockBalance", "ReversedAmount", "Post", "Insert", "Update", "CommitTx"}
+
+	for _, failAt := range steps {
+		t.Run(failAt, func(t *testing.T) {
+			service, mockRepo, mockLedger, _ := setup()
+			ctx := context.Background()
+			txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+			mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+			mockRepo.On("GetByID", txCtx, int64(3)).Return(&entity.AccountTransaction{TransactionID: 3, TransactionGroupID: 7, Status: enum.Peniding}, errAt("GetByID", failAt))
+			mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(completedLegs(), errAt("ListByTransactionGroupID", failAt))
+			mockLedger.On("GetJournalEntry", txCtx, 7).Return(journalEntry(7), errAt("GetJournalEntry", failAt))
+			mockLedger.On("LockBalance", txCtx, mock.Anything, enum.USD).Return(usd(5000), errAt("LockBalance", failAt))
+			mockLedger.On("ReversedAmount", txCtx, 7, mock.Anything, enum.USD).Return(usd(0), errAt("ReversedAmount", failAt))
+			mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(postEntry(8)).Return(errAt("Post", failAt))
+			mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(errAt("Insert", failAt))
+			mockRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(errAt("Update", failAt))
+			mockRepo.On("CommitTx", txCtx).Return(errAt("CommitTx", failAt))
+			mockRepo.On("RollbackTx", txCtx).Return(nil)
+
+			err := service.CancelTransaction(ctx, 3)
+			assert.ErrorIs(t, err, errInjected)
+			mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+		})
+	}
+}
+
+func TestRegisterTransactionRollsBackOnFailure(t *testing.T) {
+	steps := []string{"GetJournalEntry", "Insert", "Complete", "CommitTx"}
+
+	req := &request.RegisterTransactionRequest{
+		TransactionGroupID: 7,
+		FinancialAccountID: 2,
+		Amount:             usd(1000),
+	}
+
+	for _, failAt := range steps {
+		t.Run(failAt, func(t *testing.T) {
+			service, mockRepo, mockLedger, _ := setup()
+			ctx := context.Background()
+			txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+			mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+			mockLedger.On("GetJournalEntry", txCtx, 7).Return(journalEntry(7), errAt("GetJournalEntry", failAt))
+			mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(errAt("Insert", failAt))
+			mockRepo.On("CommitTx", txCtx).Return(errAt("CommitTx", failAt))
+			mockRepo.On("RollbackTx", txCtx).Return(nil)
+			mockIdempotency := new(protocol.MockIdempotencyService)
+			mockIdempotency.On("Complete", txCtx, mock.AnythingOfType("*response.RegisterTransactionResponse")).Return(errAt("Complete", failAt))
+			service.idempotencyService = mockIdempotency
+
+			_, err := service.RegisterTransaction(ctx, req)
+			assert.ErrorIs(t, err, errInjected)
+			mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+		})
+	}
+}
+
+func TestTransferRejectsInsufficientFunds(t *testing.T) {
+	service, mockRepo, mockLedger, mockAccountService := setup()
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockRepo.On("RollbackTx", txCtx).Return(nil)
+	mockAccountService.On("GetAccountStatus", txCtx, mock.Anything).Return(enum.Verified, nil)
+	mockAccountService.On("GetAccountCurrency", txCtx, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(500), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(0), nil)
+
+	_, err := service.Transfer(ctx, request.TransferRequest{SenderAccountID: 1, ReceiverAccountID: 2, Amount: usd(1000)})
+	assert.Error(t, err)
+	mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+}
+
+func TestRegisterTransactionRejectsMismatchedPosting(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockRepo.On("RollbackTx", txCtx).Return(nil)
+	mockLedger.On("GetJournalEntry", txCtx, 7).Return(journalEntry(7), nil)
+
+	_, err := service.RegisterTransaction(ctx, &request.RegisterTransactionRequest{
+		TransactionGroupID: 7,
+		FinancialAccountID: 2,
+		Amount:             usd(2500),
+	})
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestTransferRejectsPolicyViolations(t *testing.T) {
+	service, mockRepo, mockLedger, mockAccountService := setup()
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+	violations := []entity.PolicyViolation{
+		{Rule: enum.PolicyDailyLimit, Message: "daily limit 10 reached"},
+		{Rule: enum.PolicyNewAccountMaxAmount, Message: "amount exceeds new-account cap 5.00 USD"},
+	}
+
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockRepo.On("RollbackTx", txCtx).Return(nil)
+	mockAccountService.On("GetAccountStatus", txCtx, mock.Anything).Return(enum.Verified, nil)
+	mockAccountService.On("GetAccountCurrency", txCtx, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(10000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(0), nil)
+	mockRules := new(protocol.MockAccountRulesService)
+	mockRules.On("Evaluate", txCtx, mock.Anything).Return(violations, nil)
+	service.accountRulesService = mockRules
+
+	_, err := service.Transfer(ctx, request.TransferRequest{SenderAccountID: 1, ReceiverAccountID: 2, Amount: usd(1000)})
+	require.Error(t, err)
+
+	derr, ok := err.(*derror.Error)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusUnprocessableEntity, derr.Code)
+	assert.Equal(t, violations, derr.Details)
+	mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+}
+
+func TestTransferHeldByRiskChecks(t *testing.T) {
+	const usdSuspense = 903
+
+	service, mockRepo, mockLedger, mockAccountService := setup()
+	service.riskCfg = config.Risk{SuspenseAccounts: []config.SuspenseAccount{{Currency: "USD", AccountID: usdSuspense}}}
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+	assessment := &entity.RiskAssessment{
+		FinancialAccountID: 1,
+		Score:              50,
+		Decision:           enum.PendingReview,
+		Reasons:            []entity.RiskReason{{Check: "large_amount", Score: 50, Message: "amount 10.00 USD is above 5.00 USD"}},
+	}
+
+	var posted *entity.JournalEntry
+	var inserted []*entity.AccountTransaction
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockAccountService.On("GetAccountStatus", txCtx, mock.Anything).Return(enum.Verified, nil)
+	mockAccountService.On("GetAccountCurrency", txCtx, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(10000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(500), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		posted = args.Get(1).(*entity.JournalEntry)
+		postEntry(7)(args)
+	}).Return(nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Run(func(args mock.Arguments) {
+		inserted = append(inserted, args.Get(1).(*entity.AccountTransaction))
+	}).Return(nil)
+	mockRepo.On("CommitTx", txCtx).Return(nil)
+	mockRisk := new(protocol.MockRiskService)
+	mockRisk.On("Assess", txCtx, mock.MatchedBy(func(req request.AssessRisk) bool {
+		return req.SenderAccountID == 1 && req.ReceiverAccountID == 2 && req.Amount == usd(1000) && req.Balance == usd(10000)
+	})).Return(assessment, nil)
+	mockRisk.On("Record", txCtx, assessment).Return(nil)
+	service.riskService = mockRisk
+
+	res, err := service.Transfer(ctx, request.TransferRequest{SenderAccountID: 1, ReceiverAccountID: 2, Amount: usd(1000)})
+	require.NoError(t, err)
+
+	// The credit waits in the suspense account and the receiver's balance is unchanged
+	assert.True(t, posted.IsBalanced())
+	assert.Nil(t, posted.PostingFor(2))
+	assert.Equal(t, usd(1000), posted.PostingFor(usdSuspense).Amount)
+	assert.Equal(t, usd(500), res.ReceiverTx.Balance)
+	assert.Equal(t, usd(9000), res.SenderTx.Balance)
+
+	require.Len(t, inserted, 2)
+	for _, tx := range inserted {
+		assert.Equal(t, enum.PendingReview, tx.Status)
+	}
+
+	assert.Equal(t, 7, assessment.TransactionGroupID)
+	mockRisk.AssertCalled(t, "Record", txCtx, assessment)
+}
+
+func TestTransferStepUp(t *testing.T) {
+	const binding = "transfer:3:1:2:1000:USD"
+
+	twoFactorViolation := []entity.PolicyViolation{
+		{Rule: enum.PolicyRequire2FA, Message: "two-factor authentication is required for amounts above 5.00 USD"},
+	}
+	answer := &request.TwoFactorAnswer{Challenge: "challenge", Code: "123456"}
+
+	prepare := func() (*Service, *protocol.MockAccountTransactionRepo, *protocol.MockLedgerRepo, *protocol.MockAccountRulesService, *protocol.MockTwoFactorService) {
+		service, mockRepo, mockLedger, mockAccountService := setup()
+		mockRepo.On("BeginTx", mock.Anything).Return(context.Background(), nil)
+		mockRepo.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+		mockAccountService.On("GetAccountStatus", mock.Anything, mock.Anything).Return(enum.Verified, nil)
+		mockAccountService.On("GetAccountCurrency", mock.Anything, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+		mockLedger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		mockLedger.On("LockBalance", mock.Anything, 2, enum.USD).Return(usd(0), nil)
+
+		mockRules := new(protocol.MockAccountRulesService)
+		mockTwoFactor := new(protocol.MockTwoFactorService)
+		service.accountRulesService = mockRules
+		service.twoFactorService = mockTwoFactor
+
+		return service, mockRepo, mockLedger, mockRules, mockTwoFactor
+	}
+
+	transfer := request.TransferRequest{UserID: 3, SenderAccountID: 1, ReceiverAccountID: 2, Amount: usd(1000)}
+
+	t.Run("challenged", func(t *testing.T) {
+		service, _, mockLedger, mockRules, mockTwoFactor := prepare()
+		factor := entity.SecondFactor{UserID: 3, Binding: binding}
+		stepUp := derror.NewForbiddenError("the payment needs a two-factor code")
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, (*request.TwoFactorAnswer)(nil)).Return(factor, nil)
+		mockRules.On("Evaluate", mock.Anything, mock.Anything).Return(twoFactorViolation, nil)
+		mockTwoFactor.On("StepUp", mock.Anything, factor, twoFactorViolation).Return(stepUp)
+
+		_, err := service.Transfer(context.Background(), transfer)
+		assert.Equal(t, stepUp, err)
+		mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("other violations are not stepped up", func(t *testing.T) {
+		service, _, mockLedger, mockRules, mockTwoFactor := prepare()
+		violations := append([]entity.PolicyViolation{{Rule: enum.PolicyMaxAmount}}, twoFactorViolation...)
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, (*request.TwoFactorAnswer)(nil)).Return(entity.SecondFactor{UserID: 3, Binding: binding}, nil)
+		mockRules.On("Evaluate", mock.Anything, mock.Anything).Return(violations, nil)
+
+		_, err := service.Transfer(context.Background(), transfer)
+		require.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		mockTwoFactor.AssertNotCalled(t, "StepUp", mock.Anything, mock.Anything, mock.Anything)
+		mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("answered", func(t *testing.T) {
+		service, mockRepo, mockLedger, mockRules, mockTwoFactor := prepare()
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, answer).Return(entity.SecondFactor{UserID: 3, Binding: binding, Verified: true}, nil)
+		mockRules.On("Evaluate", mock.Anything, mock.MatchedBy(func(req request.EvaluatePolicy) bool {
+			return req.SecondFactorVerified
+		})).Return(nil, nil)
+		mockLedger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(postEntry(7)).Return(nil)
+		mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+		mockRepo.On("CommitTx", mock.Anything).Return(nil)
+
+		answered := transfer
+		answered.TwoFactor = answer
+		_, err := service.Transfer(context.Background(), answered)
+		require.NoError(t, err)
+		mockLedger.AssertCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		service, mockRepo, _, _, mockTwoFactor := prepare()
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, answer).Return(entity.SecondFactor{UserID: 3, Binding: binding}, derror.NewUnauthorizedError("invalid two-factor code"))
+
+		answered := transfer
+		answered.TwoFactor = answer
+		_, err := service.Transfer(context.Background(), answered)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+		mockRepo.AssertNotCalled(t, "BeginTx", mock.Anything)
+	})
+}
@@ -0,0 +1,326 @@
+package accounttransaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultQuoteTTL = 30 * time.Second
+	bpsScale        = 10000
+)
+
+// QuoteTransfer prices a transfer between accounts in different currencies and locks
+// the rate until the quote expires.
+func (s *Service) QuoteTransfer(ctx context.Context, req request.QuoteTransferRequest) (response.TransferQuote, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid quote request", zap.Error(err))
+		return response.TransferQuote{}, derror.NewBadRequestError(err.Error())
+	}
+
+	senderCurrency, err := s.financialAccountService.GetAccountCurrency(ctx, req.SenderAccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the sender's account currency", zap.Error(err))
+		return response.TransferQuote{}, err
+	}
+
+	receiverCurrency, err := s.financialAccountService.GetAccountCurrency(ctx, req.ReceiverAccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the receiver's account currency", zap.Error(err))
+		return response.TransferQuote{}, err
+	}
+
+	if req.Amount.Currency != senderCurrency.CurrencyCode {
+		return response.TransferQuote{}, derror.NewBadRequestError("the amount must be in the sender account's currency")
+	}
+
+	if senderCurrency.CurrencyCode == receiverCurrency.CurrencyCode {
+		return response.TransferQuote{}, derror.NewBadRequestError("both accounts use %s, no quote is needed", senderCurrency.CurrencyCode)
+	}
+
+	quote, err := s.newQuote(ctx, req.UserID, req.SenderAccountID, req.ReceiverAccountID, req.Amount, receiverCurrency.CurrencyCode)
+	if err != nil {
+		return response.TransferQuote{}, err
+	}
+
+	return toTransferQuote(quote), nil
+}
+
+// ExecuteQuote runs the transfer priced by a quote of the user, at the quoted rate.
+func (s *Service) ExecuteQuote(ctx context.Context, req request.ExecuteQuoteRequest) (res response.TransferResponse, err error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid execute quote request", zap.Error(err))
+		return res, derror.NewBadRequestError(err.Error())
+	}
+
+	factor, err := s.twoFactorService.VerifyStepUp(ctx, req.UserID, quoteBinding(req), req.TwoFactor)
+	if err != nil {
+		return res, err
+	}
+
+	ctx, err = s.accountTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return res, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.accountTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	// The row lock keeps a quote from being executed twice by concurrent requests
+	quote, err := s.fxQuoteRepo.GetForUpdate(ctx, req.QuoteID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the quote", zap.Error(err))
+		return res, err
+	}
+
+	if quote == nil || quote.UserID != req.UserID {
+		err = derror.NewNotFoundError("quote not found")
+		return res, err
+	}
+
+	if quote.Status == enum.FXQuoteExecuted {
+		err = derror.NewConflictError("quote has already been executed")
+		return res, err
+	}
+
+	if quote.IsExpired(time.Now()) {
+		err = derror.NewValidationError("quote has expired, request a new one")
+		return res, err
+	}
+
+	if err := s.checkTransferAccounts(ctx, quote.SenderAccountID, quote.ReceiverAccountID); err != nil {
+		return res, err
+	}
+
+	transfer, err := s.executeQuote(ctx, quote, req.Description, factor)
+	if err != nil {
+		return res, err
+	}
+
+	return s.completeTransfer(ctx, transfer)
+}
+
+// newQuote converts amount into the target currency at the current rate less the
+// configured spread and stores the quote.
+func (s *Service) newQuote(ctx context.Context, userID, senderAccountID, receiverAccountID int, amount entity.Money, target enum.CurrencyCode) (*entity.FXQuote, error) {
+	midRate, err := s.currencyService.GetExchangeRate(ctx, amount.Currency, target)
+	if err != nil {
+		s.logger.Error("Failed to get the exchange rate", zap.Error(err))
+		return nil, err
+	}
+
+	spreadBps := s.fxCfg.SpreadBps
+	rate := entity.Rate(int64(midRate) * (bpsScale - spreadBps) / bpsScale)
+
+	targetAmount, err := amount.Convert(rate, target)
+	if err != nil {
+		s.logger.Error("Failed to convert the amount", zap.Error(err))
+		return nil, derror.NewBadRequestError("the amount cannot be converted")
+	}
+
+	if !targetAmount.IsPositive() {
+		return nil, derror.NewBadRequestError("the amount is too small to convert to %s", target)
+	}
+
+	midAmount, err := amount.Convert(midRate, target)
+	if err != nil {
+		s.logger.Error("Failed to convert the amount", zap.Error(err))
+		return nil, derror.NewBadRequestError("the amount cannot be converted")
+	}
+
+	spread, err := midAmount.Sub(targetAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &entity.FXQuote{
+		UserID:            userID,
+		SenderAccountID:   senderAccountID,
+		ReceiverAccountID: receiverAccountID,
+		SourceAmount:      amount,
+		TargetAmount:      targetAmount,
+		MidRate:           midRate,
+		Rate:              rate,
+		SpreadBps:         spreadBps,
+		Spread:            spread,
+		Status:            enum.FXQuoteOpen,
+		ExpiresAt:         time.Now().Add(s.quoteTTL()),
+	}
+
+	if err := s.fxQuoteRepo.Insert(ctx, quote); err != nil {
+		s.logger.Error("Failed to store the quote", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Quoted cross-currency transfer",
+		zap.Int("QuoteID", quote.FXQuoteID),
+		zap.String("Rate", rate.String()),
+		zap.String("Spread", spread.String()))
+
+	return quote, nil
+}
+
+// executeQuote posts a quoted transfer inside the caller's database transaction. The
+// house accounts buy the source currency from the sender and sell the target currency
+// to the receiver, so the entry balances in each currency.
+func (s *Service) executeQuote(ctx context.Context, quote *entity.FXQuote, description string, factor entity.SecondFactor) (response.TransferResponse, error) {
+	source, target := quote.SourceAmount.Currency, quote.TargetAmount.Currency
+
+	sourceHouse, ok := s.houseAccount(source)
+	if !ok {
+		return response.TransferResponse{}, derror.NewBadRequestError("transfers from %s are not supported", source)
+	}
+
+	targetHouse, ok := s.houseAccount(target)
+	if !ok {
+		return response.TransferResponse{}, derror.NewBadRequestError("transfers to %s are not supported", target)
+	}
+
+	senderCurrentBalance, receiverCurrentBalance, err := s.lockBalances(ctx, quote.SenderAccountID, source, quote.ReceiverAccountID, target)
+	if err != nil {
+		s.logger.Error("Failed to lock account balances", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	senderCurrentBalance, err = s.availableBalance(ctx, quote.SenderAccountID, senderCurrentBalance)
+	if err != nil {
+		return response.TransferResponse{}, err
+	}
+
+	if senderCurrentBalance.Amount < quote.SourceAmount.Amount {
+		s.logger.Error("Insufficient funds in the sender's account")
+		return response.TransferResponse{}, errors.New("insufficient funds in the sender's account")
+	}
+
+	if err := s.enforcePolicy(ctx, quote.SenderAccountID, quote.SourceAmount, senderCurrentBalance, factor); err != nil {
+		return response.TransferResponse{}, err
+	}
+
+	assessment, err := s.assessRisk(ctx, quote.UserID, quote.SenderAccountID, quote.ReceiverAccountID, quote.SourceAmount, senderCurrentBalance)
+	if err != nil {
+		return response.TransferResponse{}, err
+	}
+
+	payee, err := s.payee(assessment, quote.ReceiverAccountID, target)
+	if err != nil {
+		return response.TransferResponse{}, err
+	}
+
+	entry := &entity.JournalEntry{
+		Description: &description,
+		FX: &entity.FXConversion{
+			QuoteID: quote.FXQuoteID,
+			Rate:    quote.Rate,
+			MidRate: quote.MidRate,
+			Spread:  quote.Spread,
+		},
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: quote.SenderAccountID, Amount: quote.SourceAmount.Neg()},
+			{FinancialAccountID: sourceHouse, Amount: quote.SourceAmount},
+			{FinancialAccountID: targetHouse, Amount: quote.TargetAmount.Neg()},
+			{FinancialAccountID: payee, Amount: quote.TargetAmount},
+		},
+	}
+
+	if err := s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	s.logger.Info("Posted cross-currency journal entry", zap.Int("TransactionGroupID", entry.JournalEntryID), zap.Int("QuoteID", quote.FXQuoteID))
+
+	senderTx := &entity.AccountTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialAccountID: quote.SenderAccountID,
+		Amount:             quote.SourceAmount.Neg(),
+		Balance:            entry.PostingFor(quote.SenderAccountID).BalanceAfter,
+		Description:        &description,
+		Status:             assessment.Decision,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if err := s.accountTransactionRepo.Insert(ctx, senderTx); err != nil {
+		s.logger.Error("Failed to insert sender's transaction record", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	receiverBalance := receiverCurrentBalance
+	if !assessment.IsHeld() {
+		receiverBalance = entry.PostingFor(quote.ReceiverAccountID).BalanceAfter
+	}
+
+	receiverTx := &entity.AccountTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialAccountID: quote.ReceiverAccountID,
+		Amount:             quote.TargetAmount,
+		Balance:            receiverBalance,
+		Description:        &description,
+		Status:             assessment.Decision,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if err := s.accountTransactionRepo.Insert(ctx, receiverTx); err != nil {
+		s.logger.Error("Failed to insert receiver's transaction record", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	assessment.TransactionGroupID = entry.JournalEntryID
+	if err := s.riskService.Record(ctx, assessment); err != nil {
+		return response.TransferResponse{}, err
+	}
+
+	if err := s.fxQuoteRepo.MarkExecuted(ctx, quote.FXQuoteID, entry.JournalEntryID); err != nil {
+		s.logger.Error("Failed to mark the quote as executed", zap.Error(err))
+		return response.TransferResponse{}, err
+	}
+
+	transferQuote := toTransferQuote(quote)
+
+	return response.TransferResponse{
+		SenderTx:   *senderTx,
+		ReceiverTx: *receiverTx,
+		Quote:      &transferQuote,
+	}, nil
+}
+
+func (s *Service) houseAccount(code enum.CurrencyCode) (int, bool) {
+	for _, house := range s.fxCfg.HouseAccounts {
+		if enum.CurrencyCode(house.Currency) == code {
+			return house.AccountID, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Service) quoteTTL() time.Duration {
+	if s.fxCfg.QuoteTTL <= 0 {
+		return defaultQuoteTTL
+	}
+	return s.fxCfg.QuoteTTL
+}
+
+func toTransferQuote(quote *entity.FXQuote) response.TransferQuote {
+	return response.TransferQuote{
+		QuoteID:      quote.FXQuoteID,
+		SourceAmount: quote.SourceAmount,
+		TargetAmount: quote.TargetAmount,
+		MidRate:      quote.MidRate,
+		Rate:         quote.Rate,
+		Spread:       quote.Spread,
+		ExpiresAt:    quote.ExpiresAt,
+	}
+}
@@ -0,0 +1,229 @@
+package accounttransaction
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	usdHouse = 901
+	eurHouse = 902
+)
+
+// 0.92 EUR per USD, less a 50 bps spread.
+const (
+	midRate   entity.Rate = 920000
+	quoteRate entity.Rate = 915400
+)
+
+func eur(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.EUR)
+}
+
+func setupFX() (*Service, *protocol.MockAccountTransactionRepo, *protocol.MockLedgerRepo, *protocol.MockFinancialAccountService, *protocol.MockCurrencyService, *protocol.MockFXQuoteRepo) {
+	service, mockRepo, mockLedger, mockAccountService := setup()
+	mockCurrency := new(protocol.MockCurrencyService)
+	mockQuoteRepo := new(protocol.MockFXQuoteRepo)
+
+	service.fxCfg = config.FX{
+		QuoteTTL:  30 * time.Second,
+		SpreadBps: 50,
+		HouseAccounts: []config.FXHouseAccount{
+			{Currency: "USD", AccountID: usdHouse},
+			{Currency: "EUR", AccountID: eurHouse},
+		},
+	}
+	service.currencyService = mockCurrency
+	service.fxQuoteRepo = mockQuoteRepo
+
+	return service, mockRepo, mockLedger, mockAccountService, mockCurrency, mockQuoteRepo
+}
+
+func openQuote() *entity.FXQuote {
+	return &entity.FXQuote{
+		FXQuoteID:         3,
+		UserID:            5,
+		SenderAccountID:   1,
+		ReceiverAccountID: 2,
+		SourceAmount:      usd(10000),
+		TargetAmount:      eur(9154),
+		MidRate:           midRate,
+		Rate:              quoteRate,
+		SpreadBps:         50,
+		Spread:            eur(46),
+		Status:            enum.FXQuoteOpen,
+		ExpiresAt:         time.Now().Add(time.Minute),
+	}
+}
+
+// postFXEntry simulates the ledger posting a cross-currency entry.
+func postFXEntry(journalEntryID int, posted **entity.JournalEntry) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		entry := args.Get(1).(*entity.JournalEntry)
+		entry.JournalEntryID = journalEntryID
+		for _, p := range entry.Postings {
+			p.JournalEntryID = journalEntryID
+			p.BalanceAfter = p.Amount
+		}
+		*posted = entry
+	}
+}
+
+func TestNewRejectsSpreadOutOfRange(t *testing.T) {
+	for _, spreadBps := range []int64{-1, 10000, 25000} {
+		_, err := New(config.JWT{}, config.FX{SpreadBps: spreadBps}, config.Risk{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		assert.Error(t, err, "spread %d", spreadBps)
+	}
+
+	_, err := New(config.JWT{}, config.FX{SpreadBps: 9999}, config.Risk{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+}
+
+func TestQuoteTransfer(t *testing.T) {
+	service, _, _, mockAccountService, mockCurrency, mockQuoteRepo := setupFX()
+	ctx := context.Background()
+
+	mockAccountService.On("GetAccountCurrency", ctx, 1).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+	mockAccountService.On("GetAccountCurrency", ctx, 2).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+	mockCurrency.On("GetExchangeRate", ctx, enum.USD, enum.EUR).Return(midRate, nil)
+	mockQuoteRepo.On("Insert", ctx, mock.AnythingOfType("*entity.FXQuote")).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.FXQuote).FXQuoteID = 3
+	}).Return(nil)
+
+	quote, err := service.QuoteTransfer(ctx, request.QuoteTransferRequest{
+		UserID:            5,
+		SenderAccountID:   1,
+		ReceiverAccountID: 2,
+		Amount:            usd(10000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, quote.QuoteID)
+	assert.Equal(t, quoteRate, quote.Rate)
+	assert.Equal(t, eur(9154), quote.TargetAmount)
+	assert.Equal(t, eur(46), quote.Spread)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), quote.ExpiresAt, time.Second)
+
+	mockQuoteRepo.AssertCalled(t, "Insert", ctx, mock.MatchedBy(func(q *entity.FXQuote) bool {
+		return q.UserID == 5 && q.Status == enum.FXQuoteOpen && q.SpreadBps == 50
+	}))
+}
+
+func TestQuoteTransferRejectsSameCurrency(t *testing.T) {
+	service, _, _, mockAccountService, _, mockQuoteRepo := setupFX()
+	ctx := context.Background()
+
+	mockAccountService.On("GetAccountCurrency", ctx, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+
+	_, err := service.QuoteTransfer(ctx, request.QuoteTransferRequest{SenderAccountID: 1, ReceiverAccountID: 2, Amount: usd(10000)})
+	assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest))
+	mockQuoteRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestExecuteQuote(t *testing.T) {
+	service, mockRepo, mockLedger, mockAccountService, _, mockQuoteRepo := setupFX()
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+	var posted *entity.JournalEntry
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockQuoteRepo.On("GetForUpdate", txCtx, 3).Return(openQuote(), nil)
+	mockAccountService.On("GetAccountStatus", txCtx, mock.Anything).Return(enum.Verified, nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(20000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.EUR).Return(eur(0), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(postFXEntry(9, &posted)).Return(nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockQuoteRepo.On("MarkExecuted", txCtx, 3, 9).Return(nil)
+	mockRepo.On("CommitTx", txCtx).Return(nil)
+
+	res, err := service.ExecuteQuote(ctx, request.ExecuteQuoteRequest{UserID: 5, QuoteID: 3})
+	require.NoError(t, err)
+
+	assert.True(t, posted.IsBalanced())
+	assert.Len(t, posted.Postings, 4)
+	assert.Equal(t, usd(10000), posted.PostingFor(usdHouse).Amount)
+	assert.Equal(t, eur(-9154), posted.PostingFor(eurHouse).Amount)
+	assert.Equal(t, &entity.FXConversion{QuoteID: 3, Rate: quoteRate, MidRate: midRate, Spread: eur(46)}, posted.FX)
+
+	assert.Equal(t, usd(-10000), res.SenderTx.Amount)
+	assert.Equal(t, eur(9154), res.ReceiverTx.Amount)
+	assert.Equal(t, 3, res.Quote.QuoteID)
+	mockRepo.AssertNotCalled(t, "RollbackTx", mock.Anything)
+}
+
+func TestExecuteQuoteRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		quote  func(q *entity.FXQuote)
+		status int
+	}{
+		{name: "another user's quote", quote: func(q *entity.FXQuote) { q.UserID = 6 }, status: http.StatusNotFound},
+		{name: "executed quote", quote: func(q *entity.FXQuote) { q.Status = enum.FXQuoteExecuted }, status: http.StatusConflict},
+		{name: "expired quote", quote: func(q *entity.FXQuote) { q.ExpiresAt = time.Now().Add(-time.Second) }, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockRepo, mockLedger, _, _, mockQuoteRepo := setupFX()
+			ctx := context.Background()
+			txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+			quote := openQuote()
+			tt.quote(quote)
+
+			mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+			mockRepo.On("RollbackTx", txCtx).Return(nil)
+			mockQuoteRepo.On("GetForUpdate", txCtx, 3).Return(quote, nil)
+
+			_, err := service.ExecuteQuote(ctx, request.ExecuteQuoteRequest{UserID: 5, QuoteID: 3})
+			assert.True(t, derror.IsHTTPError(err, tt.status))
+			mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+		})
+	}
+}
+
+func TestTransferConvertsBetweenCurrencies(t *testing.T) {
+	service, mockRepo, mockLedger, mockAccountService, mockCurrency, mockQuoteRepo := setupFX()
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+
+	var posted *entity.JournalEntry
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockAccountService.On("GetAccountStatus", txCtx, mock.Anything).Return(enum.Verified, nil)
+	mockAccountService.On("GetAccountCurrency", txCtx, 1).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil)
+	mockAccountService.On("GetAccountCurrency", txCtx, 2).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+	mockCurrency.On("GetExchangeRate", txCtx, enum.USD, enum.EUR).Return(midRate, nil)
+	mockQuoteRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.FXQuote")).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.FXQuote).FXQuoteID = 4
+	}).Return(nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(20000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.EUR).Return(eur(0), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(postFXEntry(9, &posted)).Return(nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockQuoteRepo.On("MarkExecuted", txCtx, 4, 9).Return(nil)
+	mockRepo.On("CommitTx", txCtx).Return(nil)
+
+	res, err := service.Transfer(ctx, request.TransferRequest{
+		UserID:            5,
+		SenderAccountID:   1,
+		ReceiverAccountID: 2,
+		Amount:            usd(10000),
+	})
+	require.NoError(t, err)
+	assert.True(t, posted.IsBalanced())
+	assert.Equal(t, 4, posted.FX.QuoteID)
+	assert.Equal(t, eur(9154), res.ReceiverTx.Amount)
+}
@@ -0,0 +1,326 @@
+package accounttransaction
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+// ReverseTransactionGroup posts a compensating journal entry for a transaction group. A
+// partial reversal refunds part of the debit and can be repeated until the whole amount
+// has been returned; the original legs are then marked reversed.
+func (s *Service) ReverseTransactionGroup(ctx context.Context, req request.ReverseTransactionGroup) (res response.ReversalResponse, err error) {
+	s.logger.Info("Starting to reverse transaction group", zap.Int("TransactionGroupID", req.TransactionGroupID))
+
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid reversal request", zap.Error(err))
+		return res, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.accountTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return res, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.accountTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Reversal of transaction group %d", req.TransactionGroupID)
+	}
+
+	res, err = s.reverseGroup(ctx, req.TransactionGroupID, req.Amount, description)
+	if err != nil {
+		return res, err
+	}
+
+	if err = s.accountTransactionRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return res, err
+	}
+
+	s.logger.Info("Reversed transaction group",
+		zap.Int("TransactionGroupID", req.TransactionGroupID),
+		zap.Int("JournalEntryID", res.JournalEntryID),
+		zap.String("Remaining", res.Remaining.String()))
+
+	return res, nil
+}
+
+// reverseGroup reverses amount of the group's debit, or all that is left of it when amount
+// is nil, inside the caller's database transaction.
+func (s *Service) reverseGroup(ctx context.Context, groupID int, amount *entity.Money, description string) (res response.ReversalResponse, err error) {
+	legs, err := s.accountTransactionRepo.ListByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list the legs of the transaction group", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return res, err
+	}
+
+	if len(legs) == 0 {
+		return res, derror.NewNotFoundError("transaction group %d not found", groupID)
+	}
+
+	heldCase, err := s.reviewCaseRepo.GetByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get the review case of the transaction group", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return res, err
+	}
+
+	if heldCase != nil && heldCase.Status == enum.ReviewOpen {
+		return res, derror.NewConflictError("transaction group %d is waiting for review and cannot be reversed until its case is resolved", groupID)
+	}
+
+	var debit *entity.AccountTransaction
+	for _, leg := range legs {
+		switch {
+		case leg.ReversesTransactionID != nil:
+			return res, derror.NewConflictError("transaction group %d is itself a reversal", groupID)
+		case leg.IsHeld():
+			return res, derror.NewConflictError("transaction group %d is held for review and is resolved through its review case", groupID)
+		case !isReversible(leg.Status):
+			return res, derror.NewConflictError("transaction %d cannot be reversed in its current status", leg.TransactionID)
+		}
+
+		if debit == nil && leg.Amount.IsNegative() {
+			debit = leg
+		}
+	}
+
+	if debit == nil {
+		return res, derror.NewValidationError("transaction group %d has no debit to reverse", groupID)
+	}
+
+	original, err := s.ledgerRepo.GetJournalEntry(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get the journal entry", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return res, err
+	}
+
+	if original == nil {
+		s.logger.Error("The transaction group has no journal entry", zap.Int("TransactionGroupID", groupID))
+		return res, derror.NewInternalSystemError()
+	}
+
+	postings, err := s.settledPostings(ctx, original, heldCase)
+	if err != nil {
+		return res, err
+	}
+
+	// Concurrent reversals of the same group wait on these locks, so together they can
+	// never return more than the original amount.
+	balances, err := s.lockLegBalances(ctx, legs)
+	if err != nil {
+		s.logger.Error("Failed to lock account balances", zap.Error(err))
+		return res, err
+	}
+
+	whole := debit.Amount.Neg()
+	reversed, err := s.ledgerRepo.ReversedAmount(ctx, groupID, debit.FinancialAccountID, whole.Currency)
+	if err != nil {
+		s.logger.Error("Failed to sum earlier reversals", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return res, err
+	}
+
+	remaining, err := whole.Sub(reversed)
+	if err != nil {
+		return res, err
+	}
+
+	part := remaining
+	if amount != nil {
+		if amount.Currency != whole.Currency {
+			return res, derror.NewBadRequestError("the reversal must be in %s, the currency of the debited account", whole.Currency)
+		}
+
+		if amount.Amount > remaining.Amount {
+			return res, derror.NewValidationError("the reversal exceeds the %s %s left to reverse", remaining.String(), remaining.Currency)
+		}
+		part = *amount
+	}
+
+	if !part.IsPositive() {
+		return res, derror.NewConflictError("transaction group %d is already fully reversed", groupID)
+	}
+	total := reversed.Amount + part.Amount
+
+	entry := &entity.JournalEntry{Description: &description, ReversesEntryID: &groupID}
+	for _, p := range postings {
+		// Every posting is reversed up to the same share of the original, less what earlier
+		// reversals returned, so a refund split in several parts leaves no rounding residue.
+		target, err := p.Amount.Neg().Scale(total, whole.Amount)
+		if err != nil {
+			return res, err
+		}
+
+		done, err := s.ledgerRepo.ReversedAmount(ctx, groupID, p.FinancialAccountID, p.Amount.Currency)
+		if err != nil {
+			s.logger.Error("Failed to sum earlier reversals", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+			return res, err
+		}
+
+		posting, err := target.Sub(done)
+		if err != nil {
+			return res, err
+		}
+
+		if !posting.IsZero() {
+			entry.Postings = append(entry.Postings, &entity.LedgerPosting{FinancialAccountID: p.FinancialAccountID, Amount: posting})
+		}
+	}
+
+	// The legs that were credited give the money back and must still have it
+	for _, leg := range legs {
+		posting := entry.PostingFor(leg.FinancialAccountID)
+		if posting != nil && balances[leg.FinancialAccountID].Amount+posting.Amount.Amount < 0 {
+			return res, derror.NewValidationError("account %d has insufficient funds for the reversal", leg.FinancialAccountID)
+		}
+	}
+
+	if err := s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the reversal entry", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return res, err
+	}
+
+	status := enum.PartiallyReversed
+	if total == whole.Amount {
+		status = enum.Reversed
+	}
+
+	for _, leg := range legs {
+		posting := entry.PostingFor(leg.FinancialAccountID)
+		if posting == nil {
+			continue
+		}
+
+		reversal := &entity.AccountTransaction{
+			TransactionGroupID:    entry.JournalEntryID,
+			FinancialAccountID:    leg.FinancialAccountID,
+			Amount:                posting.Amount,
+			Balance:               posting.BalanceAfter,
+			Description:           &description,
+			Status:                enum.Completed,
+			ReversesTransactionID: &leg.TransactionID,
+			CreatedAt:             time.Now(),
+			UpdatedAt:             time.Now(),
+		}
+
+		if err := s.accountTransactionRepo.Insert(ctx, reversal); err != nil {
+			s.logger.Error("Failed to insert the reversal transaction", zap.Error(err), zap.Int("TransactionID", leg.TransactionID))
+			return res, err
+		}
+
+		leg.Status = status
+		if err := s.accountTransactionRepo.Update(ctx, leg); err != nil {
+			s.logger.Error("Failed to update the reversed transaction", zap.Error(err), zap.Int("TransactionID", leg.TransactionID))
+			return res, err
+		}
+
+		res.ReversalTxs = append(res.ReversalTxs, reversal)
+		res.OriginalTxs = append(res.OriginalTxs, leg)
+	}
+
+	res.JournalEntryID = entry.JournalEntryID
+	res.Remaining = entity.NewMoney(whole.Amount-total, whole.Currency)
+
+	return res, nil
+}
+
+// settledPostings returns the postings of the group's entry as they finally settled. A
+// transfer released through review credited the suspense account, and the release entry
+// later moved the money on to the receiver; the suspense posting is replaced by one on the
+// receiver's account so the reversal takes the money back from whoever holds it.
+func (s *Service) settledPostings(ctx context.Context, original *entity.JournalEntry, heldCase *entity.ReviewCase) ([]*entity.LedgerPosting, error) {
+	if heldCase == nil || heldCase.Status != enum.ReviewApproved {
+		return original.Postings, nil
+	}
+
+	if heldCase.ResolutionEntryID == nil {
+		s.logger.Error("The approved review case has no release entry", zap.Int("ReviewCaseID", heldCase.ReviewCaseID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	release, err := s.ledgerRepo.GetJournalEntry(ctx, *heldCase.ResolutionEntryID)
+	if err != nil {
+		s.logger.Error("Failed to get the release entry", zap.Error(err), zap.Int("JournalEntryID", *heldCase.ResolutionEntryID))
+		return nil, err
+	}
+
+	if release == nil {
+		s.logger.Error("The release entry of the review case is missing", zap.Int("ReviewCaseID", heldCase.ReviewCaseID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	// The release debits the suspense account and credits the receiver in the same currency
+	payees := make(map[int]int)
+	for _, debit := range release.Postings {
+		if !debit.Amount.IsNegative() {
+			continue
+		}
+
+		for _, credit := range release.Postings {
+			if credit.Amount.IsPositive() && credit.Amount.Currency == debit.Amount.Currency {
+				payees[debit.FinancialAccountID] = credit.FinancialAccountID
+			}
+		}
+	}
+
+	settled := make([]*entity.LedgerPosting, 0, len(original.Postings))
+	for _, p := range original.Postings {
+		posting := *p
+		if payee, ok := payees[p.FinancialAccountID]; ok {
+			posting.FinancialAccountID = payee
+		}
+		settled = append(settled, &posting)
+	}
+
+	return settled, nil
+}
+
+// lockLegBalances locks the balance of every leg's account, in ascending account order
+// like lockBalances, and returns them by account.
+func (s *Service) lockLegBalances(ctx context.Context, legs []*entity.AccountTransaction) (map[int]entity.Money, error) {
+	sorted := make([]*entity.AccountTransaction, len(legs))
+	copy(sorted, legs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FinancialAccountID < sorted[j].FinancialAccountID
+	})
+
+	balances := make(map[int]entity.Money, len(legs))
+	for _, leg := range sorted {
+		if _, ok := balances[leg.FinancialAccountID]; ok {
+			continue
+		}
+
+		balance, err := s.ledgerRepo.LockBalance(ctx, leg.FinancialAccountID, leg.Amount.Currency)
+		if err != nil {
+			return nil, err
+		}
+		balances[leg.FinancialAccountID] = balance
+	}
+
+	return balances, nil
+}
+
+// isReversible reports whether a leg in the given status still has money to give back.
+// Held legs are left to the review queue.
+func isReversible(status enum.AccountTransactionStatus) bool {
+	switch status {
+	case enum.Peniding, enum.Completed, enum.PartiallyReversed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,294 @@
+package accounttransaction
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// completedLegs are the account transactions of journalEntry(7).
+func completedLegs() []*entity.AccountTransaction {
+	return []*entity.AccountTransaction{
+		{TransactionID: 1, TransactionGroupID: 7, FinancialAccountID: 1, Amount: usd(-1000), Balance: usd(9000), Status: enum.Completed},
+		{TransactionID: 2, TransactionGroupID: 7, FinancialAccountID: 2, Amount: usd(1000), Balance: usd(1000), Status: enum.Completed},
+	}
+}
+
+// expectReversalTx wires a unit of work that must either commit or roll back.
+func expectReversalTx(mockRepo *protocol.MockAccountTransactionRepo) context.Context {
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+	mockRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	mockRepo.On("CommitTx", txCtx).Return(nil)
+	mockRepo.On("RollbackTx", txCtx).Return(nil)
+	return txCtx
+}
+
+func TestReverseTransactionGroup(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	txCtx := expectReversalTx(mockRepo)
+
+	legs := completedLegs()
+	var posted *entity.JournalEntry
+	var inserted []*entity.AccountTransaction
+
+	mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Run(func(args mock.Arguments) {
+		inserted = append(inserted, args.Get(1).(*entity.AccountTransaction))
+	}).Return(nil)
+	mockRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockLedger.On("GetJournalEntry", txCtx, 7).Return(journalEntry(7), nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(9000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(1000), nil)
+	mockLedger.On("ReversedAmount", txCtx, 7, mock.Anything, enum.USD).Return(usd(0), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		postEntry(8)(args)
+		posted = args.Get(1).(*entity.JournalEntry)
+	}).Return(nil)
+
+	res, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7})
+	require.NoError(t, err)
+
+	assert.True(t, posted.IsBalanced())
+	assert.Equal(t, 7, *posted.ReversesEntryID)
+	assert.Equal(t, usd(1000), posted.PostingFor(1).Amount)
+	assert.Equal(t, usd(-1000), posted.PostingFor(2).Amount)
+
+	require.Len(t, inserted, 2)
+	for i, reversal := range inserted {
+		assert.Equal(t, 8, reversal.TransactionGroupID)
+		assert.Equal(t, legs[i].TransactionID, *reversal.ReversesTransactionID)
+		assert.Equal(t, legs[i].Amount.Neg(), reversal.Amount)
+		assert.Equal(t, enum.Completed, reversal.Status)
+		assert.Equal(t, enum.Reversed, legs[i].Status)
+	}
+
+	assert.Equal(t, 8, res.JournalEntryID)
+	assert.Equal(t, usd(0), res.Remaining)
+	mockRepo.AssertNotCalled(t, "RollbackTx", mock.Anything)
+}
+
+func TestReverseTransactionGroupPartially(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	txCtx := expectReversalTx(mockRepo)
+
+	legs := completedLegs()
+	var posted *entity.JournalEntry
+
+	mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockLedger.On("GetJournalEntry", txCtx, 7).Return(journalEntry(7), nil)
+	mockLedger.On("LockBalance", txCtx, mock.Anything, enum.USD).Return(usd(5000), nil)
+	// An earlier refund already returned 2.00 of the 10.00
+	mockLedger.On("ReversedAmount", txCtx, 7, 1, enum.USD).Return(usd(200), nil)
+	mockLedger.On("ReversedAmount", txCtx, 7, 2, enum.USD).Return(usd(-200), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		postEntry(9)(args)
+		posted = args.Get(1).(*entity.JournalEntry)
+	}).Return(nil)
+
+	refund := usd(300)
+	res, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7, Amount: &refund})
+	require.NoError(t, err)
+
+	assert.Equal(t, usd(300), posted.PostingFor(1).Amount)
+	assert.Equal(t, usd(-300), posted.PostingFor(2).Amount)
+	assert.Equal(t, enum.PartiallyReversed, legs[0].Status)
+	assert.Equal(t, enum.PartiallyReversed, legs[1].Status)
+	assert.Equal(t, usd(500), res.Remaining)
+}
+
+func TestReverseTransactionGroupLeavesNoResidueAcrossCurrencies(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	txCtx := expectReversalTx(mockRepo)
+
+	legs := []*entity.AccountTransaction{
+		{TransactionID: 1, TransactionGroupID: 7, FinancialAccountID: 1, Amount: usd(-10000), Status: enum.PartiallyReversed},
+		{TransactionID: 2, TransactionGroupID: 7, FinancialAccountID: 2, Amount: eur(9154), Status: enum.PartiallyReversed},
+	}
+	original := &entity.JournalEntry{
+		JournalEntryID: 7,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: 1, Amount: usd(-10000)},
+			{FinancialAccountID: usdHouse, Amount: usd(10000)},
+			{FinancialAccountID: eurHouse, Amount: eur(-9154)},
+			{FinancialAccountID: 2, Amount: eur(9154)},
+		},
+	}
+
+	var posted *entity.JournalEntry
+	mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockLedger.On("GetJournalEntry", txCtx, 7).Return(original, nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(50000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.EUR).Return(eur(50000), nil)
+	// A third was refunded before: 33.33 USD, which rounded to 30.51 EUR
+	mockLedger.On("ReversedAmount", txCtx, 7, 1, enum.USD).Return(usd(3333), nil)
+	mockLedger.On("ReversedAmount", txCtx, 7, usdHouse, enum.USD).Return(usd(-3333), nil)
+	mockLedger.On("ReversedAmount", txCtx, 7, eurHouse, enum.EUR).Return(eur(3051), nil)
+	mockLedger.On("ReversedAmount", txCtx, 7, 2, enum.EUR).Return(eur(-3051), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(postFXEntry(9, &posted)).Return(nil)
+
+	_, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7})
+	require.NoError(t, err)
+
+	assert.True(t, posted.IsBalanced())
+	assert.Equal(t, usd(6667), posted.PostingFor(1).Amount)
+	assert.Equal(t, eur(-6103), posted.PostingFor(2).Amount)
+	assert.Equal(t, eur(6103), posted.PostingFor(eurHouse).Amount)
+	assert.Equal(t, enum.Reversed, legs[0].Status)
+	assert.Equal(t, enum.Reversed, legs[1].Status)
+}
+
+// releasedFromReview wires a transfer of 10.00 from account 1 to account 2 that was held in
+// the suspense account and then released to the receiver by entry 8.
+func releasedFromReview(service *Service, mockLedger *protocol.MockLedgerRepo, txCtx context.Context) {
+	const suspense = 900
+
+	resolution := 8
+	reviewCases := new(protocol.MockReviewCaseRepo)
+	reviewCases.On("GetByTransactionGroupID", txCtx, 7).Return(&entity.ReviewCase{
+		ReviewCaseID:       3,
+		TransactionGroupID: 7,
+		Status:             enum.ReviewApproved,
+		ResolutionEntryID:  &resolution,
+	}, nil)
+	service.reviewCaseRepo = reviewCases
+
+	mockLedger.On("GetJournalEntry", txCtx, 7).Return(&entity.JournalEntry{
+		JournalEntryID: 7,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: 1, Amount: usd(-1000)},
+			{FinancialAccountID: suspense, Amount: usd(1000)},
+		},
+	}, nil)
+	mockLedger.On("GetJournalEntry", txCtx, 8).Return(&entity.JournalEntry{
+		JournalEntryID: 8,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: suspense, Amount: usd(-1000)},
+			{FinancialAccountID: 2, Amount: usd(1000)},
+		},
+	}, nil)
+	mockLedger.On("ReversedAmount", txCtx, 7, mock.Anything, enum.USD).Return(usd(0), nil)
+}
+
+func TestReverseTransactionGroupReleasedFromReview(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	txCtx := expectReversalTx(mockRepo)
+	releasedFromReview(service, mockLedger, txCtx)
+
+	legs := completedLegs()
+	var posted *entity.JournalEntry
+	var inserted []*entity.AccountTransaction
+
+	mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+	mockRepo.On("Insert", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Run(func(args mock.Arguments) {
+		inserted = append(inserted, args.Get(1).(*entity.AccountTransaction))
+	}).Return(nil)
+	mockRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(9000), nil)
+	mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(1000), nil)
+	mockLedger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		postEntry(9)(args)
+		posted = args.Get(1).(*entity.JournalEntry)
+	}).Return(nil)
+
+	_, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7})
+	require.NoError(t, err)
+
+	// The money comes back from the receiver; the suspense account is left alone
+	assert.True(t, posted.IsBalanced())
+	require.Len(t, posted.Postings, 2)
+	assert.Equal(t, usd(1000), posted.PostingFor(1).Amount)
+	assert.Equal(t, usd(-1000), posted.PostingFor(2).Amount)
+	assert.Nil(t, posted.PostingFor(900))
+
+	require.Len(t, inserted, 2)
+	assert.Equal(t, 2, inserted[1].FinancialAccountID)
+	assert.Equal(t, usd(-1000), inserted[1].Amount)
+	assert.Equal(t, enum.Reversed, legs[1].Status)
+}
+
+func TestReverseTransactionGroupReleasedFromReviewChecksReceiverFunds(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	txCtx := expectReversalTx(mockRepo)
+	releasedFromReview(service, mockLedger, txCtx)
+
+	mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(completedLegs(), nil)
+	mockLedger.On("LockBalance", txCtx, 1, enum.USD).Return(usd(9000), nil)
+	// The receiver already spent 6.00 of the 10.00
+	mockLedger.On("LockBalance", txCtx, 2, enum.USD).Return(usd(400), nil)
+
+	_, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7})
+	assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+	mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+}
+
+func TestReverseTransactionGroupWaitingForReview(t *testing.T) {
+	service, mockRepo, mockLedger, _ := setup()
+	txCtx := expectReversalTx(mockRepo)
+
+	reviewCases := new(protocol.MockReviewCaseRepo)
+	reviewCases.On("GetByTransactionGroupID", txCtx, 7).Return(&entity.ReviewCase{ReviewCaseID: 3, TransactionGroupID: 7, Status: enum.ReviewOpen}, nil)
+	service.reviewCaseRepo = reviewCases
+	mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(completedLegs(), nil)
+
+	_, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7})
+	assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+	mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+}
+
+func TestReverseTransactionGroupRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   *entity.Money
+		setup    func(legs []*entity.AccountTransaction)
+		reversed int64
+		balance  int64
+		status   int
+	}{
+		{name: "held for review", setup: func(legs []*entity.AccountTransaction) { legs[0].Status = enum.PendingReview }, status: http.StatusConflict},
+		{name: "already reversed", setup: func(legs []*entity.AccountTransaction) { legs[0].Status = enum.Reversed }, status: http.StatusConflict},
+		{name: "a reversal itself", setup: func(legs []*entity.AccountTransaction) { legs[0].ReversesTransactionID = new(int) }, status: http.StatusConflict},
+		{name: "more than is left", amount: &entity.Money{Amount: 900, Currency: enum.USD}, reversed: 200, balance: 5000, status: http.StatusUnprocessableEntity},
+		{name: "other currency", amount: &entity.Money{Amount: 100, Currency: enum.EUR}, balance: 5000, status: http.StatusBadRequest},
+		{name: "receiver spent the money", balance: 400, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockRepo, mockLedger, _ := setup()
+			txCtx := expectReversalTx(mockRepo)
+
+			legs := completedLegs()
+			if tt.setup != nil {
+				tt.setup(legs)
+			}
+
+			mockRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+			mockLedger.On("GetJournalEntry", txCtx, 7).Return(journalEntry(7), nil).Maybe()
+			mockLedger.On("LockBalance", txCtx, mock.Anything, enum.USD).Return(usd(tt.balance), nil).Maybe()
+			mockLedger.On("ReversedAmount", txCtx, 7, 1, enum.USD).Return(usd(tt.reversed), nil).Maybe()
+			mockLedger.On("ReversedAmount", txCtx, 7, 2, enum.USD).Return(usd(-tt.reversed), nil).Maybe()
+
+			_, err := service.ReverseTransactionGroup(context.Background(), request.ReverseTransactionGroup{TransactionGroupID: 7, Amount: tt.amount})
+			assert.True(t, derror.IsHTTPError(err, tt.status), "got %v", err)
+			mockLedger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			mockRepo.AssertCalled(t, "RollbackTx", txCtx)
+			mockRepo.AssertNotCalled(t, "CommitTx", mock.Anything)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package accounttransaction
 
 import (
+	"fmt"
+
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"go.uber.org/zap"
@@ -8,25 +10,60 @@ import (
 
 type Service struct {
 	cfg                     config.JWT
+	fxCfg                   config.FX
+	riskCfg                 config.Risk
 	logger                  *zap.SugaredLogger
 	tokenGen                protocol.TokenGenerator
 	financialAccountService protocol.FinancialAccount
 	accountTransactionRepo  protocol.AccountTransactionRepository
+	ledgerRepo              protocol.LedgerRepository
+	idempotencyService      protocol.Idempotency
+	currencyService         protocol.Currency
+	fxQuoteRepo             protocol.FXQuoteRepository
+	accountRulesService     protocol.AccountRules
+	riskService             protocol.Risk
+	twoFactorService        protocol.TwoFactor
+	reviewCaseRepo          protocol.ReviewCaseRepository
 }
 
+// New refuses a spread outside [0, 10000) basis points, which would quote a negative or
+// zero rate, or one better than the market.
 func New(
 	cfg config.JWT,
+	fxCfg config.FX,
+	riskCfg config.Risk,
 	logger *zap.SugaredLogger,
 	tokenGen protocol.TokenGenerator,
 	financialAccountService protocol.FinancialAccount,
 	accountTransactionRepo protocol.AccountTransactionRepository,
+	ledgerRepo protocol.LedgerRepository,
+	idempotencyService protocol.Idempotency,
+	currencyService protocol.Currency,
+	fxQuoteRepo protocol.FXQuoteRepository,
+	accountRulesService protocol.AccountRules,
+	riskService protocol.Risk,
+	twoFactorService protocol.TwoFactor,
+	reviewCaseRepo protocol.ReviewCaseRepository,
+) (*Service, error) {
+	if fxCfg.SpreadBps < 0 || fxCfg.SpreadBps >= bpsScale {
+		return nil, fmt.Errorf("fx spread: want 0 to %d basis points, got %d", bpsScale-1, fxCfg.SpreadBps)
+	}
 
-) *Service {
 	return &Service{
 		cfg:                     cfg,
+		fxCfg:                   fxCfg,
+		riskCfg:                 riskCfg,
 		logger:                  logger,
 		tokenGen:                tokenGen,
 		financialAccountService: financialAccountService,
 		accountTransactionRepo:  accountTransactionRepo,
-	}
+		ledgerRepo:              ledgerRepo,
+		idempotencyService:      idempotencyService,
+		currencyService:         currencyService,
+		fxQuoteRepo:             fxQuoteRepo,
+		accountRulesService:     accountRulesService,
+		riskService:             riskService,
+		twoFactorService:        twoFactorService,
+		reviewCaseRepo:          reviewCaseRepo,
+	}, nil
 }
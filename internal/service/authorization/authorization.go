@@ -0,0 +1,250 @@
+package authorization
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) AuthorizeAccount(ctx context.Context, claims *entity.JWTClaims, accountID int) error {
+	if claims.Admin {
+		return nil
+	}
+
+	return s.checkAccount(ctx, claims.UserID, accountID)
+}
+
+func (s *Service) AuthorizeCard(ctx context.Context, claims *entity.JWTClaims, cardID int) error {
+	if claims.Admin {
+		return nil
+	}
+
+	return s.checkCard(ctx, claims.UserID, cardID)
+}
+
+func (s *Service) AuthorizeAccountTransaction(ctx context.Context, claims *entity.JWTClaims, transactionID int) error {
+	if claims.Admin {
+		return nil
+	}
+
+	transaction, err := s.accountTransactionRepo.GetByID(ctx, int64(transactionID))
+	if err != nil {
+		s.logger.Error("Failed to get the account transaction", zap.Error(err), zap.Int("TransactionID", transactionID))
+		return derror.NewInternalSystemError()
+	}
+
+	if transaction == nil {
+		return derror.NewNotFoundError("transaction %d not found", transactionID)
+	}
+
+	return s.checkAccount(ctx, claims.UserID, transaction.FinancialAccountID)
+}
+
+func (s *Service) AuthorizeAccountTransactionGroup(ctx context.Context, claims *entity.JWTClaims, groupID int) error {
+	if claims.Admin {
+		return nil
+	}
+
+	transactions, err := s.accountTransactionRepo.ListByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list the account transaction group", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return derror.NewInternalSystemError()
+	}
+
+	if len(transactions) == 0 {
+		return derror.NewNotFoundError("transaction group %d not found", groupID)
+	}
+
+	accountIDs := make([]int, 0, len(transactions))
+	for _, transaction := range transactions {
+		accountIDs = append(accountIDs, transaction.FinancialAccountID)
+	}
+
+	return s.checkAnyAccount(ctx, claims.UserID, accountIDs, "transaction group %d does not belong to the user", groupID)
+}
+
+func (s *Service) AuthorizeCardTransaction(ctx context.Context, claims *entity.JWTClaims, transactionID int) error {
+	if claims.Admin {
+		return nil
+	}
+
+	transaction, err := s.cardTransactionRepo.GetByID(ctx, int64(transactionID))
+	if err != nil {
+		s.logger.Error("Failed to get the card transaction", zap.Error(err), zap.Int("TransactionID", transactionID))
+		return derror.NewInternalSystemError()
+	}
+
+	if transaction == nil {
+		return derror.NewNotFoundError("card transaction %d not found", transactionID)
+	}
+
+	return s.checkCard(ctx, claims.UserID, transaction.FinancialCardID)
+}
+
+func (s *Service) AuthorizeCardTransactionGroup(ctx context.Context, claims *entity.JWTClaims, groupID int) error {
+	if claims.Admin {
+		return nil
+	}
+
+	transactions, err := s.cardTransactionRepo.ListByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list the card transaction group", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return derror.NewInternalSystemError()
+	}
+
+	if len(transactions) == 0 {
+		return derror.NewNotFoundError("card transaction group %d not found", groupID)
+	}
+
+	accountIDs := make([]int, 0, len(transactions))
+	for _, transaction := range transactions {
+		card, err := s.getCard(ctx, transaction.FinancialCardID)
+		if err != nil {
+			return err
+		}
+		accountIDs = append(accountIDs, card.AccountID)
+	}
+
+	return s.checkAnyAccount(ctx, claims.UserID, accountIDs, "card transaction group %d does not belong to the user", groupID)
+}
+
+// OwnAccountTransactions keeps the legs of a group that post to the caller's accounts, so
+// neither party of a transfer sees the other's account or balance. Admins and staff allowed
+// to read any account keep every leg.
+func (s *Service) OwnAccountTransactions(ctx context.Context, claims *entity.JWTClaims, transactions []*entity.AccountTransaction) ([]*entity.AccountTransaction, error) {
+	if seesAll(claims) {
+		return transactions, nil
+	}
+
+	owned := make(map[int]bool)
+	visible := make([]*entity.AccountTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		ok, err := s.ownsAccount(ctx, claims.UserID, transaction.FinancialAccountID, owned)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			visible = append(visible, transaction)
+		}
+	}
+
+	return visible, nil
+}
+
+// OwnCardTransactions is OwnAccountTransactions for the legs of a card transaction group,
+// which are on the caller's cards when the account the card draws on is theirs.
+func (s *Service) OwnCardTransactions(ctx context.Context, claims *entity.JWTClaims, transactions []*entity.CardTransaction) ([]*entity.CardTransaction, error) {
+	if seesAll(claims) {
+		return transactions, nil
+	}
+
+	owned := make(map[int]bool)
+	visible := make([]*entity.CardTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		card, err := s.getCard(ctx, transaction.FinancialCardID)
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := s.ownsAccount(ctx, claims.UserID, card.AccountID, owned)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			visible = append(visible, transaction)
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *Service) checkAccount(ctx context.Context, userID, accountID int) error {
+	account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.UserID != userID {
+		return derror.NewForbiddenError("account %d does not belong to the user", accountID)
+	}
+
+	return nil
+}
+
+func (s *Service) checkCard(ctx context.Context, userID, cardID int) error {
+	card, err := s.getCard(ctx, cardID)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.financialAccountService.GetAccountByID(ctx, card.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if account.UserID != userID {
+		return derror.NewForbiddenError("card %d does not belong to the user", cardID)
+	}
+
+	return nil
+}
+
+func (s *Service) getCard(ctx context.Context, cardID int) (*entity.FinancialCard, error) {
+	card, err := s.financialCardRepo.GetByID(ctx, int64(cardID))
+	if err != nil {
+		s.logger.Error("Failed to get the card", zap.Error(err), zap.Int("CardID", cardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if card == nil {
+		return nil, derror.NewNotFoundError("card %d not found", cardID)
+	}
+
+	return card, nil
+}
+
+// ownsAccount reports whether the user owns the account, remembering the answer in owned.
+func (s *Service) ownsAccount(ctx context.Context, userID, accountID int, owned map[int]bool) (bool, error) {
+	if ok, checked := owned[accountID]; checked {
+		return ok, nil
+	}
+
+	account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return false, err
+	}
+
+	owned[accountID] = account.UserID == userID
+	return owned[accountID], nil
+}
+
+func seesAll(claims *entity.JWTClaims) bool {
+	return claims.Admin || claims.Can(enum.PermissionAccountsReadAll)
+}
+
+// checkAnyAccount passes when the user owns at least one of the accounts.
+func (s *Service) checkAnyAccount(ctx context.Context, userID int, accountIDs []int, msg string, args ...any) error {
+	checked := make(map[int]bool, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if checked[accountID] {
+			continue
+		}
+		checked[accountID] = true
+
+		account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		if account.UserID == userID {
+			return nil
+		}
+	}
+
+	return derror.NewForbiddenError(msg, args...)
+}
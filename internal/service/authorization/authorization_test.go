@@ -0,0 +1,192 @@
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mocks struct {
+	accounts            *protocol.MockFinancialAccountService
+	cards               *protocol.MockFinancialCardRepo
+	accountTransactions *protocol.MockAccountTransactionRepo
+	cardTransactions    *protocol.MockCardTransactionRepo
+}
+
+// Account 1 belongs to user 3 and account 2 to user 4. Card 5 draws on account 1.
+func setup() (*Service, mocks) {
+	m := mocks{
+		accounts:            new(protocol.MockFinancialAccountService),
+		cards:               new(protocol.MockFinancialCardRepo),
+		accountTransactions: new(protocol.MockAccountTransactionRepo),
+		cardTransactions:    new(protocol.MockCardTransactionRepo),
+	}
+	m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil).Maybe()
+	m.accounts.On("GetAccountByID", mock.Anything, 2).Return(response.GetFinancialAccount{AccountID: 2, UserID: 4}, nil).Maybe()
+	m.accounts.On("GetAccountByID", mock.Anything, 9).Return(response.GetFinancialAccount{}, derror.NewNotFoundError("Account not found")).Maybe()
+	m.cards.On("GetByID", mock.Anything, int64(5)).Return(&entity.FinancialCard{CardID: 5, AccountID: 1}, nil).Maybe()
+	m.cards.On("GetByID", mock.Anything, int64(6)).Return(&entity.FinancialCard{CardID: 6, AccountID: 2}, nil).Maybe()
+
+	return New(nil, m.accounts, m.cards, m.accountTransactions, m.cardTransactions), m
+}
+
+func user(userID int) *entity.JWTClaims {
+	return &entity.JWTClaims{UserID: userID}
+}
+
+var admin = &entity.JWTClaims{UserID: 1, Admin: true}
+
+func TestAuthorizeAccount(t *testing.T) {
+	service, m := setup()
+	ctx := context.Background()
+
+	assert.NoError(t, service.AuthorizeAccount(ctx, user(3), 1))
+
+	err := service.AuthorizeAccount(ctx, user(3), 2)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+
+	err = service.AuthorizeAccount(ctx, user(3), 9)
+	assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+
+	m.accounts.Calls = nil
+	assert.NoError(t, service.AuthorizeAccount(ctx, admin, 2))
+	m.accounts.AssertNotCalled(t, "GetAccountByID", mock.Anything, mock.Anything)
+}
+
+func TestAuthorizeCard(t *testing.T) {
+	service, m := setup()
+	ctx := context.Background()
+	m.cards.On("GetByID", mock.Anything, int64(7)).Return(nil, nil)
+
+	assert.NoError(t, service.AuthorizeCard(ctx, user(3), 5))
+
+	err := service.AuthorizeCard(ctx, user(3), 6)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+
+	err = service.AuthorizeCard(ctx, user(3), 7)
+	assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+
+	assert.NoError(t, service.AuthorizeCard(ctx, admin, 6))
+}
+
+func TestAuthorizeAccountTransaction(t *testing.T) {
+	service, m := setup()
+	ctx := context.Background()
+	m.accountTransactions.On("GetByID", mock.Anything, int64(20)).Return(&entity.AccountTransaction{TransactionID: 20, FinancialAccountID: 2}, nil)
+
+	err := service.AuthorizeAccountTransaction(ctx, user(3), 20)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+
+	assert.NoError(t, service.AuthorizeAccountTransaction(ctx, user(4), 20))
+	assert.NoError(t, service.AuthorizeAccountTransaction(ctx, admin, 20))
+}
+
+func TestAuthorizeAccountTransactionGroup(t *testing.T) {
+	service, m := setup()
+	ctx := context.Background()
+
+	// A transfer from account 1 to account 2
+	m.accountTransactions.On("ListByTransactionGroupID", mock.Anything, 30).Return([]*entity.AccountTransaction{
+		{TransactionID: 31, TransactionGroupID: 30, FinancialAccountID: 1},
+		{TransactionID: 32, TransactionGroupID: 30, FinancialAccountID: 2},
+	}, nil)
+	m.accountTransactions.On("ListByTransactionGroupID", mock.Anything, 40).Return([]*entity.AccountTransaction{}, nil)
+
+	t.Run("Sender and receiver", func(t *testing.T) {
+		assert.NoError(t, service.AuthorizeAccountTransactionGroup(ctx, user(3), 30))
+		assert.NoError(t, service.AuthorizeAccountTransactionGroup(ctx, user(4), 30))
+	})
+
+	t.Run("Outsider", func(t *testing.T) {
+		err := service.AuthorizeAccountTransactionGroup(ctx, user(5), 30)
+		assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+	})
+
+	t.Run("Unknown group", func(t *testing.T) {
+		err := service.AuthorizeAccountTransactionGroup(ctx, user(3), 40)
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+}
+
+func TestAuthorizeCardTransactionGroup(t *testing.T) {
+	service, m := setup()
+	ctx := context.Background()
+
+	// A card-to-card transfer from card 5 to card 6
+	m.cardTransactions.On("ListByTransactionGroupID", mock.Anything, 50).Return([]*entity.CardTransaction{
+		{TransactionID: 51, TransactionGroupID: 50, FinancialCardID: 5},
+		{TransactionID: 52, TransactionGroupID: 50, FinancialCardID: 6},
+	}, nil)
+	m.cardTransactions.On("GetByID", mock.Anything, int64(51)).Return(&entity.CardTransaction{TransactionID: 51, FinancialCardID: 5}, nil)
+
+	assert.NoError(t, service.AuthorizeCardTransactionGroup(ctx, user(4), 50))
+
+	err := service.AuthorizeCardTransactionGroup(ctx, user(5), 50)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+
+	assert.NoError(t, service.AuthorizeCardTransaction(ctx, user(3), 51))
+
+	err = service.AuthorizeCardTransaction(ctx, user(4), 51)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+}
+
+func TestOwnAccountTransactions(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	// A transfer from account 1 to account 2: each side sees only its own leg and balance
+	sent := &entity.AccountTransaction{TransactionID: 31, TransactionGroupID: 30, FinancialAccountID: 1}
+	received := &entity.AccountTransaction{TransactionID: 32, TransactionGroupID: 30, FinancialAccountID: 2}
+	legs := []*entity.AccountTransaction{sent, received}
+
+	tests := []struct {
+		name   string
+		claims *entity.JWTClaims
+		want   []*entity.AccountTransaction
+	}{
+		{name: "Sender", claims: user(3), want: []*entity.AccountTransaction{sent}},
+		{name: "Receiver", claims: user(4), want: []*entity.AccountTransaction{received}},
+		{name: "Outsider", claims: user(5), want: []*entity.AccountTransaction{}},
+		{name: "Admin", claims: admin, want: legs},
+		{name: "Staff reading any account", claims: &entity.JWTClaims{UserID: 2, Permissions: []enum.Permission{enum.PermissionAccountsReadAll}}, want: legs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visible, err := service.OwnAccountTransactions(ctx, tt.claims, legs)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, visible)
+		})
+	}
+}
+
+func TestOwnCardTransactions(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	// A card-to-card transfer from card 5 to card 6
+	sent := &entity.CardTransaction{TransactionID: 51, TransactionGroupID: 50, FinancialCardID: 5}
+	received := &entity.CardTransaction{TransactionID: 52, TransactionGroupID: 50, FinancialCardID: 6}
+	legs := []*entity.CardTransaction{sent, received}
+
+	visible, err := service.OwnCardTransactions(ctx, user(3), legs)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.CardTransaction{sent}, visible)
+
+	visible, err = service.OwnCardTransactions(ctx, user(4), legs)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.CardTransaction{received}, visible)
+
+	visible, err = service.OwnCardTransactions(ctx, admin, legs)
+	require.NoError(t, err)
+	assert.Equal(t, legs, visible)
+}
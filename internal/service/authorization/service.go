@@ -0,0 +1,30 @@
+package authorization
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	logger                  *zap.SugaredLogger
+	financialAccountService protocol.FinancialAccount
+	financialCardRepo       protocol.FinancialCardRepository
+	accountTransactionRepo  protocol.AccountTransactionRepository
+	cardTransactionRepo     protocol.CardTransactionRepository
+}
+
+func New(
+	logger *zap.SugaredLogger,
+	financialAccountService protocol.FinancialAccount,
+	financialCardRepo protocol.FinancialCardRepository,
+	accountTransactionRepo protocol.AccountTransactionRepository,
+	cardTransactionRepo protocol.CardTransactionRepository,
+) *Service {
+	return &Service{
+		logger:                  logger,
+		financialAccountService: financialAccountService,
+		financialCardRepo:       financialCardRepo,
+		accountTransactionRepo:  accountTransactionRepo,
+		cardTransactionRepo:     cardTransactionRepo,
+	}
+}
@@ -0,0 +1,348 @@
+package cardtransaction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+func (s *Service) Authorize(ctx context.Context, req request.CardAuthorization) (hold *entity.CardHold, err error) {
+	s.logger.Info("Starting card authorization",
+		zap.Int("CardID", req.CardID),
+		zap.String("Amount", req.Amount.String()),
+		zap.String("Currency", string(req.Amount.Currency)))
+
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid authorization request", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.cardTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.cardTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	card, err := s.usableCard(ctx, req.CardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkOwner(ctx, req.UserID, card); err != nil {
+		return nil, err
+	}
+
+	currency, err := s.accountCurrency(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount.Currency != currency {
+		err = derror.NewBadRequestError("the amount must be in %s, the currency of the card's account", currency)
+		return nil, err
+	}
+
+	// The capture settles through the settlement account, so refuse what it could not settle
+	if _, err = s.settlementAccount(currency); err != nil {
+		return nil, err
+	}
+
+	available, err := s.lockAvailableBalance(ctx, card, currency)
+	if err != nil {
+		s.logger.Error("Failed to lock the account balance", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return nil, err
+	}
+
+	if available.Amount < req.Amount.Amount {
+		err = derror.NewValidationError("insufficient funds in the card's account")
+		return nil, err
+	}
+
+	if err = s.enforceControls(ctx, card, payment{Amount: req.Amount, Merchant: req.Merchant, MCC: req.MCC, Country: req.Country, Online: req.Online}); err != nil {
+		return nil, err
+	}
+
+	// The merchant charges the card, so there is nobody to ask for a code
+	if err = s.enforcePolicy(ctx, card.AccountID, req.Amount, available, entity.SecondFactor{Exempt: true}); err != nil {
+		return nil, err
+	}
+
+	hold = &entity.CardHold{
+		FinancialCardID:    card.CardID,
+		FinancialAccountID: card.AccountID,
+		Amount:             req.Amount,
+		CapturedAmount:     entity.NewMoney(0, currency),
+		Merchant:           req.Merchant,
+		Description:        req.Description,
+		Status:             enum.HoldActive,
+		ExpiresAt:          time.Now().Add(s.holdTTL()),
+	}
+
+	if err = s.cardHoldRepo.Insert(ctx, hold); err != nil {
+		s.logger.Error("Failed to insert the authorization hold", zap.Error(err))
+		return nil, err
+	}
+
+	if err = s.complete(ctx, hold); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Card authorization completed", zap.Int("HoldID", hold.HoldID), zap.Time("ExpiresAt", hold.ExpiresAt))
+
+	return hold, nil
+}
+
+func (s *Service) Capture(ctx context.Context, req request.CaptureHold) (res response.CardCapture, err error) {
+	s.logger.Info("Starting capture of the authorization hold", zap.Int("HoldID", req.HoldID))
+
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid capture request", zap.Error(err))
+		return res, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.cardTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return res, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.cardTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	// The lock keeps the sweeper and a second capture off the hold until this one commits
+	hold, err := s.activeHold(ctx, req.HoldID)
+	if err != nil {
+		return res, err
+	}
+
+	amount := hold.Amount
+	if req.Amount != nil {
+		if req.Amount.Currency != hold.Amount.Currency {
+			err = derror.NewBadRequestError("the capture must be in %s, the currency of the authorization", hold.Amount.Currency)
+			return res, err
+		}
+
+		if req.Amount.Amount > hold.Amount.Amount {
+			err = derror.NewValidationError("the capture exceeds the %s %s authorized", hold.Amount.String(), hold.Amount.Currency)
+			return res, err
+		}
+		amount = *req.Amount
+	}
+
+	settlement, err := s.settlementAccount(amount.Currency)
+	if err != nil {
+		return res, err
+	}
+
+	description := hold.Description
+	if description == "" {
+		description = fmt.Sprintf("Card purchase at %s", hold.Merchant)
+	}
+
+	// The funds were reserved by the hold, so the capture needs no balance check of its own
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: hold.FinancialAccountID, Amount: amount.Neg()},
+			{FinancialAccountID: settlement, Amount: amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return res, err
+	}
+
+	purchase := &entity.CardTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialCardID:    hold.FinancialCardID,
+		Type:               enum.CardPurchase,
+		Amount:             amount.Neg(),
+		Balance:            entry.PostingFor(hold.FinancialAccountID).BalanceAfter,
+		Merchant:           &hold.Merchant,
+		Description:        description,
+		Status:             enum.Completedd,
+		HoldID:             &hold.HoldID,
+	}
+
+	if err = s.cardTransactionRepo.Insert(ctx, purchase); err != nil {
+		s.logger.Error("Failed to insert the captured purchase", zap.Error(err))
+		return res, err
+	}
+
+	// Whatever was authorized beyond the capture is released with the hold
+	hold.Status = enum.HoldCaptured
+	hold.CapturedAmount = amount
+	hold.CaptureTransactionID = &purchase.TransactionID
+	if err = s.cardHoldRepo.Update(ctx, hold); err != nil {
+		s.logger.Error("Failed to update the captured hold", zap.Error(err))
+		return res, err
+	}
+
+	if err = s.cardTransactionRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return res, err
+	}
+
+	s.logger.Info("Authorization hold captured", zap.Int("HoldID", hold.HoldID), zap.String("Amount", amount.String()))
+
+	return response.CardCapture{PurchaseTx: *purchase, Hold: *hold}, nil
+}
+
+func (s *Service) ReleaseHold(ctx context.Context, req request.ReleaseHold) (hold *entity.CardHold, err error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid release request", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.cardTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.cardTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	hold, err = s.activeHold(ctx, req.HoldID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Status = enum.HoldReleased
+	if err = s.cardHoldRepo.Update(ctx, hold); err != nil {
+		s.logger.Error("Failed to release the hold", zap.Error(err))
+		return nil, err
+	}
+
+	if err = s.cardTransactionRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Authorization hold released", zap.Int("HoldID", hold.HoldID))
+
+	return hold, nil
+}
+
+func (s *Service) ListHoldsByCardID(ctx context.Context, cardID int) ([]*entity.CardHold, error) {
+	if cardID <= 0 {
+		return nil, derror.NewBadRequestError("Invalid card ID")
+	}
+
+	holds, err := s.cardHoldRepo.ListByCardID(ctx, cardID)
+	if err != nil {
+		s.logger.Error("Failed to list the card's holds", zap.Error(err), zap.Int("CardID", cardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return holds, nil
+}
+
+func (s *Service) GetCardBalance(ctx context.Context, userID, cardID int) (response.CardBalance, error) {
+	if cardID <= 0 {
+		return response.CardBalance{}, derror.NewBadRequestError("Invalid card ID")
+	}
+
+	card, err := s.financialCardService.GetCardByID(ctx, int64(cardID))
+	if err != nil {
+		return response.CardBalance{}, err
+	}
+
+	if card.DeletedAt != nil {
+		return response.CardBalance{}, derror.NewNotFoundError("card %d not found", cardID)
+	}
+
+	if err := s.checkOwner(ctx, userID, card); err != nil {
+		return response.CardBalance{}, err
+	}
+
+	currency, err := s.financialAccountService.GetAccountCurrency(ctx, card.AccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account currency", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return response.CardBalance{}, err
+	}
+
+	ledger, err := s.ledgerRepo.GetBalance(ctx, card.AccountID, currency.CurrencyCode)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account balance", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return response.CardBalance{}, derror.NewInternalSystemError()
+	}
+
+	held, err := s.ledgerRepo.HeldAmount(ctx, card.AccountID, currency.CurrencyCode)
+	if err != nil {
+		s.logger.Error("Failed to sum the authorization holds", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return response.CardBalance{}, derror.NewInternalSystemError()
+	}
+
+	balance := response.CardBalance{
+		CardID:    card.CardID,
+		AccountID: card.AccountID,
+		Ledger:    ledger,
+		Held:      held,
+		Available: entity.NewMoney(ledger.Amount-held.Amount, ledger.Currency),
+	}
+
+	if card.CardType == enum.Credit {
+		limit, err := s.creditLimit(ctx, card)
+		if err != nil {
+			return response.CardBalance{}, err
+		}
+
+		balance.CreditLimit = &limit
+		balance.Available.Amount += limit.Amount
+	}
+
+	return balance, nil
+}
+
+// activeHold locks the hold and checks that it can still be captured or released.
+func (s *Service) activeHold(ctx context.Context, holdID int) (*entity.CardHold, error) {
+	hold, err := s.cardHoldRepo.GetForUpdate(ctx, holdID)
+	if err != nil {
+		s.logger.Error("Failed to lock the hold", zap.Error(err), zap.Int("HoldID", holdID))
+		return nil, err
+	}
+
+	if hold == nil {
+		return nil, derror.NewNotFoundError("hold %d not found", holdID)
+	}
+
+	if hold.Status != enum.HoldActive {
+		return nil, derror.NewConflictError("hold %d is no longer active", holdID)
+	}
+
+	if hold.IsExpired(time.Now()) {
+		return nil, derror.NewConflictError("hold %d has expired", holdID)
+	}
+
+	return hold, nil
+}
+
+func (s *Service) holdTTL() time.Duration {
+	if s.cfg.HoldTTL <= 0 {
+		return defaultHoldTTL
+	}
+	return s.cfg.HoldTTL
+}
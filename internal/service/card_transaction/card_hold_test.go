@@ -0,0 +1,170 @@
+package cardtransaction
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func activeHold(expiresAt time.Time) *entity.CardHold {
+	return &entity.CardHold{
+		HoldID:             30,
+		FinancialCardID:    10,
+		FinancialAccountID: 1,
+		Amount:             usd(5000),
+		CapturedAmount:     usd(0),
+		Merchant:           "Hotel",
+		Status:             enum.HoldActive,
+		ExpiresAt:          expiresAt,
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	req := request.CardAuthorization{UserID: 3, CardID: 10, Amount: usd(5000), Merchant: "Hotel"}
+
+	t.Run("Successfully authorize", func(t *testing.T) {
+		service, m := setup()
+
+		var inserted *entity.CardHold
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		m.holds.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardHold")).Run(func(args mock.Arguments) {
+			inserted = args.Get(1).(*entity.CardHold)
+		}).Return(nil)
+
+		hold, err := service.Authorize(context.Background(), req)
+		require.NoError(t, err)
+		assert.Same(t, inserted, hold)
+		assert.Equal(t, enum.HoldActive, hold.Status)
+		assert.Equal(t, usd(5000), hold.Amount)
+		assert.WithinDuration(t, time.Now().Add(defaultHoldTTL), hold.ExpiresAt, time.Minute)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+		m.repo.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+
+	t.Run("Held funds are not available", func(t *testing.T) {
+		service, m := setup()
+
+		ledger := new(protocol.MockLedgerRepo)
+		ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		ledger.On("HeldAmount", mock.Anything, 1, enum.USD).Return(usd(6000), nil)
+		service.ledgerRepo = ledger
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+
+		_, err := service.Authorize(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.holds.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Card expired before the renewal job ended it", func(t *testing.T) {
+		service, m := setup()
+
+		expired := card(10, 1)
+		expired.ExpirationDate = time.Now().AddDate(0, 0, -1)
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(expired, nil)
+
+		_, err := service.Authorize(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.holds.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCapture(t *testing.T) {
+	capture := func(hold *entity.CardHold, amount *entity.Money) (response.CardCapture, *entity.JournalEntry, mocks, error) {
+		service, m := setup()
+
+		var posted *entity.JournalEntry
+		m.holds.On("GetForUpdate", mock.Anything, 30).Return(hold, nil)
+		m.holds.On("Update", mock.Anything, mock.AnythingOfType("*entity.CardHold")).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Run(func(args mock.Arguments) {
+			args.Get(1).(*entity.CardTransaction).TransactionID = 40
+		}).Return(nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			posted = args.Get(1).(*entity.JournalEntry)
+			postEntry(11, map[int]entity.Money{1: usd(10000), settlementAccountID: usd(0)})(args)
+		}).Return(nil)
+
+		res, err := service.Capture(context.Background(), request.CaptureHold{HoldID: 30, Amount: amount})
+		return res, posted, m, err
+	}
+
+	t.Run("Partial capture releases the rest", func(t *testing.T) {
+		part := usd(4200)
+		res, posted, _, err := capture(activeHold(time.Now().Add(time.Hour)), &part)
+		require.NoError(t, err)
+		assert.True(t, posted.IsBalanced())
+		assert.Equal(t, usd(-4200), posted.PostingFor(1).Amount)
+		assert.Equal(t, usd(4200), posted.PostingFor(settlementAccountID).Amount)
+		assert.Equal(t, enum.CardPurchase, res.PurchaseTx.Type)
+		assert.Equal(t, 30, *res.PurchaseTx.HoldID)
+		assert.Equal(t, enum.HoldCaptured, res.Hold.Status)
+		assert.Equal(t, usd(4200), res.Hold.CapturedAmount)
+		assert.Equal(t, int64(40), *res.Hold.CaptureTransactionID)
+	})
+
+	t.Run("Capture exceeds the authorization", func(t *testing.T) {
+		part := usd(5001)
+		_, posted, m, err := capture(activeHold(time.Now().Add(time.Hour)), &part)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		assert.Nil(t, posted)
+		m.repo.AssertCalled(t, "RollbackTx", mock.Anything)
+	})
+
+	t.Run("Expired hold", func(t *testing.T) {
+		_, posted, _, err := capture(activeHold(time.Now().Add(-time.Minute)), nil)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		assert.Nil(t, posted)
+	})
+
+	t.Run("Hold already captured", func(t *testing.T) {
+		hold := activeHold(time.Now().Add(time.Hour))
+		hold.Status = enum.HoldCaptured
+		_, posted, _, err := capture(hold, nil)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		assert.Nil(t, posted)
+	})
+}
+
+func TestExpireHolds(t *testing.T) {
+	service, m := setup()
+	service.cfg.SweepBatchSize = 2
+	now := time.Now()
+
+	m.holds.On("ExpireDue", mock.Anything, now, 2).Return([]*entity.CardHold{activeHold(now), activeHold(now)}, nil).Once()
+	m.holds.On("ExpireDue", mock.Anything, now, 2).Return([]*entity.CardHold{activeHold(now)}, nil).Once()
+
+	expired, err := service.ExpireHolds(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, expired)
+	m.holds.AssertNumberOfCalls(t, "ExpireDue", 2)
+}
+
+func TestGetCardBalance(t *testing.T) {
+	service, m := setup()
+
+	ledger := new(protocol.MockLedgerRepo)
+	ledger.On("GetBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+	ledger.On("HeldAmount", mock.Anything, 1, enum.USD).Return(usd(3500), nil)
+	service.ledgerRepo = ledger
+	m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+	m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+
+	balance, err := service.GetCardBalance(context.Background(), 3, 10)
+	require.NoError(t, err)
+	assert.Equal(t, usd(10000), balance.Ledger)
+	assert.Equal(t, usd(3500), balance.Held)
+	assert.Equal(t, usd(6500), balance.Available)
+}
@@ -0,0 +1,710 @@
+package cardtransaction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) Purchase(ctx context.Context, req request.CardPurchase) (transaction *entity.CardTransaction, err error) {
+	s.logger.Info("Starting card purchase",
+		zap.Int("CardID", req.CardID),
+		zap.String("Amount", req.Amount.String()),
+		zap.String("Currency", string(req.Amount.Currency)))
+
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid purchase request", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.cardTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.cardTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	card, err := s.usableCard(ctx, req.CardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkOwner(ctx, req.UserID, card); err != nil {
+		return nil, err
+	}
+
+	currency, err := s.accountCurrency(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount.Currency != currency {
+		err = derror.NewBadRequestError("the amount must be in %s, the currency of the card's account", currency)
+		return nil, err
+	}
+
+	settlement, err := s.settlementAccount(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := s.lockAvailableBalance(ctx, card, currency)
+	if err != nil {
+		s.logger.Error("Failed to lock the account balance", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return nil, err
+	}
+
+	if available.Amount < req.Amount.Amount {
+		err = derror.NewValidationError("insufficient funds in the card's account")
+		return nil, err
+	}
+
+	if err = s.enforceControls(ctx, card, payment{Amount: req.Amount, Merchant: req.Merchant, MCC: req.MCC, Country: req.Country, Online: req.Online}); err != nil {
+		return nil, err
+	}
+
+	// The merchant charges the card, so there is nobody to ask for a code
+	if err = s.enforcePolicy(ctx, card.AccountID, req.Amount, available, entity.SecondFactor{Exempt: true}); err != nil {
+		return nil, err
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Card purchase at %s", req.Merchant)
+	}
+
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: card.AccountID, Amount: req.Amount.Neg()},
+			{FinancialAccountID: settlement, Amount: req.Amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return nil, err
+	}
+
+	transaction = &entity.CardTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialCardID:    card.CardID,
+		Type:               enum.CardPurchase,
+		Amount:             req.Amount.Neg(),
+		Balance:            entry.PostingFor(card.AccountID).BalanceAfter,
+		Merchant:           &req.Merchant,
+		Description:        description,
+		Status:             enum.Completedd,
+	}
+
+	if err = s.cardTransactionRepo.Insert(ctx, transaction); err != nil {
+		s.logger.Error("Failed to insert the card transaction", zap.Error(err))
+		return nil, err
+	}
+
+	if err = s.complete(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Card purchase completed", zap.Int64("TransactionID", transaction.TransactionID), zap.Int("TransactionGroupID", entry.JournalEntryID))
+
+	return transaction, nil
+}
+
+func (s *Service) Refund(ctx context.Context, req request.CardRefund) (res response.CardRefund, err error) {
+	s.logger.Info("Starting card refund", zap.Int64("TransactionID", req.TransactionID))
+
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid refund request", zap.Error(err))
+		return res, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.cardTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return res, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.cardTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	// Concurrent refunds of the purchase wait on this lock, so together they can never
+	// return more than was paid.
+	purchase, err := s.cardTransactionRepo.GetForUpdate(ctx, req.TransactionID)
+	if err != nil {
+		s.logger.Error("Failed to lock the card transaction", zap.Error(err), zap.Int64("TransactionID", req.TransactionID))
+		return res, err
+	}
+
+	if purchase == nil {
+		err = derror.NewNotFoundError("card transaction %d not found", req.TransactionID)
+		return res, err
+	}
+
+	if purchase.Type != enum.CardPurchase {
+		err = derror.NewConflictError("only purchases can be refunded")
+		return res, err
+	}
+
+	if purchase.Status != enum.Completedd {
+		err = derror.NewConflictError("card transaction %d cannot be refunded in its current status", req.TransactionID)
+		return res, err
+	}
+
+	// The money goes back to the card's account, which a frozen, lost or replaced card
+	// still points at, so the card itself need not be usable any more
+	card, err := s.financialCardService.GetCardByID(ctx, int64(purchase.FinancialCardID))
+	if err != nil {
+		return res, err
+	}
+
+	paid := purchase.Amount.Neg()
+	refunded, err := s.cardTransactionRepo.RefundedAmount(ctx, purchase.TransactionID, paid.Currency)
+	if err != nil {
+		s.logger.Error("Failed to sum earlier refunds", zap.Error(err), zap.Int64("TransactionID", purchase.TransactionID))
+		return res, err
+	}
+
+	refundable, err := paid.Sub(refunded)
+	if err != nil {
+		return res, err
+	}
+
+	part := refundable
+	if req.Amount != nil {
+		if req.Amount.Currency != paid.Currency {
+			err = derror.NewBadRequestError("the refund must be in %s, the currency of the purchase", paid.Currency)
+			return res, err
+		}
+
+		if req.Amount.Amount > refundable.Amount {
+			err = derror.NewValidationError("the refund exceeds the %s %s left to refund", refundable.String(), refundable.Currency)
+			return res, err
+		}
+		part = *req.Amount
+	}
+
+	settlement, err := s.settlementAccount(paid.Currency)
+	if err != nil {
+		return res, err
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Refund of card transaction %d", purchase.TransactionID)
+	}
+
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: settlement, Amount: part.Neg()},
+			{FinancialAccountID: card.AccountID, Amount: part},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return res, err
+	}
+
+	refund := &entity.CardTransaction{
+		TransactionGroupID:   entry.JournalEntryID,
+		FinancialCardID:      card.CardID,
+		Type:                 enum.CardRefund,
+		Amount:               part,
+		Balance:              entry.PostingFor(card.AccountID).BalanceAfter,
+		Merchant:             purchase.Merchant,
+		Description:          description,
+		Status:               enum.Completedd,
+		RefundsTransactionID: &purchase.TransactionID,
+	}
+
+	if err = s.cardTransactionRepo.Insert(ctx, refund); err != nil {
+		s.logger.Error("Failed to insert the refund", zap.Error(err))
+		return res, err
+	}
+
+	if part.Amount == refundable.Amount {
+		purchase.Status = enum.Reversedd
+		if err = s.cardTransactionRepo.Update(ctx, purchase); err != nil {
+			s.logger.Error("Failed to update the refunded purchase", zap.Error(err))
+			return res, err
+		}
+	}
+
+	if err = s.cardTransactionRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return res, err
+	}
+
+	s.logger.Info("Card refund completed", zap.Int64("TransactionID", refund.TransactionID), zap.String("Amount", part.String()))
+
+	return response.CardRefund{
+		RefundTx:   *refund,
+		PurchaseTx: *purchase,
+		Refundable: entity.NewMoney(refundable.Amount-part.Amount, paid.Currency),
+	}, nil
+}
+
+func (s *Service) Transfer(ctx context.Context, req request.Transfer) (res response.Transfer, err error) {
+	s.logger.Info("Starting card to card transfer",
+		zap.Int("SenderCardID", req.SenderCardID),
+		zap.Int("ReceiverCardID", req.ReceiverCardID),
+		zap.String("Amount", req.Amount.String()),
+		zap.String("Currency", string(req.Amount.Currency)))
+
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid transfer request", zap.Error(err))
+		return res, derror.NewBadRequestError(err.Error())
+	}
+
+	// The code is checked outside the transaction so that a wrong one still counts towards
+	// the lockout when the transfer rolls back.
+	factor, err := s.twoFactorService.VerifyStepUp(ctx, req.UserID, transferBinding(req), req.TwoFactor)
+	if err != nil {
+		return res, err
+	}
+
+	ctx, err = s.cardTransactionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return res, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.cardTransactionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	sender, err := s.usableCard(ctx, req.SenderCardID)
+	if err != nil {
+		return res, err
+	}
+
+	if err = s.checkOwner(ctx, req.UserID, sender); err != nil {
+		return res, err
+	}
+
+	// A transfer would get around the single payment, merchant or cap the card was issued with
+	if sender.Usage != enum.MultiUse || !sender.SpendCap.IsZero() {
+		err = derror.NewValidationError("card %d can only pay merchants", sender.CardID)
+		return res, err
+	}
+
+	receiver, err := s.usableCard(ctx, req.ReceiverCardID)
+	if err != nil {
+		return res, err
+	}
+
+	// A credit card's account only borrows for purchases and is paid back through its line
+	for _, card := range []*entity.FinancialCard{sender, receiver} {
+		if card.CardType == enum.Credit {
+			err = derror.NewValidationError("credit card %d cannot send or receive transfers", card.CardID)
+			return res, err
+		}
+	}
+
+	if sender.AccountID == receiver.AccountID {
+		err = derror.NewBadRequestError("both cards are linked to the same account")
+		return res, err
+	}
+
+	senderCurrency, err := s.accountCurrency(ctx, sender)
+	if err != nil {
+		return res, err
+	}
+
+	receiverCurrency, err := s.accountCurrency(ctx, receiver)
+	if err != nil {
+		return res, err
+	}
+
+	if req.Amount.Currency != senderCurrency {
+		err = derror.NewBadRequestError("the amount must be in %s, the currency of the sender card's account", senderCurrency)
+		return res, err
+	}
+
+	if senderCurrency != receiverCurrency {
+		err = derror.NewBadRequestError("card transfers between %s and %s accounts are not supported", senderCurrency, receiverCurrency)
+		return res, err
+	}
+
+	senderBalance, receiverBalance, err := s.lockBalances(ctx, sender.AccountID, receiver.AccountID, senderCurrency)
+	if err != nil {
+		s.logger.Error("Failed to lock account balances", zap.Error(err))
+		return res, err
+	}
+
+	senderBalance, err = s.availableBalance(ctx, sender.AccountID, senderBalance)
+	if err != nil {
+		return res, err
+	}
+
+	if senderBalance.Amount < req.Amount.Amount {
+		err = derror.NewValidationError("insufficient funds in the sender card's account")
+		return res, err
+	}
+
+	if err = s.enforcePolicy(ctx, sender.AccountID, req.Amount, senderBalance, factor); err != nil {
+		return res, err
+	}
+
+	// The risk score decides whether the transfer completes now or waits on hold or for review
+	assessment, err := s.assessRisk(ctx, req.UserID, sender.AccountID, receiver.AccountID, req.Amount, senderBalance)
+	if err != nil {
+		return res, err
+	}
+
+	payee, err := s.payee(assessment, receiver.AccountID, senderCurrency)
+	if err != nil {
+		return res, err
+	}
+
+	entry := &entity.JournalEntry{
+		Description: &req.Description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: sender.AccountID, Amount: req.Amount.Neg()},
+			{FinancialAccountID: payee, Amount: req.Amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return res, err
+	}
+
+	// A held transfer waits in the suspense account, and the receiver's balance only moves
+	// once review releases it
+	status := enum.Completedd
+	if assessment.IsHeld() {
+		status = enum.OnHoldd
+	} else {
+		receiverBalance = entry.PostingFor(receiver.AccountID).BalanceAfter
+	}
+
+	senderTx := &entity.CardTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialCardID:    sender.CardID,
+		Type:               enum.CardTransferOut,
+		Amount:             req.Amount.Neg(),
+		Balance:            entry.PostingFor(sender.AccountID).BalanceAfter,
+		Description:        req.Description,
+		Status:             status,
+	}
+
+	receiverTx := &entity.CardTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialCardID:    receiver.CardID,
+		Type:               enum.CardTransferIn,
+		Amount:             req.Amount,
+		Balance:            receiverBalance,
+		Description:        req.Description,
+		Status:             status,
+	}
+
+	for _, transaction := range []*entity.CardTransaction{senderTx, receiverTx} {
+		if err = s.cardTransactionRepo.Insert(ctx, transaction); err != nil {
+			s.logger.Error("Failed to insert the card transaction", zap.Error(err), zap.Int("CardID", transaction.FinancialCardID))
+			return res, err
+		}
+	}
+
+	assessment.TransactionGroupID = entry.JournalEntryID
+	if err = s.riskService.Record(ctx, assessment); err != nil {
+		return res, err
+	}
+
+	res = response.Transfer{SenderTx: *senderTx, ReceiverTx: *receiverTx}
+	if err = s.complete(ctx, res); err != nil {
+		return response.Transfer{}, err
+	}
+
+	s.logger.Info("Card to card transfer completed", zap.Int("TransactionGroupID", entry.JournalEntryID))
+
+	return res, nil
+}
+
+func (s *Service) GetTransactionByID(ctx context.Context, transactionID int64) (*entity.CardTransaction, error) {
+	if transactionID <= 0 {
+		return nil, derror.NewBadRequestError("Invalid transaction ID")
+	}
+
+	transaction, err := s.cardTransactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		s.logger.Error("Failed to get the card transaction", zap.Error(err), zap.Int64("TransactionID", transactionID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if transaction == nil {
+		return nil, derror.NewNotFoundError("card transaction %d not found", transactionID)
+	}
+
+	return transaction, nil
+}
+
+func (s *Service) ListTransactionsByCardID(ctx context.Context, cardID int) ([]*entity.CardTransaction, error) {
+	if cardID <= 0 {
+		return nil, derror.NewBadRequestError("Invalid card ID")
+	}
+
+	transactions, err := s.cardTransactionRepo.ListByCardID(ctx, cardID)
+	if err != nil {
+		s.logger.Error("Failed to list the card's transactions", zap.Error(err), zap.Int("CardID", cardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return transactions, nil
+}
+
+func (s *Service) ListTransactionsByGroupID(ctx context.Context, groupID int) ([]*entity.CardTransaction, error) {
+	if groupID <= 0 {
+		return nil, derror.NewBadRequestError("Invalid transaction group ID")
+	}
+
+	transactions, err := s.cardTransactionRepo.ListByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list the card transactions of the group", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if len(transactions) == 0 {
+		return nil, derror.NewNotFoundError("transaction group %d has no card transactions", groupID)
+	}
+
+	return transactions, nil
+}
+
+// usableCard returns the card if it may be charged or credited right now.
+func (s *Service) usableCard(ctx context.Context, cardID int) (*entity.FinancialCard, error) {
+	card, err := s.financialCardService.GetCardByID(ctx, int64(cardID))
+	if err != nil {
+		return nil, err
+	}
+
+	if card.DeletedAt != nil {
+		return nil, derror.NewNotFoundError("card %d not found", cardID)
+	}
+
+	if card.Status != enum.Active {
+		return nil, derror.NewValidationError("card %d is not active", cardID)
+	}
+
+	if card.IsExpired(time.Now()) {
+		return nil, derror.NewValidationError("card %d has expired", cardID)
+	}
+
+	return card, nil
+}
+
+func (s *Service) checkOwner(ctx context.Context, userID int, card *entity.FinancialCard) error {
+	account, err := s.financialAccountService.GetAccountByID(ctx, card.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if account.UserID != userID {
+		return derror.NewForbiddenError("card %d does not belong to the user", card.CardID)
+	}
+
+	return nil
+}
+
+// accountCurrency returns the currency of the card's linked account after checking that
+// the account accepts transactions.
+func (s *Service) accountCurrency(ctx context.Context, card *entity.FinancialCard) (enum.CurrencyCode, error) {
+	status, err := s.financialAccountService.GetAccountStatus(ctx, card.AccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account status", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return "", err
+	}
+
+	if status != enum.Verified {
+		return "", derror.NewValidationError("the account linked to card %d does not allow transactions", card.CardID)
+	}
+
+	currency, err := s.financialAccountService.GetAccountCurrency(ctx, card.AccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account currency", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return "", err
+	}
+
+	return currency.CurrencyCode, nil
+}
+
+func (s *Service) settlementAccount(currency enum.CurrencyCode) (int, error) {
+	account, ok := s.cfg.SettlementAccountFor(string(currency))
+	if !ok {
+		s.logger.Error("No card settlement account is configured", zap.String("currency", string(currency)))
+		return 0, derror.NewValidationError("card payments in %s are not supported", currency)
+	}
+	return account, nil
+}
+
+// enforcePolicy rejects a debit that breaks the rules of its account, listing every violated rule.
+// A transfer held back by the two-factor rule alone is answered with a challenge instead.
+func (s *Service) enforcePolicy(ctx context.Context, accountID int, amount, balance entity.Money, factor entity.SecondFactor) error {
+	violations, err := s.accountRulesService.Evaluate(ctx, request.EvaluatePolicy{
+		FinancialAccountID:   accountID,
+		Amount:               amount,
+		Balance:              balance,
+		SecondFactorVerified: factor.Verified,
+		TwoFactorExempt:      factor.Exempt,
+	})
+	if err != nil {
+		s.logger.Error("Failed to evaluate the account rules", zap.Error(err))
+		return err
+	}
+
+	if factor.UserID != 0 && entity.OnlyTwoFactor(violations) {
+		return s.twoFactorService.StepUp(ctx, factor, violations)
+	}
+
+	if len(violations) > 0 {
+		s.logger.Warn("Card transaction violates the account rules", zap.Int("accountID", accountID), zap.Any("violations", violations))
+		return derror.WithDetails(derror.NewValidationError("the transaction violates the rules of account %d", accountID), violations)
+	}
+
+	return nil
+}
+
+// lockAvailableBalance locks the balance of the card's account and returns the part of it
+// that is not reserved by authorization holds. A credit card can also spend its credit
+// limit, taking the account below zero.
+func (s *Service) lockAvailableBalance(ctx context.Context, card *entity.FinancialCard, currency enum.CurrencyCode) (entity.Money, error) {
+	balance, err := s.ledgerRepo.LockBalance(ctx, card.AccountID, currency)
+	if err != nil {
+		return entity.Money{}, err
+	}
+
+	available, err := s.availableBalance(ctx, card.AccountID, balance)
+	if err != nil || card.CardType != enum.Credit {
+		return available, err
+	}
+
+	limit, err := s.creditLimit(ctx, card)
+	if err != nil {
+		return entity.Money{}, err
+	}
+
+	available.Amount += limit.Amount
+	return available, nil
+}
+
+func (s *Service) creditLimit(ctx context.Context, card *entity.FinancialCard) (entity.Money, error) {
+	line, err := s.creditLineRepo.GetByCardID(ctx, card.CardID)
+	if err != nil {
+		s.logger.Error("Failed to get the credit line", zap.Error(err), zap.Int("CardID", card.CardID))
+		return entity.Money{}, err
+	}
+
+	if line == nil {
+		return entity.Money{}, derror.NewValidationError("credit card %d has no credit line", card.CardID)
+	}
+
+	return line.Limit, nil
+}
+
+// availableBalance takes the open authorization holds off a locked balance. New holds
+// lock the same balance row first, so the result cannot go stale before commit.
+func (s *Service) availableBalance(ctx context.Context, accountID int, balance entity.Money) (entity.Money, error) {
+	held, err := s.ledgerRepo.HeldAmount(ctx, accountID, balance.Currency)
+	if err != nil {
+		s.logger.Error("Failed to sum the authorization holds", zap.Error(err), zap.Int("accountID", accountID))
+		return entity.Money{}, err
+	}
+
+	return entity.NewMoney(balance.Amount-held.Amount, balance.Currency), nil
+}
+
+// lockBalances locks both balances in ascending account order, so concurrent transfers
+// cannot deadlock each other, and returns them in the order asked for.
+func (s *Service) lockBalances(ctx context.Context, senderAccountID, receiverAccountID int, currency enum.CurrencyCode) (sender, receiver entity.Money, err error) {
+	first, second := senderAccountID, receiverAccountID
+	if second < first {
+		first, second = second, first
+	}
+
+	firstBalance, err := s.ledgerRepo.LockBalance(ctx, first, currency)
+	if err != nil {
+		return sender, receiver, err
+	}
+
+	secondBalance, err := s.ledgerRepo.LockBalance(ctx, second, currency)
+	if err != nil {
+		return sender, receiver, err
+	}
+
+	if first == senderAccountID {
+		return firstBalance, secondBalance, nil
+	}
+	return secondBalance, firstBalance, nil
+}
+
+// assessRisk runs the risk checks on a transfer before it is posted.
+func (s *Service) assessRisk(ctx context.Context, userID, senderAccountID, receiverAccountID int, amount, balance entity.Money) (*entity.RiskAssessment, error) {
+	assessment, err := s.riskService.Assess(ctx, request.AssessRisk{
+		UserID:            userID,
+		SenderAccountID:   senderAccountID,
+		ReceiverAccountID: receiverAccountID,
+		Amount:            amount,
+		Balance:           balance,
+		At:                time.Now(),
+	})
+	if err != nil {
+		s.logger.Error("Failed to assess the transfer risk", zap.Error(err))
+		return nil, err
+	}
+
+	return assessment, nil
+}
+
+// payee returns the account a transfer credits: the receiver's, or the suspense account
+// of the currency while the transfer is held.
+func (s *Service) payee(assessment *entity.RiskAssessment, receiverAccountID int, currency enum.CurrencyCode) (int, error) {
+	if !assessment.IsHeld() {
+		return receiverAccountID, nil
+	}
+
+	if suspense, ok := s.riskCfg.SuspenseAccountFor(string(currency)); ok {
+		return suspense, nil
+	}
+
+	s.logger.Error("No suspense account is configured for the currency", zap.String("currency", string(currency)))
+	return 0, derror.NewInternalSystemError()
+}
+
+// complete stores the response for the request's Idempotency-Key and commits, so both
+// take effect together.
+func (s *Service) complete(ctx context.Context, resp any) error {
+	if err := s.idempotencyService.Complete(ctx, resp); err != nil {
+		s.logger.Error("Failed to store the idempotent response", zap.Error(err))
+		return err
+	}
+
+	if err := s.cardTransactionRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func transferBinding(req request.Transfer) string {
+	return fmt.Sprintf("card_transfer:%d:%d:%d:%d:%s", req.UserID, req.SenderCardID, req.ReceiverCardID, req.Amount.Amount, req.Amount.Currency)
+}
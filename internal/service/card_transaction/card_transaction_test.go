@@ -0,0 +1,524 @@
+package cardtransaction
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	settlementAccountID = 50
+	suspenseAccountID   = 60
+)
+
+type mocks struct {
+	repo     *protocol.MockCardTransactionRepo
+	holds    *protocol.MockCardHoldRepo
+	controls *protocol.MockCardControlsRepo
+	lines    *protocol.MockCreditLineRepo
+	ledger   *protocol.MockLedgerRepo
+	cards    *protocol.MockFinancialCardService
+	accounts *protocol.MockFinancialAccountService
+	rules    *protocol.MockAccountRulesService
+	risk     *protocol.MockRiskService
+}
+
+func setup() (*Service, mocks) {
+	m := mocks{
+		repo:     new(protocol.MockCardTransactionRepo),
+		holds:    new(protocol.MockCardHoldRepo),
+		controls: new(protocol.MockCardControlsRepo),
+		lines:    new(protocol.MockCreditLineRepo),
+		ledger:   new(protocol.MockLedgerRepo),
+		cards:    new(protocol.MockFinancialCardService),
+		accounts: new(protocol.MockFinancialAccountService),
+		rules:    new(protocol.MockAccountRulesService),
+		risk:     new(protocol.MockRiskService),
+	}
+	mockIdempotency := new(protocol.MockIdempotencyService)
+	mockIdempotency.On("Complete", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockTwoFactor := new(protocol.MockTwoFactorService)
+	mockTwoFactor.On("VerifyStepUp", mock.Anything, mock.Anything, mock.Anything, (*request.TwoFactorAnswer)(nil)).Return(entity.SecondFactor{}, nil).Maybe()
+	m.controls.On("GetByCardID", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	m.rules.On("Evaluate", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	m.risk.On("Assess", mock.Anything, mock.Anything).Return(&entity.RiskAssessment{Decision: enum.Completed}, nil).Maybe()
+	m.risk.On("Record", mock.Anything, mock.Anything).Return(nil).Maybe()
+	m.repo.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	m.repo.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	m.repo.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	m.ledger.On("HeldAmount", mock.Anything, mock.Anything, mock.Anything).Return(entity.Money{}, nil).Maybe()
+	m.accounts.On("GetAccountStatus", mock.Anything, mock.Anything).Return(enum.Verified, nil).Maybe()
+	m.accounts.On("GetAccountCurrency", mock.Anything, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil).Maybe()
+
+	cfg := config.Card{
+		SettlementAccounts: []config.CardSettlementAccount{{Currency: "USD", AccountID: settlementAccountID}},
+	}
+
+	riskCfg := config.Risk{
+		SuspenseAccounts: []config.SuspenseAccount{{Currency: "USD", AccountID: suspenseAccountID}},
+	}
+
+	logger, _ := zap.NewProduction()
+
+	service := New(cfg, riskCfg, logger.Sugar(), m.repo, m.holds, m.controls, m.lines, m.ledger, m.cards, m.accounts, m.rules, mockIdempotency, mockTwoFactor, m.risk)
+	return service, m
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+func card(cardID, accountID int) *entity.FinancialCard {
+	return &entity.FinancialCard{
+		CardID:         cardID,
+		AccountID:      accountID,
+		Status:         enum.Active,
+		ExpirationDate: time.Now().AddDate(1, 0, 0),
+	}
+}
+
+// postEntry simulates the ledger assigning an ID and running balances to a posted entry.
+func postEntry(journalEntryID int, opening map[int]entity.Money) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		entry := args.Get(1).(*entity.JournalEntry)
+		entry.JournalEntryID = journalEntryID
+		for _, p := range entry.Postings {
+			p.JournalEntryID = journalEntryID
+			p.BalanceAfter, _ = opening[p.FinancialAccountID].Add(p.Amount)
+		}
+	}
+}
+
+func TestPurchase(t *testing.T) {
+	req := request.CardPurchase{UserID: 3, CardID: 10, Amount: usd(2500), Merchant: "Book Store"}
+
+	t.Run("Successfully purchase", func(t *testing.T) {
+		service, m := setup()
+
+		var posted *entity.JournalEntry
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			posted = args.Get(1).(*entity.JournalEntry)
+			postEntry(7, map[int]entity.Money{1: usd(10000), settlementAccountID: usd(0)})(args)
+		}).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+
+		transaction, err := service.Purchase(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, posted.IsBalanced())
+		assert.Equal(t, usd(-2500), posted.PostingFor(1).Amount)
+		assert.Equal(t, usd(2500), posted.PostingFor(settlementAccountID).Amount)
+		assert.Equal(t, enum.CardPurchase, transaction.Type)
+		assert.Equal(t, 7, transaction.TransactionGroupID)
+		assert.Equal(t, usd(-2500), transaction.Amount)
+		assert.Equal(t, usd(7500), transaction.Balance)
+		assert.Equal(t, "Card purchase at Book Store", transaction.Description)
+		m.repo.AssertCalled(t, "CommitTx", mock.Anything)
+		// Merchants charge the card, so the two-factor rule has nobody to ask
+		m.rules.AssertCalled(t, "Evaluate", mock.Anything, mock.MatchedBy(func(req request.EvaluatePolicy) bool {
+			return req.TwoFactorExempt
+		}))
+	})
+
+	tests := []struct {
+		name       string
+		card       *entity.FinancialCard
+		ownerID    int
+		amount     entity.Money
+		wantStatus int
+	}{
+		{
+			name:       "Card is blocked",
+			card:       &entity.FinancialCard{CardID: 10, AccountID: 1, Status: enum.Lost, ExpirationDate: time.Now().AddDate(1, 0, 0)},
+			ownerID:    3,
+			amount:     usd(2500),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Card has expired",
+			card:       &entity.FinancialCard{CardID: 10, AccountID: 1, Status: enum.Active, ExpirationDate: time.Now().AddDate(0, 0, -1)},
+			ownerID:    3,
+			amount:     usd(2500),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Card belongs to another user",
+			card:       card(10, 1),
+			ownerID:    4,
+			amount:     usd(2500),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Amount in another currency",
+			card:       card(10, 1),
+			ownerID:    3,
+			amount:     entity.NewMoney(2500, enum.EUR),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Insufficient funds",
+			card:       card(10, 1),
+			ownerID:    3,
+			amount:     usd(20000),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+
+			m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(tt.card, nil)
+			m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: tt.ownerID}, nil)
+			m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+
+			req := req
+			req.Amount = tt.amount
+			_, err := service.Purchase(context.Background(), req)
+			assert.True(t, derror.IsHTTPError(err, tt.wantStatus), "got %v", err)
+			m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			m.repo.AssertCalled(t, "RollbackTx", mock.Anything)
+		})
+	}
+}
+
+func TestRefund(t *testing.T) {
+	purchase := func() *entity.CardTransaction {
+		merchant := "Book Store"
+		return &entity.CardTransaction{
+			TransactionID:   20,
+			FinancialCardID: 10,
+			Type:            enum.CardPurchase,
+			Amount:          usd(-2500),
+			Merchant:        &merchant,
+			Status:          enum.Completedd,
+		}
+	}
+
+	refundTo := func(refunded *entity.FinancialCard, alreadyRefunded int64, amount *entity.Money) (response.CardRefund, *entity.JournalEntry, mocks, error) {
+		service, m := setup()
+
+		var posted *entity.JournalEntry
+		m.repo.On("GetForUpdate", mock.Anything, int64(20)).Return(purchase(), nil)
+		m.repo.On("RefundedAmount", mock.Anything, int64(20), enum.USD).Return(usd(alreadyRefunded), nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+		m.repo.On("Update", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(refunded, nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			posted = args.Get(1).(*entity.JournalEntry)
+			postEntry(8, map[int]entity.Money{1: usd(7500), settlementAccountID: usd(2500)})(args)
+		}).Return(nil)
+
+		res, err := service.Refund(context.Background(), request.CardRefund{TransactionID: 20, Amount: amount})
+		return res, posted, m, err
+	}
+
+	refund := func(alreadyRefunded int64, amount *entity.Money) (response.CardRefund, *entity.JournalEntry, mocks, error) {
+		return refundTo(card(10, 1), alreadyRefunded, amount)
+	}
+
+	blocked := map[string]enum.FinancialCardStatus{
+		"Refund to a frozen card":   enum.CardFrozen,
+		"Refund to a replaced card": enum.CardReplaced,
+		"Refund to a lost card":     enum.Lost,
+	}
+
+	for name, status := range blocked {
+		t.Run(name, func(t *testing.T) {
+			blocked := card(10, 1)
+			blocked.Status = status
+
+			res, posted, _, err := refundTo(blocked, 0, nil)
+			require.NoError(t, err)
+			assert.Equal(t, usd(2500), posted.PostingFor(1).Amount)
+			assert.Equal(t, usd(2500), res.RefundTx.Amount)
+		})
+	}
+
+	t.Run("Refund to an expired card", func(t *testing.T) {
+		expired := card(10, 1)
+		expired.ExpirationDate = time.Now().AddDate(0, 0, -1)
+
+		_, posted, _, err := refundTo(expired, 0, nil)
+		require.NoError(t, err)
+		assert.Equal(t, usd(2500), posted.PostingFor(1).Amount)
+	})
+
+	t.Run("Partial refund", func(t *testing.T) {
+		part := usd(1000)
+		res, posted, m, err := refund(0, &part)
+		require.NoError(t, err)
+		assert.True(t, posted.IsBalanced())
+		assert.Equal(t, usd(1000), posted.PostingFor(1).Amount)
+		assert.Equal(t, usd(-1000), posted.PostingFor(settlementAccountID).Amount)
+		assert.Equal(t, enum.CardRefund, res.RefundTx.Type)
+		assert.Equal(t, int64(20), *res.RefundTx.RefundsTransactionID)
+		assert.Equal(t, usd(1500), res.Refundable)
+		assert.Equal(t, enum.Completedd, res.PurchaseTx.Status)
+		m.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refund of the remainder reverses the purchase", func(t *testing.T) {
+		res, _, m, err := refund(1000, nil)
+		require.NoError(t, err)
+		assert.Equal(t, usd(1500), res.RefundTx.Amount)
+		assert.Equal(t, usd(0), res.Refundable)
+		assert.Equal(t, enum.Reversedd, res.PurchaseTx.Status)
+		m.repo.AssertCalled(t, "Update", mock.Anything, mock.AnythingOfType("*entity.CardTransaction"))
+	})
+
+	t.Run("Refund exceeds the remainder", func(t *testing.T) {
+		part := usd(2000)
+		_, posted, m, err := refund(1000, &part)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		assert.Nil(t, posted)
+		m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Only purchases are refundable", func(t *testing.T) {
+		service, m := setup()
+		transaction := purchase()
+		transaction.Type = enum.CardTransferOut
+		m.repo.On("GetForUpdate", mock.Anything, int64(20)).Return(transaction, nil)
+
+		_, err := service.Refund(context.Background(), request.CardRefund{TransactionID: 20})
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+	})
+}
+
+func TestTransfer(t *testing.T) {
+	req := request.Transfer{UserID: 3, SenderCardID: 10, ReceiverCardID: 11, Amount: usd(1000), Description: "dinner"}
+
+	t.Run("Successfully transfer", func(t *testing.T) {
+		service, m := setup()
+
+		var posted *entity.JournalEntry
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(11)).Return(card(11, 2), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		m.ledger.On("LockBalance", mock.Anything, 2, enum.USD).Return(usd(0), nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			posted = args.Get(1).(*entity.JournalEntry)
+			postEntry(9, map[int]entity.Money{1: usd(10000), 2: usd(0)})(args)
+		}).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+
+		res, err := service.Transfer(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, posted.IsBalanced())
+		assert.Equal(t, enum.CardTransferOut, res.SenderTx.Type)
+		assert.Equal(t, enum.CardTransferIn, res.ReceiverTx.Type)
+		assert.Equal(t, usd(9000), res.SenderTx.Balance)
+		assert.Equal(t, usd(1000), res.ReceiverTx.Balance)
+		assert.Equal(t, 9, res.ReceiverTx.TransactionGroupID)
+	})
+
+	t.Run("Transfer held for review", func(t *testing.T) {
+		service, m := setup()
+		m.risk.ExpectedCalls = nil
+
+		assessment := &entity.RiskAssessment{Score: 70, Decision: enum.PendingReview}
+		var posted *entity.JournalEntry
+		var inserted []*entity.CardTransaction
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(11)).Return(card(11, 2), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		m.ledger.On("LockBalance", mock.Anything, 2, enum.USD).Return(usd(300), nil)
+		m.risk.On("Assess", mock.Anything, mock.MatchedBy(func(req request.AssessRisk) bool {
+			return req.UserID == 3 && req.SenderAccountID == 1 && req.ReceiverAccountID == 2 && req.Amount == usd(1000)
+		})).Return(assessment, nil)
+		m.risk.On("Record", mock.Anything, assessment).Return(nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			posted = args.Get(1).(*entity.JournalEntry)
+			postEntry(9, map[int]entity.Money{1: usd(10000), suspenseAccountID: usd(0)})(args)
+		}).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Run(func(args mock.Arguments) {
+			inserted = append(inserted, args.Get(1).(*entity.CardTransaction))
+		}).Return(nil)
+
+		res, err := service.Transfer(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, usd(1000), posted.PostingFor(suspenseAccountID).Amount)
+		assert.Nil(t, posted.PostingFor(2))
+		require.Len(t, inserted, 2)
+		for _, tx := range inserted {
+			assert.Equal(t, enum.OnHoldd, tx.Status)
+		}
+		assert.Equal(t, usd(300), res.ReceiverTx.Balance)
+		assert.Equal(t, 9, assessment.TransactionGroupID)
+		m.risk.AssertCalled(t, "Record", mock.Anything, assessment)
+	})
+
+	t.Run("Receiver card is stolen", func(t *testing.T) {
+		service, m := setup()
+
+		stolen := card(11, 2)
+		stolen.Status = enum.Stolen
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(11)).Return(stolen, nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+
+		_, err := service.Transfer(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cards share the account", func(t *testing.T) {
+		service, m := setup()
+
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(11)).Return(card(11, 1), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+
+		_, err := service.Transfer(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest), "got %v", err)
+	})
+}
+
+func TestTransferStepUp(t *testing.T) {
+	const binding = "card_transfer:3:10:11:1000:USD"
+
+	twoFactorViolation := []entity.PolicyViolation{
+		{Rule: enum.PolicyRequire2FA, Message: "two-factor authentication is required for amounts above 5.00 USD"},
+	}
+	answer := &request.TwoFactorAnswer{Challenge: "challenge", Code: "123456"}
+	transfer := request.Transfer{UserID: 3, SenderCardID: 10, ReceiverCardID: 11, Amount: usd(1000)}
+
+	prepare := func() (*Service, mocks, *protocol.MockAccountRulesService, *protocol.MockTwoFactorService) {
+		service, m := setup()
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(11)).Return(card(11, 2), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		m.ledger.On("LockBalance", mock.Anything, 2, enum.USD).Return(usd(0), nil)
+
+		mockRules := new(protocol.MockAccountRulesService)
+		mockTwoFactor := new(protocol.MockTwoFactorService)
+		service.accountRulesService = mockRules
+		service.twoFactorService = mockTwoFactor
+
+		return service, m, mockRules, mockTwoFactor
+	}
+
+	t.Run("without a code", func(t *testing.T) {
+		service, m, mockRules, mockTwoFactor := prepare()
+		factor := entity.SecondFactor{UserID: 3, Binding: binding}
+		stepUp := derror.NewForbiddenError("the payment needs a two-factor code")
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, (*request.TwoFactorAnswer)(nil)).Return(factor, nil)
+		mockRules.On("Evaluate", mock.Anything, mock.MatchedBy(func(req request.EvaluatePolicy) bool {
+			return !req.SecondFactorVerified && !req.TwoFactorExempt
+		})).Return(twoFactorViolation, nil)
+		mockTwoFactor.On("StepUp", mock.Anything, factor, twoFactorViolation).Return(stepUp)
+
+		_, err := service.Transfer(context.Background(), transfer)
+		assert.Equal(t, stepUp, err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("with a code", func(t *testing.T) {
+		service, m, mockRules, mockTwoFactor := prepare()
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, answer).Return(entity.SecondFactor{UserID: 3, Binding: binding, Verified: true}, nil)
+		mockRules.On("Evaluate", mock.Anything, mock.MatchedBy(func(req request.EvaluatePolicy) bool {
+			return req.SecondFactorVerified
+		})).Return(nil, nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(postEntry(9, map[int]entity.Money{1: usd(10000), 2: usd(0)})).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+
+		answered := transfer
+		answered.TwoFactor = answer
+		_, err := service.Transfer(context.Background(), answered)
+		require.NoError(t, err)
+		m.ledger.AssertCalled(t, "Post", mock.Anything, mock.Anything)
+		mockTwoFactor.AssertNotCalled(t, "StepUp", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		service, m, _, mockTwoFactor := prepare()
+		mockTwoFactor.On("VerifyStepUp", mock.Anything, 3, binding, answer).Return(entity.SecondFactor{UserID: 3, Binding: binding}, derror.NewUnauthorizedError("invalid two-factor code"))
+
+		answered := transfer
+		answered.TwoFactor = answer
+		_, err := service.Transfer(context.Background(), answered)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+		m.repo.AssertNotCalled(t, "BeginTx", mock.Anything)
+	})
+}
+
+func TestCreditCardPurchase(t *testing.T) {
+	creditCard := func() *entity.FinancialCard {
+		c := card(10, 1)
+		c.CardType = enum.Credit
+		return c
+	}
+	line := &entity.CreditLine{CreditLineID: 4, FinancialCardID: 10, AccountID: 1, Limit: usd(5000)}
+
+	t.Run("Purchase on credit", func(t *testing.T) {
+		service, m := setup()
+
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(-1000), nil)
+		m.lines.On("GetByCardID", mock.Anything, 10).Return(line, nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).
+			Run(postEntry(7, map[int]entity.Money{1: usd(-1000), settlementAccountID: usd(0)})).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+
+		transaction, err := service.Purchase(context.Background(), request.CardPurchase{UserID: 3, CardID: 10, Amount: usd(4000), Merchant: "Book Store"})
+		require.NoError(t, err)
+		assert.Equal(t, usd(-5000), transaction.Balance)
+	})
+
+	t.Run("Purchase over the credit limit", func(t *testing.T) {
+		service, m := setup()
+
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(-1000), nil)
+		m.lines.On("GetByCardID", mock.Anything, 10).Return(line, nil)
+
+		_, err := service.Purchase(context.Background(), request.CardPurchase{UserID: 3, CardID: 10, Amount: usd(4001), Merchant: "Book Store"})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Credit card without a credit line", func(t *testing.T) {
+		service, m := setup()
+
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(0), nil)
+		m.lines.On("GetByCardID", mock.Anything, 10).Return(nil, nil)
+
+		_, err := service.Purchase(context.Background(), request.CardPurchase{UserID: 3, CardID: 10, Amount: usd(100), Merchant: "Book Store"})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+	})
+
+	t.Run("Credit card cannot transfer", func(t *testing.T) {
+		service, m := setup()
+
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.cards.On("GetCardByID", mock.Anything, int64(11)).Return(card(11, 2), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+
+		_, err := service.Transfer(context.Background(), request.Transfer{UserID: 3, SenderCardID: 10, ReceiverCardID: 11, Amount: usd(100)})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
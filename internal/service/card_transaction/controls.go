@@ -0,0 +1,147 @@
+package cardtransaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const defaultHomeCountry = "IR"
+
+// payment is what the card controls look at in a purchase or an authorization.
+type payment struct {
+	Amount   entity.Money
+	Merchant string
+	MCC      string
+	Country  string
+	Online   bool
+}
+
+// enforceControls declines the payment with every control of the card it breaks, and with
+// every restriction a virtual card was issued with. It runs with the account balance locked,
+// so the limits see concurrent payments on the card.
+func (s *Service) enforceControls(ctx context.Context, card *entity.FinancialCard, p payment) error {
+	var violations []entity.PolicyViolation
+	violate := func(rule enum.PolicyRule, format string, args ...any) {
+		violations = append(violations, entity.PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if err := s.checkRestrictions(ctx, card, p, violate); err != nil {
+		return err
+	}
+
+	controls, err := s.cardControlsRepo.GetByCardID(ctx, card.CardID)
+	if err != nil {
+		s.logger.Error("Failed to get the card controls", zap.Error(err), zap.Int("CardID", card.CardID))
+		return err
+	}
+
+	if controls != nil {
+		if err := s.checkControls(ctx, card, controls, p, violate); err != nil {
+			return err
+		}
+	}
+
+	if len(violations) > 0 {
+		s.logger.Warn("Card payment declined by the card controls", zap.Int("CardID", card.CardID), zap.Any("violations", violations))
+		return derror.WithDetails(derror.NewValidationError("the controls of card %d decline the payment", card.CardID), violations)
+	}
+
+	return nil
+}
+
+func (s *Service) checkRestrictions(ctx context.Context, card *entity.FinancialCard, p payment,
+	violate func(rule enum.PolicyRule, format string, args ...any),
+) error {
+	switch card.Usage {
+	case enum.SingleUse:
+		used, err := s.cardTransactionRepo.HasPayments(ctx, card.CardID)
+		if err != nil {
+			s.logger.Error("Failed to check whether the card has been used", zap.Error(err), zap.Int("CardID", card.CardID))
+			return err
+		}
+		if used {
+			violate(enum.CardSingleUse, "the single-use card has already been used")
+		}
+	case enum.MerchantLocked:
+		if card.LockedMerchant == nil || !strings.EqualFold(strings.TrimSpace(p.Merchant), *card.LockedMerchant) {
+			violate(enum.CardMerchantLock, "the card only pays %s", stringValue(card.LockedMerchant))
+		}
+	}
+
+	if spendCap := card.SpendCap; !spendCap.IsZero() {
+		// Every purchase the card has made counts from the day it was issued, less its refunds
+		spent, err := s.cardTransactionRepo.SpentSince(ctx, card.CardID, p.Amount.Currency, time.Time{})
+		if err != nil {
+			s.logger.Error("Failed to sum the card's spending", zap.Error(err), zap.Int("CardID", card.CardID))
+			return err
+		}
+
+		if spent.Amount+p.Amount.Amount > spendCap.Amount {
+			violate(enum.CardSpendCap, "amount exceeds the card's spend cap of %s %s, of which %s is spent",
+				spendCap.String(), spendCap.Currency, spent.String())
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) checkControls(ctx context.Context, card *entity.FinancialCard, controls *entity.CardControls, p payment,
+	violate func(rule enum.PolicyRule, format string, args ...any),
+) error {
+	if controls.OnlineDisabled && p.Online {
+		violate(enum.CardControlOnline, "online payments are disabled on the card")
+	}
+
+	if controls.InternationalDisabled && p.Country != "" && p.Country != s.homeCountry() {
+		violate(enum.CardControlInternational, "payments at merchants outside %s are disabled on the card", s.homeCountry())
+	}
+
+	if len(controls.BlockedMCCs) > 0 {
+		if p.MCC == "" {
+			violate(enum.CardControlMCC, "the card blocks some merchant categories and the merchant's is unknown")
+		} else if controls.BlocksMCC(p.MCC) {
+			violate(enum.CardControlMCC, "merchant category %s is blocked on the card", p.MCC)
+		}
+	}
+
+	limit := controls.PerTransactionLimit
+	if !limit.IsZero() && p.Amount.Amount > limit.Amount {
+		violate(enum.CardControlPerTransactionLimit, "amount exceeds the card's per-transaction limit of %s %s", limit.String(), limit.Currency)
+	}
+
+	if limit := controls.DailyLimit; !limit.IsZero() {
+		spent, err := s.cardTransactionRepo.SpentSince(ctx, card.CardID, p.Amount.Currency, time.Now().AddDate(0, 0, -1))
+		if err != nil {
+			s.logger.Error("Failed to sum the card's daily spending", zap.Error(err), zap.Int("CardID", card.CardID))
+			return err
+		}
+
+		if spent.Amount+p.Amount.Amount > limit.Amount {
+			violate(enum.CardControlDailyLimit, "amount exceeds the card's daily limit of %s %s, of which %s is spent",
+				limit.String(), limit.Currency, spent.String())
+		}
+	}
+
+	return nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (s *Service) homeCountry() string {
+	if s.cfg.HomeCountry == "" {
+		return defaultHomeCountry
+	}
+	return s.cfg.HomeCountry
+}
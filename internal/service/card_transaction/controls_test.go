@@ -0,0 +1,217 @@
+package cardtransaction
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// withControls replaces the card-without-controls default of setup.
+func withControls(m mocks, controls *entity.CardControls) {
+	m.controls.ExpectedCalls = nil
+	m.controls.On("GetByCardID", mock.Anything, 10).Return(controls, nil)
+}
+
+func declinedRules(t *testing.T, err error) []enum.PolicyRule {
+	t.Helper()
+
+	require.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+	derr, ok := err.(*derror.Error)
+	require.True(t, ok)
+
+	var rules []enum.PolicyRule
+	for _, v := range derr.Details.([]entity.PolicyViolation) {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestEnforceControls(t *testing.T) {
+	base := entity.CardControls{
+		FinancialCardID:     10,
+		BlockedMCCs:         []string{},
+		PerTransactionLimit: usd(0),
+		DailyLimit:          usd(0),
+	}
+
+	tests := []struct {
+		name     string
+		controls func(c *entity.CardControls)
+		req      request.CardPurchase
+		want     []enum.PolicyRule
+	}{
+		{
+			name:     "Online payment on a card with online disabled",
+			controls: func(c *entity.CardControls) { c.OnlineDisabled = true },
+			req:      request.CardPurchase{Amount: usd(2500), Online: true},
+			want:     []enum.PolicyRule{enum.CardControlOnline},
+		},
+		{
+			name:     "Foreign merchant on a card with international disabled",
+			controls: func(c *entity.CardControls) { c.InternationalDisabled = true },
+			req:      request.CardPurchase{Amount: usd(2500), Country: "TR"},
+			want:     []enum.PolicyRule{enum.CardControlInternational},
+		},
+		{
+			name:     "Blocked merchant category",
+			controls: func(c *entity.CardControls) { c.BlockedMCCs = []string{"7995"} },
+			req:      request.CardPurchase{Amount: usd(2500), MCC: "7995"},
+			want:     []enum.PolicyRule{enum.CardControlMCC},
+		},
+		{
+			name:     "Unknown merchant category on a card that blocks some",
+			controls: func(c *entity.CardControls) { c.BlockedMCCs = []string{"7995"} },
+			req:      request.CardPurchase{Amount: usd(2500)},
+			want:     []enum.PolicyRule{enum.CardControlMCC},
+		},
+		{
+			name:     "Amount over the per-transaction limit",
+			controls: func(c *entity.CardControls) { c.PerTransactionLimit = usd(2000) },
+			req:      request.CardPurchase{Amount: usd(2500)},
+			want:     []enum.PolicyRule{enum.CardControlPerTransactionLimit},
+		},
+		{
+			name: "Every broken control is reported",
+			controls: func(c *entity.CardControls) {
+				c.OnlineDisabled = true
+				c.InternationalDisabled = true
+				c.PerTransactionLimit = usd(2000)
+			},
+			req:  request.CardPurchase{Amount: usd(2500), Online: true, Country: "DE"},
+			want: []enum.PolicyRule{enum.CardControlOnline, enum.CardControlInternational, enum.CardControlPerTransactionLimit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+
+			controls := base
+			tt.controls(&controls)
+			withControls(m, &controls)
+			m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+			m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+			m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+
+			req := tt.req
+			req.UserID, req.CardID, req.Merchant = 3, 10, "Shop"
+
+			_, err := service.Purchase(context.Background(), req)
+			assert.Equal(t, tt.want, declinedRules(t, err))
+			m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			m.repo.AssertCalled(t, "RollbackTx", mock.Anything)
+		})
+	}
+
+	t.Run("Daily limit counts the last 24 hours of spending", func(t *testing.T) {
+		service, m := setup()
+
+		controls := base
+		controls.DailyLimit = usd(10000)
+		withControls(m, &controls)
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(50000), nil)
+		m.repo.On("SpentSince", mock.Anything, 10, enum.USD, mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since) > 23*time.Hour && time.Since(since) <= 24*time.Hour+time.Minute
+		})).Return(usd(8000), nil)
+
+		_, err := service.Authorize(context.Background(), request.CardAuthorization{UserID: 3, CardID: 10, Amount: usd(2500), Merchant: "Hotel"})
+		assert.Equal(t, []enum.PolicyRule{enum.CardControlDailyLimit}, declinedRules(t, err))
+		m.holds.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Payment within the controls goes through", func(t *testing.T) {
+		service, m := setup()
+
+		controls := base
+		controls.OnlineDisabled = true
+		controls.BlockedMCCs = []string{"7995"}
+		controls.PerTransactionLimit = usd(5000)
+		controls.DailyLimit = usd(10000)
+		withControls(m, &controls)
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(card(10, 1), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+		m.repo.On("SpentSince", mock.Anything, 10, enum.USD, mock.Anything).Return(usd(7500), nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).
+			Run(postEntry(7, map[int]entity.Money{1: usd(10000), settlementAccountID: usd(0)})).Return(nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+
+		_, err := service.Purchase(context.Background(), request.CardPurchase{
+			UserID: 3, CardID: 10, Amount: usd(2500), Merchant: "Grocer", MCC: "5411", Country: "IR",
+		})
+		require.NoError(t, err)
+	})
+}
+
+func virtualCard(usage enum.CardUsage, merchant string, spendCap entity.Money) *entity.FinancialCard {
+	c := card(10, 1)
+	c.Virtual = true
+	c.Usage = usage
+	c.SpendCap = spendCap
+	if merchant != "" {
+		c.LockedMerchant = &merchant
+	}
+	return c
+}
+
+func TestVirtualCardRestrictions(t *testing.T) {
+	tests := []struct {
+		name     string
+		card     *entity.FinancialCard
+		merchant string
+		used     bool
+		spent    entity.Money
+		want     []enum.PolicyRule
+	}{
+		{name: "First payment of a single-use card", card: virtualCard(enum.SingleUse, "", usd(0)), merchant: "Shop"},
+		{name: "Second payment of a single-use card", card: virtualCard(enum.SingleUse, "", usd(0)), merchant: "Shop", used: true, want: []enum.PolicyRule{enum.CardSingleUse}},
+		{name: "Locked merchant in another case", card: virtualCard(enum.MerchantLocked, "Book Store", usd(0)), merchant: "book store"},
+		{name: "Another merchant", card: virtualCard(enum.MerchantLocked, "Book Store", usd(0)), merchant: "Casino", want: []enum.PolicyRule{enum.CardMerchantLock}},
+		{name: "Within the spend cap", card: virtualCard(enum.MultiUse, "", usd(5000)), merchant: "Shop", spent: usd(2500)},
+		{name: "Over the spend cap", card: virtualCard(enum.MultiUse, "", usd(5000)), merchant: "Shop", spent: usd(2600), want: []enum.PolicyRule{enum.CardSpendCap}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+
+			m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(tt.card, nil)
+			m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+			m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(10000), nil)
+			m.repo.On("HasPayments", mock.Anything, 10).Return(tt.used, nil)
+			m.repo.On("SpentSince", mock.Anything, 10, enum.USD, time.Time{}).Return(tt.spent, nil)
+			m.holds.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardHold")).Return(nil)
+
+			_, err := service.Authorize(context.Background(), request.CardAuthorization{UserID: 3, CardID: 10, Amount: usd(2500), Merchant: tt.merchant})
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.want, declinedRules(t, err))
+			m.holds.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Restricted card cannot transfer", func(t *testing.T) {
+		service, m := setup()
+
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(virtualCard(enum.SingleUse, "", usd(0)), nil)
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+
+		_, err := service.Transfer(context.Background(), request.Transfer{UserID: 3, SenderCardID: 10, ReceiverCardID: 11, Amount: usd(2500)})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
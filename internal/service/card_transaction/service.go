@@ -0,0 +1,58 @@
+package cardtransaction
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                     config.Card
+	riskCfg                 config.Risk
+	logger                  *zap.SugaredLogger
+	cardTransactionRepo     protocol.CardTransactionRepository
+	cardHoldRepo            protocol.CardHoldRepository
+	cardControlsRepo        protocol.CardControlsRepository
+	creditLineRepo          protocol.CreditLineRepository
+	ledgerRepo              protocol.LedgerRepository
+	financialCardService    protocol.FinancialCard
+	financialAccountService protocol.FinancialAccount
+	accountRulesService     protocol.AccountRules
+	idempotencyService      protocol.Idempotency
+	twoFactorService        protocol.TwoFactor
+	riskService             protocol.Risk
+}
+
+func New(
+	cfg config.Card,
+	riskCfg config.Risk,
+	logger *zap.SugaredLogger,
+	cardTransactionRepo protocol.CardTransactionRepository,
+	cardHoldRepo protocol.CardHoldRepository,
+	cardControlsRepo protocol.CardControlsRepository,
+	creditLineRepo protocol.CreditLineRepository,
+	ledgerRepo protocol.LedgerRepository,
+	financialCardService protocol.FinancialCard,
+	financialAccountService protocol.FinancialAccount,
+	accountRulesService protocol.AccountRules,
+	idempotencyService protocol.Idempotency,
+	twoFactorService protocol.TwoFactor,
+	riskService protocol.Risk,
+) *Service {
+	return &Service{
+		cfg:                     cfg,
+		riskCfg:                 riskCfg,
+		logger:                  logger,
+		cardTransactionRepo:     cardTransactionRepo,
+		cardHoldRepo:            cardHoldRepo,
+		cardControlsRepo:        cardControlsRepo,
+		creditLineRepo:          creditLineRepo,
+		ledgerRepo:              ledgerRepo,
+		financialCardService:    financialCardService,
+		financialAccountService: financialAccountService,
+		accountRulesService:     accountRulesService,
+		idempotencyService:      idempotencyService,
+		twoFactorService:        twoFactorService,
+		riskService:             riskService,
+	}
+}
@@ -0,0 +1,71 @@
+package cardtransaction
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSweepInterval  = time.Minute
+	defaultSweepBatchSize = 100
+)
+
+func (s *Service) RunHoldSweeper(ctx context.Context) error {
+	s.logger.Info("Card hold sweeper started", zap.Duration("sweepInterval", s.sweepInterval()))
+	defer s.logger.Info("Card hold sweeper stopped")
+
+	ticker := time.NewTicker(s.sweepInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireHolds(context.Background(), time.Now()); err != nil {
+			s.logger.Error("Failed to expire the stale card holds", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireHolds works through the expired holds a batch at a time. Expiring posts nothing to
+// the ledger: the reserved funds become available again as soon as the hold is closed.
+func (s *Service) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		holds, err := s.cardHoldRepo.ExpireDue(ctx, now, s.sweepBatchSize())
+		if err != nil {
+			return expired, err
+		}
+
+		for _, hold := range holds {
+			s.logger.Info("Card hold expired uncaptured",
+				zap.Int("HoldID", hold.HoldID),
+				zap.Int("CardID", hold.FinancialCardID),
+				zap.String("Amount", hold.Amount.String()))
+		}
+		expired += len(holds)
+
+		if len(holds) < s.sweepBatchSize() {
+			return expired, nil
+		}
+	}
+}
+
+func (s *Service) sweepInterval() time.Duration {
+	if s.cfg.SweepInterval <= 0 {
+		return defaultSweepInterval
+	}
+	return s.cfg.SweepInterval
+}
+
+func (s *Service) sweepBatchSize() int {
+	if s.cfg.SweepBatchSize <= 0 {
+		return defaultSweepBatchSize
+	}
+	return s.cfg.SweepBatchSize
+}
@@ -0,0 +1,61 @@
+package cardvault
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	logger         *zap.SugaredLogger
+	cardVaultRepo  protocol.CardVaultRepository
+	activeKeyID    string
+	keys           map[string][]byte
+	fingerprintKey []byte
+}
+
+// New decodes the vault keys up front so that a bad key stops the service from starting
+// instead of failing the first card registration.
+func New(cfg config.CardVault, logger *zap.SugaredLogger, cardVaultRepo protocol.CardVaultRepository) (*Service, error) {
+	keys := make(map[string][]byte, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		key, err := decodeKey(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("card vault key %q: %w", k.ID, err)
+		}
+		keys[k.ID] = key
+	}
+
+	if _, ok := keys[cfg.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("card vault: active key %q is not configured", cfg.ActiveKeyID)
+	}
+
+	fingerprintKey, err := decodeKey(cfg.FingerprintKey)
+	if err != nil {
+		return nil, fmt.Errorf("card vault fingerprint key: %w", err)
+	}
+
+	return &Service{
+		logger:         logger,
+		cardVaultRepo:  cardVaultRepo,
+		activeKeyID:    cfg.ActiveKeyID,
+		keys:           keys,
+		fingerprintKey: fingerprintKey,
+	}, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("want 32 bytes, got %d", len(key))
+	}
+
+	return key, nil
+}
@@ -0,0 +1,171 @@
+package cardvault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const tokenPrefix = "tok_"
+
+func (s *Service) Tokenize(ctx context.Context, number string) (string, error) {
+	fingerprint := s.fingerprint(number)
+
+	existing, err := s.cardVaultRepo.GetByFingerprint(ctx, fingerprint)
+	if err != nil {
+		s.logger.Error("Failed to look the card number up in the vault", zap.Error(err))
+		return "", derror.NewInternalSystemError()
+	}
+
+	if existing != nil {
+		return existing.Token, nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		s.logger.Error("Failed to generate a vault token", zap.Error(err))
+		return "", derror.NewInternalSystemError()
+	}
+
+	entry, err := s.seal(token, number)
+	if err != nil {
+		s.logger.Error("Failed to seal the card number", zap.Error(err))
+		return "", derror.NewInternalSystemError()
+	}
+	entry.Fingerprint = fingerprint
+
+	if err := s.cardVaultRepo.Insert(ctx, entry); err != nil {
+		s.logger.Error("Failed to store the card number in the vault", zap.Error(err))
+		return "", derror.NewInternalSystemError()
+	}
+
+	return token, nil
+}
+
+func (s *Service) Detokenize(ctx context.Context, token string) (string, error) {
+	entry, err := s.cardVaultRepo.GetByToken(ctx, token)
+	if err != nil {
+		s.logger.Error("Failed to read the vault", zap.Error(err))
+		return "", derror.NewInternalSystemError()
+	}
+
+	if entry == nil {
+		return "", derror.NewNotFoundError("vault token not found")
+	}
+
+	number, err := s.open(entry)
+	if err != nil {
+		// Never log the token's number, only that it could not be opened
+		s.logger.Error("Failed to open the vault entry", zap.Error(err), zap.String("keyID", entry.KeyID))
+		return "", derror.NewInternalSystemError()
+	}
+
+	return number, nil
+}
+
+// seal encrypts the number under a fresh data key and wraps the data key with the active
+// vault key. The token is bound to the ciphertext as additional data, so entries cannot be
+// swapped between tokens.
+func (s *Service) seal(token, number string) (*entity.VaultEntry, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(dataKey, []byte(number), []byte(token))
+	if err != nil {
+		return nil, fmt.Errorf("encrypting the number: %w", err)
+	}
+
+	wrappedKey, err := encrypt(s.keys[s.activeKeyID], dataKey, []byte(s.activeKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("wrapping the data key: %w", err)
+	}
+
+	return &entity.VaultEntry{
+		Token:      token,
+		KeyID:      s.activeKeyID,
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func (s *Service) open(entry *entity.VaultEntry) (string, error) {
+	key, ok := s.keys[entry.KeyID]
+	if !ok {
+		return "", fmt.Errorf("vault key %q is not configured", entry.KeyID)
+	}
+
+	dataKey, err := decrypt(key, entry.WrappedKey, []byte(entry.KeyID))
+	if err != nil {
+		return "", fmt.Errorf("unwrapping the data key: %w", err)
+	}
+
+	number, err := decrypt(dataKey, entry.Ciphertext, []byte(entry.Token))
+	if err != nil {
+		return "", fmt.Errorf("decrypting the number: %w", err)
+	}
+
+	return string(number), nil
+}
+
+func (s *Service) fingerprint(number string) []byte {
+	mac := hmac.New(sha256.New, s.fingerprintKey)
+	mac.Write([]byte(number))
+	return mac.Sum(nil)
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + hex.EncodeToString(b), nil
+}
+
+// encrypt seals plaintext with AES-256-GCM and returns the nonce followed by the ciphertext.
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
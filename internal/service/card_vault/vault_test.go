@@ -0,0 +1,150 @@
+package cardvault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const cardNumber = "6037991234567890"
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func vaultConfig(activeKeyID string, keys ...config.VaultKey) config.CardVault {
+	return config.CardVault{ActiveKeyID: activeKeyID, Keys: keys, FingerprintKey: key('f')}
+}
+
+func setup(t *testing.T, cfg config.CardVault) (*Service, *protocol.MockCardVaultRepo) {
+	repo := new(protocol.MockCardVaultRepo)
+	service, err := New(cfg, zap.NewNop().Sugar(), repo)
+	require.NoError(t, err)
+	return service, repo
+}
+
+// tokenize stores a number in a fresh vault and returns the entry that was inserted.
+func tokenize(t *testing.T, service *Service, repo *protocol.MockCardVaultRepo) *entity.VaultEntry {
+	var inserted *entity.VaultEntry
+	repo.On("GetByFingerprint", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.VaultEntry")).Run(func(args mock.Arguments) {
+		inserted = args.Get(1).(*entity.VaultEntry)
+	}).Return(nil).Once()
+
+	token, err := service.Tokenize(context.Background(), cardNumber)
+	require.NoError(t, err)
+	require.NotNil(t, inserted)
+	assert.Equal(t, inserted.Token, token)
+	return inserted
+}
+
+func TestTokenize(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		service, repo := setup(t, vaultConfig("k1", config.VaultKey{ID: "k1", Key: key(1)}))
+
+		entry := tokenize(t, service, repo)
+		assert.True(t, strings.HasPrefix(entry.Token, tokenPrefix))
+		assert.Equal(t, "k1", entry.KeyID)
+		assert.NotContains(t, string(entry.Ciphertext), cardNumber)
+
+		repo.On("GetByToken", mock.Anything, entry.Token).Return(entry, nil)
+		number, err := service.Detokenize(context.Background(), entry.Token)
+		require.NoError(t, err)
+		assert.Equal(t, cardNumber, number)
+	})
+
+	t.Run("Known number keeps its token", func(t *testing.T) {
+		service, repo := setup(t, vaultConfig("k1", config.VaultKey{ID: "k1", Key: key(1)}))
+
+		existing := &entity.VaultEntry{Token: "tok_existing"}
+		repo.On("GetByFingerprint", mock.Anything, service.fingerprint(cardNumber)).Return(existing, nil)
+
+		token, err := service.Tokenize(context.Background(), cardNumber)
+		require.NoError(t, err)
+		assert.Equal(t, "tok_existing", token)
+		repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDetokenize(t *testing.T) {
+	t.Run("Opens entries sealed before a key rotation", func(t *testing.T) {
+		before, repo := setup(t, vaultConfig("k1", config.VaultKey{ID: "k1", Key: key(1)}))
+		entry := tokenize(t, before, repo)
+
+		after, repo := setup(t, vaultConfig("k2",
+			config.VaultKey{ID: "k1", Key: key(1)},
+			config.VaultKey{ID: "k2", Key: key(2)},
+		))
+		repo.On("GetByToken", mock.Anything, entry.Token).Return(entry, nil)
+
+		number, err := after.Detokenize(context.Background(), entry.Token)
+		require.NoError(t, err)
+		assert.Equal(t, cardNumber, number)
+
+		assert.Equal(t, "k2", tokenize(t, after, repo).KeyID)
+	})
+
+	t.Run("Entries cannot be swapped between tokens", func(t *testing.T) {
+		service, repo := setup(t, vaultConfig("k1", config.VaultKey{ID: "k1", Key: key(1)}))
+		entry := tokenize(t, service, repo)
+
+		swapped := *entry
+		swapped.Token = "tok_other"
+		repo.On("GetByToken", mock.Anything, "tok_other").Return(&swapped, nil)
+
+		_, err := service.Detokenize(context.Background(), "tok_other")
+		assert.True(t, derror.IsHTTPError(err, http.StatusInternalServerError), "got %v", err)
+	})
+
+	t.Run("Tampered ciphertext", func(t *testing.T) {
+		service, repo := setup(t, vaultConfig("k1", config.VaultKey{ID: "k1", Key: key(1)}))
+		entry := tokenize(t, service, repo)
+		entry.Ciphertext[len(entry.Ciphertext)-1] ^= 0xff
+
+		repo.On("GetByToken", mock.Anything, entry.Token).Return(entry, nil)
+
+		_, err := service.Detokenize(context.Background(), entry.Token)
+		assert.True(t, derror.IsHTTPError(err, http.StatusInternalServerError), "got %v", err)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		service, repo := setup(t, vaultConfig("k1", config.VaultKey{ID: "k1", Key: key(1)}))
+		repo.On("GetByToken", mock.Anything, "tok_missing").Return(nil, nil)
+
+		_, err := service.Detokenize(context.Background(), "tok_missing")
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+}
+
+func TestNew(t *testing.T) {
+	short := base64.StdEncoding.EncodeToString([]byte("too short"))
+
+	tests := []struct {
+		name string
+		cfg  config.CardVault
+	}{
+		{name: "Key of the wrong size", cfg: vaultConfig("k1", config.VaultKey{ID: "k1", Key: short})},
+		{name: "Key not base64", cfg: vaultConfig("k1", config.VaultKey{ID: "k1", Key: "not base64!"})},
+		{name: "Active key missing", cfg: vaultConfig("k2", config.VaultKey{ID: "k1", Key: key(1)})},
+		{name: "Bad fingerprint key", cfg: config.CardVault{ActiveKeyID: "k1", Keys: []config.VaultKey{{ID: "k1", Key: key(1)}}, FingerprintKey: short}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg, zap.NewNop().Sugar(), new(protocol.MockCardVaultRepo))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package creditcard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	cardAccountID     = 1
+	paymentAccountID  = 2
+	interestAccountID = 60
+	feeAccountID      = 61
+	lateFee           = 2900
+)
+
+type mocks struct {
+	lines        *protocol.MockCreditLineRepo
+	transactions *protocol.MockCardTransactionRepo
+	ledger       *protocol.MockLedgerRepo
+	cards        *protocol.MockFinancialCardService
+	accounts     *protocol.MockFinancialAccountService
+}
+
+func setup() (*Service, mocks) {
+	m := mocks{
+		lines:        new(protocol.MockCreditLineRepo),
+		transactions: new(protocol.MockCardTransactionRepo),
+		ledger:       new(protocol.MockLedgerRepo),
+		cards:        new(protocol.MockFinancialCardService),
+		accounts:     new(protocol.MockFinancialAccountService),
+	}
+	mockRules := new(protocol.MockAccountRulesService)
+	mockRules.On("Evaluate", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	mockIdempotency := new(protocol.MockIdempotencyService)
+	mockIdempotency.On("Complete", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockTwoFactor := new(protocol.MockTwoFactorService)
+	mockTwoFactor.On("VerifyStepUp", mock.Anything, mock.Anything, mock.Anything, (*request.TwoFactorAnswer)(nil)).Return(entity.SecondFactor{}, nil).Maybe()
+	m.lines.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	m.lines.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	m.lines.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	m.lines.On("Update", mock.Anything, mock.AnythingOfType("*entity.CreditLine")).Return(nil).Maybe()
+	m.lines.On("UpdateStatement", mock.Anything, mock.AnythingOfType("*entity.CreditStatement")).Return(nil).Maybe()
+	m.transactions.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CardTransaction")).Return(nil).Maybe()
+	m.ledger.On("HeldAmount", mock.Anything, mock.Anything, mock.Anything).Return(usd(0), nil).Maybe()
+	m.accounts.On("GetAccountByID", mock.Anything, cardAccountID).Return(response.GetFinancialAccount{AccountID: cardAccountID, UserID: 3}, nil).Maybe()
+	m.accounts.On("GetAccountByID", mock.Anything, paymentAccountID).
+		Return(response.GetFinancialAccount{AccountID: paymentAccountID, UserID: 3, AccountType: enum.Checking}, nil).Maybe()
+	m.accounts.On("GetAccountStatus", mock.Anything, paymentAccountID).Return(enum.Verified, nil).Maybe()
+	m.accounts.On("GetAccountCurrency", mock.Anything, mock.Anything).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil).Maybe()
+
+	cfg := config.CreditCard{
+		Accounts: []config.CreditCardAccounts{{
+			Currency:          "USD",
+			InterestAccountID: interestAccountID,
+			FeeAccountID:      feeAccountID,
+			MinimumPayment:    2500,
+			LateFee:           lateFee,
+		}},
+	}
+
+	logger, _ := zap.NewProduction()
+
+	service := New(cfg, logger.Sugar(), m.lines, m.transactions, m.ledger, m.cards, m.accounts, mockRules, mockIdempotency, mockTwoFactor)
+	return service, m
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+func creditLine() *entity.CreditLine {
+	return &entity.CreditLine{
+		CreditLineID:     4,
+		FinancialCardID:  10,
+		AccountID:        cardAccountID,
+		PaymentAccountID: paymentAccountID,
+		Limit:            usd(500000),
+		APR:              1825,
+		StatementDay:     5,
+		FeesDue:          usd(0),
+		InterestDue:      usd(0),
+		AccruedInterest:  usd(0),
+	}
+}
+
+// postEntry fills in the entry the way the ledger does, from the balances before it.
+func postEntry(journalEntryID int, opening map[int]entity.Money) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		entry := args.Get(1).(*entity.JournalEntry)
+		entry.JournalEntryID = journalEntryID
+		for _, p := range entry.Postings {
+			p.JournalEntryID = journalEntryID
+			p.BalanceAfter, _ = opening[p.FinancialAccountID].Add(p.Amount)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	line := creditLine()
+	line.FeesDue = usd(2900)
+	line.InterestDue = usd(1500)
+
+	fees, interest, principal := allocate(line, usd(3500))
+	assert.Equal(t, usd(2900), fees)
+	assert.Equal(t, usd(600), interest)
+	assert.Equal(t, usd(0), principal)
+	assert.Equal(t, usd(0), line.FeesDue)
+	assert.Equal(t, usd(900), line.InterestDue)
+
+	fees, interest, principal = allocate(line, usd(5000))
+	assert.Equal(t, usd(0), fees)
+	assert.Equal(t, usd(900), interest)
+	assert.Equal(t, usd(4100), principal)
+	assert.Equal(t, usd(0), line.InterestDue)
+}
+
+func TestOpenCreditLine(t *testing.T) {
+	req := request.OpenCreditLine{CardID: 10, PaymentAccountID: paymentAccountID, Limit: usd(500000), APR: 1825, StatementDay: 5}
+	creditCard := func() *entity.FinancialCard {
+		return &entity.FinancialCard{CardID: 10, AccountID: cardAccountID, CardType: enum.Credit}
+	}
+
+	t.Run("Successfully open", func(t *testing.T) {
+		service, m := setup()
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(nil, nil)
+		m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(0), nil)
+		m.lines.On("Insert", mock.Anything, mock.AnythingOfType("*entity.CreditLine")).Return(nil)
+
+		line, err := service.OpenCreditLine(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, cardAccountID, line.AccountID)
+		assert.Equal(t, usd(500000), line.Limit)
+		assert.Equal(t, 5, line.NextStatementAt.Day())
+		assert.True(t, line.NextStatementAt.After(time.Now()))
+		m.lines.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+
+	t.Run("Card is not a credit card", func(t *testing.T) {
+		service, m := setup()
+		debit := creditCard()
+		debit.CardType = enum.Debit
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(debit, nil)
+
+		_, err := service.OpenCreditLine(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+	})
+
+	t.Run("Card already has a credit line", func(t *testing.T) {
+		service, m := setup()
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(creditLine(), nil)
+
+		_, err := service.OpenCreditLine(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		m.lines.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Card account holds money", func(t *testing.T) {
+		service, m := setup()
+		m.cards.On("GetCardByID", mock.Anything, int64(10)).Return(creditCard(), nil)
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(nil, nil)
+		m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(1200), nil)
+
+		_, err := service.OpenCreditLine(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.lines.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMakePayment(t *testing.T) {
+	req := request.CreditCardPayment{UserID: 3, CardID: 10, Amount: usd(20000)}
+
+	t.Run("Payment goes to fees and interest first", func(t *testing.T) {
+		service, m := setup()
+		line := creditLine()
+		line.FeesDue = usd(2900)
+		line.InterestDue = usd(1500)
+		line.Revolving = true
+		statement := &entity.CreditStatement{CreditStatementID: 8, CreditLineID: 4, ClosingBalance: usd(20000), Paid: usd(0), Status: enum.StatementOpen}
+
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(line, nil)
+		m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(-30000), nil)
+		m.ledger.On("LockBalance", mock.Anything, paymentAccountID, enum.USD).Return(usd(50000), nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).
+			Run(postEntry(7, map[int]entity.Money{cardAccountID: usd(-30000), paymentAccountID: usd(50000)})).Return(nil)
+		m.lines.On("LatestStatement", mock.Anything, 4).Return(statement, nil)
+
+		res, err := service.MakePayment(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, usd(2900), res.Fees)
+		assert.Equal(t, usd(1500), res.Interest)
+		assert.Equal(t, usd(15600), res.Principal)
+		assert.Equal(t, usd(10000), res.Owed)
+		assert.Equal(t, enum.CardPayment, res.Transaction.Type)
+		assert.Equal(t, usd(-10000), res.Transaction.Balance)
+
+		m.ledger.AssertCalled(t, "Post", mock.Anything, mock.MatchedBy(func(e *entity.JournalEntry) bool {
+			return e.IsBalanced() && e.PostingFor(paymentAccountID).Amount == usd(-20000) && e.PostingFor(cardAccountID).Amount == usd(20000)
+		}))
+		assert.Equal(t, enum.StatementPaid, statement.Status)
+		assert.False(t, line.Revolving)
+		m.lines.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+
+	t.Run("Partial payment leaves the statement open", func(t *testing.T) {
+		service, m := setup()
+		line := creditLine()
+		line.Revolving = true
+		statement := &entity.CreditStatement{CreditStatementID: 8, CreditLineID: 4, ClosingBalance: usd(30000), Paid: usd(0), Status: enum.StatementOpen}
+
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(line, nil)
+		m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(-30000), nil)
+		m.ledger.On("LockBalance", mock.Anything, paymentAccountID, enum.USD).Return(usd(50000), nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).
+			Run(postEntry(7, map[int]entity.Money{cardAccountID: usd(-30000), paymentAccountID: usd(50000)})).Return(nil)
+		m.lines.On("LatestStatement", mock.Anything, 4).Return(statement, nil)
+
+		_, err := service.MakePayment(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, usd(20000), statement.Paid)
+		assert.Equal(t, enum.StatementOpen, statement.Status)
+		assert.True(t, line.Revolving)
+	})
+
+	t.Run("Payment above what is owed", func(t *testing.T) {
+		service, m := setup()
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(creditLine(), nil)
+		m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(-15000), nil)
+		m.ledger.On("LockBalance", mock.Anything, paymentAccountID, enum.USD).Return(usd(50000), nil)
+
+		_, err := service.MakePayment(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Insufficient funds", func(t *testing.T) {
+		service, m := setup()
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(creditLine(), nil)
+		m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(-30000), nil)
+		m.ledger.On("LockBalance", mock.Anything, paymentAccountID, enum.USD).Return(usd(5000), nil)
+
+		_, err := service.MakePayment(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Card of another user", func(t *testing.T) {
+		service, m := setup()
+		m.lines.On("GetByCardIDForUpdate", mock.Anything, 10).Return(creditLine(), nil)
+
+		_, err := service.MakePayment(context.Background(), request.CreditCardPayment{UserID: 4, CardID: 10, Amount: usd(20000)})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+}
+
+func TestAccrue(t *testing.T) {
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Revolving line accrues daily interest on the principal", func(t *testing.T) {
+		service, m := setup()
+		line := creditLine()
+		line.Revolving = true
+		line.InterestDue = usd(4000)
+		line.InterestAccruedOn = today.AddDate(0, 0, -2)
+		m.lines.On("GetForUpdate", mock.Anything, 4).Return(line, nil)
+		m.ledger.On("GetBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(-104000), nil)
+
+		require.NoError(t, service.accrue(context.Background(), 4, today))
+		// 100000 of principal at 18.25% a year is 50 a day
+		assert.Equal(t, usd(100), line.AccruedInterest)
+		assert.Equal(t, today, line.InterestAccruedOn)
+	})
+
+	t.Run("Line paid in full accrues nothing", func(t *testing.T) {
+		service, m := setup()
+		line := creditLine()
+		line.InterestAccruedOn = today.AddDate(0, 0, -1)
+		m.lines.On("GetForUpdate", mock.Anything, 4).Return(line, nil)
+
+		require.NoError(t, service.accrue(context.Background(), 4, today))
+		assert.Equal(t, usd(0), line.AccruedInterest)
+		assert.Equal(t, today, line.InterestAccruedOn)
+		m.ledger.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCloseStatement(t *testing.T) {
+	now := time.Date(2026, 3, 5, 0, 30, 0, 0, time.UTC)
+	periodEnd := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	service, m := setup()
+	line := creditLine()
+	line.Revolving = true
+	line.AccruedInterest = usd(1200)
+	line.NextStatementAt = periodEnd
+	previous := &entity.CreditStatement{CreditStatementID: 7, PeriodEnd: periodEnd.AddDate(0, -1, 0), ClosingBalance: usd(40000)}
+	transactions := []*entity.CardTransaction{
+		{Type: enum.CardPurchase, Amount: usd(-80000)},
+		{Type: enum.CardRefund, Amount: usd(5000)},
+		{Type: enum.CardPayment, Amount: usd(15000)},
+		{Type: enum.CardInterest, Amount: usd(-1200)},
+	}
+
+	m.lines.On("GetForUpdate", mock.Anything, 4).Return(line, nil)
+	m.ledger.On("LockBalance", mock.Anything, cardAccountID, enum.USD).Return(usd(-300000), nil)
+	m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).
+		Run(postEntry(9, map[int]entity.Money{cardAccountID: usd(-300000), interestAccountID: usd(0)})).Return(nil)
+	m.lines.On("LatestStatement", mock.Anything, 4).Return(previous, nil)
+	m.lines.On("InsertStatement", mock.Anything, mock.AnythingOfType("*entity.CreditStatement")).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.CreditStatement).CreditStatementID = 8
+	}).Return(nil)
+	m.transactions.On("AssignToStatement", mock.Anything, cardAccountID, 8).Return(nil)
+	m.transactions.On("ListByStatementID", mock.Anything, 8).Return(transactions, nil)
+
+	require.NoError(t, service.closeStatement(context.Background(), 4, now))
+
+	var statement *entity.CreditStatement
+	for _, call := range m.lines.Calls {
+		if call.Method == "UpdateStatement" {
+			statement = call.Arguments.Get(1).(*entity.CreditStatement)
+		}
+	}
+	require.NotNil(t, statement)
+
+	m.ledger.AssertCalled(t, "Post", mock.Anything, mock.MatchedBy(func(e *entity.JournalEntry) bool {
+		return e.PostingFor(cardAccountID).Amount == usd(-1200) && e.PostingFor(interestAccountID).Amount == usd(1200)
+	}))
+	assert.Equal(t, previous.PeriodEnd, statement.PeriodStart)
+	assert.Equal(t, usd(40000), statement.OpeningBalance)
+	assert.Equal(t, usd(301200), statement.ClosingBalance)
+	assert.Equal(t, usd(80000), statement.Purchases)
+	assert.Equal(t, usd(5000), statement.Credits)
+	assert.Equal(t, usd(15000), statement.Payments)
+	assert.Equal(t, usd(1200), statement.InterestCharged)
+	// 1% of the 300000 principal plus the 1200 of interest
+	assert.Equal(t, usd(4200), statement.MinimumPayment)
+	assert.Equal(t, periodEnd.Add(defaultGracePeriod), statement.DueDate)
+	assert.Equal(t, enum.StatementOpen, statement.Status)
+
+	assert.Equal(t, usd(0), line.AccruedInterest)
+	assert.Equal(t, usd(1200), line.InterestDue)
+	assert.Equal(t, periodEnd.AddDate(0, 1, 0), line.NextStatementAt)
+}
+
+func TestMinimumPayment(t *testing.T) {
+	service, _ := setup()
+	line := creditLine()
+
+	minimum, err := service.minimumPayment(line, usd(30000), 2500)
+	require.NoError(t, err)
+	assert.Equal(t, usd(2500), minimum, "the floor applies to small balances")
+
+	minimum, err = service.minimumPayment(line, usd(1800), 2500)
+	require.NoError(t, err)
+	assert.Equal(t, usd(1800), minimum, "never more than is owed")
+}
+
+func TestSettleStatement(t *testing.T) {
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	statement := func(paid int64) *entity.CreditStatement {
+		return &entity.CreditStatement{
+			CreditStatementID: 8,
+			CreditLineID:      4,
+			ClosingBalance:    usd(30000),
+			MinimumPayment:    usd(2500),
+			Paid:              usd(paid),
+			DueDate:           now.Add(-time.Hour),
+			Status:            enum.StatementOpen,
+		}
+	}
+
+	t.Run("Minimum paid revolves the balance", func(t *testing.T) {
+		service, m := setup()
+		line := creditLine()
+		s := statement(2500)
+		m.lines.On("GetForUpdate", mock.Anything, 4).Return(line, nil)
+		m.lines.On("GetStatement", mock.Anything, 8).Return(s, nil)
+
+		require.NoError(t, service.settleStatement(context.Background(), 4, 8, now))
+		assert.Equal(t, enum.StatementRevolved, s.Status)
+		assert.True(t, line.Revolving)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Minimum missed charges the late fee", func(t *testing.T) {
+		service, m := setup()
+		line := creditLine()
+		s := statement(1000)
+		m.lines.On("GetForUpdate", mock.Anything, 4).Return(line, nil)
+		m.lines.On("GetStatement", mock.Anything, 8).Return(s, nil)
+		m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).
+			Run(postEntry(9, map[int]entity.Money{cardAccountID: usd(-30000), feeAccountID: usd(0)})).Return(nil)
+
+		require.NoError(t, service.settleStatement(context.Background(), 4, 8, now))
+		assert.Equal(t, enum.StatementLate, s.Status)
+		assert.True(t, line.Revolving)
+		assert.Equal(t, usd(lateFee), line.FeesDue)
+		m.ledger.AssertCalled(t, "Post", mock.Anything, mock.MatchedBy(func(e *entity.JournalEntry) bool {
+			return e.PostingFor(cardAccountID).Amount == usd(-lateFee) && e.PostingFor(feeAccountID).Amount == usd(lateFee)
+		}))
+		m.transactions.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(tr *entity.CardTransaction) bool {
+			return tr.Type == enum.CardFee && tr.Balance == usd(-30000-lateFee)
+		}))
+	})
+
+	t.Run("Statement paid while waiting for the lock", func(t *testing.T) {
+		service, m := setup()
+		s := statement(30000)
+		s.Status = enum.StatementPaid
+		m.lines.On("GetForUpdate", mock.Anything, 4).Return(creditLine(), nil)
+		m.lines.On("GetStatement", mock.Anything, 8).Return(s, nil)
+
+		require.NoError(t, service.settleStatement(context.Background(), 4, 8, now))
+		m.lines.AssertNotCalled(t, "UpdateStatement", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,370 @@
+package creditcard
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) OpenCreditLine(ctx context.Context, req request.OpenCreditLine) (line *entity.CreditLine, err error) {
+	s.logger.Info("Opening credit line",
+		zap.Int("CardID", req.CardID),
+		zap.String("Limit", req.Limit.String()),
+		zap.String("Currency", string(req.Limit.Currency)))
+
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	card, err := s.financialCardService.GetCardByID(ctx, int64(req.CardID))
+	if err != nil {
+		return nil, err
+	}
+
+	if card.DeletedAt != nil {
+		return nil, derror.NewNotFoundError("card %d not found", req.CardID)
+	}
+
+	if card.CardType != enum.Credit {
+		return nil, derror.NewValidationError("card %d is not a credit card", req.CardID)
+	}
+
+	if req.PaymentAccountID == card.AccountID {
+		return nil, derror.NewBadRequestError("the card cannot be paid from its own account")
+	}
+
+	if err := s.checkPaymentAccount(ctx, card, req.PaymentAccountID, req.Limit.Currency); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.accountsFor(req.Limit.Currency); err != nil {
+		return nil, err
+	}
+
+	ctx, err = s.creditLineRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.creditLineRepo.RollbackTx(ctx)
+		}
+	}()
+
+	existing, err := s.creditLineRepo.GetByCardIDForUpdate(ctx, card.CardID)
+	if err != nil {
+		s.logger.Error("Failed to get the credit line", zap.Error(err), zap.Int("CardID", card.CardID))
+		return nil, err
+	}
+
+	if existing != nil {
+		err = derror.NewConflictError("card %d already has a credit line", card.CardID)
+		return nil, err
+	}
+
+	// Whatever the account held would otherwise be mixed up with what the card owes
+	balance, err := s.ledgerRepo.LockBalance(ctx, card.AccountID, req.Limit.Currency)
+	if err != nil {
+		s.logger.Error("Failed to lock the account balance", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return nil, err
+	}
+
+	if !balance.IsZero() {
+		err = derror.NewValidationError("the account linked to card %d must be empty to carry a credit line", card.CardID)
+		return nil, err
+	}
+
+	now := time.Now()
+	zero := entity.NewMoney(0, req.Limit.Currency)
+	line = &entity.CreditLine{
+		FinancialCardID:   card.CardID,
+		AccountID:         card.AccountID,
+		PaymentAccountID:  req.PaymentAccountID,
+		Limit:             req.Limit,
+		APR:               req.APR,
+		StatementDay:      req.StatementDay,
+		FeesDue:           zero,
+		InterestDue:       zero,
+		AccruedInterest:   zero,
+		InterestAccruedOn: startOfDay(now),
+		NextStatementAt:   nextStatementAt(now, req.StatementDay),
+	}
+
+	if err = s.creditLineRepo.Insert(ctx, line); err != nil {
+		s.logger.Error("Failed to insert the credit line", zap.Error(err))
+		return nil, err
+	}
+
+	if err = s.creditLineRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Credit line opened", zap.Int("CreditLineID", line.CreditLineID), zap.Int("CardID", card.CardID))
+
+	return line, nil
+}
+
+// UpdateCreditLimit changes the limit of the card's line. A limit below what is owed stops
+// new spending but does not call in the debt.
+func (s *Service) UpdateCreditLimit(ctx context.Context, req request.UpdateCreditLimit) (line *entity.CreditLine, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.creditLineRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.creditLineRepo.RollbackTx(ctx)
+		}
+	}()
+
+	line, err = s.creditLineRepo.GetByCardIDForUpdate(ctx, req.CardID)
+	if err != nil {
+		s.logger.Error("Failed to lock the credit line", zap.Error(err), zap.Int("CardID", req.CardID))
+		return nil, err
+	}
+
+	if line == nil {
+		err = derror.NewNotFoundError("card %d has no credit line", req.CardID)
+		return nil, err
+	}
+
+	if req.Limit.Currency != line.Limit.Currency {
+		err = derror.NewBadRequestError("the limit must be in %s, the currency of the credit line", line.Limit.Currency)
+		return nil, err
+	}
+
+	line.Limit = req.Limit
+	if err = s.creditLineRepo.Update(ctx, line); err != nil {
+		s.logger.Error("Failed to update the credit line", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+		return nil, err
+	}
+
+	if err = s.creditLineRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return line, nil
+}
+
+func (s *Service) GetCreditLine(ctx context.Context, req request.CardAction) (response.CreditLine, error) {
+	line, err := s.ownedLine(ctx, req)
+	if err != nil {
+		return response.CreditLine{}, err
+	}
+
+	currency := line.Limit.Currency
+	balance, err := s.ledgerRepo.GetBalance(ctx, line.AccountID, currency)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account balance", zap.Error(err), zap.Int("accountID", line.AccountID))
+		return response.CreditLine{}, derror.NewInternalSystemError()
+	}
+
+	held, err := s.ledgerRepo.HeldAmount(ctx, line.AccountID, currency)
+	if err != nil {
+		s.logger.Error("Failed to sum the authorization holds", zap.Error(err), zap.Int("accountID", line.AccountID))
+		return response.CreditLine{}, derror.NewInternalSystemError()
+	}
+
+	return response.CreditLine{
+		CreditLine: line,
+		Owed:       balance.Neg(),
+		Principal:  principal(line, balance.Neg()),
+		Held:       held,
+		Available:  entity.NewMoney(line.Limit.Amount+balance.Amount-held.Amount, currency),
+	}, nil
+}
+
+func (s *Service) ListStatements(ctx context.Context, req request.CardAction) ([]*entity.CreditStatement, error) {
+	line, err := s.ownedLine(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := s.creditLineRepo.ListStatements(ctx, line.CreditLineID)
+	if err != nil {
+		s.logger.Error("Failed to list the statements", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return statements, nil
+}
+
+func (s *Service) GetStatement(ctx context.Context, req request.CreditStatement) (response.CreditStatement, error) {
+	if err := req.Validate(); err != nil {
+		return response.CreditStatement{}, derror.NewBadRequestError(err.Error())
+	}
+
+	statement, err := s.creditLineRepo.GetStatement(ctx, req.StatementID)
+	if err != nil {
+		s.logger.Error("Failed to get the statement", zap.Error(err), zap.Int("StatementID", req.StatementID))
+		return response.CreditStatement{}, derror.NewInternalSystemError()
+	}
+
+	if statement == nil {
+		return response.CreditStatement{}, derror.NewNotFoundError("statement %d not found", req.StatementID)
+	}
+
+	line, err := s.creditLineRepo.GetByID(ctx, statement.CreditLineID)
+	if err != nil {
+		s.logger.Error("Failed to get the credit line", zap.Error(err), zap.Int("CreditLineID", statement.CreditLineID))
+		return response.CreditStatement{}, derror.NewInternalSystemError()
+	}
+
+	owned, err := s.ownedBy(ctx, req.UserID, line)
+	if err != nil {
+		return response.CreditStatement{}, err
+	}
+
+	if !owned {
+		return response.CreditStatement{}, derror.NewNotFoundError("statement %d not found", req.StatementID)
+	}
+
+	transactions, err := s.cardTransactionRepo.ListByStatementID(ctx, statement.CreditStatementID)
+	if err != nil {
+		s.logger.Error("Failed to list the statement's transactions", zap.Error(err), zap.Int("StatementID", req.StatementID))
+		return response.CreditStatement{}, derror.NewInternalSystemError()
+	}
+
+	return response.CreditStatement{CreditStatement: statement, Transactions: transactions}, nil
+}
+
+// ownedLine returns the line of a card the user owns. Other users' cards are not found.
+func (s *Service) ownedLine(ctx context.Context, req request.CardAction) (*entity.CreditLine, error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	line, err := s.creditLineRepo.GetByCardID(ctx, req.CardID)
+	if err != nil {
+		s.logger.Error("Failed to get the credit line", zap.Error(err), zap.Int("CardID", req.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	owned, err := s.ownedBy(ctx, req.UserID, line)
+	if err != nil {
+		return nil, err
+	}
+
+	if !owned {
+		return nil, derror.NewNotFoundError("card %d has no credit line", req.CardID)
+	}
+
+	return line, nil
+}
+
+func (s *Service) ownedBy(ctx context.Context, userID int, line *entity.CreditLine) (bool, error) {
+	if line == nil {
+		return false, nil
+	}
+
+	account, err := s.financialAccountService.GetAccountByID(ctx, line.AccountID)
+	if err != nil {
+		return false, err
+	}
+
+	return account.UserID == userID, nil
+}
+
+// checkPaymentAccount checks that the card's owner can pay the card from the account: a
+// verified checking account of theirs in the currency of the line.
+func (s *Service) checkPaymentAccount(ctx context.Context, card *entity.FinancialCard, accountID int, currency enum.CurrencyCode) error {
+	owner, err := s.financialAccountService.GetAccountByID(ctx, card.AccountID)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.UserID != owner.UserID {
+		return derror.NewValidationError("account %d does not belong to the owner of card %d", accountID, card.CardID)
+	}
+
+	if account.AccountType != enum.Checking {
+		return derror.NewValidationError("credit cards are paid from a checking account")
+	}
+
+	for _, id := range []int{card.AccountID, accountID} {
+		accountCurrency, err := s.financialAccountService.GetAccountCurrency(ctx, id)
+		if err != nil {
+			s.logger.Error("Failed to fetch the account currency", zap.Error(err), zap.Int("accountID", id))
+			return err
+		}
+
+		if accountCurrency.CurrencyCode != currency {
+			return derror.NewBadRequestError("account %d is in %s, not %s", id, accountCurrency.CurrencyCode, currency)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) accountsFor(currency enum.CurrencyCode) (config.CreditCardAccounts, error) {
+	accounts, ok := s.cfg.AccountsFor(string(currency))
+	if !ok {
+		s.logger.Error("No credit card accounts are configured", zap.String("currency", string(currency)))
+		return accounts, derror.NewValidationError("credit cards in %s are not supported", currency)
+	}
+	return accounts, nil
+}
+
+// principal is the part of what is owed that is neither interest nor fees.
+func principal(line *entity.CreditLine, owed entity.Money) entity.Money {
+	amount := owed.Amount - line.FeesDue.Amount - line.InterestDue.Amount
+	if amount < 0 {
+		amount = 0
+	}
+	return entity.NewMoney(amount, owed.Currency)
+}
+
+// clampDue keeps the interest and fees due within what is owed. Refunds pay down the debt
+// without going through allocate, and can leave less owed than was charged.
+func clampDue(line *entity.CreditLine, owed entity.Money) {
+	left := owed.Amount
+	if left < 0 {
+		left = 0
+	}
+
+	if line.FeesDue.Amount > left {
+		line.FeesDue.Amount = left
+	}
+	left -= line.FeesDue.Amount
+
+	if line.InterestDue.Amount > left {
+		line.InterestDue.Amount = left
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// nextStatementAt is the first start of the statement day of a month after t.
+func nextStatementAt(t time.Time, statementDay int) time.Time {
+	year, month, _ := t.UTC().Date()
+	next := time.Date(year, month, statementDay, 0, 0, 0, 0, time.UTC)
+	if !next.After(t) {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next
+}
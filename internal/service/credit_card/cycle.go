@@ -0,0 +1,455 @@
+package creditcard
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCycleInterval      = time.Hour
+	defaultCycleBatchSize     = 100
+	defaultGracePeriod        = 25 * 24 * time.Hour
+	defaultMinimumPaymentRate = 100 // 1% of the principal
+	daysPerYear               = 365
+)
+
+func (s *Service) RunStatementCycle(ctx context.Context) error {
+	s.logger.Info("Credit card statement cycle started", zap.Duration("cycleInterval", s.cycleInterval()))
+	defer s.logger.Info("Credit card statement cycle stopped")
+
+	ticker := time.NewTicker(s.cycleInterval())
+	defer ticker.Stop()
+
+	for {
+		// Interest is accrued up to today before a statement due today charges it
+		now := time.Now()
+		if _, err := s.AccrueInterest(context.Background(), now); err != nil {
+			s.logger.Error("Failed to accrue the credit card interest", zap.Error(err))
+		}
+
+		if _, err := s.CloseStatements(context.Background(), now); err != nil {
+			s.logger.Error("Failed to close the credit card statements", zap.Error(err))
+		}
+
+		if _, err := s.ChargeLateFees(context.Background(), now); err != nil {
+			s.logger.Error("Failed to charge the credit card late fees", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// AccrueInterest works through a batch of lines not yet accrued today. A line that fails is
+// logged and retried on the next run.
+func (s *Service) AccrueInterest(ctx context.Context, now time.Time) (int, error) {
+	today := startOfDay(now)
+	lines, err := s.creditLineRepo.ListAccrualDue(ctx, today, s.cycleBatchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	accrued := 0
+	for _, line := range lines {
+		if err := s.accrue(ctx, line.CreditLineID, today); err != nil {
+			s.logger.Error("Failed to accrue the interest", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+			continue
+		}
+		accrued++
+	}
+
+	return accrued, nil
+}
+
+// accrue adds the interest of every day since the line was last accrued. Only revolving
+// lines pay interest, at the daily rate of the APR on the principal; interest and fees do
+// not compound.
+func (s *Service) accrue(ctx context.Context, creditLineID int, today time.Time) (err error) {
+	ctx, err = s.creditLineRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.creditLineRepo.RollbackTx(ctx)
+		}
+	}()
+
+	line, err := s.creditLineRepo.GetForUpdate(ctx, creditLineID)
+	if err != nil {
+		return err
+	}
+
+	accruedOn := startOfDay(line.InterestAccruedOn)
+	if !accruedOn.Before(today) {
+		return s.creditLineRepo.CommitTx(ctx)
+	}
+
+	if line.Revolving && line.APR > 0 {
+		balance, err := s.ledgerRepo.GetBalance(ctx, line.AccountID, line.Limit.Currency)
+		if err != nil {
+			return err
+		}
+
+		days := int64(today.Sub(accruedOn) / (24 * time.Hour))
+		interest, err := principal(line, balance.Neg()).Scale(int64(line.APR)*days, 10000*daysPerYear)
+		if err != nil {
+			return err
+		}
+
+		line.AccruedInterest.Amount += interest.Amount
+	}
+
+	line.InterestAccruedOn = today
+	if err = s.creditLineRepo.Update(ctx, line); err != nil {
+		return err
+	}
+
+	return s.creditLineRepo.CommitTx(ctx)
+}
+
+// CloseStatements closes the statements of a batch of lines due by now. A line that fails
+// is logged and retried on the next run.
+func (s *Service) CloseStatements(ctx context.Context, now time.Time) (int, error) {
+	lines, err := s.creditLineRepo.ListStatementDue(ctx, now, s.cycleBatchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, line := range lines {
+		if err := s.closeStatement(ctx, line.CreditLineID, now); err != nil {
+			s.logger.Error("Failed to close the statement", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+			continue
+		}
+		closed++
+	}
+
+	return closed, nil
+}
+
+// closeStatement charges the interest accrued over the period and closes a statement over
+// every transaction of the account's cards not yet on one.
+func (s *Service) closeStatement(ctx context.Context, creditLineID int, now time.Time) (err error) {
+	ctx, err = s.creditLineRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.creditLineRepo.RollbackTx(ctx)
+		}
+	}()
+
+	line, err := s.creditLineRepo.GetForUpdate(ctx, creditLineID)
+	if err != nil {
+		return err
+	}
+
+	if line.NextStatementAt.After(now) {
+		return s.creditLineRepo.CommitTx(ctx)
+	}
+
+	currency := line.Limit.Currency
+	accounts, err := s.accountsFor(currency)
+	if err != nil {
+		return err
+	}
+
+	balance, err := s.ledgerRepo.LockBalance(ctx, line.AccountID, currency)
+	if err != nil {
+		return err
+	}
+
+	if line.AccruedInterest.IsPositive() {
+		interest := line.AccruedInterest
+		balance, err = s.charge(ctx, line, interest, accounts.InterestAccountID, enum.CardInterest, "Interest charge")
+		if err != nil {
+			return err
+		}
+
+		line.InterestDue.Amount += interest.Amount
+		line.AccruedInterest.Amount = 0
+	}
+
+	previous, err := s.creditLineRepo.LatestStatement(ctx, line.CreditLineID)
+	if err != nil {
+		return err
+	}
+
+	zero := entity.NewMoney(0, currency)
+	statement := &entity.CreditStatement{
+		CreditLineID:    line.CreditLineID,
+		PeriodStart:     line.CreatedAt,
+		PeriodEnd:       line.NextStatementAt,
+		OpeningBalance:  zero,
+		ClosingBalance:  balance.Neg(),
+		Purchases:       zero,
+		Credits:         zero,
+		Payments:        zero,
+		InterestCharged: zero,
+		FeesCharged:     zero,
+		MinimumPayment:  zero,
+		DueDate:         line.NextStatementAt.Add(s.gracePeriod()),
+		Paid:            zero,
+		Status:          enum.StatementOpen,
+	}
+
+	if previous != nil {
+		statement.PeriodStart = previous.PeriodEnd
+		statement.OpeningBalance = previous.ClosingBalance
+	}
+
+	if err = s.creditLineRepo.InsertStatement(ctx, statement); err != nil {
+		return err
+	}
+
+	if err = s.cardTransactionRepo.AssignToStatement(ctx, line.AccountID, statement.CreditStatementID); err != nil {
+		return err
+	}
+
+	transactions, err := s.cardTransactionRepo.ListByStatementID(ctx, statement.CreditStatementID)
+	if err != nil {
+		return err
+	}
+
+	tally(statement, transactions)
+	clampDue(line, statement.ClosingBalance)
+
+	statement.MinimumPayment, err = s.minimumPayment(line, statement.ClosingBalance, accounts.MinimumPayment)
+	if err != nil {
+		return err
+	}
+
+	if !statement.ClosingBalance.IsPositive() {
+		statement.Status = enum.StatementPaid
+		line.Revolving = false
+	}
+
+	if err = s.creditLineRepo.UpdateStatement(ctx, statement); err != nil {
+		return err
+	}
+
+	line.NextStatementAt = nextStatementAt(line.NextStatementAt, line.StatementDay)
+	if err = s.creditLineRepo.Update(ctx, line); err != nil {
+		return err
+	}
+
+	if err = s.creditLineRepo.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Credit card statement closed",
+		zap.Int("CreditLineID", line.CreditLineID),
+		zap.Int("StatementID", statement.CreditStatementID),
+		zap.String("ClosingBalance", statement.ClosingBalance.String()),
+		zap.String("MinimumPayment", statement.MinimumPayment.String()))
+
+	return nil
+}
+
+// ChargeLateFees settles a batch of statements past their due date. A statement that fails
+// is logged and retried on the next run.
+func (s *Service) ChargeLateFees(ctx context.Context, now time.Time) (int, error) {
+	statements, err := s.creditLineRepo.ListPastDue(ctx, now, s.cycleBatchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, statement := range statements {
+		if err := s.settleStatement(ctx, statement.CreditLineID, statement.CreditStatementID, now); err != nil {
+			s.logger.Error("Failed to settle the past due statement", zap.Error(err), zap.Int("StatementID", statement.CreditStatementID))
+			continue
+		}
+		settled++
+	}
+
+	return settled, nil
+}
+
+// settleStatement decides what a statement left unpaid at its due date costs. Any balance
+// carried makes the line revolve; missing the minimum also charges the late fee.
+func (s *Service) settleStatement(ctx context.Context, creditLineID, statementID int, now time.Time) (err error) {
+	ctx, err = s.creditLineRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.creditLineRepo.RollbackTx(ctx)
+		}
+	}()
+
+	line, err := s.creditLineRepo.GetForUpdate(ctx, creditLineID)
+	if err != nil {
+		return err
+	}
+
+	// A payment may have settled the statement while it waited for the lock
+	statement, err := s.creditLineRepo.GetStatement(ctx, statementID)
+	if err != nil {
+		return err
+	}
+
+	if statement.Status != enum.StatementOpen || statement.DueDate.After(now) {
+		return s.creditLineRepo.CommitTx(ctx)
+	}
+
+	switch {
+	case statement.Paid.Amount >= statement.ClosingBalance.Amount:
+		statement.Status = enum.StatementPaid
+	case statement.Paid.Amount >= statement.MinimumPayment.Amount:
+		statement.Status = enum.StatementRevolved
+		line.Revolving = true
+	default:
+		statement.Status = enum.StatementLate
+		line.Revolving = true
+
+		accounts, err := s.accountsFor(line.Limit.Currency)
+		if err != nil {
+			return err
+		}
+
+		if accounts.LateFee > 0 {
+			fee := entity.NewMoney(accounts.LateFee, line.Limit.Currency)
+			if _, err = s.charge(ctx, line, fee, accounts.FeeAccountID, enum.CardFee, "Late payment fee"); err != nil {
+				return err
+			}
+			line.FeesDue.Amount += fee.Amount
+		}
+	}
+
+	if err = s.creditLineRepo.UpdateStatement(ctx, statement); err != nil {
+		return err
+	}
+
+	if err = s.creditLineRepo.Update(ctx, line); err != nil {
+		return err
+	}
+
+	if err = s.creditLineRepo.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Credit card statement fell due",
+		zap.Int("CreditLineID", line.CreditLineID),
+		zap.Int("StatementID", statement.CreditStatementID),
+		zap.Uint("Status", uint(statement.Status)))
+
+	return nil
+}
+
+// charge posts an interest or fee charge against the card's account, crediting the income
+// account, and returns the account's balance after it.
+func (s *Service) charge(ctx context.Context, line *entity.CreditLine, amount entity.Money, incomeAccountID int,
+	transactionType enum.CardTransactionType, description string,
+) (entity.Money, error) {
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: line.AccountID, Amount: amount.Neg()},
+			{FinancialAccountID: incomeAccountID, Amount: amount},
+		},
+	}
+
+	if err := s.ledgerRepo.Post(ctx, entry); err != nil {
+		return entity.Money{}, err
+	}
+
+	balance := entry.PostingFor(line.AccountID).BalanceAfter
+	err := s.cardTransactionRepo.Insert(ctx, &entity.CardTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialCardID:    line.FinancialCardID,
+		Type:               transactionType,
+		Amount:             amount.Neg(),
+		Balance:            balance,
+		Description:        description,
+		Status:             enum.Completedd,
+	})
+	if err != nil {
+		return entity.Money{}, err
+	}
+
+	return balance, nil
+}
+
+// tally sums the statement's transactions by type.
+func tally(statement *entity.CreditStatement, transactions []*entity.CardTransaction) {
+	for _, t := range transactions {
+		switch t.Type {
+		case enum.CardPurchase:
+			statement.Purchases.Amount -= t.Amount.Amount
+		case enum.CardRefund:
+			statement.Credits.Amount += t.Amount.Amount
+		case enum.CardPayment:
+			statement.Payments.Amount += t.Amount.Amount
+		case enum.CardInterest:
+			statement.InterestCharged.Amount -= t.Amount.Amount
+		case enum.CardFee:
+			statement.FeesCharged.Amount -= t.Amount.Amount
+		}
+	}
+}
+
+// minimumPayment is the share of the principal set by the minimum payment rate plus the
+// interest and fees due, but at least floor, and never more than is owed.
+func (s *Service) minimumPayment(line *entity.CreditLine, owed entity.Money, floor int64) (entity.Money, error) {
+	if !owed.IsPositive() {
+		return entity.NewMoney(0, owed.Currency), nil
+	}
+
+	minimum, err := principal(line, owed).Scale(int64(s.minimumPaymentRate()), 10000)
+	if err != nil {
+		return entity.Money{}, err
+	}
+
+	minimum.Amount += line.InterestDue.Amount + line.FeesDue.Amount
+	if minimum.Amount < floor {
+		minimum.Amount = floor
+	}
+
+	if minimum.Amount > owed.Amount {
+		minimum.Amount = owed.Amount
+	}
+
+	return minimum, nil
+}
+
+func (s *Service) cycleInterval() time.Duration {
+	if s.cfg.CycleInterval <= 0 {
+		return defaultCycleInterval
+	}
+	return s.cfg.CycleInterval
+}
+
+func (s *Service) cycleBatchSize() int {
+	if s.cfg.CycleBatchSize <= 0 {
+		return defaultCycleBatchSize
+	}
+	return s.cfg.CycleBatchSize
+}
+
+func (s *Service) gracePeriod() time.Duration {
+	if s.cfg.GracePeriod <= 0 {
+		return defaultGracePeriod
+	}
+	return s.cfg.GracePeriod
+}
+
+func (s *Service) minimumPaymentRate() int {
+	if s.cfg.MinimumPaymentRate <= 0 {
+		return defaultMinimumPaymentRate
+	}
+	return s.cfg.MinimumPaymentRate
+}
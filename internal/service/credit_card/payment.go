@@ -0,0 +1,292 @@
+package creditcard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) MakePayment(ctx context.Context, req request.CreditCardPayment) (res response.CreditCardPayment, err error) {
+	s.logger.Info("Starting credit card payment",
+		zap.Int("CardID", req.CardID),
+		zap.String("Amount", req.Amount.String()),
+		zap.String("Currency", string(req.Amount.Currency)))
+
+	if err := req.Validate(); err != nil {
+		return res, derror.NewBadRequestError(err.Error())
+	}
+
+	// The code is checked outside the transaction so that a wrong one still counts towards
+	// the lockout when the payment rolls back.
+	factor, err := s.twoFactorService.VerifyStepUp(ctx, req.UserID, paymentBinding(req), req.TwoFactor)
+	if err != nil {
+		return res, err
+	}
+
+	ctx, err = s.creditLineRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return res, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.creditLineRepo.RollbackTx(ctx)
+		}
+	}()
+
+	// Payments, statements and late fees of the line wait on each other on this lock
+	line, err := s.creditLineRepo.GetByCardIDForUpdate(ctx, req.CardID)
+	if err != nil {
+		s.logger.Error("Failed to lock the credit line", zap.Error(err), zap.Int("CardID", req.CardID))
+		return res, err
+	}
+
+	owned, err := s.ownedBy(ctx, req.UserID, line)
+	if err != nil {
+		return res, err
+	}
+
+	if !owned {
+		err = derror.NewNotFoundError("card %d has no credit line", req.CardID)
+		return res, err
+	}
+
+	currency := line.Limit.Currency
+	if req.Amount.Currency != currency {
+		err = derror.NewBadRequestError("the payment must be in %s, the currency of the credit line", currency)
+		return res, err
+	}
+
+	status, err := s.financialAccountService.GetAccountStatus(ctx, line.PaymentAccountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account status", zap.Error(err), zap.Int("accountID", line.PaymentAccountID))
+		return res, err
+	}
+
+	if status != enum.Verified {
+		err = derror.NewValidationError("account %d does not allow transactions", line.PaymentAccountID)
+		return res, err
+	}
+
+	cardBalance, paymentBalance, err := s.lockBalances(ctx, line.AccountID, line.PaymentAccountID, currency)
+	if err != nil {
+		s.logger.Error("Failed to lock the account balances", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+		return res, err
+	}
+
+	owed := cardBalance.Neg()
+	if !owed.IsPositive() {
+		err = derror.NewValidationError("nothing is owed on card %d", req.CardID)
+		return res, err
+	}
+
+	if req.Amount.Amount > owed.Amount {
+		err = derror.NewValidationError("the payment exceeds the %s %s owed", owed.String(), currency)
+		return res, err
+	}
+
+	available, err := s.availableBalance(ctx, line.PaymentAccountID, paymentBalance)
+	if err != nil {
+		return res, err
+	}
+
+	if available.Amount < req.Amount.Amount {
+		err = derror.NewValidationError("insufficient funds in account %d", line.PaymentAccountID)
+		return res, err
+	}
+
+	if err = s.enforcePolicy(ctx, line.PaymentAccountID, req.Amount, available, factor); err != nil {
+		return res, err
+	}
+
+	description := fmt.Sprintf("Payment from account %d", line.PaymentAccountID)
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: line.PaymentAccountID, Amount: req.Amount.Neg()},
+			{FinancialAccountID: line.AccountID, Amount: req.Amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return res, err
+	}
+
+	res.Fees, res.Interest, res.Principal = allocate(line, req.Amount)
+	res.Owed = entry.PostingFor(line.AccountID).BalanceAfter.Neg()
+	clampDue(line, res.Owed)
+
+	res.Transaction = &entity.CardTransaction{
+		TransactionGroupID: entry.JournalEntryID,
+		FinancialCardID:    line.FinancialCardID,
+		Type:               enum.CardPayment,
+		Amount:             req.Amount,
+		Balance:            entry.PostingFor(line.AccountID).BalanceAfter,
+		Description:        description,
+		Status:             enum.Completedd,
+	}
+
+	if err = s.cardTransactionRepo.Insert(ctx, res.Transaction); err != nil {
+		s.logger.Error("Failed to insert the card transaction", zap.Error(err))
+		return res, err
+	}
+
+	if err = s.payStatement(ctx, line, req.Amount); err != nil {
+		return res, err
+	}
+
+	if err = s.creditLineRepo.Update(ctx, line); err != nil {
+		s.logger.Error("Failed to update the credit line", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+		return res, err
+	}
+
+	if err = s.complete(ctx, res); err != nil {
+		return res, err
+	}
+
+	s.logger.Info("Credit card payment completed",
+		zap.Int("CreditLineID", line.CreditLineID),
+		zap.Int("TransactionGroupID", entry.JournalEntryID),
+		zap.String("Owed", res.Owed.String()))
+
+	return res, nil
+}
+
+// allocate applies a payment to what the line owes in a fixed order: first the fees
+// charged, then the interest charged, and whatever is left to the principal. Interest
+// accrued since the last statement is not owed yet and takes nothing.
+func allocate(line *entity.CreditLine, amount entity.Money) (fees, interest, principal entity.Money) {
+	left := amount.Amount
+
+	fees = entity.NewMoney(minAmount(left, line.FeesDue.Amount), amount.Currency)
+	left -= fees.Amount
+	line.FeesDue.Amount -= fees.Amount
+
+	interest = entity.NewMoney(minAmount(left, line.InterestDue.Amount), amount.Currency)
+	left -= interest.Amount
+	line.InterestDue.Amount -= interest.Amount
+
+	return fees, interest, entity.NewMoney(left, amount.Currency)
+}
+
+// payStatement counts the payment towards the latest statement. Paying it in full ends
+// revolving, so new purchases are free of interest again until a balance is carried.
+func (s *Service) payStatement(ctx context.Context, line *entity.CreditLine, amount entity.Money) error {
+	statement, err := s.creditLineRepo.LatestStatement(ctx, line.CreditLineID)
+	if err != nil {
+		s.logger.Error("Failed to get the latest statement", zap.Error(err), zap.Int("CreditLineID", line.CreditLineID))
+		return err
+	}
+
+	if statement == nil || statement.Status == enum.StatementPaid {
+		return nil
+	}
+
+	statement.Paid.Amount += amount.Amount
+	if statement.Paid.Amount >= statement.ClosingBalance.Amount {
+		statement.Status = enum.StatementPaid
+		line.Revolving = false
+	}
+
+	if err := s.creditLineRepo.UpdateStatement(ctx, statement); err != nil {
+		s.logger.Error("Failed to update the statement", zap.Error(err), zap.Int("StatementID", statement.CreditStatementID))
+		return err
+	}
+
+	return nil
+}
+
+// lockBalances locks both balances in ascending account order, so concurrent payments
+// cannot deadlock, and returns them in the order asked for.
+func (s *Service) lockBalances(ctx context.Context, cardAccountID, paymentAccountID int, currency enum.CurrencyCode) (card, payment entity.Money, err error) {
+	first, second := cardAccountID, paymentAccountID
+	if second < first {
+		first, second = second, first
+	}
+
+	firstBalance, err := s.ledgerRepo.LockBalance(ctx, first, currency)
+	if err != nil {
+		return card, payment, err
+	}
+
+	secondBalance, err := s.ledgerRepo.LockBalance(ctx, second, currency)
+	if err != nil {
+		return card, payment, err
+	}
+
+	if first == cardAccountID {
+		return firstBalance, secondBalance, nil
+	}
+	return secondBalance, firstBalance, nil
+}
+
+func (s *Service) availableBalance(ctx context.Context, accountID int, balance entity.Money) (entity.Money, error) {
+	held, err := s.ledgerRepo.HeldAmount(ctx, accountID, balance.Currency)
+	if err != nil {
+		s.logger.Error("Failed to sum the authorization holds", zap.Error(err), zap.Int("accountID", accountID))
+		return entity.Money{}, err
+	}
+
+	return entity.NewMoney(balance.Amount-held.Amount, balance.Currency), nil
+}
+
+// enforcePolicy rejects a payment that breaks the rules of its account, listing every violated
+// rule. One held back by the two-factor rule alone is answered with a challenge instead.
+func (s *Service) enforcePolicy(ctx context.Context, accountID int, amount, balance entity.Money, factor entity.SecondFactor) error {
+	violations, err := s.accountRulesService.Evaluate(ctx, request.EvaluatePolicy{
+		FinancialAccountID:   accountID,
+		Amount:               amount,
+		Balance:              balance,
+		SecondFactorVerified: factor.Verified,
+	})
+	if err != nil {
+		s.logger.Error("Failed to evaluate the account rules", zap.Error(err))
+		return err
+	}
+
+	if factor.UserID != 0 && entity.OnlyTwoFactor(violations) {
+		return s.twoFactorService.StepUp(ctx, factor, violations)
+	}
+
+	if len(violations) > 0 {
+		s.logger.Warn("Credit card payment violates the account rules", zap.Int("accountID", accountID), zap.Any("violations", violations))
+		return derror.WithDetails(derror.NewValidationError("the transaction violates the rules of account %d", accountID), violations)
+	}
+
+	return nil
+}
+
+// complete stores the response for the request's Idempotency-Key and commits, so both
+// take effect together.
+func (s *Service) complete(ctx context.Context, resp any) error {
+	if err := s.idempotencyService.Complete(ctx, resp); err != nil {
+		s.logger.Error("Failed to store the idempotent response", zap.Error(err))
+		return err
+	}
+
+	if err := s.creditLineRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func minAmount(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func paymentBinding(req request.CreditCardPayment) string {
+	return fmt.Sprintf("credit_payment:%d:%d:%d:%s", req.UserID, req.CardID, req.Amount.Amount, req.Amount.Currency)
+}
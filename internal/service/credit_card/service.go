@@ -0,0 +1,46 @@
+package creditcard
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                     config.CreditCard
+	logger                  *zap.SugaredLogger
+	creditLineRepo          protocol.CreditLineRepository
+	cardTransactionRepo     protocol.CardTransactionRepository
+	ledgerRepo              protocol.LedgerRepository
+	financialCardService    protocol.FinancialCard
+	financialAccountService protocol.FinancialAccount
+	accountRulesService     protocol.AccountRules
+	idempotencyService      protocol.Idempotency
+	twoFactorService        protocol.TwoFactor
+}
+
+func New(
+	cfg config.CreditCard,
+	logger *zap.SugaredLogger,
+	creditLineRepo protocol.CreditLineRepository,
+	cardTransactionRepo protocol.CardTransactionRepository,
+	ledgerRepo protocol.LedgerRepository,
+	financialCardService protocol.FinancialCard,
+	financialAccountService protocol.FinancialAccount,
+	accountRulesService protocol.AccountRules,
+	idempotencyService protocol.Idempotency,
+	twoFactorService protocol.TwoFactor,
+) *Service {
+	return &Service{
+		cfg:                     cfg,
+		logger:                  logger,
+		creditLineRepo:          creditLineRepo,
+		cardTransactionRepo:     cardTransactionRepo,
+		ledgerRepo:              ledgerRepo,
+		financialCardService:    financialCardService,
+		financialAccountService: financialAccountService,
+		accountRulesService:     accountRulesService,
+		idempotencyService:      idempotencyService,
+		twoFactorService:        twoFactorService,
+	}
+}
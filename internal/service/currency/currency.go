@@ -112,11 +112,11 @@ func (s *Service) ListCurrencies(ctx context.Context) ([]entity.Currency, error)
 	return currencies, nil
 }
 
-func (s *Service) GetExchangeRate(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode) (float64, error) {
+func (s *Service) GetExchangeRate(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode) (entity.Rate, error) {
 	rates, err := s.currencyRepo.GetLatestExchangeRates(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get latest exchange rates", zap.Error(err))
-		return 0.0, err
+		return 0, err
 	}
 
 	fromRate, fromExists := rates[fromCode]
@@ -124,17 +124,23 @@ func (s *Service) GetExchangeRate(ctx context.Context, fromCode enum.CurrencyCod
 
 	if !fromExists || !toExists {
 		s.logger.Warn("Exchange rate not available for one or both currencies", zap.String("fromCode", string(fromCode)), zap.String("toCode", string(toCode)))
-		return 0.0, fmt.Errorf("exchange rate not available for one or both currencies")
+		return 0, fmt.Errorf("exchange rate not available for one or both currencies")
 	}
 
-	exchangeRate := toRate / fromRate
-	s.logger.Info("Successfully fetched exchange rate", zap.String("fromCode", string(fromCode)), zap.String("toCode", string(toCode)), zap.Float64("exchangeRate", exchangeRate))
+	exchangeRate, err := toRate.Div(fromRate)
+	if err != nil {
+		s.logger.Error("Failed to derive exchange rate", zap.Error(err))
+		return 0, err
+	}
+
+	s.logger.Info("Successfully fetched exchange rate", zap.String("fromCode", string(fromCode)), zap.String("toCode", string(toCode)), zap.String("exchangeRate", exchangeRate.String()))
 	return exchangeRate, nil
 }
 
-func (s *Service) BulkUpdateExchangeRates(ctx context.Context, rates map[enum.CurrencyCode]float64) error {
+func (s *Service) BulkUpdateExchangeRates(ctx context.Context, rates map[enum.CurrencyCode]entity.Rate) error {
 	var currenciesToUpdate []entity.Currency
 	for code, rate := range rates {
+		rate := rate
 		currenciesToUpdate = append(currenciesToUpdate, entity.Currency{
 			CurrencyCode: code,
 			ExchangeRate: &rate,
@@ -167,15 +173,20 @@ func (s *Service) SearchCurrencies(ctx context.Context, query string) ([]entity.
 	return currencies, nil
 }
 
-func (s *Service) ConvertAmount(ctx context.Context, fromCode enum.CurrencyCode, toCode enum.CurrencyCode, amount float64) (float64, error) {
-	exchangeRate, err := s.GetExchangeRate(ctx, fromCode, toCode)
+func (s *Service) ConvertAmount(ctx context.Context, amount entity.Money, toCode enum.CurrencyCode) (entity.Money, error) {
+	exchangeRate, err := s.GetExchangeRate(ctx, amount.Currency, toCode)
 	if err != nil {
 		s.logger.Error("Failed to get exchange rate for currency conversion", zap.Error(err))
-		return 0.0, err
+		return entity.Money{}, err
+	}
+
+	convertedAmount, err := amount.Convert(exchangeRate, toCode)
+	if err != nil {
+		s.logger.Error("Failed to convert amount", zap.Error(err))
+		return entity.Money{}, err
 	}
 
-	convertedAmount := amount * exchangeRate
-	s.logger.Info("Successfully converted amount", zap.Float64("convertedAmount", convertedAmount))
+	s.logger.Info("Successfully converted amount", zap.String("convertedAmount", convertedAmount.String()), zap.String("currency", string(toCode)))
 	return convertedAmount, nil
 }
 
@@ -191,7 +202,7 @@ func (s *Service) CompareCurrencies(ctx context.Context, firstCode enum.Currency
 	isStronger := firstCurrency.ExchangeRate != nil && secondCurrency.ExchangeRate != nil && *firstCurrency.ExchangeRate > *secondCurrency.ExchangeRate
 	comparisonRate := 0.0
 	if firstCurrency.ExchangeRate != nil && secondCurrency.ExchangeRate != nil {
-		comparisonRate = *firstCurrency.ExchangeRate / *secondCurrency.ExchangeRate
+		comparisonRate = firstCurrency.ExchangeRate.Float64() / secondCurrency.ExchangeRate.Float64()
 	}
 
 	result := response.CurrencyComparison{
@@ -217,14 +228,14 @@ func (s *Service) GetCurrencyTrends(ctx context.Context, code enum.CurrencyCode,
 
 	var trends []struct {
 		Date         time.Time
-		ExchangeRate float64
+		ExchangeRate entity.Rate
 	}
 
 	for _, rate := range historicalRates {
 		if rate.ExchangeRate != nil {
 			trends = append(trends, struct {
 				Date         time.Time
-				ExchangeRate float64
+				ExchangeRate entity.Rate
 			}{
 				Date:         rate.UpdatedAt,
 				ExchangeRate: *rate.ExchangeRate,
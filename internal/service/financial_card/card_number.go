@@ -0,0 +1,27 @@
+package financialcard
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/luhn"
+)
+
+const binLength = 6
+
+// newCardNumber returns a random number of the given length on the BIN, ending with a valid
+// check digit.
+func newCardNumber(bin string, length int) (string, error) {
+	number := make([]byte, 0, length)
+	number = append(number, bin...)
+
+	for len(number) < length-1 {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		number = append(number, byte('0'+digit.Int64()))
+	}
+
+	return string(append(number, luhn.CheckDigit(string(number)))), nil
+}
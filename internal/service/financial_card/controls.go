@@ -0,0 +1,105 @@
+package financialcard
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) GetCardControls(ctx context.Context, req request.CardAction) (*entity.CardControls, error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	card, err := s.financialCardRepo.GetByID(ctx, int64(req.CardID))
+	if err != nil {
+		s.logger.Error("Failed to get card by ID", zap.Error(err), zap.Int("CardID", req.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if err := s.checkOwner(ctx, req, card); err != nil {
+		return nil, err
+	}
+
+	controls, err := s.cardControlsRepo.GetByCardID(ctx, card.CardID)
+	if err != nil {
+		s.logger.Error("Failed to get the card controls", zap.Error(err), zap.Int("CardID", card.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if controls == nil {
+		currency, err := s.financialAccountService.GetAccountCurrency(ctx, card.AccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		controls = &entity.CardControls{
+			FinancialCardID:     card.CardID,
+			BlockedMCCs:         []string{},
+			PerTransactionLimit: entity.NewMoney(0, currency.CurrencyCode),
+			DailyLimit:          entity.NewMoney(0, currency.CurrencyCode),
+		}
+	}
+
+	return controls, nil
+}
+
+// UpdateCardControls replaces the card's controls, creating them if the card has none.
+func (s *Service) UpdateCardControls(ctx context.Context, req request.UpdateCardControls) (*entity.CardControls, error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	card, err := s.financialCardRepo.GetByID(ctx, int64(req.CardID))
+	if err != nil {
+		s.logger.Error("Failed to get card by ID", zap.Error(err), zap.Int("CardID", req.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if err := s.checkOwner(ctx, request.CardAction{UserID: req.UserID, CardID: req.CardID}, card); err != nil {
+		return nil, err
+	}
+
+	currency, err := s.financialAccountService.GetAccountCurrency(ctx, card.AccountID)
+	if err != nil {
+		s.logger.Error("Failed to get the account currency", zap.Error(err), zap.Int("accountID", card.AccountID))
+		return nil, err
+	}
+
+	code := currency.CurrencyCode
+	for _, limit := range []*entity.Money{&req.PerTransactionLimit, &req.DailyLimit} {
+		// Omitted limits decode without a currency and mean no limit
+		if limit.IsZero() {
+			*limit = entity.NewMoney(0, code)
+		}
+		if limit.Currency != code {
+			return nil, derror.NewBadRequestError("card limits must be in the account currency %s", code)
+		}
+	}
+
+	blocked := req.BlockedMCCs
+	if blocked == nil {
+		blocked = []string{}
+	}
+
+	controls := &entity.CardControls{
+		FinancialCardID:       card.CardID,
+		OnlineDisabled:        req.OnlineDisabled,
+		InternationalDisabled: req.InternationalDisabled,
+		BlockedMCCs:           blocked,
+		PerTransactionLimit:   req.PerTransactionLimit,
+		DailyLimit:            req.DailyLimit,
+	}
+
+	if err := s.cardControlsRepo.Upsert(ctx, controls); err != nil {
+		s.logger.Error("Failed to save the card controls", zap.Error(err), zap.Int("CardID", card.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	s.logger.Info("Updated card controls", zap.Int("CardID", card.CardID))
+
+	return controls, nil
+}
@@ -0,0 +1,82 @@
+package financialcard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCardControls(t *testing.T) {
+	t.Run("Card without controls allows everything", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("GetByID", mock.Anything, int64(5)).Return(ownedCard(enum.Active), nil)
+		m.controls.On("GetByCardID", mock.Anything, 5).Return(nil, nil)
+		m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+
+		controls, err := service.GetCardControls(context.Background(), request.CardAction{UserID: 3, CardID: 5})
+		require.NoError(t, err)
+		assert.False(t, controls.OnlineDisabled)
+		assert.Empty(t, controls.BlockedMCCs)
+		assert.Equal(t, entity.NewMoney(0, enum.EUR), controls.DailyLimit)
+	})
+
+	t.Run("Card of another user", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("GetByID", mock.Anything, int64(5)).Return(ownedCard(enum.Active), nil)
+
+		_, err := service.GetCardControls(context.Background(), request.CardAction{UserID: 4, CardID: 5})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+}
+
+func TestUpdateCardControls(t *testing.T) {
+	t.Run("Omitted limits are stored in the account currency", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("GetByID", mock.Anything, int64(5)).Return(ownedCard(enum.Active), nil)
+		m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+		m.controls.On("Upsert", mock.Anything, mock.AnythingOfType("*entity.CardControls")).Return(nil)
+
+		controls, err := service.UpdateCardControls(context.Background(), request.UpdateCardControls{
+			UserID:              3,
+			CardID:              5,
+			OnlineDisabled:      true,
+			BlockedMCCs:         []string{"7995"},
+			PerTransactionLimit: entity.NewMoney(50000, enum.EUR),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5, controls.FinancialCardID)
+		assert.True(t, controls.OnlineDisabled)
+		assert.Equal(t, entity.NewMoney(0, enum.EUR), controls.DailyLimit)
+		m.controls.AssertCalled(t, "Upsert", mock.Anything, controls)
+	})
+
+	t.Run("Limit in another currency", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("GetByID", mock.Anything, int64(5)).Return(ownedCard(enum.Active), nil)
+		m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+
+		_, err := service.UpdateCardControls(context.Background(), request.UpdateCardControls{
+			UserID: 3, CardID: 5, DailyLimit: entity.NewMoney(10000, enum.USD),
+		})
+		assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest), "got %v", err)
+		m.controls.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Malformed merchant category", func(t *testing.T) {
+		service, _ := setup()
+
+		_, err := service.UpdateCardControls(context.Background(), request.UpdateCardControls{
+			UserID: 3, CardID: 5, BlockedMCCs: []string{"79"},
+		})
+		assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest), "got %v", err)
+	})
+}
@@ -2,6 +2,7 @@ package financialcard
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
@@ -35,14 +36,31 @@ func (s *Service) RegisterCard(ctx context.Context, req *request.RegisterFinanci
 		return 0, derror.NewBadRequestError("Account ID does not exist")
 	}
 
+	network, bankID, err := s.detectIssuer(ctx, req.AccountID, req.CardNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	// The CVV was checked by Validate and goes no further than this request
+	token, err := s.cardVaultService.Tokenize(ctx, req.CardNumber)
+	if err != nil {
+		s.logger.Error("Failed to store the card number in the vault",
+			zap.Error(err),
+			zap.String("method", registerCardMethod),
+		)
+		return 0, err
+	}
+
 	card := entity.FinancialCard{
 		AccountID:      req.AccountID,
-		CardNumber:     req.CardNumber,
+		CardToken:      token,
+		LastFour:       req.CardNumber[len(req.CardNumber)-4:],
 		CardType:       req.CardType,
+		Network:        network,
+		BankID:         bankID,
 		ExpirationDate: req.ExpirationDate,
 		CardHolderName: req.CardHolderName,
-		CVV:            req.CVV,
-		Status:         req.Status,
+		Status:         enum.Active,
 		IssuedDate:     time.Now(),
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
@@ -59,6 +77,40 @@ func (s *Service) RegisterCard(ctx context.Context, req *request.RegisterFinanci
 	return card.CardID, nil
 }
 
+// detectIssuer looks the card's BIN up to find its network and issuing bank. A card whose
+// BIN names a bank must belong to an account at that bank.
+func (s *Service) detectIssuer(ctx context.Context, accountID int, number string) (enum.CardNetwork, *int64, error) {
+	bin, _ := strconv.Atoi(number[:binLength]) // Validate has checked it is all digits
+
+	cardBIN, err := s.cardBINRepo.Lookup(ctx, bin)
+	if err != nil {
+		s.logger.Error("Failed to look up the card BIN",
+			zap.Error(err),
+			zap.String("method", registerCardMethod),
+		)
+		return enum.NetworkUnknown, nil, derror.NewInternalSystemError()
+	}
+
+	if cardBIN == nil {
+		return enum.NetworkUnknown, nil, derror.NewValidationError("the card's issuer is not recognised")
+	}
+
+	if cardBIN.BankCode == nil {
+		return cardBIN.Network, nil, nil
+	}
+
+	bank, err := s.financialAccountService.GetBankForAccount(ctx, accountID)
+	if err != nil {
+		return enum.NetworkUnknown, nil, err
+	}
+
+	if bank.BankCode != *cardBIN.BankCode {
+		return enum.NetworkUnknown, nil, derror.NewValidationError("the card was not issued by the account's bank")
+	}
+
+	return cardBIN.Network, &bank.BankID, nil
+}
+
 func (s *Service) UpdateCard(ctx context.Context, req *request.UpdateFinancialCard) error {
 	if err := req.Validate(); err != nil {
 		s.logger.Error("Failed to validate request",
@@ -81,12 +133,7 @@ func (s *Service) UpdateCard(ctx context.Context, req *request.UpdateFinancialCa
 		return derror.NewNotFoundError("Card not found")
 	}
 
-	card.CardNumber = req.CardNumber
-	card.CardType = req.CardType
-	card.ExpirationDate = req.ExpirationDate
 	card.CardHolderName = req.CardHolderName
-	card.CVV = req.CVV
-	card.Status = req.Status
 	card.UpdatedAt = time.Now()
 
 	if err := s.financialCardRepo.Update(ctx, card); err != nil {
@@ -0,0 +1,127 @@
+package financialcard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mocks struct {
+	repo     *protocol.MockFinancialCardRepo
+	accounts *protocol.MockFinancialAccountService
+	vault    *protocol.MockCardVaultService
+	bins     *protocol.MockCardBINRepo
+	controls *protocol.MockCardControlsRepo
+	notifier *protocol.MockNotifier
+}
+
+func setup() (*Service, mocks) {
+	m := mocks{
+		repo:     new(protocol.MockFinancialCardRepo),
+		accounts: new(protocol.MockFinancialAccountService),
+		vault:    new(protocol.MockCardVaultService),
+		bins:     new(protocol.MockCardBINRepo),
+		controls: new(protocol.MockCardControlsRepo),
+		notifier: new(protocol.MockNotifier),
+	}
+	m.accounts.On("IsAccountExist", mock.Anything, 1).Return(true, nil).Maybe()
+	m.accounts.On("GetBankForAccount", mock.Anything, 1).Return(response.GetBank{BankID: 7, BankCode: "017"}, nil).Maybe()
+	m.vault.On("Tokenize", mock.Anything, mock.Anything).Return("tok_card", nil).Maybe()
+	m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.FinancialCard")).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.FinancialCard).CardID = 10
+	}).Return(nil).Maybe()
+	m.repo.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	m.repo.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	m.repo.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil).Maybe()
+
+	logger, _ := zap.NewProduction()
+
+	service := New(config.JWT{}, config.CardIssuing{BINStart: 489000, BINEnd: 489099}, config.CardRenewal{}, logger.Sugar(), m.repo, nil, m.accounts, m.vault, m.bins, m.controls, m.notifier)
+	return service, m
+}
+
+func registration(number string) *request.RegisterFinancialCard {
+	return &request.RegisterFinancialCard{
+		AccountID:      1,
+		CardNumber:     number,
+		CardType:       enum.Debit,
+		ExpirationDate: time.Now().AddDate(3, 0, 0),
+		CardHolderName: "Sara Ahmadi",
+		CVV:            "123",
+	}
+}
+
+func bankCode(code string) *string {
+	return &code
+}
+
+func TestRegisterCard(t *testing.T) {
+	t.Run("Shetab card is linked to its bank", func(t *testing.T) {
+		service, m := setup()
+		m.bins.On("Lookup", mock.Anything, 603799).Return(&entity.CardBIN{Network: enum.Shetab, BankCode: bankCode("017")}, nil)
+
+		cardID, err := service.RegisterCard(context.Background(), registration("6037991234567893"))
+		require.NoError(t, err)
+		assert.Equal(t, 10, cardID)
+
+		card := m.repo.Calls[0].Arguments.Get(1).(*entity.FinancialCard)
+		assert.Equal(t, enum.Shetab, card.Network)
+		assert.Equal(t, int64(7), *card.BankID)
+		assert.Equal(t, "tok_card", card.CardToken)
+		assert.Equal(t, "7893", card.LastFour)
+		assert.Equal(t, enum.Active, card.Status)
+	})
+
+	t.Run("Card from another bank", func(t *testing.T) {
+		service, m := setup()
+		m.bins.On("Lookup", mock.Anything, 610433).Return(&entity.CardBIN{Network: enum.Shetab, BankCode: bankCode("012")}, nil)
+
+		_, err := service.RegisterCard(context.Background(), registration("6104331234567890"))
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.vault.AssertNotCalled(t, "Tokenize", mock.Anything, mock.Anything)
+		m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Network range without an issuer", func(t *testing.T) {
+		service, m := setup()
+		m.bins.On("Lookup", mock.Anything, 411111).Return(&entity.CardBIN{Network: enum.Visa}, nil)
+
+		_, err := service.RegisterCard(context.Background(), registration("4111111111111111"))
+		require.NoError(t, err)
+
+		card := m.repo.Calls[0].Arguments.Get(1).(*entity.FinancialCard)
+		assert.Equal(t, enum.Visa, card.Network)
+		assert.Nil(t, card.BankID)
+		m.accounts.AssertNotCalled(t, "GetBankForAccount", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown BIN", func(t *testing.T) {
+		service, m := setup()
+		m.bins.On("Lookup", mock.Anything, 999999).Return(nil, nil)
+
+		_, err := service.RegisterCard(context.Background(), registration("9999991234567893"))
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+	})
+
+	t.Run("Bad check digit", func(t *testing.T) {
+		service, m := setup()
+
+		_, err := service.RegisterCard(context.Background(), registration("6037991234567890"))
+		assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest), "got %v", err)
+		m.bins.AssertNotCalled(t, "Lookup", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,290 @@
+package financialcard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+// reissueValidity is how long a reissued card is valid; it expires at the end of the month.
+const reissueValidity = 3
+
+func (s *Service) FreezeCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	return s.transition(ctx, req, enum.CardActionFrozen, enum.CardFrozen)
+}
+
+func (s *Service) UnfreezeCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	return s.transition(ctx, req, enum.CardActionUnfrozen, enum.Active)
+}
+
+func (s *Service) ReportCardLost(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	return s.transition(ctx, req, enum.CardActionReportedLost, enum.Lost)
+}
+
+func (s *Service) ReportCardStolen(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	return s.transition(ctx, req, enum.CardActionReportedStolen, enum.Stolen)
+}
+
+// transition moves the owner's locked card to the given status if the state machine allows
+// it, and records the change in the card's history.
+func (s *Service) transition(ctx context.Context, req request.CardAction, action enum.CardEventAction, to enum.FinancialCardStatus) (card *entity.FinancialCard, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.financialCardRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.financialCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	card, err = s.lockOwnedCard(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !card.Status.CanBecome(to) {
+		err = derror.NewConflictError("card %d cannot be %s in its current status", card.CardID, strings.ReplaceAll(string(action), "_", " "))
+		return nil, err
+	}
+
+	from := card.Status
+	card.Status = to
+	card.UpdatedAt = time.Now()
+	if err = s.financialCardRepo.Update(ctx, card); err != nil {
+		s.logger.Error("Failed to update the card status", zap.Error(err), zap.Int("CardID", card.CardID))
+		return nil, err
+	}
+
+	if err = s.recordEvent(ctx, &req.UserID, card.CardID, action, from, to, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err = s.financialCardRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Card status changed",
+		zap.Int("CardID", card.CardID),
+		zap.String("action", string(action)),
+		zap.Uint("from", uint(from)),
+		zap.Uint("to", uint(to)))
+
+	return card, nil
+}
+
+func (s *Service) ReissueCard(ctx context.Context, req request.CardAction) (card *entity.FinancialCard, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.financialCardRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.financialCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	old, err := s.lockOwnedCard(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lost, stolen and expired cards stay as they are; any other card has to be able to become replaced
+	if !old.Status.IsTerminal() && !old.Status.CanBecome(enum.CardReplaced) {
+		err = derror.NewConflictError("card %d cannot be reissued in its current status", old.CardID)
+		return nil, err
+	}
+
+	replacement, err := s.financialCardRepo.GetReplacement(ctx, int64(old.CardID))
+	if err != nil {
+		s.logger.Error("Failed to look up the card's replacement", zap.Error(err), zap.Int("CardID", old.CardID))
+		return nil, err
+	}
+
+	if replacement != nil || old.Status == enum.CardReplaced {
+		err = derror.NewConflictError("card %d has already been reissued", old.CardID)
+		return nil, err
+	}
+
+	if old.CardToken == "" {
+		err = derror.NewConflictError("card %d cannot be reissued until its number is moved into the vault", old.CardID)
+		return nil, err
+	}
+
+	card, err = s.newReplacement(ctx, old, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.financialCardRepo.Insert(ctx, card); err != nil {
+		s.logger.Error("Failed to insert the reissued card", zap.Error(err))
+		return nil, err
+	}
+
+	from := old.Status
+	if !old.Status.IsTerminal() {
+		old.Status = enum.CardReplaced
+		old.UpdatedAt = card.IssuedDate
+		if err = s.financialCardRepo.Update(ctx, old); err != nil {
+			s.logger.Error("Failed to mark the card replaced", zap.Error(err), zap.Int("CardID", old.CardID))
+			return nil, err
+		}
+	}
+
+	note := fmt.Sprintf("Replaced by card %d", card.CardID)
+	if req.Note != "" {
+		note = req.Note + ". " + note
+	}
+
+	if err = s.recordEvent(ctx, &req.UserID, old.CardID, enum.CardActionReissued, from, old.Status, note); err != nil {
+		return nil, err
+	}
+
+	if err = s.financialCardRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Card reissued", zap.Int("CardID", old.CardID), zap.Int("newCardID", card.CardID))
+
+	return card, nil
+}
+
+func (s *Service) ListCardEvents(ctx context.Context, req request.CardAction) ([]*entity.CardEvent, error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	card, err := s.financialCardRepo.GetByID(ctx, int64(req.CardID))
+	if err != nil {
+		s.logger.Error("Failed to get card by ID", zap.Error(err), zap.Int("CardID", req.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if err := s.checkOwner(ctx, req, card); err != nil {
+		return nil, err
+	}
+
+	events, err := s.financialCardRepo.ListEvents(ctx, int64(card.CardID))
+	if err != nil {
+		s.logger.Error("Failed to list the card's history", zap.Error(err), zap.Int("CardID", card.CardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return events, nil
+}
+
+func (s *Service) lockOwnedCard(ctx context.Context, req request.CardAction) (*entity.FinancialCard, error) {
+	card, err := s.financialCardRepo.GetForUpdate(ctx, int64(req.CardID))
+	if err != nil {
+		s.logger.Error("Failed to lock the card", zap.Error(err), zap.Int("CardID", req.CardID))
+		return nil, err
+	}
+
+	if err := s.checkOwner(ctx, req, card); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// checkOwner hides cards of other users behind the same error as cards that do not exist.
+func (s *Service) checkOwner(ctx context.Context, req request.CardAction, card *entity.FinancialCard) error {
+	if card == nil || card.DeletedAt != nil {
+		return derror.NewNotFoundError("card %d not found", req.CardID)
+	}
+
+	account, err := s.financialAccountService.GetAccountByID(ctx, card.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if account.UserID != req.UserID {
+		return derror.NewNotFoundError("card %d not found", req.CardID)
+	}
+
+	return nil
+}
+
+// recordEvent appends to the card's history. A nil actor is the system.
+func (s *Service) recordEvent(ctx context.Context, actorID *int, cardID int, action enum.CardEventAction, from, to enum.FinancialCardStatus, note string) error {
+	event := &entity.CardEvent{
+		FinancialCardID: cardID,
+		ActorID:         actorID,
+		Action:          action,
+		FromStatus:      from,
+		ToStatus:        to,
+	}
+	if note != "" {
+		event.Note = &note
+	}
+
+	if err := s.financialCardRepo.InsertEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to record the card event", zap.Error(err), zap.Int("CardID", cardID))
+		return err
+	}
+
+	return nil
+}
+
+// newReplacement draws a new number on the BIN of the old card, which must be in the vault,
+// and returns the card to replace it with. It is not stored yet.
+func (s *Service) newReplacement(ctx context.Context, old *entity.FinancialCard, now time.Time) (*entity.FinancialCard, error) {
+	// Only the BIN of the old number is used, to keep the new card on the same range
+	oldNumber, err := s.cardVaultService.Detokenize(ctx, old.CardToken)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := newCardNumber(oldNumber[:binLength], len(oldNumber))
+	if err != nil {
+		s.logger.Error("Failed to generate a card number", zap.Error(err))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	token, err := s.cardVaultService.Tokenize(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.FinancialCard{
+		AccountID:      old.AccountID,
+		CardToken:      token,
+		LastFour:       number[len(number)-4:],
+		CardType:       old.CardType,
+		Network:        old.Network,
+		BankID:         old.BankID,
+		ExpirationDate: endOfMonth(now.AddDate(reissueValidity, 0, 0)),
+		CardHolderName: old.CardHolderName,
+		Status:         enum.Active,
+		IssuedDate:     now,
+		ReplacesCardID: &old.CardID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}, nil
+}
+
+func endOfMonth(t time.Time) time.Time {
+	year, month, _ := t.Date()
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,143 @@
+package financialcard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/luhn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func ownedCard(status enum.FinancialCardStatus) *entity.FinancialCard {
+	return &entity.FinancialCard{
+		CardID:         5,
+		AccountID:      1,
+		CardToken:      "tok_old",
+		LastFour:       "7893",
+		CardType:       enum.Debit,
+		Network:        enum.Shetab,
+		ExpirationDate: time.Now().AddDate(1, 0, 0),
+		CardHolderName: "Sara Ahmadi",
+		Status:         status,
+	}
+}
+
+func TestCardTransitions(t *testing.T) {
+	action := request.CardAction{UserID: 3, CardID: 5, Note: "Left it at home"}
+
+	tests := []struct {
+		name     string
+		from     enum.FinancialCardStatus
+		apply    func(*Service, context.Context, request.CardAction) (*entity.FinancialCard, error)
+		to       enum.FinancialCardStatus
+		conflict bool
+	}{
+		{name: "Freeze an active card", from: enum.Active, apply: (*Service).FreezeCard, to: enum.CardFrozen},
+		{name: "Unfreeze a frozen card", from: enum.CardFrozen, apply: (*Service).UnfreezeCard, to: enum.Active},
+		{name: "Report a frozen card stolen", from: enum.CardFrozen, apply: (*Service).ReportCardStolen, to: enum.Stolen},
+		{name: "Unfreeze an active card", from: enum.Active, apply: (*Service).UnfreezeCard, conflict: true},
+		{name: "Lost is terminal", from: enum.Lost, apply: (*Service).UnfreezeCard, conflict: true},
+		{name: "Report a replaced card lost", from: enum.CardReplaced, apply: (*Service).ReportCardLost, conflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+
+			var event *entity.CardEvent
+			m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(ownedCard(tt.from), nil)
+			m.repo.On("Update", mock.Anything, mock.AnythingOfType("*entity.FinancialCard")).Return(nil)
+			m.repo.On("InsertEvent", mock.Anything, mock.AnythingOfType("*entity.CardEvent")).Run(func(args mock.Arguments) {
+				event = args.Get(1).(*entity.CardEvent)
+			}).Return(nil)
+
+			card, err := tt.apply(service, context.Background(), action)
+			if tt.conflict {
+				assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+				m.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				m.repo.AssertCalled(t, "RollbackTx", mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.to, card.Status)
+			assert.Equal(t, tt.from, event.FromStatus)
+			assert.Equal(t, tt.to, event.ToStatus)
+			assert.Equal(t, 3, *event.ActorID)
+			assert.Equal(t, "Left it at home", *event.Note)
+			m.repo.AssertCalled(t, "CommitTx", mock.Anything)
+		})
+	}
+
+	t.Run("Card of another user", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(ownedCard(enum.Active), nil)
+
+		_, err := service.FreezeCard(context.Background(), request.CardAction{UserID: 4, CardID: 5})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+}
+
+func TestReissueCard(t *testing.T) {
+	reissue := func(old *entity.FinancialCard, replacement *entity.FinancialCard) (*entity.FinancialCard, *entity.CardEvent, mocks, error) {
+		service, m := setup()
+
+		var event *entity.CardEvent
+		m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(old, nil)
+		m.repo.On("GetReplacement", mock.Anything, int64(5)).Return(replacement, nil)
+		m.repo.On("Update", mock.Anything, mock.AnythingOfType("*entity.FinancialCard")).Return(nil)
+		m.repo.On("InsertEvent", mock.Anything, mock.AnythingOfType("*entity.CardEvent")).Run(func(args mock.Arguments) {
+			event = args.Get(1).(*entity.CardEvent)
+		}).Return(nil)
+		m.vault.On("Detokenize", mock.Anything, "tok_old").Return("6037991234567893", nil)
+
+		card, err := service.ReissueCard(context.Background(), request.CardAction{UserID: 3, CardID: 5})
+		return card, event, m, err
+	}
+
+	t.Run("Active card is replaced", func(t *testing.T) {
+		old := ownedCard(enum.Active)
+		card, event, m, err := reissue(old, nil)
+		require.NoError(t, err)
+
+		number := m.vault.Calls[1].Arguments.Get(1).(string)
+		assert.True(t, strings.HasPrefix(number, "603799"))
+		assert.Len(t, number, 16)
+		assert.True(t, luhn.Valid(number))
+		assert.NotEqual(t, "6037991234567893", number)
+
+		assert.Equal(t, number[12:], card.LastFour)
+		assert.Equal(t, 5, *card.ReplacesCardID)
+		assert.Equal(t, enum.Active, card.Status)
+		assert.True(t, card.ExpirationDate.After(time.Now().AddDate(2, 11, 0)))
+		assert.Equal(t, enum.CardReplaced, old.Status)
+		assert.Equal(t, enum.CardActionReissued, event.Action)
+		assert.Equal(t, enum.Active, event.FromStatus)
+		assert.Equal(t, enum.CardReplaced, event.ToStatus)
+	})
+
+	t.Run("Stolen card stays stolen", func(t *testing.T) {
+		old := ownedCard(enum.Stolen)
+		card, event, m, err := reissue(old, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 5, *card.ReplacesCardID)
+		assert.Equal(t, enum.Stolen, old.Status)
+		assert.Equal(t, enum.Stolen, event.ToStatus)
+		m.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Card already reissued", func(t *testing.T) {
+		_, _, m, err := reissue(ownedCard(enum.Lost), ownedCard(enum.Active))
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
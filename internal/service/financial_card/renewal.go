@@ -0,0 +1,229 @@
+package financialcard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNoticeWindow     = 30 * 24 * time.Hour
+	defaultRenewalInterval  = time.Hour
+	defaultRenewalBatchSize = 100
+)
+
+func (s *Service) RunCardRenewal(ctx context.Context) error {
+	s.logger.Info("Card renewal job started", zap.Duration("interval", s.renewalInterval()))
+	defer s.logger.Info("Card renewal job stopped")
+
+	ticker := time.NewTicker(s.renewalInterval())
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if _, err := s.RenewExpiringCards(context.Background(), now); err != nil {
+			s.logger.Error("Failed to renew the expiring cards", zap.Error(err))
+		}
+
+		if _, err := s.ExpireCards(context.Background(), now); err != nil {
+			s.logger.Error("Failed to expire the cards", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RenewExpiringCards renews a batch of cards expiring within the notice window. A card that
+// fails is logged and retried on the next run.
+func (s *Service) RenewExpiringCards(ctx context.Context, now time.Time) (int, error) {
+	cards, err := s.financialCardRepo.ListExpiring(ctx, now.Add(s.noticeWindow()), s.renewalBatchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	renewed := 0
+	for _, card := range cards {
+		if err := s.renew(ctx, card.CardID, now); err != nil {
+			s.logger.Error("Failed to renew the card", zap.Error(err), zap.Int("CardID", card.CardID))
+			continue
+		}
+		renewed++
+	}
+
+	return renewed, nil
+}
+
+// renew issues the replacement of a card about to expire. Unlike a reissue, the old card is
+// left as it is and keeps working until its expiration date.
+func (s *Service) renew(ctx context.Context, cardID int, now time.Time) (err error) {
+	ctx, err = s.financialCardRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.financialCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	old, err := s.financialCardRepo.GetForUpdate(ctx, int64(cardID))
+	if err != nil {
+		return err
+	}
+
+	// The owner may have ended or reissued the card since it was listed
+	if old == nil || old.DeletedAt != nil || (old.Status != enum.Active && old.Status != enum.CardFrozen) {
+		return s.financialCardRepo.CommitTx(ctx)
+	}
+
+	replacement, err := s.financialCardRepo.GetReplacement(ctx, int64(old.CardID))
+	if err != nil {
+		return err
+	}
+
+	if replacement != nil {
+		return s.financialCardRepo.CommitTx(ctx)
+	}
+
+	card, err := s.newReplacement(ctx, old, now)
+	if err != nil {
+		return err
+	}
+
+	// A card frozen by its owner stays blocked on the new number too
+	card.Status = old.Status
+	if err = s.financialCardRepo.Insert(ctx, card); err != nil {
+		return err
+	}
+
+	note := fmt.Sprintf("Renewed by card %d", card.CardID)
+	if err = s.recordEvent(ctx, nil, old.CardID, enum.CardActionRenewed, old.Status, old.Status, note); err != nil {
+		return err
+	}
+
+	if err = s.financialCardRepo.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Card renewed", zap.Int("CardID", old.CardID), zap.Int("newCardID", card.CardID))
+
+	s.notifyRenewal(ctx, old, card)
+
+	return nil
+}
+
+// notifyRenewal tells the owner about the new card. The renewal stands even if they cannot
+// be reached, so a failure is only logged.
+func (s *Service) notifyRenewal(ctx context.Context, old, card *entity.FinancialCard) {
+	account, err := s.financialAccountService.GetAccountByID(ctx, old.AccountID)
+	if err != nil {
+		s.logger.Error("Failed to find the owner of the renewed card", zap.Error(err), zap.Int("CardID", old.CardID))
+		return
+	}
+
+	err = s.notifier.Notify(ctx, entity.Notification{
+		UserID:  account.UserID,
+		Subject: "Your card is being renewed",
+		Body: fmt.Sprintf("Your card %s expires on %s. It is replaced by card %s, valid until %s. "+
+			"The old card keeps working until it expires.",
+			old.MaskedNumber(), old.ExpirationDate.Format("01/06"),
+			card.MaskedNumber(), card.ExpirationDate.Format("01/06")),
+	})
+	if err != nil {
+		s.logger.Error("Failed to notify the owner of the renewed card", zap.Error(err), zap.Int("CardID", old.CardID))
+	}
+}
+
+// ExpireCards ends a batch of cards past their expiration date. A card that fails is logged
+// and retried on the next run.
+func (s *Service) ExpireCards(ctx context.Context, now time.Time) (int, error) {
+	year, month, day := now.UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	cards, err := s.financialCardRepo.ListExpired(ctx, today, s.renewalBatchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, card := range cards {
+		if err := s.expire(ctx, card.CardID, now); err != nil {
+			s.logger.Error("Failed to expire the card", zap.Error(err), zap.Int("CardID", card.CardID))
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (s *Service) expire(ctx context.Context, cardID int, now time.Time) (err error) {
+	ctx, err = s.financialCardRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.financialCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	card, err := s.financialCardRepo.GetForUpdate(ctx, int64(cardID))
+	if err != nil {
+		return err
+	}
+
+	if card == nil || card.DeletedAt != nil || !card.IsExpired(now) || !card.Status.CanBecome(enum.CardExpired) {
+		return s.financialCardRepo.CommitTx(ctx)
+	}
+
+	from := card.Status
+	card.Status = enum.CardExpired
+	card.UpdatedAt = now
+	if err = s.financialCardRepo.Update(ctx, card); err != nil {
+		return err
+	}
+
+	if err = s.recordEvent(ctx, nil, card.CardID, enum.CardActionExpired, from, card.Status, ""); err != nil {
+		return err
+	}
+
+	if err = s.financialCardRepo.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Card expired", zap.Int("CardID", card.CardID))
+
+	return nil
+}
+
+func (s *Service) noticeWindow() time.Duration {
+	if s.renewalCfg.NoticeWindow <= 0 {
+		return defaultNoticeWindow
+	}
+	return s.renewalCfg.NoticeWindow
+}
+
+func (s *Service) renewalInterval() time.Duration {
+	if s.renewalCfg.Interval <= 0 {
+		return defaultRenewalInterval
+	}
+	return s.renewalCfg.Interval
+}
+
+func (s *Service) renewalBatchSize() int {
+	if s.renewalCfg.BatchSize <= 0 {
+		return defaultRenewalBatchSize
+	}
+	return s.renewalCfg.BatchSize
+}
@@ -0,0 +1,147 @@
+package financialcard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewExpiringCards(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	expiring := func(status enum.FinancialCardStatus) *entity.FinancialCard {
+		card := ownedCard(status)
+		card.ExpirationDate = time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+		return card
+	}
+
+	renew := func(old, replacement *entity.FinancialCard) (*entity.CardEvent, mocks, int, error) {
+		service, m := setup()
+
+		var event *entity.CardEvent
+		m.repo.On("ListExpiring", mock.Anything, now.Add(defaultNoticeWindow), defaultRenewalBatchSize).Return([]*entity.FinancialCard{old}, nil)
+		m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(old, nil)
+		m.repo.On("GetReplacement", mock.Anything, int64(5)).Return(replacement, nil)
+		m.repo.On("InsertEvent", mock.Anything, mock.AnythingOfType("*entity.CardEvent")).Run(func(args mock.Arguments) {
+			event = args.Get(1).(*entity.CardEvent)
+		}).Return(nil)
+		m.vault.On("Detokenize", mock.Anything, "tok_old").Return("6037991234567893", nil)
+		m.notifier.On("Notify", mock.Anything, mock.AnythingOfType("entity.Notification")).Return(nil).Maybe()
+
+		renewed, err := service.RenewExpiringCards(context.Background(), now)
+		return event, m, renewed, err
+	}
+
+	t.Run("Active card is renewed and the owner told", func(t *testing.T) {
+		old := expiring(enum.Active)
+		event, m, renewed, err := renew(old, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, renewed)
+
+		var card *entity.FinancialCard
+		m.repo.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(c *entity.FinancialCard) bool {
+			card = c
+			return true
+		}))
+		assert.Equal(t, 5, *card.ReplacesCardID)
+		assert.Equal(t, old.AccountID, card.AccountID)
+		assert.Equal(t, old.CardType, card.CardType)
+		assert.Equal(t, enum.Active, card.Status)
+		assert.True(t, card.ExpirationDate.After(now.AddDate(2, 11, 0)))
+
+		// The old card works until it expires
+		assert.Equal(t, enum.Active, old.Status)
+		m.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		assert.Equal(t, enum.CardActionRenewed, event.Action)
+		assert.Nil(t, event.ActorID)
+
+		m.notifier.AssertCalled(t, "Notify", mock.Anything, mock.MatchedBy(func(n entity.Notification) bool {
+			return n.UserID == 3
+		}))
+	})
+
+	t.Run("Renewal of a frozen card starts frozen", func(t *testing.T) {
+		_, m, renewed, err := renew(expiring(enum.CardFrozen), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, renewed)
+		m.repo.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(c *entity.FinancialCard) bool {
+			return c.Status == enum.CardFrozen
+		}))
+	})
+
+	t.Run("Card reissued since it was listed", func(t *testing.T) {
+		_, m, _, err := renew(expiring(enum.Active), ownedCard(enum.Active))
+		require.NoError(t, err)
+		m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		m.notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Card reported stolen since it was listed", func(t *testing.T) {
+		_, m, _, err := renew(expiring(enum.Stolen), nil)
+		require.NoError(t, err)
+		m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Owner cannot be reached", func(t *testing.T) {
+		service, m := setup()
+		old := expiring(enum.Active)
+		m.repo.On("ListExpiring", mock.Anything, mock.Anything, mock.Anything).Return([]*entity.FinancialCard{old}, nil)
+		m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(old, nil)
+		m.repo.On("GetReplacement", mock.Anything, int64(5)).Return(nil, nil)
+		m.repo.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+		m.vault.On("Detokenize", mock.Anything, "tok_old").Return("6037991234567893", nil)
+		m.notifier.On("Notify", mock.Anything, mock.Anything).Return(errors.New("gateway down"))
+
+		renewed, err := service.RenewExpiringCards(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, renewed)
+		m.repo.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+}
+
+func TestExpireCards(t *testing.T) {
+	now := time.Date(2026, 11, 1, 0, 30, 0, 0, time.UTC)
+	today := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Card past its expiration date expires", func(t *testing.T) {
+		service, m := setup()
+		card := ownedCard(enum.CardFrozen)
+		card.ExpirationDate = time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+
+		var event *entity.CardEvent
+		m.repo.On("ListExpired", mock.Anything, today, defaultRenewalBatchSize).Return([]*entity.FinancialCard{card}, nil)
+		m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(card, nil)
+		m.repo.On("Update", mock.Anything, mock.AnythingOfType("*entity.FinancialCard")).Return(nil)
+		m.repo.On("InsertEvent", mock.Anything, mock.AnythingOfType("*entity.CardEvent")).Run(func(args mock.Arguments) {
+			event = args.Get(1).(*entity.CardEvent)
+		}).Return(nil)
+
+		expired, err := service.ExpireCards(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		assert.Equal(t, enum.CardExpired, card.Status)
+		assert.Equal(t, enum.CardActionExpired, event.Action)
+		assert.Equal(t, enum.CardFrozen, event.FromStatus)
+		assert.Nil(t, event.ActorID)
+	})
+
+	t.Run("Card is usable through its expiration date", func(t *testing.T) {
+		service, m := setup()
+		card := ownedCard(enum.Active)
+		card.ExpirationDate = today
+
+		m.repo.On("ListExpired", mock.Anything, today, defaultRenewalBatchSize).Return([]*entity.FinancialCard{card}, nil)
+		m.repo.On("GetForUpdate", mock.Anything, int64(5)).Return(card, nil)
+
+		_, err := service.ExpireCards(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, enum.Active, card.Status)
+		m.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -8,18 +8,30 @@ import (
 
 type Service struct {
 	cfg                     config.JWT
+	issuingCfg              config.CardIssuing
+	renewalCfg              config.CardRenewal
 	logger                  *zap.SugaredLogger
 	financialCardRepo       protocol.FinancialCardRepository
 	financialAccountService protocol.FinancialAccount
+	cardVaultService        protocol.CardVault
+	cardBINRepo             protocol.CardBINRepository
+	cardControlsRepo        protocol.CardControlsRepository
 	tokenGen                protocol.TokenGenerator
+	notifier                protocol.Notifier
 }
 
-func New(cfg config.JWT, logger *zap.SugaredLogger, financialCard protocol.FinancialCardRepository, tokenGen protocol.TokenGenerator, FinancialAccount protocol.FinancialAccount) *Service {
+func New(cfg config.JWT, issuingCfg config.CardIssuing, renewalCfg config.CardRenewal, logger *zap.SugaredLogger, financialCard protocol.FinancialCardRepository, tokenGen protocol.TokenGenerator, FinancialAccount protocol.FinancialAccount, cardVault protocol.CardVault, cardBIN protocol.CardBINRepository, cardControls protocol.CardControlsRepository, notifier protocol.Notifier) *Service {
 	return &Service{
 		cfg:                     cfg,
+		issuingCfg:              issuingCfg,
+		renewalCfg:              renewalCfg,
 		logger:                  logger,
 		financialAccountService: FinancialAccount,
+		cardVaultService:        cardVault,
+		cardBINRepo:             cardBIN,
+		cardControlsRepo:        cardControls,
 		financialCardRepo:       financialCard,
 		tokenGen:                tokenGen,
+		notifier:                notifier,
 	}
 }
@@ -0,0 +1,141 @@
+package financialcard
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNumberLength   = 16
+	defaultValidityMonths = reissueValidity * 12
+)
+
+// IssueVirtualCard numbers a new virtual debit card on the issuing BIN range and links it to
+// one of the user's accounts. The full number is returned here and nowhere else; from then
+// on it only lives in the vault.
+func (s *Service) IssueVirtualCard(ctx context.Context, req request.IssueVirtualCard) (*entity.FinancialCard, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", derror.NewBadRequestError(err.Error())
+	}
+
+	if s.issuingCfg.BINStart == 0 {
+		return nil, "", derror.NewError("virtual cards are not being issued", http.StatusServiceUnavailable)
+	}
+
+	account, err := s.financialAccountService.GetAccountByID(ctx, req.AccountID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if account.UserID != req.UserID {
+		return nil, "", derror.NewNotFoundError("account %d not found", req.AccountID)
+	}
+
+	currency, err := s.financialAccountService.GetAccountCurrency(ctx, req.AccountID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	spendCap := req.SpendCap
+	if spendCap.IsZero() {
+		spendCap = entity.NewMoney(0, currency.CurrencyCode)
+	}
+	if spendCap.Currency != currency.CurrencyCode {
+		return nil, "", derror.NewBadRequestError("the spend cap must be in the account currency %s", currency.CurrencyCode)
+	}
+
+	now := time.Now()
+	expiresAt := endOfMonth(now.AddDate(0, s.validityMonths(), 0))
+	if req.ExpirationDate != nil {
+		if req.ExpirationDate.After(expiresAt) {
+			return nil, "", derror.NewBadRequestError("a virtual card cannot be valid beyond %s", expiresAt.Format("2006-01-02"))
+		}
+		expiresAt = *req.ExpirationDate
+	}
+
+	number, err := s.issuingNumber()
+	if err != nil {
+		s.logger.Error("Failed to generate a card number", zap.Error(err))
+		return nil, "", derror.NewInternalSystemError()
+	}
+
+	network, bankID, err := s.detectIssuer(ctx, req.AccountID, number)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.cardVaultService.Tokenize(ctx, number)
+	if err != nil {
+		return nil, "", err
+	}
+
+	card := &entity.FinancialCard{
+		AccountID:      req.AccountID,
+		CardToken:      token,
+		LastFour:       number[len(number)-4:],
+		CardType:       enum.Debit,
+		Network:        network,
+		BankID:         bankID,
+		ExpirationDate: expiresAt,
+		CardHolderName: req.CardHolderName,
+		Status:         enum.Active,
+		IssuedDate:     now,
+		Virtual:        true,
+		Usage:          req.Usage,
+		SpendCap:       spendCap,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if req.Usage == enum.MerchantLocked {
+		merchant := strings.TrimSpace(req.Merchant)
+		card.LockedMerchant = &merchant
+	}
+
+	if err := s.financialCardRepo.Insert(ctx, card); err != nil {
+		s.logger.Error("Failed to insert the virtual card", zap.Error(err), zap.Int("accountID", req.AccountID))
+		return nil, "", derror.NewInternalSystemError()
+	}
+
+	s.logger.Info("Virtual card issued", zap.Int("CardID", card.CardID), zap.Int("accountID", req.AccountID), zap.Uint("usage", uint(req.Usage)))
+
+	return card, number, nil
+}
+
+// issuingNumber picks a BIN from the issuing range and numbers a card on it.
+func (s *Service) issuingNumber() (string, error) {
+	span := big.NewInt(int64(s.issuingCfg.BINEnd - s.issuingCfg.BINStart + 1))
+	if span.Sign() <= 0 {
+		span = big.NewInt(1)
+	}
+
+	offset, err := rand.Int(rand.Reader, span)
+	if err != nil {
+		return "", err
+	}
+
+	length := s.issuingCfg.NumberLength
+	if length == 0 {
+		length = defaultNumberLength
+	}
+
+	return newCardNumber(fmt.Sprintf("%06d", s.issuingCfg.BINStart+int(offset.Int64())), length)
+}
+
+func (s *Service) validityMonths() int {
+	if s.issuingCfg.ValidityMonths == 0 {
+		return defaultValidityMonths
+	}
+	return s.issuingCfg.ValidityMonths
+}
@@ -0,0 +1,129 @@
+package financialcard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/luhn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueVirtualCard(t *testing.T) {
+	issue := func(service *Service, req request.IssueVirtualCard) (*entity.FinancialCard, string, error) {
+		req.UserID, req.AccountID, req.CardHolderName = 3, 1, "Sara Ahmadi"
+		return service.IssueVirtualCard(context.Background(), req)
+	}
+
+	visa := &entity.CardBIN{RangeStart: 400000, RangeEnd: 499999, Network: enum.Visa}
+
+	t.Run("Merchant-locked card on the issuing range", func(t *testing.T) {
+		service, m := setup()
+		m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+		m.bins.On("Lookup", mock.Anything, mock.AnythingOfType("int")).Return(visa, nil)
+
+		card, number, err := issue(service, request.IssueVirtualCard{
+			Usage:    enum.MerchantLocked,
+			Merchant: " Book Store ",
+			SpendCap: entity.NewMoney(20000, enum.EUR),
+		})
+		require.NoError(t, err)
+		assert.Len(t, number, 16)
+		assert.True(t, luhn.Valid(number))
+		assert.GreaterOrEqual(t, number[:6], "489000")
+		assert.LessOrEqual(t, number[:6], "489099")
+		assert.Equal(t, number[12:], card.LastFour)
+		assert.Equal(t, "tok_card", card.CardToken)
+		assert.True(t, card.Virtual)
+		assert.Equal(t, enum.Visa, card.Network)
+		assert.Nil(t, card.BankID)
+		assert.Equal(t, "Book Store", *card.LockedMerchant)
+		assert.Equal(t, entity.NewMoney(20000, enum.EUR), card.SpendCap)
+		assert.Equal(t, endOfMonth(time.Now().AddDate(0, defaultValidityMonths, 0)), card.ExpirationDate)
+		m.vault.AssertCalled(t, "Tokenize", mock.Anything, number)
+	})
+
+	t.Run("Custom expiry and no cap", func(t *testing.T) {
+		service, m := setup()
+		m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+		m.bins.On("Lookup", mock.Anything, mock.AnythingOfType("int")).Return(visa, nil)
+
+		expiresAt := time.Now().AddDate(0, 0, 7)
+		card, _, err := issue(service, request.IssueVirtualCard{Usage: enum.SingleUse, ExpirationDate: &expiresAt})
+		require.NoError(t, err)
+		assert.Equal(t, expiresAt, card.ExpirationDate)
+		assert.Equal(t, enum.SingleUse, card.Usage)
+		assert.Equal(t, entity.NewMoney(0, enum.EUR), card.SpendCap)
+	})
+
+	tests := []struct {
+		name       string
+		req        request.IssueVirtualCard
+		userID     int
+		wantStatus int
+	}{
+		{
+			name:       "Merchant-locked card without a merchant",
+			req:        request.IssueVirtualCard{Usage: enum.MerchantLocked},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Merchant on a multi-use card",
+			req:        request.IssueVirtualCard{Usage: enum.MultiUse, Merchant: "Book Store"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Spend cap in another currency",
+			req:        request.IssueVirtualCard{SpendCap: entity.NewMoney(20000, enum.USD)},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Expiry beyond the longest validity",
+			req:        request.IssueVirtualCard{ExpirationDate: timePtr(time.Now().AddDate(10, 0, 0))},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Account of another user",
+			req:        request.IssueVirtualCard{},
+			userID:     4,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+			m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+
+			req := tt.req
+			req.UserID, req.AccountID, req.CardHolderName = 3, 1, "Sara Ahmadi"
+			if tt.userID != 0 {
+				req.UserID = tt.userID
+			}
+
+			_, _, err := service.IssueVirtualCard(context.Background(), req)
+			assert.True(t, derror.IsHTTPError(err, tt.wantStatus), "got %v", err)
+			m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Issuing is turned off", func(t *testing.T) {
+		service, _ := setup()
+		service.issuingCfg.BINStart = 0
+
+		_, _, err := issue(service, request.IssueVirtualCard{})
+		assert.True(t, derror.IsHTTPError(err, http.StatusServiceUnavailable), "got %v", err)
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,140 @@
+package giftcard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSweepInterval  = time.Hour
+	defaultSweepBatchSize = 100
+)
+
+func (s *Service) RunBreakageSweeper(ctx context.Context) error {
+	s.logger.Info("Gift card breakage sweeper started", zap.Duration("sweepInterval", s.sweepInterval()))
+	defer s.logger.Info("Gift card breakage sweeper stopped")
+
+	ticker := time.NewTicker(s.sweepInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireGiftCards(context.Background(), time.Now()); err != nil {
+			s.logger.Error("Failed to expire the gift cards", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireGiftCards closes a batch of expired cards and writes what is left on them off the
+// liability account as breakage. A card that fails is logged and retried on the next sweep.
+func (s *Service) ExpireGiftCards(ctx context.Context, now time.Time) (int, error) {
+	cards, err := s.giftCardRepo.ListExpired(ctx, now, s.sweepBatchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, card := range cards {
+		if err := s.expire(ctx, card.GiftCardID, now); err != nil {
+			s.logger.Error("Failed to expire the gift card", zap.Error(err), zap.Int("GiftCardID", card.GiftCardID))
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (s *Service) expire(ctx context.Context, giftCardID int, now time.Time) (err error) {
+	ctx, err = s.giftCardRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.giftCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	// The card may have been used up while it waited for the sweep
+	card, err := s.giftCardRepo.GetForUpdate(ctx, giftCardID)
+	if err != nil {
+		return err
+	}
+
+	if card.Status != enum.GiftCardActive || !card.IsExpired(now) {
+		return s.giftCardRepo.CommitTx(ctx)
+	}
+
+	breakage := card.Balance
+	accounts, err := s.accountsFor(breakage.Currency)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Gift card %d expired", card.GiftCardID)
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: accounts.LiabilityAccountID, Amount: breakage.Neg()},
+			{FinancialAccountID: accounts.BreakageAccountID, Amount: breakage},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		return err
+	}
+
+	card.Balance = entity.NewMoney(0, breakage.Currency)
+	card.Status = enum.GiftCardExpired
+	if err = s.giftCardRepo.Update(ctx, card); err != nil {
+		return err
+	}
+
+	err = s.giftCardRepo.InsertTransaction(ctx, &entity.GiftCardTransaction{
+		GiftCardID:         card.GiftCardID,
+		Type:               enum.GiftCardBreakage,
+		Amount:             breakage.Neg(),
+		BalanceAfter:       card.Balance,
+		TransactionGroupID: entry.JournalEntryID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = s.giftCardRepo.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Gift card expired",
+		zap.Int("GiftCardID", card.GiftCardID),
+		zap.String("Breakage", breakage.String()),
+		zap.String("Currency", string(breakage.Currency)))
+
+	return nil
+}
+
+func (s *Service) sweepInterval() time.Duration {
+	if s.cfg.SweepInterval <= 0 {
+		return defaultSweepInterval
+	}
+	return s.cfg.SweepInterval
+}
+
+func (s *Service) sweepBatchSize() int {
+	if s.cfg.SweepBatchSize <= 0 {
+		return defaultSweepBatchSize
+	}
+	return s.cfg.SweepBatchSize
+}
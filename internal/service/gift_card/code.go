@@ -0,0 +1,65 @@
+package giftcard
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// Codes use Crockford's base32 alphabet, which has no I, L, O or U, so a code read out or
+// typed by hand is hard to get wrong. Sixteen characters give 80 bits of entropy.
+const (
+	codeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	codeLength   = 16
+	codeGroup    = 4
+)
+
+// newCode returns a random activation code in groups of four, e.g. "7K3Q-M9XD-2HPA-W4TN".
+func newCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(codeAlphabet)))
+
+	for i := 0; i < codeLength; i++ {
+		if i > 0 && i%codeGroup == 0 {
+			b.WriteByte('-')
+		}
+
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+
+	return b.String(), nil
+}
+
+// normalizeCode drops the separators and reads the letters Crockford's alphabet leaves out
+// as the digits they are mistaken for.
+func normalizeCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-':
+			return -1
+		case 'O':
+			return '0'
+		case 'I', 'L':
+			return '1'
+		}
+		return r
+	}, code)
+}
+
+// hashCode is the only form of a code that is stored. The codes are random enough that an
+// unkeyed hash cannot be reversed by guessing.
+func hashCode(code string) []byte {
+	sum := sha256.Sum256([]byte(normalizeCode(code)))
+	return sum[:]
+}
+
+func lastFour(code string) string {
+	normalized := normalizeCode(code)
+	return normalized[len(normalized)-4:]
+}
@@ -0,0 +1,34 @@
+package giftcard
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCode(t *testing.T) {
+	code, err := newCode()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{4}(-[0-9A-HJKMNP-TV-Z]{4}){3}$`), code)
+
+	other, err := newCode()
+	require.NoError(t, err)
+	assert.NotEqual(t, code, other)
+}
+
+func TestHashCode(t *testing.T) {
+	t.Run("Case, separators and look-alike letters do not matter", func(t *testing.T) {
+		assert.Equal(t, hashCode("7K3Q-M9XD-2H0A-W41N"), hashCode(" 7k3q m9xd 2hoa w4in "))
+		assert.Equal(t, hashCode("7K3QM9XD2H0AW41N"), hashCode("7k3q-m9xd-2hOa-w4lN"))
+	})
+
+	t.Run("Different codes", func(t *testing.T) {
+		assert.NotEqual(t, hashCode("7K3Q-M9XD-2H0A-W41N"), hashCode("7K3Q-M9XD-2H0A-W41P"))
+	})
+
+	t.Run("Last four", func(t *testing.T) {
+		assert.Equal(t, "W41N", lastFour("7k3q-m9xd-2h0a-w4in"))
+	})
+}
@@ -0,0 +1,549 @@
+package giftcard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultValidity          = 365 * 24 * time.Hour
+	defaultMaxFailedAttempts = 5
+	defaultFailureWindow     = time.Hour
+)
+
+func (s *Service) IssueGiftCard(ctx context.Context, req request.IssueGiftCard) (card *entity.GiftCard, code string, err error) {
+	s.logger.Info("Starting gift card purchase",
+		zap.Int("accountID", req.AccountID),
+		zap.String("Amount", req.Amount.String()),
+		zap.String("Currency", string(req.Amount.Currency)))
+
+	if err := req.Validate(); err != nil {
+		return nil, "", derror.NewBadRequestError(err.Error())
+	}
+
+	if err := s.checkAccount(ctx, req.UserID, req.AccountID, req.Amount.Currency); err != nil {
+		return nil, "", err
+	}
+
+	accounts, err := s.accountsFor(req.Amount.Currency)
+	if err != nil {
+		return nil, "", err
+	}
+
+	code, err = newCode()
+	if err != nil {
+		s.logger.Error("Failed to generate a gift card code", zap.Error(err))
+		return nil, "", derror.NewInternalSystemError()
+	}
+
+	// The code is checked outside the transaction so that a wrong one still counts towards
+	// the lockout when the purchase rolls back.
+	factor, err := s.twoFactorService.VerifyStepUp(ctx, req.UserID, issueBinding(req), req.TwoFactor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, err = s.giftCardRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, "", err
+	}
+
+	defer func() {
+		if err != nil {
+			s.giftCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	available, err := s.lockAvailableBalance(ctx, req.AccountID, req.Amount.Currency)
+	if err != nil {
+		s.logger.Error("Failed to lock the account balance", zap.Error(err), zap.Int("accountID", req.AccountID))
+		return nil, "", err
+	}
+
+	if available.Amount < req.Amount.Amount {
+		err = derror.NewValidationError("insufficient funds in account %d", req.AccountID)
+		return nil, "", err
+	}
+
+	if err = s.enforcePolicy(ctx, req.AccountID, req.Amount, available, factor); err != nil {
+		return nil, "", err
+	}
+
+	description := "Gift card purchase"
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: req.AccountID, Amount: req.Amount.Neg()},
+			{FinancialAccountID: accounts.LiabilityAccountID, Amount: req.Amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return nil, "", err
+	}
+
+	card = &entity.GiftCard{
+		PurchaserID:   req.UserID,
+		CodeHash:      hashCode(code),
+		CodeLastFour:  lastFour(code),
+		InitialAmount: req.Amount,
+		Balance:       req.Amount,
+		Status:        enum.GiftCardActive,
+		ExpiresAt:     time.Now().Add(s.validity()),
+	}
+
+	if err = s.giftCardRepo.Insert(ctx, card); err != nil {
+		s.logger.Error("Failed to insert the gift card", zap.Error(err))
+		return nil, "", err
+	}
+
+	load := &entity.GiftCardTransaction{
+		GiftCardID:         card.GiftCardID,
+		Type:               enum.GiftCardLoad,
+		Amount:             req.Amount,
+		BalanceAfter:       card.Balance,
+		UserID:             &req.UserID,
+		FinancialAccountID: &req.AccountID,
+		TransactionGroupID: entry.JournalEntryID,
+	}
+
+	if err = s.giftCardRepo.InsertTransaction(ctx, load); err != nil {
+		s.logger.Error("Failed to insert the gift card transaction", zap.Error(err))
+		return nil, "", err
+	}
+
+	if err = s.giftCardRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, "", err
+	}
+
+	s.logger.Info("Gift card issued", zap.Int("GiftCardID", card.GiftCardID), zap.Int("TransactionGroupID", entry.JournalEntryID))
+
+	return card, code, nil
+}
+
+func (s *Service) RedeemGiftCard(ctx context.Context, req request.RedeemGiftCard) (transaction *entity.GiftCardTransaction, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	found, err := s.lookup(ctx, req.UserID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAccount(ctx, req.UserID, req.AccountID, found.Balance.Currency); err != nil {
+		return nil, err
+	}
+
+	ctx, err = s.giftCardRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.giftCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	// Concurrent redemptions and payments wait on this lock, so the balance is paid out once
+	card, err := s.giftCardRepo.GetForUpdate(ctx, found.GiftCardID)
+	if err != nil {
+		s.logger.Error("Failed to lock the gift card", zap.Error(err), zap.Int("GiftCardID", found.GiftCardID))
+		return nil, err
+	}
+
+	if card.Status == enum.GiftCardDepleted {
+		transaction, err = s.earlierRedemption(ctx, card, req)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.complete(ctx, transaction)
+		return transaction, err
+	}
+
+	if err = usable(card); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountsFor(card.Balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := card.Balance
+	description := fmt.Sprintf("Gift card %d redeemed", card.GiftCardID)
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: accounts.LiabilityAccountID, Amount: amount.Neg()},
+			{FinancialAccountID: req.AccountID, Amount: amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return nil, err
+	}
+
+	transaction, err = s.drawDown(ctx, card, amount, entry, func(t *entity.GiftCardTransaction) {
+		t.Type = enum.GiftCardRedeem
+		t.UserID = &req.UserID
+		t.FinancialAccountID = &req.AccountID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.complete(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Gift card redeemed", zap.Int("GiftCardID", card.GiftCardID), zap.Int("accountID", req.AccountID))
+
+	return transaction, nil
+}
+
+func (s *Service) SpendGiftCard(ctx context.Context, req request.SpendGiftCard) (transaction *entity.GiftCardTransaction, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	found, err := s.lookup(ctx, req.UserID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = s.giftCardRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.giftCardRepo.RollbackTx(ctx)
+		}
+	}()
+
+	card, err := s.giftCardRepo.GetForUpdate(ctx, found.GiftCardID)
+	if err != nil {
+		s.logger.Error("Failed to lock the gift card", zap.Error(err), zap.Int("GiftCardID", found.GiftCardID))
+		return nil, err
+	}
+
+	if err = usable(card); err != nil {
+		return nil, err
+	}
+
+	if req.Amount.Currency != card.Balance.Currency {
+		err = derror.NewBadRequestError("the amount must be in %s, the currency of the gift card", card.Balance.Currency)
+		return nil, err
+	}
+
+	if req.Amount.Amount > card.Balance.Amount {
+		err = derror.NewValidationError("the gift card has only %s %s left", card.Balance.String(), card.Balance.Currency)
+		return nil, err
+	}
+
+	accounts, err := s.accountsFor(card.Balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement, ok := s.cardCfg.SettlementAccountFor(string(card.Balance.Currency))
+	if !ok {
+		s.logger.Error("No card settlement account is configured", zap.String("currency", string(card.Balance.Currency)))
+		err = derror.NewValidationError("card payments in %s are not supported", card.Balance.Currency)
+		return nil, err
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Gift card payment at %s", req.Merchant)
+	}
+
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: accounts.LiabilityAccountID, Amount: req.Amount.Neg()},
+			{FinancialAccountID: settlement, Amount: req.Amount},
+		},
+	}
+
+	if err = s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the journal entry", zap.Error(err))
+		return nil, err
+	}
+
+	transaction, err = s.drawDown(ctx, card, req.Amount, entry, func(t *entity.GiftCardTransaction) {
+		t.Type = enum.GiftCardSpend
+		t.UserID = &req.UserID
+		t.Merchant = &req.Merchant
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.complete(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Gift card payment completed", zap.Int("GiftCardID", card.GiftCardID), zap.Int("TransactionGroupID", entry.JournalEntryID))
+
+	return transaction, nil
+}
+
+func (s *Service) GetGiftCardBalance(ctx context.Context, req request.GiftCardCode) (*entity.GiftCard, error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	return s.lookup(ctx, req.UserID, req.Code)
+}
+
+func (s *Service) ListGiftCards(ctx context.Context, userID int) ([]*entity.GiftCard, error) {
+	cards, err := s.giftCardRepo.ListByPurchaserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list the user's gift cards", zap.Error(err), zap.Int("userID", userID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return cards, nil
+}
+
+// lookup finds the card behind a code. Every code is counted against the user as a wrong
+// one before it is looked at, and forgotten again when it is right, so a user with too many
+// recent wrong codes is turned away even when the guesses arrive in parallel.
+func (s *Service) lookup(ctx context.Context, userID int, code string) (*entity.GiftCard, error) {
+	attemptID, claimed, err := s.giftCardRepo.ClaimAttempt(ctx, userID, time.Now().Add(-s.failureWindow()), s.maxFailedAttempts())
+	if err != nil {
+		s.logger.Error("Failed to record the gift card code attempt", zap.Error(err), zap.Int("userID", userID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if !claimed {
+		s.logger.Warn("Gift card codes locked out", zap.Int("userID", userID))
+		return nil, derror.NewError("too many wrong gift card codes, try again later", http.StatusTooManyRequests)
+	}
+
+	card, err := s.giftCardRepo.GetByCodeHash(ctx, hashCode(code))
+	if err != nil {
+		s.logger.Error("Failed to look up the gift card", zap.Error(err))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if card == nil {
+		s.logger.Warn("Wrong gift card code", zap.Int("userID", userID))
+		return nil, derror.NewNotFoundError("gift card not found")
+	}
+
+	if err := s.giftCardRepo.ForgetAttempt(ctx, attemptID); err != nil {
+		s.logger.Error("Failed to forget the gift card code attempt", zap.Error(err), zap.Int("userID", userID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return card, nil
+}
+
+// earlierRedemption makes a repeated redemption into the same account return the first
+// one. A card used up any other way cannot be redeemed.
+func (s *Service) earlierRedemption(ctx context.Context, card *entity.GiftCard, req request.RedeemGiftCard) (*entity.GiftCardTransaction, error) {
+	redemption, err := s.giftCardRepo.GetRedemption(ctx, card.GiftCardID)
+	if err != nil {
+		s.logger.Error("Failed to get the gift card redemption", zap.Error(err), zap.Int("GiftCardID", card.GiftCardID))
+		return nil, err
+	}
+
+	if redemption == nil || redemption.UserID == nil || *redemption.UserID != req.UserID ||
+		redemption.FinancialAccountID == nil || *redemption.FinancialAccountID != req.AccountID {
+		return nil, derror.NewConflictError("gift card has already been used up")
+	}
+
+	return redemption, nil
+}
+
+// drawDown takes amount off the locked card and records the movement posted by entry.
+func (s *Service) drawDown(ctx context.Context, card *entity.GiftCard, amount entity.Money, entry *entity.JournalEntry,
+	describe func(*entity.GiftCardTransaction),
+) (*entity.GiftCardTransaction, error) {
+	balance, err := card.Balance.Sub(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Balance = balance
+	if balance.IsZero() {
+		card.Status = enum.GiftCardDepleted
+	}
+
+	if err := s.giftCardRepo.Update(ctx, card); err != nil {
+		s.logger.Error("Failed to update the gift card", zap.Error(err), zap.Int("GiftCardID", card.GiftCardID))
+		return nil, err
+	}
+
+	transaction := &entity.GiftCardTransaction{
+		GiftCardID:         card.GiftCardID,
+		Amount:             amount.Neg(),
+		BalanceAfter:       balance,
+		TransactionGroupID: entry.JournalEntryID,
+	}
+	describe(transaction)
+
+	if err := s.giftCardRepo.InsertTransaction(ctx, transaction); err != nil {
+		s.logger.Error("Failed to insert the gift card transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func usable(card *entity.GiftCard) error {
+	switch {
+	case card.Status == enum.GiftCardExpired || card.IsExpired(time.Now()):
+		return derror.NewValidationError("gift card has expired")
+	case card.Status == enum.GiftCardDepleted:
+		return derror.NewValidationError("gift card has been used up")
+	}
+	return nil
+}
+
+// checkAccount checks that the account is the user's, accepts transactions and holds the
+// given currency.
+func (s *Service) checkAccount(ctx context.Context, userID, accountID int, currency enum.CurrencyCode) error {
+	account, err := s.financialAccountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.UserID != userID {
+		return derror.NewNotFoundError("account %d not found", accountID)
+	}
+
+	status, err := s.financialAccountService.GetAccountStatus(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account status", zap.Error(err), zap.Int("accountID", accountID))
+		return err
+	}
+
+	if status != enum.Verified {
+		return derror.NewValidationError("account %d does not allow transactions", accountID)
+	}
+
+	accountCurrency, err := s.financialAccountService.GetAccountCurrency(ctx, accountID)
+	if err != nil {
+		s.logger.Error("Failed to fetch the account currency", zap.Error(err), zap.Int("accountID", accountID))
+		return err
+	}
+
+	if accountCurrency.CurrencyCode != currency {
+		return derror.NewBadRequestError("account %d is in %s, not %s", accountID, accountCurrency.CurrencyCode, currency)
+	}
+
+	return nil
+}
+
+func (s *Service) accountsFor(currency enum.CurrencyCode) (config.GiftCardAccounts, error) {
+	accounts, ok := s.cfg.AccountsFor(string(currency))
+	if !ok {
+		s.logger.Error("No gift card accounts are configured", zap.String("currency", string(currency)))
+		return accounts, derror.NewValidationError("gift cards in %s are not supported", currency)
+	}
+	return accounts, nil
+}
+
+func (s *Service) lockAvailableBalance(ctx context.Context, accountID int, currency enum.CurrencyCode) (entity.Money, error) {
+	balance, err := s.ledgerRepo.LockBalance(ctx, accountID, currency)
+	if err != nil {
+		return entity.Money{}, err
+	}
+
+	held, err := s.ledgerRepo.HeldAmount(ctx, accountID, currency)
+	if err != nil {
+		s.logger.Error("Failed to sum the authorization holds", zap.Error(err), zap.Int("accountID", accountID))
+		return entity.Money{}, err
+	}
+
+	return entity.NewMoney(balance.Amount-held.Amount, currency), nil
+}
+
+// enforcePolicy rejects a purchase that breaks the rules of its account, listing every violated
+// rule. One held back by the two-factor rule alone is answered with a challenge instead.
+func (s *Service) enforcePolicy(ctx context.Context, accountID int, amount, balance entity.Money, factor entity.SecondFactor) error {
+	violations, err := s.accountRulesService.Evaluate(ctx, request.EvaluatePolicy{
+		FinancialAccountID:   accountID,
+		Amount:               amount,
+		Balance:              balance,
+		SecondFactorVerified: factor.Verified,
+	})
+	if err != nil {
+		s.logger.Error("Failed to evaluate the account rules", zap.Error(err))
+		return err
+	}
+
+	if factor.UserID != 0 && entity.OnlyTwoFactor(violations) {
+		return s.twoFactorService.StepUp(ctx, factor, violations)
+	}
+
+	if len(violations) > 0 {
+		s.logger.Warn("Gift card purchase violates the account rules", zap.Int("accountID", accountID), zap.Any("violations", violations))
+		return derror.WithDetails(derror.NewValidationError("the transaction violates the rules of account %d", accountID), violations)
+	}
+
+	return nil
+}
+
+// complete stores the response for the request's Idempotency-Key and commits, so both
+// take effect together.
+func (s *Service) complete(ctx context.Context, resp any) error {
+	if err := s.idempotencyService.Complete(ctx, resp); err != nil {
+		s.logger.Error("Failed to store the idempotent response", zap.Error(err))
+		return err
+	}
+
+	if err := s.giftCardRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) validity() time.Duration {
+	if s.cfg.Validity <= 0 {
+		return defaultValidity
+	}
+	return s.cfg.Validity
+}
+
+func (s *Service) maxFailedAttempts() int {
+	if s.cfg.MaxFailedAttempts <= 0 {
+		return defaultMaxFailedAttempts
+	}
+	return s.cfg.MaxFailedAttempts
+}
+
+func (s *Service) failureWindow() time.Duration {
+	if s.cfg.FailureWindow <= 0 {
+		return defaultFailureWindow
+	}
+	return s.cfg.FailureWindow
+}
+
+func issueBinding(req request.IssueGiftCard) string {
+	return fmt.Sprintf("gift_card:%d:%d:%d:%s", req.UserID, req.AccountID, req.Amount.Amount, req.Amount.Currency)
+}
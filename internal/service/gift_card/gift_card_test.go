@@ -0,0 +1,334 @@
+package giftcard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	liabilityAccountID  = 70
+	breakageAccountID   = 80
+	settlementAccountID = 50
+	code                = "7K3Q-M9XD-2H0A-W41N"
+)
+
+type mocks struct {
+	repo     *protocol.MockGiftCardRepo
+	ledger   *protocol.MockLedgerRepo
+	accounts *protocol.MockFinancialAccountService
+	rules    *protocol.MockAccountRulesService
+}
+
+func setup() (*Service, mocks) {
+	m := mocks{
+		repo:     new(protocol.MockGiftCardRepo),
+		ledger:   new(protocol.MockLedgerRepo),
+		accounts: new(protocol.MockFinancialAccountService),
+		rules:    new(protocol.MockAccountRulesService),
+	}
+	mockIdempotency := new(protocol.MockIdempotencyService)
+	mockIdempotency.On("Complete", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockTwoFactor := new(protocol.MockTwoFactorService)
+	mockTwoFactor.On("VerifyStepUp", mock.Anything, mock.Anything, mock.Anything, (*request.TwoFactorAnswer)(nil)).Return(entity.SecondFactor{}, nil).Maybe()
+	m.rules.On("Evaluate", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	m.repo.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	m.repo.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	m.repo.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	m.repo.On("ClaimAttempt", mock.Anything, 3, mock.Anything, defaultMaxFailedAttempts).Return(1, true, nil).Maybe()
+	m.repo.On("ForgetAttempt", mock.Anything, 1).Return(nil).Maybe()
+	m.repo.On("Update", mock.Anything, mock.AnythingOfType("*entity.GiftCard")).Return(nil).Maybe()
+	m.repo.On("InsertTransaction", mock.Anything, mock.AnythingOfType("*entity.GiftCardTransaction")).Return(nil).Maybe()
+	m.ledger.On("HeldAmount", mock.Anything, mock.Anything, mock.Anything).Return(usd(0), nil).Maybe()
+	m.ledger.On("Post", mock.Anything, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		args.Get(1).(*entity.JournalEntry).JournalEntryID = 9
+	}).Return(nil).Maybe()
+	m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil).Maybe()
+	m.accounts.On("GetAccountStatus", mock.Anything, 1).Return(enum.Verified, nil).Maybe()
+	m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.USD}, nil).Maybe()
+
+	cfg := config.GiftCard{
+		Accounts: []config.GiftCardAccounts{{Currency: "USD", LiabilityAccountID: liabilityAccountID, BreakageAccountID: breakageAccountID}},
+	}
+	cardCfg := config.Card{
+		SettlementAccounts: []config.CardSettlementAccount{{Currency: "USD", AccountID: settlementAccountID}},
+	}
+
+	logger, _ := zap.NewProduction()
+
+	service := New(cfg, cardCfg, logger.Sugar(), m.repo, m.ledger, m.accounts, m.rules, mockIdempotency, mockTwoFactor)
+	return service, m
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+func giftCard(balance int64, status enum.GiftCardStatus, expiresAt time.Time) *entity.GiftCard {
+	return &entity.GiftCard{
+		GiftCardID:    20,
+		PurchaserID:   4,
+		CodeHash:      hashCode(code),
+		CodeLastFour:  "W41N",
+		InitialAmount: usd(5000),
+		Balance:       usd(balance),
+		Status:        status,
+		ExpiresAt:     expiresAt,
+	}
+}
+
+// postedEntry returns the entry of the mocked ledger's only Post call.
+func postedEntry(t *testing.T, m mocks) *entity.JournalEntry {
+	t.Helper()
+
+	var entries []*entity.JournalEntry
+	for _, call := range m.ledger.Calls {
+		if call.Method == "Post" {
+			entries = append(entries, call.Arguments.Get(1).(*entity.JournalEntry))
+		}
+	}
+	require.Len(t, entries, 1)
+	return entries[0]
+}
+
+func TestIssueGiftCard(t *testing.T) {
+	req := request.IssueGiftCard{UserID: 3, AccountID: 1, Amount: usd(5000)}
+
+	t.Run("Successfully issue", func(t *testing.T) {
+		service, m := setup()
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(8000), nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.GiftCard")).Run(func(args mock.Arguments) {
+			args.Get(1).(*entity.GiftCard).GiftCardID = 20
+		}).Return(nil)
+
+		card, code, err := service.IssueGiftCard(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, hashCode(code), card.CodeHash)
+		assert.Equal(t, lastFour(code), card.CodeLastFour)
+		assert.Equal(t, usd(5000), card.Balance)
+		assert.Equal(t, enum.GiftCardActive, card.Status)
+		assert.WithinDuration(t, time.Now().Add(defaultValidity), card.ExpiresAt, time.Minute)
+
+		entry := postedEntry(t, m)
+		assert.True(t, entry.IsBalanced())
+		assert.Equal(t, usd(-5000), entry.PostingFor(1).Amount)
+		assert.Equal(t, usd(5000), entry.PostingFor(liabilityAccountID).Amount)
+		m.repo.AssertCalled(t, "InsertTransaction", mock.Anything, mock.MatchedBy(func(tr *entity.GiftCardTransaction) bool {
+			return tr.Type == enum.GiftCardLoad && tr.GiftCardID == 20 && tr.TransactionGroupID == 9
+		}))
+		m.repo.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+
+	t.Run("Insufficient funds", func(t *testing.T) {
+		service, m := setup()
+		m.ledger.On("LockBalance", mock.Anything, 1, enum.USD).Return(usd(4000), nil)
+
+		_, _, err := service.IssueGiftCard(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Account of another user", func(t *testing.T) {
+		service, _ := setup()
+
+		_, _, err := service.IssueGiftCard(context.Background(), request.IssueGiftCard{UserID: 4, AccountID: 1, Amount: usd(5000)})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+
+	t.Run("Currency without gift card accounts", func(t *testing.T) {
+		service, m := setup()
+		m.accounts.ExpectedCalls = nil
+		m.accounts.On("GetAccountByID", mock.Anything, 1).Return(response.GetFinancialAccount{AccountID: 1, UserID: 3}, nil)
+		m.accounts.On("GetAccountStatus", mock.Anything, 1).Return(enum.Verified, nil)
+		m.accounts.On("GetAccountCurrency", mock.Anything, 1).Return(response.GetCurrency{CurrencyCode: enum.EUR}, nil)
+
+		_, _, err := service.IssueGiftCard(context.Background(), request.IssueGiftCard{UserID: 3, AccountID: 1, Amount: entity.NewMoney(5000, enum.EUR)})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+	})
+}
+
+func TestRedeemGiftCard(t *testing.T) {
+	req := request.RedeemGiftCard{UserID: 3, Code: "7k3q m9xd 2h0a w41n", AccountID: 1}
+
+	t.Run("Whole balance goes into the account", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(3000, enum.GiftCardActive, time.Now().AddDate(0, 1, 0))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		transaction, err := service.RedeemGiftCard(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, enum.GiftCardRedeem, transaction.Type)
+		assert.Equal(t, usd(-3000), transaction.Amount)
+		assert.Equal(t, usd(0), transaction.BalanceAfter)
+		assert.Equal(t, 1, *transaction.FinancialAccountID)
+		assert.Equal(t, enum.GiftCardDepleted, card.Status)
+		m.repo.AssertCalled(t, "ForgetAttempt", mock.Anything, 1)
+
+		entry := postedEntry(t, m)
+		assert.Equal(t, usd(-3000), entry.PostingFor(liabilityAccountID).Amount)
+		assert.Equal(t, usd(3000), entry.PostingFor(1).Amount)
+	})
+
+	t.Run("Redeeming again into the same account returns the first redemption", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(0, enum.GiftCardDepleted, time.Now().AddDate(0, 1, 0))
+		userID, accountID := 3, 1
+		first := &entity.GiftCardTransaction{GiftCardTransactionID: 31, GiftCardID: 20, Type: enum.GiftCardRedeem, UserID: &userID, FinancialAccountID: &accountID}
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+		m.repo.On("GetRedemption", mock.Anything, 20).Return(first, nil)
+
+		transaction, err := service.RedeemGiftCard(context.Background(), req)
+		require.NoError(t, err)
+		assert.Same(t, first, transaction)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Card spent at merchants cannot be redeemed", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(0, enum.GiftCardDepleted, time.Now().AddDate(0, 1, 0))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+		m.repo.On("GetRedemption", mock.Anything, 20).Return(nil, nil)
+
+		_, err := service.RedeemGiftCard(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+	})
+
+	t.Run("Expired card", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(3000, enum.GiftCardActive, time.Now().Add(-time.Minute))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		_, err := service.RedeemGiftCard(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong code counts against the user", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("GetByCodeHash", mock.Anything, mock.Anything).Return(nil, nil)
+
+		_, err := service.RedeemGiftCard(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+		m.repo.AssertCalled(t, "ClaimAttempt", mock.Anything, 3, mock.Anything, defaultMaxFailedAttempts)
+		m.repo.AssertNotCalled(t, "ForgetAttempt", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Too many wrong codes", func(t *testing.T) {
+		service, m := setup()
+		m.repo.ExpectedCalls = nil
+		m.repo.On("ClaimAttempt", mock.Anything, 3, mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since) >= defaultFailureWindow
+		}), defaultMaxFailedAttempts).Return(0, false, nil)
+
+		_, err := service.RedeemGiftCard(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusTooManyRequests), "got %v", err)
+		m.repo.AssertNotCalled(t, "GetByCodeHash", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSpendGiftCard(t *testing.T) {
+	req := request.SpendGiftCard{UserID: 3, Code: code, Amount: usd(2000), Merchant: "Book Store"}
+
+	t.Run("Partial payment", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(3000, enum.GiftCardActive, time.Now().AddDate(0, 1, 0))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		transaction, err := service.SpendGiftCard(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, enum.GiftCardSpend, transaction.Type)
+		assert.Equal(t, usd(1000), transaction.BalanceAfter)
+		assert.Equal(t, "Book Store", *transaction.Merchant)
+		assert.Equal(t, enum.GiftCardActive, card.Status)
+
+		entry := postedEntry(t, m)
+		assert.Equal(t, usd(-2000), entry.PostingFor(liabilityAccountID).Amount)
+		assert.Equal(t, usd(2000), entry.PostingFor(settlementAccountID).Amount)
+	})
+
+	t.Run("Payment of the whole balance depletes the card", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(2000, enum.GiftCardActive, time.Now().AddDate(0, 1, 0))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		_, err := service.SpendGiftCard(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, enum.GiftCardDepleted, card.Status)
+	})
+
+	t.Run("Amount over the balance", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(1500, enum.GiftCardActive, time.Now().AddDate(0, 1, 0))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		_, err := service.SpendGiftCard(context.Background(), req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Amount in another currency", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(3000, enum.GiftCardActive, time.Now().AddDate(0, 1, 0))
+		m.repo.On("GetByCodeHash", mock.Anything, hashCode(code)).Return(card, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		_, err := service.SpendGiftCard(context.Background(), request.SpendGiftCard{
+			UserID: 3, Code: code, Amount: entity.NewMoney(2000, enum.EUR), Merchant: "Book Store",
+		})
+		assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest), "got %v", err)
+	})
+}
+
+func TestExpireGiftCards(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Unused balance is written off as breakage", func(t *testing.T) {
+		service, m := setup()
+		card := giftCard(1200, enum.GiftCardActive, now.Add(-time.Hour))
+		m.repo.On("ListExpired", mock.Anything, now, defaultSweepBatchSize).Return([]*entity.GiftCard{card}, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(card, nil)
+
+		expired, err := service.ExpireGiftCards(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		assert.Equal(t, enum.GiftCardExpired, card.Status)
+		assert.Equal(t, usd(0), card.Balance)
+
+		entry := postedEntry(t, m)
+		assert.Equal(t, usd(-1200), entry.PostingFor(liabilityAccountID).Amount)
+		assert.Equal(t, usd(1200), entry.PostingFor(breakageAccountID).Amount)
+		m.repo.AssertCalled(t, "InsertTransaction", mock.Anything, mock.MatchedBy(func(tr *entity.GiftCardTransaction) bool {
+			return tr.Type == enum.GiftCardBreakage && tr.Amount == usd(-1200)
+		}))
+	})
+
+	t.Run("Card used up before the sweep reached it", func(t *testing.T) {
+		service, m := setup()
+		m.repo.On("ListExpired", mock.Anything, now, defaultSweepBatchSize).Return([]*entity.GiftCard{giftCard(1200, enum.GiftCardActive, now.Add(-time.Hour))}, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 20).Return(giftCard(0, enum.GiftCardDepleted, now.Add(-time.Hour)), nil)
+
+		_, err := service.ExpireGiftCards(context.Background(), now)
+		require.NoError(t, err)
+		m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,43 @@
+package giftcard
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                     config.GiftCard
+	cardCfg                 config.Card
+	logger                  *zap.SugaredLogger
+	giftCardRepo            protocol.GiftCardRepository
+	ledgerRepo              protocol.LedgerRepository
+	financialAccountService protocol.FinancialAccount
+	accountRulesService     protocol.AccountRules
+	idempotencyService      protocol.Idempotency
+	twoFactorService        protocol.TwoFactor
+}
+
+func New(
+	cfg config.GiftCard,
+	cardCfg config.Card,
+	logger *zap.SugaredLogger,
+	giftCardRepo protocol.GiftCardRepository,
+	ledgerRepo protocol.LedgerRepository,
+	financialAccountService protocol.FinancialAccount,
+	accountRulesService protocol.AccountRules,
+	idempotencyService protocol.Idempotency,
+	twoFactorService protocol.TwoFactor,
+) *Service {
+	return &Service{
+		cfg:                     cfg,
+		cardCfg:                 cardCfg,
+		logger:                  logger,
+		giftCardRepo:            giftCardRepo,
+		ledgerRepo:              ledgerRepo,
+		financialAccountService: financialAccountService,
+		accountRulesService:     accountRulesService,
+		idempotencyService:      idempotencyService,
+		twoFactorService:        twoFactorService,
+	}
+}
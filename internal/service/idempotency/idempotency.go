@@ -0,0 +1,163 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTTL   = 24 * time.Hour
+	defaultLease = time.Minute
+	maxKeyLength = 255
+)
+
+type claimKey struct{}
+
+// Begin claims key for the user and scope before the operation runs. When the key is
+// empty the request is not idempotent and ctx is returned unchanged.
+//
+// The claim holds the key only for a short lease. A request that crashed before completing
+// or releasing its claim leaves it behind, and a retry of the same request takes it over
+// once the lease lapses rather than getting a conflict until the key expires.
+func (s *Service) Begin(ctx context.Context, userID int, scope, key string, req any) (context.Context, []byte, error) {
+	if key == "" {
+		return ctx, nil, nil
+	}
+
+	if len(key) > maxKeyLength {
+		return ctx, nil, derror.NewBadRequestError("Idempotency-Key must be at most %d characters", maxKeyLength)
+	}
+
+	fingerprint, err := s.fingerprint(scope, req)
+	if err != nil {
+		s.logger.Error("Failed to fingerprint the request", zap.Error(err))
+		return ctx, nil, derror.NewInternalSystemError()
+	}
+
+	now := time.Now()
+	lockedUntil := now.Add(s.lease())
+	record := &entity.IdempotencyKey{
+		UserID:      userID,
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      enum.IdempotencyInFlight,
+		LockedUntil: &lockedUntil,
+		ExpiresAt:   now.Add(s.ttl()),
+	}
+
+	claimed, err := s.idempotencyRepo.Claim(ctx, record)
+	if err != nil {
+		s.logger.Error("Failed to claim the idempotency key", zap.Error(err))
+		return ctx, nil, derror.NewInternalSystemError()
+	}
+
+	if claimed {
+		return context.WithValue(ctx, claimKey{}, record), nil, nil
+	}
+
+	existing, err := s.idempotencyRepo.Get(ctx, userID, scope, key)
+	if err != nil {
+		s.logger.Error("Failed to fetch the idempotency key", zap.Error(err))
+		return ctx, nil, derror.NewInternalSystemError()
+	}
+
+	// The key expired and was removed between the claim and the read; let the client retry.
+	if existing == nil {
+		return ctx, nil, derror.NewConflictError("the request with this Idempotency-Key is being processed, retry later")
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return ctx, nil, derror.NewValidationError("Idempotency-Key was already used with a different request")
+	}
+
+	if existing.Status != enum.IdempotencyCompleted {
+		return ctx, nil, derror.NewConflictError("the request with this Idempotency-Key is being processed, retry later")
+	}
+
+	s.logger.Info("Replaying the stored response", zap.String("scope", scope), zap.Int("userID", userID))
+
+	return ctx, existing.Response, nil
+}
+
+// Complete stores resp for the claim in ctx. Callers run it inside the database
+// transaction of the operation so the response and its effects commit together.
+func (s *Service) Complete(ctx context.Context, resp any) error {
+	record, ok := ctx.Value(claimKey{}).(*entity.IdempotencyKey)
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.logger.Error("Failed to marshal the response", zap.Error(err))
+		return derror.NewInternalSystemError()
+	}
+
+	completed, err := s.idempotencyRepo.Complete(ctx, record.UserID, record.Scope, record.Key, *record.LockedUntil, data)
+	if err != nil {
+		s.logger.Error("Failed to store the idempotent response", zap.Error(err))
+		return err
+	}
+
+	// A retry took the claim over after its lease lapsed; rolling this attempt back keeps the
+	// operation from running twice.
+	if !completed {
+		s.logger.Warn("Idempotency claim was taken over", zap.String("scope", record.Scope), zap.Int("userID", record.UserID))
+		return derror.NewConflictError("the request with this Idempotency-Key took too long and was retried")
+	}
+
+	record.Status = enum.IdempotencyCompleted
+	record.LockedUntil = nil
+	record.Response = data
+
+	return nil
+}
+
+// Release drops an in-flight claim so that a failed request can be retried with the same key.
+func (s *Service) Release(ctx context.Context) error {
+	record, ok := ctx.Value(claimKey{}).(*entity.IdempotencyKey)
+	if !ok || record.Status == enum.IdempotencyCompleted {
+		return nil
+	}
+
+	// A claim taken over by a retry is no longer this request's to drop
+	if _, err := s.idempotencyRepo.Delete(ctx, record.UserID, record.Scope, record.Key, *record.LockedUntil); err != nil {
+		s.logger.Error("Failed to release the idempotency key", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) fingerprint(scope string, req any) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(append([]byte(scope+"\n"), body...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (s *Service) ttl() time.Duration {
+	if s.cfg.TTL <= 0 {
+		return defaultTTL
+	}
+	return s.cfg.TTL
+}
+
+func (s *Service) lease() time.Duration {
+	if s.cfg.Lease <= 0 {
+		return defaultLease
+	}
+	return s.cfg.Lease
+}
@@ -0,0 +1,151 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const scope = "accountTransaction.transfer"
+
+type transfer struct {
+	From   int `json:"from"`
+	To     int `json:"to"`
+	Amount int `json:"amount"`
+}
+
+func setup() (*Service, *protocol.MockIdempotencyRepo) {
+	mockRepo := new(protocol.MockIdempotencyRepo)
+	logger, _ := zap.NewProduction()
+
+	return New(config.Idempotency{TTL: time.Hour}, logger.Sugar(), mockRepo), mockRepo
+}
+
+func TestBegin(t *testing.T) {
+	req := transfer{From: 1, To: 2, Amount: 1000}
+
+	t.Run("skips requests without a key", func(t *testing.T) {
+		service, mockRepo := setup()
+		ctx := context.Background()
+
+		got, replay, err := service.Begin(ctx, 1, scope, "", req)
+		require.NoError(t, err)
+		assert.Nil(t, replay)
+		assert.Equal(t, ctx, got)
+		mockRepo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything)
+	})
+
+	t.Run("claims a new key and stores the response on completion", func(t *testing.T) {
+		service, mockRepo := setup()
+		mockRepo.On("Claim", mock.Anything, mock.MatchedBy(func(k *entity.IdempotencyKey) bool {
+			return k.UserID == 1 && k.Key == "abc" && k.Status == enum.IdempotencyInFlight && time.Until(k.ExpiresAt) > 59*time.Minute &&
+				time.Until(*k.LockedUntil) <= time.Minute
+		})).Return(true, nil)
+		mockRepo.On("Complete", mock.Anything, 1, scope, "abc", mock.AnythingOfType("time.Time"), []byte(`{"ok":true}`)).Return(true, nil)
+
+		ctx, replay, err := service.Begin(context.Background(), 1, scope, "abc", req)
+		require.NoError(t, err)
+		assert.Nil(t, replay)
+
+		require.NoError(t, service.Complete(ctx, map[string]bool{"ok": true}))
+		// A completed claim is kept so that retries replay it.
+		require.NoError(t, service.Release(ctx))
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("replays the stored response for the same request", func(t *testing.T) {
+		service, mockRepo := setup()
+		fingerprint, err := service.fingerprint(scope, req)
+		require.NoError(t, err)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, 1, scope, "abc").Return(&entity.IdempotencyKey{
+			Fingerprint: fingerprint,
+			Status:      enum.IdempotencyCompleted,
+			Response:    []byte(`{"ok":true}`),
+		}, nil)
+
+		_, replay, err := service.Begin(context.Background(), 1, scope, "abc", req)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"ok":true}`), replay)
+	})
+
+	t.Run("rejects a different request with the same key", func(t *testing.T) {
+		service, mockRepo := setup()
+		fingerprint, err := service.fingerprint(scope, req)
+		require.NoError(t, err)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, 1, scope, "abc").Return(&entity.IdempotencyKey{
+			Fingerprint: fingerprint,
+			Status:      enum.IdempotencyCompleted,
+		}, nil)
+
+		_, _, err = service.Begin(context.Background(), 1, scope, "abc", transfer{From: 1, To: 2, Amount: 2000})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity))
+	})
+
+	t.Run("reports a key that is still in flight as a conflict", func(t *testing.T) {
+		service, mockRepo := setup()
+		fingerprint, err := service.fingerprint(scope, req)
+		require.NoError(t, err)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, 1, scope, "abc").Return(&entity.IdempotencyKey{
+			Fingerprint: fingerprint,
+			Status:      enum.IdempotencyInFlight,
+		}, nil)
+
+		_, _, err = service.Begin(context.Background(), 1, scope, "abc", req)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict))
+	})
+
+	t.Run("releases a failed claim", func(t *testing.T) {
+		service, mockRepo := setup()
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(true, nil)
+		mockRepo.On("Delete", mock.Anything, 1, scope, "abc", mock.AnythingOfType("time.Time")).Return(true, nil)
+
+		ctx, _, err := service.Begin(context.Background(), 1, scope, "abc", req)
+		require.NoError(t, err)
+		require.NoError(t, service.Release(ctx))
+		mockRepo.AssertCalled(t, "Delete", mock.Anything, 1, scope, "abc", mock.AnythingOfType("time.Time"))
+	})
+
+	t.Run("a retry takes over a stale claim", func(t *testing.T) {
+		service, mockRepo := setup()
+		service.cfg.Lease = time.Millisecond
+
+		var leases []time.Time
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			leases = append(leases, *args.Get(1).(*entity.IdempotencyKey).LockedUntil)
+		}).Return(true, nil)
+
+		stale, _, err := service.Begin(context.Background(), 1, scope, "abc", req)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+
+		retry, replay, err := service.Begin(context.Background(), 1, scope, "abc", req)
+		require.NoError(t, err)
+		assert.Nil(t, replay)
+		require.Len(t, leases, 2)
+
+		// Only the claim holding the current lease may complete
+		mockRepo.On("Complete", mock.Anything, 1, scope, "abc", leases[0], mock.Anything).Return(false, nil)
+		mockRepo.On("Complete", mock.Anything, 1, scope, "abc", leases[1], mock.Anything).Return(true, nil)
+
+		err = service.Complete(stale, map[string]bool{"ok": true})
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		require.NoError(t, service.Complete(retry, map[string]bool{"ok": true}))
+	})
+}
@@ -0,0 +1,21 @@
+package idempotency
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg             config.Idempotency
+	logger          *zap.SugaredLogger
+	idempotencyRepo protocol.IdempotencyRepository
+}
+
+func New(cfg config.Idempotency, logger *zap.SugaredLogger, idempotencyRepo protocol.IdempotencyRepository) *Service {
+	return &Service{
+		cfg:             cfg,
+		logger:          logger,
+		idempotencyRepo: idempotencyRepo,
+	}
+}
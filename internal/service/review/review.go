@@ -0,0 +1,495 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+// OpenCase runs inside the transaction of the held transfer, so a transfer is never
+// held without a case to release it.
+func (s *Service) OpenCase(ctx context.Context, assessment *entity.RiskAssessment) error {
+	reviewCase := &entity.ReviewCase{
+		TransactionGroupID: assessment.TransactionGroupID,
+		RiskAssessmentID:   assessment.RiskAssessmentID,
+		Status:             enum.ReviewOpen,
+		Assessment:         assessment,
+	}
+
+	if err := s.reviewCaseRepo.Insert(ctx, reviewCase); err != nil {
+		s.logger.Error("Failed to open the review case", zap.Error(err), zap.Int("TransactionGroupID", assessment.TransactionGroupID))
+		return err
+	}
+
+	if err := s.audit(ctx, reviewCase, nil, enum.ReviewActionOpened, nil); err != nil {
+		return err
+	}
+
+	s.logger.Info("Opened review case", zap.Int("ReviewCaseID", reviewCase.ReviewCaseID), zap.Int("TransactionGroupID", assessment.TransactionGroupID))
+
+	return nil
+}
+
+func (s *Service) ListCases(ctx context.Context, req request.ListReviewCases) ([]*entity.ReviewCase, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid review case filter", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	cases, err := s.reviewCaseRepo.List(ctx, req)
+	if err != nil {
+		s.logger.Error("Failed to list review cases", zap.Error(err))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return cases, nil
+}
+
+func (s *Service) GetCase(ctx context.Context, caseID int) (response.ReviewCaseDetail, error) {
+	if caseID <= 0 {
+		return response.ReviewCaseDetail{}, derror.NewBadRequestError("Invalid review case ID")
+	}
+
+	reviewCase, err := s.reviewCaseRepo.Get(ctx, caseID)
+	if err != nil {
+		s.logger.Error("Failed to get the review case", zap.Error(err), zap.Int("ReviewCaseID", caseID))
+		return response.ReviewCaseDetail{}, derror.NewInternalSystemError()
+	}
+
+	if reviewCase == nil {
+		return response.ReviewCaseDetail{}, derror.NewNotFoundError("review case not found")
+	}
+
+	transactions, err := s.accountTransactionRepo.ListByTransactionGroupID(ctx, reviewCase.TransactionGroupID)
+	if err != nil {
+		s.logger.Error("Failed to list the transactions of the review case", zap.Error(err), zap.Int("ReviewCaseID", caseID))
+		return response.ReviewCaseDetail{}, derror.NewInternalSystemError()
+	}
+
+	cardTransactions, err := s.cardTransactionRepo.ListByTransactionGroupID(ctx, reviewCase.TransactionGroupID)
+	if err != nil {
+		s.logger.Error("Failed to list the card transactions of the review case", zap.Error(err), zap.Int("ReviewCaseID", caseID))
+		return response.ReviewCaseDetail{}, derror.NewInternalSystemError()
+	}
+
+	events, err := s.reviewCaseRepo.ListEvents(ctx, caseID)
+	if err != nil {
+		s.logger.Error("Failed to list the review events", zap.Error(err), zap.Int("ReviewCaseID", caseID))
+		return response.ReviewCaseDetail{}, derror.NewInternalSystemError()
+	}
+
+	return response.ReviewCaseDetail{
+		Case:             *reviewCase,
+		Transactions:     transactions,
+		CardTransactions: cardTransactions,
+		Events:           events,
+	}, nil
+}
+
+// AssignCase hands an open case to an admin, taking it from its current assignee if any.
+func (s *Service) AssignCase(ctx context.Context, req request.AssignReviewCase) (reviewCase *entity.ReviewCase, err error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid review case assignment", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	reviewer, err := s.userService.HasPermission(ctx, req.AssigneeID, enum.PermissionReviewsManage)
+	if err != nil {
+		s.logger.Error("Failed to check the assignee", zap.Error(err), zap.Int("AssigneeID", req.AssigneeID))
+		return nil, err
+	}
+
+	if !reviewer {
+		return nil, derror.NewValidationError("cases can only be assigned to reviewers")
+	}
+
+	ctx, err = s.reviewCaseRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.reviewCaseRepo.RollbackTx(ctx)
+		}
+	}()
+
+	reviewCase, err = s.lockOpenCase(ctx, req.ReviewCaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewCase.AssigneeID = &req.AssigneeID
+	if err = s.reviewCaseRepo.Update(ctx, reviewCase); err != nil {
+		s.logger.Error("Failed to assign the review case", zap.Error(err), zap.Int("ReviewCaseID", reviewCase.ReviewCaseID))
+		return nil, err
+	}
+
+	if err = s.audit(ctx, reviewCase, &req.ReviewerID, enum.ReviewActionAssigned, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err = s.reviewCaseRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return reviewCase, nil
+}
+
+// ClaimCase assigns an unassigned case to the reviewer. Claiming one's own case is a no-op.
+func (s *Service) ClaimCase(ctx context.Context, req request.ReviewCaseAction) (reviewCase *entity.ReviewCase, err error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid review case claim", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.reviewCaseRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.reviewCaseRepo.RollbackTx(ctx)
+		}
+	}()
+
+	reviewCase, err = s.lockOpenCase(ctx, req.ReviewCaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewCase.IsAssignedTo(req.ReviewerID) {
+		err = s.reviewCaseRepo.CommitTx(ctx)
+		return reviewCase, err
+	}
+
+	if err = s.claim(ctx, reviewCase, req); err != nil {
+		return nil, err
+	}
+
+	if err = s.reviewCaseRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return reviewCase, nil
+}
+
+// ApproveCase posts the held credit from the suspense account to the receiver and
+// completes both legs of the transfer.
+func (s *Service) ApproveCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error) {
+	return s.resolve(ctx, req, enum.ReviewActionApproved)
+}
+
+// RejectCase posts the reverse of the held transfer's entry, which returns the debit to
+// the sender, and marks the debit reversed and the credit cancelled.
+func (s *Service) RejectCase(ctx context.Context, req request.ReviewCaseAction) (*entity.ReviewCase, error) {
+	return s.resolve(ctx, req, enum.ReviewActionRejected)
+}
+
+func (s *Service) resolve(ctx context.Context, req request.ReviewCaseAction, action enum.ReviewAction) (reviewCase *entity.ReviewCase, err error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid review decision", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.reviewCaseRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.reviewCaseRepo.RollbackTx(ctx)
+		}
+	}()
+
+	reviewCase, err = s.lockOpenCase(ctx, req.ReviewCaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deciding an unassigned case claims it for the reviewer first
+	if !reviewCase.IsAssignedTo(req.ReviewerID) {
+		if err = s.claim(ctx, reviewCase, request.ReviewCaseAction{ReviewerID: req.ReviewerID, ReviewCaseID: req.ReviewCaseID}); err != nil {
+			return nil, err
+		}
+	}
+
+	held, err := s.heldTransfer(ctx, reviewCase.TransactionGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *entity.JournalEntry
+	status := enum.ReviewApproved
+	if action == enum.ReviewActionApproved {
+		entry, err = s.release(ctx, held)
+	} else {
+		status = enum.ReviewRejected
+		entry, err = s.reverse(ctx, held)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reviewCase.Status = status
+	reviewCase.ResolutionEntryID = &entry.JournalEntryID
+	reviewCase.ResolvedAt = &now
+
+	if err = s.reviewCaseRepo.Update(ctx, reviewCase); err != nil {
+		s.logger.Error("Failed to resolve the review case", zap.Error(err), zap.Int("ReviewCaseID", reviewCase.ReviewCaseID))
+		return nil, err
+	}
+
+	if err = s.audit(ctx, reviewCase, &req.ReviewerID, action, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err = s.reviewCaseRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Resolved review case",
+		zap.Int("ReviewCaseID", reviewCase.ReviewCaseID),
+		zap.String("action", string(action)),
+		zap.Int("ReviewerID", req.ReviewerID))
+
+	return reviewCase, nil
+}
+
+// heldTransfer is a transfer waiting on review. An account transfer is recorded as account
+// transactions and a card to card transfer as card transactions; the other legs are nil.
+type heldTransfer struct {
+	groupID           int
+	receiverAccountID int
+	amount            entity.Money // Credited to the receiver
+	sender, receiver  *entity.AccountTransaction
+	cardSender        *entity.CardTransaction
+	cardReceiver      *entity.CardTransaction
+}
+
+// release credits the receiver from the suspense account holding the transfer.
+func (s *Service) release(ctx context.Context, held *heldTransfer) (*entity.JournalEntry, error) {
+	suspense, ok := s.cfg.SuspenseAccountFor(string(held.amount.Currency))
+	if !ok {
+		s.logger.Error("No suspense account is configured for the currency", zap.String("currency", string(held.amount.Currency)))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	description := fmt.Sprintf("Release of held transfer %d", held.groupID)
+	entry := &entity.JournalEntry{
+		Description: &description,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: suspense, Amount: held.amount.Neg()},
+			{FinancialAccountID: held.receiverAccountID, Amount: held.amount},
+		},
+	}
+
+	if err := s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the release entry", zap.Error(err), zap.Int("TransactionGroupID", held.groupID))
+		return nil, err
+	}
+
+	balance := entry.PostingFor(held.receiverAccountID).BalanceAfter
+	if held.cardSender != nil {
+		held.cardSender.Status = enum.Completedd
+		held.cardReceiver.Status = enum.Completedd
+		held.cardReceiver.Balance = balance
+		return entry, s.updateCardLegs(ctx, held.cardSender, held.cardReceiver)
+	}
+
+	held.sender.Status = enum.Completed
+	held.receiver.Status = enum.Completed
+	held.receiver.Balance = balance
+	return entry, s.updateLegs(ctx, held.sender, held.receiver)
+}
+
+// reverse posts the held entry with every posting negated, which also unwinds the
+// house-account legs of a cross-currency transfer.
+func (s *Service) reverse(ctx context.Context, held *heldTransfer) (*entity.JournalEntry, error) {
+	groupID := held.groupID
+	original, err := s.ledgerRepo.GetJournalEntry(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get the held journal entry", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return nil, err
+	}
+
+	if original == nil {
+		s.logger.Error("The held transfer has no journal entry", zap.Int("TransactionGroupID", groupID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	description := fmt.Sprintf("Reversal of rejected transfer %d", groupID)
+	entry := &entity.JournalEntry{Description: &description, ReversesEntryID: &groupID}
+	for _, p := range original.Postings {
+		entry.Postings = append(entry.Postings, &entity.LedgerPosting{FinancialAccountID: p.FinancialAccountID, Amount: p.Amount.Neg()})
+	}
+
+	if err := s.ledgerRepo.Post(ctx, entry); err != nil {
+		s.logger.Error("Failed to post the reversal entry", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return nil, err
+	}
+
+	if held.cardSender != nil {
+		held.cardSender.Status = enum.Reversedd
+		held.cardReceiver.Status = enum.Cancelledd
+		return entry, s.updateCardLegs(ctx, held.cardSender, held.cardReceiver)
+	}
+
+	held.sender.Status = enum.Reversed
+	held.receiver.Status = enum.Cancelled
+	return entry, s.updateLegs(ctx, held.sender, held.receiver)
+}
+
+// heldTransfer returns the debit and the credit of a held transfer, looking for card
+// transactions when the group has no account transactions.
+func (s *Service) heldTransfer(ctx context.Context, groupID int) (*heldTransfer, error) {
+	transactions, err := s.accountTransactionRepo.ListByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list the held transactions", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return s.heldCardTransfer(ctx, groupID)
+	}
+
+	held := &heldTransfer{groupID: groupID}
+	for _, tx := range transactions {
+		if tx.Amount.IsNegative() {
+			held.sender = tx
+		} else {
+			held.receiver = tx
+		}
+	}
+
+	if held.sender == nil || held.receiver == nil || !held.sender.IsHeld() || !held.receiver.IsHeld() {
+		return nil, derror.NewConflictError("transaction group %d is not held", groupID)
+	}
+
+	held.receiverAccountID = held.receiver.FinancialAccountID
+	held.amount = held.receiver.Amount
+	return held, nil
+}
+
+func (s *Service) heldCardTransfer(ctx context.Context, groupID int) (*heldTransfer, error) {
+	transactions, err := s.cardTransactionRepo.ListByTransactionGroupID(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list the held card transactions", zap.Error(err), zap.Int("TransactionGroupID", groupID))
+		return nil, err
+	}
+
+	held := &heldTransfer{groupID: groupID}
+	for _, tx := range transactions {
+		if tx.Amount.IsNegative() {
+			held.cardSender = tx
+		} else {
+			held.cardReceiver = tx
+		}
+	}
+
+	if held.cardSender == nil || held.cardReceiver == nil || held.cardSender.Status != enum.OnHoldd || held.cardReceiver.Status != enum.OnHoldd {
+		return nil, derror.NewConflictError("transaction group %d is not held", groupID)
+	}
+
+	// The held entry credited the suspense account, so the receiver is found through its card
+	card, err := s.financialCardRepo.GetByID(ctx, int64(held.cardReceiver.FinancialCardID))
+	if err != nil {
+		s.logger.Error("Failed to get the receiver card", zap.Error(err), zap.Int("CardID", held.cardReceiver.FinancialCardID))
+		return nil, err
+	}
+
+	if card == nil {
+		s.logger.Error("The held transfer's receiver card is missing", zap.Int("CardID", held.cardReceiver.FinancialCardID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	held.receiverAccountID = card.AccountID
+	held.amount = held.cardReceiver.Amount
+	return held, nil
+}
+
+func (s *Service) updateLegs(ctx context.Context, legs ...*entity.AccountTransaction) error {
+	for _, leg := range legs {
+		if err := s.accountTransactionRepo.Update(ctx, leg); err != nil {
+			s.logger.Error("Failed to update the held transaction", zap.Error(err), zap.Int("TransactionID", leg.TransactionID))
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) updateCardLegs(ctx context.Context, legs ...*entity.CardTransaction) error {
+	for _, leg := range legs {
+		if err := s.cardTransactionRepo.Update(ctx, leg); err != nil {
+			s.logger.Error("Failed to update the held card transaction", zap.Error(err), zap.Int64("TransactionID", leg.TransactionID))
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) lockOpenCase(ctx context.Context, caseID int) (*entity.ReviewCase, error) {
+	reviewCase, err := s.reviewCaseRepo.GetForUpdate(ctx, caseID)
+	if err != nil {
+		s.logger.Error("Failed to get the review case", zap.Error(err), zap.Int("ReviewCaseID", caseID))
+		return nil, err
+	}
+
+	if reviewCase == nil {
+		return nil, derror.NewNotFoundError("review case not found")
+	}
+
+	if reviewCase.Status != enum.ReviewOpen {
+		return nil, derror.NewConflictError("review case %d is already resolved", caseID)
+	}
+
+	return reviewCase, nil
+}
+
+// claim assigns the case to the reviewer unless another reviewer already has it.
+func (s *Service) claim(ctx context.Context, reviewCase *entity.ReviewCase, req request.ReviewCaseAction) error {
+	if reviewCase.AssigneeID != nil {
+		return derror.NewConflictError("review case %d is assigned to another reviewer", reviewCase.ReviewCaseID)
+	}
+
+	reviewCase.AssigneeID = &req.ReviewerID
+	if err := s.reviewCaseRepo.Update(ctx, reviewCase); err != nil {
+		s.logger.Error("Failed to claim the review case", zap.Error(err), zap.Int("ReviewCaseID", reviewCase.ReviewCaseID))
+		return err
+	}
+
+	return s.audit(ctx, reviewCase, &req.ReviewerID, enum.ReviewActionClaimed, req.Note)
+}
+
+func (s *Service) audit(ctx context.Context, reviewCase *entity.ReviewCase, actorID *int, action enum.ReviewAction, note *string) error {
+	event := &entity.ReviewEvent{
+		ReviewCaseID: reviewCase.ReviewCaseID,
+		ActorID:      actorID,
+		Action:       action,
+		AssigneeID:   reviewCase.AssigneeID,
+		Note:         note,
+	}
+
+	if err := s.reviewCaseRepo.InsertEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to record the review event", zap.Error(err), zap.Int("ReviewCaseID", reviewCase.ReviewCaseID))
+		return err
+	}
+
+	return nil
+}
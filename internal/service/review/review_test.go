@@ -0,0 +1,269 @@
+package review
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type txCtxKey struct{}
+
+const (
+	usdSuspense = 903
+	reviewerID  = 11
+)
+
+type mocks struct {
+	caseRepo        *protocol.MockReviewCaseRepo
+	transactionRepo *protocol.MockAccountTransactionRepo
+	ledger          *protocol.MockLedgerRepo
+	users           *protocol.MockUserRepository
+	cardTxRepo      *protocol.MockCardTransactionRepo
+	cardRepo        *protocol.MockFinancialCardRepo
+}
+
+func setup() (*Service, mocks) {
+	m := mocks{
+		caseRepo:        new(protocol.MockReviewCaseRepo),
+		transactionRepo: new(protocol.MockAccountTransactionRepo),
+		ledger:          new(protocol.MockLedgerRepo),
+		users:           new(protocol.MockUserRepository),
+		cardTxRepo:      new(protocol.MockCardTransactionRepo),
+		cardRepo:        new(protocol.MockFinancialCardRepo),
+	}
+	logger, _ := zap.NewProduction()
+	cfg := config.Risk{SuspenseAccounts: []config.SuspenseAccount{{Currency: "USD", AccountID: usdSuspense}}}
+
+	return New(cfg, logger.Sugar(), m.caseRepo, m.transactionRepo, m.ledger, m.users, m.cardTxRepo, m.cardRepo), m
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+func openCase() *entity.ReviewCase {
+	return &entity.ReviewCase{ReviewCaseID: 4, TransactionGroupID: 7, RiskAssessmentID: 2, Status: enum.ReviewOpen}
+}
+
+func heldLegs() []*entity.AccountTransaction {
+	return []*entity.AccountTransaction{
+		{TransactionID: 1, TransactionGroupID: 7, FinancialAccountID: 1, Amount: usd(-1000), Balance: usd(9000), Status: enum.PendingReview},
+		{TransactionID: 2, TransactionGroupID: 7, FinancialAccountID: 2, Amount: usd(1000), Balance: usd(500), Status: enum.PendingReview},
+	}
+}
+
+// expectTx wires a unit of work that must either commit or roll back.
+func expectTx(m mocks) context.Context {
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, txCtxKey{}, "tx")
+	m.caseRepo.On("BeginTx", ctx).Return(txCtx, nil)
+	m.caseRepo.On("CommitTx", txCtx).Return(nil)
+	m.caseRepo.On("RollbackTx", txCtx).Return(nil)
+	return txCtx
+}
+
+func TestApproveCase(t *testing.T) {
+	service, m := setup()
+	txCtx := expectTx(m)
+
+	legs := heldLegs()
+	var posted *entity.JournalEntry
+	var events []enum.ReviewAction
+
+	m.caseRepo.On("GetForUpdate", txCtx, 4).Return(openCase(), nil)
+	m.caseRepo.On("Update", txCtx, mock.AnythingOfType("*entity.ReviewCase")).Return(nil)
+	m.caseRepo.On("InsertEvent", txCtx, mock.AnythingOfType("*entity.ReviewEvent")).Run(func(args mock.Arguments) {
+		events = append(events, args.Get(1).(*entity.ReviewEvent).Action)
+	}).Return(nil)
+	m.transactionRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+	m.transactionRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	m.ledger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		posted = args.Get(1).(*entity.JournalEntry)
+		posted.JournalEntryID = 8
+		posted.PostingFor(2).BalanceAfter = usd(1500)
+	}).Return(nil)
+
+	reviewCase, err := service.ApproveCase(context.Background(), request.ReviewCaseAction{ReviewerID: reviewerID, ReviewCaseID: 4})
+	require.NoError(t, err)
+
+	assert.True(t, posted.IsBalanced())
+	assert.Equal(t, usd(-1000), posted.PostingFor(usdSuspense).Amount)
+	assert.Equal(t, usd(1000), posted.PostingFor(2).Amount)
+
+	assert.Equal(t, enum.Completed, legs[0].Status)
+	assert.Equal(t, enum.Completed, legs[1].Status)
+	assert.Equal(t, usd(1500), legs[1].Balance)
+
+	assert.Equal(t, enum.ReviewApproved, reviewCase.Status)
+	assert.Equal(t, 8, *reviewCase.ResolutionEntryID)
+	assert.True(t, reviewCase.IsAssignedTo(reviewerID))
+	assert.NotNil(t, reviewCase.ResolvedAt)
+	assert.Equal(t, []enum.ReviewAction{enum.ReviewActionClaimed, enum.ReviewActionApproved}, events)
+	m.caseRepo.AssertNotCalled(t, "RollbackTx", mock.Anything)
+}
+
+func TestResolveCardTransfer(t *testing.T) {
+	heldCardLegs := func() []*entity.CardTransaction {
+		return []*entity.CardTransaction{
+			{TransactionID: 1, TransactionGroupID: 7, FinancialCardID: 10, Amount: usd(-1000), Balance: usd(9000), Status: enum.OnHoldd},
+			{TransactionID: 2, TransactionGroupID: 7, FinancialCardID: 11, Amount: usd(1000), Balance: usd(500), Status: enum.OnHoldd},
+		}
+	}
+
+	prepare := func() (*Service, mocks, context.Context, []*entity.CardTransaction, **entity.JournalEntry) {
+		service, m := setup()
+		txCtx := expectTx(m)
+		legs := heldCardLegs()
+		posted := new(*entity.JournalEntry)
+
+		m.caseRepo.On("GetForUpdate", txCtx, 4).Return(openCase(), nil)
+		m.caseRepo.On("Update", txCtx, mock.AnythingOfType("*entity.ReviewCase")).Return(nil)
+		m.caseRepo.On("InsertEvent", txCtx, mock.AnythingOfType("*entity.ReviewEvent")).Return(nil)
+		m.transactionRepo.On("ListByTransactionGroupID", txCtx, 7).Return([]*entity.AccountTransaction{}, nil)
+		m.cardTxRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+		m.cardTxRepo.On("Update", txCtx, mock.AnythingOfType("*entity.CardTransaction")).Return(nil)
+		m.cardRepo.On("GetByID", txCtx, int64(11)).Return(&entity.FinancialCard{CardID: 11, AccountID: 2}, nil)
+		m.ledger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+			*posted = args.Get(1).(*entity.JournalEntry)
+			(*posted).JournalEntryID = 8
+			if p := (*posted).PostingFor(2); p != nil {
+				p.BalanceAfter = usd(1500)
+			}
+		}).Return(nil)
+
+		return service, m, txCtx, legs, posted
+	}
+
+	t.Run("approval credits the receiver card's account", func(t *testing.T) {
+		service, m, _, legs, posted := prepare()
+
+		_, err := service.ApproveCase(context.Background(), request.ReviewCaseAction{ReviewerID: reviewerID, ReviewCaseID: 4})
+		require.NoError(t, err)
+
+		assert.Equal(t, usd(-1000), (*posted).PostingFor(usdSuspense).Amount)
+		assert.Equal(t, usd(1000), (*posted).PostingFor(2).Amount)
+		assert.Equal(t, enum.Completedd, legs[0].Status)
+		assert.Equal(t, enum.Completedd, legs[1].Status)
+		assert.Equal(t, usd(1500), legs[1].Balance)
+		m.transactionRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejection reverses the entry", func(t *testing.T) {
+		service, m, txCtx, legs, posted := prepare()
+		m.ledger.On("GetJournalEntry", txCtx, 7).Return(&entity.JournalEntry{
+			JournalEntryID: 7,
+			Postings: []*entity.LedgerPosting{
+				{FinancialAccountID: 1, Amount: usd(-1000)},
+				{FinancialAccountID: usdSuspense, Amount: usd(1000)},
+			},
+		}, nil)
+
+		_, err := service.RejectCase(context.Background(), request.ReviewCaseAction{ReviewerID: reviewerID, ReviewCaseID: 4})
+		require.NoError(t, err)
+
+		assert.Equal(t, usd(1000), (*posted).PostingFor(1).Amount)
+		assert.Equal(t, enum.Reversedd, legs[0].Status)
+		assert.Equal(t, enum.Cancelledd, legs[1].Status)
+	})
+}
+
+func TestRejectCaseReversesTheEntry(t *testing.T) {
+	service, m := setup()
+	txCtx := expectTx(m)
+
+	legs := heldLegs()
+	reviewCase := openCase()
+	reviewCase.AssigneeID = new(int)
+	*reviewCase.AssigneeID = reviewerID
+
+	held := &entity.JournalEntry{
+		JournalEntryID: 7,
+		Postings: []*entity.LedgerPosting{
+			{FinancialAccountID: 1, Amount: usd(-1000)},
+			{FinancialAccountID: usdSuspense, Amount: usd(1000)},
+		},
+	}
+
+	var posted *entity.JournalEntry
+	m.caseRepo.On("GetForUpdate", txCtx, 4).Return(reviewCase, nil)
+	m.caseRepo.On("Update", txCtx, reviewCase).Return(nil)
+	m.caseRepo.On("InsertEvent", txCtx, mock.MatchedBy(func(e *entity.ReviewEvent) bool {
+		return e.Action == enum.ReviewActionRejected && *e.ActorID == reviewerID && *e.Note == "mule account"
+	})).Return(nil)
+	m.transactionRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+	m.transactionRepo.On("Update", txCtx, mock.AnythingOfType("*entity.AccountTransaction")).Return(nil)
+	m.ledger.On("GetJournalEntry", txCtx, 7).Return(held, nil)
+	m.ledger.On("Post", txCtx, mock.AnythingOfType("*entity.JournalEntry")).Run(func(args mock.Arguments) {
+		posted = args.Get(1).(*entity.JournalEntry)
+		posted.JournalEntryID = 9
+	}).Return(nil)
+
+	note := "mule account"
+	resolved, err := service.RejectCase(context.Background(), request.ReviewCaseAction{ReviewerID: reviewerID, ReviewCaseID: 4, Note: &note})
+	require.NoError(t, err)
+
+	assert.True(t, posted.IsBalanced())
+	assert.Equal(t, usd(1000), posted.PostingFor(1).Amount)
+	assert.Equal(t, usd(-1000), posted.PostingFor(usdSuspense).Amount)
+	assert.Equal(t, enum.Reversed, legs[0].Status)
+	assert.Equal(t, enum.Cancelled, legs[1].Status)
+	assert.Equal(t, enum.ReviewRejected, resolved.Status)
+	assert.Equal(t, 9, *resolved.ResolutionEntryID)
+}
+
+func TestResolveRejects(t *testing.T) {
+	other := 12
+
+	tests := []struct {
+		name   string
+		setup  func(c *entity.ReviewCase, legs []*entity.AccountTransaction)
+		status int
+	}{
+		{name: "resolved case", setup: func(c *entity.ReviewCase, _ []*entity.AccountTransaction) { c.Status = enum.ReviewApproved }, status: http.StatusConflict},
+		{name: "another reviewer's case", setup: func(c *entity.ReviewCase, _ []*entity.AccountTransaction) { c.AssigneeID = &other }, status: http.StatusConflict},
+		{name: "transfer not held", setup: func(_ *entity.ReviewCase, legs []*entity.AccountTransaction) { legs[0].Status = enum.Completed }, status: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+			txCtx := expectTx(m)
+
+			reviewCase, legs := openCase(), heldLegs()
+			tt.setup(reviewCase, legs)
+
+			m.caseRepo.On("GetForUpdate", txCtx, 4).Return(reviewCase, nil)
+			m.caseRepo.On("Update", txCtx, mock.Anything).Return(nil)
+			m.caseRepo.On("InsertEvent", txCtx, mock.Anything).Return(nil)
+			m.transactionRepo.On("ListByTransactionGroupID", txCtx, 7).Return(legs, nil)
+
+			_, err := service.ApproveCase(context.Background(), request.ReviewCaseAction{ReviewerID: reviewerID, ReviewCaseID: 4})
+			assert.True(t, derror.IsHTTPError(err, tt.status))
+			m.ledger.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			m.caseRepo.AssertCalled(t, "RollbackTx", txCtx)
+			m.caseRepo.AssertNotCalled(t, "CommitTx", mock.Anything)
+		})
+	}
+}
+
+func TestAssignCaseRequiresReviewer(t *testing.T) {
+	service, m := setup()
+	ctx := context.Background()
+
+	m.users.On("HasPermission", ctx, 12, enum.PermissionReviewsManage).Return(false, nil)
+
+	_, err := service.AssignCase(ctx, request.AssignReviewCase{ReviewerID: reviewerID, ReviewCaseID: 4, AssigneeID: 12})
+	assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity))
+	m.caseRepo.AssertNotCalled(t, "BeginTx", mock.Anything)
+}
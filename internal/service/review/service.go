@@ -0,0 +1,40 @@
+package review
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                    config.Risk
+	logger                 *zap.SugaredLogger
+	reviewCaseRepo         protocol.ReviewCaseRepository
+	accountTransactionRepo protocol.AccountTransactionRepository
+	ledgerRepo             protocol.LedgerRepository
+	userService            protocol.User
+	cardTransactionRepo    protocol.CardTransactionRepository
+	financialCardRepo      protocol.FinancialCardRepository
+}
+
+func New(
+	cfg config.Risk,
+	logger *zap.SugaredLogger,
+	reviewCaseRepo protocol.ReviewCaseRepository,
+	accountTransactionRepo protocol.AccountTransactionRepository,
+	ledgerRepo protocol.LedgerRepository,
+	userService protocol.User,
+	cardTransactionRepo protocol.CardTransactionRepository,
+	financialCardRepo protocol.FinancialCardRepository,
+) *Service {
+	return &Service{
+		cfg:                    cfg,
+		logger:                 logger,
+		reviewCaseRepo:         reviewCaseRepo,
+		accountTransactionRepo: accountTransactionRepo,
+		ledgerRepo:             ledgerRepo,
+		userService:            userService,
+		cardTransactionRepo:    cardTransactionRepo,
+		financialCardRepo:      financialCardRepo,
+	}
+}
@@ -0,0 +1,212 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+)
+
+const (
+	CheckLargeAmount    = "large_amount"
+	CheckUnusualHours   = "unusual_hours"
+	CheckVelocity       = "velocity"
+	CheckFirstTimePayee = "first_time_payee"
+	CheckAmountSpike    = "amount_spike"
+)
+
+// NewChecks builds the built-in checks listed in the config, in the same order.
+func NewChecks(cfg config.Risk, accountTransactionRepo protocol.AccountTransactionRepository) ([]protocol.RiskCheck, error) {
+	checks := make([]protocol.RiskCheck, 0, len(cfg.Checks))
+
+	for _, c := range cfg.Checks {
+		if c.Score <= 0 {
+			return nil, fmt.Errorf("risk check %q: score must be positive", c.Name)
+		}
+
+		var check protocol.RiskCheck
+		switch c.Name {
+		case CheckLargeAmount:
+			thresholds := make(map[enum.CurrencyCode]entity.Money, len(c.Thresholds))
+			for _, t := range c.Thresholds {
+				code := enum.CurrencyCode(t.Currency)
+				if !code.IsValid() || t.Amount <= 0 {
+					return nil, fmt.Errorf("risk check %q: invalid threshold %d %s", c.Name, t.Amount, t.Currency)
+				}
+				thresholds[code] = entity.NewMoney(t.Amount, code)
+			}
+			check = &largeAmount{score: c.Score, thresholds: thresholds}
+
+		case CheckUnusualHours:
+			if c.StartHour < 0 || c.StartHour > 23 || c.EndHour < 0 || c.EndHour > 23 || c.StartHour == c.EndHour {
+				return nil, fmt.Errorf("risk check %q: invalid hours %d-%d", c.Name, c.StartHour, c.EndHour)
+			}
+			location, err := time.LoadLocation(c.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("risk check %q: %w", c.Name, err)
+			}
+			check = &unusualHours{score: c.Score, start: c.StartHour, end: c.EndHour, location: location}
+
+		case CheckVelocity:
+			if c.Count <= 0 || c.Window <= 0 {
+				return nil, fmt.Errorf("risk check %q: count and window must be positive", c.Name)
+			}
+			check = &velocity{score: c.Score, count: c.Count, window: c.Window, repo: accountTransactionRepo}
+
+		case CheckFirstTimePayee:
+			check = &firstTimePayee{score: c.Score, repo: accountTransactionRepo}
+
+		case CheckAmountSpike:
+			if c.Multiplier <= 1 || c.Window <= 0 {
+				return nil, fmt.Errorf("risk check %q: multiplier must be above 1 and window positive", c.Name)
+			}
+			check = &amountSpike{score: c.Score, multiplier: c.Multiplier, window: c.Window, repo: accountTransactionRepo}
+
+		default:
+			return nil, fmt.Errorf("unknown risk check %q", c.Name)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// largeAmount scores transfers above the threshold of their currency.
+type largeAmount struct {
+	score      int
+	thresholds map[enum.CurrencyCode]entity.Money
+}
+
+func (c *largeAmount) Name() string { return CheckLargeAmount }
+
+func (c *largeAmount) Check(_ context.Context, req request.AssessRisk) (*entity.RiskReason, error) {
+	threshold, ok := c.thresholds[req.Amount.Currency]
+	if !ok || req.Amount.Amount <= threshold.Amount {
+		return nil, nil
+	}
+
+	return &entity.RiskReason{
+		Check:   c.Name(),
+		Score:   c.score,
+		Message: fmt.Sprintf("amount %s is above %s", display(req.Amount), display(threshold)),
+	}, nil
+}
+
+// unusualHours scores transfers made between start and end o'clock, wrapping past midnight
+// when start is later than end.
+type unusualHours struct {
+	score      int
+	start, end int
+	location   *time.Location
+}
+
+func (c *unusualHours) Name() string { return CheckUnusualHours }
+
+func (c *unusualHours) Check(_ context.Context, req request.AssessRisk) (*entity.RiskReason, error) {
+	hour := req.At.In(c.location).Hour()
+
+	inside := hour >= c.start && hour < c.end
+	if c.start > c.end {
+		inside = hour >= c.start || hour < c.end
+	}
+
+	if !inside {
+		return nil, nil
+	}
+
+	return &entity.RiskReason{
+		Check:   c.Name(),
+		Score:   c.score,
+		Message: fmt.Sprintf("made at %02d:00-%02d:00 %s", c.start, c.end, c.location),
+	}, nil
+}
+
+// velocity scores senders that already made count debits within the window.
+type velocity struct {
+	score  int
+	count  int
+	window time.Duration
+	repo   protocol.AccountTransactionRepository
+}
+
+func (c *velocity) Name() string { return CheckVelocity }
+
+func (c *velocity) Check(ctx context.Context, req request.AssessRisk) (*entity.RiskReason, error) {
+	count, err := c.repo.CountDebitsSince(ctx, req.SenderAccountID, req.At.Add(-c.window))
+	if err != nil {
+		return nil, err
+	}
+
+	if count < c.count {
+		return nil, nil
+	}
+
+	return &entity.RiskReason{
+		Check:   c.Name(),
+		Score:   c.score,
+		Message: fmt.Sprintf("%d debits in the last %s", count, c.window),
+	}, nil
+}
+
+// firstTimePayee scores transfers to an account the sender never paid before.
+type firstTimePayee struct {
+	score int
+	repo  protocol.AccountTransactionRepository
+}
+
+func (c *firstTimePayee) Name() string { return CheckFirstTimePayee }
+
+func (c *firstTimePayee) Check(ctx context.Context, req request.AssessRisk) (*entity.RiskReason, error) {
+	paid, err := c.repo.HasPaid(ctx, req.SenderAccountID, req.ReceiverAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if paid {
+		return nil, nil
+	}
+
+	return &entity.RiskReason{
+		Check:   c.Name(),
+		Score:   c.score,
+		Message: fmt.Sprintf("first transfer to account %d", req.ReceiverAccountID),
+	}, nil
+}
+
+// amountSpike scores transfers over multiplier times the sender's average debit in the
+// window. Senders without debits in the window are left to the other checks.
+type amountSpike struct {
+	score      int
+	multiplier int64
+	window     time.Duration
+	repo       protocol.AccountTransactionRepository
+}
+
+func (c *amountSpike) Name() string { return CheckAmountSpike }
+
+func (c *amountSpike) Check(ctx context.Context, req request.AssessRisk) (*entity.RiskReason, error) {
+	average, err := c.repo.AverageDebitSince(ctx, req.SenderAccountID, req.At.Add(-c.window))
+	if err != nil {
+		return nil, err
+	}
+
+	if average <= 0 || req.Amount.Amount <= average*c.multiplier {
+		return nil, nil
+	}
+
+	return &entity.RiskReason{
+		Check:   c.Name(),
+		Score:   c.score,
+		Message: fmt.Sprintf("amount is over %d times the average debit of %s", c.multiplier, display(entity.NewMoney(average, req.Amount.Currency))),
+	}, nil
+}
+
+func display(m entity.Money) string {
+	return m.String() + " " + string(m.Currency)
+}
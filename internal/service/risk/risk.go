@@ -0,0 +1,71 @@
+package risk
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"go.uber.org/zap"
+)
+
+// Assess runs the checks in their configured order. A failing check fails the
+// assessment rather than letting the transfer through unscored.
+func (s *Service) Assess(ctx context.Context, req request.AssessRisk) (*entity.RiskAssessment, error) {
+	if req.At.IsZero() {
+		req.At = time.Now()
+	}
+
+	assessment := &entity.RiskAssessment{FinancialAccountID: req.SenderAccountID}
+
+	for _, check := range s.checks {
+		reason, err := check.Check(ctx, req)
+		if err != nil {
+			s.logger.Error("Risk check failed", zap.String("check", check.Name()), zap.Error(err))
+			return nil, err
+		}
+
+		if reason != nil {
+			assessment.Score += reason.Score
+			assessment.Reasons = append(assessment.Reasons, *reason)
+		}
+	}
+
+	assessment.Decision = s.decide(assessment.Score)
+
+	if assessment.IsHeld() {
+		s.logger.Warn("Transfer held by the risk checks",
+			zap.Int("SenderAccountID", req.SenderAccountID),
+			zap.Int("ReceiverAccountID", req.ReceiverAccountID),
+			zap.Int("score", assessment.Score),
+			zap.Any("reasons", assessment.Reasons))
+	}
+
+	return assessment, nil
+}
+
+// Record stores the assessment and queues a held transfer for manual review.
+func (s *Service) Record(ctx context.Context, assessment *entity.RiskAssessment) error {
+	if err := s.riskAssessmentRepo.Insert(ctx, assessment); err != nil {
+		s.logger.Error("Failed to record the risk assessment", zap.Error(err), zap.Int("TransactionGroupID", assessment.TransactionGroupID))
+		return err
+	}
+
+	if assessment.IsHeld() {
+		return s.reviewService.OpenCase(ctx, assessment)
+	}
+
+	return nil
+}
+
+func (s *Service) decide(score int) enum.AccountTransactionStatus {
+	switch {
+	case s.cfg.HoldScore > 0 && score >= s.cfg.HoldScore:
+		return enum.OnHold
+	case s.cfg.ReviewScore > 0 && score >= s.cfg.ReviewScore:
+		return enum.PendingReview
+	default:
+		return enum.Completed
+	}
+}
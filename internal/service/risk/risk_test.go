@@ -0,0 +1,178 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var riskConfig = config.Risk{
+	ReviewScore: 40,
+	HoldScore:   70,
+	Checks: []config.RiskCheck{
+		{Name: CheckLargeAmount, Score: 40, Thresholds: []config.RiskThreshold{{Currency: "USD", Amount: 100000}}},
+		{Name: CheckUnusualHours, Score: 15, StartHour: 23, EndHour: 6, Timezone: "UTC"},
+		{Name: CheckVelocity, Score: 30, Count: 5, Window: time.Hour},
+		{Name: CheckFirstTimePayee, Score: 10},
+		{Name: CheckAmountSpike, Score: 25, Multiplier: 5, Window: 30 * 24 * time.Hour},
+	},
+}
+
+func setup(t *testing.T) (*Service, *protocol.MockAccountTransactionRepo, *protocol.MockRiskAssessmentRepo, *protocol.MockReviewService) {
+	mockTransactionRepo := new(protocol.MockAccountTransactionRepo)
+	mockAssessmentRepo := new(protocol.MockRiskAssessmentRepo)
+	mockReview := new(protocol.MockReviewService)
+	logger, _ := zap.NewProduction()
+
+	checks, err := NewChecks(riskConfig, mockTransactionRepo)
+	require.NoError(t, err)
+
+	return New(riskConfig, logger.Sugar(), mockAssessmentRepo, mockReview, checks), mockTransactionRepo, mockAssessmentRepo, mockReview
+}
+
+func usd(cents int64) entity.Money {
+	return entity.NewMoney(cents, enum.USD)
+}
+
+func checksOf(reasons []entity.RiskReason) []string {
+	var out []string
+	for _, r := range reasons {
+		out = append(out, r.Check)
+	}
+	return out
+}
+
+func TestAssess(t *testing.T) {
+	ctx := context.Background()
+	noon := time.Date(2023, 10, 2, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2023, 10, 2, 2, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		amount   entity.Money
+		at       time.Time
+		debits   int
+		paid     bool
+		average  int64
+		score    int
+		decision enum.AccountTransactionStatus
+		checks   []string
+	}{
+		{
+			name:     "usual transfer completes",
+			amount:   usd(5000),
+			at:       noon,
+			debits:   1,
+			paid:     true,
+			average:  4000,
+			decision: enum.Completed,
+		},
+		{
+			name:     "first payee alone stays below review",
+			amount:   usd(5000),
+			at:       noon,
+			average:  4000,
+			score:    10,
+			decision: enum.Completed,
+			checks:   []string{CheckFirstTimePayee},
+		},
+		{
+			name:     "large amount goes to review",
+			amount:   usd(150000),
+			at:       noon,
+			paid:     true,
+			average:  40000,
+			score:    40,
+			decision: enum.PendingReview,
+			checks:   []string{CheckLargeAmount},
+		},
+		{
+			name:     "burst at night to a new payee is held",
+			amount:   usd(50000),
+			at:       night,
+			debits:   6,
+			average:  4000,
+			score:    80,
+			decision: enum.OnHold,
+			checks:   []string{CheckUnusualHours, CheckVelocity, CheckFirstTimePayee, CheckAmountSpike},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockTransactionRepo, _, _ := setup(t)
+			mockTransactionRepo.On("CountDebitsSince", ctx, 1, tt.at.Add(-time.Hour)).Return(tt.debits, nil)
+			mockTransactionRepo.On("HasPaid", ctx, 1, 2).Return(tt.paid, nil)
+			mockTransactionRepo.On("AverageDebitSince", ctx, 1, tt.at.Add(-30*24*time.Hour)).Return(tt.average, nil)
+
+			assessment, err := service.Assess(ctx, request.AssessRisk{SenderAccountID: 1, ReceiverAccountID: 2, Amount: tt.amount, At: tt.at})
+			require.NoError(t, err)
+			assert.Equal(t, tt.score, assessment.Score)
+			assert.Equal(t, tt.decision, assessment.Decision)
+			assert.Equal(t, tt.checks, checksOf(assessment.Reasons))
+			assert.Equal(t, 1, assessment.FinancialAccountID)
+		})
+	}
+}
+
+func TestAssessFailsWithItsCheck(t *testing.T) {
+	service, mockTransactionRepo, _, _ := setup(t)
+	ctx := context.Background()
+	errDB := errors.New("connection reset")
+
+	mockTransactionRepo.On("CountDebitsSince", ctx, 1, mock.Anything).Return(0, errDB)
+
+	_, err := service.Assess(ctx, request.AssessRisk{SenderAccountID: 1, ReceiverAccountID: 2, Amount: usd(100)})
+	assert.ErrorIs(t, err, errDB)
+	mockTransactionRepo.AssertNotCalled(t, "HasPaid", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecordOpensCaseForHeldTransfers(t *testing.T) {
+	ctx := context.Background()
+
+	for _, decision := range []enum.AccountTransactionStatus{enum.Completed, enum.PendingReview, enum.OnHold} {
+		service, _, mockAssessmentRepo, mockReview := setup(t)
+		assessment := &entity.RiskAssessment{TransactionGroupID: 7, Decision: decision}
+		mockAssessmentRepo.On("Insert", ctx, assessment).Return(nil)
+		mockReview.On("OpenCase", ctx, assessment).Return(nil)
+
+		require.NoError(t, service.Record(ctx, assessment))
+		if decision == enum.Completed {
+			mockReview.AssertNotCalled(t, "OpenCase", mock.Anything, mock.Anything)
+		} else {
+			mockReview.AssertCalled(t, "OpenCase", ctx, assessment)
+		}
+	}
+}
+
+func TestNewChecksRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		check config.RiskCheck
+	}{
+		{name: "unknown check", check: config.RiskCheck{Name: "weather", Score: 10}},
+		{name: "no score", check: config.RiskCheck{Name: CheckFirstTimePayee}},
+		{name: "unknown currency", check: config.RiskCheck{Name: CheckLargeAmount, Score: 10, Thresholds: []config.RiskThreshold{{Currency: "XXX", Amount: 1}}}},
+		{name: "empty hour range", check: config.RiskCheck{Name: CheckUnusualHours, Score: 10, StartHour: 3, EndHour: 3}},
+		{name: "velocity without window", check: config.RiskCheck{Name: CheckVelocity, Score: 10, Count: 3}},
+		{name: "spike multiplier of one", check: config.RiskCheck{Name: CheckAmountSpike, Score: 10, Multiplier: 1, Window: time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewChecks(config.Risk{Checks: []config.RiskCheck{tt.check}}, nil)
+			assert.Error(t, err)
+		})
+	}
+}
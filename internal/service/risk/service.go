@@ -0,0 +1,31 @@
+package risk
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                config.Risk
+	logger             *zap.SugaredLogger
+	riskAssessmentRepo protocol.RiskAssessmentRepository
+	reviewService      protocol.Review
+	checks             []protocol.RiskCheck
+}
+
+func New(
+	cfg config.Risk,
+	logger *zap.SugaredLogger,
+	riskAssessmentRepo protocol.RiskAssessmentRepository,
+	reviewService protocol.Review,
+	checks []protocol.RiskCheck,
+) *Service {
+	return &Service{
+		cfg:                cfg,
+		logger:             logger,
+		riskAssessmentRepo: riskAssessmentRepo,
+		reviewService:      reviewService,
+		checks:             checks,
+	}
+}
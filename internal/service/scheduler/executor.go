@@ -0,0 +1,271 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeScheduledTransfer = "scheduler.transfer"
+
+	defaultPollInterval = 30 * time.Second
+	defaultBatchSize    = 50
+	defaultLease        = 5 * time.Minute
+	defaultMaxAttempts  = 3
+	defaultRetryBackoff = 10 * time.Minute
+)
+
+func (s *Service) Run(ctx context.Context) error {
+	s.logger.Info("Scheduled transfer executor started", zap.Duration("pollInterval", s.pollInterval()))
+	defer s.logger.Info("Scheduled transfer executor stopped")
+
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		// A batch is not interrupted halfway: ctx only stops the loop between batches
+		if _, err := s.RunDue(context.Background(), time.Now()); err != nil {
+			s.logger.Error("Failed to run the due scheduled transfers", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	transfers, err := s.scheduledTransferRepo.ClaimDue(ctx, now, now.Add(s.lease()), s.batchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, transfer := range transfers {
+		if ctx.Err() != nil {
+			// The leases run out and another executor picks the rest up
+			return 0, ctx.Err()
+		}
+
+		if err := s.execute(ctx, transfer); err != nil {
+			s.logger.Error("Failed to record the run of the scheduled transfer", zap.Error(err),
+				zap.Int("ScheduledTransferID", transfer.ScheduledTransferID))
+		}
+	}
+
+	return len(transfers), nil
+}
+
+// execute runs the pending occurrence of a claimed order and records the outcome.
+func (s *Service) execute(ctx context.Context, claimed *entity.ScheduledTransfer) error {
+	// The owner may have paused or cancelled the order since it was claimed
+	current, err := s.scheduledTransferRepo.Get(ctx, claimed.ScheduledTransferID)
+	if err != nil {
+		return err
+	}
+
+	if current == nil || current.Status != enum.ScheduledActive || current.NextRunAt == nil {
+		s.logger.Info("Skipping a scheduled transfer that is no longer active", zap.Int("ScheduledTransferID", claimed.ScheduledTransferID))
+		return nil
+	}
+
+	run := &entity.ScheduledTransferRun{
+		ScheduledTransferID: current.ScheduledTransferID,
+		OccurrenceAt:        *current.NextRunAt,
+		Attempt:             current.Attempts + 1,
+		StartedAt:           time.Now(),
+	}
+
+	groupID, err := s.transfer(ctx, current, run.OccurrenceAt)
+	run.FinishedAt = time.Now()
+	if err != nil {
+		s.logger.Warn("Scheduled transfer failed", zap.Error(err),
+			zap.Int("ScheduledTransferID", current.ScheduledTransferID),
+			zap.Int("attempt", run.Attempt))
+
+		message := err.Error()
+		run.Status = enum.RunFailed
+		run.Error = &message
+	} else {
+		run.Status = enum.RunSucceeded
+		run.TransactionGroupID = &groupID
+	}
+
+	return s.recordRun(ctx, run)
+}
+
+// transfer moves the money for one occurrence. The occurrence is its idempotency key, so
+// an attempt that transferred but failed to record its run is replayed instead of paying twice.
+func (s *Service) transfer(ctx context.Context, transfer *entity.ScheduledTransfer, occurrence time.Time) (int, error) {
+	req := request.TransferRequest{
+		UserID:            transfer.UserID,
+		SenderAccountID:   transfer.SenderAccountID,
+		ReceiverAccountID: transfer.ReceiverAccountID,
+		Amount:            transfer.Amount,
+		Description:       transfer.Description,
+	}
+
+	key := fmt.Sprintf("%d:%d", transfer.ScheduledTransferID, occurrence.Unix())
+	ctx, replay, err := s.idempotencyService.Begin(ctx, transfer.UserID, scopeScheduledTransfer, key, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if replay != nil {
+		var res response.TransferResponse
+		if err := json.Unmarshal(replay, &res); err != nil {
+			return 0, fmt.Errorf("decoding the stored transfer: %w", err)
+		}
+		return res.SenderTx.TransactionGroupID, nil
+	}
+
+	res, err := s.accountTransactionService.Transfer(ctx, req)
+	if err != nil {
+		_ = s.idempotencyService.Release(ctx)
+		return 0, err
+	}
+
+	return res.SenderTx.TransactionGroupID, nil
+}
+
+// recordRun stores the run and moves the order on: to its next occurrence after a success
+// or the last attempt, or to a retry with exponential backoff after a failure.
+func (s *Service) recordRun(ctx context.Context, run *entity.ScheduledTransferRun) (err error) {
+	ctx, err = s.scheduledTransferRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.scheduledTransferRepo.RollbackTx(ctx)
+		}
+	}()
+
+	transfer, err := s.scheduledTransferRepo.GetForUpdate(ctx, run.ScheduledTransferID)
+	if err != nil {
+		return err
+	}
+
+	if transfer == nil {
+		err = errors.New("the scheduled transfer was removed while it ran")
+		return err
+	}
+
+	if err = s.scheduledTransferRepo.InsertRun(ctx, run); err != nil {
+		return err
+	}
+
+	switch {
+	case run.Status == enum.RunSucceeded:
+		err = s.advance(transfer, run.FinishedAt)
+
+	case run.Attempt >= s.maxAttempts():
+		s.logger.Warn("Giving up on the occurrence of the scheduled transfer",
+			zap.Int("ScheduledTransferID", transfer.ScheduledTransferID),
+			zap.Time("occurrence", run.OccurrenceAt))
+
+		if transfer.Frequency == enum.FrequencyOnce && transfer.Status != enum.ScheduledCancelled {
+			transfer.Status = enum.ScheduledFailed
+			transfer.NextRunAt = nil
+			transfer.RetryAt = nil
+			transfer.Attempts = run.Attempt
+			break
+		}
+		err = s.advance(transfer, run.FinishedAt)
+
+	default:
+		retry := run.FinishedAt.Add(s.backoff() << (run.Attempt - 1))
+		transfer.Attempts = run.Attempt
+		transfer.RetryAt = &retry
+	}
+	if err != nil {
+		return err
+	}
+
+	transfer.LastRunAt = &run.FinishedAt
+	transfer.LockedUntil = nil
+	if err = s.scheduledTransferRepo.Update(ctx, transfer); err != nil {
+		return err
+	}
+
+	if err = s.scheduledTransferRepo.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Recorded the run of the scheduled transfer",
+		zap.Int("ScheduledTransferID", transfer.ScheduledTransferID),
+		zap.Int("attempt", run.Attempt),
+		zap.Any("status", run.Status))
+
+	return nil
+}
+
+// advance moves the order to its first occurrence after now. An executor that was down
+// runs the latest missed occurrence once rather than each of them in a burst.
+func (s *Service) advance(transfer *entity.ScheduledTransfer, now time.Time) error {
+	transfer.Attempts = 0
+	transfer.RetryAt = nil
+	if transfer.Status == enum.ScheduledCancelled {
+		return nil
+	}
+
+	next, ok, err := nextOccurrence(transfer, now)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		transfer.NextRunAt = nil
+		transfer.Status = enum.ScheduledCompleted
+		return nil
+	}
+
+	transfer.NextRunAt = &next
+	return nil
+}
+
+func (s *Service) pollInterval() time.Duration {
+	if s.cfg.PollInterval <= 0 {
+		return defaultPollInterval
+	}
+	return s.cfg.PollInterval
+}
+
+func (s *Service) batchSize() int {
+	if s.cfg.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return s.cfg.BatchSize
+}
+
+func (s *Service) lease() time.Duration {
+	if s.cfg.Lease <= 0 {
+		return defaultLease
+	}
+	return s.cfg.Lease
+}
+
+func (s *Service) maxAttempts() int {
+	if s.cfg.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return s.cfg.MaxAttempts
+}
+
+func (s *Service) backoff() time.Duration {
+	if s.cfg.RetryBackoff <= 0 {
+		return defaultRetryBackoff
+	}
+	return s.cfg.RetryBackoff
+}
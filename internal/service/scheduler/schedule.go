@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+)
+
+// nextOccurrence returns the first occurrence of the order strictly after after, and false
+// when the order has none left.
+func nextOccurrence(transfer *entity.ScheduledTransfer, after time.Time) (time.Time, bool, error) {
+	location, err := time.LoadLocation(transfer.Timezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	start := transfer.StartAt.In(location)
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+
+	var next time.Time
+	switch transfer.Frequency {
+	case enum.FrequencyOnce:
+		if !start.After(after) {
+			return time.Time{}, false, nil
+		}
+		next = start
+
+	case enum.FrequencyDaily, enum.FrequencyWeekly, enum.FrequencyMonthly:
+		// Start a little before the answer; periods vary with DST and month lengths
+		i := int(after.Sub(start)/minPeriod(transfer.Frequency)) - 1
+		if i < 0 {
+			i = 0
+		}
+		next = nthOccurrence(start, transfer.Frequency, i)
+		for !next.After(after) {
+			i++
+			next = nthOccurrence(start, transfer.Frequency, i)
+		}
+
+	case enum.FrequencyCron:
+		if transfer.CronExpression == nil {
+			return time.Time{}, false, fmt.Errorf("scheduled transfer %d has no cron expression", transfer.ScheduledTransferID)
+		}
+		schedule, err := parseCron(*transfer.CronExpression)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		var ok bool
+		if next, ok = schedule.next(after.In(location)); !ok {
+			return time.Time{}, false, nil
+		}
+
+	default:
+		return time.Time{}, false, fmt.Errorf("unknown frequency %q", transfer.Frequency)
+	}
+
+	if transfer.EndAt != nil && next.After(*transfer.EndAt) {
+		return time.Time{}, false, nil
+	}
+
+	return next, true, nil
+}
+
+// nthOccurrence counts from start, so a monthly order on the 31st runs on the last day of
+// shorter months and is back on the 31st afterwards.
+func nthOccurrence(start time.Time, frequency enum.TransferFrequency, n int) time.Time {
+	switch frequency {
+	case enum.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case enum.FrequencyMonthly:
+		year, month, day := start.Date()
+		first := time.Date(year, month+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+func minPeriod(frequency enum.TransferFrequency) time.Duration {
+	switch frequency {
+	case enum.FrequencyWeekly:
+		return 7 * 24 * time.Hour
+	case enum.FrequencyMonthly:
+		return 28 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// cronSchedule is a five-field cron expression: minute, hour, day of month, month and day
+// of week, where 0 and 7 are Sunday. Fields take *, lists, ranges and /steps.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// cronSearchLimit bounds the search for expressions that never match, such as 30 February.
+const cronSearchLimit = 5
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written as 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		values, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			values = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case values == "*":
+		case strings.Contains(values, "-"):
+			bounds := strings.SplitN(values, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			var err error
+			if lo, err = strconv.Atoi(values); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			// A single value with a step runs from the value to the end of the range
+			if step == 1 {
+				hi = lo
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// next returns the first minute after t, in t's location, that matches the schedule.
+func (c *cronSchedule) next(t time.Time) (time.Time, bool) {
+	location := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchLimit, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			// Not Truncate(time.Hour): zones such as Asia/Tehran are off the hour from UTC
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// dayMatches follows cron: when both day fields are restricted, either may match.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	cron := func(expr string) *string { return &expr }
+	end := utc(2024, time.March, 1, 0, 0)
+
+	tests := []struct {
+		name     string
+		transfer entity.ScheduledTransfer
+		after    time.Time
+		want     time.Time
+		none     bool
+	}{
+		{
+			name:     "once before the start",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyOnce, StartAt: utc(2024, time.January, 10, 9, 0)},
+			after:    utc(2024, time.January, 1, 0, 0),
+			want:     utc(2024, time.January, 10, 9, 0),
+		},
+		{
+			name:     "once after the start",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyOnce, StartAt: utc(2024, time.January, 10, 9, 0)},
+			after:    utc(2024, time.January, 10, 9, 0),
+			none:     true,
+		},
+		{
+			name:     "daily",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyDaily, StartAt: utc(2024, time.January, 1, 8, 0)},
+			after:    utc(2024, time.February, 3, 8, 0),
+			want:     utc(2024, time.February, 4, 8, 0),
+		},
+		{
+			name:     "weekly",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyWeekly, StartAt: utc(2024, time.January, 1, 8, 0)},
+			after:    utc(2024, time.January, 9, 0, 0),
+			want:     utc(2024, time.January, 15, 8, 0),
+		},
+		{
+			name:     "monthly on the 31st in February",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyMonthly, StartAt: utc(2024, time.January, 31, 12, 0)},
+			after:    utc(2024, time.February, 1, 0, 0),
+			want:     utc(2024, time.February, 29, 12, 0),
+		},
+		{
+			name:     "monthly back on the 31st",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyMonthly, StartAt: utc(2024, time.January, 31, 12, 0)},
+			after:    utc(2024, time.April, 30, 12, 0),
+			want:     utc(2024, time.May, 31, 12, 0),
+		},
+		{
+			name:     "daily keeps the wall clock across DST",
+			transfer: entity.ScheduledTransfer{Frequency: enum.FrequencyDaily, Timezone: "Europe/Berlin", StartAt: utc(2024, time.March, 25, 7, 0)},
+			after:    utc(2024, time.April, 1, 0, 0),
+			want:     utc(2024, time.April, 1, 6, 0),
+		},
+		{
+			name: "cron in the order's timezone",
+			transfer: entity.ScheduledTransfer{
+				Frequency: enum.FrequencyCron, CronExpression: cron("30 9 * * 6"), Timezone: "Asia/Tehran",
+				StartAt: utc(2024, time.January, 1, 0, 0),
+			},
+			after: utc(2024, time.January, 3, 0, 0),
+			want:  time.Date(2024, time.January, 6, 9, 30, 0, 0, tehran),
+		},
+		{
+			name: "cron with both day fields matches either",
+			transfer: entity.ScheduledTransfer{
+				Frequency: enum.FrequencyCron, CronExpression: cron("0 0 15 * 1"),
+				StartAt: utc(2024, time.January, 1, 0, 0),
+			},
+			after: utc(2024, time.January, 9, 0, 0),
+			want:  utc(2024, time.January, 15, 0, 0),
+		},
+		{
+			name: "past the end",
+			transfer: entity.ScheduledTransfer{
+				Frequency: enum.FrequencyMonthly, StartAt: utc(2024, time.January, 15, 0, 0), EndAt: &end,
+			},
+			after: utc(2024, time.February, 15, 0, 0),
+			none:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.transfer.Timezone == "" {
+				tt.transfer.Timezone = "UTC"
+			}
+
+			got, ok, err := nextOccurrence(&tt.transfer, tt.after)
+			require.NoError(t, err)
+			if tt.none {
+				assert.False(t, ok, "got %v", got)
+				return
+			}
+
+			require.True(t, ok)
+			assert.True(t, tt.want.Equal(got), "want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	schedule, err := parseCron("*/15 9-17 * 1,7 1-5")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1|1<<15|1<<30|1<<45), schedule.minute)
+	assert.Equal(t, uint64(1<<1|1<<7), schedule.month)
+
+	sunday, err := parseCron("0 0 * * 7")
+	require.NoError(t, err)
+	assert.True(t, sunday.dayMatches(utc(2024, time.January, 7, 0, 0)))
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := parseCron(expr)
+		assert.Error(t, err, expr)
+	}
+
+	never, err := parseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	_, ok := never.next(utc(2024, time.January, 1, 0, 0))
+	assert.False(t, ok)
+}
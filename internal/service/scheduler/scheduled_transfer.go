@@ -0,0 +1,244 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+// CreateScheduledTransfer refuses amounts above the sender account's two-factor threshold:
+// the runs happen with nobody there to answer a challenge, so every one of them would fail.
+func (s *Service) CreateScheduledTransfer(ctx context.Context, req request.CreateScheduledTransfer) (*entity.ScheduledTransfer, error) {
+	if err := req.Validate(); err != nil {
+		s.logger.Error("Invalid scheduled transfer", zap.Error(err))
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, derror.NewBadRequestError("unknown timezone %q", req.Timezone)
+	}
+
+	if req.Frequency == enum.FrequencyCron {
+		if _, err := parseCron(req.CronExpression); err != nil {
+			return nil, derror.NewBadRequestError(err.Error())
+		}
+	}
+
+	sender, err := s.financialAccountService.GetAccountByID(ctx, req.SenderAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sender.UserID != req.UserID {
+		return nil, derror.NewForbiddenError("account %d does not belong to the user", req.SenderAccountID)
+	}
+
+	if enum.CurrencyCode(sender.CurrencyCode) != req.Amount.Currency {
+		return nil, derror.NewBadRequestError("the amount must be in the sender account's currency")
+	}
+
+	if err := s.checkTwoFactorThreshold(ctx, req.SenderAccountID, req.Amount); err != nil {
+		return nil, err
+	}
+
+	transfer := &entity.ScheduledTransfer{
+		UserID:            req.UserID,
+		SenderAccountID:   req.SenderAccountID,
+		ReceiverAccountID: req.ReceiverAccountID,
+		Amount:            req.Amount,
+		Description:       req.Description,
+		Frequency:         req.Frequency,
+		Timezone:          req.Timezone,
+		StartAt:           req.StartAt,
+		EndAt:             req.EndAt,
+		Status:            enum.ScheduledActive,
+	}
+	if req.CronExpression != "" {
+		transfer.CronExpression = &req.CronExpression
+	}
+
+	// A start in the past only matters to recurring orders, which begin with the next occurrence
+	next, ok, err := nextOccurrence(transfer, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to compute the first occurrence", zap.Error(err))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if !ok {
+		return nil, derror.NewValidationError("the schedule has no occurrence in the future")
+	}
+	transfer.NextRunAt = &next
+
+	if err := s.scheduledTransferRepo.Insert(ctx, transfer); err != nil {
+		s.logger.Error("Failed to insert the scheduled transfer", zap.Error(err))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	s.logger.Info("Scheduled transfer created",
+		zap.Int("ScheduledTransferID", transfer.ScheduledTransferID),
+		zap.String("frequency", string(transfer.Frequency)),
+		zap.Time("nextRunAt", next))
+
+	return transfer, nil
+}
+
+// checkTwoFactorThreshold turns down an amount the account's rules only let through with a
+// second factor.
+func (s *Service) checkTwoFactorThreshold(ctx context.Context, accountID int, amount entity.Money) error {
+	rules, err := s.accountRulesService.GetRules(ctx, accountID)
+	if err != nil {
+		if derror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	threshold := rules.Require2FAForAmount
+	if !threshold.IsZero() && amount.Amount > threshold.Amount {
+		return derror.NewValidationError("scheduled transfers cannot be confirmed with two-factor authentication, so they must not exceed %s %s", threshold.String(), threshold.Currency)
+	}
+
+	return nil
+}
+
+func (s *Service) ListScheduledTransfers(ctx context.Context, userID int) ([]*entity.ScheduledTransfer, error) {
+	transfers, err := s.scheduledTransferRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list scheduled transfers", zap.Error(err), zap.Int("userID", userID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return transfers, nil
+}
+
+func (s *Service) GetScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (response.ScheduledTransferDetail, error) {
+	if err := req.Validate(); err != nil {
+		return response.ScheduledTransferDetail{}, derror.NewBadRequestError(err.Error())
+	}
+
+	transfer, err := s.scheduledTransferRepo.Get(ctx, req.ScheduledTransferID)
+	if err != nil {
+		s.logger.Error("Failed to get the scheduled transfer", zap.Error(err), zap.Int("ScheduledTransferID", req.ScheduledTransferID))
+		return response.ScheduledTransferDetail{}, derror.NewInternalSystemError()
+	}
+
+	if transfer == nil || transfer.UserID != req.UserID {
+		return response.ScheduledTransferDetail{}, derror.NewNotFoundError("scheduled transfer %d not found", req.ScheduledTransferID)
+	}
+
+	runs, err := s.scheduledTransferRepo.ListRuns(ctx, transfer.ScheduledTransferID)
+	if err != nil {
+		s.logger.Error("Failed to list the runs of the scheduled transfer", zap.Error(err), zap.Int("ScheduledTransferID", req.ScheduledTransferID))
+		return response.ScheduledTransferDetail{}, derror.NewInternalSystemError()
+	}
+
+	return response.ScheduledTransferDetail{Transfer: transfer, Runs: runs}, nil
+}
+
+func (s *Service) PauseScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (*entity.ScheduledTransfer, error) {
+	return s.change(ctx, req, func(transfer *entity.ScheduledTransfer) error {
+		if transfer.Status != enum.ScheduledActive {
+			return derror.NewConflictError("only an active scheduled transfer can be paused")
+		}
+
+		transfer.Status = enum.ScheduledPaused
+		return nil
+	})
+}
+
+func (s *Service) ResumeScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (*entity.ScheduledTransfer, error) {
+	return s.change(ctx, req, func(transfer *entity.ScheduledTransfer) error {
+		if transfer.Status != enum.ScheduledPaused {
+			return derror.NewConflictError("only a paused scheduled transfer can be resumed")
+		}
+
+		now := time.Now()
+		if due := transfer.DueAt(); due != nil && due.Before(now) {
+			next, ok, err := nextOccurrence(transfer, now)
+			if err != nil {
+				s.logger.Error("Failed to compute the next occurrence", zap.Error(err))
+				return derror.NewInternalSystemError()
+			}
+
+			if !ok {
+				return derror.NewValidationError("the schedule has no occurrence left after the pause")
+			}
+
+			s.logger.Info("Skipping the occurrences missed while paused",
+				zap.Int("ScheduledTransferID", transfer.ScheduledTransferID),
+				zap.Time("nextRunAt", next))
+			transfer.NextRunAt = &next
+			transfer.RetryAt = nil
+			transfer.Attempts = 0
+		}
+
+		transfer.Status = enum.ScheduledActive
+		return nil
+	})
+}
+
+func (s *Service) CancelScheduledTransfer(ctx context.Context, req request.ScheduledTransferAction) (*entity.ScheduledTransfer, error) {
+	return s.change(ctx, req, func(transfer *entity.ScheduledTransfer) error {
+		if transfer.Status != enum.ScheduledActive && transfer.Status != enum.ScheduledPaused {
+			return derror.NewConflictError("the scheduled transfer has already ended")
+		}
+
+		transfer.Status = enum.ScheduledCancelled
+		transfer.NextRunAt = nil
+		transfer.RetryAt = nil
+		return nil
+	})
+}
+
+// change applies an owner's change to a locked order. An executor that is running the
+// order at the same time reloads it before recording the run, so the change is kept.
+func (s *Service) change(ctx context.Context, req request.ScheduledTransferAction, apply func(*entity.ScheduledTransfer) error) (transfer *entity.ScheduledTransfer, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	ctx, err = s.scheduledTransferRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.scheduledTransferRepo.RollbackTx(ctx)
+		}
+	}()
+
+	transfer, err = s.scheduledTransferRepo.GetForUpdate(ctx, req.ScheduledTransferID)
+	if err != nil {
+		s.logger.Error("Failed to lock the scheduled transfer", zap.Error(err), zap.Int("ScheduledTransferID", req.ScheduledTransferID))
+		return nil, err
+	}
+
+	if transfer == nil || transfer.UserID != req.UserID {
+		err = derror.NewNotFoundError("scheduled transfer %d not found", req.ScheduledTransferID)
+		return nil, err
+	}
+
+	if err = apply(transfer); err != nil {
+		return nil, err
+	}
+
+	if err = s.scheduledTransferRepo.Update(ctx, transfer); err != nil {
+		s.logger.Error("Failed to update the scheduled transfer", zap.Error(err), zap.Int("ScheduledTransferID", transfer.ScheduledTransferID))
+		return nil, err
+	}
+
+	if err = s.scheduledTransferRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return transfer, nil
+}
@@ -0,0 +1,300 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mocks struct {
+	repo         *protocol.MockScheduledTransferRepo
+	accounts     *protocol.MockFinancialAccountService
+	transactions *protocol.MockAccountTransactionService
+	idempotency  *protocol.MockIdempotencyService
+	rules        *protocol.MockAccountRulesService
+}
+
+func setup() (*Service, mocks) {
+	m := mocks{
+		repo:         new(protocol.MockScheduledTransferRepo),
+		accounts:     new(protocol.MockFinancialAccountService),
+		transactions: new(protocol.MockAccountTransactionService),
+		idempotency:  new(protocol.MockIdempotencyService),
+		rules:        new(protocol.MockAccountRulesService),
+	}
+	logger, _ := zap.NewProduction()
+
+	cfg := config.Scheduler{BatchSize: 10, Lease: time.Minute, MaxAttempts: 3, RetryBackoff: 10 * time.Minute}
+	return New(cfg, logger.Sugar(), m.repo, m.accounts, m.transactions, m.idempotency, m.rules), m
+}
+
+func usd(amount int64) entity.Money {
+	return entity.NewMoney(amount, enum.USD)
+}
+
+// expectTx lets every unit of work commit or roll back.
+func (m mocks) expectTx() {
+	m.repo.On("BeginTx", mock.Anything).Return(context.Background(), nil)
+	m.repo.On("CommitTx", mock.Anything).Return(nil)
+	m.repo.On("RollbackTx", mock.Anything).Return(nil)
+}
+
+func monthlyOrder(next time.Time) *entity.ScheduledTransfer {
+	return &entity.ScheduledTransfer{
+		ScheduledTransferID: 5,
+		UserID:              1,
+		SenderAccountID:     10,
+		ReceiverAccountID:   20,
+		Amount:              usd(2500),
+		Description:         "Rent",
+		Frequency:           enum.FrequencyMonthly,
+		Timezone:            "UTC",
+		StartAt:             next.AddDate(0, -3, 0),
+		NextRunAt:           &next,
+		Status:              enum.ScheduledActive,
+	}
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	valid := func() request.CreateScheduledTransfer {
+		return request.CreateScheduledTransfer{
+			UserID:            1,
+			SenderAccountID:   10,
+			ReceiverAccountID: 20,
+			Amount:            usd(2500),
+			Frequency:         enum.FrequencyMonthly,
+			StartAt:           time.Now().AddDate(0, -2, 0),
+		}
+	}
+
+	t.Run("stays within the two-factor threshold", func(t *testing.T) {
+		service, m := setup()
+		m.accounts.On("GetAccountByID", mock.Anything, 10).Return(response.GetFinancialAccount{AccountID: 10, UserID: 1, CurrencyCode: "USD"}, nil)
+		m.rules.On("GetRules", mock.Anything, 10).Return(&entity.AccountRules{FinancialAccountID: 10, Require2FAForAmount: usd(2500)}, nil)
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.ScheduledTransfer")).Return(nil)
+
+		_, err := service.CreateScheduledTransfer(context.Background(), valid())
+		require.NoError(t, err)
+	})
+
+	t.Run("starts a standing order at its next occurrence", func(t *testing.T) {
+		service, m := setup()
+		m.accounts.On("GetAccountByID", mock.Anything, 10).Return(response.GetFinancialAccount{AccountID: 10, UserID: 1, CurrencyCode: "USD"}, nil)
+		m.rules.On("GetRules", mock.Anything, 10).Return(nil, derror.NewNotFoundError("No rules are set for account 10"))
+		m.repo.On("Insert", mock.Anything, mock.AnythingOfType("*entity.ScheduledTransfer")).Return(nil)
+
+		transfer, err := service.CreateScheduledTransfer(context.Background(), valid())
+		require.NoError(t, err)
+		assert.Equal(t, enum.ScheduledActive, transfer.Status)
+		assert.Equal(t, "UTC", transfer.Timezone)
+		require.NotNil(t, transfer.NextRunAt)
+		assert.True(t, transfer.NextRunAt.After(time.Now()))
+		assert.True(t, transfer.NextRunAt.Before(time.Now().AddDate(0, 1, 1)))
+	})
+
+	tests := []struct {
+		name   string
+		change func(req *request.CreateScheduledTransfer)
+		owner  int
+		rules  *entity.AccountRules
+		status int
+	}{
+		{name: "someone else's account", owner: 2, status: http.StatusForbidden},
+		{name: "unknown timezone", change: func(req *request.CreateScheduledTransfer) { req.Timezone = "Mars/Olympus" }, status: http.StatusBadRequest},
+		{name: "bad cron expression", change: func(req *request.CreateScheduledTransfer) {
+			req.Frequency, req.CronExpression = enum.FrequencyCron, "0 25 * * *"
+		}, status: http.StatusBadRequest},
+		{name: "one-off in the past", change: func(req *request.CreateScheduledTransfer) { req.Frequency = enum.FrequencyOnce }, status: http.StatusUnprocessableEntity},
+		{name: "other currency", change: func(req *request.CreateScheduledTransfer) { req.Amount = entity.NewMoney(2500, enum.EUR) }, status: http.StatusBadRequest},
+		{name: "above the two-factor threshold", rules: &entity.AccountRules{FinancialAccountID: 10, Require2FAForAmount: usd(2000)}, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup()
+			owner := 1
+			if tt.owner != 0 {
+				owner = tt.owner
+			}
+			m.accounts.On("GetAccountByID", mock.Anything, 10).Return(response.GetFinancialAccount{AccountID: 10, UserID: owner, CurrencyCode: "USD"}, nil).Maybe()
+			if tt.rules != nil {
+				m.rules.On("GetRules", mock.Anything, 10).Return(tt.rules, nil)
+			} else {
+				m.rules.On("GetRules", mock.Anything, 10).Return(nil, derror.NewNotFoundError("No rules are set for account 10")).Maybe()
+			}
+
+			req := valid()
+			if tt.change != nil {
+				tt.change(&req)
+			}
+
+			_, err := service.CreateScheduledTransfer(context.Background(), req)
+			assert.True(t, derror.IsHTTPError(err, tt.status), "got %v", err)
+			m.repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestResumeScheduledTransferSkipsMissedOccurrences(t *testing.T) {
+	service, m := setup()
+	m.expectTx()
+
+	missed := time.Now().AddDate(0, -2, 0)
+	order := monthlyOrder(missed)
+	order.Status = enum.ScheduledPaused
+	order.Attempts = 2
+	retry := missed.Add(time.Hour)
+	order.RetryAt = &retry
+
+	m.repo.On("GetForUpdate", mock.Anything, 5).Return(order, nil)
+	m.repo.On("Update", mock.Anything, order).Return(nil)
+
+	resumed, err := service.ResumeScheduledTransfer(context.Background(), request.ScheduledTransferAction{UserID: 1, ScheduledTransferID: 5})
+	require.NoError(t, err)
+	assert.Equal(t, enum.ScheduledActive, resumed.Status)
+	assert.True(t, resumed.NextRunAt.After(time.Now()))
+	assert.Nil(t, resumed.RetryAt)
+	assert.Zero(t, resumed.Attempts)
+}
+
+func TestScheduledTransferBelongsToItsOwner(t *testing.T) {
+	service, m := setup()
+	m.expectTx()
+	m.repo.On("GetForUpdate", mock.Anything, 5).Return(monthlyOrder(time.Now()), nil)
+
+	_, err := service.PauseScheduledTransfer(context.Background(), request.ScheduledTransferAction{UserID: 2, ScheduledTransferID: 5})
+	assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	m.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRunDue(t *testing.T) {
+	now := time.Now()
+	due := now.Add(-time.Minute)
+
+	// expectRun wires one claimed order and captures the run and the order it records.
+	expectRun := func(m mocks, order *entity.ScheduledTransfer) (*entity.ScheduledTransferRun, *entity.ScheduledTransfer) {
+		m.expectTx()
+		m.repo.On("ClaimDue", mock.Anything, now, now.Add(time.Minute), 10).Return([]*entity.ScheduledTransfer{order}, nil)
+		m.repo.On("Get", mock.Anything, 5).Return(order, nil)
+		m.repo.On("GetForUpdate", mock.Anything, 5).Return(order, nil)
+
+		run := &entity.ScheduledTransferRun{}
+		m.repo.On("InsertRun", mock.Anything, mock.AnythingOfType("*entity.ScheduledTransferRun")).Run(func(args mock.Arguments) {
+			*run = *args.Get(1).(*entity.ScheduledTransferRun)
+		}).Return(nil)
+		m.repo.On("Update", mock.Anything, order).Return(nil)
+		return run, order
+	}
+
+	t.Run("transfers and moves to the next occurrence", func(t *testing.T) {
+		service, m := setup()
+		run, order := expectRun(m, monthlyOrder(due))
+
+		m.idempotency.On("Begin", mock.Anything, 1, scopeScheduledTransfer, fmt.Sprintf("5:%d", due.Unix()), mock.Anything).Return(context.Background(), nil, nil)
+		m.transactions.On("Transfer", mock.Anything, request.TransferRequest{
+			UserID: 1, SenderAccountID: 10, ReceiverAccountID: 20, Amount: usd(2500), Description: "Rent",
+		}).Return(response.TransferResponse{SenderTx: entity.AccountTransaction{TransactionGroupID: 42}}, nil)
+
+		count, err := service.RunDue(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		assert.Equal(t, enum.RunSucceeded, run.Status)
+		assert.Equal(t, 42, *run.TransactionGroupID)
+		assert.Equal(t, 1, run.Attempt)
+		assert.True(t, due.Equal(run.OccurrenceAt))
+
+		assert.True(t, order.NextRunAt.After(now), "got %v", order.NextRunAt)
+		assert.True(t, order.NextRunAt.Before(now.AddDate(0, 1, 1)), "got %v", order.NextRunAt)
+		assert.Nil(t, order.LockedUntil)
+		assert.NotNil(t, order.LastRunAt)
+	})
+
+	t.Run("retries a failure with backoff", func(t *testing.T) {
+		service, m := setup()
+		order := monthlyOrder(due)
+		order.Attempts = 1
+		run, order := expectRun(m, order)
+
+		m.idempotency.On("Begin", mock.Anything, 1, scopeScheduledTransfer, mock.Anything, mock.Anything).Return(context.Background(), nil, nil)
+		m.idempotency.On("Release", mock.Anything).Return(nil)
+		m.transactions.On("Transfer", mock.Anything, mock.Anything).Return(response.TransferResponse{}, derror.NewValidationError("insufficient funds"))
+
+		_, err := service.RunDue(context.Background(), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, enum.RunFailed, run.Status)
+		assert.Contains(t, *run.Error, "insufficient funds")
+		assert.Equal(t, 2, order.Attempts)
+		require.NotNil(t, order.RetryAt)
+		assert.WithinDuration(t, time.Now().Add(20*time.Minute), *order.RetryAt, 5*time.Second)
+		assert.True(t, due.Equal(*order.NextRunAt))
+		m.idempotency.AssertCalled(t, "Release", mock.Anything)
+	})
+
+	t.Run("gives up a one-off after the last attempt", func(t *testing.T) {
+		service, m := setup()
+		order := monthlyOrder(due)
+		order.Frequency = enum.FrequencyOnce
+		order.Attempts = 2
+		run, order := expectRun(m, order)
+
+		m.idempotency.On("Begin", mock.Anything, 1, scopeScheduledTransfer, mock.Anything, mock.Anything).Return(context.Background(), nil, nil)
+		m.idempotency.On("Release", mock.Anything).Return(nil)
+		m.transactions.On("Transfer", mock.Anything, mock.Anything).Return(response.TransferResponse{}, errors.New("boom"))
+
+		_, err := service.RunDue(context.Background(), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, run.Attempt)
+		assert.Equal(t, enum.ScheduledFailed, order.Status)
+		assert.Nil(t, order.NextRunAt)
+		assert.Nil(t, order.RetryAt)
+	})
+
+	t.Run("replays an occurrence that already transferred", func(t *testing.T) {
+		service, m := setup()
+		run, _ := expectRun(m, monthlyOrder(due))
+
+		stored := []byte(`{"SenderTx":{"TransactionGroupID":42}}`)
+		m.idempotency.On("Begin", mock.Anything, 1, scopeScheduledTransfer, mock.Anything, mock.Anything).Return(context.Background(), stored, nil)
+
+		_, err := service.RunDue(context.Background(), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, enum.RunSucceeded, run.Status)
+		assert.Equal(t, 42, *run.TransactionGroupID)
+		m.transactions.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("skips an order paused after it was claimed", func(t *testing.T) {
+		service, m := setup()
+		claimed := monthlyOrder(due)
+		paused := monthlyOrder(due)
+		paused.Status = enum.ScheduledPaused
+
+		m.repo.On("ClaimDue", mock.Anything, now, now.Add(time.Minute), 10).Return([]*entity.ScheduledTransfer{claimed}, nil)
+		m.repo.On("Get", mock.Anything, 5).Return(paused, nil)
+
+		count, err := service.RunDue(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		m.transactions.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything)
+		m.repo.AssertNotCalled(t, "InsertRun", mock.Anything, mock.Anything)
+	})
+}
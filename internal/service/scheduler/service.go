@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg                       config.Scheduler
+	logger                    *zap.SugaredLogger
+	scheduledTransferRepo     protocol.ScheduledTransferRepository
+	financialAccountService   protocol.FinancialAccount
+	accountTransactionService protocol.AccountTransaction
+	idempotencyService        protocol.Idempotency
+	accountRulesService       protocol.AccountRules
+}
+
+func New(
+	cfg config.Scheduler,
+	logger *zap.SugaredLogger,
+	scheduledTransferRepo protocol.ScheduledTransferRepository,
+	financialAccountService protocol.FinancialAccount,
+	accountTransactionService protocol.AccountTransaction,
+	idempotencyService protocol.Idempotency,
+	accountRulesService protocol.AccountRules,
+) *Service {
+	return &Service{
+		cfg:                       cfg,
+		logger:                    logger,
+		scheduledTransferRepo:     scheduledTransferRepo,
+		financialAccountService:   financialAccountService,
+		accountTransactionService: accountTransactionService,
+		idempotencyService:        idempotencyService,
+		accountRulesService:       accountRulesService,
+	}
+}
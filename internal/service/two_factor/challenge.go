@@ -0,0 +1,120 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+// challenge is what a challenge token vouches for. The binding ties it to one request, such
+// as a transfer of a given amount between given accounts, so an answer cannot be reused for
+// a different one.
+type challenge struct {
+	Purpose   enum.TwoFactorPurpose `json:"p"`
+	UserID    int                   `json:"u"`
+	Binding   string                `json:"b,omitempty"`
+	ExpiresAt int64                 `json:"e"`
+}
+
+func (s *Service) Challenge(ctx context.Context, userID int, purpose enum.TwoFactorPurpose, binding string) (response.TwoFactorChallenge, error) {
+	expiresAt := time.Now().Add(s.challengeTTL())
+
+	token, err := s.sign(challenge{
+		Purpose:   purpose,
+		UserID:    userID,
+		Binding:   binding,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		s.logger.Error("Failed to sign the two-factor challenge", zap.Error(err), zap.Int("userID", userID))
+		return response.TwoFactorChallenge{}, derror.NewInternalSystemError()
+	}
+
+	return response.TwoFactorChallenge{
+		Challenge: token,
+		Purpose:   purpose,
+		ExpiresAt: expiresAt.Truncate(time.Second),
+	}, nil
+}
+
+func (s *Service) Verify(ctx context.Context, answer request.TwoFactorAnswer, purpose enum.TwoFactorPurpose, binding string) (int, error) {
+	if err := answer.Validate(); err != nil {
+		return 0, derror.NewBadRequestError(err.Error())
+	}
+
+	issued, ok := s.open(answer.Challenge)
+	if !ok || issued.Purpose != purpose || issued.Binding != binding || time.Now().Unix() >= issued.ExpiresAt {
+		return 0, derror.NewUnauthorizedError("the two-factor challenge is invalid or has expired")
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, issued.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get the two-factor authenticator", zap.Error(err), zap.Int("userID", issued.UserID))
+		return 0, derror.NewInternalSystemError()
+	}
+
+	// The authenticator may have been turned off since the challenge was issued
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		return 0, derror.NewUnauthorizedError("the two-factor challenge is invalid or has expired")
+	}
+
+	// Recovery codes stand in for a lost phone at sign-in only; anything else can wait
+	// until the user is back in and has set up a new authenticator.
+	if err := s.checkCode(ctx, twoFactor, answer.Code, purpose == enum.TwoFactorSignIn); err != nil {
+		return 0, err
+	}
+
+	return issued.UserID, nil
+}
+
+// sign encodes the challenge and appends an HMAC of it, so that the server keeps no state
+// between issuing a challenge and checking its answer.
+func (s *Service) sign(c challenge) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded)), nil
+}
+
+func (s *Service) open(token string) (challenge, bool) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return challenge{}, false
+	}
+
+	sum, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, s.mac(encoded)) {
+		return challenge{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return challenge{}, false
+	}
+
+	var c challenge
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return challenge{}, false
+	}
+
+	return c, true
+}
+
+func (s *Service) mac(encoded string) []byte {
+	h := hmac.New(sha256.New, s.challengeKey)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
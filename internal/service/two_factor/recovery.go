@@ -0,0 +1,62 @@
+package twofactor
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// Recovery codes use the same Crockford alphabet as gift card codes. Ten characters give
+// 50 bits, plenty for a code that is spent once and locked out after a few wrong guesses.
+const (
+	recoveryAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	recoveryLength   = 10
+	recoveryGroup    = 5
+)
+
+// newRecoveryCodes returns n codes such as "7K3QM-9XD2H" along with the hashes to store.
+func newRecoveryCodes(n int) (codes []string, hashes [][]byte, err error) {
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+
+	for len(codes) < n {
+		var b strings.Builder
+		for i := 0; i < recoveryLength; i++ {
+			if i > 0 && i%recoveryGroup == 0 {
+				b.WriteByte('-')
+			}
+
+			d, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.WriteByte(recoveryAlphabet[d.Int64()])
+		}
+
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-':
+			return -1
+		case 'O':
+			return '0'
+		case 'I', 'L':
+			return '1'
+		}
+		return r
+	}, code)
+}
+
+func hashRecoveryCode(code string) []byte {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return sum[:]
+}
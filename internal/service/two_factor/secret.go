@@ -0,0 +1,52 @@
+package twofactor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"strconv"
+)
+
+// sealSecret encrypts the base32 secret with AES-256-GCM under the secret key. The user ID
+// is bound as additional data, so a sealed secret cannot be copied onto another user's row.
+func (s *Service) sealSecret(userID int, secret string) ([]byte, error) {
+	aead, err := s.secretAEAD()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, []byte(secret), []byte(strconv.Itoa(userID))), nil
+}
+
+func (s *Service) openSecret(userID int, sealed []byte) (string, error) {
+	aead, err := s.secretAEAD()
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("sealed secret is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, []byte(strconv.Itoa(userID)))
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+func (s *Service) secretAEAD() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
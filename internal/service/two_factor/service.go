@@ -0,0 +1,50 @@
+package twofactor
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	cfg           config.TwoFactor
+	logger        *zap.SugaredLogger
+	twoFactorRepo protocol.TwoFactorRepository
+	userRepo      protocol.UserRepository
+	challengeKey  []byte
+	secretKey     []byte
+}
+
+// New decodes the challenge and secret keys up front so that a bad key stops the service
+// from starting instead of failing every sign-in.
+func New(cfg config.TwoFactor, logger *zap.SugaredLogger, twoFactorRepo protocol.TwoFactorRepository, userRepo protocol.UserRepository) (*Service, error) {
+	challengeKey, err := base64.StdEncoding.DecodeString(cfg.ChallengeKey)
+	if err != nil {
+		return nil, fmt.Errorf("two-factor challenge key: %w", err)
+	}
+
+	if len(challengeKey) < 32 {
+		return nil, fmt.Errorf("two-factor challenge key: want at least 32 bytes, got %d", len(challengeKey))
+	}
+
+	secretKey, err := base64.StdEncoding.DecodeString(cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("two-factor secret key: %w", err)
+	}
+
+	if len(secretKey) != 32 {
+		return nil, fmt.Errorf("two-factor secret key: want 32 bytes, got %d", len(secretKey))
+	}
+
+	return &Service{
+		cfg:           cfg,
+		logger:        logger,
+		twoFactorRepo: twoFactorRepo,
+		userRepo:      userRepo,
+		challengeKey:  challengeKey,
+		secretKey:     secretKey,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"go.uber.org/zap"
+)
+
+func (s *Service) VerifyStepUp(ctx context.Context, userID int, binding string, answer *request.TwoFactorAnswer) (entity.SecondFactor, error) {
+	factor := entity.SecondFactor{UserID: userID, Binding: binding}
+	if answer == nil {
+		return factor, nil
+	}
+
+	verifiedUserID, err := s.Verify(ctx, *answer, enum.TwoFactorTransfer, binding)
+	if err != nil {
+		return factor, err
+	}
+
+	if verifiedUserID != userID {
+		return factor, derror.NewUnauthorizedError("the two-factor challenge is invalid or has expired")
+	}
+
+	factor.Verified = true
+	return factor, nil
+}
+
+func (s *Service) StepUp(ctx context.Context, factor entity.SecondFactor, violations []entity.PolicyViolation) error {
+	enabled, err := s.IsEnabled(ctx, factor.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		return derror.WithDetails(derror.NewForbiddenError("turn on two-factor authentication to make this payment"), violations)
+	}
+
+	challenge, err := s.Challenge(ctx, factor.UserID, enum.TwoFactorTransfer, factor.Binding)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Payment needs a second factor", zap.Int("userID", factor.UserID))
+	return derror.WithDetails(derror.NewForbiddenError("the payment needs a two-factor code"), challenge)
+}
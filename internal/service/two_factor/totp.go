@@ -0,0 +1,81 @@
+package twofactor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Codes follow RFC 6238 with the parameters every authenticator app supports: HMAC-SHA1,
+// six digits and a 30 second step. One step either side is accepted for clock drift.
+const (
+	secretBytes = 20
+	codeDigits  = 6
+	stepPeriod  = 30
+	stepSkew    = 1
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+func timeStep(t time.Time) int64 {
+	return t.Unix() / stepPeriod
+}
+
+// codeAt is the code of the given time step, the HOTP value of RFC 4226 for the step as
+// its counter.
+func codeAt(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", codeDigits, value%1000000)
+}
+
+// matchStep returns the time step whose code is code, looking one step either side of now.
+func matchStep(secret []byte, code string, now time.Time) (int64, bool) {
+	if len(code) != codeDigits {
+		return 0, false
+	}
+
+	current := timeStep(now)
+	for step := current - stepSkew; step <= current+stepSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(codeAt(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// provisioningURI is the otpauth URI authenticator apps read from the enrollment QR code.
+func provisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(codeDigits))
+	query.Set("period", fmt.Sprint(stepPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
@@ -0,0 +1,302 @@
+package twofactor
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultIssuer            = "Digital Wallet"
+	defaultChallengeTTL      = 5 * time.Minute
+	defaultMaxFailedAttempts = 5
+	defaultFailureWindow     = 15 * time.Minute
+	defaultRecoveryCodes     = 10
+	qrCodeSize               = 256
+)
+
+func (s *Service) Enroll(ctx context.Context, userID int) (response.TwoFactorEnrollment, error) {
+	existing, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get the two-factor authenticator", zap.Error(err), zap.Int("userID", userID))
+		return response.TwoFactorEnrollment{}, derror.NewInternalSystemError()
+	}
+
+	if existing != nil && existing.IsEnabled() {
+		return response.TwoFactorEnrollment{}, derror.NewConflictError("two-factor authentication is already on")
+	}
+
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get the user", zap.Error(err), zap.Int("userID", userID))
+		return response.TwoFactorEnrollment{}, derror.NewInternalSystemError()
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		s.logger.Error("Failed to generate a two-factor secret", zap.Error(err))
+		return response.TwoFactorEnrollment{}, derror.NewInternalSystemError()
+	}
+
+	sealed, err := s.sealSecret(userID, secret)
+	if err != nil {
+		s.logger.Error("Failed to seal the two-factor secret", zap.Error(err), zap.Int("userID", userID))
+		return response.TwoFactorEnrollment{}, derror.NewInternalSystemError()
+	}
+
+	// Enrolling again while pending overwrites the secret on the row
+	if err := s.twoFactorRepo.Upsert(ctx, &entity.TwoFactor{UserID: userID, SealedSecret: sealed}); err != nil {
+		s.logger.Error("Failed to save the two-factor authenticator", zap.Error(err), zap.Int("userID", userID))
+		return response.TwoFactorEnrollment{}, derror.NewInternalSystemError()
+	}
+
+	uri := provisioningURI(s.issuer(), user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		s.logger.Error("Failed to draw the two-factor QR code", zap.Error(err))
+		return response.TwoFactorEnrollment{}, derror.NewInternalSystemError()
+	}
+
+	s.logger.Info("Two-factor enrollment started", zap.Int("userID", userID))
+
+	return response.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+func (s *Service) Confirm(ctx context.Context, req request.TwoFactorCode) (codes response.RecoveryCodes, err error) {
+	if err := req.Validate(); err != nil {
+		return response.RecoveryCodes{}, derror.NewBadRequestError(err.Error())
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, req.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get the two-factor authenticator", zap.Error(err), zap.Int("userID", req.UserID))
+		return response.RecoveryCodes{}, derror.NewInternalSystemError()
+	}
+
+	if twoFactor == nil {
+		return response.RecoveryCodes{}, derror.NewNotFoundError("two-factor authentication has not been set up")
+	}
+
+	if twoFactor.IsEnabled() {
+		return response.RecoveryCodes{}, derror.NewConflictError("two-factor authentication is already on")
+	}
+
+	if err := s.checkCode(ctx, twoFactor, req.Code, false); err != nil {
+		return response.RecoveryCodes{}, err
+	}
+
+	plain, hashes, err := newRecoveryCodes(s.recoveryCodes())
+	if err != nil {
+		s.logger.Error("Failed to generate recovery codes", zap.Error(err))
+		return response.RecoveryCodes{}, derror.NewInternalSystemError()
+	}
+
+	ctx, err = s.twoFactorRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Error("Failed to begin database transaction", zap.Error(err))
+		return response.RecoveryCodes{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.twoFactorRepo.RollbackTx(ctx)
+		}
+	}()
+
+	if err = s.twoFactorRepo.Enable(ctx, req.UserID, time.Now()); err != nil {
+		s.logger.Error("Failed to turn on two-factor authentication", zap.Error(err), zap.Int("userID", req.UserID))
+		return response.RecoveryCodes{}, err
+	}
+
+	if err = s.twoFactorRepo.ReplaceRecoveryCodes(ctx, req.UserID, hashes); err != nil {
+		s.logger.Error("Failed to save the recovery codes", zap.Error(err), zap.Int("userID", req.UserID))
+		return response.RecoveryCodes{}, err
+	}
+
+	if err = s.twoFactorRepo.CommitTx(ctx); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return response.RecoveryCodes{}, err
+	}
+
+	s.logger.Info("Two-factor authentication turned on", zap.Int("userID", req.UserID))
+
+	return response.RecoveryCodes{Codes: plain}, nil
+}
+
+// Disable takes a recovery code as well as a current code, so a user who lost their phone
+// can turn the authenticator off and enroll a new one.
+func (s *Service) Disable(ctx context.Context, req request.TwoFactorCode) error {
+	twoFactor, err := s.enabled(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkCode(ctx, twoFactor, req.Code, true); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Delete(ctx, req.UserID); err != nil {
+		s.logger.Error("Failed to delete the two-factor authenticator", zap.Error(err), zap.Int("userID", req.UserID))
+		return derror.NewInternalSystemError()
+	}
+
+	s.logger.Info("Two-factor authentication turned off", zap.Int("userID", req.UserID))
+
+	return nil
+}
+
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, req request.TwoFactorCode) (response.RecoveryCodes, error) {
+	twoFactor, err := s.enabled(ctx, req)
+	if err != nil {
+		return response.RecoveryCodes{}, err
+	}
+
+	if err := s.checkCode(ctx, twoFactor, req.Code, false); err != nil {
+		return response.RecoveryCodes{}, err
+	}
+
+	plain, hashes, err := newRecoveryCodes(s.recoveryCodes())
+	if err != nil {
+		s.logger.Error("Failed to generate recovery codes", zap.Error(err))
+		return response.RecoveryCodes{}, derror.NewInternalSystemError()
+	}
+
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, req.UserID, hashes); err != nil {
+		s.logger.Error("Failed to save the recovery codes", zap.Error(err), zap.Int("userID", req.UserID))
+		return response.RecoveryCodes{}, derror.NewInternalSystemError()
+	}
+
+	return response.RecoveryCodes{Codes: plain}, nil
+}
+
+func (s *Service) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get the two-factor authenticator", zap.Error(err), zap.Int("userID", userID))
+		return false, derror.NewInternalSystemError()
+	}
+
+	return twoFactor != nil && twoFactor.IsEnabled(), nil
+}
+
+func (s *Service) enabled(ctx context.Context, req request.TwoFactorCode) (*entity.TwoFactor, error) {
+	if err := req.Validate(); err != nil {
+		return nil, derror.NewBadRequestError(err.Error())
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, req.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get the two-factor authenticator", zap.Error(err), zap.Int("userID", req.UserID))
+		return nil, derror.NewInternalSystemError()
+	}
+
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		return nil, derror.NewConflictError("two-factor authentication is not on")
+	}
+
+	return twoFactor, nil
+}
+
+// checkCode accepts a current code from the authenticator or, when recovery is set, an
+// unused recovery code. Wrong codes count towards a lockout, since six digits are few
+// enough to guess through otherwise.
+func (s *Service) checkCode(ctx context.Context, twoFactor *entity.TwoFactor, code string, recovery bool) error {
+	failed, err := s.twoFactorRepo.CountFailedAttempts(ctx, twoFactor.UserID, time.Now().Add(-s.failureWindow()))
+	if err != nil {
+		s.logger.Error("Failed to count the wrong two-factor codes", zap.Error(err), zap.Int("userID", twoFactor.UserID))
+		return derror.NewInternalSystemError()
+	}
+
+	if failed >= s.maxFailedAttempts() {
+		s.logger.Warn("Two-factor codes locked out", zap.Int("userID", twoFactor.UserID), zap.Int("failedAttempts", failed))
+		return derror.NewError("too many wrong two-factor codes, try again later", http.StatusTooManyRequests)
+	}
+
+	accepted, err := s.acceptCode(ctx, twoFactor, code, recovery)
+	if err != nil {
+		s.logger.Error("Failed to check the two-factor code", zap.Error(err), zap.Int("userID", twoFactor.UserID))
+		return derror.NewInternalSystemError()
+	}
+
+	if !accepted {
+		if err := s.twoFactorRepo.RecordFailedAttempt(ctx, twoFactor.UserID); err != nil {
+			s.logger.Error("Failed to record the wrong two-factor code", zap.Error(err), zap.Int("userID", twoFactor.UserID))
+			return derror.NewInternalSystemError()
+		}
+
+		s.logger.Warn("Wrong two-factor code", zap.Int("userID", twoFactor.UserID), zap.Int("failedAttempts", failed+1))
+		return derror.NewUnauthorizedError("invalid two-factor code")
+	}
+
+	return nil
+}
+
+func (s *Service) acceptCode(ctx context.Context, twoFactor *entity.TwoFactor, code string, recovery bool) (bool, error) {
+	encoded, err := s.openSecret(twoFactor.UserID, twoFactor.SealedSecret)
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := secretEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	if step, ok := matchStep(secret, code, time.Now()); ok {
+		// A code that was already used is as good as a wrong one
+		return s.twoFactorRepo.UseStep(ctx, twoFactor.UserID, step)
+	}
+
+	if !recovery {
+		return false, nil
+	}
+
+	return s.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hashRecoveryCode(code), time.Now())
+}
+
+func (s *Service) issuer() string {
+	if s.cfg.Issuer == "" {
+		return defaultIssuer
+	}
+	return s.cfg.Issuer
+}
+
+func (s *Service) challengeTTL() time.Duration {
+	if s.cfg.ChallengeTTL <= 0 {
+		return defaultChallengeTTL
+	}
+	return s.cfg.ChallengeTTL
+}
+
+func (s *Service) maxFailedAttempts() int {
+	if s.cfg.MaxFailedAttempts <= 0 {
+		return defaultMaxFailedAttempts
+	}
+	return s.cfg.MaxFailedAttempts
+}
+
+func (s *Service) failureWindow() time.Duration {
+	if s.cfg.FailureWindow <= 0 {
+		return defaultFailureWindow
+	}
+	return s.cfg.FailureWindow
+}
+
+func (s *Service) recoveryCodes() int {
+	if s.cfg.RecoveryCodes <= 0 {
+		return defaultRecoveryCodes
+	}
+	return s.cfg.RecoveryCodes
+}
@@ -0,0 +1,375 @@
+package twofactor
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors, "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+var secretKey = []byte("fedcba9876543210fedcba9876543210")
+
+// sealedRFCSecret is rfcSecret as it is stored on user 3's row.
+var sealedRFCSecret = func() []byte {
+	sealed, err := (&Service{secretKey: secretKey}).sealSecret(3, rfcSecret)
+	if err != nil {
+		panic(err)
+	}
+	return sealed
+}()
+
+type mocks struct {
+	repo  *protocol.MockTwoFactorRepo
+	users *protocol.MockUserRepository
+}
+
+func setup(t *testing.T) (*Service, mocks) {
+	m := mocks{
+		repo:  new(protocol.MockTwoFactorRepo),
+		users: new(protocol.MockUserRepository),
+	}
+	m.repo.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	m.repo.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	m.repo.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	m.repo.On("CountFailedAttempts", mock.Anything, 3, mock.Anything).Return(0, nil).Maybe()
+	m.repo.On("RecordFailedAttempt", mock.Anything, 3).Return(nil).Maybe()
+
+	cfg := config.TwoFactor{
+		Issuer:       "Digital Wallet",
+		ChallengeKey: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		SecretKey:    base64.StdEncoding.EncodeToString(secretKey),
+	}
+
+	service, err := New(cfg, zap.NewNop().Sugar(), m.repo, m.users)
+	require.NoError(t, err)
+
+	return service, m
+}
+
+func enabled() *entity.TwoFactor {
+	enabledAt := time.Now().Add(-time.Hour)
+	return &entity.TwoFactor{UserID: 3, SealedSecret: sealedRFCSecret, EnabledAt: &enabledAt}
+}
+
+func currentCode(t *testing.T) string {
+	secret, err := secretEncoding.DecodeString(rfcSecret)
+	require.NoError(t, err)
+	return codeAt(secret, timeStep(time.Now()))
+}
+
+func TestCodeAt(t *testing.T) {
+	secret, err := secretEncoding.DecodeString(rfcSecret)
+	require.NoError(t, err)
+
+	// The last six digits of the eight digit SHA1 vectors in RFC 6238, appendix B
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, codeAt(secret, timeStep(time.Unix(tt.unix, 0))), "at %d", tt.unix)
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	secret, err := secretEncoding.DecodeString(rfcSecret)
+	require.NoError(t, err)
+
+	now := time.Unix(1234567890, 0)
+	current := timeStep(now)
+
+	step, ok := matchStep(secret, codeAt(secret, current-1), now)
+	assert.True(t, ok, "a code from the previous step is within the drift allowed")
+	assert.Equal(t, current-1, step)
+
+	_, ok = matchStep(secret, codeAt(secret, current-2), now)
+	assert.False(t, ok)
+
+	_, ok = matchStep(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestEnroll(t *testing.T) {
+	t.Run("new authenticator", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(nil, nil)
+		m.users.On("Get", mock.Anything, 3).Return(entity.User{ID: 3, Username: "sara"}, nil)
+
+		var stored *entity.TwoFactor
+		m.repo.On("Upsert", mock.Anything, mock.AnythingOfType("*entity.TwoFactor")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*entity.TwoFactor)
+		}).Return(nil)
+
+		res, err := service.Enroll(context.Background(), 3)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, stored.UserID)
+		assert.NotContains(t, string(stored.SealedSecret), res.Secret)
+		secret, err := service.openSecret(3, stored.SealedSecret)
+		require.NoError(t, err)
+		assert.Equal(t, secret, res.Secret)
+		assert.True(t, strings.HasPrefix(res.ProvisioningURI, "otpauth://totp/Digital%20Wallet:sara?"), res.ProvisioningURI)
+		assert.Contains(t, res.ProvisioningURI, "secret="+res.Secret)
+		assert.True(t, strings.HasPrefix(res.QRCode, "data:image/png;base64,"))
+	})
+
+	t.Run("pending authenticator gets a new secret", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(&entity.TwoFactor{UserID: 3, SealedSecret: sealedRFCSecret}, nil)
+		m.users.On("Get", mock.Anything, 3).Return(entity.User{ID: 3, Username: "sara"}, nil)
+		m.repo.On("Upsert", mock.Anything, mock.MatchedBy(func(twoFactor *entity.TwoFactor) bool {
+			secret, err := service.openSecret(3, twoFactor.SealedSecret)
+			return err == nil && secret != rfcSecret
+		})).Return(nil)
+
+		_, err := service.Enroll(context.Background(), 3)
+		require.NoError(t, err)
+		m.repo.AssertExpectations(t)
+	})
+
+	t.Run("already on", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(enabled(), nil)
+
+		_, err := service.Enroll(context.Background(), 3)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		m.repo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOpenSecret(t *testing.T) {
+	service, _ := setup(t)
+
+	secret, err := service.openSecret(3, sealedRFCSecret)
+	require.NoError(t, err)
+	assert.Equal(t, rfcSecret, secret)
+
+	_, err = service.openSecret(4, sealedRFCSecret)
+	assert.Error(t, err, "a secret sealed for one user does not open for another")
+}
+
+func TestConfirm(t *testing.T) {
+	t.Run("code from the app", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(&entity.TwoFactor{UserID: 3, SealedSecret: sealedRFCSecret}, nil)
+		m.repo.On("UseStep", mock.Anything, 3, timeStep(time.Now())).Return(true, nil)
+		m.repo.On("Enable", mock.Anything, 3, mock.Anything).Return(nil)
+		m.repo.On("ReplaceRecoveryCodes", mock.Anything, 3, mock.Anything).Return(nil)
+
+		res, err := service.Confirm(context.Background(), request.TwoFactorCode{UserID: 3, Code: currentCode(t)})
+		require.NoError(t, err)
+		require.Len(t, res.Codes, defaultRecoveryCodes)
+
+		hashes := m.repo.Calls[len(m.repo.Calls)-2].Arguments.Get(2).([][]byte)
+		assert.Equal(t, hashRecoveryCode(strings.ToLower(res.Codes[0])), hashes[0])
+		m.repo.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(&entity.TwoFactor{UserID: 3, SealedSecret: sealedRFCSecret}, nil)
+		m.repo.On("UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+
+		_, err := service.Confirm(context.Background(), request.TwoFactorCode{UserID: 3, Code: "ABCDE-FGHJK"})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+		m.repo.AssertCalled(t, "RecordFailedAttempt", mock.Anything, 3)
+		m.repo.AssertNotCalled(t, "UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		m.repo.AssertNotCalled(t, "Enable", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose enum.TwoFactorPurpose
+		binding string
+		expired bool
+		tamper  bool
+		code    func(t *testing.T) string
+		prepare func(m mocks)
+		status  int
+	}{
+		{
+			name:    "current code",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:500:USD",
+			code:    currentCode,
+			prepare: func(m mocks) {
+				m.repo.On("UseStep", mock.Anything, 3, mock.Anything).Return(true, nil)
+			},
+		},
+		{
+			name:    "another transfer",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:900:USD",
+			code:    currentCode,
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "another purpose",
+			purpose: enum.TwoFactorSignIn,
+			code:    currentCode,
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "expired challenge",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:500:USD",
+			expired: true,
+			code:    currentCode,
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "tampered challenge",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:500:USD",
+			tamper:  true,
+			code:    currentCode,
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "replayed code",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:500:USD",
+			code:    currentCode,
+			prepare: func(m mocks) {
+				m.repo.On("UseStep", mock.Anything, 3, mock.Anything).Return(false, nil)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "locked out",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:500:USD",
+			code:    currentCode,
+			prepare: func(m mocks) {
+				m.repo.ExpectedCalls = nil
+				m.repo.On("Get", mock.Anything, 3).Return(enabled(), nil)
+				m.repo.On("CountFailedAttempts", mock.Anything, 3, mock.Anything).Return(defaultMaxFailedAttempts, nil)
+			},
+			status: http.StatusTooManyRequests,
+		},
+		{
+			name:    "recovery code for a transfer",
+			purpose: enum.TwoFactorTransfer,
+			binding: "transfer:3:1:2:500:USD",
+			code:    func(*testing.T) string { return "ABCDE-FGHJK" },
+			status:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, m := setup(t)
+			m.repo.On("Get", mock.Anything, 3).Return(enabled(), nil).Maybe()
+			if tt.prepare != nil {
+				tt.prepare(m)
+			}
+
+			issued, err := service.Challenge(context.Background(), 3, enum.TwoFactorTransfer, "transfer:3:1:2:500:USD")
+			require.NoError(t, err)
+
+			token := issued.Challenge
+			if tt.expired {
+				token, err = service.sign(challenge{
+					Purpose:   enum.TwoFactorTransfer,
+					UserID:    3,
+					Binding:   "transfer:3:1:2:500:USD",
+					ExpiresAt: time.Now().Add(-time.Second).Unix(),
+				})
+				require.NoError(t, err)
+			}
+			if tt.tamper {
+				token = strings.Replace(token, ".", "x.", 1)
+			}
+
+			userID, err := service.Verify(context.Background(), request.TwoFactorAnswer{Challenge: token, Code: tt.code(t)}, tt.purpose, tt.binding)
+			if tt.status != 0 {
+				assert.True(t, derror.IsHTTPError(err, tt.status), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, 3, userID)
+		})
+	}
+}
+
+func TestVerifyRecoveryCodeAtSignIn(t *testing.T) {
+	service, m := setup(t)
+	m.repo.On("Get", mock.Anything, 3).Return(enabled(), nil)
+	m.repo.On("UseRecoveryCode", mock.Anything, 3, hashRecoveryCode("ABCDEFGHJK"), mock.Anything).Return(true, nil)
+
+	issued, err := service.Challenge(context.Background(), 3, enum.TwoFactorSignIn, "")
+	require.NoError(t, err)
+
+	userID, err := service.Verify(context.Background(), request.TwoFactorAnswer{Challenge: issued.Challenge, Code: "abcde-fghjk"}, enum.TwoFactorSignIn, "")
+	require.NoError(t, err)
+	assert.Equal(t, 3, userID)
+}
+
+func TestStepUp(t *testing.T) {
+	violations := []entity.PolicyViolation{{Rule: enum.PolicyRequire2FA}}
+	factor := entity.SecondFactor{UserID: 3, Binding: "card_transfer:3:1:2:500:USD"}
+
+	t.Run("challenge bound to the payment", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(enabled(), nil)
+		m.repo.On("UseStep", mock.Anything, 3, mock.Anything).Return(true, nil)
+
+		err := service.StepUp(context.Background(), factor, violations)
+		require.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+
+		issued, ok := err.(*derror.Error).Details.(response.TwoFactorChallenge)
+		require.True(t, ok, "got %v", err)
+
+		answer := &request.TwoFactorAnswer{Challenge: issued.Challenge, Code: currentCode(t)}
+		verified, err := service.VerifyStepUp(context.Background(), 3, factor.Binding, answer)
+		require.NoError(t, err)
+		assert.True(t, verified.Verified)
+
+		_, err = service.VerifyStepUp(context.Background(), 3, "card_transfer:3:1:2:900:USD", answer)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+	})
+
+	t.Run("no authenticator", func(t *testing.T) {
+		service, m := setup(t)
+		m.repo.On("Get", mock.Anything, 3).Return(nil, nil)
+
+		err := service.StepUp(context.Background(), factor, violations)
+		assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+	})
+
+	t.Run("no answer", func(t *testing.T) {
+		service, _ := setup(t)
+
+		verified, err := service.VerifyStepUp(context.Background(), 3, factor.Binding, nil)
+		require.NoError(t, err)
+		assert.False(t, verified.Verified)
+		assert.Equal(t, factor, verified)
+	})
+}
@@ -6,12 +6,12 @@ import (
 	"time"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror/message"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -40,12 +40,12 @@ func (s *Service) SignUp(ctx context.Context, req request.SignUp) (response.Sign
 		return response.SignUp{}, err
 	}
 
-	accessToken, err := s.generateJWTToken(ctx, user, s.cfg.AccessTokenExp)
-	if err != nil {
-		return response.SignUp{}, err
+	if err := s.roleRepo.Assign(ctx, user.ID, enum.RoleCustomer); err != nil {
+		s.logger.Errorw("service.user.SignUp.roleRepo.Assign", "error", err.Error())
+		return response.SignUp{}, derror.NewInternalSystemError()
 	}
 
-	refreshToken, err := s.generateJWTToken(ctx, user, s.cfg.RefreshTokenExp)
+	accessToken, refreshToken, err := s.startSession(ctx, user, req.Client)
 	if err != nil {
 		return response.SignUp{}, err
 	}
@@ -92,13 +92,37 @@ func (s *Service) getUserByUsername(ctx context.Context, username string) (entit
 	return user, nil
 }
 
-func (s *Service) generateJWTToken(ctx context.Context, user entity.User, exp time.Duration) (string, error) {
+// generateJWTToken signs the access token of the session, carrying the roles and permissions
+// the user holds now.
+func (s *Service) generateJWTToken(ctx context.Context, session *entity.Session) (string, error) {
+	roles, err := s.roleRepo.ListByUserID(ctx, session.UserID)
+	if err != nil {
+		s.logger.Errorw("service.user.generateJWTToken.roleRepo.ListByUserID", "error", err.Error())
+		return "", derror.NewInternalSystemError()
+	}
+
+	permissions, err := s.roleRepo.ListPermissionsByUserID(ctx, session.UserID)
+	if err != nil {
+		s.logger.Errorw("service.user.generateJWTToken.roleRepo.ListPermissionsByUserID", "error", err.Error())
+		return "", derror.NewInternalSystemError()
+	}
+
+	admin := false
+	for _, role := range roles {
+		if role == enum.RoleSuperadmin {
+			admin = true
+		}
+	}
+
 	claims := entity.JWTClaims{
-		UserID: user.ID,
-		Admin:  false,
+		UserID:      session.UserID,
+		Admin:       admin,
+		Roles:       roles,
+		Permissions: permissions,
+		SessionID:   session.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        uuid.New().String(),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
+			ID:        session.AccessTokenID,
+			ExpiresAt: jwt.NewNumericDate(session.AccessExpiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -116,12 +140,42 @@ func (s *Service) SignIn(ctx context.Context, req request.SignIn) (response.Sign
 		return response.SignIn{}, err
 	}
 
-	accessToken, err := s.generateUserAccessToken(ctx, user)
+	// With two-factor authentication on, the password only earns a challenge; the tokens
+	// come from CompleteSignIn once it is answered.
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
 	if err != nil {
 		return response.SignIn{}, err
 	}
 
-	refreshToken, err := s.generateUserRefreshToken(ctx, user)
+	if enabled {
+		challenge, err := s.twoFactor.Challenge(ctx, user.ID, enum.TwoFactorSignIn, "")
+		if err != nil {
+			return response.SignIn{}, err
+		}
+		return response.SignIn{TwoFactor: &challenge}, nil
+	}
+
+	accessToken, refreshToken, err := s.startSession(ctx, user, req.Client)
+	if err != nil {
+		return response.SignIn{}, err
+	}
+
+	return response.SignIn{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *Service) CompleteSignIn(ctx context.Context, req request.SignInTwoFactor) (response.SignIn, error) {
+	userID, err := s.twoFactor.Verify(ctx, req.TwoFactorAnswer, enum.TwoFactorSignIn, "")
+	if err != nil {
+		return response.SignIn{}, err
+	}
+
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("service.user.CompleteSignIn.userRepo.Get", "error", err.Error())
+		return response.SignIn{}, derror.NewInternalSystemError()
+	}
+
+	accessToken, refreshToken, err := s.startSession(ctx, user, req.Client)
 	if err != nil {
 		return response.SignIn{}, err
 	}
@@ -151,37 +205,3 @@ func (s *Service) validateUserCredentials(ctx context.Context, username, passwor
 	}
 	return user, nil
 }
-
-func (s *Service) generateUserAccessToken(ctx context.Context, user entity.User) (string, error) {
-	return s.generateJWTToken(ctx, user, s.cfg.AccessTokenExp)
-}
-
-func (s *Service) generateUserRefreshToken(ctx context.Context, user entity.User) (string, error) {
-	return s.generateJWTToken(ctx, user, s.cfg.RefreshTokenExp)
-}
-
-func (s *Service) RefreshToken(ctx context.Context, userID int) (response.RefreshToken, error) {
-
-	user, err := s.getUserByID(ctx, userID)
-	if err != nil {
-		return response.RefreshToken{}, err
-	}
-
-	accessToken, err := s.generateUserAccessToken(ctx, user)
-	if err != nil {
-		return response.RefreshToken{}, err
-	}
-
-	return response.RefreshToken{AccessToken: accessToken}, nil
-
-}
-
-// A new function to retrieve user by ID
-func (s *Service) getUserByID(ctx context.Context, userID int) (entity.User, error) {
-	user, err := s.userRepo.Get(ctx, userID)
-	if err != nil {
-		s.logger.Errorw("service.user.getUserByID", "error", err.Error())
-		return entity.User{}, derror.NewInternalSystemError()
-	}
-	return user, nil
-}
@@ -10,14 +10,17 @@ import (
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/config"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror/message"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -39,12 +42,30 @@ func setup() (*Service, *protocol.MockUserRepository, *protocol.MockHasher) {
 	logger, _ := zap.NewProduction()
 	sugaredLogger := logger.Sugar()
 
+	roleRepo := new(protocol.MockRoleRepo)
+	roleRepo.On("Assign", mock.Anything, mock.Anything, enum.RoleCustomer).Return(nil).Maybe()
+	roleRepo.On("ListByUserID", mock.Anything, mock.Anything).Return([]enum.Role{enum.RoleCustomer}, nil).Maybe()
+	roleRepo.On("ListPermissionsByUserID", mock.Anything, mock.Anything).Return([]enum.Permission{enum.PermissionProfile}, nil).Maybe()
+
+	sessionRepo := new(protocol.MockSessionRepo)
+	sessionRepo.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	sessionRepo.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	sessionRepo.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	sessionRepo.On("Insert", mock.Anything, mock.Anything).Return(nil).Maybe()
+	sessionRepo.On("InsertRefreshToken", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	twoFactor := new(protocol.MockTwoFactorService)
+	twoFactor.On("IsEnabled", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+
 	service := &Service{
-		userRepo: mockRepo,
-		hasher:   mockHasher,
-		tokenGen: &tokenGen,
-		cfg:      cfg,
-		logger:   sugaredLogger,
+		userRepo:    mockRepo,
+		roleRepo:    roleRepo,
+		sessionRepo: sessionRepo,
+		twoFactor:   twoFactor,
+		hasher:      mockHasher,
+		tokenGen:    &tokenGen,
+		cfg:         cfg,
+		logger:      sugaredLogger,
 	}
 	return service, mockRepo, mockHasher
 }
@@ -114,11 +135,9 @@ func TestService_SignUp(t *testing.T) {
 func TestGenerateJWTToken(t *testing.T) {
 	service, _, _ := setup()
 
-	user := entity.User{
-		ID: 1,
-	}
+	session := &entity.Session{SessionID: 3, UserID: 1, AccessTokenID: "jti", AccessExpiresAt: time.Now().Add(time.Minute * 15)}
 
-	token, err := service.generateJWTToken(context.Background(), user, time.Minute*15)
+	token, err := service.generateJWTToken(context.Background(), session)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 }
@@ -279,26 +298,41 @@ func TestValidateUserCredentials(t *testing.T) {
 
 }
 
-func TestGenerateUserAccessToken(t *testing.T) {
-	service, _, _ := setup()
-
-	user := entity.User{
-		Username: "testUser",
-	}
-
-	token, err := service.generateUserAccessToken(context.Background(), user)
-	assert.Nil(t, err)
-	assert.NotEmpty(t, token)
-}
-
-func TestGenerateUserRefreshToken(t *testing.T) {
-	service, _, _ := setup()
-
-	user := entity.User{
-		Username: "testUser",
-	}
-
-	token, err := service.generateUserRefreshToken(context.Background(), user)
-	assert.Nil(t, err)
-	assert.NotEmpty(t, token)
+func TestSignInWithTwoFactor(t *testing.T) {
+	service, mockRepo, mockHasher := setup()
+	user := entity.User{ID: 4, Username: "sara", Password: "hashed"}
+	mockRepo.On("GetByUsername", mock.Anything, "sara").Return(user, nil)
+	mockRepo.On("Get", mock.Anything, 4).Return(user, nil)
+	mockHasher.On("CompareHashAndPassword", []byte("hashed"), []byte("secret")).Return(nil)
+
+	sessions := new(protocol.MockSessionRepo)
+	sessions.On("BeginTx", mock.Anything).Return(context.Background(), nil)
+	sessions.On("CommitTx", mock.Anything).Return(nil)
+	sessions.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	sessions.On("Insert", mock.Anything, mock.Anything).Return(nil)
+	sessions.On("InsertRefreshToken", mock.Anything, mock.Anything).Return(nil)
+	service.sessionRepo = sessions
+
+	challenge := response.TwoFactorChallenge{Challenge: "challenge", Purpose: enum.TwoFactorSignIn}
+	answer := request.TwoFactorAnswer{Challenge: "challenge", Code: "123456"}
+	twoFactor := new(protocol.MockTwoFactorService)
+	twoFactor.On("IsEnabled", mock.Anything, 4).Return(true, nil)
+	twoFactor.On("Challenge", mock.Anything, 4, enum.TwoFactorSignIn, "").Return(challenge, nil)
+	twoFactor.On("Verify", mock.Anything, answer, enum.TwoFactorSignIn, "").Return(4, nil)
+	service.twoFactor = twoFactor
+
+	// The password alone earns a challenge and no session
+	res, err := service.SignIn(context.Background(), request.SignIn{Username: "sara", Password: "secret"})
+	require.NoError(t, err)
+	assert.Empty(t, res.AccessToken)
+	assert.Empty(t, res.RefreshToken)
+	assert.Equal(t, &challenge, res.TwoFactor)
+	sessions.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+
+	res, err = service.CompleteSignIn(context.Background(), request.SignInTwoFactor{TwoFactorAnswer: answer})
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NotEmpty(t, res.RefreshToken)
+	assert.Nil(t, res.TwoFactor)
+	sessions.AssertCalled(t, "Insert", mock.Anything, mock.Anything)
 }
@@ -65,12 +65,13 @@ func (s *Service) EditProfile(ctx context.Context, req request.EditProfile) erro
 		user.LastName = req.LastName
 	}
 
-	if req.Email != "" {
+	// A new address has to be verified again; sending the current one back changes nothing
+	if req.Email != "" && req.Email != user.Email {
 		user.Email = req.Email
 		user.ValidatedEmail = false
 	}
 
-	if req.Cellphone != "" {
+	if req.Cellphone != "" && req.Cellphone != user.Cellphone {
 		user.Cellphone = req.Cellphone
 		user.ValidatedCellphone = false
 	}
@@ -94,3 +94,30 @@ func TestEditProfile(t *testing.T) {
 	// Assert any other expectations as needed
 	// ...
 }
+
+func TestEditProfileKeepsVerifiedContacts(t *testing.T) {
+	ctx := context.TODO()
+	service, mockRepo, _ := setup()
+
+	mockRepo.On("Get", ctx, 42).Return(entity.User{
+		ID:                 42,
+		Email:              "johndoe@example.com",
+		ValidatedEmail:     true,
+		Cellphone:          "09121234567",
+		ValidatedCellphone: true,
+	}, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("entity.User")).Return(nil)
+
+	// The email is sent unchanged, only the cellphone is replaced
+	err := service.EditProfile(ctx, request.EditProfile{
+		UserID:    42,
+		Email:     "johndoe@example.com",
+		Cellphone: "09127654321",
+	})
+	assert.NoError(t, err)
+
+	updated := mockRepo.Calls[1].Arguments.Get(1).(entity.User)
+	assert.True(t, updated.ValidatedEmail)
+	assert.False(t, updated.ValidatedCellphone)
+	assert.Equal(t, "09127654321", updated.Cellphone)
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"context"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+)
+
+func (s *Service) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		s.logger.Errorw("service.user.ListRoles.roleRepo.List", "error", err.Error())
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return roles, nil
+}
+
+func (s *Service) ListUserRoles(ctx context.Context, userID int) ([]enum.Role, error) {
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("service.user.ListUserRoles.roleRepo.ListByUserID", "error", err.Error())
+		return nil, derror.NewInternalSystemError()
+	}
+
+	return roles, nil
+}
+
+// AssignRole grants a role to a user. It takes effect once the user's token is refreshed.
+func (s *Service) AssignRole(ctx context.Context, req request.UserRole) error {
+	if err := req.Validate(); err != nil {
+		return derror.NewBadRequestError(err.Error())
+	}
+
+	if err := s.ensureRoleExists(ctx, req.Role); err != nil {
+		return err
+	}
+
+	if err := s.ensureUserExists(ctx, req.UserID); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Assign(ctx, req.UserID, req.Role); err != nil {
+		s.logger.Errorw("service.user.AssignRole.roleRepo.Assign", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	s.logger.Infow("Role assigned", "actorID", req.ActorID, "userID", req.UserID, "role", req.Role)
+
+	return nil
+}
+
+// RevokeRole takes a role away from a user. Superadmins cannot drop their own role, so the
+// last of them cannot lock everyone out of role management.
+func (s *Service) RevokeRole(ctx context.Context, req request.UserRole) error {
+	if err := req.Validate(); err != nil {
+		return derror.NewBadRequestError(err.Error())
+	}
+
+	if req.UserID == req.ActorID && req.Role == enum.RoleSuperadmin {
+		return derror.NewValidationError("superadmins cannot revoke their own role")
+	}
+
+	revoked, err := s.roleRepo.Revoke(ctx, req.UserID, req.Role)
+	if err != nil {
+		s.logger.Errorw("service.user.RevokeRole.roleRepo.Revoke", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if !revoked {
+		return derror.NewNotFoundError("user %d does not hold the role %s", req.UserID, req.Role)
+	}
+
+	s.logger.Infow("Role revoked", "actorID", req.ActorID, "userID", req.UserID, "role", req.Role)
+
+	return nil
+}
+
+func (s *Service) HasPermission(ctx context.Context, userID int, permission enum.Permission) (bool, error) {
+	has, err := s.roleRepo.HasPermission(ctx, userID, permission)
+	if err != nil {
+		s.logger.Errorw("service.user.HasPermission.roleRepo.HasPermission", "error", err.Error())
+		return false, derror.NewInternalSystemError()
+	}
+
+	return has, nil
+}
+
+func (s *Service) ensureRoleExists(ctx context.Context, role enum.Role) error {
+	exists, err := s.roleRepo.Exists(ctx, role)
+	if err != nil {
+		s.logger.Errorw("service.user.ensureRoleExists.roleRepo.Exists", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if !exists {
+		return derror.NewNotFoundError("role %s not found", role)
+	}
+
+	return nil
+}
+
+func (s *Service) ensureUserExists(ctx context.Context, userID int) error {
+	exists, err := s.userRepo.IsExist(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("service.user.ensureUserExists.userRepo.IsExist", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if !exists {
+		return derror.NewNotFoundError("user %d not found", userID)
+	}
+
+	return nil
+}
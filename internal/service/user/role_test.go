@@ -0,0 +1,104 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Role granted", func(t *testing.T) {
+		service, userRepo, _ := setup()
+		roles := service.roleRepo.(*protocol.MockRoleRepo)
+		roles.On("Exists", ctx, enum.RoleCompliance).Return(true, nil)
+		roles.On("Assign", ctx, 7, enum.RoleCompliance).Return(nil)
+		userRepo.On("IsExist", ctx, 7).Return(true, nil)
+
+		err := service.AssignRole(ctx, request.UserRole{ActorID: 1, UserID: 7, Role: enum.RoleCompliance})
+		require.NoError(t, err)
+		roles.AssertCalled(t, "Assign", ctx, 7, enum.RoleCompliance)
+	})
+
+	t.Run("Unknown role", func(t *testing.T) {
+		service, _, _ := setup()
+		roles := service.roleRepo.(*protocol.MockRoleRepo)
+		roles.On("Exists", ctx, enum.Role("auditor")).Return(false, nil)
+
+		err := service.AssignRole(ctx, request.UserRole{ActorID: 1, UserID: 7, Role: "auditor"})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		service, userRepo, _ := setup()
+		roles := service.roleRepo.(*protocol.MockRoleRepo)
+		roles.On("Exists", ctx, enum.RoleSupport).Return(true, nil)
+		userRepo.On("IsExist", ctx, 70).Return(false, nil)
+
+		err := service.AssignRole(ctx, request.UserRole{ActorID: 1, UserID: 70, Role: enum.RoleSupport})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+		roles.AssertNotCalled(t, "Assign", mock.Anything, 70, mock.Anything)
+	})
+}
+
+func TestRevokeRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Role taken away", func(t *testing.T) {
+		service, _, _ := setup()
+		roles := service.roleRepo.(*protocol.MockRoleRepo)
+		roles.On("Revoke", ctx, 7, enum.RoleSupport).Return(true, nil)
+
+		require.NoError(t, service.RevokeRole(ctx, request.UserRole{ActorID: 1, UserID: 7, Role: enum.RoleSupport}))
+	})
+
+	t.Run("Role not held", func(t *testing.T) {
+		service, _, _ := setup()
+		roles := service.roleRepo.(*protocol.MockRoleRepo)
+		roles.On("Revoke", ctx, 7, enum.RoleTreasury).Return(false, nil)
+
+		err := service.RevokeRole(ctx, request.UserRole{ActorID: 1, UserID: 7, Role: enum.RoleTreasury})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+	})
+
+	t.Run("Superadmin cannot drop their own role", func(t *testing.T) {
+		service, _, _ := setup()
+		roles := service.roleRepo.(*protocol.MockRoleRepo)
+
+		err := service.RevokeRole(ctx, request.UserRole{ActorID: 1, UserID: 1, Role: enum.RoleSuperadmin})
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		roles.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTokenCarriesRoles(t *testing.T) {
+	service, _, _ := setup()
+	roles := new(protocol.MockRoleRepo)
+	roles.On("ListByUserID", mock.Anything, 1).Return([]enum.Role{enum.RoleCustomer, enum.RoleSuperadmin}, nil)
+	roles.On("ListPermissionsByUserID", mock.Anything, 1).Return([]enum.Permission{enum.PermissionProfile, enum.PermissionRolesManage}, nil)
+	service.roleRepo = roles
+
+	token, err := service.generateJWTToken(context.Background(), &entity.Session{SessionID: 2, UserID: 1, AccessExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+
+	claims := &entity.JWTClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+	assert.True(t, claims.Admin)
+	assert.Equal(t, []enum.Role{enum.RoleCustomer, enum.RoleSuperadmin}, claims.Roles)
+	assert.True(t, claims.Can(enum.PermissionRolesManage))
+	assert.False(t, claims.Can(enum.PermissionCardsSettle))
+}
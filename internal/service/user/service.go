@@ -7,19 +7,43 @@ import (
 )
 
 type Service struct {
-	cfg      config.JWT
-	logger   *zap.SugaredLogger
-	userRepo protocol.UserRepository
-	hasher   protocol.Hasher
-	tokenGen protocol.TokenGenerator
+	cfg              config.JWT
+	verificationCfg  config.Verification
+	logger           *zap.SugaredLogger
+	userRepo         protocol.UserRepository
+	roleRepo         protocol.RoleRepository
+	sessionRepo      protocol.SessionRepository
+	verificationRepo protocol.VerificationCodeRepository
+	twoFactor        protocol.TwoFactor
+	hasher           protocol.Hasher
+	tokenGen         protocol.TokenGenerator
+	notifier         protocol.Notifier
 }
 
-func New(cfg config.JWT, logger *zap.SugaredLogger, userRepo protocol.UserRepository, hasher protocol.Hasher, tokenGen protocol.TokenGenerator) *Service {
+func New(
+	cfg config.JWT,
+	verificationCfg config.Verification,
+	logger *zap.SugaredLogger,
+	userRepo protocol.UserRepository,
+	roleRepo protocol.RoleRepository,
+	sessionRepo protocol.SessionRepository,
+	verificationRepo protocol.VerificationCodeRepository,
+	twoFactor protocol.TwoFactor,
+	hasher protocol.Hasher,
+	tokenGen protocol.TokenGenerator,
+	notifier protocol.Notifier,
+) *Service {
 	return &Service{
-		cfg:      cfg,
-		logger:   logger,
-		userRepo: userRepo,
-		hasher:   hasher,
-		tokenGen: tokenGen,
+		cfg:              cfg,
+		verificationCfg:  verificationCfg,
+		logger:           logger,
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		sessionRepo:      sessionRepo,
+		verificationRepo: verificationRepo,
+		twoFactor:        twoFactor,
+		hasher:           hasher,
+		tokenGen:         tokenGen,
+		notifier:         notifier,
 	}
 }
@@ -0,0 +1,406 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror/message"
+	"github.com/google/uuid"
+)
+
+const (
+	// refreshTokenBytes is the entropy of a refresh token, 256 bits.
+	refreshTokenBytes = 32
+
+	defaultDenylistPurgeInterval = time.Hour
+)
+
+// startSession opens a session for a user who just signed in or up and issues its first
+// pair of tokens.
+func (s *Service) startSession(ctx context.Context, user entity.User, client request.Client) (accessToken, refreshToken string, err error) {
+	ctx, err = s.sessionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Errorw("service.user.startSession.sessionRepo.BeginTx", "error", err.Error())
+		return "", "", derror.NewInternalSystemError()
+	}
+
+	defer func() {
+		if err != nil {
+			s.sessionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	now := time.Now()
+	session := &entity.Session{
+		UserID:    user.ID,
+		Device:    client.Device,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		ExpiresAt: now.Add(s.cfg.RefreshTokenExp),
+	}
+	s.renewAccessTokenID(session, now)
+
+	if err = s.sessionRepo.Insert(ctx, session); err != nil {
+		s.logger.Errorw("service.user.startSession.sessionRepo.Insert", "error", err.Error())
+		return "", "", derror.NewInternalSystemError()
+	}
+
+	accessToken, refreshToken, err = s.issueTokens(ctx, session)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = s.sessionRepo.CommitTx(ctx); err != nil {
+		s.logger.Errorw("service.user.startSession.sessionRepo.CommitTx", "error", err.Error())
+		return "", "", derror.NewInternalSystemError()
+	}
+
+	s.logger.Infow("Session started", "userID", user.ID, "sessionID", session.SessionID, "device", session.Device)
+
+	return accessToken, refreshToken, nil
+}
+
+// RefreshToken spends a refresh token for a new access and refresh token of the same session.
+// The previous access token is denied, so a session has one live access token at a time. A
+// refresh token that was already spent means the family leaked, and the whole session is
+// revoked.
+func (s *Service) RefreshToken(ctx context.Context, req request.RefreshToken) (res response.RefreshToken, err error) {
+	if err := req.Validate(); err != nil {
+		return res, err
+	}
+
+	ctx, err = s.sessionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.BeginTx", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	defer func() {
+		if err != nil {
+			s.sessionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	tokenHash := hashRefreshToken(req.RefreshToken)
+	token, err := s.sessionRepo.GetRefreshTokenForUpdate(ctx, tokenHash)
+	if err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.GetRefreshTokenForUpdate", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	if token == nil {
+		return res, derror.NewUnauthorizedError(message.InvalidToken)
+	}
+
+	session, err := s.sessionRepo.GetForUpdate(ctx, token.SessionID)
+	if err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.GetForUpdate", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	now := time.Now()
+	if session == nil || !session.IsActive(now) {
+		return res, derror.NewUnauthorizedError(message.InvalidToken)
+	}
+
+	if token.UsedAt != nil {
+		s.logger.Warnw("Spent refresh token reused, revoking the session",
+			"userID", session.UserID, "sessionID", session.SessionID, "ipAddress", req.IPAddress)
+
+		if err = s.endSession(ctx, session, enum.SessionTokenReused, now); err != nil {
+			return res, err
+		}
+
+		// The revocation must outlive the refused request, so it is committed first; the
+		// deferred rollback then finds the transaction done and does nothing.
+		if err = s.sessionRepo.CommitTx(ctx); err != nil {
+			s.logger.Errorw("service.user.RefreshToken.sessionRepo.CommitTx", "error", err.Error())
+			return res, derror.NewInternalSystemError()
+		}
+
+		return res, derror.NewUnauthorizedError(message.InvalidToken)
+	}
+
+	if err = s.sessionRepo.SpendRefreshToken(ctx, tokenHash, now); err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.SpendRefreshToken", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	if err = s.sessionRepo.DenyToken(ctx, session.AccessTokenID, session.AccessExpiresAt); err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.DenyToken", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	s.renewAccessTokenID(session, now)
+	session.IPAddress = req.IPAddress
+	session.UserAgent = req.UserAgent
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(s.cfg.RefreshTokenExp)
+
+	if err = s.sessionRepo.Update(ctx, session); err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.Update", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	res.AccessToken, res.RefreshToken, err = s.issueTokens(ctx, session)
+	if err != nil {
+		return res, err
+	}
+
+	if err = s.sessionRepo.CommitTx(ctx); err != nil {
+		s.logger.Errorw("service.user.RefreshToken.sessionRepo.CommitTx", "error", err.Error())
+		return res, derror.NewInternalSystemError()
+	}
+
+	return res, nil
+}
+
+// ListSessions returns the active sessions of the user, marking the one currentSessionID
+// names.
+func (s *Service) ListSessions(ctx context.Context, userID, currentSessionID int) ([]*response.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		s.logger.Errorw("service.user.ListSessions.sessionRepo.ListActiveByUserID", "error", err.Error())
+		return nil, derror.NewInternalSystemError()
+	}
+
+	res := make([]*response.Session, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, &response.Session{
+			SessionID:  session.SessionID,
+			Device:     session.Device,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.SessionID == currentSessionID,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeSession signs the user out of one session. Revoking a session that already ended
+// does nothing.
+func (s *Service) RevokeSession(ctx context.Context, req request.RevokeSession) (err error) {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	ctx, err = s.sessionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Errorw("service.user.RevokeSession.sessionRepo.BeginTx", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	defer func() {
+		if err != nil {
+			s.sessionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	session, err := s.sessionRepo.GetForUpdate(ctx, req.SessionID)
+	if err != nil {
+		s.logger.Errorw("service.user.RevokeSession.sessionRepo.GetForUpdate", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	// Another user's session is reported missing rather than forbidden, so session IDs
+	// cannot be probed.
+	if session == nil || session.UserID != req.UserID {
+		return derror.NewNotFoundError("session %d not found", req.SessionID)
+	}
+
+	now := time.Now()
+	if session.IsActive(now) {
+		if err = s.endSession(ctx, session, enum.SessionSignedOut, now); err != nil {
+			return err
+		}
+	}
+
+	if err = s.sessionRepo.CommitTx(ctx); err != nil {
+		s.logger.Errorw("service.user.RevokeSession.sessionRepo.CommitTx", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	s.logger.Infow("Session revoked", "userID", req.UserID, "sessionID", req.SessionID)
+
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere, including the session making the request.
+func (s *Service) RevokeAllSessions(ctx context.Context, userID int) (err error) {
+	ctx, err = s.sessionRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Errorw("service.user.RevokeAllSessions.sessionRepo.BeginTx", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	defer func() {
+		if err != nil {
+			s.sessionRepo.RollbackTx(ctx)
+		}
+	}()
+
+	now := time.Now()
+	sessions, err := s.sessionRepo.ListActiveByUserID(ctx, userID, now)
+	if err != nil {
+		s.logger.Errorw("service.user.RevokeAllSessions.sessionRepo.ListActiveByUserID", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	for _, listed := range sessions {
+		// The lock makes a refresh racing with the revocation either finish first, and have
+		// its new access token denied here, or find the session revoked.
+		session, err := s.sessionRepo.GetForUpdate(ctx, listed.SessionID)
+		if err != nil {
+			s.logger.Errorw("service.user.RevokeAllSessions.sessionRepo.GetForUpdate", "error", err.Error())
+			return derror.NewInternalSystemError()
+		}
+
+		if session == nil || !session.IsActive(now) {
+			continue
+		}
+
+		if err := s.endSession(ctx, session, enum.SessionSignedOut, now); err != nil {
+			return err
+		}
+	}
+
+	if err = s.sessionRepo.CommitTx(ctx); err != nil {
+		s.logger.Errorw("service.user.RevokeAllSessions.sessionRepo.CommitTx", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	s.logger.Infow("All sessions revoked", "userID", userID, "sessions", len(sessions))
+
+	return nil
+}
+
+// IsTokenRevoked reports whether the access token with the given jti was denied.
+func (s *Service) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	denied, err := s.sessionRepo.IsTokenDenied(ctx, jti)
+	if err != nil {
+		s.logger.Errorw("service.user.IsTokenRevoked.sessionRepo.IsTokenDenied", "error", err.Error())
+		return false, derror.NewInternalSystemError()
+	}
+
+	return denied, nil
+}
+
+// RunDenylistPurge deletes the expired tokens from the denylist every purge interval until
+// ctx is cancelled. An expired token is refused by its signature check alone.
+func (s *Service) RunDenylistPurge(ctx context.Context) error {
+	s.logger.Infow("Token denylist purge started", "purgeInterval", s.denylistPurgeInterval())
+	defer s.logger.Infow("Token denylist purge stopped")
+
+	ticker := time.NewTicker(s.denylistPurgeInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDeniedTokens(context.Background(), time.Now()); err != nil {
+			s.logger.Errorw("Failed to purge the token denylist", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDeniedTokens deletes the denied tokens that expired before now.
+func (s *Service) PurgeDeniedTokens(ctx context.Context, now time.Time) (int, error) {
+	purged, err := s.sessionRepo.PurgeDeniedTokens(ctx, now)
+	if err != nil {
+		s.logger.Errorw("service.user.PurgeDeniedTokens.sessionRepo.PurgeDeniedTokens", "error", err.Error())
+		return 0, derror.NewInternalSystemError()
+	}
+
+	if purged > 0 {
+		s.logger.Infow("Expired tokens purged from the denylist", "purged", purged)
+	}
+
+	return purged, nil
+}
+
+func (s *Service) denylistPurgeInterval() time.Duration {
+	if s.cfg.DenylistPurgeInterval <= 0 {
+		return defaultDenylistPurgeInterval
+	}
+	return s.cfg.DenylistPurgeInterval
+}
+
+// endSession revokes the session and denies its live access token. Its refresh tokens are
+// refused from then on because the session is no longer active.
+func (s *Service) endSession(ctx context.Context, session *entity.Session, reason enum.SessionRevocation, now time.Time) error {
+	session.RevokedAt = &now
+	session.RevokeReason = &reason
+
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		s.logger.Errorw("service.user.endSession.sessionRepo.Update", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if err := s.sessionRepo.DenyToken(ctx, session.AccessTokenID, session.AccessExpiresAt); err != nil {
+		s.logger.Errorw("service.user.endSession.sessionRepo.DenyToken", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	return nil
+}
+
+// issueTokens signs the session's access token and stores a new refresh token for it.
+func (s *Service) issueTokens(ctx context.Context, session *entity.Session) (accessToken, refreshToken string, err error) {
+	accessToken, err = s.generateJWTToken(ctx, session)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err = newRefreshToken()
+	if err != nil {
+		s.logger.Errorw("service.user.issueTokens.newRefreshToken", "error", err.Error())
+		return "", "", derror.NewInternalSystemError()
+	}
+
+	token := &entity.RefreshToken{TokenHash: hashRefreshToken(refreshToken), SessionID: session.SessionID}
+	if err := s.sessionRepo.InsertRefreshToken(ctx, token); err != nil {
+		s.logger.Errorw("service.user.issueTokens.sessionRepo.InsertRefreshToken", "error", err.Error())
+		return "", "", derror.NewInternalSystemError()
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (s *Service) renewAccessTokenID(session *entity.Session, now time.Time) {
+	session.AccessTokenID = uuid.New().String()
+	session.AccessExpiresAt = now.Add(s.cfg.AccessTokenExp)
+}
+
+// newRefreshToken returns an opaque refresh token. Unlike an access token it carries no
+// claims; it only means something to the session it is stored for.
+func newRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashRefreshToken gives the form a refresh token is stored and looked up in, so a copy of
+// the table cannot be replayed.
+func hashRefreshToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
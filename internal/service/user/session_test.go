@@ -0,0 +1,213 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSessions gives the service a session repository of its own whose transactions always
+// begin, commit and roll back.
+func setupSessions() (*Service, *protocol.MockSessionRepo) {
+	service, _, _ := setup()
+
+	sessions := new(protocol.MockSessionRepo)
+	sessions.On("BeginTx", mock.Anything).Return(context.Background(), nil)
+	sessions.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	sessions.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	service.sessionRepo = sessions
+
+	return service, sessions
+}
+
+func activeSession(userID int) *entity.Session {
+	return &entity.Session{
+		SessionID:       7,
+		UserID:          userID,
+		Device:          "phone",
+		AccessTokenID:   "old-jti",
+		AccessExpiresAt: time.Now().Add(time.Minute),
+		ExpiresAt:       time.Now().Add(time.Hour),
+	}
+}
+
+func TestStartSession(t *testing.T) {
+	service, sessions := setupSessions()
+
+	var saved *entity.Session
+	sessions.On("Insert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*entity.Session)
+		saved.SessionID = 12
+	}).Return(nil)
+	sessions.On("InsertRefreshToken", mock.Anything, mock.MatchedBy(func(token *entity.RefreshToken) bool {
+		return token.SessionID == 12
+	})).Return(nil)
+
+	client := request.Client{Device: "laptop", IPAddress: "10.0.0.1", UserAgent: "curl/8.0"}
+	accessToken, refreshToken, err := service.startSession(context.Background(), entity.User{ID: 4}, client)
+	require.NoError(t, err)
+
+	assert.Equal(t, "laptop", saved.Device)
+	assert.Equal(t, "10.0.0.1", saved.IPAddress)
+	assert.Equal(t, "curl/8.0", saved.UserAgent)
+
+	claims := &entity.JWTClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(accessToken, claims)
+	require.NoError(t, err)
+	assert.Equal(t, 12, claims.SessionID)
+	assert.Equal(t, saved.AccessTokenID, claims.ID)
+
+	// The refresh token is opaque and only its hash is stored
+	assert.NotEmpty(t, refreshToken)
+	sessions.AssertCalled(t, "InsertRefreshToken", mock.Anything, &entity.RefreshToken{TokenHash: hashRefreshToken(refreshToken), SessionID: 12})
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	service, sessions := setupSessions()
+	session := activeSession(4)
+	hash := hashRefreshToken("current")
+
+	sessions.On("GetRefreshTokenForUpdate", mock.Anything, hash).Return(&entity.RefreshToken{TokenHash: hash, SessionID: 7}, nil)
+	sessions.On("GetForUpdate", mock.Anything, 7).Return(session, nil)
+	sessions.On("SpendRefreshToken", mock.Anything, hash, mock.Anything).Return(nil)
+	sessions.On("DenyToken", mock.Anything, "old-jti", session.AccessExpiresAt).Return(nil)
+	sessions.On("Update", mock.Anything, session).Return(nil)
+	sessions.On("InsertRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+	res, err := service.RefreshToken(context.Background(), request.RefreshToken{
+		RefreshToken: "current",
+		Client:       request.Client{IPAddress: "10.0.0.2", UserAgent: "app/2"},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, "current", res.RefreshToken)
+
+	claims := &entity.JWTClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(res.AccessToken, claims)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.SessionID)
+	assert.NotEqual(t, "old-jti", claims.ID)
+	assert.Equal(t, session.AccessTokenID, claims.ID)
+	assert.Equal(t, "10.0.0.2", session.IPAddress)
+	assert.Nil(t, session.RevokedAt)
+
+	sessions.AssertCalled(t, "InsertRefreshToken", mock.Anything, &entity.RefreshToken{TokenHash: hashRefreshToken(res.RefreshToken), SessionID: 7})
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	service, sessions := setupSessions()
+	session := activeSession(4)
+	hash := hashRefreshToken("spent")
+	usedAt := time.Now().Add(-time.Minute)
+
+	sessions.On("GetRefreshTokenForUpdate", mock.Anything, hash).Return(&entity.RefreshToken{TokenHash: hash, SessionID: 7, UsedAt: &usedAt}, nil)
+	sessions.On("GetForUpdate", mock.Anything, 7).Return(session, nil)
+	sessions.On("Update", mock.Anything, session).Return(nil)
+	sessions.On("DenyToken", mock.Anything, "old-jti", session.AccessExpiresAt).Return(nil)
+
+	_, err := service.RefreshToken(context.Background(), request.RefreshToken{RefreshToken: "spent"})
+	assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+
+	require.NotNil(t, session.RevokedAt)
+	assert.Equal(t, enum.SessionTokenReused, *session.RevokeReason)
+	sessions.AssertCalled(t, "CommitTx", mock.Anything)
+	sessions.AssertNotCalled(t, "SpendRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	sessions.AssertNotCalled(t, "InsertRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestRefreshTokenRefused(t *testing.T) {
+	revoked := activeSession(4)
+	revokedAt := time.Now().Add(-time.Minute)
+	revoked.RevokedAt = &revokedAt
+
+	expired := activeSession(4)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name    string
+		token   *entity.RefreshToken
+		session *entity.Session
+	}{
+		{name: "unknown token"},
+		{name: "revoked session", token: &entity.RefreshToken{SessionID: 7}, session: revoked},
+		{name: "expired session", token: &entity.RefreshToken{SessionID: 7}, session: expired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, sessions := setupSessions()
+			sessions.On("GetRefreshTokenForUpdate", mock.Anything, mock.Anything).Return(tt.token, nil)
+			sessions.On("GetForUpdate", mock.Anything, 7).Return(tt.session, nil).Maybe()
+
+			_, err := service.RefreshToken(context.Background(), request.RefreshToken{RefreshToken: "token"})
+			assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+			sessions.AssertNotCalled(t, "SpendRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+			sessions.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("own session", func(t *testing.T) {
+		service, sessions := setupSessions()
+		session := activeSession(4)
+		sessions.On("GetForUpdate", mock.Anything, 7).Return(session, nil)
+		sessions.On("Update", mock.Anything, session).Return(nil)
+		sessions.On("DenyToken", mock.Anything, "old-jti", session.AccessExpiresAt).Return(nil)
+
+		err := service.RevokeSession(context.Background(), request.RevokeSession{UserID: 4, SessionID: 7})
+		require.NoError(t, err)
+		require.NotNil(t, session.RevokedAt)
+		assert.Equal(t, enum.SessionSignedOut, *session.RevokeReason)
+	})
+
+	t.Run("another user's session", func(t *testing.T) {
+		service, sessions := setupSessions()
+		sessions.On("GetForUpdate", mock.Anything, 7).Return(activeSession(5), nil)
+
+		err := service.RevokeSession(context.Background(), request.RevokeSession{UserID: 4, SessionID: 7})
+		assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+		sessions.AssertNotCalled(t, "DenyToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	service, sessions := setupSessions()
+
+	first, second := activeSession(4), activeSession(4)
+	second.SessionID, second.AccessTokenID = 8, "other-jti"
+
+	sessions.On("ListActiveByUserID", mock.Anything, 4, mock.Anything).Return([]*entity.Session{first, second}, nil)
+	sessions.On("GetForUpdate", mock.Anything, 7).Return(first, nil)
+	sessions.On("GetForUpdate", mock.Anything, 8).Return(second, nil)
+	sessions.On("Update", mock.Anything, mock.Anything).Return(nil)
+	sessions.On("DenyToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, service.RevokeAllSessions(context.Background(), 4))
+
+	assert.NotNil(t, first.RevokedAt)
+	assert.NotNil(t, second.RevokedAt)
+	sessions.AssertCalled(t, "DenyToken", mock.Anything, "old-jti", first.AccessExpiresAt)
+	sessions.AssertCalled(t, "DenyToken", mock.Anything, "other-jti", second.AccessExpiresAt)
+}
+
+func TestPurgeDeniedTokens(t *testing.T) {
+	service, sessions := setupSessions()
+
+	now := time.Now()
+	sessions.On("PurgeDeniedTokens", mock.Anything, now).Return(3, nil)
+
+	purged, err := service.PurgeDeniedTokens(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, purged)
+}
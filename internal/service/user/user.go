@@ -7,8 +7,13 @@ import (
 )
 
 func (s *Service) Get(ctx context.Context, id int) (entity.User, error) {
-	//TODO: implement me
-	panic("implement me")
+	user, err := s.userRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Errorw("service.user.Get.userRepo.Get", "error", err.Error())
+		return entity.User{}, err
+	}
+
+	return user, nil
 }
 
 func (s *Service) IsUserExist(ctx context.Context, userID int) (bool, error) {
@@ -0,0 +1,253 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror/message"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultCodeTTL        = 10 * time.Minute
+	defaultMaxAttempts    = 5
+	defaultResendInterval = time.Minute
+	defaultMaxCodesPerDay = 10
+)
+
+func (s *Service) SendVerificationCode(ctx context.Context, req request.SendVerificationCode) (response.VerificationCodeSent, error) {
+	if err := req.Validate(); err != nil {
+		return response.VerificationCodeSent{}, err
+	}
+
+	user, err := s.userRepo.Get(ctx, req.UserID)
+	if err != nil {
+		if derror.IsNotFound(err) {
+			return response.VerificationCodeSent{}, derror.NewNotFoundError(message.UserNotFound)
+		}
+		s.logger.Errorw("service.user.SendVerificationCode.userRepo.Get", "error", err.Error())
+		return response.VerificationCodeSent{}, derror.NewInternalSystemError()
+	}
+
+	destination, verified := contact(user, req.Channel)
+	if destination == "" {
+		return response.VerificationCodeSent{}, derror.NewValidationError("there is no %s on the profile to verify", req.Channel)
+	}
+
+	if verified {
+		return response.VerificationCodeSent{}, derror.NewConflictError("the %s is already verified", req.Channel)
+	}
+
+	if err := s.checkSendRate(ctx, req.UserID, req.Channel); err != nil {
+		return response.VerificationCodeSent{}, err
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		s.logger.Errorw("service.user.SendVerificationCode.newVerificationCode", "error", err.Error())
+		return response.VerificationCodeSent{}, derror.NewInternalSystemError()
+	}
+
+	// The code is hashed like a password: six digits are quickly guessed from a plain hash
+	codeHash, err := s.hasher.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.Errorw("service.user.SendVerificationCode.hasher.GenerateFromPassword", "error", err.Error())
+		return response.VerificationCodeSent{}, derror.NewInternalSystemError()
+	}
+
+	ttl := s.codeTTL()
+	sent := &entity.VerificationCode{
+		UserID:      req.UserID,
+		Channel:     req.Channel,
+		Destination: destination,
+		CodeHash:    codeHash,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+
+	if err := s.verificationRepo.Insert(ctx, sent); err != nil {
+		s.logger.Errorw("service.user.SendVerificationCode.verificationRepo.Insert", "error", err.Error())
+		return response.VerificationCodeSent{}, derror.NewInternalSystemError()
+	}
+
+	err = s.notifier.Notify(ctx, entity.Notification{
+		UserID:      req.UserID,
+		Channel:     req.Channel,
+		Destination: destination,
+		Subject:     "Your verification code",
+		Body:        fmt.Sprintf("Your Digital Wallet verification code is %s. It expires in %d minutes.", code, int(ttl.Minutes())),
+	})
+	if err != nil {
+		s.logger.Errorw("service.user.SendVerificationCode.notifier.Notify", "error", err.Error(), "user_id", req.UserID)
+		return response.VerificationCodeSent{}, derror.NewInternalSystemError()
+	}
+
+	return response.VerificationCodeSent{
+		Channel:     req.Channel,
+		Destination: maskContact(req.Channel, destination),
+		ExpiresAt:   sent.ExpiresAt.Truncate(time.Second),
+	}, nil
+}
+
+// ConfirmContact checks the code against the newest one sent on the channel. A wrong guess
+// is counted even though the request fails, so the code cannot be guessed by retrying.
+func (s *Service) ConfirmContact(ctx context.Context, req request.ConfirmContact) (err error) {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	ctx, err = s.verificationRepo.BeginTx(ctx)
+	if err != nil {
+		s.logger.Errorw("service.user.ConfirmContact.verificationRepo.BeginTx", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	defer func() {
+		if err != nil {
+			s.verificationRepo.RollbackTx(ctx)
+		}
+	}()
+
+	sent, err := s.verificationRepo.GetLatestForUpdate(ctx, req.UserID, req.Channel)
+	if err != nil {
+		s.logger.Errorw("service.user.ConfirmContact.verificationRepo.GetLatestForUpdate", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	now := time.Now()
+	if sent == nil || !sent.IsUsable(now, s.maxAttempts()) {
+		return derror.NewValidationError("the verification code has expired, request a new one")
+	}
+
+	if s.hasher.CompareHashAndPassword(sent.CodeHash, []byte(req.Code)) != nil {
+		sent.Attempts++
+		if err = s.verificationRepo.Update(ctx, sent); err != nil {
+			s.logger.Errorw("service.user.ConfirmContact.verificationRepo.Update", "error", err.Error())
+			return derror.NewInternalSystemError()
+		}
+
+		if err = s.verificationRepo.CommitTx(ctx); err != nil {
+			s.logger.Errorw("service.user.ConfirmContact.verificationRepo.CommitTx", "error", err.Error())
+			return derror.NewInternalSystemError()
+		}
+
+		return derror.NewValidationError("wrong verification code")
+	}
+
+	sent.UsedAt = &now
+	if err = s.verificationRepo.Update(ctx, sent); err != nil {
+		s.logger.Errorw("service.user.ConfirmContact.verificationRepo.Update", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	marked, err := s.userRepo.MarkContactVerified(ctx, req.UserID, req.Channel, sent.Destination)
+	if err != nil {
+		s.logger.Errorw("service.user.ConfirmContact.userRepo.MarkContactVerified", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if err = s.verificationRepo.CommitTx(ctx); err != nil {
+		s.logger.Errorw("service.user.ConfirmContact.verificationRepo.CommitTx", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	// The code is spent either way; it was sent to an address the user has since replaced
+	if !marked {
+		return derror.NewConflictError("the %s changed after the code was sent, request a new one", req.Channel)
+	}
+
+	return nil
+}
+
+// checkSendRate keeps a user from flooding an address with codes, which costs money for
+// text messages and gets mail flagged as spam.
+func (s *Service) checkSendRate(ctx context.Context, userID int, channel enum.ContactChannel) error {
+	latest, err := s.verificationRepo.GetLatest(ctx, userID, channel)
+	if err != nil {
+		s.logger.Errorw("service.user.checkSendRate.verificationRepo.GetLatest", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if latest != nil && time.Since(latest.CreatedAt) < s.resendInterval() {
+		return derror.NewError("a code was sent moments ago, wait before asking for another", http.StatusTooManyRequests)
+	}
+
+	sent, err := s.verificationRepo.CountSince(ctx, userID, channel, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		s.logger.Errorw("service.user.checkSendRate.verificationRepo.CountSince", "error", err.Error())
+		return derror.NewInternalSystemError()
+	}
+
+	if sent >= s.maxCodesPerDay() {
+		return derror.NewError("too many verification codes today, try again tomorrow", http.StatusTooManyRequests)
+	}
+
+	return nil
+}
+
+func contact(user entity.User, channel enum.ContactChannel) (destination string, verified bool) {
+	if channel == enum.ContactCellphone {
+		return user.Cellphone, user.ValidatedCellphone
+	}
+	return user.Email, user.ValidatedEmail
+}
+
+func newVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// maskContact hides most of an address in responses: "s***@example.com", "*******4567".
+func maskContact(channel enum.ContactChannel, destination string) string {
+	if channel == enum.ContactEmail {
+		local, domain, found := strings.Cut(destination, "@")
+		if found && local != "" {
+			return local[:1] + "***@" + domain
+		}
+	}
+
+	if len(destination) <= 4 {
+		return destination
+	}
+	return strings.Repeat("*", len(destination)-4) + destination[len(destination)-4:]
+}
+
+func (s *Service) codeTTL() time.Duration {
+	if s.verificationCfg.CodeTTL <= 0 {
+		return defaultCodeTTL
+	}
+	return s.verificationCfg.CodeTTL
+}
+
+func (s *Service) maxAttempts() int {
+	if s.verificationCfg.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return s.verificationCfg.MaxAttempts
+}
+
+func (s *Service) resendInterval() time.Duration {
+	if s.verificationCfg.ResendInterval <= 0 {
+		return defaultResendInterval
+	}
+	return s.verificationCfg.ResendInterval
+}
+
+func (s *Service) maxCodesPerDay() int {
+	if s.verificationCfg.MaxCodesPerDay <= 0 {
+		return defaultMaxCodesPerDay
+	}
+	return s.verificationCfg.MaxCodesPerDay
+}
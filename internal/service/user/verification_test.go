@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupVerification gives the service a code repository whose transactions always begin,
+// commit and roll back, and a notifier that records what was sent.
+func setupVerification() (*Service, *protocol.MockUserRepository, *protocol.MockHasher, *protocol.MockVerificationCodeRepo, *protocol.MockNotifier) {
+	service, users, hasher := setup()
+
+	codes := new(protocol.MockVerificationCodeRepo)
+	codes.On("BeginTx", mock.Anything).Return(context.Background(), nil).Maybe()
+	codes.On("CommitTx", mock.Anything).Return(nil).Maybe()
+	codes.On("RollbackTx", mock.Anything).Return(nil).Maybe()
+	service.verificationRepo = codes
+
+	notifier := new(protocol.MockNotifier)
+	service.notifier = notifier
+
+	return service, users, hasher, codes, notifier
+}
+
+func TestSendVerificationCode(t *testing.T) {
+	t.Run("sends a code to the email", func(t *testing.T) {
+		service, users, hasher, codes, notifier := setupVerification()
+		users.On("Get", mock.Anything, 4).Return(entity.User{ID: 4, Email: "sara@example.com"}, nil)
+		codes.On("GetLatest", mock.Anything, 4, enum.ContactEmail).Return(nil, nil)
+		codes.On("CountSince", mock.Anything, 4, enum.ContactEmail, mock.Anything).Return(0, nil)
+		hasher.On("GenerateFromPassword", mock.Anything, mock.Anything).Return([]byte("hash"), nil)
+		codes.On("Insert", mock.Anything, mock.MatchedBy(func(code *entity.VerificationCode) bool {
+			return code.Destination == "sara@example.com" && string(code.CodeHash) == "hash"
+		})).Return(nil)
+		notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n entity.Notification) bool {
+			return n.Channel == enum.ContactEmail && n.Destination == "sara@example.com"
+		})).Return(nil)
+
+		res, err := service.SendVerificationCode(context.Background(), request.SendVerificationCode{UserID: 4, Channel: enum.ContactEmail})
+		require.NoError(t, err)
+		assert.Equal(t, "s***@example.com", res.Destination)
+		assert.WithinDuration(t, time.Now().Add(defaultCodeTTL), res.ExpiresAt, 2*time.Second)
+		notifier.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name     string
+		user     entity.User
+		latest   *entity.VerificationCode
+		today    int
+		wantCode int
+	}{
+		{name: "no cellphone on the profile", user: entity.User{ID: 4}, wantCode: http.StatusUnprocessableEntity},
+		{name: "already verified", user: entity.User{ID: 4, Cellphone: "09121234567", ValidatedCellphone: true}, wantCode: http.StatusConflict},
+		{name: "sent moments ago", user: entity.User{ID: 4, Cellphone: "09121234567"}, latest: &entity.VerificationCode{CreatedAt: time.Now().Add(-10 * time.Second)}, wantCode: http.StatusTooManyRequests},
+		{name: "too many today", user: entity.User{ID: 4, Cellphone: "09121234567"}, today: defaultMaxCodesPerDay, wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, users, _, codes, notifier := setupVerification()
+			users.On("Get", mock.Anything, 4).Return(tt.user, nil)
+			codes.On("GetLatest", mock.Anything, 4, enum.ContactCellphone).Return(tt.latest, nil).Maybe()
+			codes.On("CountSince", mock.Anything, 4, enum.ContactCellphone, mock.Anything).Return(tt.today, nil).Maybe()
+
+			_, err := service.SendVerificationCode(context.Background(), request.SendVerificationCode{UserID: 4, Channel: enum.ContactCellphone})
+			assert.True(t, derror.IsHTTPError(err, tt.wantCode), "got %v", err)
+			codes.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+			notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestConfirmContact(t *testing.T) {
+	pending := func() *entity.VerificationCode {
+		return &entity.VerificationCode{
+			VerificationCodeID: 9,
+			UserID:             4,
+			Channel:            enum.ContactCellphone,
+			Destination:        "09121234567",
+			CodeHash:           []byte("hash"),
+			ExpiresAt:          time.Now().Add(time.Minute),
+		}
+	}
+	confirm := request.ConfirmContact{UserID: 4, Channel: enum.ContactCellphone, Code: "123456"}
+
+	t.Run("right code verifies the cellphone", func(t *testing.T) {
+		service, users, hasher, codes, _ := setupVerification()
+		code := pending()
+		codes.On("GetLatestForUpdate", mock.Anything, 4, enum.ContactCellphone).Return(code, nil)
+		hasher.On("CompareHashAndPassword", []byte("hash"), []byte("123456")).Return(nil)
+		codes.On("Update", mock.Anything, code).Return(nil)
+		users.On("MarkContactVerified", mock.Anything, 4, enum.ContactCellphone, "09121234567").Return(true, nil)
+
+		require.NoError(t, service.ConfirmContact(context.Background(), confirm))
+		assert.NotNil(t, code.UsedAt)
+		codes.AssertCalled(t, "CommitTx", mock.Anything)
+	})
+
+	t.Run("wrong code counts the attempt", func(t *testing.T) {
+		service, users, hasher, codes, _ := setupVerification()
+		code := pending()
+		codes.On("GetLatestForUpdate", mock.Anything, 4, enum.ContactCellphone).Return(code, nil)
+		hasher.On("CompareHashAndPassword", mock.Anything, mock.Anything).Return(assert.AnError)
+		codes.On("Update", mock.Anything, code).Return(nil)
+
+		err := service.ConfirmContact(context.Background(), confirm)
+		assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+		assert.Equal(t, 1, code.Attempts)
+		assert.Nil(t, code.UsedAt)
+		codes.AssertCalled(t, "CommitTx", mock.Anything)
+		users.AssertNotCalled(t, "MarkContactVerified", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cellphone changed after the code was sent", func(t *testing.T) {
+		service, users, hasher, codes, _ := setupVerification()
+		code := pending()
+		codes.On("GetLatestForUpdate", mock.Anything, 4, enum.ContactCellphone).Return(code, nil)
+		hasher.On("CompareHashAndPassword", mock.Anything, mock.Anything).Return(nil)
+		codes.On("Update", mock.Anything, code).Return(nil)
+		users.On("MarkContactVerified", mock.Anything, 4, enum.ContactCellphone, "09121234567").Return(false, nil)
+
+		err := service.ConfirmContact(context.Background(), confirm)
+		assert.True(t, derror.IsHTTPError(err, http.StatusConflict), "got %v", err)
+		assert.NotNil(t, code.UsedAt)
+	})
+
+	expired := pending()
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	exhausted := pending()
+	exhausted.Attempts = defaultMaxAttempts
+	used := pending()
+	usedAt := time.Now().Add(-time.Minute)
+	used.UsedAt = &usedAt
+
+	unusable := []struct {
+		name string
+		code *entity.VerificationCode
+	}{
+		{name: "never sent"},
+		{name: "expired", code: expired},
+		{name: "out of attempts", code: exhausted},
+		{name: "already used", code: used},
+	}
+
+	for _, tt := range unusable {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, hasher, codes, _ := setupVerification()
+			codes.On("GetLatestForUpdate", mock.Anything, 4, enum.ContactCellphone).Return(tt.code, nil)
+
+			err := service.ConfirmContact(context.Background(), confirm)
+			assert.True(t, derror.IsHTTPError(err, http.StatusUnprocessableEntity), "got %v", err)
+			hasher.AssertNotCalled(t, "CompareHashAndPassword", mock.Anything, mock.Anything)
+			codes.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestMaskContact(t *testing.T) {
+	assert.Equal(t, "s***@example.com", maskContact(enum.ContactEmail, "sara@example.com"))
+	assert.Equal(t, "*******4567", maskContact(enum.ContactCellphone, "09121234567"))
+	assert.Equal(t, "123", maskContact(enum.ContactCellphone, "123"))
+}
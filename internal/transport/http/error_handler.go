@@ -53,7 +53,8 @@ func errorHandler(logger *zap.SugaredLogger) echo.HTTPErrorHandler {
 			err = c.NoContent(derr.Code)
 		} else {
 			err = c.JSON(derr.Code, protocol.Error{
-				Message: derr.Message,
+				Message: derr.Error(),
+				Details: derr.Details,
 			})
 		}
 
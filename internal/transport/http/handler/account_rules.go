@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type AccountRulesHandler struct {
+	logger              *zap.SugaredLogger
+	accountRulesService protocol.AccountRules
+}
+
+func NewAccountRulesHandler(logger *zap.SugaredLogger, accountRulesService protocol.AccountRules) *AccountRulesHandler {
+	return &AccountRulesHandler{logger: logger, accountRulesService: accountRulesService}
+}
+
+func (h *AccountRulesHandler) GetRulesHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	accountID, err := strconv.Atoi(c.Param("accountID"))
+	if err != nil {
+		h.logger.Error("Invalid account ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid account ID")
+	}
+
+	rules, err := h.accountRulesService.GetRules(ctx, accountID)
+	if err != nil {
+		h.logger.Error("Failed to get account rules", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    rules,
+	})
+}
+
+func (h *AccountRulesHandler) UpdateRulesHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.UpdateAccountRules
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	rules, err := h.accountRulesService.UpdateRules(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to update account rules", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Account rules updated successfully",
+		Data:    rules,
+	})
+}
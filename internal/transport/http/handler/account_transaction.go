@@ -1,23 +1,40 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	scopeTransfer            = "accountTransaction.transfer"
+	scopeRegisterTransaction = "accountTransaction.registerTransaction"
+	scopeExecuteQuote        = "accountTransaction.executeQuote"
+)
+
 type AccountTransactionHandler struct {
 	logger                    *zap.SugaredLogger
 	accountTransactionService protocol.AccountTransaction
+	idempotencyService        protocol.Idempotency
+	authorizer                protocol.Authorizer
 }
 
-func NewAccountTransactionHandler(logger *zap.SugaredLogger, accountTransactionService protocol.AccountTransaction) *AccountTransactionHandler {
-	return &AccountTransactionHandler{logger: logger, accountTransactionService: accountTransactionService}
+func NewAccountTransactionHandler(logger *zap.SugaredLogger, accountTransactionService protocol.AccountTransaction, idempotencyService protocol.Idempotency, authorizer protocol.Authorizer) *AccountTransactionHandler {
+	return &AccountTransactionHandler{
+		logger:                    logger,
+		accountTransactionService: accountTransactionService,
+		idempotencyService:        idempotencyService,
+		authorizer:                authorizer,
+	}
 }
 
 func (h *AccountTransactionHandler) RegisterTransactionHandler(c echo.Context) error {
@@ -32,13 +49,23 @@ func (h *AccountTransactionHandler) RegisterTransactionHandler(c echo.Context) e
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request"))
 	}
 
+	ctx, replay, err := h.idempotencyService.Begin(ctx, jwt.Claims(c).UserID, scopeRegisterTransaction, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Transaction registered successfully", replay)
+	}
+
 	resp, err := h.accountTransactionService.RegisterTransaction(ctx, &req)
 	if err != nil {
 		h.logger.Error("Failed to register transaction",
 			zap.Error(err),
 			zap.String("handler", "RegisterTransactionHandler"),
 		)
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		_ = h.idempotencyService.Release(ctx)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, protocol.Success{
@@ -47,34 +74,6 @@ func (h *AccountTransactionHandler) RegisterTransactionHandler(c echo.Context) e
 	})
 }
 
-func (h *AccountTransactionHandler) DeleteTransactionHandler(c echo.Context) error {
-	ctx := c.Request().Context()
-	transactionIDStr := c.Param("transactionID")
-	transactionID, err := strconv.ParseInt(transactionIDStr, 10, 64)
-	if err != nil {
-		h.logger.Error("Invalid transaction ID",
-			zap.Error(err),
-			zap.String("handler", "DeleteTransactionHandler"),
-			zap.String("transactionID", transactionIDStr),
-		)
-		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid transaction ID"))
-	}
-
-	err = h.accountTransactionService.DeleteTransaction(ctx, transactionID)
-	if err != nil {
-		h.logger.Error("Failed to delete transaction",
-			zap.Error(err),
-			zap.String("handler", "DeleteTransactionHandler"),
-			zap.Int64("transactionID", transactionID),
-		)
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
-	}
-
-	return c.JSON(http.StatusOK, protocol.Success{
-		Message: "Transaction deleted successfully",
-		Data:    nil,
-	})
-}
 func (h *AccountTransactionHandler) GetTransactionByIDHandler(c echo.Context) error {
 	ctx := c.Request().Context()
 	transactionIDStr := c.Param("id")
@@ -87,7 +86,7 @@ func (h *AccountTransactionHandler) GetTransactionByIDHandler(c echo.Context) er
 	resp, err := h.accountTransactionService.GetTransactionByID(ctx, transactionID)
 	if err != nil {
 		h.logger.Error("Failed to get transaction by ID", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, protocol.Success{
@@ -108,7 +107,7 @@ func (h *AccountTransactionHandler) ListTransactionsByAccountIDHandler(c echo.Co
 	resp, err := h.accountTransactionService.ListTransactionsByAccountID(ctx, accountID)
 	if err != nil {
 		h.logger.Error("Failed to list transactions by account ID", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, protocol.Success{
@@ -119,7 +118,7 @@ func (h *AccountTransactionHandler) ListTransactionsByAccountIDHandler(c echo.Co
 
 func (h *AccountTransactionHandler) ListTransactionsByGroupIDHandler(c echo.Context) error {
 	ctx := c.Request().Context()
-	groupIDStr := c.Param("id")
+	groupIDStr := c.Param("groupID")
 	groupID, err := strconv.Atoi(groupIDStr)
 	if err != nil {
 		h.logger.Error("Invalid group ID", zap.Error(err))
@@ -129,7 +128,13 @@ func (h *AccountTransactionHandler) ListTransactionsByGroupIDHandler(c echo.Cont
 	resp, err := h.accountTransactionService.ListTransactionsByGroupID(ctx, groupID)
 	if err != nil {
 		h.logger.Error("Failed to list transactions by group ID", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		return err
+	}
+
+	// The other party's legs are left out
+	resp, err = h.authorizer.OwnAccountTransactions(ctx, jwt.Claims(c), resp)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, protocol.Success{
@@ -150,7 +155,7 @@ func (h *AccountTransactionHandler) CancelTransactionHandler(c echo.Context) err
 	err = h.accountTransactionService.CancelTransaction(ctx, transactionID)
 	if err != nil {
 		h.logger.Error("Failed to cancel transaction", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, protocol.Success{
@@ -159,6 +164,28 @@ func (h *AccountTransactionHandler) CancelTransactionHandler(c echo.Context) err
 	})
 }
 
+func (h *AccountTransactionHandler) ReverseTransactionGroupHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.ReverseTransactionGroup
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	resp, err := h.accountTransactionService.ReverseTransactionGroup(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to reverse transaction group", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Transaction group reversed successfully",
+		Data:    resp,
+	})
+}
+
 func (h *AccountTransactionHandler) TransferHandler(c echo.Context) error {
 	ctx := c.Request().Context()
 	var req request.TransferRequest
@@ -168,10 +195,27 @@ func (h *AccountTransactionHandler) TransferHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request"))
 	}
 
+	claims := jwt.Claims(c)
+	req.UserID = claims.UserID
+
+	if err := h.authorizer.AuthorizeAccount(ctx, claims, req.SenderAccountID); err != nil {
+		return err
+	}
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeTransfer, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Transfer initiated successfully", replay)
+	}
+
 	resp, err := h.accountTransactionService.Transfer(ctx, req)
 	if err != nil {
 		h.logger.Error("Failed to initiate transfer", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		_ = h.idempotencyService.Release(ctx)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, protocol.Success{
@@ -193,7 +237,7 @@ func (h *AccountTransactionHandler) GetAccountTransactionHistoryHandler(c echo.C
 	transactions, err := h.accountTransactionService.GetAccountTransactionHistory(ctx, accountID)
 	if err != nil {
 		h.logger.Error("Failed to retrieve account transaction history", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
+		return err
 	}
 
 	h.logger.Info("Successfully retrieved account transaction history", zap.Int("accountID", accountID), zap.Int("transactionCount", len(transactions)))
@@ -202,3 +246,71 @@ func (h *AccountTransactionHandler) GetAccountTransactionHistoryHandler(c echo.C
 		Data:    transactions,
 	})
 }
+
+func (h *AccountTransactionHandler) QuoteTransferHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.QuoteTransferRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	claims := jwt.Claims(c)
+	req.UserID = claims.UserID
+
+	if err := h.authorizer.AuthorizeAccount(ctx, claims, req.SenderAccountID); err != nil {
+		return err
+	}
+
+	resp, err := h.accountTransactionService.QuoteTransfer(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to quote transfer", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Transfer quoted successfully",
+		Data:    resp,
+	})
+}
+
+func (h *AccountTransactionHandler) ExecuteQuoteHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.ExecuteQuoteRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeExecuteQuote, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Transfer executed successfully", replay)
+	}
+
+	resp, err := h.accountTransactionService.ExecuteQuote(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to execute quote", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Transfer executed successfully",
+		Data:    resp,
+	})
+}
+
+// replayResponse answers a retried request with the response stored for its Idempotency-Key.
+func replayResponse(c echo.Context, message string, data []byte) error {
+	c.Response().Header().Set(headerIdempotentReplayed, "true")
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: message,
+		Data:    json.RawMessage(data),
+	})
+}
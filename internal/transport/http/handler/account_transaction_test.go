@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// The handlers hand service errors to the error handler, which maps them to their status.
+func TestAccountTransactionHandlersReturnServiceErrors(t *testing.T) {
+	notFound := derror.NewNotFoundError("not found")
+
+	tests := []struct {
+		name    string
+		param   string
+		prepare func(m *protocol.MockAccountTransactionService)
+		handle  func(h *AccountTransactionHandler, c echo.Context) error
+	}{
+		{
+			name:  "get transaction",
+			param: "id",
+			prepare: func(m *protocol.MockAccountTransactionService) {
+				m.On("GetTransactionByID", mock.Anything, int64(7)).Return(nil, notFound)
+			},
+			handle: (*AccountTransactionHandler).GetTransactionByIDHandler,
+		},
+		{
+			name:  "list by account",
+			param: "id",
+			prepare: func(m *protocol.MockAccountTransactionService) {
+				m.On("ListTransactionsByAccountID", mock.Anything, 7).Return(nil, notFound)
+			},
+			handle: (*AccountTransactionHandler).ListTransactionsByAccountIDHandler,
+		},
+		{
+			name:  "list by group",
+			param: "groupID",
+			prepare: func(m *protocol.MockAccountTransactionService) {
+				m.On("ListTransactionsByGroupID", mock.Anything, 7).Return(nil, notFound)
+			},
+			handle: (*AccountTransactionHandler).ListTransactionsByGroupIDHandler,
+		},
+		{
+			name:  "cancel",
+			param: "id",
+			prepare: func(m *protocol.MockAccountTransactionService) {
+				m.On("CancelTransaction", mock.Anything, int64(7)).Return(notFound)
+			},
+			handle: (*AccountTransactionHandler).CancelTransactionHandler,
+		},
+		{
+			name:  "history",
+			param: "id",
+			prepare: func(m *protocol.MockAccountTransactionService) {
+				m.On("GetAccountTransactionHistory", mock.Anything, 7).Return(nil, notFound)
+			},
+			handle: (*AccountTransactionHandler).GetAccountTransactionHistoryHandler,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := new(protocol.MockAccountTransactionService)
+			tt.prepare(transactions)
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+			c.SetParamNames(tt.param)
+			c.SetParamValues("7")
+
+			h := NewAccountTransactionHandler(zap.NewNop().Sugar(), transactions, new(protocol.MockIdempotencyService), new(protocol.MockAuthorizer))
+			err := tt.handle(h, c)
+			assert.True(t, derror.IsHTTPError(err, http.StatusNotFound), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeCardPurchase  = "cardTransaction.purchase"
+	scopeCardTransfer  = "cardTransaction.transfer"
+	scopeCardAuthorize = "cardTransaction.authorize"
+)
+
+type CardTransactionHandler struct {
+	logger                 *zap.SugaredLogger
+	cardTransactionService protocol.FinancialCardTransactionService
+	idempotencyService     protocol.Idempotency
+	authorizer             protocol.Authorizer
+}
+
+func NewCardTransactionHandler(logger *zap.SugaredLogger, cardTransactionService protocol.FinancialCardTransactionService, idempotencyService protocol.Idempotency, authorizer protocol.Authorizer) *CardTransactionHandler {
+	return &CardTransactionHandler{
+		logger:                 logger,
+		cardTransactionService: cardTransactionService,
+		idempotencyService:     idempotencyService,
+		authorizer:             authorizer,
+	}
+}
+
+func (h *CardTransactionHandler) PurchaseHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardPurchase
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeCardPurchase, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Purchase completed successfully", replay)
+	}
+
+	resp, err := h.cardTransactionService.Purchase(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to complete the card purchase", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Purchase completed successfully",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) RefundHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardRefund
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	resp, err := h.cardTransactionService.Refund(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to refund the card transaction", zap.Error(err), zap.Int64("transactionID", req.TransactionID))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Card transaction refunded successfully",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) TransferHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.Transfer
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeCardTransfer, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Card transfer completed successfully", replay)
+	}
+
+	resp, err := h.cardTransactionService.Transfer(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to transfer between cards", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Card transfer completed successfully",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) GetTransactionByIDHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	transactionID, err := strconv.ParseInt(c.Param("transactionID"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid transaction ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid transaction ID")
+	}
+
+	resp, err := h.cardTransactionService.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		h.logger.Error("Failed to get the card transaction", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) ListTransactionsByCardIDHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID, err := strconv.Atoi(c.Param("cardID"))
+	if err != nil {
+		h.logger.Error("Invalid card ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid card ID")
+	}
+
+	resp, err := h.cardTransactionService.ListTransactionsByCardID(ctx, cardID)
+	if err != nil {
+		h.logger.Error("Failed to list the card's transactions", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) ListTransactionsByGroupIDHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	groupID, err := strconv.Atoi(c.Param("groupID"))
+	if err != nil {
+		h.logger.Error("Invalid transaction group ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid transaction group ID")
+	}
+
+	resp, err := h.cardTransactionService.ListTransactionsByGroupID(ctx, groupID)
+	if err != nil {
+		h.logger.Error("Failed to list the card transactions of the group", zap.Error(err))
+		return err
+	}
+
+	// The other party's legs are left out
+	resp, err = h.authorizer.OwnCardTransactions(ctx, jwt.Claims(c), resp)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) AuthorizeHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardAuthorization
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeCardAuthorize, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Card payment authorized", replay)
+	}
+
+	resp, err := h.cardTransactionService.Authorize(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to authorize the card payment", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Card payment authorized",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) CaptureHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CaptureHold
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	resp, err := h.cardTransactionService.Capture(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to capture the hold", zap.Error(err), zap.Int("holdID", req.HoldID))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Hold captured successfully",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) ReleaseHoldHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.ReleaseHold
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	resp, err := h.cardTransactionService.ReleaseHold(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to release the hold", zap.Error(err), zap.Int("holdID", req.HoldID))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Hold released successfully",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) ListHoldsByCardIDHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID, err := strconv.Atoi(c.Param("cardID"))
+	if err != nil {
+		h.logger.Error("Invalid card ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid card ID")
+	}
+
+	resp, err := h.cardTransactionService.ListHoldsByCardID(ctx, cardID)
+	if err != nil {
+		h.logger.Error("Failed to list the card's holds", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    resp,
+	})
+}
+
+func (h *CardTransactionHandler) GetCardBalanceHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID, err := strconv.Atoi(c.Param("cardID"))
+	if err != nil {
+		h.logger.Error("Invalid card ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid card ID")
+	}
+
+	resp, err := h.cardTransactionService.GetCardBalance(ctx, jwt.Claims(c).UserID, cardID)
+	if err != nil {
+		h.logger.Error("Failed to get the card balance", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    resp,
+	})
+}
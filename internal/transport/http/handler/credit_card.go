@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const scopeCreditCardPayment = "creditCard.payment"
+
+type CreditCardHandler struct {
+	logger             *zap.SugaredLogger
+	creditCardService  protocol.CreditCard
+	idempotencyService protocol.Idempotency
+}
+
+func NewCreditCardHandler(logger *zap.SugaredLogger, creditCardService protocol.CreditCard, idempotencyService protocol.Idempotency) *CreditCardHandler {
+	return &CreditCardHandler{
+		logger:             logger,
+		creditCardService:  creditCardService,
+		idempotencyService: idempotencyService,
+	}
+}
+
+func (h *CreditCardHandler) OpenCreditLineHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.OpenCreditLine
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	line, err := h.creditCardService.OpenCreditLine(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to open the credit line", zap.Error(err), zap.Int("cardID", req.CardID))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Credit line opened successfully",
+		Data:    line,
+	})
+}
+
+func (h *CreditCardHandler) UpdateCreditLimitHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.UpdateCreditLimit
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	line, err := h.creditCardService.UpdateCreditLimit(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to update the credit limit", zap.Error(err), zap.Int("cardID", req.CardID))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Credit limit updated successfully",
+		Data:    line,
+	})
+}
+
+func (h *CreditCardHandler) GetCreditLineHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	line, err := h.creditCardService.GetCreditLine(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to get the credit line", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    line,
+	})
+}
+
+func (h *CreditCardHandler) MakePaymentHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CreditCardPayment
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeCreditCardPayment, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Credit card payment completed successfully", replay)
+	}
+
+	resp, err := h.creditCardService.MakePayment(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to pay the credit card", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Credit card payment completed successfully",
+		Data:    resp,
+	})
+}
+
+func (h *CreditCardHandler) ListStatementsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	statements, err := h.creditCardService.ListStatements(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to list the statements", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    statements,
+	})
+}
+
+func (h *CreditCardHandler) GetStatementHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CreditStatement
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	statement, err := h.creditCardService.GetStatement(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to get the statement", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    statement,
+	})
+}
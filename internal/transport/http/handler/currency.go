@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
@@ -165,7 +166,7 @@ func (h *CurrencyHandler) GetExchangeRateHandler(c echo.Context) error {
 func (h *CurrencyHandler) BulkUpdateExchangeRatesHandler(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	var req map[enum.CurrencyCode]float64
+	var req map[enum.CurrencyCode]entity.Rate
 	if err := c.Bind(&req); err != nil {
 		h.logger.Error("Failed to bind request in BulkUpdateExchangeRatesHandler", zap.Error(err))
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request"))
@@ -204,23 +205,23 @@ func (h *CurrencyHandler) SearchCurrenciesHandler(c echo.Context) error {
 func (h *CurrencyHandler) ConvertAmountHandler(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	fromCode := c.QueryParam("from")
-	toCode := c.QueryParam("to")
-	amountStr := c.QueryParam("amount")
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	fromCode := c.Param("fromCode")
+	toCode := c.Param("toCode")
+	amountStr := c.Param("amount")
+	amount, err := entity.ParseMoney(amountStr, enum.CurrencyCode(fromCode))
 
 	if err != nil {
 		h.logger.Error("Failed to parse amount", zap.Error(err))
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid amount"))
 	}
 
-	convertedAmount, err := h.currencyService.ConvertAmount(ctx, enum.CurrencyCode(fromCode), enum.CurrencyCode(toCode), amount)
+	convertedAmount, err := h.currencyService.ConvertAmount(ctx, amount, enum.CurrencyCode(toCode))
 	if err != nil {
 		h.logger.Error("Failed to convert amount", zap.Error(err))
 		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
 	}
 
-	h.logger.Info("Successfully converted amount", zap.String("from", fromCode), zap.String("to", toCode), zap.Float64("amount", amount))
+	h.logger.Info("Successfully converted amount", zap.String("from", fromCode), zap.String("to", toCode), zap.String("amount", amount.String()))
 	return c.JSON(http.StatusOK, protocol.Success{
 		Message: "Amount converted successfully",
 		Data:    convertedAmount,
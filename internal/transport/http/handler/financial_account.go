@@ -7,6 +7,8 @@ import (
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -15,12 +17,14 @@ import (
 type FinancialAccountHandler struct {
 	logger                  *zap.SugaredLogger
 	FinancialAccountService protocol.FinancialAccount
+	authorizer              protocol.Authorizer
 }
 
-func NewFinancialAccountHandler(logger *zap.SugaredLogger, FinancialAccountService protocol.FinancialAccount) *FinancialAccountHandler {
+func NewFinancialAccountHandler(logger *zap.SugaredLogger, FinancialAccountService protocol.FinancialAccount, authorizer protocol.Authorizer) *FinancialAccountHandler {
 	return &FinancialAccountHandler{
 		logger:                  logger,
 		FinancialAccountService: FinancialAccountService,
+		authorizer:              authorizer,
 	}
 }
 
@@ -34,6 +38,11 @@ func (h *FinancialAccountHandler) CreateAccountHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request payload"))
 	}
 
+	// Only admins open accounts on behalf of other users
+	if claims := jwt.Claims(c); !claims.Admin && req.UserID != claims.UserID {
+		return derror.NewForbiddenError("user %d may not open an account for user %d", claims.UserID, req.UserID)
+	}
+
 	h.logger.Debug("CreateAccount request payload validated", zap.Any("requestData", req))
 
 	resp, err := h.FinancialAccountService.CreateAccount(ctx, req)
@@ -84,6 +93,16 @@ func (h *FinancialAccountHandler) UpdateAccountHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request payload"))
 	}
 
+	claims := jwt.Claims(c)
+	if err := h.authorizer.AuthorizeAccount(ctx, claims, req.AccountID); err != nil {
+		return err
+	}
+
+	// The owner is part of the payload, so a user could otherwise hand the account to someone else
+	if !claims.Admin && req.UserID != claims.UserID {
+		return derror.NewForbiddenError("user %d may not move account %d to user %d", claims.UserID, req.AccountID, req.UserID)
+	}
+
 	h.logger.Debug("UpdateAccount request payload validated", zap.Any("requestData", req))
 
 	err := h.FinancialAccountService.UpdateAccount(ctx, req)
@@ -212,6 +231,29 @@ func (h *FinancialAccountHandler) GetAccountByShabaHandler(c echo.Context) error
 		return c.JSON(http.StatusInternalServerError, derror.NewInternalSystemError())
 	}
 
+	// Looking up a payee by Shaba number is open to every user, but only the owner and staff
+	// see the full record
+	claims := jwt.Claims(c)
+	if !claims.Can(enum.PermissionAccountsReadAll) {
+		err := h.authorizer.AuthorizeAccount(ctx, claims, account.AccountID)
+		if derror.IsHTTPError(err, http.StatusForbidden) {
+			return c.JSON(http.StatusOK, protocol.Success{
+				Message: "Account retrieved successfully",
+				Data: response.FinancialAccountPayee{
+					AccountID:    account.AccountID,
+					BankID:       account.BankID,
+					ShabaNumber:  account.ShabaNumber,
+					AccountName:  account.AccountName,
+					CurrencyCode: account.CurrencyCode,
+				},
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
 	h.logger.Info("Successfully retrieved account by Shaba number", zap.String("shabaNumber", shabaNumber))
 	return c.JSON(http.StatusOK, protocol.Success{
 		Message: "Account retrieved successfully",
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetAccountByShabaHandler(t *testing.T) {
+	const shaba = "IR820540102680020817909002"
+
+	account := response.GetFinancialAccount{
+		AccountID:    9,
+		UserID:       5,
+		BankID:       2,
+		ShabaNumber:  shaba,
+		AccountName:  "Sara",
+		CurrencyCode: "USD",
+		Status:       enum.Verified,
+	}
+
+	tests := []struct {
+		name      string
+		claims    *entity.JWTClaims
+		authorize error
+		wantFull  bool
+	}{
+		{name: "owner", claims: &entity.JWTClaims{UserID: 5}, wantFull: true},
+		{name: "another user", claims: &entity.JWTClaims{UserID: 4}, authorize: derror.NewForbiddenError("account 9 does not belong to the user")},
+		{name: "staff reading any account", claims: &entity.JWTClaims{UserID: 3, Permissions: []enum.Permission{enum.PermissionAccountsReadAll}}, wantFull: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := new(protocol.MockFinancialAccountService)
+			accounts.On("GetAccountByShaba", mock.Anything, shaba).Return(account, nil)
+			authorizer := new(protocol.MockAuthorizer)
+			authorizer.On("AuthorizeAccount", mock.Anything, tt.claims, 9).Return(tt.authorize).Maybe()
+
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/account/shaba/"+shaba, nil), rec)
+			c.SetParamNames("shabaNumber")
+			c.SetParamValues(shaba)
+			c.Set("user", &jwt.Token{Claims: tt.claims})
+
+			h := NewFinancialAccountHandler(zap.NewNop().Sugar(), accounts, authorizer)
+			require.NoError(t, h.GetAccountByShabaHandler(c))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var body struct {
+				Data map[string]any
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, float64(9), body.Data["AccountID"])
+			assert.Equal(t, shaba, body.Data["ShabaNumber"])
+
+			_, hasOwner := body.Data["UserID"]
+			_, hasStatus := body.Data["Status"]
+			assert.Equal(t, tt.wantFull, hasOwner)
+			assert.Equal(t, tt.wantFull, hasStatus)
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -15,10 +19,11 @@ import (
 type FinancialCardHandler struct {
 	logger               *zap.SugaredLogger
 	financialCardService protocol.FinancialCard
+	authorizer           protocol.Authorizer
 }
 
-func NewFinancialCardHandler(logger *zap.SugaredLogger, financialCardService protocol.FinancialCard) *FinancialCardHandler {
-	return &FinancialCardHandler{logger: logger, financialCardService: financialCardService}
+func NewFinancialCardHandler(logger *zap.SugaredLogger, financialCardService protocol.FinancialCard, authorizer protocol.Authorizer) *FinancialCardHandler {
+	return &FinancialCardHandler{logger: logger, financialCardService: financialCardService, authorizer: authorizer}
 }
 
 func (h *FinancialCardHandler) RegisterCardHandler(c echo.Context) error {
@@ -30,6 +35,10 @@ func (h *FinancialCardHandler) RegisterCardHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request"))
 	}
 
+	if err := h.authorizer.AuthorizeAccount(ctx, jwt.Claims(c), req.AccountID); err != nil {
+		return err
+	}
+
 	ID, err := h.financialCardService.RegisterCard(ctx, &req)
 	if err != nil {
 		h.logger.Error("Failed to register card", zap.Error(err))
@@ -42,6 +51,28 @@ func (h *FinancialCardHandler) RegisterCardHandler(c echo.Context) error {
 	})
 }
 
+func (h *FinancialCardHandler) IssueVirtualCardHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.IssueVirtualCard
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	card, number, err := h.financialCardService.IssueVirtualCard(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to issue a virtual card", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Virtual card issued successfully",
+		Data:    response.IssuedCard{FinancialCard: maskedCard(card), CardNumber: number},
+	})
+}
+
 func (h *FinancialCardHandler) UpdateCardHandler(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -51,6 +82,10 @@ func (h *FinancialCardHandler) UpdateCardHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, derror.NewBadRequestError("Invalid request"))
 	}
 
+	if err := h.authorizer.AuthorizeCard(ctx, jwt.Claims(c), int(req.CardID)); err != nil {
+		return err
+	}
+
 	err := h.financialCardService.UpdateCard(ctx, &req)
 	if err != nil {
 		h.logger.Error("Failed to update card", zap.Error(err))
@@ -105,7 +140,7 @@ func (h *FinancialCardHandler) GetCardByIDHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, protocol.Success{
 		Message: "Success",
-		Data:    card,
+		Data:    maskedCard(card),
 	})
 }
 
@@ -132,7 +167,7 @@ func (h *FinancialCardHandler) ListCardsByAccountIDHandler(c echo.Context) error
 
 	return c.JSON(http.StatusOK, protocol.Success{
 		Message: "Success",
-		Data:    cards,
+		Data:    maskedCards(cards),
 	})
 }
 
@@ -159,6 +194,151 @@ func (h *FinancialCardHandler) ListCardsByTypeHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, protocol.Success{
 		Message: "Success",
-		Data:    cards,
+		Data:    maskedCards(cards),
+	})
+}
+
+func maskedCard(card *entity.FinancialCard) *response.FinancialCard {
+	if card == nil {
+		return nil
+	}
+
+	return &response.FinancialCard{
+		CardID:         card.CardID,
+		AccountID:      card.AccountID,
+		MaskedNumber:   card.MaskedNumber(),
+		CardType:       card.CardType,
+		Network:        card.Network,
+		BankID:         card.BankID,
+		ExpirationDate: card.ExpirationDate,
+		CardHolderName: card.CardHolderName,
+		Status:         card.Status,
+		IssuedDate:     card.IssuedDate,
+		ReplacesCardID: card.ReplacesCardID,
+		Virtual:        card.Virtual,
+		Usage:          card.Usage,
+		LockedMerchant: card.LockedMerchant,
+		SpendCap:       card.SpendCap,
+		CreatedAt:      card.CreatedAt,
+		UpdatedAt:      card.UpdatedAt,
+	}
+}
+
+func maskedCards(cards []*entity.FinancialCard) []*response.FinancialCard {
+	masked := make([]*response.FinancialCard, 0, len(cards))
+	for _, card := range cards {
+		masked = append(masked, maskedCard(card))
+	}
+	return masked
+}
+
+func (h *FinancialCardHandler) FreezeCardHandler(c echo.Context) error {
+	return h.action(c, h.financialCardService.FreezeCard, "Card frozen")
+}
+
+func (h *FinancialCardHandler) UnfreezeCardHandler(c echo.Context) error {
+	return h.action(c, h.financialCardService.UnfreezeCard, "Card unfrozen")
+}
+
+func (h *FinancialCardHandler) ReportCardLostHandler(c echo.Context) error {
+	return h.action(c, h.financialCardService.ReportCardLost, "Card reported lost")
+}
+
+func (h *FinancialCardHandler) ReportCardStolenHandler(c echo.Context) error {
+	return h.action(c, h.financialCardService.ReportCardStolen, "Card reported stolen")
+}
+
+func (h *FinancialCardHandler) ReissueCardHandler(c echo.Context) error {
+	return h.action(c, h.financialCardService.ReissueCard, "Card reissued")
+}
+
+func (h *FinancialCardHandler) ListCardEventsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	events, err := h.financialCardService.ListCardEvents(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to list the card's history", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    events,
+	})
+}
+
+func (h *FinancialCardHandler) GetCardControlsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CardAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	controls, err := h.financialCardService.GetCardControls(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to get the card controls", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    controls,
+	})
+}
+
+func (h *FinancialCardHandler) UpdateCardControlsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.UpdateCardControls
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	controls, err := h.financialCardService.UpdateCardControls(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to update the card controls", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Card controls updated successfully",
+		Data:    controls,
+	})
+}
+
+func (h *FinancialCardHandler) action(c echo.Context,
+	apply func(context.Context, request.CardAction) (*entity.FinancialCard, error),
+	message string,
+) error {
+	ctx := c.Request().Context()
+	var req request.CardAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	card, err := apply(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to change the card", zap.Error(err), zap.Int("cardID", req.CardID))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: message,
+		Data:    maskedCard(card),
 	})
 }
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/response"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeGiftCardRedeem = "giftCard.redeem"
+	scopeGiftCardSpend  = "giftCard.spend"
+)
+
+type GiftCardHandler struct {
+	logger             *zap.SugaredLogger
+	giftCardService    protocol.GiftCard
+	idempotencyService protocol.Idempotency
+}
+
+func NewGiftCardHandler(logger *zap.SugaredLogger, giftCardService protocol.GiftCard, idempotencyService protocol.Idempotency) *GiftCardHandler {
+	return &GiftCardHandler{
+		logger:             logger,
+		giftCardService:    giftCardService,
+		idempotencyService: idempotencyService,
+	}
+}
+
+func (h *GiftCardHandler) IssueGiftCardHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.IssueGiftCard
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	card, code, err := h.giftCardService.IssueGiftCard(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to issue the gift card", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Gift card issued successfully",
+		Data:    response.IssuedGiftCard{GiftCard: maskedGiftCard(card), Code: code},
+	})
+}
+
+func (h *GiftCardHandler) RedeemGiftCardHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.RedeemGiftCard
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeGiftCardRedeem, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Gift card redeemed successfully", replay)
+	}
+
+	resp, err := h.giftCardService.RedeemGiftCard(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to redeem the gift card", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Gift card redeemed successfully",
+		Data:    resp,
+	})
+}
+
+func (h *GiftCardHandler) SpendGiftCardHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.SpendGiftCard
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	ctx, replay, err := h.idempotencyService.Begin(ctx, req.UserID, scopeGiftCardSpend, c.Request().Header.Get(headerIdempotencyKey), req)
+	if err != nil {
+		return err
+	}
+
+	if replay != nil {
+		return replayResponse(c, "Gift card payment completed successfully", replay)
+	}
+
+	resp, err := h.giftCardService.SpendGiftCard(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to pay with the gift card", zap.Error(err))
+		_ = h.idempotencyService.Release(ctx)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Gift card payment completed successfully",
+		Data:    resp,
+	})
+}
+
+// GetGiftCardBalanceHandler takes the code in the body so that it stays out of access logs.
+func (h *GiftCardHandler) GetGiftCardBalanceHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.GiftCardCode
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	card, err := h.giftCardService.GetGiftCardBalance(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to get the gift card balance", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    maskedGiftCard(card),
+	})
+}
+
+func (h *GiftCardHandler) ListGiftCardsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	cards, err := h.giftCardService.ListGiftCards(ctx, jwt.Claims(c).UserID)
+	if err != nil {
+		h.logger.Error("Failed to list the gift cards", zap.Error(err))
+		return err
+	}
+
+	masked := make([]*response.GiftCard, 0, len(cards))
+	for _, card := range cards {
+		masked = append(masked, maskedGiftCard(card))
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    masked,
+	})
+}
+
+func maskedGiftCard(card *entity.GiftCard) *response.GiftCard {
+	if card == nil {
+		return nil
+	}
+
+	return &response.GiftCard{
+		GiftCardID:    card.GiftCardID,
+		MaskedCode:    "****-****-****-" + card.CodeLastFour,
+		InitialAmount: card.InitialAmount,
+		Balance:       card.Balance,
+		Status:        card.Status,
+		ExpiresAt:     card.ExpiresAt,
+		CreatedAt:     card.CreatedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type ReviewHandler struct {
+	logger        *zap.SugaredLogger
+	reviewService protocol.Review
+}
+
+func NewReviewHandler(logger *zap.SugaredLogger, reviewService protocol.Review) *ReviewHandler {
+	return &ReviewHandler{logger: logger, reviewService: reviewService}
+}
+
+func (h *ReviewHandler) ListCasesHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.ListReviewCases
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+
+	cases, err := h.reviewService.ListCases(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to list review cases", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    cases,
+	})
+}
+
+func (h *ReviewHandler) GetCaseHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	caseID, err := strconv.Atoi(c.Param("caseID"))
+	if err != nil {
+		h.logger.Error("Invalid review case ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid review case ID")
+	}
+
+	detail, err := h.reviewService.GetCase(ctx, caseID)
+	if err != nil {
+		h.logger.Error("Failed to get the review case", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    detail,
+	})
+}
+
+func (h *ReviewHandler) AssignCaseHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.AssignReviewCase
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.ReviewerID = jwt.Claims(c).UserID
+
+	reviewCase, err := h.reviewService.AssignCase(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to assign the review case", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Review case assigned successfully",
+		Data:    reviewCase,
+	})
+}
+
+func (h *ReviewHandler) ClaimCaseHandler(c echo.Context) error {
+	return h.act(c, h.reviewService.ClaimCase, "Review case claimed successfully")
+}
+
+func (h *ReviewHandler) ApproveCaseHandler(c echo.Context) error {
+	return h.act(c, h.reviewService.ApproveCase, "Transfer approved successfully")
+}
+
+func (h *ReviewHandler) RejectCaseHandler(c echo.Context) error {
+	return h.act(c, h.reviewService.RejectCase, "Transfer rejected successfully")
+}
+
+// act runs a reviewer's action on the case in the path.
+func (h *ReviewHandler) act(c echo.Context, action func(context.Context, request.ReviewCaseAction) (*entity.ReviewCase, error), message string) error {
+	ctx := c.Request().Context()
+	var req request.ReviewCaseAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.ReviewerID = jwt.Claims(c).UserID
+
+	reviewCase, err := action(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to act on the review case", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: message,
+		Data:    reviewCase,
+	})
+}
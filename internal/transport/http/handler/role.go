@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type RoleHandler struct {
+	logger      *zap.SugaredLogger
+	userService protocol.User
+}
+
+func NewRoleHandler(logger *zap.SugaredLogger, userService protocol.User) *RoleHandler {
+	return &RoleHandler{logger: logger, userService: userService}
+}
+
+func (h *RoleHandler) ListRolesHandler(c echo.Context) error {
+	roles, err := h.userService.ListRoles(c.Request().Context())
+	if err != nil {
+		h.logger.Error("Failed to list the roles", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    roles,
+	})
+}
+
+func (h *RoleHandler) ListUserRolesHandler(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		h.logger.Error("Invalid user ID", zap.Error(err))
+		return derror.NewBadRequestError("Invalid user ID")
+	}
+
+	roles, err := h.userService.ListUserRoles(c.Request().Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list the roles of the user", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    roles,
+	})
+}
+
+func (h *RoleHandler) AssignRoleHandler(c echo.Context) error {
+	var req request.UserRole
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.ActorID = jwt.Claims(c).UserID
+
+	if err := h.userService.AssignRole(c.Request().Context(), req); err != nil {
+		h.logger.Error("Failed to assign the role", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Role assigned successfully",
+	})
+}
+
+func (h *RoleHandler) RevokeRoleHandler(c echo.Context) error {
+	var req request.UserRole
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.ActorID = jwt.Claims(c).UserID
+
+	if err := h.userService.RevokeRole(c.Request().Context(), req); err != nil {
+		h.logger.Error("Failed to revoke the role", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Role revoked successfully",
+	})
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type ScheduledTransferHandler struct {
+	logger                   *zap.SugaredLogger
+	scheduledTransferService protocol.ScheduledTransfer
+}
+
+func NewScheduledTransferHandler(logger *zap.SugaredLogger, scheduledTransferService protocol.ScheduledTransfer) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{logger: logger, scheduledTransferService: scheduledTransferService}
+}
+
+func (h *ScheduledTransferHandler) CreateScheduledTransferHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.CreateScheduledTransfer
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	transfer, err := h.scheduledTransferService.CreateScheduledTransfer(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to create the scheduled transfer", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Scheduled transfer created",
+		Data:    transfer,
+	})
+}
+
+func (h *ScheduledTransferHandler) ListScheduledTransfersHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	transfers, err := h.scheduledTransferService.ListScheduledTransfers(ctx, jwt.Claims(c).UserID)
+	if err != nil {
+		h.logger.Error("Failed to list scheduled transfers", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    transfers,
+	})
+}
+
+func (h *ScheduledTransferHandler) GetScheduledTransferHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.ScheduledTransferAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	detail, err := h.scheduledTransferService.GetScheduledTransfer(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to get the scheduled transfer", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Success",
+		Data:    detail,
+	})
+}
+
+func (h *ScheduledTransferHandler) PauseScheduledTransferHandler(c echo.Context) error {
+	return h.action(c, h.scheduledTransferService.PauseScheduledTransfer, "Scheduled transfer paused")
+}
+
+func (h *ScheduledTransferHandler) ResumeScheduledTransferHandler(c echo.Context) error {
+	return h.action(c, h.scheduledTransferService.ResumeScheduledTransfer, "Scheduled transfer resumed")
+}
+
+func (h *ScheduledTransferHandler) CancelScheduledTransferHandler(c echo.Context) error {
+	return h.action(c, h.scheduledTransferService.CancelScheduledTransfer, "Scheduled transfer cancelled")
+}
+
+func (h *ScheduledTransferHandler) action(c echo.Context,
+	apply func(context.Context, request.ScheduledTransferAction) (*entity.ScheduledTransfer, error),
+	message string,
+) error {
+	ctx := c.Request().Context()
+	var req request.ScheduledTransferAction
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	transfer, err := apply(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to update the scheduled transfer", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: message,
+		Data:    transfer,
+	})
+}
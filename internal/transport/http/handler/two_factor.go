@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol/request"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type TwoFactorHandler struct {
+	logger           *zap.SugaredLogger
+	twoFactorService protocol.TwoFactor
+}
+
+func NewTwoFactorHandler(logger *zap.SugaredLogger, twoFactorService protocol.TwoFactor) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		logger:           logger,
+		twoFactorService: twoFactorService,
+	}
+}
+
+func (h *TwoFactorHandler) EnrollHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	enrollment, err := h.twoFactorService.Enroll(ctx, jwt.Claims(c).UserID)
+	if err != nil {
+		h.logger.Error("Failed to start two-factor enrollment", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Scan the QR code with an authenticator app and confirm with a code from it",
+		Data:    enrollment,
+	})
+}
+
+func (h *TwoFactorHandler) ConfirmHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.TwoFactorCode
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	codes, err := h.twoFactorService.Confirm(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to confirm two-factor authentication", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Two-factor authentication turned on; keep the recovery codes somewhere safe",
+		Data:    codes,
+	})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodesHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.TwoFactorCode
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(ctx, req)
+	if err != nil {
+		h.logger.Error("Failed to regenerate the recovery codes", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Recovery codes replaced",
+		Data:    codes,
+	})
+}
+
+func (h *TwoFactorHandler) DisableHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req request.TwoFactorCode
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind request", zap.Error(err))
+		return derror.NewBadRequestError("Invalid request")
+	}
+	req.UserID = jwt.Claims(c).UserID
+
+	if err := h.twoFactorService.Disable(ctx, req); err != nil {
+		h.logger.Error("Failed to turn off two-factor authentication", zap.Error(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, protocol.Success{
+		Message: "Two-factor authentication turned off",
+	})
+}
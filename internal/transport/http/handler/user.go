@@ -19,6 +19,8 @@ func SignUpHandler(userService protocol.User) echo.HandlerFunc {
 		if err := c.Bind(&req); err != nil {
 			return derror.NewBadRequestError(message.InvalidRequest)
 		}
+		req.IPAddress = c.RealIP()
+		req.UserAgent = c.Request().UserAgent()
 
 		tokens, err := userService.SignUp(ctx, req)
 		if err != nil {
@@ -40,6 +42,8 @@ func SignInHandler(userService protocol.User) echo.HandlerFunc {
 		if err := c.Bind(&req); err != nil {
 			return derror.NewBadRequestError(message.InvalidRequest)
 		}
+		req.IPAddress = c.RealIP()
+		req.UserAgent = c.Request().UserAgent()
 
 		tokens, err := userService.SignIn(ctx, req)
 		if err != nil {
@@ -53,13 +57,46 @@ func SignInHandler(userService protocol.User) echo.HandlerFunc {
 	}
 }
 
+// SignInTwoFactorHandler finishes a sign-in that SignInHandler answered with a two-factor
+// challenge. Like the first step it needs no access token.
+func SignInTwoFactorHandler(userService protocol.User) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		var req request.SignInTwoFactor
+
+		if err := c.Bind(&req); err != nil {
+			return derror.NewBadRequestError(message.InvalidRequest)
+		}
+		req.IPAddress = c.RealIP()
+		req.UserAgent = c.Request().UserAgent()
+
+		tokens, err := userService.CompleteSignIn(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, protocol.Success{
+			Message: "success",
+			Data:    tokens,
+		})
+	}
+}
 
+
+// RefreshTokenHandler needs no access token, which has usually expired by the time the
+// client refreshes; the refresh token in the body is the credential.
 func RefreshTokenHandler(userService protocol.User) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := c.Request().Context()
-		userID := jwt.Claims(c).UserID
+		var req request.RefreshToken
 
-		tokens, err := userService.RefreshToken(ctx, userID)
+		if err := c.Bind(&req); err != nil {
+			return derror.NewBadRequestError(message.InvalidRequest)
+		}
+		req.IPAddress = c.RealIP()
+		req.UserAgent = c.Request().UserAgent()
+
+		tokens, err := userService.RefreshToken(ctx, req)
 		if err != nil {
 			return err
 		}
@@ -71,6 +108,59 @@ func RefreshTokenHandler(userService protocol.User) echo.HandlerFunc {
 	}
 }
 
+func ListSessionsHandler(userService protocol.User) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		claims := jwt.Claims(c)
+
+		sessions, err := userService.ListSessions(ctx, claims.UserID, claims.SessionID)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, protocol.Success{
+			Message: "success",
+			Data:    sessions,
+		})
+	}
+}
+
+func RevokeSessionHandler(userService protocol.User) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		var req request.RevokeSession
+
+		if err := c.Bind(&req); err != nil {
+			return derror.NewBadRequestError(message.InvalidRequest)
+		}
+		req.UserID = jwt.Claims(c).UserID
+
+		if err := userService.RevokeSession(ctx, req); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, protocol.Success{
+			Message: "success",
+			Data:    nil,
+		})
+	}
+}
+
+func RevokeAllSessionsHandler(userService protocol.User) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		if err := userService.RevokeAllSessions(ctx, jwt.Claims(c).UserID); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, protocol.Success{
+			Message: "success",
+			Data:    nil,
+		})
+	}
+}
+
 func GetProfileHandler(userService protocol.User) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		ctx := c.Request().Context()
@@ -109,3 +199,46 @@ func EditProfileHandler(userService protocol.User) echo.HandlerFunc {
 		})
 	}
 }
+
+func SendVerificationCodeHandler(userService protocol.User) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		var req request.SendVerificationCode
+
+		if err := c.Bind(&req); err != nil {
+			return derror.NewBadRequestError(message.InvalidRequest)
+		}
+		req.UserID = jwt.Claims(c).UserID
+
+		sent, err := userService.SendVerificationCode(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, protocol.Success{
+			Message: "success",
+			Data:    sent,
+		})
+	}
+}
+
+func ConfirmContactHandler(userService protocol.User) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		var req request.ConfirmContact
+
+		if err := c.Bind(&req); err != nil {
+			return derror.NewBadRequestError(message.InvalidRequest)
+		}
+		req.UserID = jwt.Claims(c).UserID
+
+		if err := userService.ConfirmContact(ctx, req); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, protocol.Success{
+			Message: "success",
+			Data:    nil,
+		})
+	}
+}
@@ -26,6 +26,15 @@ type (
 		CurrencyService      protocol.Currency
 		FinancialAccount     protocol.FinancialAccount
 		AccountTransaction   protocol.AccountTransaction
+		Idempotency          protocol.Idempotency
+		AccountRules         protocol.AccountRules
+		Review               protocol.Review
+		ScheduledTransfer    protocol.ScheduledTransfer
+		CardTransaction      protocol.FinancialCardTransactionService
+		GiftCard             protocol.GiftCard
+		CreditCard           protocol.CreditCard
+		TwoFactor            protocol.TwoFactor
+		Authorizer           protocol.Authorizer
 		JWTSecret            string
 	}
 )
@@ -74,6 +83,15 @@ func New(sc ServerConfig) *Server {
 		sc.CurrencyService,
 		sc.FinancialAccount,
 		sc.AccountTransaction,
+		sc.Idempotency,
+		sc.AccountRules,
+		sc.Review,
+		sc.ScheduledTransfer,
+		sc.CardTransaction,
+		sc.GiftCard,
+		sc.CreditCard,
+		sc.TwoFactor,
+		sc.Authorizer,
 	)
 
 	return server
@@ -2,18 +2,43 @@ package middleware
 
 import (
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror/message"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
-func JWT(secret string) echo.MiddlewareFunc {
+// JWT accepts a request carrying a valid access token whose jti is not on the denylist. A
+// token without a jti could never be revoked, so it is refused.
+func JWT(secret string, denylist protocol.TokenDenylist) echo.MiddlewareFunc {
 	jwtConfig := echojwt.Config{
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(entity.JWTClaims)
 		},
 		SigningKey: []byte(secret),
 	}
+	parse := echojwt.WithConfig(jwtConfig)
 
-	return echojwt.WithConfig(jwtConfig)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return parse(func(c echo.Context) error {
+			token, _ := c.Get("user").(*jwt.Token)
+			claims, _ := token.Claims.(*entity.JWTClaims)
+			if claims.ID == "" {
+				return derror.NewUnauthorizedError(message.InvalidToken)
+			}
+
+			revoked, err := denylist.IsTokenRevoked(c.Request().Context(), claims.ID)
+			if err != nil {
+				return err
+			}
+
+			if revoked {
+				return derror.NewUnauthorizedError(message.InvalidToken)
+			}
+
+			return next(c)
+		})
+	}
 }
@@ -4,9 +4,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestJWT(t *testing.T) {
@@ -23,7 +30,7 @@ func TestJWT(t *testing.T) {
 	secret := "my-secret-key"
 
 	// Define the JWT middleware
-	jwtMiddleware := JWT(secret)
+	jwtMiddleware := JWT(secret, new(protocol.MockTokenDenylist))
 
 	// Create a new Echo group and attach the JWT middleware
 	g := e.Group("/api")
@@ -43,3 +50,57 @@ func TestJWT(t *testing.T) {
 	// Assert that the response code is 401 Unauthorized
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
 }
+
+func TestJWTDenylist(t *testing.T) {
+	secret := "my-secret-key"
+
+	denylist := new(protocol.MockTokenDenylist)
+	denylist.On("IsTokenRevoked", mock.Anything, "live").Return(false, nil)
+	denylist.On("IsTokenRevoked", mock.Anything, "revoked").Return(true, nil)
+
+	mw := JWT(secret, denylist)
+	e := echo.New()
+
+	sign := func(jti string) string {
+		token, err := utils.JWTTokenGenerator{}.GenerateToken(entity.JWTClaims{
+			UserID: 5,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        jti,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}, secret)
+		assert.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		jti     string
+		allowed bool
+	}{
+		{name: "live token", jti: "live", allowed: true},
+		{name: "revoked token", jti: "revoked"},
+		{name: "token without a jti", jti: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+sign(tt.jti))
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			called := false
+			err := mw(func(c echo.Context) error {
+				called = true
+				return nil
+			})(c)
+
+			assert.Equal(t, tt.allowed, called)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, derror.IsHTTPError(err, http.StatusUnauthorized), "got %v", err)
+			}
+		})
+	}
+}
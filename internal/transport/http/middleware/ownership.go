@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+)
+
+// Owns lets a request through only if authorize accepts the caller for the resource whose ID
+// is in the path parameter param. Pass one of the protocol.Authorizer methods. Staff allowed
+// to read any account pass reads without owning the resource.
+func Owns(param string, authorize func(ctx context.Context, claims *entity.JWTClaims, id int) error) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id, err := strconv.Atoi(c.Param(param))
+			if err != nil || id <= 0 {
+				return derror.NewBadRequestError("invalid %s", param)
+			}
+
+			claims := jwt.Claims(c)
+			if readsAny(c, claims) {
+				return next(c)
+			}
+
+			if err := authorize(c.Request().Context(), claims, id); err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Self lets a request through only if the user ID in the path parameter param is the
+// caller's own. Admins may pass any user ID, and staff allowed to read any account may read.
+func Self(param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, err := strconv.Atoi(c.Param(param))
+			if err != nil || userID <= 0 {
+				return derror.NewBadRequestError("invalid %s", param)
+			}
+
+			claims := jwt.Claims(c)
+			if !claims.Admin && !readsAny(c, claims) && claims.UserID != userID {
+				return derror.NewForbiddenError("user %d may not act for user %d", claims.UserID, userID)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func readsAny(c echo.Context, claims *entity.JWTClaims) bool {
+	return c.Request().Method == http.MethodGet && claims.Can(enum.PermissionAccountsReadAll)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func serve(mw echo.MiddlewareFunc, method, param, value string, claims *entity.JWTClaims) (bool, error) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(method, "/", nil), httptest.NewRecorder())
+	c.SetParamNames(param)
+	c.SetParamValues(value)
+	c.Set("user", &jwt.Token{Claims: claims})
+
+	called := false
+	err := mw(func(c echo.Context) error {
+		called = true
+		return nil
+	})(c)
+
+	return called, err
+}
+
+func TestOwns(t *testing.T) {
+	authorizer := new(protocol.MockAuthorizer)
+	owner := &entity.JWTClaims{UserID: 3}
+	other := &entity.JWTClaims{UserID: 4}
+	authorizer.On("AuthorizeAccount", mock.Anything, owner, 1).Return(nil)
+	authorizer.On("AuthorizeAccount", mock.Anything, other, 1).Return(derror.NewForbiddenError("account %d does not belong to the user", 1))
+
+	mw := Owns("id", authorizer.AuthorizeAccount)
+
+	called, err := serve(mw, http.MethodGet, "id", "1", owner)
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	called, err = serve(mw, http.MethodGet, "id", "1", other)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+	assert.False(t, called)
+
+	called, err = serve(mw, http.MethodGet, "id", "abc", owner)
+	assert.True(t, derror.IsHTTPError(err, http.StatusBadRequest), "got %v", err)
+	assert.False(t, called)
+
+	t.Run("Staff reading any account", func(t *testing.T) {
+		support := &entity.JWTClaims{UserID: 8, Permissions: []enum.Permission{enum.PermissionAccountsReadAll}}
+		authorizer.On("AuthorizeAccount", mock.Anything, support, 1).Return(derror.NewForbiddenError("account %d does not belong to the user", 1))
+
+		called, err := serve(mw, http.MethodGet, "id", "1", support)
+		assert.NoError(t, err)
+		assert.True(t, called)
+
+		called, err = serve(mw, http.MethodDelete, "id", "1", support)
+		assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+		assert.False(t, called)
+	})
+}
+
+func TestSelf(t *testing.T) {
+	mw := Self("userID")
+
+	called, err := serve(mw, http.MethodGet, "userID", "3", &entity.JWTClaims{UserID: 3})
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	called, err = serve(mw, http.MethodGet, "userID", "4", &entity.JWTClaims{UserID: 3})
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+	assert.False(t, called)
+
+	called, err = serve(mw, http.MethodGet, "userID", "4", &entity.JWTClaims{UserID: 1, Admin: true})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/jwt"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/labstack/echo/v4"
+)
+
+// Require lets a request through only if the roles of the caller grant at least one of the
+// permissions.
+func Require(permissions ...enum.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := jwt.Claims(c)
+			for _, permission := range permissions {
+				if claims.Can(permission) {
+					return next(c)
+				}
+			}
+
+			return derror.NewForbiddenError("permission %s is required", permissions[0])
+		}
+	}
+}
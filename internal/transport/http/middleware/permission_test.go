@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/pkg/derror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequire(t *testing.T) {
+	mw := Require(enum.PermissionBanksManage)
+
+	treasury := &entity.JWTClaims{UserID: 3, Permissions: []enum.Permission{enum.PermissionBanksRead, enum.PermissionBanksManage}}
+	called, err := serve(mw, http.MethodPost, "", "", treasury)
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	customer := &entity.JWTClaims{UserID: 4, Permissions: []enum.Permission{enum.PermissionBanksRead}}
+	called, err = serve(mw, http.MethodPost, "", "", customer)
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+	assert.False(t, called)
+
+	// Either permission will do
+	called, err = serve(Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), http.MethodGet, "", "", &entity.JWTClaims{
+		UserID: 8, Permissions: []enum.Permission{enum.PermissionAccountsReadAll},
+	})
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	// Being an admin is not a permission of its own
+	called, err = serve(mw, http.MethodPost, "", "", &entity.JWTClaims{UserID: 1, Admin: true})
+	assert.True(t, derror.IsHTTPError(err, http.StatusForbidden), "got %v", err)
+	assert.False(t, called)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"fmt"
 
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/protocol"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/handler"
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/transport/http/middleware"
@@ -17,6 +18,15 @@ func (s *Server) register(secret string, userService protocol.User,
 	currencyService protocol.Currency,
 	financialAccountSerrvice protocol.FinancialAccount,
 	accountTransactionService protocol.AccountTransaction,
+	idempotencyService protocol.Idempotency,
+	accountRulesService protocol.AccountRules,
+	reviewService protocol.Review,
+	scheduledTransferService protocol.ScheduledTransfer,
+	cardTransactionService protocol.FinancialCardTransactionService,
+	giftCardService protocol.GiftCard,
+	creditCardService protocol.CreditCard,
+	twoFactorService protocol.TwoFactor,
+	authorizer protocol.Authorizer,
 ) {
 
 	logConfig := log.Config{
@@ -33,94 +43,193 @@ func (s *Server) register(secret string, userService protocol.User,
 	}
 
 	bankBranchHandler := handler.NewBranchHandler(logger, bankBranchService)
-	financialCardHandler := handler.NewFinancialCardHandler(logger, financialCardService)
+	financialCardHandler := handler.NewFinancialCardHandler(logger, financialCardService, authorizer)
 	currencyHandler := handler.NewCurrencyHandler(logger, currencyService)
-	financialAccountHandler := handler.NewFinancialAccountHandler(logger, financialAccountSerrvice)
-	accountTransactionHandler := handler.NewAccountTransactionHandler(logger, accountTransactionService)
+	financialAccountHandler := handler.NewFinancialAccountHandler(logger, financialAccountSerrvice, authorizer)
+	accountTransactionHandler := handler.NewAccountTransactionHandler(logger, accountTransactionService, idempotencyService, authorizer)
+	accountRulesHandler := handler.NewAccountRulesHandler(logger, accountRulesService)
+	reviewHandler := handler.NewReviewHandler(logger, reviewService)
+	scheduledTransferHandler := handler.NewScheduledTransferHandler(logger, scheduledTransferService)
+	cardTransactionHandler := handler.NewCardTransactionHandler(logger, cardTransactionService, idempotencyService, authorizer)
+	giftCardHandler := handler.NewGiftCardHandler(logger, giftCardService, idempotencyService)
+	creditCardHandler := handler.NewCreditCardHandler(logger, creditCardService, idempotencyService)
+	roleHandler := handler.NewRoleHandler(logger, userService)
+	twoFactorHandler := handler.NewTwoFactorHandler(logger, twoFactorService)
 
+	// Signing in needs no permission, and a refresh only the refresh token it spends
 	auth := s.echo.Group("/auth")
 	auth.POST("/sign-up", handler.SignUpHandler(userService))
 	auth.POST("/sign-in", handler.SignInHandler(userService))
-	auth.POST("/refresh", handler.RefreshTokenHandler(userService), middleware.JWT(secret))
+	auth.POST("/sign-in/2fa", handler.SignInTwoFactorHandler(userService))
+	auth.POST("/refresh", handler.RefreshTokenHandler(userService))
 
-	user := s.echo.Group("/account", middleware.JWT(secret))
-	user.GET("profile", handler.GetProfileHandler(userService))
-	user.PUT("profile", handler.EditProfileHandler(userService))
+	// Every user may see and end their own sessions
+	sessions := s.echo.Group("/auth/sessions", middleware.JWT(secret, userService))
+	sessions.GET("", handler.ListSessionsHandler(userService), middleware.Require(enum.PermissionProfile))
+	sessions.DELETE("", handler.RevokeAllSessionsHandler(userService), middleware.Require(enum.PermissionProfile))
+	sessions.DELETE("/:sessionID", handler.RevokeSessionHandler(userService), middleware.Require(enum.PermissionProfile))
 
-	bank := s.echo.Group("/bank", middleware.JWT(secret))
-	bank.POST("/register", handler.RegisterBankHandler(bankService))
-	bank.GET("/id/:id", handler.GetBankByIDHandler(bankService))
-	bank.GET("/code/:code", handler.GetBankByCodeHandler(bankService))
-	bank.GET("/name/:name", handler.GetBankByNameHandler(bankService))
-	bank.PUT("/update", handler.UpdateBankDetailsHandler(bankService))
-	bank.GET("/list", handler.ListAllBanksHandler(bankService))
-	bank.GET("/status/:status", handler.ListBanksByStatusHandler(bankService))
+	user := s.echo.Group("/account", middleware.JWT(secret, userService))
+	user.GET("profile", handler.GetProfileHandler(userService), middleware.Require(enum.PermissionProfile))
+	user.PUT("profile", handler.EditProfileHandler(userService), middleware.Require(enum.PermissionProfile))
+	user.POST("/verify/:channel", handler.SendVerificationCodeHandler(userService), middleware.Require(enum.PermissionProfile))
+	user.POST("/verify/:channel/confirm", handler.ConfirmContactHandler(userService), middleware.Require(enum.PermissionProfile))
+	user.POST("/2fa/enroll", twoFactorHandler.EnrollHandler, middleware.Require(enum.PermissionProfile))
+	user.POST("/2fa/confirm", twoFactorHandler.ConfirmHandler, middleware.Require(enum.PermissionProfile))
+	user.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodesHandler, middleware.Require(enum.PermissionProfile))
+	user.DELETE("/2fa", twoFactorHandler.DisableHandler, middleware.Require(enum.PermissionProfile))
+
+	bank := s.echo.Group("/bank", middleware.JWT(secret, userService))
+	bank.POST("/register", handler.RegisterBankHandler(bankService), middleware.Require(enum.PermissionBanksManage))
+	bank.GET("/id/:id", handler.GetBankByIDHandler(bankService), middleware.Require(enum.PermissionBanksRead))
+	bank.GET("/code/:code", handler.GetBankByCodeHandler(bankService), middleware.Require(enum.PermissionBanksRead))
+	bank.GET("/name/:name", handler.GetBankByNameHandler(bankService), middleware.Require(enum.PermissionBanksRead))
+	bank.PUT("/update", handler.UpdateBankDetailsHandler(bankService), middleware.Require(enum.PermissionBanksManage))
+	bank.GET("/list", handler.ListAllBanksHandler(bankService), middleware.Require(enum.PermissionBanksRead))
+	bank.GET("/status/:status", handler.ListBanksByStatusHandler(bankService), middleware.Require(enum.PermissionBanksRead))
 
 	// Adding new group for bank-branch
-	branch := s.echo.Group("/branch", middleware.JWT(secret))
-	branch.POST("/add", bankBranchHandler.AddBranchHandler(bankBranchService))
-	branch.GET("/id/:id", bankBranchHandler.GetBranchByIDHandler(bankBranchService))
-	branch.GET("/name/:name", bankBranchHandler.GetBranchByNameHandler(bankBranchService))
-	branch.GET("/code/:code", bankBranchHandler.GetBranchByCodeHandler(bankBranchService))
-	branch.PUT("/update", bankBranchHandler.UpdateBranchHandler(bankBranchService))
-	branch.DELETE("/delete/:id", bankBranchHandler.DeleteBranchHandler(bankBranchService))
-	branch.GET("/list", bankBranchHandler.ListAllBranchesHandler(bankBranchService))
-	branch.GET("/status/:status", bankBranchHandler.ListBranchesByStatusHandler(bankBranchService))
-	branch.GET("/listByBank/:id", bankBranchHandler.ListBranchesByBankIDHandler(bankBranchService))
+	branch := s.echo.Group("/branch", middleware.JWT(secret, userService))
+	branch.POST("/add", bankBranchHandler.AddBranchHandler(bankBranchService), middleware.Require(enum.PermissionBanksManage))
+	branch.GET("/id/:id", bankBranchHandler.GetBranchByIDHandler(bankBranchService), middleware.Require(enum.PermissionBanksRead))
+	branch.GET("/name/:name", bankBranchHandler.GetBranchByNameHandler(bankBranchService), middleware.Require(enum.PermissionBanksRead))
+	branch.GET("/code/:code", bankBranchHandler.GetBranchByCodeHandler(bankBranchService), middleware.Require(enum.PermissionBanksRead))
+	branch.PUT("/update", bankBranchHandler.UpdateBranchHandler(bankBranchService), middleware.Require(enum.PermissionBanksManage))
+	branch.DELETE("/delete/:id", bankBranchHandler.DeleteBranchHandler(bankBranchService), middleware.Require(enum.PermissionBanksManage))
+	branch.GET("/list", bankBranchHandler.ListAllBranchesHandler(bankBranchService), middleware.Require(enum.PermissionBanksRead))
+	branch.GET("/status/:status", bankBranchHandler.ListBranchesByStatusHandler(bankBranchService), middleware.Require(enum.PermissionBanksRead))
+	branch.GET("/listByBank/:id", bankBranchHandler.ListBranchesByBankIDHandler(bankBranchService), middleware.Require(enum.PermissionBanksRead))
 
 	// Adding new group for financial-card
-	card := s.echo.Group("/card", middleware.JWT(secret))
-	card.POST("/register", financialCardHandler.RegisterCardHandler)
-	card.PUT("/update", financialCardHandler.UpdateCardHandler)
-	card.DELETE("/delete/:id", financialCardHandler.DeleteCardHandler)
-	card.GET("/id/:id", financialCardHandler.GetCardByIDHandler)
-	card.GET("/listByAccount/:id", financialCardHandler.ListCardsByAccountIDHandler)
-	card.GET("/listByType/:type", financialCardHandler.ListCardsByTypeHandler)
+	card := s.echo.Group("/card", middleware.JWT(secret, userService))
+	card.POST("/register", financialCardHandler.RegisterCardHandler, middleware.Require(enum.PermissionCardsUse))
+	card.POST("/issueVirtual", financialCardHandler.IssueVirtualCardHandler, middleware.Require(enum.PermissionCardsUse))
+	card.PUT("/update", financialCardHandler.UpdateCardHandler, middleware.Require(enum.PermissionCardsUse))
+	card.DELETE("/delete/:id", financialCardHandler.DeleteCardHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("id", authorizer.AuthorizeCard))
+	card.GET("/id/:id", financialCardHandler.GetCardByIDHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeCard))
+	card.GET("/listByAccount/:id", financialCardHandler.ListCardsByAccountIDHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeAccount))
+	card.GET("/listByType/:type", financialCardHandler.ListCardsByTypeHandler, middleware.Require(enum.PermissionAccountsReadAll))
+	card.POST("/:cardID/freeze", financialCardHandler.FreezeCardHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.POST("/:cardID/unfreeze", financialCardHandler.UnfreezeCardHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.POST("/:cardID/reportLost", financialCardHandler.ReportCardLostHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.POST("/:cardID/reportStolen", financialCardHandler.ReportCardStolenHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.POST("/:cardID/reissue", financialCardHandler.ReissueCardHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.GET("/:cardID/events", financialCardHandler.ListCardEventsHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.GET("/:cardID/controls", financialCardHandler.GetCardControlsHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	card.PUT("/:cardID/controls", financialCardHandler.UpdateCardControlsHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
 
 	// Adding new group for currency
-	currency := s.echo.Group("/currency", middleware.JWT(secret))
-	currency.POST("/add", currencyHandler.AddCurrencyHandler)
-	currency.PUT("/update", currencyHandler.UpdateCurrencyHandler)
-	currency.DELETE("/delete/:id", currencyHandler.DeleteCurrencyHandler)
-	currency.GET("/id/:id", currencyHandler.GetCurrencyByIDHandler)
-	currency.GET("/name/:name", currencyHandler.GetCurrencyByNameHandler)
-	currency.GET("/list", currencyHandler.ListCurrenciesHandler)
-	currency.GET("/exchangeRate/:fromCode/:toCode", currencyHandler.GetExchangeRateHandler)
-	currency.PUT("/bulkUpdateExchangeRates", currencyHandler.BulkUpdateExchangeRatesHandler)
-	currency.GET("/search/:query", currencyHandler.SearchCurrenciesHandler)
-	currency.GET("/convert/:fromCode/:toCode/:amount", currencyHandler.ConvertAmountHandler)
-	currency.GET("/compare/:firstCode/:secondCode", currencyHandler.CompareCurrenciesHandler)
-	currency.GET("/trends/:code/:duration", currencyHandler.GetCurrencyTrendsHandler)
-	currency.GET("/strongest", currencyHandler.GetStrongestCurrencyHandler)
-	currency.GET("/weakest", currencyHandler.GetWeakestCurrencyHandler)
-	currency.PUT("/notifyUsersOnExchangeRateChange/:threshold", currencyHandler.NotifyUsersOnExchangeRateChangeHandler)
-	currency.GET("/countries/:code", currencyHandler.GetCountriesUsingCurrencyHandler)
+	currency := s.echo.Group("/currency", middleware.JWT(secret, userService))
+	currency.POST("/add", currencyHandler.AddCurrencyHandler, middleware.Require(enum.PermissionCurrenciesManage))
+	currency.PUT("/update", currencyHandler.UpdateCurrencyHandler, middleware.Require(enum.PermissionCurrenciesManage))
+	currency.DELETE("/delete/:id", currencyHandler.DeleteCurrencyHandler, middleware.Require(enum.PermissionCurrenciesManage))
+	currency.GET("/id/:id", currencyHandler.GetCurrencyByIDHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/name/:name", currencyHandler.GetCurrencyByNameHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/list", currencyHandler.ListCurrenciesHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/exchangeRate/:fromCode/:toCode", currencyHandler.GetExchangeRateHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.PUT("/bulkUpdateExchangeRates", currencyHandler.BulkUpdateExchangeRatesHandler, middleware.Require(enum.PermissionCurrenciesManage))
+	currency.GET("/search/:query", currencyHandler.SearchCurrenciesHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/convert/:fromCode/:toCode/:amount", currencyHandler.ConvertAmountHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/compare/:firstCode/:secondCode", currencyHandler.CompareCurrenciesHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/trends/:code/:duration", currencyHandler.GetCurrencyTrendsHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/strongest", currencyHandler.GetStrongestCurrencyHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.GET("/weakest", currencyHandler.GetWeakestCurrencyHandler, middleware.Require(enum.PermissionCurrenciesRead))
+	currency.PUT("/notifyUsersOnExchangeRateChange/:threshold", currencyHandler.NotifyUsersOnExchangeRateChangeHandler, middleware.Require(enum.PermissionCurrenciesManage))
+	currency.GET("/countries/:code", currencyHandler.GetCountriesUsingCurrencyHandler, middleware.Require(enum.PermissionCurrenciesRead))
 
 	// Adding new group for financial-account
-	account := s.echo.Group("/account", middleware.JWT(secret))
-	account.POST("/register", financialAccountHandler.CreateAccountHandler)
-	account.GET("/id/:id", financialAccountHandler.GetAccountByIDHandler)
-	account.PUT("/update", financialAccountHandler.UpdateAccountHandler)
-	account.DELETE("/delete/:id", financialAccountHandler.DeleteAccountHandler)
-	account.GET("/listByUserID/:userID", financialAccountHandler.ListAccountsByUserIDHandler)
-	account.GET("/listByStatus/:status", financialAccountHandler.ListAccountsByStatusHandler)
-	account.GET("/verify/:id", financialAccountHandler.VerifyAccountHandler)
-	account.GET("/shaba/:shabaNumber", financialAccountHandler.GetAccountByShabaHandler)
-
-	account.GET("/listByType/:type", financialAccountHandler.ListAccountsByTypeHandler)
-	account.GET("/currency/:id", financialAccountHandler.GetAccountCurrencyHandler)
-	account.GET("/branch/:id", financialAccountHandler.GetBranchForAccountHandler)
-	account.GET("/bank/:id", financialAccountHandler.GetBankForAccountHandler)
+	account := s.echo.Group("/account", middleware.JWT(secret, userService))
+	account.POST("/register", financialAccountHandler.CreateAccountHandler, middleware.Require(enum.PermissionAccountsUse))
+	account.GET("/id/:id", financialAccountHandler.GetAccountByIDHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeAccount))
+	account.PUT("/update", financialAccountHandler.UpdateAccountHandler, middleware.Require(enum.PermissionAccountsUse))
+	account.DELETE("/delete/:id", financialAccountHandler.DeleteAccountHandler, middleware.Require(enum.PermissionAccountsUse), middleware.Owns("id", authorizer.AuthorizeAccount))
+	account.GET("/listByUserID/:userID", financialAccountHandler.ListAccountsByUserIDHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Self("userID"))
+	account.GET("/listByStatus/:status", financialAccountHandler.ListAccountsByStatusHandler, middleware.Require(enum.PermissionAccountsReadAll))
+	account.GET("/verify/:id", financialAccountHandler.VerifyAccountHandler, middleware.Require(enum.PermissionAccountsVerify))
+	// Any user may look up a payee by Shaba number; the handler only shows the full record to the owner
+	account.GET("/shaba/:shabaNumber", financialAccountHandler.GetAccountByShabaHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll))
+
+	account.GET("/listByType/:type", financialAccountHandler.ListAccountsByTypeHandler, middleware.Require(enum.PermissionAccountsReadAll))
+	account.GET("/currency/:id", financialAccountHandler.GetAccountCurrencyHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeAccount))
+	account.GET("/branch/:id", financialAccountHandler.GetBranchForAccountHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeAccount))
+	account.GET("/bank/:id", financialAccountHandler.GetBankForAccountHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeAccount))
 
 	// Adding new group for account-transaction operations
-	accountTransaction := s.echo.Group("/accountTransaction", middleware.JWT(secret))
-	accountTransaction.POST("/registerTransaction", accountTransactionHandler.RegisterTransactionHandler)
-	accountTransaction.DELETE("/transaction/:transactionID", accountTransactionHandler.DeleteTransactionHandler)
-	accountTransaction.GET("/transaction/:transactionID", accountTransactionHandler.GetTransactionByIDHandler)
-	accountTransaction.GET("/transactions/account/:accountID", accountTransactionHandler.ListTransactionsByAccountIDHandler)
-	accountTransaction.GET("/transactions/group/:groupID", accountTransactionHandler.ListTransactionsByGroupIDHandler)
-	accountTransaction.PUT("/transaction/cancel/:transactionID", accountTransactionHandler.CancelTransactionHandler)
-	accountTransaction.POST("/transfer", accountTransactionHandler.TransferHandler)
-	accountTransaction.GET("/transactionHistory/:id", accountTransactionHandler.GetAccountTransactionHistoryHandler)
+	accountTransaction := s.echo.Group("/accountTransaction", middleware.JWT(secret, userService))
+	accountTransaction.POST("/registerTransaction", accountTransactionHandler.RegisterTransactionHandler, middleware.Require(enum.PermissionTransactionsPost))
+	accountTransaction.GET("/transaction/:transactionID", accountTransactionHandler.GetTransactionByIDHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("transactionID", authorizer.AuthorizeAccountTransaction))
+	accountTransaction.GET("/transactions/account/:accountID", accountTransactionHandler.ListTransactionsByAccountIDHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("accountID", authorizer.AuthorizeAccount))
+	accountTransaction.GET("/transactions/group/:groupID", accountTransactionHandler.ListTransactionsByGroupIDHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("groupID", authorizer.AuthorizeAccountTransactionGroup))
+	accountTransaction.PUT("/transaction/cancel/:transactionID", accountTransactionHandler.CancelTransactionHandler, middleware.Require(enum.PermissionTransactionsReverse))
+	accountTransaction.POST("/transactions/group/:groupID/reverse", accountTransactionHandler.ReverseTransactionGroupHandler, middleware.Require(enum.PermissionTransactionsReverse))
+	accountTransaction.POST("/transfer", accountTransactionHandler.TransferHandler, middleware.Require(enum.PermissionTransfersMake))
+	accountTransaction.POST("/transfer/quote", accountTransactionHandler.QuoteTransferHandler, middleware.Require(enum.PermissionTransfersMake))
+	accountTransaction.POST("/transfer/quote/:quoteID/execute", accountTransactionHandler.ExecuteQuoteHandler, middleware.Require(enum.PermissionTransfersMake))
+	accountTransaction.GET("/transactionHistory/:id", accountTransactionHandler.GetAccountTransactionHistoryHandler, middleware.Require(enum.PermissionAccountsUse, enum.PermissionAccountsReadAll), middleware.Owns("id", authorizer.AuthorizeAccount))
+
+	// One-off future transfers and standing orders, run by the scheduler
+	scheduledTransfer := s.echo.Group("/scheduledTransfer", middleware.JWT(secret, userService))
+	scheduledTransfer.POST("", scheduledTransferHandler.CreateScheduledTransferHandler, middleware.Require(enum.PermissionTransfersMake))
+	scheduledTransfer.GET("", scheduledTransferHandler.ListScheduledTransfersHandler, middleware.Require(enum.PermissionTransfersMake))
+	scheduledTransfer.GET("/:scheduledTransferID", scheduledTransferHandler.GetScheduledTransferHandler, middleware.Require(enum.PermissionTransfersMake))
+	scheduledTransfer.POST("/:scheduledTransferID/pause", scheduledTransferHandler.PauseScheduledTransferHandler, middleware.Require(enum.PermissionTransfersMake))
+	scheduledTransfer.POST("/:scheduledTransferID/resume", scheduledTransferHandler.ResumeScheduledTransferHandler, middleware.Require(enum.PermissionTransfersMake))
+	scheduledTransfer.POST("/:scheduledTransferID/cancel", scheduledTransferHandler.CancelScheduledTransferHandler, middleware.Require(enum.PermissionTransfersMake))
+
+	// Card payments, posted to the ledger of the account linked to each card
+	cardTransaction := s.echo.Group("/cardTransaction", middleware.JWT(secret, userService))
+	cardTransaction.POST("/purchase", cardTransactionHandler.PurchaseHandler, middleware.Require(enum.PermissionCardsUse))
+	cardTransaction.POST("/transfer", cardTransactionHandler.TransferHandler, middleware.Require(enum.PermissionCardsUse))
+	cardTransaction.GET("/transaction/:transactionID", cardTransactionHandler.GetTransactionByIDHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("transactionID", authorizer.AuthorizeCardTransaction))
+	cardTransaction.GET("/transactions/card/:cardID", cardTransactionHandler.ListTransactionsByCardIDHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	cardTransaction.GET("/transactions/group/:groupID", cardTransactionHandler.ListTransactionsByGroupIDHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("groupID", authorizer.AuthorizeCardTransactionGroup))
+	cardTransaction.POST("/authorize", cardTransactionHandler.AuthorizeHandler, middleware.Require(enum.PermissionCardsUse))
+	cardTransaction.GET("/holds/card/:cardID", cardTransactionHandler.ListHoldsByCardIDHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	cardTransaction.GET("/balance/card/:cardID", cardTransactionHandler.GetCardBalanceHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+
+	// Gift cards are bought from a wallet account and used by whoever holds the code
+	giftCard := s.echo.Group("/giftCard", middleware.JWT(secret, userService))
+	giftCard.POST("/issue", giftCardHandler.IssueGiftCardHandler, middleware.Require(enum.PermissionCardsUse))
+	giftCard.POST("/redeem", giftCardHandler.RedeemGiftCardHandler, middleware.Require(enum.PermissionCardsUse))
+	giftCard.POST("/spend", giftCardHandler.SpendGiftCardHandler, middleware.Require(enum.PermissionCardsUse))
+	giftCard.POST("/balance", giftCardHandler.GetGiftCardBalanceHandler, middleware.Require(enum.PermissionCardsUse))
+	giftCard.GET("/list", giftCardHandler.ListGiftCardsHandler, middleware.Require(enum.PermissionCardsUse))
+
+	// Credit lines of credit cards, their monthly statements and the payments towards them
+	creditCard := s.echo.Group("/creditCard", middleware.JWT(secret, userService))
+	creditCard.GET("/:cardID", creditCardHandler.GetCreditLineHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	creditCard.POST("/:cardID/pay", creditCardHandler.MakePaymentHandler, middleware.Require(enum.PermissionCardsUse), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	creditCard.GET("/:cardID/statements", creditCardHandler.ListStatementsHandler, middleware.Require(enum.PermissionCardsUse, enum.PermissionAccountsReadAll), middleware.Owns("cardID", authorizer.AuthorizeCard))
+	creditCard.GET("/statement/:statementID", creditCardHandler.GetStatementHandler, middleware.Require(enum.PermissionCardsUse))
+
+	// Staff operations, each limited to the roles granted its permission
+	admin := s.echo.Group("/admin", middleware.JWT(secret, userService))
+
+	// Management of per-account transaction rules
+	admin.GET("/accountRules/:accountID", accountRulesHandler.GetRulesHandler, middleware.Require(enum.PermissionAccountRules))
+	admin.PUT("/accountRules/:accountID", accountRulesHandler.UpdateRulesHandler, middleware.Require(enum.PermissionAccountRules))
+
+	// Manual review of transfers held by the risk checks
+	admin.GET("/reviews", reviewHandler.ListCasesHandler, middleware.Require(enum.PermissionReviewsManage))
+	admin.GET("/reviews/:caseID", reviewHandler.GetCaseHandler, middleware.Require(enum.PermissionReviewsManage))
+	admin.POST("/reviews/:caseID/assign", reviewHandler.AssignCaseHandler, middleware.Require(enum.PermissionReviewsManage))
+	admin.POST("/reviews/:caseID/claim", reviewHandler.ClaimCaseHandler, middleware.Require(enum.PermissionReviewsManage))
+	admin.POST("/reviews/:caseID/approve", reviewHandler.ApproveCaseHandler, middleware.Require(enum.PermissionReviewsManage))
+	admin.POST("/reviews/:caseID/reject", reviewHandler.RejectCaseHandler, middleware.Require(enum.PermissionReviewsManage))
+
+	// Merchant refunds of card purchases and settlement of authorization holds
+	admin.POST("/cardTransaction/:transactionID/refund", cardTransactionHandler.RefundHandler, middleware.Require(enum.PermissionCardsSettle))
+	admin.POST("/cardHold/:holdID/capture", cardTransactionHandler.CaptureHandler, middleware.Require(enum.PermissionCardsSettle))
+	admin.POST("/cardHold/:holdID/release", cardTransactionHandler.ReleaseHoldHandler, middleware.Require(enum.PermissionCardsSettle))
+
+	// Credit lines are opened and their limits set by the issuer
+	admin.POST("/creditCard/:cardID/creditLine", creditCardHandler.OpenCreditLineHandler, middleware.Require(enum.PermissionCreditManage))
+	admin.PUT("/creditCard/:cardID/limit", creditCardHandler.UpdateCreditLimitHandler, middleware.Require(enum.PermissionCreditManage))
+
+	// Roles and the permissions they grant. Changes apply once the user's token is refreshed.
+	admin.GET("/roles", roleHandler.ListRolesHandler, middleware.Require(enum.PermissionRolesManage))
+	admin.GET("/users/:userID/roles", roleHandler.ListUserRolesHandler, middleware.Require(enum.PermissionRolesManage))
+	admin.PUT("/users/:userID/roles/:role", roleHandler.AssignRoleHandler, middleware.Require(enum.PermissionRolesManage))
+	admin.DELETE("/users/:userID/roles/:role", roleHandler.RevokeRoleHandler, middleware.Require(enum.PermissionRolesManage))
 
 }
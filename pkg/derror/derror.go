@@ -12,6 +12,7 @@ type Error struct {
 	Message string
 	Args    []interface{}
 	Code    int
+	Details any
 }
 
 // NewError creates a generic error with a custom message, status code, and arguments.
@@ -42,6 +43,29 @@ func NewBadRequestError(msg string, args ...interface{}) error {
 	return NewError(msg, http.StatusBadRequest, args...)
 }
 
+// NewUnauthorizedError creates a 401 Unauthorized error.
+func NewUnauthorizedError(msg string, args ...interface{}) error {
+	return NewError(msg, http.StatusUnauthorized, args...)
+}
+
+// NewForbiddenError creates a 403 Forbidden error.
+func NewForbiddenError(msg string, args ...interface{}) error {
+	return NewError(msg, http.StatusForbidden, args...)
+}
+
+// NewConflictError creates a 409 Conflict error.
+func NewConflictError(msg string, args ...interface{}) error {
+	return NewError(msg, http.StatusConflict, args...)
+}
+
+// WithDetails attaches structured details, such as the rules a request violated, to err.
+func WithDetails(err error, details any) error {
+	if derr, ok := err.(*Error); ok {
+		derr.Details = details
+	}
+	return err
+}
+
 // IsHTTPError checks if the error corresponds to a specific HTTP status code.
 func IsHTTPError(err error, code int) bool {
 	derr, ok := err.(*Error)
@@ -0,0 +1,43 @@
+// Package luhn computes the check digit that ends every payment card number.
+package luhn
+
+// Valid reports whether the digits end with the right check digit: every second digit from
+// the right is doubled, and the digits of the result must add up to a multiple of ten. An
+// empty number, or one with anything but digits, is not valid.
+func Valid(number string) bool {
+	if number == "" || !digitsOnly(number) {
+		return false
+	}
+	return sum(number, false)%10 == 0
+}
+
+// CheckDigit returns the digit that makes partial followed by it valid. partial must hold
+// only digits.
+func CheckDigit(partial string) byte {
+	return byte('0' + (10-sum(partial, true)%10)%10)
+}
+
+func sum(digits string, double bool) int {
+	total := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		total += digit
+		double = !double
+	}
+	return total
+}
+
+func digitsOnly(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
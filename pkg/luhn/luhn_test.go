@@ -0,0 +1,66 @@
+package luhn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		want   bool
+	}{
+		{name: "visa", number: "4111111111111111", want: true},
+		{name: "mastercard", number: "5500005555555559", want: true},
+		{name: "discover", number: "6011111111111117", want: true},
+		{name: "odd length amex", number: "378282246310005", want: true},
+		{name: "odd length", number: "79927398713", want: true},
+		{name: "even length", number: "18", want: true},
+		{name: "single zero", number: "0", want: true},
+		{name: "last digit changed", number: "4111111111111112", want: false},
+		{name: "middle digit changed", number: "4111111151111111", want: false},
+		{name: "odd length digit changed", number: "79927398712", want: false},
+		{name: "empty", number: "", want: false},
+		{name: "letters", number: "41111111111111a1", want: false},
+		{name: "spaces", number: "4111 1111 1111 1111", want: false},
+		{name: "sign", number: "-18", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Valid(tt.number))
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+		want    byte
+	}{
+		{name: "visa", partial: "411111111111111", want: '1'},
+		{name: "mastercard", partial: "550000555555555", want: '9'},
+		{name: "odd length partial", partial: "7992739871", want: '3'},
+		{name: "even length partial", partial: "37828224631000", want: '5'},
+		{name: "single digit", partial: "1", want: '8'},
+		{name: "empty", partial: "", want: '0'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digit := CheckDigit(tt.partial)
+			assert.Equal(t, string(tt.want), string(digit))
+
+			// Appending the check digit always gives a valid number, and any other digit does not
+			assert.True(t, Valid(tt.partial+string(digit)))
+			for other := byte('0'); other <= '9'; other++ {
+				if other != digit {
+					assert.False(t, Valid(tt.partial+string(other)), "check digit %c", other)
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
 	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity"
+	"github.com/delaram-gholampoor-sagha/Digital-Wallet/internal/entity/enum"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -23,3 +32,68 @@ type JWTTokenGenerator struct{}
 func (t JWTTokenGenerator) GenerateToken(claims entity.JWTClaims, secret string) (string, error) {
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
 }
+
+// LogNotifier writes notifications to the log instead of delivering them, for development
+// and until a gateway is wired in.
+type LogNotifier struct {
+	Logger *zap.SugaredLogger
+}
+
+func (n LogNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.Logger.Infow("Notification",
+		"userID", notification.UserID,
+		"channel", notification.Channel,
+		"destination", notification.Destination,
+		"subject", notification.Subject,
+		"body", notification.Body)
+	return nil
+}
+
+// FileNotifier appends notifications to a file, one JSON object a line, so that the codes
+// sent while trying the wallet out can be read back. Like LogNotifier it delivers nothing.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+type notificationRecord struct {
+	SentAt      time.Time           `json:"sent_at"`
+	UserID      int                 `json:"user_id"`
+	Channel     enum.ContactChannel `json:"channel,omitempty"`
+	Destination string              `json:"destination,omitempty"`
+	Subject     string              `json:"subject"`
+	Body        string              `json:"body"`
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	line, err := json.Marshal(notificationRecord{
+		SentAt:      time.Now(),
+		UserID:      notification.UserID,
+		Channel:     notification.Channel,
+		Destination: notification.Destination,
+		Subject:     notification.Subject,
+		Body:        notification.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("utils.FileNotifier.Notify.Marshal: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("utils.FileNotifier.Notify.OpenFile: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("utils.FileNotifier.Notify.Write: %w", err)
+	}
+
+	return nil
+}
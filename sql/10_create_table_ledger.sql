@@ -0,0 +1,68 @@
+CREATE TABLE public.journal_entry (
+    journal_entry_id SERIAL PRIMARY KEY, -- Referenced by account_transaction.transaction_group_id
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE public.ledger_posting (
+    posting_id SERIAL PRIMARY KEY,
+    journal_entry_id INT NOT NULL REFERENCES public.journal_entry,
+    financial_account_id INT NOT NULL REFERENCES public.financial_account,
+    currency_code CHAR(3) NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL, -- Positive for credits, negative for debits
+    balance_after DECIMAL(15, 2) NOT NULL, -- Running balance of the account in this currency
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX ledger_posting_account_idx ON public.ledger_posting (financial_account_id, posting_id);
+
+-- Cached balance per account and currency, maintained in the same transaction as the postings.
+-- It can always be rebuilt with SUM(amount) over ledger_posting.
+CREATE TABLE public.account_balance (
+    financial_account_id INT NOT NULL REFERENCES public.financial_account,
+    currency_code CHAR(3) NOT NULL,
+    balance DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (financial_account_id, currency_code)
+);
+
+-- The ledger is append-only: postings and journal entries are corrected by new entries, never edited.
+CREATE FUNCTION public.ledger_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_posting_append_only
+    BEFORE UPDATE OR DELETE ON public.ledger_posting
+    FOR EACH ROW EXECUTE FUNCTION public.ledger_reject_change();
+
+CREATE TRIGGER journal_entry_append_only
+    BEFORE UPDATE OR DELETE ON public.journal_entry
+    FOR EACH ROW EXECUTE FUNCTION public.ledger_reject_change();
+
+-- Postings of a journal entry must sum to zero per currency. The check is deferred to commit
+-- so that all legs of an entry can be inserted first.
+CREATE FUNCTION public.ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM public.ledger_posting
+        WHERE journal_entry_id = NEW.journal_entry_id
+        GROUP BY currency_code
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_posting_balanced
+    AFTER INSERT ON public.ledger_posting
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION public.ledger_check_balanced();
+
+ALTER TABLE public.account_transaction
+    ADD CONSTRAINT account_transaction_journal_entry_fk
+    FOREIGN KEY (transaction_group_id) REFERENCES public.journal_entry (journal_entry_id);
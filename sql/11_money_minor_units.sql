@@ -0,0 +1,45 @@
+-- Money is stored as BIGINT minor units next to its currency code, so amounts are exact
+-- in every currency (0 decimals for JPY/KRW, 3 for BHD/JOD/OMR, 2 for most others).
+CREATE FUNCTION public.currency_exponent(code CHAR(3)) RETURNS INT AS $$
+    SELECT CASE
+        WHEN code IN ('JPY', 'KRW', 'CLP', 'VND', 'UGX') THEN 0
+        WHEN code IN ('BHD', 'JOD', 'OMR') THEN 3
+        ELSE 2
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE public.ledger_posting DISABLE TRIGGER ledger_posting_append_only;
+
+ALTER TABLE public.ledger_posting
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN balance_after TYPE BIGINT USING ROUND(balance_after * 10 ^ public.currency_exponent(currency_code))::BIGINT;
+
+ALTER TABLE public.ledger_posting ENABLE TRIGGER ledger_posting_append_only;
+
+ALTER TABLE public.account_balance
+    ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 10 ^ public.currency_exponent(currency_code))::BIGINT;
+
+ALTER TABLE public.account_transaction ADD COLUMN currency_code CHAR(3);
+
+UPDATE public.account_transaction t
+SET currency_code = a.currency_code
+FROM public.financial_account a
+WHERE a.account_id = t.financial_account_id;
+
+ALTER TABLE public.account_transaction
+    ALTER COLUMN currency_code SET NOT NULL,
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 10 ^ public.currency_exponent(currency_code))::BIGINT;
+
+ALTER TABLE public.card_transaction ADD COLUMN currency_code CHAR(3);
+
+UPDATE public.card_transaction t
+SET currency_code = a.currency_code
+FROM public.financial_cards c
+JOIN public.financial_account a ON a.account_id = c.account_id
+WHERE c.card_id = t.financial_card_id;
+
+ALTER TABLE public.card_transaction
+    ALTER COLUMN currency_code SET NOT NULL,
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 10 ^ public.currency_exponent(currency_code))::BIGINT;
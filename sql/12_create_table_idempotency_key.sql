@@ -0,0 +1,14 @@
+CREATE TABLE public.idempotency_key (
+    user_id INT NOT NULL REFERENCES public.user,
+    scope VARCHAR(64) NOT NULL, -- The operation the key belongs to, e.g. accountTransaction.transfer
+    key VARCHAR(255) NOT NULL, -- Client supplied Idempotency-Key header
+    fingerprint CHAR(64) NOT NULL, -- SHA-256 of the request body
+    status SMALLINT NOT NULL, -- 0 in flight, 1 completed
+    response JSONB, -- Stored response, replayed for retries
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, scope, key)
+);
+
+CREATE INDEX idempotency_key_expires_at_idx ON public.idempotency_key (expires_at);
@@ -0,0 +1,30 @@
+-- A quote locks an exchange rate for a cross-currency transfer until expires_at.
+CREATE TABLE public.fx_quote (
+    fx_quote_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES public.user,
+    sender_account_id INT NOT NULL REFERENCES public.financial_account,
+    receiver_account_id INT NOT NULL REFERENCES public.financial_account,
+    source_currency CHAR(3) NOT NULL,
+    source_amount BIGINT NOT NULL, -- Debited from the sender, in minor units of source_currency
+    target_currency CHAR(3) NOT NULL,
+    target_amount BIGINT NOT NULL, -- Credited to the receiver, in minor units of target_currency
+    mid_rate NUMERIC(20, 6) NOT NULL, -- Market rate, target units per source unit
+    rate NUMERIC(20, 6) NOT NULL, -- Rate applied to the customer after the spread
+    spread_bps INT NOT NULL,
+    spread BIGINT NOT NULL, -- Kept by the FX house account, in minor units of target_currency
+    status SMALLINT NOT NULL, -- 0 open, 1 executed
+    journal_entry_id INT REFERENCES public.journal_entry,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX fx_quote_user_idx ON public.fx_quote (user_id, fx_quote_id);
+
+-- Cross-currency entries carry the quote they executed, its rates and the spread.
+ALTER TABLE public.journal_entry
+    ADD COLUMN fx_quote_id INT REFERENCES public.fx_quote,
+    ADD COLUMN exchange_rate NUMERIC(20, 6),
+    ADD COLUMN mid_rate NUMERIC(20, 6),
+    ADD COLUMN fx_spread BIGINT,
+    ADD COLUMN fx_spread_currency CHAR(3);
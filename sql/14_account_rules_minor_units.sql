@@ -0,0 +1,30 @@
+-- Rule amounts are compared with Money, so they move to BIGINT minor units in the account's currency.
+ALTER TABLE public.account_rules ADD COLUMN currency_code CHAR(3);
+
+UPDATE public.account_rules r
+SET currency_code = a.currency_code
+FROM public.financial_account a
+WHERE a.account_id = r.financial_account_id;
+
+ALTER TABLE public.account_rules
+    ALTER COLUMN currency_code SET NOT NULL,
+    ALTER COLUMN min_amount TYPE BIGINT USING ROUND(min_amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN max_amount TYPE BIGINT USING ROUND(max_amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN new_account_max_amount TYPE BIGINT USING ROUND(new_account_max_amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN min_balance_required TYPE BIGINT USING ROUND(min_balance_required * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN require_2fa_for_amount TYPE BIGINT USING ROUND(require_2fa_for_amount * 10 ^ public.currency_exponent(currency_code))::BIGINT,
+    ALTER COLUMN min_amount DROP DEFAULT,
+    ALTER COLUMN max_amount DROP DEFAULT,
+    ALTER COLUMN new_account_max_amount DROP DEFAULT,
+    ALTER COLUMN min_balance_required DROP DEFAULT,
+    ALTER COLUMN require_2fa_for_amount DROP DEFAULT,
+    -- A rules row is live until it is explicitly deleted.
+    ALTER COLUMN deleted_at DROP DEFAULT;
+
+UPDATE public.account_rules SET deleted_at = NULL;
+
+-- One set of rules per account, so rules can be upserted by account.
+CREATE UNIQUE INDEX account_rules_account_idx ON public.account_rules (financial_account_id);
+
+-- Transaction limits count the account's debits over a rolling window.
+CREATE INDEX account_transaction_account_created_idx ON public.account_transaction (financial_account_id, created_at);
@@ -0,0 +1,17 @@
+-- Every transfer is scored by the risk checks before it is posted. The decision is the
+-- status its legs got (1 completed, 4 on hold, 6 pending review) and reasons lists the
+-- checks that scored it.
+CREATE TABLE public.risk_assessment (
+    risk_assessment_id SERIAL PRIMARY KEY,
+    transaction_group_id INT NOT NULL REFERENCES public.journal_entry,
+    financial_account_id INT NOT NULL REFERENCES public.financial_account, -- The sender's account
+    score INT NOT NULL,
+    decision SMALLINT NOT NULL,
+    reasons JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX risk_assessment_group_idx ON public.risk_assessment (transaction_group_id);
+CREATE INDEX risk_assessment_decision_idx ON public.risk_assessment (decision, created_at);
+
+CREATE INDEX account_transaction_group_idx ON public.account_transaction (transaction_group_id);
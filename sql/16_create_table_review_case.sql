@@ -0,0 +1,30 @@
+-- A review case is opened for every transfer the risk checks hold. Its funds wait in
+-- a suspense account until a reviewer approves (releases them to the receiver) or
+-- rejects (reverses the debit) the case.
+CREATE TABLE public.review_case (
+    review_case_id SERIAL PRIMARY KEY,
+    transaction_group_id INT NOT NULL REFERENCES public.journal_entry,
+    risk_assessment_id INT NOT NULL REFERENCES public.risk_assessment,
+    status SMALLINT NOT NULL, -- 0 open, 1 approved, 2 rejected
+    assignee_id INT REFERENCES public.user,
+    resolution_entry_id INT REFERENCES public.journal_entry, -- The release or reversal entry
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX review_case_group_idx ON public.review_case (transaction_group_id);
+CREATE INDEX review_case_queue_idx ON public.review_case (status, assignee_id, created_at);
+
+-- The audit trail of a case. Rows are only ever appended.
+CREATE TABLE public.review_event (
+    review_event_id SERIAL PRIMARY KEY,
+    review_case_id INT NOT NULL REFERENCES public.review_case,
+    actor_id INT REFERENCES public.user, -- NULL when the system acted
+    action VARCHAR(20) NOT NULL,
+    assignee_id INT REFERENCES public.user,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX review_event_case_idx ON public.review_event (review_case_id, review_event_id);
@@ -0,0 +1,22 @@
+-- Transfers are undone by posting a compensating journal entry that points back at the
+-- entry it reverses. A partial reversal (a refund) posts a smaller entry; several of them
+-- may reverse one entry until its full amount is returned.
+ALTER TABLE public.journal_entry ADD COLUMN reverses_entry_id INT REFERENCES public.journal_entry;
+
+CREATE INDEX journal_entry_reverses_idx ON public.journal_entry (reverses_entry_id)
+    WHERE reverses_entry_id IS NOT NULL;
+
+-- The account transactions of a reversal entry point at the legs they compensate.
+ALTER TABLE public.account_transaction
+    ADD COLUMN reverses_transaction_id INT REFERENCES public.account_transaction;
+
+-- Account transactions mirror ledger postings, so they are never deleted either.
+CREATE FUNCTION public.account_transaction_reject_delete() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'account_transaction rows cannot be deleted, reverse their transaction group instead';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER account_transaction_no_delete
+    BEFORE DELETE ON public.account_transaction
+    FOR EACH ROW EXECUTE FUNCTION public.account_transaction_reject_delete();
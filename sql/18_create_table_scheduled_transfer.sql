@@ -0,0 +1,42 @@
+CREATE TABLE public.scheduled_transfer (
+    scheduled_transfer_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES public.user,
+    sender_account_id INT NOT NULL REFERENCES public.financial_account,
+    receiver_account_id INT NOT NULL REFERENCES public.financial_account,
+    currency_code CHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    description TEXT NOT NULL DEFAULT '',
+    frequency VARCHAR(16) NOT NULL, -- once, daily, weekly, monthly or cron
+    cron_expression VARCHAR(100),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ,
+    next_run_at TIMESTAMPTZ, -- The pending occurrence, NULL once none is left
+    retry_at TIMESTAMPTZ, -- Next attempt at a failed occurrence
+    attempts INT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0, -- 0 active, 1 paused, 2 completed, 3 cancelled, 4 failed
+    last_run_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ, -- Lease of the executor working on the order
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX scheduled_transfer_due_idx ON public.scheduled_transfer (COALESCE(retry_at, next_run_at))
+    WHERE status = 0;
+
+CREATE INDEX scheduled_transfer_user_idx ON public.scheduled_transfer (user_id, scheduled_transfer_id);
+
+-- One row per attempt at an occurrence, whatever its outcome.
+CREATE TABLE public.scheduled_transfer_run (
+    scheduled_transfer_run_id SERIAL PRIMARY KEY,
+    scheduled_transfer_id INT NOT NULL REFERENCES public.scheduled_transfer,
+    occurrence_at TIMESTAMPTZ NOT NULL,
+    attempt INT NOT NULL,
+    status SMALLINT NOT NULL, -- 0 succeeded, 1 failed
+    transaction_group_id INT REFERENCES public.journal_entry,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX scheduled_transfer_run_order_idx ON public.scheduled_transfer_run (scheduled_transfer_id, scheduled_transfer_run_id);
@@ -0,0 +1,17 @@
+-- Card transactions are posted to the ledger of the card's linked account. Each row is the
+-- card's view of one journal entry; refunds point back at the purchase they return.
+ALTER TABLE public.card_transaction
+    ADD COLUMN transaction_type SMALLINT NOT NULL DEFAULT 0,
+    ADD COLUMN merchant TEXT,
+    ADD COLUMN refunds_transaction_id INT REFERENCES public.card_transaction (transaction_id);
+
+ALTER TABLE public.card_transaction ALTER COLUMN deleted_at DROP DEFAULT;
+
+ALTER TABLE public.card_transaction
+    ADD CONSTRAINT card_transaction_journal_entry_fk
+    FOREIGN KEY (transaction_group_id) REFERENCES public.journal_entry (journal_entry_id);
+
+CREATE INDEX card_transaction_card_idx ON public.card_transaction (financial_card_id, transaction_id);
+CREATE INDEX card_transaction_group_idx ON public.card_transaction (transaction_group_id);
+CREATE INDEX card_transaction_refunds_idx ON public.card_transaction (refunds_transaction_id)
+    WHERE refunds_transaction_id IS NOT NULL;
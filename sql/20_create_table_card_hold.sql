@@ -0,0 +1,28 @@
+-- Authorization holds reserve funds on the card's linked account without posting to the
+-- ledger. The available balance is the ledger balance less the open holds.
+CREATE TABLE public.card_hold (
+    hold_id SERIAL PRIMARY KEY,
+    financial_card_id INT NOT NULL REFERENCES public.financial_cards,
+    financial_account_id INT NOT NULL REFERENCES public.financial_account,
+    currency_code CHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    merchant TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status SMALLINT NOT NULL DEFAULT 0, -- 0 active, 1 captured, 2 released, 3 expired
+    capture_transaction_id INT REFERENCES public.card_transaction (transaction_id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX card_hold_account_idx ON public.card_hold (financial_account_id, currency_code)
+    WHERE status = 0;
+
+CREATE INDEX card_hold_expiry_idx ON public.card_hold (expires_at)
+    WHERE status = 0;
+
+CREATE INDEX card_hold_card_idx ON public.card_hold (financial_card_id, hold_id);
+
+ALTER TABLE public.card_transaction
+    ADD COLUMN hold_id INT REFERENCES public.card_hold (hold_id);
@@ -0,0 +1,24 @@
+-- Card numbers live only in the vault, sealed with envelope encryption. Cards refer to
+-- them by token and keep the last four digits for display.
+CREATE TABLE public.card_vault (
+    token VARCHAR(40) PRIMARY KEY,
+    key_id VARCHAR(32) NOT NULL, -- The vault key that wrapped the data key
+    wrapped_key BYTEA NOT NULL,
+    ciphertext BYTEA NOT NULL,
+    fingerprint BYTEA NOT NULL UNIQUE, -- HMAC of the card number
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE public.financial_cards
+    ADD COLUMN card_token VARCHAR(40) UNIQUE REFERENCES public.card_vault (token),
+    ADD COLUMN last_four CHAR(4);
+
+UPDATE public.financial_cards SET last_four = RIGHT(card_number, 4);
+
+ALTER TABLE public.financial_cards
+    ALTER COLUMN last_four SET NOT NULL,
+    ALTER COLUMN card_number DROP NOT NULL,
+    DROP COLUMN cvv; -- Checked at registration, never kept
+
+-- The numbers already stored are moved into the vault by the vault-cards command, which
+-- clears card_number as it goes. The column is dropped once that has run everywhere.
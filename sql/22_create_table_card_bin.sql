@@ -0,0 +1,68 @@
+-- BIN ranges map the first six digits of a card number to its network and, for ranges
+-- owned by one issuer, the issuing bank. bank_code matches bank.bank_code, which for
+-- Iranian banks is the three-digit identifier also found in the Sheba number.
+CREATE TABLE public.card_bin (
+    card_bin_id SERIAL PRIMARY KEY,
+    range_start INT NOT NULL,
+    range_end INT NOT NULL,
+    network SMALLINT NOT NULL, -- 1 Shetab, 2 Visa, 3 Mastercard
+    bank_code VARCHAR(10),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (range_start BETWEEN 100000 AND 999999 AND range_end BETWEEN range_start AND 999999)
+);
+
+CREATE INDEX card_bin_range_idx ON public.card_bin (range_start, range_end);
+
+INSERT INTO public.card_bin (range_start, range_end, network, bank_code) VALUES
+    (603799, 603799, 1, '017'), -- Melli
+    (589210, 589210, 1, '015'), -- Sepah
+    (627648, 627648, 1, '020'), -- Tosee Saderat
+    (207177, 207177, 1, '020'), -- Tosee Saderat
+    (627961, 627961, 1, '011'), -- Sanat va Madan
+    (603770, 603770, 1, '016'), -- Keshavarzi
+    (639217, 639217, 1, '016'), -- Keshavarzi
+    (628023, 628023, 1, '014'), -- Maskan
+    (627760, 627760, 1, '021'), -- Post Bank
+    (502908, 502908, 1, '022'), -- Tosee Taavon
+    (627412, 627412, 1, '055'), -- Eghtesad Novin
+    (622106, 622106, 1, '054'), -- Parsian
+    (639194, 639194, 1, '054'), -- Parsian
+    (627884, 627884, 1, '054'), -- Parsian
+    (502229, 502229, 1, '057'), -- Pasargad
+    (639347, 639347, 1, '057'), -- Pasargad
+    (627488, 627488, 1, '053'), -- Karafarin
+    (502910, 502910, 1, '053'), -- Karafarin
+    (621986, 621986, 1, '056'), -- Saman
+    (639346, 639346, 1, '059'), -- Sina
+    (639607, 639607, 1, '058'), -- Sarmayeh
+    (636214, 636214, 1, '062'), -- Ayandeh
+    (502806, 502806, 1, '061'), -- Shahr
+    (504706, 504706, 1, '061'), -- Shahr
+    (502938, 502938, 1, '066'), -- Dey
+    (603769, 603769, 1, '019'), -- Saderat
+    (610433, 610433, 1, '012'), -- Mellat
+    (991975, 991975, 1, '012'), -- Mellat
+    (627353, 627353, 1, '018'), -- Tejarat
+    (585983, 585983, 1, '018'), -- Tejarat
+    (589463, 589463, 1, '013'), -- Refah
+    (627381, 627381, 1, '063'), -- Ansar
+    (505785, 505785, 1, '069'), -- Iran Zamin
+    (636949, 636949, 1, '065'), -- Hekmat Iranian
+    (505416, 505416, 1, '064'), -- Gardeshgari
+    (606373, 606373, 1, '060'), -- Mehr Iran
+    (628157, 628157, 1, '051'), -- Tosee
+    (505801, 505801, 1, '073'), -- Kosar
+    (639599, 639599, 1, '052'), -- Ghavamin
+    (504172, 504172, 1, '070'), -- Resalat
+    (585947, 585947, 1, '078'), -- Khavarmianeh
+    (636795, 636795, 1, '010'), -- Markazi
+    (400000, 499999, 2, NULL),
+    (510000, 559999, 3, NULL),
+    (222100, 272099, 3, NULL);
+
+-- Cards registered before this migration keep network 0 (unknown) and no issuing bank
+ALTER TABLE public.financial_cards
+    ADD COLUMN network SMALLINT NOT NULL DEFAULT 0,
+    ADD COLUMN bank_id INT REFERENCES public.bank;
+
+ALTER TABLE public.financial_cards ALTER COLUMN network DROP DEFAULT;
@@ -0,0 +1,22 @@
+-- Card status follows a state machine: owners freeze and unfreeze cards, report them lost
+-- or stolen for good, and have them reissued. A reissued card points at the one it replaces.
+ALTER TABLE public.financial_cards
+    ALTER COLUMN status DROP DEFAULT,
+    ALTER COLUMN status TYPE SMALLINT USING (CASE status
+        WHEN 'active' THEN 0 WHEN 'inactive' THEN 1 WHEN 'lost' THEN 2 WHEN 'stolen' THEN 3 END),
+    ALTER COLUMN status SET NOT NULL, -- 0 active, 1 inactive, 2 lost, 3 stolen, 4 frozen, 5 replaced
+    ADD COLUMN replaces_card_id INT UNIQUE REFERENCES public.financial_cards (card_id);
+
+-- Every status change of a card. Rows are only ever appended.
+CREATE TABLE public.card_event (
+    card_event_id SERIAL PRIMARY KEY,
+    financial_card_id INT NOT NULL REFERENCES public.financial_cards,
+    actor_id INT REFERENCES public.user, -- NULL when the system acted
+    action VARCHAR(20) NOT NULL,
+    from_status SMALLINT NOT NULL,
+    to_status SMALLINT NOT NULL,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX card_event_card_idx ON public.card_event (financial_card_id, card_event_id);
@@ -0,0 +1,17 @@
+-- Owner-set controls on a card, checked on every purchase and authorization on top of the
+-- account rules. Limits are minor units in the currency of the card's account; 0 is no limit.
+CREATE TABLE public.card_controls (
+    financial_card_id INT PRIMARY KEY REFERENCES public.financial_cards,
+    online_disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    international_disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    blocked_mccs TEXT[] NOT NULL DEFAULT '{}',
+    currency_code CHAR(3) NOT NULL,
+    per_transaction_limit BIGINT NOT NULL DEFAULT 0 CHECK (per_transaction_limit >= 0),
+    daily_limit BIGINT NOT NULL DEFAULT 0 CHECK (daily_limit >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The daily limit sums the card's recent purchases
+CREATE INDEX card_transaction_card_spend_idx ON public.card_transaction (financial_card_id, created_at)
+    WHERE transaction_type = 0;
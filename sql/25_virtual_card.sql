@@ -0,0 +1,9 @@
+-- The wallet issues virtual cards itself. A virtual card can be restricted to one payment or
+-- to one merchant, and capped over its life in the currency of its account.
+ALTER TABLE public.financial_cards
+    ADD COLUMN virtual BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN usage SMALLINT NOT NULL DEFAULT 0, -- 0 multi-use, 1 single-use, 2 merchant-locked
+    ADD COLUMN locked_merchant VARCHAR(255),
+    ADD COLUMN spend_cap BIGINT NOT NULL DEFAULT 0, -- Minor units; 0 is no cap
+    ADD COLUMN spend_cap_currency VARCHAR(3),
+    ADD CONSTRAINT financial_cards_locked_merchant_check CHECK ((usage = 2) = (locked_merchant IS NOT NULL));
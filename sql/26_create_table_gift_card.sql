@@ -0,0 +1,45 @@
+-- Gift cards are loaded once and drawn down by redemption into a wallet account or by
+-- payments to merchants. Their balances are owed to the holders, so the ledger carries them
+-- on a liability account per currency until they are used or expire as breakage.
+CREATE TABLE public.gift_card (
+    gift_card_id SERIAL PRIMARY KEY,
+    purchaser_id INT NOT NULL REFERENCES public.user,
+    code_hash BYTEA NOT NULL UNIQUE, -- SHA-256 of the normalised activation code
+    code_last_four VARCHAR(4) NOT NULL,
+    currency_code VARCHAR(3) NOT NULL,
+    initial_amount BIGINT NOT NULL CHECK (initial_amount > 0),
+    balance BIGINT NOT NULL CHECK (balance >= 0 AND balance <= initial_amount),
+    status SMALLINT NOT NULL DEFAULT 0, -- 0 active, 1 depleted, 2 expired
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX gift_card_purchaser_idx ON public.gift_card (purchaser_id);
+CREATE INDEX gift_card_expiry_idx ON public.gift_card (expires_at) WHERE status = 0;
+
+CREATE TABLE public.gift_card_transaction (
+    gift_card_transaction_id SERIAL PRIMARY KEY,
+    gift_card_id INT NOT NULL REFERENCES public.gift_card,
+    transaction_type SMALLINT NOT NULL, -- 0 load, 1 redeem, 2 spend, 3 breakage
+    currency_code VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    user_id INT REFERENCES public.user,
+    financial_account_id INT REFERENCES public.financial_account,
+    merchant VARCHAR(255),
+    transaction_group_id INT NOT NULL REFERENCES public.journal_entry (journal_entry_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX gift_card_transaction_card_idx ON public.gift_card_transaction (gift_card_id, gift_card_transaction_id);
+CREATE UNIQUE INDEX gift_card_transaction_redeem_idx ON public.gift_card_transaction (gift_card_id) WHERE transaction_type = 1;
+
+-- Wrong activation codes, counted per user to lock out guessing
+CREATE TABLE public.gift_card_failed_attempt (
+    gift_card_failed_attempt_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES public.user,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX gift_card_failed_attempt_user_idx ON public.gift_card_failed_attempt (user_id, created_at);
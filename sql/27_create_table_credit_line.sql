@@ -0,0 +1,56 @@
+-- A credit line lets a credit card's linked account go below zero, down to the limit. What
+-- the account owes is paid back from a linked checking account; statements close monthly
+-- over the card's transactions and fix a due date and a minimum payment.
+CREATE TABLE public.credit_line (
+    credit_line_id SERIAL PRIMARY KEY,
+    financial_card_id INT NOT NULL UNIQUE REFERENCES public.financial_cards,
+    account_id INT NOT NULL REFERENCES public.financial_account,
+    payment_account_id INT NOT NULL REFERENCES public.financial_account,
+    currency_code VARCHAR(3) NOT NULL,
+    credit_limit BIGINT NOT NULL CHECK (credit_limit >= 0),
+    apr INT NOT NULL CHECK (apr >= 0), -- Basis points
+    statement_day SMALLINT NOT NULL CHECK (statement_day BETWEEN 1 AND 28),
+    fees_due BIGINT NOT NULL DEFAULT 0 CHECK (fees_due >= 0),
+    interest_due BIGINT NOT NULL DEFAULT 0 CHECK (interest_due >= 0),
+    accrued_interest BIGINT NOT NULL DEFAULT 0 CHECK (accrued_interest >= 0),
+    interest_accrued_on DATE NOT NULL,
+    revolving BOOLEAN NOT NULL DEFAULT FALSE,
+    next_statement_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (account_id <> payment_account_id)
+);
+
+CREATE INDEX credit_line_next_statement_idx ON public.credit_line (next_statement_at);
+CREATE INDEX credit_line_interest_accrued_idx ON public.credit_line (interest_accrued_on);
+
+CREATE TABLE public.credit_statement (
+    credit_statement_id SERIAL PRIMARY KEY,
+    credit_line_id INT NOT NULL REFERENCES public.credit_line,
+    currency_code VARCHAR(3) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    opening_balance BIGINT NOT NULL,
+    closing_balance BIGINT NOT NULL,
+    purchases BIGINT NOT NULL DEFAULT 0,
+    credits BIGINT NOT NULL DEFAULT 0,
+    payments BIGINT NOT NULL DEFAULT 0,
+    interest_charged BIGINT NOT NULL DEFAULT 0,
+    fees_charged BIGINT NOT NULL DEFAULT 0,
+    minimum_payment BIGINT NOT NULL DEFAULT 0,
+    due_date TIMESTAMPTZ NOT NULL,
+    paid BIGINT NOT NULL DEFAULT 0,
+    status SMALLINT NOT NULL DEFAULT 0, -- 0 open, 1 paid, 2 revolved, 3 late
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX credit_statement_line_idx ON public.credit_statement (credit_line_id, credit_statement_id);
+CREATE INDEX credit_statement_due_idx ON public.credit_statement (due_date) WHERE status = 0;
+
+-- Each credit card transaction belongs to the first statement that closes after it
+ALTER TABLE public.card_transaction
+    ADD COLUMN credit_statement_id INT REFERENCES public.credit_statement;
+
+CREATE INDEX card_transaction_statement_idx ON public.card_transaction (credit_statement_id)
+    WHERE credit_statement_id IS NOT NULL;
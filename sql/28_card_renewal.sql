@@ -0,0 +1,9 @@
+-- Cards are renewed ahead of their expiry and expire on their own once past it. A renewal
+-- draws on the same account as the card it replaces, and with it on the same credit line,
+-- so an account carries at most one line.
+ALTER TABLE public.credit_line
+    ADD CONSTRAINT credit_line_account_id_key UNIQUE (account_id);
+
+-- Expired cards have status 6
+CREATE INDEX financial_cards_expiration_idx ON public.financial_cards (expiration_date)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,58 @@
+-- Roles group the permissions routes require. A user holds any number of roles, and the
+-- permissions of all of them are carried in the user's tokens.
+CREATE TABLE public.role (
+    name VARCHAR(30) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE public.role_permission (
+    role VARCHAR(30) NOT NULL REFERENCES public.role ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE public.user_role (
+    user_id INT NOT NULL REFERENCES public.user,
+    role VARCHAR(30) NOT NULL REFERENCES public.role,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO public.role (name, description) VALUES
+    ('customer', 'Wallet holders using their own accounts and cards'),
+    ('support', 'Looks up customer accounts and cards'),
+    ('compliance', 'Verifies accounts, sets their rules and reviews held transfers'),
+    ('treasury', 'Maintains banks and currencies, posts and settles transactions and sets credit lines'),
+    ('superadmin', 'Every permission, including granting roles');
+
+INSERT INTO public.role_permission (role, permission)
+SELECT 'customer', permission FROM unnest(ARRAY[
+    'profile', 'banks.read', 'currencies.read', 'accounts.use', 'cards.use', 'transfers.make'
+]) AS permission
+UNION ALL
+SELECT 'support', permission FROM unnest(ARRAY[
+    'profile', 'banks.read', 'currencies.read', 'accounts.read_all'
+]) AS permission
+UNION ALL
+SELECT 'compliance', permission FROM unnest(ARRAY[
+    'profile', 'banks.read', 'currencies.read', 'accounts.read_all', 'accounts.verify',
+    'accounts.rules', 'reviews.manage', 'transactions.reverse'
+]) AS permission
+UNION ALL
+SELECT 'treasury', permission FROM unnest(ARRAY[
+    'profile', 'banks.read', 'currencies.read', 'banks.manage', 'currencies.manage',
+    'transactions.post', 'transactions.reverse', 'cards.settle', 'credit.manage'
+]) AS permission
+UNION ALL
+SELECT 'superadmin', permission FROM unnest(ARRAY[
+    'profile', 'banks.read', 'currencies.read', 'accounts.use', 'cards.use', 'transfers.make',
+    'accounts.read_all', 'accounts.verify', 'accounts.rules', 'reviews.manage',
+    'transactions.post', 'transactions.reverse', 'banks.manage', 'currencies.manage',
+    'cards.settle', 'credit.manage', 'roles.manage'
+]) AS permission;
+
+-- Existing users keep what they could do: everyone is a customer and admins become superadmins
+INSERT INTO public.user_role (user_id, role)
+SELECT id, 'customer' FROM public.user
+UNION ALL
+SELECT id, 'superadmin' FROM public.user WHERE is_admin;
@@ -0,0 +1,36 @@
+-- A session is one sign-in. Its refresh tokens are opaque, stored only as their SHA-256,
+-- and each is used once: a refresh spends it and issues the next one of the family.
+CREATE TABLE public.user_session (
+    session_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES public.user,
+    device VARCHAR(100) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    access_jti VARCHAR(36) NOT NULL,
+    access_expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    revoke_reason VARCHAR(20)
+);
+
+CREATE INDEX user_session_active_idx ON public.user_session (user_id) WHERE revoked_at IS NULL;
+
+CREATE TABLE public.refresh_token (
+    token_hash BYTEA PRIMARY KEY,
+    session_id INT NOT NULL REFERENCES public.user_session ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX refresh_token_session_idx ON public.refresh_token (session_id);
+
+-- Access tokens are checked against this list on every request. A row is only needed until
+-- the token would have expired anyway and can be deleted after that.
+CREATE TABLE public.revoked_token (
+    jti VARCHAR(36) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revoked_token_expires_at_idx ON public.revoked_token (expires_at);
@@ -0,0 +1,26 @@
+-- TOTP authenticators. The secret itself is sealed in the card vault and only its token is
+-- kept here.
+CREATE TABLE public.two_factor (
+    user_id INT PRIMARY KEY REFERENCES public.user,
+    secret_token VARCHAR(40) NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Single-use codes for a lost authenticator, stored as their SHA-256
+CREATE TABLE public.two_factor_recovery_code (
+    user_id INT NOT NULL REFERENCES public.two_factor ON DELETE CASCADE,
+    code_hash BYTEA NOT NULL,
+    used_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE public.two_factor_failed_attempt (
+    two_factor_failed_attempt_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES public.user,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX two_factor_failed_attempt_user_idx ON public.two_factor_failed_attempt (user_id, created_at);
@@ -0,0 +1,15 @@
+-- One-time codes sent to verify a user's email address or cellphone. Only the newest code of
+-- a channel can be entered; the older rows are kept to rate limit sending.
+CREATE TABLE public.verification_code (
+    verification_code_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES public.user,
+    channel VARCHAR(16) NOT NULL CHECK (channel IN ('email', 'cellphone')),
+    destination VARCHAR(255) NOT NULL,
+    code_hash BYTEA NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX verification_code_user_channel_idx ON public.verification_code (user_id, channel, created_at);
@@ -0,0 +1,5 @@
+-- An in-flight claim holds its key only for a short lease, so that a claim left behind by a
+-- crash can be taken over by a retry of the same request instead of blocking the key until
+-- it expires. Completing or releasing the claim checks the lease is still the one it took.
+ALTER TABLE public.idempotency_key
+    ADD COLUMN locked_until TIMESTAMPTZ; -- NULL once completed
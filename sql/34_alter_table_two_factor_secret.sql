@@ -0,0 +1,8 @@
+-- The TOTP secret is sealed on the authenticator row itself instead of in the card vault,
+-- so a redone enrollment overwrites it rather than leaving the old vault entry behind.
+-- Secrets already in the vault are not carried over: those users enroll again.
+DELETE FROM public.two_factor;
+
+ALTER TABLE public.two_factor
+    DROP COLUMN secret_token,
+    ADD COLUMN sealed_secret BYTEA NOT NULL;